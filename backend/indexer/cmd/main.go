@@ -4,11 +4,15 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/etag"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/swagger"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -16,9 +20,12 @@ import (
 	"github.com/video-analitics/backend/pkg/logger"
 	"github.com/video-analitics/backend/pkg/meili"
 	"github.com/video-analitics/backend/pkg/nats"
+	"github.com/video-analitics/backend/pkg/snapshot"
 	"github.com/video-analitics/backend/pkg/violations"
 	"github.com/video-analitics/indexer/internal/config"
 	"github.com/video-analitics/indexer/internal/handler"
+	"github.com/video-analitics/indexer/internal/metadata"
+	"github.com/video-analitics/indexer/internal/metrics"
 	"github.com/video-analitics/indexer/internal/middleware"
 	indexerQueue "github.com/video-analitics/indexer/internal/queue"
 	"github.com/video-analitics/indexer/internal/repo"
@@ -35,7 +42,7 @@ func main() {
 	log := logger.Log
 
 	connectCtx, connectCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	mongoClient, err := mongo.Connect(connectCtx, options.Client().ApplyURI(cfg.MongoURL))
+	mongoClient, err := mongo.Connect(connectCtx, options.Client().ApplyURI(cfg.MongoURL).SetMonitor(metrics.MongoMonitor()))
 	connectCancel()
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to connect to MongoDB")
@@ -52,14 +59,24 @@ func main() {
 	defer natsClient.Close()
 
 	// Meilisearch клиент (обязателен)
-	meiliClient, err := meili.New(cfg.MeiliURL, cfg.MeiliKey)
+	meiliClient, err := meili.New(cfg.MeiliURL, cfg.MeiliKey, cfg.MeiliIndex)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to connect to Meilisearch")
 	}
 	log.Info().Str("url", cfg.MeiliURL).Msg("meilisearch connected")
 
 	// Violations service (централизованное управление нарушениями)
-	violationsSvc := violations.NewService(db, meiliClient)
+	matchStages, err := violations.ParseStageConfig(cfg.MatchStagesDisabled)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid MATCH_STAGES_DISABLED")
+	}
+	matchStages.RequireStrongSignal = cfg.MatchRequireStrongSignal
+	violationsSvc := violations.NewService(db, meiliClient, matchStages)
+	violationsSvc.SetMaxSearchHits(cfg.MeiliMaxHits)
+	violationsSvc.SetSearchTimeout(cfg.MeiliSearchTimeout)
+	violationsSvc.SetYearTolerance(cfg.MatchYearTolerance)
+	violationsSvc.SetSingleWordAllowlist(strings.Split(cfg.MatchSingleWordTitles, ","))
+	violationsSvc.SetMinFuzzyHits(cfg.MatchMinFuzzyHits)
 
 	// Repos - чистые, без зависимости от violations
 	siteRepo := repo.NewSiteRepo(db)
@@ -68,9 +85,15 @@ func main() {
 	contentRepo := repo.NewContentRepo(db)
 	userContentRepo := repo.NewUserContentRepo(db)
 	sitemapURLRepo := repo.NewSitemapURLRepo(db)
+	sitemapURLRepo.SetRetryPolicy(repo.SitemapRetryPolicy{
+		MaxAttempts: cfg.SitemapRetryMaxAttempts,
+		BaseDelay:   cfg.SitemapRetryBaseDelay,
+		MaxDelay:    cfg.SitemapRetryMaxDelay,
+	})
 	userRepo := repo.NewUserRepo(db)
 	refreshTokenRepo := repo.NewRefreshTokenRepo(db)
 	userSiteRepo := repo.NewUserSiteRepo(db)
+	activityRepo := repo.NewActivityRepo(db)
 
 	// Seed admin user if configured
 	if cfg.AdminPassword != "" {
@@ -81,6 +104,20 @@ func main() {
 		}
 	}
 
+	// Старые причины заморозки свободным текстом → status.FreezeReason
+	if migrated, err := siteRepo.MigrateFreezeReasons(context.Background()); err != nil {
+		log.Error().Err(err).Msg("failed to migrate freeze reasons")
+	} else if migrated > 0 {
+		log.Info().Int64("sites", migrated).Msg("freeze reasons migrated")
+	}
+
+	// Ключи поиска по названию для контента, созданного до их появления
+	if migrated, err := contentRepo.MigrateTitleSearch(context.Background()); err != nil {
+		log.Error().Err(err).Msg("failed to migrate content title search")
+	} else if migrated > 0 {
+		log.Info().Int64("content", migrated).Msg("content title search migrated")
+	}
+
 	// Подключаем contentRepo к violations service для обновления кэша счётчиков
 	violationsSvc.SetContentUpdater(contentRepo)
 	publisher := indexerQueue.NewPublisher(natsClient)
@@ -88,15 +125,45 @@ func main() {
 	// TaskProgressService - единая точка управления прогрессом задач
 	progressSvc := service.NewTaskProgressService(taskRepo, sitemapURLRepo)
 
+	// Корзина удалённых сайтов и контента
+	trashSvc := service.NewTrashService(siteRepo, pageRepo, sitemapURLRepo, taskRepo, userSiteRepo, contentRepo, userContentRepo, violationsSvc, meiliClient, cfg.TrashRetention)
+
+	// Снимки HTML страниц; HTML_SNAPSHOTS включает только запись новых, чтение и очистка работают всегда
+	snapshotStore, err := snapshot.NewGridFSStore(db)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create snapshot store")
+	}
+
 	// Handlers - получают violationsSvc для работы с нарушениями
-	siteHandler := handler.NewSiteHandler(siteRepo, pageRepo, taskRepo, sitemapURLRepo, userSiteRepo, publisher, violationsSvc, meiliClient)
+	siteHandler := handler.NewSiteHandler(siteRepo, pageRepo, taskRepo, sitemapURLRepo, userSiteRepo, userContentRepo, publisher, violationsSvc, meiliClient, trashSvc)
 	scanHandler := handler.NewScanHandler(siteRepo, taskRepo, sitemapURLRepo, userSiteRepo, publisher)
-	pageHandler := handler.NewPageHandler(pageRepo, violationsSvc)
+	pageHandler := handler.NewPageHandler(pageRepo, userContentRepo, violationsSvc)
+	pageHandler.SetSnapshots(snapshotStore)
 	taskHandler := handler.NewTaskHandler(taskRepo, db)
-	contentHandler := handler.NewContentHandler(contentRepo, userContentRepo, siteRepo, violationsSvc)
-	sitemapURLHandler := handler.NewSitemapURLHandler(sitemapURLRepo)
+	contentHandler := handler.NewContentHandler(contentRepo, userContentRepo, siteRepo, userSiteRepo, pageRepo, violationsSvc, trashSvc)
+	if cfg.KinopoiskAPIKey != "" {
+		contentHandler.SetMetadataProvider(metadata.NewKinopoiskProvider(cfg.KinopoiskAPIURL, cfg.KinopoiskAPIKey))
+		log.Info().Msg("content metadata enrichment enabled")
+	}
+	sitemapURLHandler := handler.NewSitemapURLHandler(sitemapURLRepo, siteRepo, userSiteRepo, pageRepo)
 	authHandler := handler.NewAuthHandler(userRepo, refreshTokenRepo, cfg.JWTSecret, cfg.JWTAccessExpiry, cfg.JWTRefreshExpiry)
 	userHandler := handler.NewUserHandler(userRepo)
+	parserHandler := handler.NewParserHandler(publisher)
+	detectHandler := handler.NewDetectHandler(natsClient, cfg.DetectTimeout)
+	pageParser := handler.NewNATSPageParser(natsClient, cfg.ParseTimeout)
+	parseHandler := handler.NewParseHandler(pageParser)
+	contentHandler.SetManualViolations(service.NewManualViolationService(siteRepo, userSiteRepo, pageRepo, violationsSvc, meiliClient, pageParser))
+	statsHandler := handler.NewStatsHandler(violationsSvc, userContentRepo, siteRepo, userSiteRepo)
+	siteContentCheckHandler := handler.NewSiteContentCheckHandler(siteRepo, userSiteRepo, contentRepo, userContentRepo, violationsSvc)
+	activityHandler := handler.NewActivityHandler(activityRepo, siteRepo, userSiteRepo, userContentRepo)
+
+	// Отставание консьюмеров NATS: /metrics, /api/admin/queues и вебхук при превышении порога
+	queueMonitor := service.NewQueueMonitor(natsClient, nats.WorkStreams, service.QueueMonitorConfig{
+		Interval:       cfg.QueueMonitorInterval,
+		AlertThreshold: cfg.QueueLagAlertThreshold,
+		WebhookURL:     cfg.QueueLagWebhookURL,
+	})
+	queueHandler := handler.NewQueueHandler(queueMonitor)
 
 	app := fiber.New(fiber.Config{
 		DisableStartupMessage: true,
@@ -107,6 +174,10 @@ func main() {
 	})
 
 	app.Use(cors.New())
+	app.Use(metrics.Middleware())
+	// Сжатие по Accept-Encoding. fasthttp сжимает только текстовые типы (json, csv, text/*)
+	// и пропускает ответы с уже выставленным Content-Encoding, бинарные выгрузки не сжимаются повторно
+	app.Use(compress.New(compress.Config{Level: compress.LevelBestSpeed}))
 
 	api := app.Group("/api")
 
@@ -123,6 +194,7 @@ func main() {
 	authGroup := api.Group("/auth", middleware.AuthMiddleware(cfg.JWTSecret))
 	authGroup.Post("/logout", authHandler.Logout)
 	authGroup.Get("/me", authHandler.Me)
+	authGroup.Post("/change-password", authHandler.ChangePassword)
 
 	// Admin-only user management routes
 	usersGroup := api.Group("/users", middleware.AuthMiddleware(cfg.JWTSecret), middleware.AdminOnly())
@@ -131,57 +203,129 @@ func main() {
 	usersGroup.Put("/:id", userHandler.Update)
 	usersGroup.Delete("/:id", userHandler.Delete)
 
+	// Admin-only runtime control
+	adminGroup := api.Group("/admin", middleware.AuthMiddleware(cfg.JWTSecret), middleware.AdminOnly())
+	adminGroup.Post("/parser/concurrency", parserHandler.SetConcurrency)
+	adminGroup.Get("/queues", queueHandler.List)
+	adminGroup.Get("/stats/match-types", statsHandler.MatchTypes)
+
 	// Protected API routes (require authentication)
 	protected := api.Group("", middleware.AuthMiddleware(cfg.JWTSecret))
-	protected.Post("/sites", siteHandler.Create)
-	protected.Post("/sites/batch", siteHandler.CreateBatch)
+	// Изменения, сканы и пересчёты закрыты для роли viewer; чтение и выгрузки доступны всем ролям
+	write := middleware.RequireWrite()
+	protected.Post("/sites", write, siteHandler.Create)
+	protected.Post("/sites/batch", write, siteHandler.CreateBatch)
+	protected.Post("/sites/import", write, siteHandler.Import)
+	protected.Get("/sites/import/:job_id", siteHandler.GetImportJob)
 	protected.Get("/sites", siteHandler.List)
-	protected.Get("/sites/:id", siteHandler.Get)
+	// ETag считается по телу ответа, поэтому меняется и при изменении счётчиков нарушений; If-None-Match → 304
+	detailETag := etag.New()
+	protected.Get("/sites/:id", detailETag, siteHandler.Get)
+	protected.Get("/sites/:id/related", siteHandler.GetRelated)
+	protected.Patch("/sites/:id", write, siteHandler.Update)
 	protected.Get("/sites/:id/violations", siteHandler.GetViolations)
-	protected.Post("/sites/:id/unfreeze", siteHandler.Unfreeze)
-	protected.Post("/sites/:id/analyze", siteHandler.Analyze)
-	protected.Post("/sites/:id/scan-sitemap", siteHandler.ScanSitemap)
-	protected.Post("/sites/:id/scan-pages", siteHandler.ScanPages)
+	protected.Get("/sites/:id/unmatched", siteHandler.GetUnmatched)
+	protected.Post("/sites/:id/unfreeze", write, siteHandler.Unfreeze)
+	protected.Put("/sites/:id/page-wait", write, siteHandler.UpdatePageWait)
+	protected.Put("/sites/:id/max-pages", write, siteHandler.UpdateMaxPagesPerScan)
+	protected.Put("/sites/:id/delta-crawl", write, siteHandler.UpdateDeltaCrawl)
+	protected.Put("/sites/:id/dns-override", write, siteHandler.UpdateDNSOverride)
+	protected.Put("/sites/:id/scan-window", write, siteHandler.UpdateScanWindow)
+	protected.Post("/sites/:id/analyze", write, siteHandler.Analyze)
+	protected.Post("/sites/:id/scan-sitemap", write, siteHandler.ScanSitemap)
+	protected.Post("/sites/:id/scan-pages", write, siteHandler.ScanPages)
+	protected.Post("/sites/:id/check-all-content", write, siteContentCheckHandler.CheckAllContent)
+	protected.Get("/sites/:id/check-all-content/:job_id", siteContentCheckHandler.GetCheckAllContentJob)
 	protected.Get("/sites/:id/sitemap-urls", sitemapURLHandler.List)
 	protected.Get("/sites/:id/sitemap-urls/stats", sitemapURLHandler.Stats)
+	protected.Get("/sites/:id/sitemap-urls/failed", sitemapURLHandler.ListFailed)
+	protected.Get("/sites/:id/sitemap-urls/export", sitemapURLHandler.ExportCSV)
+	protected.Get("/sites/:id/pages", sitemapURLHandler.ListPageStatuses)
 	protected.Get("/sites/:id/pending-urls", sitemapURLHandler.GetPending)
 	protected.Get("/sites/:id/all-urls", sitemapURLHandler.GetAllURLs)
-	protected.Delete("/sites/:id", siteHandler.Delete)
-	protected.Post("/sites/scan", scanHandler.StartScan)
-	protected.Post("/sites/delete", siteHandler.DeleteBulk)
+	protected.Delete("/sites/:id", write, siteHandler.Delete)
+	protected.Post("/sites/:id/restore", write, siteHandler.Restore)
+	protected.Post("/sites/scan", write, scanHandler.StartScan)
+	protected.Post("/sites/delete", write, siteHandler.DeleteBulk)
+	protected.Post("/sites/unfreeze-bulk", write, siteHandler.UnfreezeBulk)
+	protected.Post("/sites/scan-interval", write, siteHandler.UpdateScanIntervalBulk)
+	protected.Post("/detect", write, detectHandler.Detect)
+	// Каждый запрос занимает вкладку браузера в парсере, поэтому лимит на пользователя
+	parseLimiter := limiter.New(limiter.Config{
+		Max:        cfg.ParseRateLimit,
+		Expiration: time.Minute,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return middleware.GetUserID(c)
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(429).JSON(handler.ErrorResponse{Error: "rate limit exceeded"})
+		},
+	})
+	protected.Get("/parse", write, parseLimiter, parseHandler.Parse)
 	protected.Get("/pages/export", pageHandler.ExportCSV)
 	protected.Get("/pages", pageHandler.List)
 	protected.Get("/pages/stats", pageHandler.Stats)
+	protected.Get("/pages/:id/violations", pageHandler.GetViolations)
+	protected.Get("/pages/:id/snapshot", pageHandler.GetSnapshot)
+	protected.Get("/stats/top-sites", statsHandler.TopSites)
+	protected.Get("/stats/sites", statsHandler.SiteStatuses)
+	protected.Get("/activity", activityHandler.List)
 	protected.Get("/scan-tasks", taskHandler.List)
 	protected.Get("/scan-tasks/:id", taskHandler.Get)
-	protected.Post("/scan-tasks/cancel", taskHandler.Cancel)
-	protected.Post("/content", contentHandler.Create)
-	protected.Post("/content/batch", contentHandler.CreateBatch)
+	protected.Post("/scan-tasks/cancel", write, taskHandler.Cancel)
+	protected.Post("/content", write, contentHandler.Create)
+	protected.Post("/content/batch", write, contentHandler.CreateBatch)
 	protected.Get("/content/export", contentHandler.ExportCSV)
 	protected.Get("/content/violations/export-text", contentHandler.ExportAllViolationsText)
 	protected.Get("/content", contentHandler.List)
-	protected.Post("/content/check-violations", contentHandler.CheckViolations)
-	protected.Post("/content/delete", contentHandler.DeleteBulk)
-	protected.Get("/content/:id", contentHandler.Get)
+	protected.Post("/content/check-violations", write, contentHandler.CheckViolations)
+	protected.Post("/content/delete", write, contentHandler.DeleteBulk)
+	protected.Post("/content/counts", contentHandler.Counts)
+	protected.Get("/content/:id", detailETag, contentHandler.Get)
 	protected.Get("/content/:id/violations", contentHandler.GetViolations)
+	protected.Post("/content/:id/violations", write, parseLimiter, contentHandler.AddViolation)
+	protected.Post("/content/:id/explain", contentHandler.Explain)
+	protected.Get("/content/:id/matches/preview", contentHandler.PreviewMatches)
+	protected.Put("/content/:id/aliases", write, contentHandler.UpdateAliases)
+	protected.Put("/content/:id/language", write, contentHandler.UpdateLanguage)
+	protected.Put("/content/:id/priority", write, contentHandler.UpdatePriority)
+	protected.Get("/content/:id/violations/by-domain", contentHandler.GetViolationsByDomain)
+	protected.Get("/content/:id/timeline", contentHandler.GetTimeline)
+	protected.Post("/content/:id/violations/review", write, contentHandler.ReviewViolations)
+	protected.Post("/content/:id/violations/verify-all", write, parseLimiter, contentHandler.VerifyAllViolations)
+	protected.Get("/content/:id/violations/verify-all/:job_id", contentHandler.GetVerifyAllJob)
+	protected.Get("/content/:id/suppressions", contentHandler.ListSuppressions)
+	protected.Delete("/content/:id/suppressions/:suppression_id", write, contentHandler.DeleteSuppression)
 	protected.Get("/content/:id/violations/export", contentHandler.ExportViolationsCSV)
 	protected.Get("/content/:id/violations/export-text", contentHandler.ExportViolationsText)
-	protected.Delete("/content/:id", contentHandler.Delete)
+	protected.Delete("/content/:id", write, contentHandler.Delete)
+	protected.Post("/content/:id/restore", write, contentHandler.Restore)
+
+	// Живая перепроверка нарушения перед жалобой; снятое нарушение уходит в хронологию
+	protected.Post("/violations/:pageID/verify", write, parseLimiter, contentHandler.VerifyViolation)
 
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok"})
 	})
 
+	// Метрики отдаются только с внутренним токеном: Prometheus передаёт его как bearer_token
+	app.Get("/metrics", middleware.InternalAuth(cfg.InternalAPIToken), metrics.Handler)
+
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	go queueMonitor.Run(ctx)
+
 	// Start scheduler (с violationsSvc для периодического обновления нарушений)
 	sched, err := scheduler.New(siteRepo, taskRepo, sitemapURLRepo, contentRepo, publisher, violationsSvc)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to create scheduler")
 	}
+	sched.SetTrash(trashSvc)
+	sched.SetMeili(meiliClient)
+	sched.SetSnapshots(snapshotStore, pageRepo, cfg.SnapshotRetention)
 	if err := sched.Start(ctx); err != nil {
 		log.Fatal().Err(err).Msg("failed to start scheduler")
 	}
@@ -228,7 +372,7 @@ func main() {
 	}()
 
 	// Start sitemap result processor (creates PageCrawlTask after sitemap crawl)
-	sitemapResultProcessor := worker.NewSitemapResultProcessor(natsClient, siteRepo, progressSvc, publisher)
+	sitemapResultProcessor := worker.NewSitemapResultProcessor(natsClient, siteRepo, sitemapURLRepo, progressSvc, publisher)
 	go func() {
 		if err := sitemapResultProcessor.Run(ctx); err != nil && err != context.Canceled {
 			log.Error().Err(err).Msg("sitemap result processor error")
@@ -236,7 +380,10 @@ func main() {
 	}()
 
 	// Start page single processor (saves parsed pages and updates sitemap_urls status immediately)
-	pageSingleProcessor := worker.NewPageSingleProcessor(natsClient, siteRepo, pageRepo, sitemapURLRepo, progressSvc, meiliClient)
+	pageSingleProcessor := worker.NewPageSingleProcessor(natsClient, siteRepo, pageRepo, sitemapURLRepo, progressSvc, meiliClient, cfg.PageResultAckWait)
+	if cfg.HTMLSnapshots {
+		pageSingleProcessor.SetSnapshots(snapshotStore)
+	}
 	go func() {
 		if err := pageSingleProcessor.Run(ctx); err != nil && err != context.Canceled {
 			log.Error().Err(err).Msg("page single processor error")
@@ -245,6 +392,9 @@ func main() {
 
 	// Start page result processor (finalizes page crawl task)
 	pageResultProcessor := worker.NewPageResultProcessor(natsClient, siteRepo, sitemapURLRepo, progressSvc, contentRepo, violationsSvc)
+	if cfg.ScannerAutoUpgrade {
+		pageResultProcessor.SetScannerUpgradeThreshold(cfg.ScannerUpgradeEmptyTitles)
+	}
 	go func() {
 		if err := pageResultProcessor.Run(ctx); err != nil && err != context.Canceled {
 			log.Error().Err(err).Msg("page result processor error")
@@ -19,12 +19,36 @@ func main() {
 	mongoDB := flag.String("db", "video_analitics", "MongoDB database")
 	meiliURL := flag.String("meili", "http://localhost:7700", "Meilisearch URL")
 	meiliKey := flag.String("meili-key", "masterKey", "Meilisearch API key")
+	meiliIndex := flag.String("meili-index", meili.PagesIndex, "Meilisearch pages index")
 	contentID := flag.String("content", "", "Content ID to recalculate (empty = all)")
+	maxHits := flag.Int64("max-hits", violations.DefaultMaxSearchHits, "Max Meilisearch matches per search query")
+	searchTimeout := flag.Duration("search-timeout", violations.DefaultSearchTimeout, "Timeout of a single Meilisearch query")
+	sinceStr := flag.String("since", "", "Only recalculate content not checked since this RFC3339 time")
+	stale := flag.Duration("stale", 0, "Only recalculate content not checked within this duration (e.g. 24h)")
+	workers := flag.Int("workers", 4, "Number of contents recalculated in parallel")
+	progressEvery := flag.Duration("progress-every", 10*time.Second, "Progress log interval")
+	yearTolerance := flag.Int("year-tolerance", 0, "Allowed year mismatch for title+year and fuzzy year matching")
+	minFuzzyHits := flag.Int("min-fuzzy-hits", 1, "Minimum fuzzy-only matches per site to record them (1 = no limit)")
+	disabledStages := flag.String("disable-stages", "", "Comma-separated match stages to skip (e.g. title,mal)")
 	flag.Parse()
 
 	logger.Init(true)
 	log := logger.Log
 
+	var since time.Time
+	switch {
+	case *sinceStr != "" && *stale > 0:
+		log.Fatal().Msg("--since and --stale are mutually exclusive")
+	case *sinceStr != "":
+		t, err := time.Parse(time.RFC3339, *sinceStr)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid --since, expected RFC3339")
+		}
+		since = t
+	case *stale > 0:
+		since = time.Now().Add(-*stale)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
@@ -39,7 +63,7 @@ func main() {
 	}
 	log.Info().Str("url", *mongoURL).Msg("connected to MongoDB")
 
-	meiliClient, err := meili.New(*meiliURL, *meiliKey)
+	meiliClient, err := meili.New(*meiliURL, *meiliKey, *meiliIndex)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to connect to Meilisearch")
 	}
@@ -47,8 +71,16 @@ func main() {
 
 	db := client.Database(*mongoDB)
 	contentRepo := repo.NewContentRepo(db)
-	violationsSvc := violations.NewService(db, meiliClient)
+	matchStages, err := violations.ParseStageConfig(*disabledStages)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid --disable-stages")
+	}
+	violationsSvc := violations.NewService(db, meiliClient, matchStages)
 	violationsSvc.SetContentUpdater(contentRepo)
+	violationsSvc.SetMaxSearchHits(*maxHits)
+	violationsSvc.SetSearchTimeout(*searchTimeout)
+	violationsSvc.SetYearTolerance(*yearTolerance)
+	violationsSvc.SetMinFuzzyHits(*minFuzzyHits)
 
 	if *contentID != "" {
 		content, err := contentRepo.FindByID(ctx, *contentID)
@@ -70,13 +102,16 @@ func main() {
 			ID:            content.ID.Hex(),
 			Title:         content.Title,
 			OriginalTitle: content.OriginalTitle,
+			Aliases:       content.Aliases,
 			Year:          content.Year,
 			KinopoiskID:   content.KinopoiskID,
 			IMDBID:        content.IMDBID,
 			MALID:         content.MALID,
 			ShikimoriID:   content.ShikimoriID,
 			MyDramaListID: content.MyDramaListID,
-		})
+			Language:      content.Language,
+			Region:        content.Region,
+		}, 0)
 		if err != nil {
 			log.Fatal().Err(err).Msg("failed to refresh violations")
 		}
@@ -87,12 +122,21 @@ func main() {
 		return
 	}
 
-	contents, err := contentRepo.GetAll(ctx)
+	var contents []repo.Content
+	if since.IsZero() {
+		contents, err = contentRepo.GetAll(ctx)
+	} else {
+		contents, err = contentRepo.FindStale(ctx, since)
+	}
 	if err != nil {
-		log.Fatal().Err(err).Msg("failed to get all contents")
+		log.Fatal().Err(err).Msg("failed to get contents")
 	}
 
-	log.Info().Int("count", len(contents)).Msg("recalculating violations for all contents")
+	if since.IsZero() {
+		log.Info().Int("count", len(contents)).Msg("recalculating violations for all contents")
+	} else {
+		log.Info().Int("count", len(contents)).Time("since", since).Msg("recalculating violations for stale contents")
+	}
 
 	contentInfos := make([]violations.ContentInfo, len(contents))
 	for i, c := range contents {
@@ -100,16 +144,35 @@ func main() {
 			ID:            c.ID.Hex(),
 			Title:         c.Title,
 			OriginalTitle: c.OriginalTitle,
+			Aliases:       c.Aliases,
 			Year:          c.Year,
 			KinopoiskID:   c.KinopoiskID,
 			IMDBID:        c.IMDBID,
 			MALID:         c.MALID,
 			ShikimoriID:   c.ShikimoriID,
 			MyDramaListID: c.MyDramaListID,
+			Language:      c.Language,
+			Region:        c.Region,
 		}
 	}
 
-	updated, err := violationsSvc.RefreshAll(ctx, contentInfos)
+	var lastLog time.Time
+	updated, err := violationsSvc.RefreshAllWithOptions(ctx, contentInfos, violations.RefreshOptions{
+		Workers: *workers,
+		OnProgress: func(p violations.RefreshProgress) {
+			if time.Since(lastLog) < *progressEvery && p.Done < p.Total {
+				return
+			}
+			lastLog = time.Now()
+			log.Info().
+				Int("done", p.Done).
+				Int("total", p.Total).
+				Int64("updated", p.Updated).
+				Str("rate", fmt.Sprintf("%.1f/s", p.Rate())).
+				Dur("elapsed", p.Elapsed).
+				Msg("recalculation progress")
+		},
+	})
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to refresh all violations")
 	}
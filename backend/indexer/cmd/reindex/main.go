@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/meili"
+	"github.com/video-analitics/backend/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/video-analitics/indexer/internal/repo"
+)
+
+// reindex строит индекс страниц заново без простоя поиска: документы из MongoDB пишутся
+// во временный индекс, после сверки числа документов он меняется местами с живым,
+// а старые данные удаляются
+func main() {
+	mongoURL := flag.String("mongo", "mongodb://192.168.2.2:27017", "MongoDB URL")
+	mongoDB := flag.String("db", "video_analitics", "MongoDB database")
+	meiliURL := flag.String("meili", "http://192.168.2.2:7700", "Meilisearch URL")
+	meiliKey := flag.String("meili-key", "masterKey", "Meilisearch API key")
+	meiliIndex := flag.String("meili-index", meili.PagesIndex, "Live Meilisearch pages index")
+	batchSize := flag.Int("batch", 1000, "Batch size for indexing")
+	statePath := flag.String("state", "", "Progress file (default reindex-<index>.json)")
+	resume := flag.Bool("resume", false, "Continue an interrupted reindex from the progress file")
+	maxDiff := flag.Int64("max-diff", 0, "Allowed difference between MongoDB and temporary index document counts")
+	flag.Parse()
+
+	logger.Init(true)
+	log := logger.Log
+
+	if *statePath == "" {
+		*statePath = fmt.Sprintf("reindex-%s.json", *meiliIndex)
+	}
+
+	ctx := context.Background()
+
+	connectCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(connectCtx, options.Client().ApplyURI(*mongoURL))
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to connect to MongoDB")
+	}
+	defer client.Disconnect(ctx)
+
+	if err := client.Ping(connectCtx, nil); err != nil {
+		log.Fatal().Err(err).Msg("failed to ping MongoDB")
+	}
+	log.Info().Str("url", *mongoURL).Msg("connected to MongoDB")
+
+	liveClient, err := meili.New(*meiliURL, *meiliKey, *meiliIndex)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to connect to Meilisearch")
+	}
+	tempIndex := liveClient.Index() + "_reindex"
+
+	st := &reindexState{Index: liveClient.Index(), TempIndex: tempIndex, StartedAt: time.Now().UTC()}
+	if *resume {
+		saved, err := loadState(*statePath)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to read progress file")
+		}
+		if saved == nil {
+			log.Fatal().Str("state", *statePath).Msg("nothing to resume: progress file not found")
+		}
+		if saved.Index != st.Index || saved.TempIndex != st.TempIndex {
+			log.Fatal().Str("index", saved.Index).Str("temp_index", saved.TempIndex).Msg("progress file belongs to another index")
+		}
+		st = saved
+	}
+
+	tempClient, err := liveClient.ForIndex(tempIndex)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create temporary index")
+	}
+	if !*resume {
+		// Остатки прерванного запуска без -resume начинаем с нуля
+		if err := tempClient.DeleteAllDocuments(); err != nil {
+			log.Fatal().Err(err).Msg("failed to clear temporary index")
+		}
+		if err := saveState(*statePath, st); err != nil {
+			log.Fatal().Err(err).Msg("failed to write progress file")
+		}
+	}
+	log.Info().
+		Str("index", st.Index).
+		Str("temp_index", st.TempIndex).
+		Int64("already_indexed", st.Indexed).
+		Msg("reindexing into temporary index")
+
+	db := client.Database(*mongoDB)
+	collection := db.Collection("pages")
+
+	// Страницы сайтов в корзине лежат в MongoDB до очистки, но в поиске их быть не должно
+	siteDomains, err := repo.NewSiteRepo(db).ActiveDomains(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load sites")
+	}
+	siteIDs := make([]string, 0, len(siteDomains))
+	for id := range siteDomains {
+		siteIDs = append(siteIDs, id)
+	}
+	pagesFilter := func() bson.M { return bson.M{"site_id": bson.M{"$in": siteIDs}} }
+
+	total, err := collection.CountDocuments(ctx, pagesFilter())
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to count pages")
+	}
+
+	// Страницы идут по возрастанию _id, поэтому продолжить можно с последнего сохранённого
+	filter := pagesFilter()
+	if !st.LastID.IsZero() {
+		filter["_id"] = bson.M{"$gt": st.LastID}
+	}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to query pages")
+	}
+	defer cursor.Close(ctx)
+
+	started := time.Now()
+	sent := int64(0)
+	batch := make([]meili.PageDocument, 0, *batchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := tempClient.IndexPages(batch); err != nil {
+			log.Fatal().Err(err).Int("count", len(batch)).Msg("failed to index batch, rerun with -resume")
+		}
+		st.Indexed += int64(len(batch))
+		sent += int64(len(batch))
+		if err := saveState(*statePath, st); err != nil {
+			log.Fatal().Err(err).Msg("failed to write progress file")
+		}
+
+		ev := log.Info().Int64("indexed", st.Indexed).Int64("total", total)
+		if total > 0 {
+			ev = ev.Str("progress", fmt.Sprintf("%.1f%%", float64(st.Indexed)*100/float64(total)))
+		}
+		if elapsed := time.Since(started).Seconds(); elapsed > 0 {
+			ev = ev.Int("docs_per_sec", int(float64(sent)/elapsed))
+		}
+		ev.Msg("batch indexed")
+		batch = batch[:0]
+	}
+
+	for cursor.Next(ctx) {
+		var page models.Page
+		if err := cursor.Decode(&page); err != nil {
+			log.Warn().Err(err).Msg("failed to decode page")
+			continue
+		}
+		batch = append(batch, meili.NewPageDocument(&page, siteDomains[page.SiteID]))
+		st.LastID = page.ID
+		if len(batch) >= *batchSize {
+			flush()
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Fatal().Err(err).Msg("failed to read pages, rerun with -resume")
+	}
+	flush()
+
+	log.Info().Msg("waiting for Meilisearch to finish indexing")
+	if err := tempClient.WaitForTasks(ctx); err != nil {
+		log.Fatal().Err(err).Msg("failed to wait for indexing tasks")
+	}
+
+	// Сверяем с MongoDB на момент окончания: страницы могли добавиться или удалиться во время прохода
+	mongoCount, err := collection.CountDocuments(ctx, pagesFilter())
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to count pages")
+	}
+	meiliCount, err := tempClient.CountDocuments(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to count temporary index documents")
+	}
+	diff := mongoCount - meiliCount
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > *maxDiff {
+		log.Fatal().
+			Int64("mongo", mongoCount).
+			Int64("meili", meiliCount).
+			Msg("document count mismatch, live index left untouched")
+	}
+	log.Info().Int64("mongo", mongoCount).Int64("meili", meiliCount).Msg("document counts match")
+
+	if err := liveClient.SwapIndex(ctx, tempIndex); err != nil {
+		log.Fatal().Err(err).Msg("failed to swap indexes")
+	}
+	// После обмена во временном индексе лежат старые данные
+	if err := liveClient.DeleteIndex(tempIndex); err != nil {
+		log.Warn().Err(err).Str("index", tempIndex).Msg("failed to delete old index")
+	}
+	if err := os.Remove(*statePath); err != nil && !os.IsNotExist(err) {
+		log.Warn().Err(err).Str("state", *statePath).Msg("failed to remove progress file")
+	}
+
+	fmt.Printf("\nReindex completed: %d documents in %s (%s)\n", meiliCount, liveClient.Index(), time.Since(st.StartedAt).Round(time.Second))
+}
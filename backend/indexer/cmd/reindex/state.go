@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// reindexState — прогресс переиндексации, сохраняется после каждой принятой Meilisearch пачки,
+// чтобы прерванный запуск можно было продолжить с -resume
+type reindexState struct {
+	Index     string             `json:"index"`
+	TempIndex string             `json:"temp_index"`
+	LastID    primitive.ObjectID `json:"last_id"`
+	Indexed   int64              `json:"indexed"`
+	StartedAt time.Time          `json:"started_at"`
+}
+
+// loadState читает состояние; nil без ошибки — файла нет
+func loadState(path string) (*reindexState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st reindexState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("parse state %s: %w", path, err)
+	}
+	return &st, nil
+}
+
+// saveState пишет состояние через временный файл, чтобы обрыв не оставил его наполовину записанным
+func saveState(path string, st *reindexState) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reindex-pages.json")
+
+	st, err := loadState(path)
+	if err != nil || st != nil {
+		t.Fatalf("missing file: state=%v err=%v", st, err)
+	}
+
+	want := &reindexState{
+		Index:     "pages",
+		TempIndex: "pages_reindex",
+		LastID:    primitive.NewObjectID(),
+		Indexed:   42000,
+		StartedAt: time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC),
+	}
+	if err := saveState(path, want); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary file left behind: %v", err)
+	}
+
+	got, err := loadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *want {
+		t.Fatalf("loadState = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadStateCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reindex-pages.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadState(path); err == nil {
+		t.Fatal("expected error for corrupted file")
+	}
+}
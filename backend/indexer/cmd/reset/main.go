@@ -12,6 +12,8 @@ import (
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/redis/go-redis/v9"
 	"github.com/video-analitics/backend/pkg/meili"
+	"github.com/video-analitics/backend/pkg/violations"
+	"github.com/video-analitics/indexer/internal/repo"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -38,6 +40,8 @@ func main() {
 	skipMongo := flag.Bool("skip-mongo", false, "Skip clearing MongoDB")
 	skipRedis := flag.Bool("skip-redis", false, "Skip clearing Redis queues")
 	skipNats := flag.Bool("skip-nats", false, "Skip clearing NATS JetStream")
+	siteID := flag.String("site-id", "", "Reset only data of the given site (pages, tasks, sitemap URLs, Meilisearch docs, violations)")
+	domain := flag.String("domain", "", "Same as --site-id, but the site is looked up by domain")
 	flag.Parse()
 
 	godotenv.Load()
@@ -58,6 +62,7 @@ func main() {
 	if meiliKey == "" {
 		meiliKey = "masterKey"
 	}
+	meiliIndex := os.Getenv("MEILI_INDEX")
 	redisURL := os.Getenv("REDIS_URL")
 	if redisURL == "" {
 		redisURL = "192.168.2.2:6389"
@@ -70,6 +75,11 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if *siteID != "" || *domain != "" {
+		resetSite(ctx, *siteID, *domain, mongoURI, dbName, meiliURL, meiliKey, meiliIndex, *skipMongo, *skipMeili)
+		return
+	}
+
 	// Clear MongoDB
 	if !*skipMongo {
 		log.Println("Connecting to MongoDB...")
@@ -95,7 +105,7 @@ func main() {
 	// Clear Meilisearch
 	if !*skipMeili {
 		log.Println("Connecting to Meilisearch...")
-		meiliClient, err := meili.New(meiliURL, meiliKey)
+		meiliClient, err := meili.New(meiliURL, meiliKey, meiliIndex)
 		if err != nil {
 			log.Printf("Warning: Failed to connect to Meilisearch: %v", err)
 		} else {
@@ -169,3 +179,51 @@ func main() {
 
 	log.Println("Reset completed!")
 }
+
+// resetSite очищает данные одного сайта, не трогая остальные сайты, Redis и NATS
+func resetSite(ctx context.Context, siteID, domain, mongoURI, dbName, meiliURL, meiliKey, meiliIndex string, skipMongo, skipMeili bool) {
+	log.Println("Connecting to MongoDB...")
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(ctx)
+
+	db := client.Database(dbName)
+	siteRepo := repo.NewSiteRepo(db)
+
+	var site *repo.Site
+	if siteID != "" {
+		site, err = siteRepo.FindByID(ctx, siteID)
+	} else {
+		site, err = siteRepo.FindByDomain(ctx, domain)
+	}
+	if err != nil {
+		log.Fatalf("Failed to find site: %v", err)
+	}
+	if site == nil {
+		log.Fatalf("Site not found (site-id=%q, domain=%q)", siteID, domain)
+	}
+	siteID = site.ID.Hex()
+	log.Printf("Resetting site %s (%s)", site.Domain, siteID)
+
+	var targets siteTargets
+	if !skipMongo {
+		targets.Violations = violations.NewRepository(db).DeleteBySiteID
+		targets.Pages = repo.NewPageRepo(db).DeleteBySiteID
+		targets.SitemapURLs = repo.NewSitemapURLRepo(db).DeleteBySiteID
+		targets.Tasks = repo.NewScanTaskRepo(db).DeleteBySiteID
+	}
+	if !skipMeili {
+		meiliClient, err := meili.New(meiliURL, meiliKey, meiliIndex)
+		if err != nil {
+			log.Printf("Warning: Failed to connect to Meilisearch: %v", err)
+		} else {
+			targets.Meili = meiliClient.DeleteBySiteID
+		}
+	}
+
+	runSiteDeletionPlan(ctx, siteID, siteDeletionPlan(siteID, targets))
+
+	log.Printf("Reset of site %s completed!", site.Domain)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log"
+)
+
+// siteTargets — хранилища, из которых удаляются данные одного сайта.
+// nil-поле означает, что хранилище пропускается (--skip-mongo / --skip-meili).
+type siteTargets struct {
+	Violations  func(ctx context.Context, siteID string) (int64, error)
+	Pages       func(ctx context.Context, siteID string) (int64, error)
+	SitemapURLs func(ctx context.Context, siteID string) error
+	Tasks       func(ctx context.Context, siteID string) (int64, error)
+	Meili       func(siteID string) error
+}
+
+type deleteStep struct {
+	name string
+	run  func(ctx context.Context) (int64, error)
+}
+
+// siteDeletionPlan строит упорядоченный список шагов очистки сайта.
+// Сам сайт и доступы пользователей к нему не трогаются.
+func siteDeletionPlan(siteID string, t siteTargets) []deleteStep {
+	var steps []deleteStep
+
+	if t.Violations != nil {
+		steps = append(steps, deleteStep{name: "violations", run: func(ctx context.Context) (int64, error) {
+			return t.Violations(ctx, siteID)
+		}})
+	}
+	if t.Pages != nil {
+		steps = append(steps, deleteStep{name: "pages", run: func(ctx context.Context) (int64, error) {
+			return t.Pages(ctx, siteID)
+		}})
+	}
+	if t.SitemapURLs != nil {
+		steps = append(steps, deleteStep{name: "sitemap_urls", run: func(ctx context.Context) (int64, error) {
+			return -1, t.SitemapURLs(ctx, siteID)
+		}})
+	}
+	if t.Tasks != nil {
+		steps = append(steps, deleteStep{name: "scan_tasks", run: func(ctx context.Context) (int64, error) {
+			return t.Tasks(ctx, siteID)
+		}})
+	}
+	if t.Meili != nil {
+		steps = append(steps, deleteStep{name: "meilisearch", run: func(ctx context.Context) (int64, error) {
+			return -1, t.Meili(siteID)
+		}})
+	}
+
+	return steps
+}
+
+func runSiteDeletionPlan(ctx context.Context, siteID string, steps []deleteStep) {
+	for _, step := range steps {
+		deleted, err := step.run(ctx)
+		if err != nil {
+			log.Printf("Failed to clear %s for site %s: %v", step.name, siteID, err)
+			continue
+		}
+		if deleted >= 0 {
+			log.Printf("Cleared %s for site %s: %d documents deleted", step.name, siteID, deleted)
+		} else {
+			log.Printf("Cleared %s for site %s", step.name, siteID)
+		}
+	}
+}
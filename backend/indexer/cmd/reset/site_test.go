@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSiteDeletionPlan(t *testing.T) {
+	const siteID = "site-1"
+
+	var calls []string
+	record := func(name string) func(ctx context.Context, id string) (int64, error) {
+		return func(ctx context.Context, id string) (int64, error) {
+			if id != siteID {
+				t.Errorf("%s called with site %q, want %q", name, id, siteID)
+			}
+			calls = append(calls, name)
+			return 1, nil
+		}
+	}
+	all := siteTargets{
+		Violations: record("violations"),
+		Pages:      record("pages"),
+		SitemapURLs: func(ctx context.Context, id string) error {
+			_, err := record("sitemap_urls")(ctx, id)
+			return err
+		},
+		Tasks: record("scan_tasks"),
+		Meili: func(id string) error {
+			_, err := record("meilisearch")(context.Background(), id)
+			return err
+		},
+	}
+
+	tests := []struct {
+		name    string
+		targets siteTargets
+		want    []string
+	}{
+		{
+			name:    "all stores",
+			targets: all,
+			want:    []string{"violations", "pages", "sitemap_urls", "scan_tasks", "meilisearch"},
+		},
+		{
+			name: "skip meili",
+			targets: siteTargets{
+				Violations:  all.Violations,
+				Pages:       all.Pages,
+				SitemapURLs: all.SitemapURLs,
+				Tasks:       all.Tasks,
+			},
+			want: []string{"violations", "pages", "sitemap_urls", "scan_tasks"},
+		},
+		{
+			name:    "skip mongo",
+			targets: siteTargets{Meili: all.Meili},
+			want:    []string{"meilisearch"},
+		},
+		{
+			name:    "nothing",
+			targets: siteTargets{},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			steps := siteDeletionPlan(siteID, tt.targets)
+
+			var names []string
+			for _, s := range steps {
+				names = append(names, s.name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Fatalf("plan = %v, want %v", names, tt.want)
+			}
+
+			runSiteDeletionPlan(context.Background(), siteID, steps)
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Errorf("executed = %v, want %v", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSiteDeletionPlanContinuesOnError(t *testing.T) {
+	var pagesCalled bool
+	steps := siteDeletionPlan("site-1", siteTargets{
+		Violations: func(ctx context.Context, id string) (int64, error) {
+			return 0, errors.New("boom")
+		},
+		Pages: func(ctx context.Context, id string) (int64, error) {
+			pagesCalled = true
+			return 0, nil
+		},
+	})
+
+	runSiteDeletionPlan(context.Background(), "site-1", steps)
+	if !pagesCalled {
+		t.Error("pages step was not executed after violations failure")
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/video-analitics/backend/pkg/meili"
+	"github.com/video-analitics/indexer/internal/repo"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -18,6 +19,7 @@ type Content struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty"`
 	Title         string             `bson:"title"`
 	OriginalTitle string             `bson:"original_title,omitempty"`
+	TitleSearch   []string           `bson:"title_search,omitempty"`
 	Year          int                `bson:"year,omitempty"`
 	KinopoiskID   string             `bson:"kinopoisk_id,omitempty"`
 	IMDBID        string             `bson:"imdb_id,omitempty"`
@@ -98,10 +100,10 @@ func main() {
 	}
 	defer client.Disconnect(ctx)
 
-	db := client.Database(dbName)
+	store := &mongoStore{db: client.Database(dbName)}
 
 	// Meilisearch client
-	meiliClient, err := meili.New(meiliURL, meiliKey)
+	meiliClient, err := meili.New(meiliURL, meiliKey, os.Getenv("MEILI_INDEX"))
 	if err != nil {
 		log.Printf("Warning: Failed to connect to Meilisearch: %v", err)
 		meiliClient = nil
@@ -121,21 +123,19 @@ func main() {
 	}
 
 	log.Println("Seeding content...")
-	seedContent(ctx, db)
+	seedContent(ctx, store)
 
 	log.Println("Seeding sites...")
-	sites := seedSites(ctx, db)
+	sites := seedSites(ctx, store)
 
 	log.Println("Seeding pages...")
-	seedPages(ctx, db, sites, meiliClient)
+	seedPages(ctx, store, sites, meiliClient)
 
 	log.Println("Seeding completed!")
 }
 
-func seedContent(ctx context.Context, db *mongo.Database) {
-	coll := db.Collection("content")
-
-	contents := []interface{}{
+func seedContent(ctx context.Context, store seedStore) {
+	contents := []Content{
 		Content{
 			Title:         "Кибердеревня",
 			OriginalTitle: "Cyber Village",
@@ -162,12 +162,16 @@ func seedContent(ctx context.Context, db *mongo.Database) {
 		},
 	}
 
-	result, err := coll.InsertMany(ctx, contents)
-	if err != nil {
-		log.Printf("Warning: Failed to insert content (may already exist): %v", err)
-		return
+	upserted := 0
+	for _, content := range contents {
+		content.TitleSearch = repo.TitleSearchKeys(content.Title, content.OriginalTitle, nil)
+		if _, err := store.Upsert(ctx, "content", contentKey(content), content); err != nil {
+			log.Printf("Warning: Failed to upsert content %q: %v", content.Title, err)
+			continue
+		}
+		upserted++
 	}
-	log.Printf("Inserted %d content items", len(result.InsertedIDs))
+	log.Printf("Upserted %d content items", upserted)
 }
 
 // SiteInfo содержит ID и домен сайта
@@ -176,8 +180,7 @@ type SiteInfo struct {
 	Domain string
 }
 
-func seedSites(ctx context.Context, db *mongo.Database) []SiteInfo {
-	coll := db.Collection("sites")
+func seedSites(ctx context.Context, store seedStore) []SiteInfo {
 	now := time.Now()
 	yesterday := now.Add(-24 * time.Hour)
 	weekAgo := now.Add(-7 * 24 * time.Hour)
@@ -236,36 +239,28 @@ func seedSites(ctx context.Context, db *mongo.Database) []SiteInfo {
 		},
 	}
 
-	// Convert to interface{} for InsertMany
-	sites := make([]interface{}, len(sitesData))
-	for i := range sitesData {
-		sites[i] = sitesData[i]
-	}
-
-	result, err := coll.InsertMany(ctx, sites)
-	if err != nil {
-		log.Printf("Warning: Failed to insert sites (may already exist): %v", err)
-		return nil
-	}
-	log.Printf("Inserted %d sites", len(result.InsertedIDs))
-
 	var infos []SiteInfo
-	for i, id := range result.InsertedIDs {
+	for _, site := range sitesData {
+		id, err := store.Upsert(ctx, "sites", siteKey(site), site)
+		if err != nil {
+			log.Printf("Warning: Failed to upsert site %s: %v", site.Domain, err)
+			continue
+		}
 		infos = append(infos, SiteInfo{
-			ID:     id.(primitive.ObjectID).Hex(),
-			Domain: sitesData[i].Domain,
+			ID:     id.Hex(),
+			Domain: site.Domain,
 		})
 	}
+	log.Printf("Upserted %d sites", len(infos))
 	return infos
 }
 
-func seedPages(ctx context.Context, db *mongo.Database, sites []SiteInfo, meiliClient *meili.Client) {
+func seedPages(ctx context.Context, store seedStore, sites []SiteInfo, meiliClient *meili.Client) {
 	if len(sites) < 3 {
 		log.Println("Not enough sites to seed pages")
 		return
 	}
 
-	coll := db.Collection("pages")
 	now := time.Now()
 
 	pagesData := []Page{
@@ -356,44 +351,20 @@ func seedPages(ctx context.Context, db *mongo.Database, sites []SiteInfo, meiliC
 		},
 	}
 
-	// Convert to interface{} for InsertMany
-	pages := make([]interface{}, len(pagesData))
-	for i := range pagesData {
-		pages[i] = pagesData[i]
-	}
-
-	result, err := coll.InsertMany(ctx, pages)
-	if err != nil {
-		log.Printf("Warning: Failed to insert pages (may already exist): %v", err)
-		return
+	var meiliDocs []meili.PageDocument
+	for _, p := range pagesData {
+		id, err := store.Upsert(ctx, "pages", pageKey(p), p)
+		if err != nil {
+			log.Printf("Warning: Failed to upsert page %s: %v", p.URL, err)
+			continue
+		}
+		p.ID = id
+		meiliDocs = append(meiliDocs, pageToDocument(p))
 	}
-	log.Printf("Inserted %d pages into MongoDB", len(result.InsertedIDs))
+	log.Printf("Upserted %d pages into MongoDB", len(meiliDocs))
 
-	// Index in Meilisearch
+	// ID страниц стабильны между запусками, поэтому повторная индексация перезаписывает документы
 	if meiliClient != nil {
-		var meiliDocs []meili.PageDocument
-		for i, id := range result.InsertedIDs {
-			p := pagesData[i]
-			meiliDocs = append(meiliDocs, meili.PageDocument{
-				ID:            id.(primitive.ObjectID).Hex(),
-				SiteID:        p.SiteID,
-				Domain:        p.Domain,
-				URL:           p.URL,
-				Title:         p.Title,
-				Description:   p.Description,
-				MainText:      p.MainText,
-				Year:          p.Year,
-				KinopoiskID:   p.ExternalIDs.KinopoiskID,
-				IMDBID:        p.ExternalIDs.IMDBID,
-				MALID:         p.ExternalIDs.MALID,
-				ShikimoriID:   p.ExternalIDs.ShikimoriID,
-				MyDramaListID: p.ExternalIDs.MyDramaListID,
-				LinksText:     p.LinksText,
-				PlayerURLs:    []string{p.PlayerURL},
-				IndexedAt:     p.IndexedAt.Format(time.RFC3339),
-			})
-		}
-
 		if err := meiliClient.IndexPages(meiliDocs); err != nil {
 			log.Printf("Warning: Failed to index pages in Meilisearch: %v", err)
 		} else {
@@ -401,3 +372,24 @@ func seedPages(ctx context.Context, db *mongo.Database, sites []SiteInfo, meiliC
 		}
 	}
 }
+
+func pageToDocument(p Page) meili.PageDocument {
+	return meili.PageDocument{
+		ID:            p.ID.Hex(),
+		SiteID:        p.SiteID,
+		Domain:        p.Domain,
+		URL:           p.URL,
+		Title:         p.Title,
+		Description:   p.Description,
+		MainText:      p.MainText,
+		Year:          p.Year,
+		KinopoiskID:   p.ExternalIDs.KinopoiskID,
+		IMDBID:        p.ExternalIDs.IMDBID,
+		MALID:         p.ExternalIDs.MALID,
+		ShikimoriID:   p.ExternalIDs.ShikimoriID,
+		MyDramaListID: p.ExternalIDs.MyDramaListID,
+		LinksText:     p.LinksText,
+		PlayerURLs:    []string{p.PlayerURL},
+		IndexedAt:     p.IndexedAt.Format(time.RFC3339),
+	}
+}
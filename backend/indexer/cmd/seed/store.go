@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// seedStore upsert'ит документ по натуральному ключу и возвращает его _id,
+// чтобы повторный запуск seed не плодил дубликаты
+type seedStore interface {
+	Upsert(ctx context.Context, collection string, key bson.M, doc interface{}) (primitive.ObjectID, error)
+}
+
+type mongoStore struct {
+	db *mongo.Database
+}
+
+func (s *mongoStore) Upsert(ctx context.Context, collection string, key bson.M, doc interface{}) (primitive.ObjectID, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	update, err := upsertUpdate(doc)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	var result struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err = s.db.Collection(collection).FindOneAndUpdate(ctx, key, update, opts).Decode(&result)
+	return result.ID, err
+}
+
+// insertOnlyFields не перезаписываются повторным запуском seed
+var insertOnlyFields = []string{"created_at"}
+
+// upsertUpdate раскладывает документ на $set и $setOnInsert, чтобы повторный запуск
+// обновлял данные, но не сбрасывал время создания
+func upsertUpdate(doc interface{}) (bson.M, error) {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var set bson.M
+	if err := bson.Unmarshal(raw, &set); err != nil {
+		return nil, err
+	}
+
+	onInsert := bson.M{}
+	for _, field := range insertOnlyFields {
+		if v, ok := set[field]; ok {
+			onInsert[field] = v
+			delete(set, field)
+		}
+	}
+
+	update := bson.M{"$set": set}
+	if len(onInsert) > 0 {
+		update["$setOnInsert"] = onInsert
+	}
+	return update, nil
+}
+
+// contentKey выбирает самый надёжный внешний ID контента, при их отсутствии — название и год
+func contentKey(c Content) bson.M {
+	switch {
+	case c.KinopoiskID != "":
+		return bson.M{"kinopoisk_id": c.KinopoiskID}
+	case c.IMDBID != "":
+		return bson.M{"imdb_id": c.IMDBID}
+	case c.MALID != "":
+		return bson.M{"mal_id": c.MALID}
+	case c.ShikimoriID != "":
+		return bson.M{"shikimori_id": c.ShikimoriID}
+	case c.MyDramaListID != "":
+		return bson.M{"mydramalist_id": c.MyDramaListID}
+	}
+	return bson.M{"title": c.Title, "year": c.Year}
+}
+
+func siteKey(s Site) bson.M {
+	return bson.M{"domain": s.Domain}
+}
+
+func pageKey(p Page) bson.M {
+	return bson.M{"url": p.URL}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryDoc struct {
+	id  primitive.ObjectID
+	doc bson.M
+}
+
+// memoryStore эмулирует upsert MongoDB: ищет документ, у которого совпадают все поля ключа
+type memoryStore struct {
+	colls map[string][]memoryDoc
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{colls: make(map[string][]memoryDoc)}
+}
+
+func (s *memoryStore) Upsert(ctx context.Context, collection string, key bson.M, doc interface{}) (primitive.ObjectID, error) {
+	update, err := upsertUpdate(doc)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	k, err := toBSONMap(key)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	set, _ := update["$set"].(bson.M)
+	onInsert, _ := update["$setOnInsert"].(bson.M)
+
+	docs := s.colls[collection]
+	for i, d := range docs {
+		if matchesKey(d.doc, k) {
+			for f, v := range set {
+				docs[i].doc[f] = v
+			}
+			return d.id, nil
+		}
+	}
+
+	m := bson.M{}
+	for f, v := range set {
+		m[f] = v
+	}
+	for f, v := range onInsert {
+		m[f] = v
+	}
+	id := primitive.NewObjectID()
+	s.colls[collection] = append(docs, memoryDoc{id: id, doc: m})
+	return id, nil
+}
+
+// toBSONMap приводит типы значений к тем, что вернула бы MongoDB (int -> int32 и т.п.)
+func toBSONMap(v interface{}) (bson.M, error) {
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m bson.M
+	err = bson.Unmarshal(raw, &m)
+	return m, err
+}
+
+func matchesKey(doc, key bson.M) bool {
+	for k, v := range key {
+		if !reflect.DeepEqual(doc[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestContentKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		content Content
+		want    bson.M
+	}{
+		{
+			name:    "kinopoisk has priority",
+			content: Content{Title: "A", KinopoiskID: "1", IMDBID: "tt1"},
+			want:    bson.M{"kinopoisk_id": "1"},
+		},
+		{
+			name:    "imdb",
+			content: Content{Title: "A", IMDBID: "tt1", MALID: "5"},
+			want:    bson.M{"imdb_id": "tt1"},
+		},
+		{
+			name:    "mal",
+			content: Content{Title: "A", MALID: "5"},
+			want:    bson.M{"mal_id": "5"},
+		},
+		{
+			name:    "shikimori",
+			content: Content{Title: "A", ShikimoriID: "7"},
+			want:    bson.M{"shikimori_id": "7"},
+		},
+		{
+			name:    "mydramalist",
+			content: Content{Title: "A", MyDramaListID: "9"},
+			want:    bson.M{"mydramalist_id": "9"},
+		},
+		{
+			name:    "title and year fallback",
+			content: Content{Title: "A", Year: 2020},
+			want:    bson.M{"title": "A", "year": 2020},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contentKey(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("contentKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSiteAndPageKeys(t *testing.T) {
+	if got, want := siteKey(Site{Domain: "example.com", Status: "active"}), (bson.M{"domain": "example.com"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("siteKey() = %v, want %v", got, want)
+	}
+	if got, want := pageKey(Page{URL: "https://example.com/a", Title: "A"}), (bson.M{"url": "https://example.com/a"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("pageKey() = %v, want %v", got, want)
+	}
+}
+
+func TestSeedIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+
+	seedContent(ctx, store)
+	firstSites := seedSites(ctx, store)
+	seedPages(ctx, store, firstSites, nil)
+
+	counts := make(map[string]int)
+	for coll, docs := range store.colls {
+		counts[coll] = len(docs)
+	}
+	for _, coll := range []string{"content", "sites", "pages"} {
+		if counts[coll] == 0 {
+			t.Fatalf("first run seeded no %s", coll)
+		}
+	}
+
+	seedContent(ctx, store)
+	secondSites := seedSites(ctx, store)
+	seedPages(ctx, store, secondSites, nil)
+
+	for coll, docs := range store.colls {
+		if len(docs) != counts[coll] {
+			t.Errorf("%s: second run changed count from %d to %d", coll, counts[coll], len(docs))
+		}
+	}
+	if !reflect.DeepEqual(firstSites, secondSites) {
+		t.Errorf("site IDs changed between runs: %v vs %v", firstSites, secondSites)
+	}
+}
+
+func TestUpsertUpdateKeepsCreatedAt(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	update, err := upsertUpdate(Site{Domain: "example.com", Status: "active", CreatedAt: created})
+	if err != nil {
+		t.Fatalf("upsertUpdate() error = %v", err)
+	}
+
+	set := update["$set"].(bson.M)
+	if _, ok := set["created_at"]; ok {
+		t.Errorf("$set contains created_at: %v", set)
+	}
+	if set["status"] != "active" {
+		t.Errorf("$set status = %v, want active", set["status"])
+	}
+	onInsert := update["$setOnInsert"].(bson.M)
+	if got := onInsert["created_at"].(primitive.DateTime).Time().UTC(); !got.Equal(created) {
+		t.Errorf("$setOnInsert created_at = %v, want %v", got, created)
+	}
+}
+
+func TestSeedRerunKeepsCreatedAt(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+
+	seedContent(ctx, store)
+	first := make(map[primitive.ObjectID]interface{})
+	for _, d := range store.colls["content"] {
+		first[d.id] = d.doc["created_at"]
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	seedContent(ctx, store)
+	for _, d := range store.colls["content"] {
+		if !reflect.DeepEqual(d.doc["created_at"], first[d.id]) {
+			t.Errorf("content %s: created_at changed from %v to %v", d.id.Hex(), first[d.id], d.doc["created_at"])
+		}
+	}
+}
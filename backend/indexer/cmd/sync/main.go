@@ -4,7 +4,6 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"net/url"
 	"time"
 
 	"github.com/video-analitics/backend/pkg/logger"
@@ -13,6 +12,8 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/video-analitics/indexer/internal/repo"
 )
 
 func main() {
@@ -20,6 +21,7 @@ func main() {
 	mongoDB := flag.String("db", "video_analitics", "MongoDB database")
 	meiliURL := flag.String("meili", "http://192.168.2.2:7700", "Meilisearch URL")
 	meiliKey := flag.String("meili-key", "masterKey", "Meilisearch API key")
+	meiliIndex := flag.String("meili-index", meili.PagesIndex, "Meilisearch pages index")
 	batchSize := flag.Int("batch", 1000, "Batch size for indexing")
 	flag.Parse()
 
@@ -42,17 +44,30 @@ func main() {
 	log.Info().Str("url", *mongoURL).Msg("connected to MongoDB")
 
 	// Connect to Meilisearch
-	meiliClient, err := meili.New(*meiliURL, *meiliKey)
+	// Полная пересборка без простоя поиска — cmd/reindex
+	meiliClient, err := meili.New(*meiliURL, *meiliKey, *meiliIndex)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to connect to Meilisearch")
 	}
-	log.Info().Str("url", *meiliURL).Msg("connected to Meilisearch")
+	log.Info().Str("url", *meiliURL).Str("index", meiliClient.Index()).Msg("connected to Meilisearch")
 
 	// Get pages collection
-	collection := client.Database(*mongoDB).Collection("pages")
+	db := client.Database(*mongoDB)
+	collection := db.Collection("pages")
+
+	// Страницы сайтов в корзине не синхронизируются: они лежат в MongoDB только до очистки
+	siteDomains, err := repo.NewSiteRepo(db).ActiveDomains(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load sites")
+	}
+	siteIDs := make([]string, 0, len(siteDomains))
+	for id := range siteDomains {
+		siteIDs = append(siteIDs, id)
+	}
+	filter := bson.M{"site_id": bson.M{"$in": siteIDs}}
 
 	// Count total pages
-	total, err := collection.CountDocuments(ctx, bson.M{})
+	total, err := collection.CountDocuments(ctx, filter)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to count pages")
 	}
@@ -64,7 +79,7 @@ func main() {
 	}
 
 	// Fetch and index in batches
-	cursor, err := collection.Find(ctx, bson.M{})
+	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to query pages")
 	}
@@ -80,32 +95,7 @@ func main() {
 			continue
 		}
 
-		// Extract domain from URL
-		domain := ""
-		if u, err := url.Parse(page.URL); err == nil && u.Host != "" {
-			domain = u.Host
-		}
-
-		doc := meili.PageDocument{
-			ID:            page.ID.Hex(),
-			SiteID:        page.SiteID,
-			Domain:        domain,
-			URL:           page.URL,
-			Title:         page.Title,
-			Description:   page.Description,
-			MainText:      page.MainText,
-			Year:          page.Year,
-			KinopoiskID:   page.ExternalIDs.KinopoiskID,
-			IMDBID:        page.ExternalIDs.IMDBID,
-			MALID:         page.ExternalIDs.MALID,
-			ShikimoriID:   page.ExternalIDs.ShikimoriID,
-			MyDramaListID: page.ExternalIDs.MyDramaListID,
-			LinksText:     page.LinksText,
-			PlayerURLs:    []string{page.PlayerURL},
-			IndexedAt:     page.IndexedAt.Format(time.RFC3339),
-		}
-
-		batch = append(batch, doc)
+		batch = append(batch, meili.NewPageDocument(&page, siteDomains[page.SiteID]))
 
 		if len(batch) >= *batchSize {
 			if err := meiliClient.IndexPages(batch); err != nil {
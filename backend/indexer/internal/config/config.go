@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -12,6 +13,22 @@ type Config struct {
 	MongoDB  string
 	MeiliURL string
 	MeiliKey string
+	// MeiliIndex — индекс страниц; разный для окружений, делящих один Meilisearch
+	MeiliIndex string
+	// MeiliMaxHits — потолок совпадений одного поискового запроса при расчёте нарушений
+	MeiliMaxHits int64
+	// MeiliSearchTimeout — таймаут одного поискового запроса матчера
+	MeiliSearchTimeout time.Duration
+	// MatchStagesDisabled — отключённые этапы матчера через запятую (например "title,mal")
+	MatchStagesDisabled string
+	// MatchRequireStrongSignal — не засчитывать совпадения только по названию (нужен ID или название с годом)
+	MatchRequireStrongSignal bool
+	// MatchYearTolerance — допуск по году в этапах title_year и title_fuzzy_year; 0 — строгое совпадение
+	MatchYearTolerance int
+	// MatchSingleWordTitles — однословные названия и алиасы через запятую, которые ищутся и без года
+	MatchSingleWordTitles string
+	// MatchMinFuzzyHits — сколько страниц сайта должен найти title_fuzzy_year, чтобы совпадениям поверили; 1 — без ограничения
+	MatchMinFuzzyHits int
 
 	JWTSecret        string
 	JWTAccessExpiry  time.Duration
@@ -20,16 +37,62 @@ type Config struct {
 	AdminPassword    string
 
 	InternalAPIToken string
+
+	// KinopoiskAPIKey — ключ kinopoiskapiunofficial.tech для автозаполнения контента; пусто — выключено
+	KinopoiskAPIKey string
+	KinopoiskAPIURL string
+
+	QueueMonitorInterval time.Duration
+	// QueueLagAlertThreshold — pending консьюмера, выше которого шлётся алерт; 0 — выключено
+	QueueLagAlertThreshold uint64
+	QueueLagWebhookURL     string
+
+	// SitemapRetry* — повторы упавших URL карты сайта: число попыток и экспоненциальная задержка
+	SitemapRetryMaxAttempts int
+	SitemapRetryBaseDelay   time.Duration
+	SitemapRetryMaxDelay    time.Duration
+
+	// TrashRetention — сколько удалённые сайты и контент хранятся в корзине до окончательного удаления
+	TrashRetention time.Duration
+
+	// HTMLSnapshots — хранить присланный парсером HTML страниц как доказательство
+	HTMLSnapshots bool
+	// SnapshotRetention — сколько хранятся снимки HTML
+	SnapshotRetention time.Duration
+
+	// ParseRateLimit — сколько запросов GET /api/parse в минуту разрешено одному пользователю
+	ParseRateLimit int
+
+	// ParseTimeout — ожидание разового парсинга страницы (GET /api/parse, ручные нарушения)
+	ParseTimeout time.Duration
+	// DetectTimeout — ожидание синхронной детекции (POST /api/detect)
+	DetectTimeout time.Duration
+	// PageResultAckWait — сколько NATS ждёт подтверждения результата парсинга страницы
+	PageResultAckWait time.Duration
+
+	// ScannerAutoUpgrade — переводить HTTP-сайты на SPA-сканер, если страницы приходят без заголовков
+	ScannerAutoUpgrade bool
+	// ScannerUpgradeEmptyTitles — сколько пустых заголовков за обход нужно для перевода на SPA
+	ScannerUpgradeEmptyTitles int
 }
 
 func Load() *Config {
 	return &Config{
-		Port:     getEnv("PORT", "8080"),
-		NatsURL:  getEnv("NATS_URL", "nats://192.168.2.2:4222"),
-		MongoURL: getEnv("MONGO_URL", "mongodb://192.168.2.2:27017"),
-		MongoDB:  getEnv("MONGO_DB", "video_analitics"),
-		MeiliURL: getEnv("MEILI_URL", "http://192.168.2.2:7700"),
-		MeiliKey: getEnv("MEILI_KEY", "masterKey"),
+		Port:       getEnv("PORT", "8080"),
+		NatsURL:    getEnv("NATS_URL", "nats://192.168.2.2:4222"),
+		MongoURL:   getEnv("MONGO_URL", "mongodb://192.168.2.2:27017"),
+		MongoDB:    getEnv("MONGO_DB", "video_analitics"),
+		MeiliURL:   getEnv("MEILI_URL", "http://192.168.2.2:7700"),
+		MeiliKey:   getEnv("MEILI_KEY", "masterKey"),
+		MeiliIndex: getEnv("MEILI_INDEX", "pages"),
+
+		MeiliMaxHits:             parseInt64(getEnv("MEILI_MAX_HITS", "10000"), 10000),
+		MeiliSearchTimeout:       parseDurationOr(getEnv("MEILI_SEARCH_TIMEOUT", "30s"), 30*time.Second),
+		MatchStagesDisabled:      getEnv("MATCH_STAGES_DISABLED", ""),
+		MatchRequireStrongSignal: parseBool(getEnv("MATCH_REQUIRE_STRONG_SIGNAL", "false")),
+		MatchYearTolerance:       int(parseInt64(getEnv("MATCH_YEAR_TOLERANCE", "0"), 0)),
+		MatchSingleWordTitles:    getEnv("MATCH_SINGLE_WORD_TITLES", ""),
+		MatchMinFuzzyHits:        int(parseInt64(getEnv("MATCH_MIN_FUZZY_HITS", "1"), 1)),
 
 		JWTSecret:        getEnv("JWT_SECRET", ""),
 		JWTAccessExpiry:  parseDuration(getEnv("JWT_ACCESS_EXPIRY", "15m")),
@@ -38,6 +101,31 @@ func Load() *Config {
 		AdminPassword:    getEnv("ADMIN_PASSWORD", ""),
 
 		InternalAPIToken: getEnv("INTERNAL_API_TOKEN", ""),
+
+		KinopoiskAPIKey: getEnv("KINOPOISK_API_KEY", ""),
+		KinopoiskAPIURL: getEnv("KINOPOISK_API_URL", ""),
+
+		QueueMonitorInterval:   parseDurationOr(getEnv("QUEUE_MONITOR_INTERVAL", "30s"), 30*time.Second),
+		QueueLagAlertThreshold: uint64(parseInt64(getEnv("QUEUE_LAG_ALERT_THRESHOLD", "0"), 0)),
+		QueueLagWebhookURL:     getEnv("QUEUE_LAG_WEBHOOK_URL", ""),
+
+		SitemapRetryMaxAttempts: int(parseInt64(getEnv("SITEMAP_RETRY_MAX_ATTEMPTS", "5"), 5)),
+		SitemapRetryBaseDelay:   parseDurationOr(getEnv("SITEMAP_RETRY_BASE_DELAY", "5m"), 5*time.Minute),
+		SitemapRetryMaxDelay:    parseDurationOr(getEnv("SITEMAP_RETRY_MAX_DELAY", "6h"), 6*time.Hour),
+
+		TrashRetention: parseDurationOr(getEnv("TRASH_RETENTION", "720h"), 720*time.Hour),
+
+		HTMLSnapshots:     parseBool(getEnv("HTML_SNAPSHOTS", "false")),
+		SnapshotRetention: parseDurationOr(getEnv("SNAPSHOT_RETENTION", "2160h"), 2160*time.Hour),
+
+		ParseRateLimit: int(parseInt64(getEnv("PARSE_RATE_LIMIT", "10"), 10)),
+
+		ParseTimeout:      parseDurationOr(getEnv("PARSE_TIMEOUT", "90s"), 90*time.Second),
+		DetectTimeout:     parseDurationOr(getEnv("DETECT_TIMEOUT", "2m"), 2*time.Minute),
+		PageResultAckWait: parseDurationOr(getEnv("PAGE_RESULT_ACK_WAIT", "30s"), 30*time.Second),
+
+		ScannerAutoUpgrade:        parseBool(getEnv("SCANNER_AUTO_UPGRADE", "true")),
+		ScannerUpgradeEmptyTitles: int(parseInt64(getEnv("SCANNER_UPGRADE_EMPTY_TITLES", "10"), 10)),
 	}
 }
 
@@ -48,6 +136,14 @@ func getEnv(key, defaultVal string) string {
 	return defaultVal
 }
 
+func parseInt64(s string, defaultVal int64) int64 {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return defaultVal
+	}
+	return n
+}
+
 func parseDuration(s string) time.Duration {
 	d, err := time.ParseDuration(s)
 	if err != nil {
@@ -55,3 +151,16 @@ func parseDuration(s string) time.Duration {
 	}
 	return d
 }
+
+func parseDurationOr(s string, defaultVal time.Duration) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return defaultVal
+	}
+	return d
+}
+
+func parseBool(s string) bool {
+	v, _ := strconv.ParseBool(s)
+	return v
+}
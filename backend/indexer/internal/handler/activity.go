@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/indexer/internal/middleware"
+	"github.com/video-analitics/indexer/internal/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 200
+)
+
+type ActivityHandler struct {
+	activityRepo    *repo.ActivityRepo
+	siteRepo        *repo.SiteRepo
+	userSiteRepo    *repo.UserSiteRepo
+	userContentRepo *repo.UserContentRepo
+}
+
+func NewActivityHandler(activityRepo *repo.ActivityRepo, siteRepo *repo.SiteRepo, userSiteRepo *repo.UserSiteRepo, userContentRepo *repo.UserContentRepo) *ActivityHandler {
+	return &ActivityHandler{
+		activityRepo:    activityRepo,
+		siteRepo:        siteRepo,
+		userSiteRepo:    userSiteRepo,
+		userContentRepo: userContentRepo,
+	}
+}
+
+type ActivityResponse struct {
+	Items      []repo.Activity `json:"items"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// List godoc
+// @Summary Recent activity feed
+// @Description Chronological feed of sites added, scans completed or failed, sites frozen and violations discovered, newest first. Admins see everything, other users only their sites and content
+// @Tags stats
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Max events to return (default 50, max 200)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} ActivityResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/activity [get]
+func (h *ActivityHandler) List(c *fiber.Ctx) error {
+	limit, _ := strconv.ParseInt(c.Query("limit"), 10, 64)
+	if limit <= 0 {
+		limit = defaultActivityLimit
+	}
+	if limit > maxActivityLimit {
+		limit = maxActivityLimit
+	}
+
+	filter := repo.ActivityFilter{Limit: limit}
+	if raw := c.Query("cursor"); raw != "" {
+		after, err := repo.DecodeCursor(raw)
+		if err != nil {
+			return c.Status(400).JSON(ErrorResponse{Error: "invalid cursor"})
+		}
+		filter.After = after
+	}
+
+	if !middleware.IsAdmin(c) {
+		userID := middleware.GetUserID(c)
+		userOID, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "invalid user id"})
+		}
+
+		siteIDs, err := h.siteRepo.GetAccessibleSiteIDs(c.Context(), userID, h.userSiteRepo)
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch user sites"})
+		}
+		contentOIDs, err := h.userContentRepo.GetContentIDs(c.Context(), userOID)
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch user content"})
+		}
+
+		// Пустой, но не nil срез — нет доступа ни к чему, nil означал бы всё
+		filter.SiteIDs = append([]string{}, siteIDs...)
+		filter.ContentIDs = make([]string, len(contentOIDs))
+		for i, id := range contentOIDs {
+			filter.ContentIDs[i] = id.Hex()
+		}
+	}
+
+	items, err := h.activityRepo.FindRecent(c.Context(), filter)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch activity"})
+	}
+	if items == nil {
+		items = []repo.Activity{}
+	}
+
+	resp := ActivityResponse{Items: items}
+	if n := len(items); int64(n) == limit {
+		resp.NextCursor = repo.EncodeCursor(items[n-1].At, items[n-1].ID)
+	}
+	return c.JSON(resp)
+}
@@ -3,7 +3,11 @@ package handler
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
@@ -54,6 +58,33 @@ type SuccessResponse struct {
 	Message string `json:"message"`
 }
 
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+const minPasswordLength = 8
+
+// validatePasswordStrength — не короче minPasswordLength, есть и буквы, и цифры
+func validatePasswordStrength(password string) error {
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return errors.New("password must contain letters and digits")
+	}
+	return nil
+}
+
 // Login godoc
 // @Summary Login
 // @Description Authenticate user and get tokens
@@ -176,6 +207,62 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 	return c.JSON(SuccessResponse{Message: "logged out successfully"})
 }
 
+// ChangePassword godoc
+// @Summary Change own password
+// @Description Change the password of the authenticated user. The current password must match; the new one needs at least 8 characters with letters and digits. All refresh tokens of the user are revoked, so other sessions have to log in again
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/auth/change-password [post]
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(401).JSON(ErrorResponse{Error: "unauthorized"})
+	}
+
+	var req ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		return c.Status(400).JSON(ErrorResponse{Error: "current_password and new_password are required"})
+	}
+	if err := validatePasswordStrength(req.NewPassword); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: err.Error()})
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return c.Status(400).JSON(ErrorResponse{Error: "new password must differ from the current one"})
+	}
+
+	user, err := h.userRepo.FindByID(c.Context(), userID)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "internal server error"})
+	}
+	if user == nil || !user.IsActive {
+		return c.Status(401).JSON(ErrorResponse{Error: "user not found or deactivated"})
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		return c.Status(401).JSON(ErrorResponse{Error: "invalid current password"})
+	}
+
+	if err := h.userRepo.UpdatePassword(c.Context(), userID, req.NewPassword); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to update password"})
+	}
+
+	if err := h.refreshTokenRepo.DeleteByUserID(c.Context(), userID); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "password changed, but failed to revoke sessions"})
+	}
+
+	return c.JSON(SuccessResponse{Message: "password changed successfully"})
+}
+
 // Me godoc
 // @Summary Get current user
 // @Description Get authenticated user profile
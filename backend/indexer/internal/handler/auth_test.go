@@ -0,0 +1,26 @@
+package handler
+
+import "testing"
+
+func TestValidatePasswordStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"", true},
+		{"abc123", true},
+		{"abcdefgh", true},
+		{"12345678", true},
+		{"abcd1234", false},
+		{"пароль2024", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			err := validatePasswordStrength(tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePasswordStrength(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,16 +1,23 @@
 package handler
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/csv"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/meili"
 	"github.com/video-analitics/backend/pkg/violations"
+	"github.com/video-analitics/indexer/internal/metadata"
 	"github.com/video-analitics/indexer/internal/middleware"
 	"github.com/video-analitics/indexer/internal/repo"
+	"github.com/video-analitics/indexer/internal/service"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -18,38 +25,66 @@ type ContentHandler struct {
 	contentRepo     *repo.ContentRepo
 	userContentRepo *repo.UserContentRepo
 	siteRepo        *repo.SiteRepo
+	userSiteRepo    *repo.UserSiteRepo
+	pageRepo        *repo.PageRepo
 	violationsSvc   *violations.Service
+	trash           *service.TrashService
+	// metadataProvider дозаполняет название и год по ID; nil — обогащение выключено
+	metadataProvider metadata.Provider
+	// manualViolations добавляет найденные вручную адреса; nil — эндпоинт отвечает 503
+	manualViolations *service.ManualViolationService
+	verifyJobs       *verifyJobs
 }
 
-func NewContentHandler(contentRepo *repo.ContentRepo, userContentRepo *repo.UserContentRepo, siteRepo *repo.SiteRepo, violationsSvc *violations.Service) *ContentHandler {
+func NewContentHandler(contentRepo *repo.ContentRepo, userContentRepo *repo.UserContentRepo, siteRepo *repo.SiteRepo, userSiteRepo *repo.UserSiteRepo, pageRepo *repo.PageRepo, violationsSvc *violations.Service, trash *service.TrashService) *ContentHandler {
 	return &ContentHandler{
 		contentRepo:     contentRepo,
 		userContentRepo: userContentRepo,
 		siteRepo:        siteRepo,
+		userSiteRepo:    userSiteRepo,
+		pageRepo:        pageRepo,
 		violationsSvc:   violationsSvc,
+		trash:           trash,
+		verifyJobs:      newVerifyJobs(),
 	}
 }
 
+func (h *ContentHandler) SetMetadataProvider(p metadata.Provider) {
+	h.metadataProvider = p
+}
+
 type CreateContentRequest struct {
 	Title         string `json:"title"`
 	OriginalTitle string `json:"original_title,omitempty"`
-	Year          int    `json:"year,omitempty"`
-	KinopoiskID   string `json:"kinopoisk_id,omitempty"`
-	IMDBID        string `json:"imdb_id,omitempty"`
-	MALID         string `json:"mal_id,omitempty"`
-	ShikimoriID   string `json:"shikimori_id,omitempty"`
-	MyDramaListID string `json:"mydramalist_id,omitempty"`
+	// Aliases — альтернативные названия (локализации, сокращения); у существующего контента дополняют список
+	Aliases       []string `json:"aliases,omitempty"`
+	Year          int      `json:"year,omitempty"`
+	KinopoiskID   string   `json:"kinopoisk_id,omitempty"`
+	IMDBID        string   `json:"imdb_id,omitempty"`
+	MALID         string   `json:"mal_id,omitempty"`
+	ShikimoriID   string   `json:"shikimori_id,omitempty"`
+	MyDramaListID string   `json:"mydramalist_id,omitempty"`
+	// Language — язык оригинала (ISO 639-1, например "ko"), Region — страна (ISO 3166-1, например "KR");
+	// для аниме и дорам матчер предпочитает профильные базы совпадениям по названию
+	Language string `json:"language,omitempty"`
+	Region   string `json:"region,omitempty"`
+	// Priority — high, normal или low; задаёт, как часто планировщик пересчитывает нарушения
+	Priority string `json:"priority,omitempty"`
 }
 
 type ContentWithStats struct {
 	repo.Content
-	ViolationsCount int64 `json:"violations_count"`
-	SitesCount      int64 `json:"sites_count"`
+	ViolationsCount int64                          `json:"violations_count"`
+	SitesCount      int64                          `json:"sites_count"`
+	MatchTypeCounts map[violations.MatchType]int64 `json:"match_type_counts,omitempty"`
+	// Owner — кто первым добавил контент; заполняется только для админа
+	Owner *repo.ContentOwner `json:"owner,omitempty"`
 }
 
 // Create godoc
 // @Summary Create content
 // @Description Add content to track for violations. If content already exists (by external ID), links it to user.
+// @Description Empty title, original title and year are filled from the metadata provider by kinopoisk_id/imdb_id when it is configured.
 // @Tags content
 // @Accept json
 // @Produce json
@@ -65,14 +100,19 @@ func (h *ContentHandler) Create(c *fiber.Ctx) error {
 		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
 	}
 
-	if req.Title == "" {
-		return c.Status(400).JSON(ErrorResponse{Error: "title is required"})
-	}
-
 	if req.KinopoiskID == "" && req.IMDBID == "" && req.MALID == "" && req.ShikimoriID == "" && req.MyDramaListID == "" {
 		return c.Status(400).JSON(ErrorResponse{Error: "at least one ID is required (kinopoisk_id, imdb_id, mal_id, shikimori_id, mydramalist_id)"})
 	}
 
+	language, region, errMsg := normalizeLanguage(req.Language, req.Region)
+	if errMsg != "" {
+		return c.Status(400).JSON(ErrorResponse{Error: errMsg})
+	}
+	priority, errMsg := normalizePriority(req.Priority)
+	if errMsg != "" {
+		return c.Status(400).JSON(ErrorResponse{Error: errMsg})
+	}
+
 	userOID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return c.Status(500).JSON(ErrorResponse{Error: "invalid user id"})
@@ -81,12 +121,27 @@ func (h *ContentHandler) Create(c *fiber.Ctx) error {
 	content := &repo.Content{
 		Title:         req.Title,
 		OriginalTitle: req.OriginalTitle,
+		Aliases:       normalizeAliases(req.Aliases),
 		Year:          req.Year,
 		KinopoiskID:   req.KinopoiskID,
 		IMDBID:        req.IMDBID,
 		MALID:         req.MALID,
 		ShikimoriID:   req.ShikimoriID,
 		MyDramaListID: req.MyDramaListID,
+		Language:      language,
+		Region:        region,
+		Priority:      priority,
+	}
+
+	// Обогащаем, только когда пользователь не указал название
+	if content.Title == "" {
+		if _, err := metadata.Enrich(c.Context(), h.metadataProvider, content); err != nil {
+			logger.Log.Warn().Err(err).Str("kinopoisk_id", content.KinopoiskID).Str("imdb_id", content.IMDBID).Msg("metadata enrichment failed")
+		}
+	}
+
+	if content.Title == "" {
+		return c.Status(400).JSON(ErrorResponse{Error: "title is required"})
 	}
 
 	existing, err := h.contentRepo.FindByExternalID(c.Context(), content)
@@ -95,6 +150,13 @@ func (h *ContentHandler) Create(c *fiber.Ctx) error {
 	}
 
 	if existing != nil {
+		// Повторное добавление удалённого контента достаёт его из корзины
+		if existing.DeletedAt != nil {
+			if _, err := h.trash.RestoreContent(c.Context(), existing); err != nil {
+				return c.Status(500).JSON(ErrorResponse{Error: "failed to restore content"})
+			}
+		}
+
 		h.contentRepo.EnrichExternalIDs(c.Context(), existing.ID, content)
 
 		if err := h.userContentRepo.Link(c.Context(), userOID, existing.ID); err != nil {
@@ -110,6 +172,7 @@ func (h *ContentHandler) Create(c *fiber.Ctx) error {
 			Content:         *updated,
 			ViolationsCount: updated.ViolationsCount,
 			SitesCount:      updated.SitesCount,
+			MatchTypeCounts: updated.MatchTypeCounts,
 		})
 	}
 
@@ -138,37 +201,46 @@ func (h *ContentHandler) refreshViolationsForContent(content *repo.Content) {
 		ID:            content.ID.Hex(),
 		Title:         content.Title,
 		OriginalTitle: content.OriginalTitle,
+		Aliases:       content.Aliases,
 		Year:          content.Year,
 		KinopoiskID:   content.KinopoiskID,
 		IMDBID:        content.IMDBID,
 		MALID:         content.MALID,
 		ShikimoriID:   content.ShikimoriID,
 		MyDramaListID: content.MyDramaListID,
-	})
+		Language:      content.Language,
+		Region:        content.Region,
+	}, 0)
 }
 
 type ListContentResponse struct {
 	Items []ContentWithStats `json:"items"`
-	Total int64              `json:"total"`
+	Pagination
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // List godoc
 // @Summary List content
-// @Description Get list of tracked content with violation stats
+// @Description Get list of tracked content with violation stats. For admins every item also carries owner: the first user who added it and how many users track it.
 // @Tags content
 // @Produce json
-// @Param title query string false "Search by title"
+// @Param title query string false "Search by title, original title or alias: every word must start a word in the name (case, quotes and year in parens are ignored)"
 // @Param kinopoisk_id query string false "Filter by Kinopoisk ID"
 // @Param imdb_id query string false "Filter by IMDB ID"
 // @Param mal_id query string false "Filter by MAL ID"
 // @Param shikimori_id query string false "Filter by Shikimori ID"
 // @Param mydramalist_id query string false "Filter by MyDramaList ID"
+// @Param match query string false "How external ID filters combine: all must match or any of them" Enums(all, any) default(all)
 // @Param has_violations query string false "Filter by violations presence (true/false)"
-// @Param sort_by query string false "Sort by field" Enums(violations_count, created_at) default(violations_count)
+// @Param sort_by query string false "Sort by field; relevance ranks by title similarity and needs title" Enums(violations_count, created_at, relevance) default(violations_count)
 // @Param sort_order query string false "Sort order" Enums(asc, desc) default(desc)
 // @Param limit query int false "Limit" default(20)
-// @Param offset query int false "Offset" default(0)
+// @Param offset query int false "Offset"
+// @Param paginate query string false "Set to cursor to get the first page with next_cursor; cannot be combined with sort_by"
+// @Param cursor query string false "Cursor from next_cursor of the previous page (sorted by created_at desc; cannot be combined with sort_by)"
+// @Param count query bool false "Compute total with a separate count query; has_more is returned either way" default(true)
 // @Success 200 {object} ListContentResponse
+// @Failure 400 {object} ErrorResponse
 // @Router /api/content [get]
 func (h *ContentHandler) List(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
@@ -180,15 +252,14 @@ func (h *ContentHandler) List(c *fiber.Ctx) error {
 	malID := c.Query("mal_id")
 	shikimoriID := c.Query("shikimori_id")
 	mydramalistID := c.Query("mydramalist_id")
+	idMatch := c.Query("match", repo.IDMatchAll)
+	if !isValidIDMatch(idMatch) {
+		return c.Status(400).JSON(ErrorResponse{Error: "match must be all or any"})
+	}
 	hasViolationsStr := c.Query("has_violations")
 	sortBy := c.Query("sort_by", "violations_count")
 	sortOrder := c.Query("sort_order", "desc")
-	limit, _ := strconv.ParseInt(c.Query("limit", "20"), 10, 64)
-	offset, _ := strconv.ParseInt(c.Query("offset", "0"), 10, 64)
-
-	if limit > 100 {
-		limit = 100
-	}
+	lp := parseListPage(c, 100)
 
 	var hasViolations *bool
 	if hasViolationsStr == "true" {
@@ -199,6 +270,15 @@ func (h *ContentHandler) List(c *fiber.Ctx) error {
 		hasViolations = &v
 	}
 
+	page, err := parseCursorPage(c)
+	if err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid cursor"})
+	}
+	// Курсор привязан к created_at — явная сортировка с ним молча потерялась бы
+	if page.Enabled && c.Query("sort_by") != "" {
+		return c.Status(400).JSON(ErrorResponse{Error: "sort_by cannot be combined with cursor pagination"})
+	}
+
 	filter := repo.ContentFilter{
 		Title:         title,
 		KinopoiskID:   kinopoiskID,
@@ -206,16 +286,18 @@ func (h *ContentHandler) List(c *fiber.Ctx) error {
 		MALID:         malID,
 		ShikimoriID:   shikimoriID,
 		MyDramaListID: mydramalistID,
+		IDMatch:       idMatch,
 		HasViolations: hasViolations,
 		SortBy:        sortBy,
 		SortOrder:     sortOrder,
-		Limit:         limit,
-		Offset:        offset,
+		Limit:         lp.fetchLimit(),
+		Offset:        lp.Offset,
+		Cursor:        page,
+		SkipCount:     !lp.Count,
 	}
 
 	var contents []repo.Content
 	var total int64
-	var err error
 
 	if isAdmin {
 		contents, total, err = h.contentRepo.FindAll(c.Context(), filter)
@@ -229,7 +311,7 @@ func (h *ContentHandler) List(c *fiber.Ctx) error {
 			return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch user content"})
 		}
 		if len(contentIDs) == 0 {
-			return c.JSON(ListContentResponse{Items: []ContentWithStats{}, Total: 0})
+			return c.JSON(ListContentResponse{Items: []ContentWithStats{}, Pagination: lp.pagination(0, false)})
 		}
 		contents, total, err = h.contentRepo.FindByIDs(c.Context(), contentIDs, filter)
 	}
@@ -237,6 +319,7 @@ func (h *ContentHandler) List(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch content"})
 	}
+	contents, hasMore := trimPage(contents, lp.Limit)
 
 	items := make([]ContentWithStats, len(contents))
 	for i, content := range contents {
@@ -244,13 +327,22 @@ func (h *ContentHandler) List(c *fiber.Ctx) error {
 			Content:         content,
 			ViolationsCount: content.ViolationsCount,
 			SitesCount:      content.SitesCount,
+			MatchTypeCounts: content.MatchTypeCounts,
 		}
 	}
+	if isAdmin {
+		h.attachOwners(c.Context(), items)
+	}
 
-	return c.JSON(ListContentResponse{
-		Items: items,
-		Total: total,
-	})
+	resp := ListContentResponse{
+		Items:      items,
+		Pagination: lp.pagination(total, hasMore),
+	}
+	if n := len(contents); n > 0 {
+		resp.NextCursor = nextCursor(page, hasMore, contents[n-1].CreatedAt, contents[n-1].ID)
+	}
+
+	return c.JSON(resp)
 }
 
 func (h *ContentHandler) hasAccess(ctx context.Context, userID string, isAdmin bool, contentID primitive.ObjectID) bool {
@@ -265,6 +357,42 @@ func (h *ContentHandler) hasAccess(ctx context.Context, userID string, isAdmin b
 	return hasAccess
 }
 
+// visibleContentIDs возвращает множество content_id, доступных пользователю (nil для админа)
+func visibleContentIDs(c *fiber.Ctx, userContentRepo *repo.UserContentRepo) (map[string]bool, error) {
+	if middleware.IsAdmin(c) {
+		return nil, nil
+	}
+	userOID, err := primitive.ObjectIDFromHex(middleware.GetUserID(c))
+	if err != nil {
+		return nil, err
+	}
+	ids, err := userContentRepo.GetContentIDs(c.Context(), userOID)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		allowed[id.Hex()] = true
+	}
+	return allowed, nil
+}
+
+// filterContentIDs убирает exclude и content_id, не входящие в allowed (nil — без ограничений)
+func filterContentIDs(contentIDs []string, exclude string, allowed map[string]bool) []string {
+	var result []string
+	for _, id := range contentIDs {
+		if id == exclude {
+			continue
+		}
+		if allowed != nil && !allowed[id] {
+			continue
+		}
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
+
 func (h *ContentHandler) checkContentAccess(c *fiber.Ctx, contentID string) (*repo.Content, error) {
 	userID := middleware.GetUserID(c)
 	isAdmin := middleware.IsAdmin(c)
@@ -286,11 +414,14 @@ func (h *ContentHandler) checkContentAccess(c *fiber.Ctx, contentID string) (*re
 
 // Get godoc
 // @Summary Get content by ID
-// @Description Get content details with violation stats
+// @Description Get content details with violation stats. For admins the response also carries owner.
 // @Tags content
 // @Produce json
 // @Param id path string true "Content ID"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} ContentWithStats
+// @Header 200 {string} ETag "Payload version"
+// @Success 304 "Not modified"
 // @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /api/content/{id} [get]
@@ -302,11 +433,41 @@ func (h *ContentHandler) Get(c *fiber.Ctx) error {
 		return err
 	}
 
-	return c.JSON(ContentWithStats{
+	items := []ContentWithStats{{
 		Content:         *content,
 		ViolationsCount: content.ViolationsCount,
 		SitesCount:      content.SitesCount,
-	})
+		MatchTypeCounts: content.MatchTypeCounts,
+	}}
+	if middleware.IsAdmin(c) {
+		h.attachOwners(c.Context(), items)
+	}
+
+	return c.JSON(items[0])
+}
+
+// attachOwners заполняет Owner; ошибка не мешает отдать список без владельцев
+func (h *ContentHandler) attachOwners(ctx context.Context, items []ContentWithStats) {
+	if len(items) == 0 {
+		return
+	}
+
+	ids := make([]primitive.ObjectID, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+
+	owners, err := h.userContentRepo.GetOwners(ctx, ids)
+	if err != nil {
+		logger.Log.Warn().Err(err).Msg("failed to resolve content owners")
+		return
+	}
+
+	for i := range items {
+		if owner, ok := owners[items[i].ID]; ok {
+			items[i].Owner = &owner
+		}
+	}
 }
 
 type ViolationResponse struct {
@@ -316,17 +477,26 @@ type ViolationResponse struct {
 	URL       string `json:"url"`
 	Title     string `json:"title"`
 	MatchType string `json:"match_type"`
-	FoundAt   string `json:"found_at"`
+	Evidence  string `json:"evidence,omitempty"` // поле и значение, по которым найдена страница
+	// запрос в Meilisearch, которым этап нашёл страницу
+	MatchQuery  string `json:"match_query,omitempty"`
+	MatchFilter string `json:"match_filter,omitempty"`
+	Season      int    `json:"season,omitempty"`
+	Episodes    []int  `json:"episodes,omitempty"`
+	FoundAt     string `json:"found_at"`
+	// контент пользователя, также найденный на этой странице
+	AlsoViolates []string `json:"also_violates,omitempty"`
 }
 
 type ListViolationsResponse struct {
-	Items []ViolationResponse `json:"items"`
-	Total int64               `json:"total"`
+	Items   []ViolationResponse      `json:"items"`
+	Total   int64                    `json:"total"`
+	Seasons []violations.SeasonStats `json:"seasons,omitempty"`
 }
 
 // GetViolations godoc
 // @Summary Get violations for content
-// @Description Get list of pages where content was found
+// @Description Get list of pages where content was found with a per-season breakdown for series
 // @Tags content
 // @Produce json
 // @Param id path string true "Content ID"
@@ -357,56 +527,152 @@ func (h *ContentHandler) GetViolations(c *fiber.Ctx) error {
 
 	domainMap := h.getSiteDomainsMap(c.Context(), vList)
 
+	pageIDs := make([]string, len(vList))
+	for i, v := range vList {
+		pageIDs[i] = v.PageID
+	}
+	pageContents, err := h.violationsSvc.GetContentIDsByPageIDs(c.Context(), pageIDs)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch page violations"})
+	}
+	allowed, err := visibleContentIDs(c, h.userContentRepo)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch user content"})
+	}
+
 	items := make([]ViolationResponse, len(vList))
 	for i, v := range vList {
 		items[i] = ViolationResponse{
-			PageID:    v.PageID,
-			SiteID:    v.SiteID,
-			Domain:    domainMap[v.SiteID],
-			URL:       v.PageURL,
-			Title:     v.PageTitle,
-			MatchType: string(v.MatchType),
-			FoundAt:   v.FoundAt.Format("2006-01-02T15:04:05Z"),
+			PageID:       v.PageID,
+			SiteID:       v.SiteID,
+			Domain:       domainMap[v.SiteID],
+			URL:          v.PageURL,
+			Title:        v.PageTitle,
+			MatchType:    string(v.MatchType),
+			Evidence:     v.Evidence,
+			MatchQuery:   v.MatchQuery,
+			MatchFilter:  v.MatchFilter,
+			Season:       v.Season,
+			Episodes:     v.Episodes,
+			FoundAt:      v.FoundAt.Format("2006-01-02T15:04:05Z"),
+			AlsoViolates: filterContentIDs(pageContents[v.PageID], id, allowed),
 		}
 	}
 
+	seasons, err := h.violationsSvc.GetSeasonStats(c.Context(), id)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch season stats"})
+	}
+	if !hasSeasons(seasons) {
+		seasons = nil
+	}
+
 	return c.JSON(ListViolationsResponse{
-		Items: items,
-		Total: total,
+		Items:   items,
+		Total:   total,
+		Seasons: seasons,
 	})
 }
 
-func (h *ContentHandler) getSiteDomainsMap(ctx context.Context, vList []violations.Violation) map[string]string {
-	siteIDs := make(map[string]bool)
-	for _, v := range vList {
-		siteIDs[v.SiteID] = true
+type PreviewMatchesResponse struct {
+	Items []ViolationResponse `json:"items"`
+	Total int                 `json:"total"`
+	Sites int                 `json:"sites"`
+}
+
+// PreviewMatches godoc
+// @Summary Preview content matches
+// @Description Run the matcher for content and return the violations a recalculation would produce. Nothing is saved: stored violations and content counters stay unchanged
+// @Tags content
+// @Produce json
+// @Param id path string true "Content ID"
+// @Success 200 {object} PreviewMatchesResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/content/{id}/matches/preview [get]
+func (h *ContentHandler) PreviewMatches(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	content, err := h.checkContentAccess(c, id)
+	if err != nil {
+		return err
 	}
 
-	ids := make([]string, 0, len(siteIDs))
-	for id := range siteIDs {
-		ids = append(ids, id)
+	vList, err := h.violationsSvc.PreviewForContent(c.Context(), violations.ContentInfo{
+		ID:            id,
+		Title:         content.Title,
+		OriginalTitle: content.OriginalTitle,
+		Aliases:       content.Aliases,
+		Year:          content.Year,
+		KinopoiskID:   content.KinopoiskID,
+		IMDBID:        content.IMDBID,
+		MALID:         content.MALID,
+		ShikimoriID:   content.ShikimoriID,
+		MyDramaListID: content.MyDramaListID,
+		Language:      content.Language,
+		Region:        content.Region,
+	})
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to preview matches"})
 	}
 
-	sites, _ := h.siteRepo.FindByIDs(ctx, ids)
+	domainMap := h.getSiteDomainsMap(c.Context(), vList)
 
-	domainMap := make(map[string]string)
-	for _, site := range sites {
-		domainMap[site.ID.Hex()] = site.Domain
+	items := make([]ViolationResponse, len(vList))
+	sites := make(map[string]struct{})
+	for i, v := range vList {
+		items[i] = ViolationResponse{
+			PageID:      v.PageID,
+			SiteID:      v.SiteID,
+			Domain:      domainMap[v.SiteID],
+			URL:         v.PageURL,
+			Title:       v.PageTitle,
+			MatchType:   string(v.MatchType),
+			Evidence:    v.Evidence,
+			MatchQuery:  v.MatchQuery,
+			MatchFilter: v.MatchFilter,
+			Season:      v.Season,
+			Episodes:    v.Episodes,
+			FoundAt:     v.FoundAt.Format("2006-01-02T15:04:05Z"),
+		}
+		sites[v.SiteID] = struct{}{}
 	}
-	return domainMap
+
+	return c.JSON(PreviewMatchesResponse{
+		Items: items,
+		Total: len(items),
+		Sites: len(sites),
+	})
 }
 
-// ExportViolationsCSV godoc
-// @Summary Export violations to CSV
-// @Description Export all violations for content to CSV file
+type ViolationsByDomainResponse struct {
+	Items []violations.DomainStats `json:"items"`
+	Total int64                    `json:"total"`
+}
+
+// maxReviewPageIDs — потолок страниц в одном запросе отметки
+const maxReviewPageIDs = 1000
+
+type ReviewViolationsRequest struct {
+	PageIDs []string `json:"page_ids"`
+	// Status — reviewed, false_positive или пустая строка, чтобы снять отметку
+	Status string `json:"status"`
+}
+
+// ReviewViolations godoc
+// @Summary Mark content violations as reviewed or false positive
+// @Description Set review status of the content violations on the given pages. false_positive deletes the violations and keeps them from being found again on recalculation; an empty status clears the mark and lets suppressed pages return after the next recalculation, which starts in background
 // @Tags content
-// @Produce text/csv
+// @Accept json
+// @Produce json
 // @Param id path string true "Content ID"
-// @Success 200 {file} file
+// @Param request body ReviewViolationsRequest true "Pages and review status"
+// @Success 200 {object} violations.ReviewResult
+// @Failure 400 {object} ErrorResponse
 // @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
-// @Router /api/content/{id}/violations/export [get]
-func (h *ContentHandler) ExportViolationsCSV(c *fiber.Ctx) error {
+// @Router /api/content/{id}/violations/review [post]
+func (h *ContentHandler) ReviewViolations(c *fiber.Ctx) error {
 	id := c.Params("id")
 
 	content, err := h.checkContentAccess(c, id)
@@ -414,50 +680,84 @@ func (h *ContentHandler) ExportViolationsCSV(c *fiber.Ctx) error {
 		return err
 	}
 
-	vList, err := h.violationsSvc.GetAllByContentID(c.Context(), id)
-	if err != nil {
-		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch violations"})
+	var req ReviewViolationsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+	if len(req.PageIDs) == 0 {
+		return c.Status(400).JSON(ErrorResponse{Error: "page_ids is required"})
+	}
+	if len(req.PageIDs) > maxReviewPageIDs {
+		return c.Status(400).JSON(ErrorResponse{Error: fmt.Sprintf("page_ids must contain at most %d items", maxReviewPageIDs)})
+	}
+	reviewStatus, ok := violations.ParseReviewStatus(req.Status)
+	if !ok {
+		return c.Status(400).JSON(ErrorResponse{Error: "status must be reviewed, false_positive or empty"})
 	}
 
-	domainMap := h.getSiteDomainsMap(c.Context(), vList)
+	result, err := h.violationsSvc.Review(c.Context(), id, req.PageIDs, reviewStatus)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to review violations"})
+	}
+	if result.Unsuppressed > 0 {
+		go h.refreshViolationsForContent(content)
+	}
 
-	var buf bytes.Buffer
-	writer := csv.NewWriter(&buf)
+	return c.JSON(result)
+}
 
-	buf.Write([]byte{0xEF, 0xBB, 0xBF})
+type ListSuppressionsResponse struct {
+	Items []violations.Suppression `json:"items"`
+	Total int64                    `json:"total"`
+}
 
-	writer.Write([]string{"Домен", "URL", "Название страницы", "Тип совпадения", "Дата обнаружения"})
+// ListSuppressions godoc
+// @Summary List suppressed matches of content
+// @Description Pages marked as false positives for the content. Recalculation never turns them into violations again
+// @Tags content
+// @Produce json
+// @Param id path string true "Content ID"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} ListSuppressionsResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/content/{id}/suppressions [get]
+func (h *ContentHandler) ListSuppressions(c *fiber.Ctx) error {
+	id := c.Params("id")
+	limit, _ := strconv.ParseInt(c.Query("limit", "20"), 10, 64)
+	offset, _ := strconv.ParseInt(c.Query("offset", "0"), 10, 64)
 
-	for _, v := range vList {
-		writer.Write([]string{
-			domainMap[v.SiteID],
-			v.PageURL,
-			v.PageTitle,
-			string(v.MatchType),
-			v.FoundAt.Format("2006-01-02 15:04:05"),
-		})
+	if limit > 100 {
+		limit = 100
 	}
 
-	writer.Flush()
+	if _, err := h.checkContentAccess(c, id); err != nil {
+		return err
+	}
 
-	filename := fmt.Sprintf("violations_%s.csv", content.Title)
-	c.Set("Content-Type", "text/csv; charset=utf-8")
-	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	items, total, err := h.violationsSvc.ListSuppressions(c.Context(), id, limit, offset)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch suppressions"})
+	}
+	if items == nil {
+		items = []violations.Suppression{}
+	}
 
-	return c.Send(buf.Bytes())
+	return c.JSON(ListSuppressionsResponse{Items: items, Total: total})
 }
 
-// ExportViolationsText godoc
-// @Summary Export violations to text report
-// @Description Export all violations for content to plain text file
+// DeleteSuppression godoc
+// @Summary Remove a suppressed match of content
+// @Description Stop ignoring the page for the content. Violations are recalculated in background, so the page returns if it still matches
 // @Tags content
-// @Produce text/plain
 // @Param id path string true "Content ID"
-// @Success 200 {file} file
+// @Param suppression_id path string true "Suppression ID"
+// @Success 204
 // @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
-// @Router /api/content/{id}/violations/export-text [get]
-func (h *ContentHandler) ExportViolationsText(c *fiber.Ctx) error {
+// @Router /api/content/{id}/suppressions/{suppression_id} [delete]
+func (h *ContentHandler) DeleteSuppression(c *fiber.Ctx) error {
 	id := c.Params("id")
 
 	content, err := h.checkContentAccess(c, id)
@@ -465,73 +765,316 @@ func (h *ContentHandler) ExportViolationsText(c *fiber.Ctx) error {
 		return err
 	}
 
-	vList, err := h.violationsSvc.GetAllByContentID(c.Context(), id)
+	deleted, err := h.violationsSvc.RemoveSuppression(c.Context(), id, c.Params("suppression_id"))
 	if err != nil {
-		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch violations"})
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to delete suppression"})
+	}
+	if !deleted {
+		return c.Status(404).JSON(ErrorResponse{Error: "suppression not found"})
 	}
 
-	domainMap := h.getSiteDomainsMap(c.Context(), vList)
+	go h.refreshViolationsForContent(content)
 
-	var buf bytes.Buffer
+	return c.SendStatus(204)
+}
 
-	buf.WriteString(fmt.Sprintf("Отчёт о нарушениях: %s", content.Title))
-	if content.Year > 0 {
-		buf.WriteString(fmt.Sprintf(" (%d)", content.Year))
+// GetViolationsByDomain godoc
+// @Summary Get violations for content grouped by domain
+// @Description Get per-domain rollup of pages where content was found, sorted by violations count
+// @Tags content
+// @Produce json
+// @Param id path string true "Content ID"
+// @Success 200 {object} ViolationsByDomainResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/content/{id}/violations/by-domain [get]
+func (h *ContentHandler) GetViolationsByDomain(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	_, err := h.checkContentAccess(c, id)
+	if err != nil {
+		return err
 	}
-	buf.WriteString("\n")
-	buf.WriteString(fmt.Sprintf("Всего нарушений: %d\n", len(vList)))
-	buf.WriteString("\n")
 
-	domainViolations := make(map[string][]violations.Violation)
-	for _, v := range vList {
-		domain := domainMap[v.SiteID]
-		domainViolations[domain] = append(domainViolations[domain], v)
+	stats, err := h.violationsSvc.GetDomainStats(c.Context(), id)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch violations"})
+	}
+	if stats == nil {
+		stats = []violations.DomainStats{}
 	}
 
-	for domain, viols := range domainViolations {
-		buf.WriteString(fmt.Sprintf("=== %s (%d) ===\n", domain, len(viols)))
-		for _, v := range viols {
-			buf.WriteString(fmt.Sprintf("  %s\n", v.PageURL))
-		}
-		buf.WriteString("\n")
+	var total int64
+	for _, s := range stats {
+		total += s.ViolationsCount
 	}
 
-	filename := fmt.Sprintf("violations_%s.txt", content.Title)
-	c.Set("Content-Type", "text/plain; charset=utf-8")
-	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	return c.JSON(ViolationsByDomainResponse{
+		Items: stats,
+		Total: total,
+	})
+}
 
-	return c.Send(buf.Bytes())
+type TimelineEntryResponse struct {
+	PageID      string  `json:"page_id"`
+	SiteID      string  `json:"site_id"`
+	Domain      string  `json:"domain"`
+	URL         string  `json:"url"`
+	Title       string  `json:"title"`
+	MatchType   string  `json:"match_type"`
+	FirstSeenAt string  `json:"first_seen_at"`
+	LastSeenAt  string  `json:"last_seen_at"`
+	RemovedAt   *string `json:"removed_at,omitempty"`
 }
 
-// ExportCSV godoc
-// @Summary Export content list to CSV
-// @Description Export all content matching filters to CSV file
-// @Tags content
-// @Produce text/csv
-// @Param title query string false "Search by title"
-// @Param kinopoisk_id query string false "Filter by Kinopoisk ID"
-// @Param imdb_id query string false "Filter by IMDB ID"
-// @Param mal_id query string false "Filter by MAL ID"
-// @Param shikimori_id query string false "Filter by Shikimori ID"
-// @Param mydramalist_id query string false "Filter by MyDramaList ID"
-// @Param has_violations query string false "Filter by violations presence (true/false)"
-// @Param sort_by query string false "Sort by field" Enums(violations_count, created_at) default(violations_count)
-// @Param sort_order query string false "Sort order" Enums(asc, desc) default(desc)
-// @Success 200 {file} file
-// @Router /api/content/export [get]
-func (h *ContentHandler) ExportCSV(c *fiber.Ctx) error {
-	userID := middleware.GetUserID(c)
-	isAdmin := middleware.IsAdmin(c)
+type TimelineResponse struct {
+	Items []TimelineEntryResponse `json:"items"`
+	Total int                     `json:"total"`
+}
 
-	title := c.Query("title")
-	kinopoiskID := c.Query("kinopoisk_id")
-	imdbID := c.Query("imdb_id")
-	malID := c.Query("mal_id")
-	shikimoriID := c.Query("shikimori_id")
-	mydramalistID := c.Query("mydramalist_id")
-	hasViolationsStr := c.Query("has_violations")
-	sortBy := c.Query("sort_by", "violations_count")
-	sortOrder := c.Query("sort_order", "desc")
+// GetTimeline godoc
+// @Summary Get content violations timeline
+// @Description Lifecycle of every violation of the content in chronological order: when the page was first found, when it was last confirmed and, if a recalculation stopped finding it, when it was removed. A page that disappeared and came back has several entries
+// @Tags content
+// @Produce json
+// @Param id path string true "Content ID"
+// @Success 200 {object} TimelineResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/content/{id}/timeline [get]
+func (h *ContentHandler) GetTimeline(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.checkContentAccess(c, id); err != nil {
+		return err
+	}
+
+	entries, err := h.violationsSvc.GetTimeline(c.Context(), id)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch timeline"})
+	}
+
+	siteIDs := make(map[string]bool)
+	for _, e := range entries {
+		siteIDs[e.SiteID] = true
+	}
+	domainMap := h.siteDomains(c.Context(), siteIDs)
+
+	items := make([]TimelineEntryResponse, len(entries))
+	for i, e := range entries {
+		items[i] = TimelineEntryResponse{
+			PageID:      e.PageID,
+			SiteID:      e.SiteID,
+			Domain:      domainMap[e.SiteID],
+			URL:         e.PageURL,
+			Title:       e.PageTitle,
+			MatchType:   string(e.MatchType),
+			FirstSeenAt: e.FirstSeenAt.Format("2006-01-02T15:04:05Z"),
+			LastSeenAt:  e.LastSeenAt.Format("2006-01-02T15:04:05Z"),
+		}
+		if e.RemovedAt != nil {
+			removedAt := e.RemovedAt.Format("2006-01-02T15:04:05Z")
+			items[i].RemovedAt = &removedAt
+		}
+	}
+
+	return c.JSON(TimelineResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+func hasSeasons(stats []violations.SeasonStats) bool {
+	for _, s := range stats {
+		if s.Season > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *ContentHandler) getSiteDomainsMap(ctx context.Context, vList []violations.Violation) map[string]string {
+	siteIDs := make(map[string]bool)
+	for _, v := range vList {
+		siteIDs[v.SiteID] = true
+	}
+	return h.siteDomains(ctx, siteIDs)
+}
+
+func (h *ContentHandler) siteDomains(ctx context.Context, siteIDs map[string]bool) map[string]string {
+	ids := make([]string, 0, len(siteIDs))
+	for id := range siteIDs {
+		ids = append(ids, id)
+	}
+
+	sites, _ := h.siteRepo.FindByIDs(ctx, ids)
+
+	domainMap := make(map[string]string)
+	for _, site := range sites {
+		domainMap[site.ID.Hex()] = site.Domain
+	}
+	return domainMap
+}
+
+// ExportViolationsCSV godoc
+// @Summary Export violations to CSV
+// @Description Export all violations for content to CSV file
+// @Tags content
+// @Produce text/csv
+// @Param id path string true "Content ID"
+// @Param delimiter query string false "Field delimiter" Enums(",", ";", "|", tab) default(,)
+// @Param columns query string false "Comma-separated columns in output order: domain, url, title, match_type, evidence, found_at (default: all)"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/content/{id}/violations/export [get]
+func (h *ContentHandler) ExportViolationsCSV(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	layout, err := newCSVLayout(violationCSVColumns, c.Query("delimiter"), c.Query("columns"))
+	if err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: err.Error()})
+	}
+
+	content, err := h.checkContentAccess(c, id)
+	if err != nil {
+		return err
+	}
+
+	groups, err := h.violationsSvc.GetDomainStats(c.Context(), id)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch violations"})
+	}
+	domainMap := domainsBySite(groups)
+
+	filename := fmt.Sprintf("violations_%s.csv", content.Title)
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+	// Тело пишется после выхода из хендлера — fiber.Ctx внутри использовать нельзя
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		w.Write([]byte{0xEF, 0xBB, 0xBF})
+
+		writer := csv.NewWriter(w)
+		writer.Comma = layout.comma
+		writer.Write(layout.header())
+
+		rows := 0
+		err := h.violationsSvc.ForEachByContentID(context.Background(), id, nil, func(v *violations.Violation) error {
+			writer.Write(layout.row([]string{
+				domainMap[v.SiteID],
+				v.PageURL,
+				v.PageTitle,
+				string(v.MatchType),
+				v.Evidence,
+				v.FoundAt.Format("2006-01-02 15:04:05"),
+			}))
+			rows++
+			if rows%exportFlushEvery == 0 {
+				writer.Flush()
+				if err := writer.Error(); err != nil {
+					return err
+				}
+				return w.Flush()
+			}
+			return nil
+		})
+		writer.Flush()
+		if err != nil {
+			logger.Log.Warn().Err(err).Str("content", id).Int("rows", rows).Msg("violations csv export interrupted")
+		}
+	})
+
+	return nil
+}
+
+// ExportViolationsText godoc
+// @Summary Export violations to text report
+// @Description Export all violations for content to plain text file
+// @Tags content
+// @Produce text/plain
+// @Param id path string true "Content ID"
+// @Success 200 {file} file
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/content/{id}/violations/export-text [get]
+func (h *ContentHandler) ExportViolationsText(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	content, err := h.checkContentAccess(c, id)
+	if err != nil {
+		return err
+	}
+
+	groups, err := h.violationsSvc.GetDomainStats(c.Context(), id)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch violations"})
+	}
+
+	filename := fmt.Sprintf("violations_%s.txt", content.Title)
+	c.Set("Content-Type", "text/plain; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		fmt.Fprintf(w, "Отчёт о нарушениях: %s", content.Title)
+		if content.Year > 0 {
+			fmt.Fprintf(w, " (%d)", content.Year)
+		}
+		w.WriteString("\n")
+		fmt.Fprintf(w, "Всего нарушений: %d\n", totalViolations(groups))
+		w.WriteString("\n")
+
+		if err := h.writeDomainGroups(context.Background(), w, id, groups, contentReportLayout); err != nil {
+			logger.Log.Warn().Err(err).Str("content", id).Msg("violations text export interrupted")
+		}
+	})
+
+	return nil
+}
+
+// ExportCSV godoc
+// @Summary Export content list to CSV
+// @Description Export all content matching filters to CSV file
+// @Tags content
+// @Produce text/csv
+// @Param title query string false "Search by title, original title or alias: every word must start a word in the name (case, quotes and year in parens are ignored)"
+// @Param kinopoisk_id query string false "Filter by Kinopoisk ID"
+// @Param imdb_id query string false "Filter by IMDB ID"
+// @Param mal_id query string false "Filter by MAL ID"
+// @Param shikimori_id query string false "Filter by Shikimori ID"
+// @Param mydramalist_id query string false "Filter by MyDramaList ID"
+// @Param match query string false "How external ID filters combine: all must match or any of them" Enums(all, any) default(all)
+// @Param has_violations query string false "Filter by violations presence (true/false)"
+// @Param sort_by query string false "Sort by field; relevance ranks by title similarity and needs title" Enums(violations_count, created_at, relevance) default(violations_count)
+// @Param sort_order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Param delimiter query string false "Field delimiter" Enums(",", ";", "|", tab) default(,)
+// @Param columns query string false "Comma-separated columns in output order: title, original_title, year, kinopoisk_id, imdb_id, mydramalist_id, mal_id, shikimori_id, violations_count, sites_count, created_at (default: all)"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Router /api/content/export [get]
+func (h *ContentHandler) ExportCSV(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	isAdmin := middleware.IsAdmin(c)
+
+	layout, err := newCSVLayout(contentCSVColumns, c.Query("delimiter"), c.Query("columns"))
+	if err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: err.Error()})
+	}
+
+	title := c.Query("title")
+	kinopoiskID := c.Query("kinopoisk_id")
+	imdbID := c.Query("imdb_id")
+	malID := c.Query("mal_id")
+	shikimoriID := c.Query("shikimori_id")
+	mydramalistID := c.Query("mydramalist_id")
+	idMatch := c.Query("match", repo.IDMatchAll)
+	if !isValidIDMatch(idMatch) {
+		return c.Status(400).JSON(ErrorResponse{Error: "match must be all or any"})
+	}
+	hasViolationsStr := c.Query("has_violations")
+	sortBy := c.Query("sort_by", "violations_count")
+	sortOrder := c.Query("sort_order", "desc")
 
 	var hasViolations *bool
 	if hasViolationsStr == "true" {
@@ -549,6 +1092,7 @@ func (h *ContentHandler) ExportCSV(c *fiber.Ctx) error {
 		MALID:         malID,
 		ShikimoriID:   shikimoriID,
 		MyDramaListID: mydramalistID,
+		IDMatch:       idMatch,
 		HasViolations: hasViolations,
 		SortBy:        sortBy,
 		SortOrder:     sortOrder,
@@ -557,7 +1101,6 @@ func (h *ContentHandler) ExportCSV(c *fiber.Ctx) error {
 	}
 
 	var contents []repo.Content
-	var err error
 
 	if isAdmin {
 		contents, _, err = h.contentRepo.FindAll(c.Context(), filter)
@@ -583,17 +1126,18 @@ func (h *ContentHandler) ExportCSV(c *fiber.Ctx) error {
 
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
+	writer.Comma = layout.comma
 
 	buf.Write([]byte{0xEF, 0xBB, 0xBF})
 
-	writer.Write([]string{"Название", "Оригинальное название", "Год выхода", "КиноПоиск ID", "IMDb ID", "MDL ID", "MAL ID", "Shikimori ID", "Нарушений", "Сайтов", "Добавлен"})
+	writer.Write(layout.header())
 
 	for _, content := range contents {
 		createdAt := ""
 		if !content.CreatedAt.IsZero() {
 			createdAt = content.CreatedAt.Format("2006-01-02 15:04:05")
 		}
-		writer.Write([]string{
+		writer.Write(layout.row([]string{
 			content.Title,
 			content.OriginalTitle,
 			strconv.Itoa(content.Year),
@@ -605,7 +1149,7 @@ func (h *ContentHandler) ExportCSV(c *fiber.Ctx) error {
 			strconv.FormatInt(content.ViolationsCount, 10),
 			strconv.FormatInt(content.SitesCount, 10),
 			createdAt,
-		})
+		}))
 	}
 
 	writer.Flush()
@@ -621,13 +1165,15 @@ func (h *ContentHandler) ExportCSV(c *fiber.Ctx) error {
 // @Description Export violations for all content matching filters to plain text file
 // @Tags content
 // @Produce text/plain
-// @Param title query string false "Search by title"
+// @Param title query string false "Search by title, original title or alias: every word must start a word in the name (case, quotes and year in parens are ignored)"
 // @Param kinopoisk_id query string false "Filter by Kinopoisk ID"
 // @Param imdb_id query string false "Filter by IMDB ID"
 // @Param mal_id query string false "Filter by MAL ID"
 // @Param shikimori_id query string false "Filter by Shikimori ID"
 // @Param mydramalist_id query string false "Filter by MyDramaList ID"
+// @Param match query string false "How external ID filters combine: all must match or any of them" Enums(all, any) default(all)
 // @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
 // @Router /api/content/violations/export-text [get]
 func (h *ContentHandler) ExportAllViolationsText(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
@@ -639,6 +1185,10 @@ func (h *ContentHandler) ExportAllViolationsText(c *fiber.Ctx) error {
 	malID := c.Query("mal_id")
 	shikimoriID := c.Query("shikimori_id")
 	mydramalistID := c.Query("mydramalist_id")
+	idMatch := c.Query("match", repo.IDMatchAll)
+	if !isValidIDMatch(idMatch) {
+		return c.Status(400).JSON(ErrorResponse{Error: "match must be all or any"})
+	}
 
 	hasViolations := true
 	filter := repo.ContentFilter{
@@ -648,6 +1198,7 @@ func (h *ContentHandler) ExportAllViolationsText(c *fiber.Ctx) error {
 		MALID:         malID,
 		ShikimoriID:   shikimoriID,
 		MyDramaListID: mydramalistID,
+		IDMatch:       idMatch,
 		HasViolations: &hasViolations,
 		SortBy:        "violations_count",
 		SortOrder:     "desc",
@@ -680,50 +1231,315 @@ func (h *ContentHandler) ExportAllViolationsText(c *fiber.Ctx) error {
 		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch content"})
 	}
 
-	var buf bytes.Buffer
-	totalViolations := 0
-
+	// Группы по доменам считаются заранее: заголовок отчёта содержит итоги,
+	// а сами URL потом читаются курсором по одной группе
+	type contentGroups struct {
+		content repo.Content
+		groups  []violations.DomainStats
+	}
+	var reports []contentGroups
+	var total int64
 	for _, content := range contents {
-		vList, err := h.violationsSvc.GetAllByContentID(c.Context(), content.ID.Hex())
-		if err != nil || len(vList) == 0 {
+		groups, err := h.violationsSvc.GetDomainStats(c.Context(), content.ID.Hex())
+		if err != nil || len(groups) == 0 {
 			continue
 		}
+		reports = append(reports, contentGroups{content: content, groups: groups})
+		total += totalViolations(groups)
+	}
 
-		domainMap := h.getSiteDomainsMap(c.Context(), vList)
+	c.Set("Content-Type", "text/plain; charset=utf-8")
+	c.Set("Content-Disposition", "attachment; filename=\"violations_report.txt\"")
 
-		buf.WriteString(fmt.Sprintf("=== %s", content.Title))
-		if content.Year > 0 {
-			buf.WriteString(fmt.Sprintf(" (%d)", content.Year))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		fmt.Fprintf(w, "Отчёт о нарушениях\nВсего контента: %d\nВсего нарушений: %d\n\n", len(contents), total)
+
+		ctx := context.Background()
+		for _, r := range reports {
+			fmt.Fprintf(w, "=== %s", r.content.Title)
+			if r.content.Year > 0 {
+				fmt.Fprintf(w, " (%d)", r.content.Year)
+			}
+			fmt.Fprintf(w, " [%d] ===\n", totalViolations(r.groups))
+
+			if err := h.writeDomainGroups(ctx, w, r.content.ID.Hex(), r.groups, catalogReportLayout); err != nil {
+				logger.Log.Warn().Err(err).Str("content", r.content.ID.Hex()).Msg("violations report export interrupted")
+				return
+			}
+			w.WriteString("\n")
 		}
-		buf.WriteString(fmt.Sprintf(" [%d] ===\n", len(vList)))
+	})
+
+	return nil
+}
+
+// violationsTextLayout — оформление групп по доменам в текстовых отчётах
+type violationsTextLayout struct {
+	domainHeader string // формат с доменом и числом нарушений
+	urlPrefix    string
+	groupSuffix  string
+}
+
+var (
+	contentReportLayout = violationsTextLayout{domainHeader: "=== %s (%d) ===\n", urlPrefix: "  ", groupSuffix: "\n"}
+	catalogReportLayout = violationsTextLayout{domainHeader: "  %s (%d):\n", urlPrefix: "    "}
+)
 
-		domainViolations := make(map[string][]violations.Violation)
-		for _, v := range vList {
-			domain := domainMap[v.SiteID]
-			domainViolations[domain] = append(domainViolations[domain], v)
+// writeDomainGroups пишет URL нарушений контента по группам доменов, читая каждую группу курсором
+func (h *ContentHandler) writeDomainGroups(ctx context.Context, w *bufio.Writer, contentID string, groups []violations.DomainStats, layout violationsTextLayout) error {
+	for _, g := range groups {
+		fmt.Fprintf(w, layout.domainHeader, g.Domain, g.ViolationsCount)
+		err := h.violationsSvc.ForEachByContentID(ctx, contentID, g.SiteIDs, func(v *violations.Violation) error {
+			_, err := w.WriteString(layout.urlPrefix + v.PageURL + "\n")
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		w.WriteString(layout.groupSuffix)
+		if err := w.Flush(); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		for domain, viols := range domainViolations {
-			buf.WriteString(fmt.Sprintf("  %s (%d):\n", domain, len(viols)))
-			for _, v := range viols {
-				buf.WriteString(fmt.Sprintf("    %s\n", v.PageURL))
-			}
+func totalViolations(groups []violations.DomainStats) int64 {
+	var total int64
+	for _, g := range groups {
+		total += g.ViolationsCount
+	}
+	return total
+}
+
+func domainsBySite(groups []violations.DomainStats) map[string]string {
+	domains := make(map[string]string)
+	for _, g := range groups {
+		for _, siteID := range g.SiteIDs {
+			domains[siteID] = g.Domain
 		}
-		buf.WriteString("\n")
-		totalViolations += len(vList)
 	}
+	return domains
+}
 
-	header := fmt.Sprintf("Отчёт о нарушениях\nВсего контента: %d\nВсего нарушений: %d\n\n", len(contents), totalViolations)
+const maxContentAliases = 50
 
-	c.Set("Content-Type", "text/plain; charset=utf-8")
-	c.Set("Content-Disposition", "attachment; filename=\"violations_report.txt\"")
+// normalizeAliases обрезает пробелы, убирает пустые и повторяющиеся (без учёта регистра) алиасы
+func normalizeAliases(aliases []string) []string {
+	seen := make(map[string]bool, len(aliases))
+	var result []string
+	for _, alias := range aliases {
+		alias = strings.Join(strings.Fields(alias), " ")
+		key := strings.ToLower(alias)
+		if alias == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, alias)
+	}
+	return result
+}
+
+// normalizeLanguage приводит код языка и страны к нижнему и верхнему регистру;
+// непустая строка ошибки — значение не похоже на ISO-код
+func normalizeLanguage(language, region string) (string, string, string) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if language != "" && !isLetterCode(language, 2, 3) {
+		return "", "", "language must be an ISO 639 code, e.g. ko"
+	}
+	if region != "" && !isLetterCode(region, 2, 2) {
+		return "", "", "region must be an ISO 3166-1 alpha-2 code, e.g. KR"
+	}
+	return language, region, ""
+}
+
+func isLetterCode(s string, minLen, maxLen int) bool {
+	if len(s) < minLen || len(s) > maxLen {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+type UpdateLanguageRequest struct {
+	Language string `json:"language"` // ISO 639-1; пусто — удалить
+	Region   string `json:"region"`   // ISO 3166-1 alpha-2; пусто — удалить
+}
+
+// UpdateLanguage godoc
+// @Summary Set content language and region
+// @Description Tag content with original language and production region. For anime and Asian dramas the matcher prefers MAL/Shikimori/MyDramaList stages and skips title-only matches that often hit same-titled local films. Violations are recalculated in background
+// @Tags content
+// @Accept json
+// @Produce json
+// @Param id path string true "Content ID"
+// @Param request body UpdateLanguageRequest true "Language and region"
+// @Success 200 {object} ContentWithStats
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/content/{id}/language [put]
+func (h *ContentHandler) UpdateLanguage(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.checkContentAccess(c, id); err != nil {
+		return err
+	}
+
+	var req UpdateLanguageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	language, region, errMsg := normalizeLanguage(req.Language, req.Region)
+	if errMsg != "" {
+		return c.Status(400).JSON(ErrorResponse{Error: errMsg})
+	}
+
+	if err := h.contentRepo.UpdateLanguage(c.Context(), id, language, region); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to update language"})
+	}
+
+	content, err := h.contentRepo.FindByID(c.Context(), id)
+	if err != nil || content == nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch content"})
+	}
+
+	go h.refreshViolationsForContent(content)
+
+	return c.JSON(ContentWithStats{
+		Content:         *content,
+		ViolationsCount: content.ViolationsCount,
+		SitesCount:      content.SitesCount,
+		MatchTypeCounts: content.MatchTypeCounts,
+	})
+}
+
+// normalizePriority хранит normal как пустое значение;
+// непустая строка ошибки — неизвестный приоритет
+func normalizePriority(priority string) (string, string) {
+	priority = strings.ToLower(strings.TrimSpace(priority))
+	if priority == "" || priority == repo.ContentPriorityNormal {
+		return "", ""
+	}
+	if !repo.IsValidContentPriority(priority) {
+		return "", "priority must be one of: high, normal, low"
+	}
+	return priority, ""
+}
+
+type UpdatePriorityRequest struct {
+	Priority string `json:"priority"` // high, normal или low; пусто — normal
+}
+
+// UpdatePriority godoc
+// @Summary Set content priority
+// @Description Priority controls how often the scheduler recalculates violations: high every 6 hours, normal daily, low weekly. The content is recalculated on the next scheduler pass after the change
+// @Tags content
+// @Accept json
+// @Produce json
+// @Param id path string true "Content ID"
+// @Param request body UpdatePriorityRequest true "Priority"
+// @Success 200 {object} ContentWithStats
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/content/{id}/priority [put]
+func (h *ContentHandler) UpdatePriority(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.checkContentAccess(c, id); err != nil {
+		return err
+	}
+
+	var req UpdatePriorityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	priority, errMsg := normalizePriority(req.Priority)
+	if errMsg != "" {
+		return c.Status(400).JSON(ErrorResponse{Error: errMsg})
+	}
+
+	if err := h.contentRepo.UpdatePriority(c.Context(), id, priority); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to update priority"})
+	}
+
+	content, err := h.contentRepo.FindByID(c.Context(), id)
+	if err != nil || content == nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch content"})
+	}
+
+	return c.JSON(ContentWithStats{
+		Content:         *content,
+		ViolationsCount: content.ViolationsCount,
+		SitesCount:      content.SitesCount,
+		MatchTypeCounts: content.MatchTypeCounts,
+	})
+}
 
-	return c.Send(append([]byte(header), buf.Bytes()...))
+type UpdateAliasesRequest struct {
+	Aliases []string `json:"aliases"` // полный список; пустой — удалить все алиасы
+}
+
+// UpdateAliases godoc
+// @Summary Replace content aliases
+// @Description Set alternate titles used by the title and title+year match stages. Violations are recalculated in background
+// @Tags content
+// @Accept json
+// @Produce json
+// @Param id path string true "Content ID"
+// @Param request body UpdateAliasesRequest true "Aliases"
+// @Success 200 {object} ContentWithStats
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/content/{id}/aliases [put]
+func (h *ContentHandler) UpdateAliases(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.checkContentAccess(c, id); err != nil {
+		return err
+	}
+
+	var req UpdateAliasesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	aliases := normalizeAliases(req.Aliases)
+	if len(aliases) > maxContentAliases {
+		return c.Status(400).JSON(ErrorResponse{Error: fmt.Sprintf("too many aliases, max %d", maxContentAliases)})
+	}
+
+	if err := h.contentRepo.UpdateAliases(c.Context(), id, aliases); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to update aliases"})
+	}
+
+	content, err := h.contentRepo.FindByID(c.Context(), id)
+	if err != nil || content == nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch content"})
+	}
+
+	go h.refreshViolationsForContent(content)
+
+	return c.JSON(ContentWithStats{
+		Content:         *content,
+		ViolationsCount: content.ViolationsCount,
+		SitesCount:      content.SitesCount,
+		MatchTypeCounts: content.MatchTypeCounts,
+	})
 }
 
 // Delete godoc
 // @Summary Delete content
-// @Description Remove content from tracking (unlinks from user, deletes if no other users)
+// @Description Remove content from tracking: unlinks it from the user while others still track it, otherwise moves it to trash, where it can be restored until the retention period ends
 // @Tags content
 // @Param id path string true "Content ID"
 // @Success 204
@@ -740,31 +1556,69 @@ func (h *ContentHandler) Delete(c *fiber.Ctx) error {
 		return err
 	}
 
-	userOID, _ := primitive.ObjectIDFromHex(userID)
+	if !isAdmin && h.unlinkShared(c.Context(), userID, content.ID) {
+		return c.SendStatus(204)
+	}
 
-	if !isAdmin {
-		if err := h.userContentRepo.Unlink(c.Context(), userOID, content.ID); err != nil {
-			return c.Status(500).JSON(ErrorResponse{Error: "failed to unlink content"})
-		}
+	if _, err := h.trash.TrashContent(c.Context(), id, userID); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to delete content"})
+	}
 
-		count, _ := h.userContentRepo.CountByContentID(c.Context(), content.ID)
-		if count > 0 {
-			return c.SendStatus(204)
-		}
+	return c.SendStatus(204)
+}
+
+// unlinkShared отвязывает контент от пользователя, если его отслеживает кто-то ещё.
+// У последнего владельца связь сохраняется: контент уходит в корзину и восстанавливается с ней
+func (h *ContentHandler) unlinkShared(ctx context.Context, userID string, contentID primitive.ObjectID) bool {
+	count, _ := h.userContentRepo.CountByContentID(ctx, contentID)
+	if count <= 1 {
+		return false
 	}
+	userOID, _ := primitive.ObjectIDFromHex(userID)
+	return h.userContentRepo.Unlink(ctx, userOID, contentID) == nil
+}
 
-	h.violationsSvc.DeleteByContentID(c.Context(), id)
-	h.userContentRepo.DeleteByContentID(c.Context(), content.ID)
+// Restore godoc
+// @Summary Restore deleted content
+// @Description Take content out of trash and recalculate its violations in background
+// @Tags content
+// @Produce json
+// @Param id path string true "Content ID"
+// @Success 200 {object} repo.Content
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/content/{id}/restore [post]
+func (h *ContentHandler) Restore(c *fiber.Ctx) error {
+	id := c.Params("id")
 
-	if err := h.contentRepo.Delete(c.Context(), id); err != nil {
-		return c.Status(500).JSON(ErrorResponse{Error: "failed to delete content"})
+	content, err := h.contentRepo.FindDeletedByID(c.Context(), id)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch content"})
+	}
+	if content == nil {
+		return c.Status(404).JSON(ErrorResponse{Error: "deleted content not found"})
+	}
+	if !h.hasAccess(c.Context(), middleware.GetUserID(c), middleware.IsAdmin(c), content.ID) {
+		return c.Status(403).JSON(ErrorResponse{Error: "access denied"})
 	}
 
-	return c.SendStatus(204)
+	restored, err := h.trash.RestoreContent(c.Context(), content)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to restore content"})
+	}
+	if !restored {
+		return c.Status(404).JSON(ErrorResponse{Error: "deleted content not found"})
+	}
+
+	return c.JSON(content)
 }
 
 type CheckViolationsRequest struct {
 	ContentIDs []string `json:"content_ids"`
+	// RequireStrongSignal — пересчитать со строгой политикой: без совпадений только по названию
+	RequireStrongSignal bool `json:"require_strong_signal,omitempty"`
+	// MaxHits — потолок совпадений одного поискового запроса вместо MEILI_MAX_HITS; 0 — MEILI_MAX_HITS
+	MaxHits int64 `json:"max_hits,omitempty"`
 }
 
 type CheckViolationsResponse struct {
@@ -821,20 +1675,40 @@ func (h *ContentHandler) CreateBatch(c *fiber.Ctx) error {
 			failed++
 			continue
 		}
+		language, region, errMsg := normalizeLanguage(item.Language, item.Region)
+		if errMsg != "" {
+			failed++
+			continue
+		}
+		priority, errMsg := normalizePriority(item.Priority)
+		if errMsg != "" {
+			failed++
+			continue
+		}
 
 		content := &repo.Content{
 			Title:         item.Title,
 			OriginalTitle: item.OriginalTitle,
+			Aliases:       normalizeAliases(item.Aliases),
 			Year:          item.Year,
 			KinopoiskID:   item.KinopoiskID,
 			IMDBID:        item.IMDBID,
 			MALID:         item.MALID,
 			ShikimoriID:   item.ShikimoriID,
 			MyDramaListID: item.MyDramaListID,
+			Language:      language,
+			Region:        region,
+			Priority:      priority,
 		}
 
 		existing, _ := h.contentRepo.FindByExternalID(c.Context(), content)
 		if existing != nil {
+			if existing.DeletedAt != nil {
+				if _, err := h.trash.RestoreContent(c.Context(), existing); err != nil {
+					failed++
+					continue
+				}
+			}
 			h.contentRepo.EnrichExternalIDs(c.Context(), existing.ID, content)
 
 			if err := h.userContentRepo.Link(c.Context(), userOID, existing.ID); err == nil {
@@ -880,7 +1754,7 @@ type DeleteContentResponse struct {
 
 // CheckViolations godoc
 // @Summary Check violations for content
-// @Description Refresh violation stats for selected content items
+// @Description Refresh violation stats for selected content items. With require_strong_signal only external ID and title+year matches are kept, regardless of the deployment policy. max_hits (1 to the Meilisearch maxTotalHits limit) overrides the per-query match cap for this request; 0 or omitted keeps the configured cap.
 // @Tags content
 // @Accept json
 // @Produce json
@@ -901,6 +1775,15 @@ func (h *ContentHandler) CheckViolations(c *fiber.Ctx) error {
 		return c.Status(400).JSON(ErrorResponse{Error: "content_ids is required"})
 	}
 
+	if req.MaxHits < 0 || req.MaxHits > meili.MaxTotalHits {
+		return c.Status(400).JSON(ErrorResponse{Error: fmt.Sprintf("max_hits must be between 1 and %d, or 0 for the default", meili.MaxTotalHits)})
+	}
+
+	var ctx context.Context = c.Context()
+	if req.RequireStrongSignal {
+		ctx = violations.WithRequireStrongSignal(ctx)
+	}
+
 	var checked int64
 	for _, id := range req.ContentIDs {
 		contentOID, err := primitive.ObjectIDFromHex(id)
@@ -917,17 +1800,20 @@ func (h *ContentHandler) CheckViolations(c *fiber.Ctx) error {
 			continue
 		}
 
-		_, err = h.violationsSvc.RefreshForContent(c.Context(), violations.ContentInfo{
+		_, err = h.violationsSvc.RefreshForContent(ctx, violations.ContentInfo{
 			ID:            id,
 			Title:         content.Title,
 			OriginalTitle: content.OriginalTitle,
+			Aliases:       content.Aliases,
 			Year:          content.Year,
 			KinopoiskID:   content.KinopoiskID,
 			IMDBID:        content.IMDBID,
 			MALID:         content.MALID,
 			ShikimoriID:   content.ShikimoriID,
 			MyDramaListID: content.MyDramaListID,
-		})
+			Language:      content.Language,
+			Region:        content.Region,
+		}, req.MaxHits)
 		if err == nil {
 			checked++
 		}
@@ -938,7 +1824,7 @@ func (h *ContentHandler) CheckViolations(c *fiber.Ctx) error {
 
 // DeleteBulk godoc
 // @Summary Delete multiple content items
-// @Description Remove multiple content items from tracking
+// @Description Remove multiple content items from tracking; items nobody else tracks go to trash and can be restored
 // @Tags content
 // @Accept json
 // @Produce json
@@ -959,8 +1845,6 @@ func (h *ContentHandler) DeleteBulk(c *fiber.Ctx) error {
 		return c.Status(400).JSON(ErrorResponse{Error: "content_ids is required"})
 	}
 
-	userOID, _ := primitive.ObjectIDFromHex(userID)
-
 	var deleted int64
 	for _, id := range req.ContentIDs {
 		contentOID, err := primitive.ObjectIDFromHex(id)
@@ -972,21 +1856,186 @@ func (h *ContentHandler) DeleteBulk(c *fiber.Ctx) error {
 			continue
 		}
 
-		if !isAdmin {
-			h.userContentRepo.Unlink(c.Context(), userOID, contentOID)
-			count, _ := h.userContentRepo.CountByContentID(c.Context(), contentOID)
-			if count > 0 {
-				deleted++
-				continue
-			}
+		if !isAdmin && h.unlinkShared(c.Context(), userID, contentOID) {
+			deleted++
+			continue
 		}
 
-		h.violationsSvc.DeleteByContentID(c.Context(), id)
-		h.userContentRepo.DeleteByContentID(c.Context(), contentOID)
-		if err := h.contentRepo.Delete(c.Context(), id); err == nil {
+		if ok, err := h.trash.TrashContent(c.Context(), id, userID); err == nil && ok {
 			deleted++
 		}
 	}
 
 	return c.JSON(DeleteContentResponse{DeletedCount: deleted})
 }
+
+const maxCountsContentIDs = 500
+
+type ContentCountsRequest struct {
+	ContentIDs []string `json:"content_ids"`
+}
+
+type ContentCountsResponse struct {
+	Counts map[string]repo.ContentCounts `json:"counts"`
+}
+
+// Counts godoc
+// @Summary Get cached violation counts for many content items
+// @Description Return violations_count, sites_count and last_violation_at for up to 500 content IDs in one request. Values come from the counters cached on content by violation refreshes. Unknown, invalid and inaccessible IDs are omitted from the map
+// @Tags content
+// @Accept json
+// @Produce json
+// @Param request body ContentCountsRequest true "Content IDs"
+// @Success 200 {object} ContentCountsResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/content/counts [post]
+func (h *ContentHandler) Counts(c *fiber.Ctx) error {
+	var req ContentCountsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	if len(req.ContentIDs) == 0 {
+		return c.Status(400).JSON(ErrorResponse{Error: "content_ids is required"})
+	}
+	if len(req.ContentIDs) > maxCountsContentIDs {
+		return c.Status(400).JSON(ErrorResponse{Error: "too many content_ids, max 500"})
+	}
+
+	allowed, err := visibleContentIDs(c, h.userContentRepo)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to get user content"})
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(req.ContentIDs))
+	for _, id := range req.ContentIDs {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			continue
+		}
+		if allowed != nil && !allowed[id] {
+			continue
+		}
+		ids = append(ids, oid)
+	}
+
+	counts, err := h.contentRepo.GetCounts(c.Context(), ids)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to get content counts"})
+	}
+
+	return c.JSON(ContentCountsResponse{Counts: counts})
+}
+
+type ExplainMatchResponse struct {
+	ContentID string                    `json:"content_id"`
+	PageID    string                    `json:"page_id"`
+	PageURL   string                    `json:"page_url"`
+	PageTitle string                    `json:"page_title"`
+	Matched   bool                      `json:"matched"`
+	Stages    []violations.StageVerdict `json:"stages"`
+	// Recorded — сохранённое нарушение по этой странице, если оно есть
+	Recorded *RecordedMatch `json:"recorded,omitempty"`
+}
+
+// RecordedMatch — чем страница совпала при последнем пересчёте
+type RecordedMatch struct {
+	MatchType   string `json:"match_type"`
+	Evidence    string `json:"evidence,omitempty"`
+	MatchQuery  string `json:"match_query,omitempty"`
+	MatchFilter string `json:"match_filter,omitempty"`
+	FoundAt     string `json:"found_at"`
+}
+
+// Explain godoc
+// @Summary Explain page match
+// @Description Run every matcher stage against a single indexed page of a site accessible to the caller and return a per-stage verdict with normalized title comparison. If a violation is stored for the page, its stage and Meilisearch query/filter are returned in recorded
+// @Tags content
+// @Produce json
+// @Param id path string true "Content ID"
+// @Param page_url query string true "Indexed page URL"
+// @Success 200 {object} ExplainMatchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/content/{id}/explain [post]
+func (h *ContentHandler) Explain(c *fiber.Ctx) error {
+	id := c.Params("id")
+	pageURL := c.Query("page_url")
+	if pageURL == "" {
+		return c.Status(400).JSON(ErrorResponse{Error: "page_url is required"})
+	}
+
+	content, err := h.checkContentAccess(c, id)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.pageRepo.FindByURL(c.Context(), pageURL)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch page"})
+	}
+	if page == nil {
+		return c.Status(404).JSON(ErrorResponse{Error: "page not indexed"})
+	}
+	// страницы чужих сайтов не отличаются от непроиндексированных
+	hasAccess, err := h.siteRepo.HasUserAccess(c.Context(), page.SiteID, middleware.GetUserID(c), middleware.IsAdmin(c), h.userSiteRepo)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to check access"})
+	}
+	if !hasAccess {
+		return c.Status(404).JSON(ErrorResponse{Error: "page not indexed"})
+	}
+
+	stages := h.violationsSvc.ExplainMatch(violations.ContentInfo{
+		ID:            id,
+		Title:         content.Title,
+		OriginalTitle: content.OriginalTitle,
+		Aliases:       content.Aliases,
+		Year:          content.Year,
+		KinopoiskID:   content.KinopoiskID,
+		IMDBID:        content.IMDBID,
+		MALID:         content.MALID,
+		ShikimoriID:   content.ShikimoriID,
+		MyDramaListID: content.MyDramaListID,
+		Language:      content.Language,
+		Region:        content.Region,
+	}, meili.NewPageDocument(page, ""))
+
+	matched := false
+	for _, st := range stages {
+		if st.Verdict == violations.VerdictMatched {
+			matched = true
+			break
+		}
+	}
+
+	stored, err := h.violationsSvc.GetByContentAndPage(c.Context(), id, page.ID.Hex())
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch violation"})
+	}
+	var recorded *RecordedMatch
+	if stored != nil {
+		recorded = &RecordedMatch{
+			MatchType:   string(stored.MatchType),
+			Evidence:    stored.Evidence,
+			MatchQuery:  stored.MatchQuery,
+			MatchFilter: stored.MatchFilter,
+			FoundAt:     stored.FoundAt.Format("2006-01-02T15:04:05Z"),
+		}
+	}
+
+	return c.JSON(ExplainMatchResponse{
+		ContentID: id,
+		PageID:    page.ID.Hex(),
+		PageURL:   page.URL,
+		PageTitle: page.Title,
+		Matched:   matched,
+		Stages:    stages,
+		Recorded:  recorded,
+	})
+}
+
+func isValidIDMatch(m string) bool {
+	return m == repo.IDMatchAll || m == repo.IDMatchAny
+}
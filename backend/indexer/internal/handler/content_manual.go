@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/violations"
+	"github.com/video-analitics/indexer/internal/middleware"
+	"github.com/video-analitics/indexer/internal/service"
+)
+
+type AddViolationRequest struct {
+	URL string `json:"url"`
+	// Force — записать нарушение, даже если ни один этап матчера страницу не принял
+	Force bool `json:"force,omitempty"`
+}
+
+type AddViolationResponse struct {
+	Violation ViolationResponse `json:"violation"`
+	// Created — false, если нарушение по этой странице уже было записано
+	Created bool `json:"created"`
+	// Matched — страница совпала с контентом по одному из этапов матчера
+	Matched bool                      `json:"matched"`
+	Stages  []violations.StageVerdict `json:"stages"`
+}
+
+func (h *ContentHandler) SetManualViolations(svc *service.ManualViolationService) {
+	h.manualViolations = svc
+}
+
+// AddViolation godoc
+// @Summary Add a violation URL manually
+// @Description Fetch a URL found outside the crawler through a parser, index it as a page of its monitored site and record a violation with match type manual. The site must be accessible to the caller, and redirects to another host are rejected. Without force the page must pass at least one matcher stage. Manual violations are kept by recalculations and appear in lists and exports
+// @Tags content
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Content ID"
+// @Param request body AddViolationRequest true "Page URL"
+// @Success 200 {object} AddViolationResponse
+// @Success 201 {object} AddViolationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Failure 504 {object} ErrorResponse
+// @Router /api/content/{id}/violations [post]
+func (h *ContentHandler) AddViolation(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if h.manualViolations == nil {
+		return c.Status(503).JSON(ErrorResponse{Error: "manual violations are not configured"})
+	}
+
+	var req AddViolationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+	target, ok := parseTargetURL(req.URL)
+	if !ok {
+		return c.Status(400).JSON(ErrorResponse{Error: "url must be an absolute http(s) URL"})
+	}
+	pageURL := target.String()
+
+	content, err := h.checkContentAccess(c, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.manualViolations.Add(c.Context(), violations.ContentInfo{
+		ID:            id,
+		Title:         content.Title,
+		OriginalTitle: content.OriginalTitle,
+		Aliases:       content.Aliases,
+		Year:          content.Year,
+		KinopoiskID:   content.KinopoiskID,
+		IMDBID:        content.IMDBID,
+		MALID:         content.MALID,
+		ShikimoriID:   content.ShikimoriID,
+		MyDramaListID: content.MyDramaListID,
+		Language:      content.Language,
+		Region:        content.Region,
+	}, pageURL, middleware.GetUserID(c), middleware.IsAdmin(c), req.Force)
+
+	var fetchErr *service.PageFetchError
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrSiteNotMonitored):
+		return c.Status(422).JSON(ErrorResponse{Error: "site of this url is not monitored"})
+	case errors.Is(err, service.ErrPageNotMatched):
+		return c.Status(422).JSON(ErrorResponse{Error: "page does not match content; set force to record it anyway"})
+	case errors.As(err, &fetchErr):
+		return c.Status(422).JSON(ErrorResponse{Error: fetchErr.Error()})
+	case errors.Is(err, context.DeadlineExceeded):
+		return c.Status(504).JSON(ErrorResponse{Error: "parse timed out"})
+	case errors.Is(err, service.ErrParserUnavailable):
+		logger.Log.Warn().Err(err).Str("url", pageURL).Msg("manual violation parse failed")
+		return c.Status(503).JSON(ErrorResponse{Error: "no parser available"})
+	default:
+		logger.Log.Error().Err(err).Str("url", pageURL).Msg("failed to add manual violation")
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to add violation"})
+	}
+
+	v := result.Violation
+	status := 200
+	if result.Created {
+		status = 201
+	}
+	return c.Status(status).JSON(AddViolationResponse{
+		Violation: ViolationResponse{
+			PageID:    v.PageID,
+			SiteID:    v.SiteID,
+			Domain:    h.siteDomains(c.Context(), map[string]bool{v.SiteID: true})[v.SiteID],
+			URL:       v.PageURL,
+			Title:     v.PageTitle,
+			MatchType: string(v.MatchType),
+			Evidence:  v.Evidence,
+			Season:    v.Season,
+			Episodes:  v.Episodes,
+			FoundAt:   v.FoundAt.Format("2006-01-02T15:04:05Z"),
+		},
+		Created: result.Created,
+		Matched: result.Matched,
+		Stages:  result.Stages,
+	})
+}
+
+type VerifyViolationRequest struct {
+	ContentID string `json:"content_id"`
+}
+
+type VerifyViolationResponse struct {
+	PageID    string `json:"page_id"`
+	ContentID string `json:"content_id"`
+	// Status — present: страница доступна и совпадает с контентом; removed — снята или контента больше нет
+	Status string `json:"status"`
+	// Title — заголовок страницы сейчас; пусто, если загрузить её не удалось
+	Title  string                    `json:"title,omitempty"`
+	Reason string                    `json:"reason,omitempty"`
+	Stages []violations.StageVerdict `json:"stages,omitempty"`
+	// Resolved — нарушение снято этой проверкой и попало в хронологию как снятое
+	Resolved bool `json:"resolved"`
+}
+
+// VerifyViolation godoc
+// @Summary Verify a violation against the live page
+// @Description Re-fetch the violation page through a parser, re-extract it and re-check it against the content before filing a takedown. Returns present or removed with the current title. Removed means the page is definitely gone (404/410 or parked) or no longer contains the content; such a violation is resolved, the page is deleted or re-indexed so that refreshes do not bring it back, and the violation is moved to the removal history. Pages that could not be loaded (timeout, block, captcha, no title) return 422 and stay untouched
+// @Tags content
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param pageID path string true "Page ID"
+// @Param request body VerifyViolationRequest true "Content of the violation"
+// @Success 200 {object} VerifyViolationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Failure 504 {object} ErrorResponse
+// @Router /api/violations/{pageID}/verify [post]
+func (h *ContentHandler) VerifyViolation(c *fiber.Ctx) error {
+	pageID := c.Params("pageID")
+
+	if h.manualViolations == nil {
+		return c.Status(503).JSON(ErrorResponse{Error: "manual violations are not configured"})
+	}
+
+	var req VerifyViolationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+	if req.ContentID == "" {
+		return c.Status(400).JSON(ErrorResponse{Error: "content_id is required"})
+	}
+
+	content, err := h.checkContentAccess(c, req.ContentID)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.manualViolations.Verify(c.Context(), violations.ContentInfo{
+		ID:            req.ContentID,
+		Title:         content.Title,
+		OriginalTitle: content.OriginalTitle,
+		Aliases:       content.Aliases,
+		Year:          content.Year,
+		KinopoiskID:   content.KinopoiskID,
+		IMDBID:        content.IMDBID,
+		MALID:         content.MALID,
+		ShikimoriID:   content.ShikimoriID,
+		MyDramaListID: content.MyDramaListID,
+		Language:      content.Language,
+		Region:        content.Region,
+	}, pageID)
+
+	var fetchErr *service.PageFetchError
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrViolationNotFound):
+		return c.Status(404).JSON(ErrorResponse{Error: "violation not found"})
+	case errors.As(err, &fetchErr):
+		return c.Status(422).JSON(ErrorResponse{Error: fetchErr.Error()})
+	case errors.Is(err, context.DeadlineExceeded):
+		return c.Status(504).JSON(ErrorResponse{Error: "parse timed out"})
+	case errors.Is(err, service.ErrParserUnavailable):
+		logger.Log.Warn().Err(err).Str("page", pageID).Msg("violation verify parse failed")
+		return c.Status(503).JSON(ErrorResponse{Error: "no parser available"})
+	default:
+		logger.Log.Error().Err(err).Str("page", pageID).Msg("failed to verify violation")
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to verify violation"})
+	}
+
+	status := "removed"
+	if result.Present {
+		status = "present"
+	}
+	return c.JSON(VerifyViolationResponse{
+		PageID:    pageID,
+		ContentID: req.ContentID,
+		Status:    status,
+		Title:     result.Title,
+		Reason:    result.Reason,
+		Stages:    result.Stages,
+		Resolved:  result.Resolved,
+	})
+}
+
+// VerifyAllViolations godoc
+// @Summary Verify all violations of a content item against live pages
+// @Description Start a background re-check of every current violation of the content through parsers, a few pages at a time; poll verify-all/{job_id} for progress and the summary. Removed violations are resolved and moved to the removal history; pages that could not be checked are reported as errored and left untouched. While a verification of the content is running, the same job is returned
+// @Tags content
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Content ID"
+// @Success 202 {object} VerifyJob
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/content/{id}/violations/verify-all [post]
+func (h *ContentHandler) VerifyAllViolations(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if h.manualViolations == nil {
+		return c.Status(503).JSON(ErrorResponse{Error: "manual violations are not configured"})
+	}
+
+	content, err := h.checkContentAccess(c, id)
+	if err != nil {
+		return err
+	}
+
+	info := violations.ContentInfo{
+		ID:            id,
+		Title:         content.Title,
+		OriginalTitle: content.OriginalTitle,
+		Aliases:       content.Aliases,
+		Year:          content.Year,
+		KinopoiskID:   content.KinopoiskID,
+		IMDBID:        content.IMDBID,
+		MALID:         content.MALID,
+		ShikimoriID:   content.ShikimoriID,
+		MyDramaListID: content.MyDramaListID,
+		Language:      content.Language,
+		Region:        content.Region,
+	}
+
+	job, created := h.verifyJobs.start(id)
+	if created {
+		go h.runVerifyAll(job.ID, info)
+	}
+
+	snapshot, _ := h.verifyJobs.get(job.ID)
+	return c.Status(202).JSON(snapshot)
+}
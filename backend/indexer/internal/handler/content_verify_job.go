@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/violations"
+	"github.com/video-analitics/indexer/internal/service"
+)
+
+// VerifyJob - фоновая перепроверка всех нарушений контента
+type VerifyJob struct {
+	ID        string `json:"job_id"`
+	ContentID string `json:"content_id"`
+	Status    string `json:"status"`
+	Total     int    `json:"total"`
+	Checked   int    `json:"checked"`
+	// Summary — итог по страницам; есть только у завершённой задачи
+	Summary    *service.VerifySummary `json:"summary,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+}
+
+// verifyJobs — задачи хранятся в памяти процесса и теряются при рестарте
+type verifyJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*VerifyJob
+}
+
+func newVerifyJobs() *verifyJobs {
+	return &verifyJobs{jobs: make(map[string]*VerifyJob)}
+}
+
+// start создаёт задачу; если по контенту уже идёт перепроверка, возвращает её и false
+func (j *verifyJobs) start(contentID string) (*VerifyJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.pruneLocked(time.Now())
+	for _, job := range j.jobs {
+		if job.ContentID == contentID && job.Status == ContentCheckRunning {
+			return job, false
+		}
+	}
+
+	job := &VerifyJob{
+		ID:        uuid.New().String(),
+		ContentID: contentID,
+		Status:    ContentCheckRunning,
+		StartedAt: time.Now(),
+	}
+	j.jobs[job.ID] = job
+	return job, true
+}
+
+func (j *verifyJobs) progress(id string, checked, total int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if job, ok := j.jobs[id]; ok {
+		job.Total = total
+		if checked > job.Checked {
+			job.Checked = checked
+		}
+	}
+}
+
+func (j *verifyJobs) finish(id string, summary *service.VerifySummary, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	job.FinishedAt = &now
+	if err != nil {
+		job.Status = ContentCheckFailed
+		job.Error = err.Error()
+		return
+	}
+	job.Status = ContentCheckCompleted
+	job.Summary = summary
+	job.Total = summary.Total
+	job.Checked = summary.Total
+}
+
+// get возвращает копию, чтобы не читать поля под записью фоновой горутины
+func (j *verifyJobs) get(id string) (VerifyJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return VerifyJob{}, false
+	}
+	return *job, true
+}
+
+func (j *verifyJobs) pruneLocked(now time.Time) {
+	for id, job := range j.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > contentCheckJobTTL {
+			delete(j.jobs, id)
+		}
+	}
+}
+
+func (h *ContentHandler) runVerifyAll(jobID string, content violations.ContentInfo) {
+	// Запрос уже завершён, его контекст использовать нельзя
+	summary, err := h.manualViolations.VerifyAll(context.Background(), content, service.VerifyWorkers, func(checked, total int) {
+		h.verifyJobs.progress(jobID, checked, total)
+	})
+	h.verifyJobs.finish(jobID, summary, err)
+
+	if err != nil {
+		logger.Log.Error().Err(err).Str("job_id", jobID).Str("content", content.ID).Msg("failed to verify violations")
+		return
+	}
+	logger.Log.Info().
+		Str("job_id", jobID).
+		Str("content", content.ID).
+		Int("total", summary.Total).
+		Int("present", summary.Present).
+		Int("removed", summary.Removed).
+		Int("errored", summary.Errored).
+		Msg("violations verification finished")
+}
+
+// GetVerifyAllJob godoc
+// @Summary Get violations verification status
+// @Description Progress of a background verification started by verify-all; the summary is filled in once it completes
+// @Tags content
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Content ID"
+// @Param job_id path string true "Job ID"
+// @Success 200 {object} VerifyJob
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/content/{id}/violations/verify-all/{job_id} [get]
+func (h *ContentHandler) GetVerifyAllJob(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := h.checkContentAccess(c, id); err != nil {
+		return err
+	}
+
+	job, ok := h.verifyJobs.get(c.Params("job_id"))
+	if !ok || job.ContentID != id {
+		return c.Status(404).JSON(ErrorResponse{Error: "job not found"})
+	}
+	return c.JSON(job)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/video-analitics/indexer/internal/service"
+)
+
+func TestVerifyJobsOnePerContent(t *testing.T) {
+	jobs := newVerifyJobs()
+
+	first, created := jobs.start("c1")
+	if !created {
+		t.Fatal("first job not created")
+	}
+	again, created := jobs.start("c1")
+	if created || again.ID != first.ID {
+		t.Fatalf("running job for c1 must be reused, got %s", again.ID)
+	}
+	if _, created := jobs.start("c2"); !created {
+		t.Fatal("job for another content must be created")
+	}
+
+	jobs.progress(first.ID, 0, 3)
+	jobs.progress(first.ID, 2, 3)
+	jobs.progress(first.ID, 1, 3)
+	if got, _ := jobs.get(first.ID); got.Total != 3 || got.Checked != 2 {
+		t.Fatalf("running job = %+v, want 2 of 3 checked", got)
+	}
+
+	jobs.finish(first.ID, &service.VerifySummary{Total: 3, Present: 1, Removed: 1, Errored: 1}, nil)
+	got, ok := jobs.get(first.ID)
+	if !ok || got.Status != ContentCheckCompleted || got.Checked != 3 || got.Summary == nil || got.Summary.Removed != 1 || got.FinishedAt == nil {
+		t.Fatalf("finished job = %+v", got)
+	}
+	if next, created := jobs.start("c1"); !created || next.ID == first.ID {
+		t.Fatal("finished job must not block a new verification")
+	}
+}
+
+func TestVerifyJobsFailedAndPrune(t *testing.T) {
+	jobs := newVerifyJobs()
+	job, _ := jobs.start("c1")
+	jobs.finish(job.ID, nil, errors.New("mongo down"))
+
+	got, _ := jobs.get(job.ID)
+	if got.Status != ContentCheckFailed || got.Error != "mongo down" || got.Summary != nil {
+		t.Fatalf("failed job = %+v", got)
+	}
+
+	jobs.mu.Lock()
+	jobs.pruneLocked(time.Now().Add(contentCheckJobTTL + time.Minute))
+	jobs.mu.Unlock()
+
+	if _, ok := jobs.get(job.ID); ok {
+		t.Fatal("expired job not pruned")
+	}
+}
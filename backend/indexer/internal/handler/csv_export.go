@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// csvColumn — колонка CSV-выгрузки: ключ для ?columns= и заголовок в файле
+type csvColumn struct {
+	key    string
+	header string
+}
+
+var violationCSVColumns = []csvColumn{
+	{key: "domain", header: "Домен"},
+	{key: "url", header: "URL"},
+	{key: "title", header: "Название страницы"},
+	{key: "match_type", header: "Тип совпадения"},
+	{key: "evidence", header: "Основание совпадения"},
+	{key: "found_at", header: "Дата обнаружения"},
+}
+
+var contentCSVColumns = []csvColumn{
+	{key: "title", header: "Название"},
+	{key: "original_title", header: "Оригинальное название"},
+	{key: "year", header: "Год выхода"},
+	{key: "kinopoisk_id", header: "КиноПоиск ID"},
+	{key: "imdb_id", header: "IMDb ID"},
+	{key: "mydramalist_id", header: "MDL ID"},
+	{key: "mal_id", header: "MAL ID"},
+	{key: "shikimori_id", header: "Shikimori ID"},
+	{key: "violations_count", header: "Нарушений"},
+	{key: "sites_count", header: "Сайтов"},
+	{key: "created_at", header: "Добавлен"},
+}
+
+// csvDelimiters — допустимые разделители; "tab" — для тех, кому неудобно кодировать %09
+var csvDelimiters = map[string]rune{
+	",":   ',',
+	";":   ';',
+	"|":   '|',
+	"\t":  '\t',
+	"tab": '\t',
+}
+
+// csvLayout — разделитель и выбранные колонки (индексы в полном наборе, в порядке запроса)
+type csvLayout struct {
+	comma   rune
+	columns []csvColumn
+	indexes []int
+}
+
+// newCSVLayout разбирает ?delimiter= и ?columns=; пустые значения — запятая и все колонки
+func newCSVLayout(all []csvColumn, delimiter, columns string) (csvLayout, error) {
+	layout := csvLayout{comma: ',', columns: all}
+
+	if delimiter != "" {
+		comma, ok := csvDelimiters[delimiter]
+		if !ok {
+			return csvLayout{}, errors.New("delimiter must be one of , ; | tab")
+		}
+		layout.comma = comma
+	}
+
+	if strings.TrimSpace(columns) == "" {
+		return layout, nil
+	}
+
+	byKey := make(map[string]int, len(all))
+	for i, col := range all {
+		byKey[col.key] = i
+	}
+
+	seen := make(map[int]bool)
+	for _, key := range strings.Split(columns, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		i, ok := byKey[key]
+		if !ok {
+			return csvLayout{}, fmt.Errorf("unknown column %q, allowed: %s", key, columnKeys(all))
+		}
+		if seen[i] {
+			continue
+		}
+		seen[i] = true
+		layout.indexes = append(layout.indexes, i)
+	}
+
+	return layout, nil
+}
+
+func columnKeys(all []csvColumn) string {
+	keys := make([]string, len(all))
+	for i, col := range all {
+		keys[i] = col.key
+	}
+	return strings.Join(keys, ", ")
+}
+
+func (l csvLayout) header() []string {
+	headers := make([]string, len(l.columns))
+	for i, col := range l.columns {
+		headers[i] = col.header
+	}
+	return l.row(headers)
+}
+
+// row оставляет из полной строки только выбранные колонки
+func (l csvLayout) row(full []string) []string {
+	if l.indexes == nil {
+		return full
+	}
+	out := make([]string, len(l.indexes))
+	for i, idx := range l.indexes {
+		out[i] = full[idx]
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewCSVLayout(t *testing.T) {
+	full := []string{"example.com", "https://example.com/1", "Брат 2", "kinopoisk_id", "kinopoisk_id=41519", "2024-01-02 03:04:05"}
+
+	tests := []struct {
+		name       string
+		delimiter  string
+		columns    string
+		wantErr    bool
+		wantComma  rune
+		wantHeader []string
+		wantRow    []string
+	}{
+		{
+			name:       "defaults",
+			wantComma:  ',',
+			wantHeader: []string{"Домен", "URL", "Название страницы", "Тип совпадения", "Основание совпадения", "Дата обнаружения"},
+			wantRow:    full,
+		},
+		{
+			name:       "semicolon and subset in requested order",
+			delimiter:  ";",
+			columns:    "match_type, domain,url",
+			wantComma:  ';',
+			wantHeader: []string{"Тип совпадения", "Домен", "URL"},
+			wantRow:    []string{"kinopoisk_id", "example.com", "https://example.com/1"},
+		},
+		{
+			name:       "tab alias and duplicate column",
+			delimiter:  "tab",
+			columns:    "url,url",
+			wantComma:  '\t',
+			wantHeader: []string{"URL"},
+			wantRow:    []string{"https://example.com/1"},
+		},
+		{
+			name:    "unknown column",
+			columns: "domain,player",
+			wantErr: true,
+		},
+		{
+			name:      "unsupported delimiter",
+			delimiter: "\"",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := newCSVLayout(violationCSVColumns, tt.delimiter, tt.columns)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if layout.comma != tt.wantComma {
+				t.Errorf("comma = %q, want %q", layout.comma, tt.wantComma)
+			}
+			if got := layout.header(); !reflect.DeepEqual(got, tt.wantHeader) {
+				t.Errorf("header = %v, want %v", got, tt.wantHeader)
+			}
+			if got := layout.row(full); !reflect.DeepEqual(got, tt.wantRow) {
+				t.Errorf("row = %v, want %v", got, tt.wantRow)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/nats"
+	"github.com/video-analitics/backend/pkg/queue"
+	indexerQueue "github.com/video-analitics/indexer/internal/queue"
+	"github.com/video-analitics/indexer/internal/repo"
+)
+
+const detectCacheTTL = 10 * time.Minute
+
+type DetectHandler struct {
+	natsClient *nats.Client
+	// timeout — сколько ждать результата синхронной детекции от парсера
+	timeout time.Duration
+
+	cacheMu sync.Mutex
+	cache   map[string]detectCacheEntry
+}
+
+type detectCacheEntry struct {
+	resp      DetectResponse
+	expiresAt time.Time
+}
+
+func NewDetectHandler(natsClient *nats.Client, timeout time.Duration) *DetectHandler {
+	return &DetectHandler{
+		natsClient: natsClient,
+		timeout:    timeout,
+		cache:      make(map[string]detectCacheEntry),
+	}
+}
+
+type DetectRequest struct {
+	Domain string `json:"domain"`
+}
+
+type DetectResponse struct {
+	Domain           string                `json:"domain"`
+	Success          bool                  `json:"success"`
+	Error            string                `json:"error,omitempty"`
+	RedirectToDomain string                `json:"redirect_to_domain,omitempty"`
+	Parked           bool                  `json:"parked,omitempty"`
+	ParkedSignature  string                `json:"parked_signature,omitempty"`
+	Detection        *repo.DetectionUpdate `json:"detection,omitempty"`
+	Cached           bool                  `json:"cached"`
+}
+
+// Detect godoc
+// @Summary Detect site properties without adding it
+// @Description Synchronously run detection (CMS, sitemap, captcha, suggested scanner) for a domain. Successful results are cached for 10 minutes
+// @Tags sites
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param domain query string false "Domain to detect; alternative to the request body"
+// @Param request body DetectRequest false "Domain to detect"
+// @Success 200 {object} DetectResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Failure 504 {object} ErrorResponse
+// @Router /api/detect [post]
+func (h *DetectHandler) Detect(c *fiber.Ctx) error {
+	req := DetectRequest{Domain: c.Query("domain")}
+	if req.Domain == "" {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+		}
+	}
+
+	domain := normalizeDomain(req.Domain)
+	if domain == "" {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid domain"})
+	}
+
+	if resp, ok := h.cached(domain); ok {
+		return c.JSON(resp)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), h.timeout)
+	defer cancel()
+
+	var result queue.DetectResultMsg
+	err := h.natsClient.Request(ctx, nats.SubjectDetectSync, queue.DetectSyncRequest{Domain: domain}, &result)
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("domain", domain).Msg("sync detection failed")
+		if errors.Is(err, context.DeadlineExceeded) {
+			return c.Status(504).JSON(ErrorResponse{Error: "detection timed out"})
+		}
+		return c.Status(503).JSON(ErrorResponse{Error: "no parser available for detection"})
+	}
+
+	resp := DetectResponse{
+		Domain:           domain,
+		Success:          result.Success,
+		Error:            result.Error,
+		RedirectToDomain: result.RedirectToDomain,
+		Parked:           result.IsParked,
+		ParkedSignature:  result.ParkedSignature,
+	}
+	if result.Success && !result.HasDomainRedirect && !result.IsParked {
+		update := indexerQueue.DetectionUpdateFromResult(&result)
+		resp.Detection = &update
+	}
+
+	if resp.Success {
+		h.store(domain, resp)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *DetectHandler) cached(domain string) (DetectResponse, bool) {
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+
+	entry, ok := h.cache[domain]
+	if !ok {
+		return DetectResponse{}, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(h.cache, domain)
+		return DetectResponse{}, false
+	}
+
+	resp := entry.resp
+	resp.Cached = true
+	return resp, true
+}
+
+func (h *DetectHandler) store(domain string, resp DetectResponse) {
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+
+	// Просроченные записи чистим при записи, чтобы кеш не рос бесконечно
+	now := time.Now()
+	for d, entry := range h.cache {
+		if now.After(entry.expiresAt) {
+			delete(h.cache, d)
+		}
+	}
+
+	h.cache[domain] = detectCacheEntry{resp: resp, expiresAt: now.Add(detectCacheTTL)}
+}
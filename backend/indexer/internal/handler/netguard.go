@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+)
+
+// errNonPublicHost — адрес указывает во внутреннюю сеть: loopback, частные диапазоны, метаданные облака
+var errNonPublicHost = errors.New("host resolves to a non-public address")
+
+// lookupNetIP резолвит хост перед проверкой; подменяется в тестах
+var lookupNetIP = net.DefaultResolver.LookupNetIP
+
+// nonPublicPrefixes — диапазоны, которые не видны из интернета, но не покрыты методами netip.Addr
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 — обёртка над любым IPv4
+}
+
+// isPublicIP — адрес маршрутизируется в интернете, парсеру можно по нему ходить
+func isPublicIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPublicHost резолвит хост и отказывает, если хотя бы один его адрес не публичный
+func checkPublicHost(ctx context.Context, host string) error {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !isPublicIP(ip) {
+			return errNonPublicHost
+		}
+		return nil
+	}
+
+	ips, err := lookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	if len(ips) == 0 {
+		return errNonPublicHost
+	}
+	for _, ip := range ips {
+		if !isPublicIP(ip) {
+			return errNonPublicHost
+		}
+	}
+	return nil
+}
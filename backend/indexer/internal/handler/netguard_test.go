@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicIP(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPublicHostRejectsAnyPrivateAddress(t *testing.T) {
+	orig := lookupNetIP
+	lookupNetIP = func(context.Context, string, string) ([]netip.Addr, error) {
+		return []netip.Addr{netip.MustParseAddr("93.184.215.14"), netip.MustParseAddr("127.0.0.1")}, nil
+	}
+	t.Cleanup(func() { lookupNetIP = orig })
+
+	if err := checkPublicHost(context.Background(), "rebind.example"); !errors.Is(err, errNonPublicHost) {
+		t.Errorf("checkPublicHost() = %v, want %v", err, errNonPublicHost)
+	}
+}
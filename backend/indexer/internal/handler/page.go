@@ -3,26 +3,38 @@ package handler
 import (
 	"bytes"
 	"encoding/csv"
+	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/backend/pkg/snapshot"
 	"github.com/video-analitics/backend/pkg/violations"
+	"github.com/video-analitics/indexer/internal/middleware"
 	"github.com/video-analitics/indexer/internal/repo"
 )
 
 type PageHandler struct {
-	pageRepo      *repo.PageRepo
-	violationsSvc *violations.Service
+	pageRepo        *repo.PageRepo
+	userContentRepo *repo.UserContentRepo
+	violationsSvc   *violations.Service
+	snapshots       snapshot.Store
 }
 
-func NewPageHandler(pageRepo *repo.PageRepo, violationsSvc *violations.Service) *PageHandler {
+func NewPageHandler(pageRepo *repo.PageRepo, userContentRepo *repo.UserContentRepo, violationsSvc *violations.Service) *PageHandler {
 	return &PageHandler{
-		pageRepo:      pageRepo,
-		violationsSvc: violationsSvc,
+		pageRepo:        pageRepo,
+		userContentRepo: userContentRepo,
+		violationsSvc:   violationsSvc,
 	}
 }
 
+// SetSnapshots включает выдачу сохранённых снимков HTML страниц
+func (h *PageHandler) SetSnapshots(store snapshot.Store) {
+	h.snapshots = store
+}
+
 type PageExternalIDs struct {
 	KinopoiskID   string `json:"kinopoisk_id,omitempty"`
 	IMDBID        string `json:"imdb_id,omitempty"`
@@ -43,6 +55,7 @@ type PageResponse struct {
 	PlayerURL   string          `json:"player_url,omitempty"`
 	HTTPStatus  int             `json:"http_status"`
 	IndexedAt   time.Time       `json:"indexed_at"`
+	SnapshotAt  *time.Time      `json:"snapshot_at,omitempty"`
 }
 
 type ListPagesResponse struct {
@@ -61,12 +74,16 @@ type ListPagesResponse struct {
 // @Param title query string false "Search by title"
 // @Param year query int false "Filter by year"
 // @Param has_player query bool false "Filter by player presence"
+// @Param has_external_id query bool false "Filter by presence of any external ID (kinopoisk, imdb, tmdb, mal, shikimori, mydramalist)"
 // @Param has_violations query bool false "Filter by violations presence (requires site_id)"
+// @Param indexed_since query string false "Indexed at or after (RFC3339 or YYYY-MM-DD)"
+// @Param indexed_before query string false "Indexed before (RFC3339 or YYYY-MM-DD)"
 // @Param sort_by query string false "Sort by field" Enums(indexed_at, year) default(indexed_at)
 // @Param sort_order query string false "Sort order" Enums(asc, desc) default(desc)
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
 // @Success 200 {object} ListPagesResponse
+// @Failure 400 {object} ErrorResponse
 // @Router /api/pages [get]
 func (h *PageHandler) List(c *fiber.Ctx) error {
 	limit, _ := strconv.ParseInt(c.Query("limit", "20"), 10, 64)
@@ -91,11 +108,20 @@ func (h *PageHandler) List(c *fiber.Ctx) error {
 		Offset:      offset,
 	}
 
+	if err := applyIndexedRange(c, &query); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: err.Error()})
+	}
+
 	if hp := c.Query("has_player"); hp == "true" || hp == "false" {
 		hasPlayer := hp == "true"
 		query.HasPlayer = &hasPlayer
 	}
 
+	if he := c.Query("has_external_id"); he == "true" || he == "false" {
+		hasExternalID := he == "true"
+		query.HasExternalID = &hasExternalID
+	}
+
 	if hv := c.Query("has_violations"); (hv == "true" || hv == "false") && siteID != "" && h.violationsSvc != nil {
 		pageIDs, err := h.violationsSvc.GetPageIDsBySiteID(c.Context(), siteID)
 		if err != nil {
@@ -136,6 +162,7 @@ func (h *PageHandler) List(c *fiber.Ctx) error {
 			PlayerURL:  p.PlayerURL,
 			HTTPStatus: p.HTTPStatus,
 			IndexedAt:  p.IndexedAt,
+			SnapshotAt: p.SnapshotAt,
 		}
 	}
 
@@ -145,6 +172,39 @@ func (h *PageHandler) List(c *fiber.Ctx) error {
 	})
 }
 
+// applyIndexedRange читает indexed_since/indexed_before; дата без времени — полночь UTC
+func applyIndexedRange(c *fiber.Ctx, query *repo.PageQuery) error {
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"indexed_since", &query.IndexedSince},
+		{"indexed_before", &query.IndexedBefore},
+	} {
+		t, err := parseDateParam(c, p.name)
+		if err != nil {
+			return err
+		}
+		*p.dst = t
+	}
+	return nil
+}
+
+// parseDateParam читает query-параметр как RFC3339 или YYYY-MM-DD; nil, если параметра нет
+func parseDateParam(c *fiber.Ctx, name string) (*time.Time, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, v); err != nil {
+			return nil, fmt.Errorf("%s must be RFC3339 or YYYY-MM-DD", name)
+		}
+	}
+	return &t, nil
+}
+
 // GetStats godoc
 // @Summary Get page statistics
 // @Description Get statistics about indexed pages
@@ -172,10 +232,14 @@ func (h *PageHandler) Stats(c *fiber.Ctx) error {
 // @Param site_id query string false "Filter by site ID"
 // @Param year query int false "Filter by year"
 // @Param has_player query bool false "Filter by player presence"
+// @Param has_external_id query bool false "Filter by presence of any external ID (kinopoisk, imdb, tmdb, mal, shikimori, mydramalist)"
 // @Param has_violations query bool false "Filter by violations presence"
+// @Param indexed_since query string false "Indexed at or after (RFC3339 or YYYY-MM-DD)"
+// @Param indexed_before query string false "Indexed before (RFC3339 or YYYY-MM-DD)"
 // @Param sort_by query string false "Sort by field" Enums(indexed_at, year) default(indexed_at)
 // @Param sort_order query string false "Sort order" Enums(asc, desc) default(desc)
 // @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
 // @Router /api/pages/export [get]
 func (h *PageHandler) ExportCSV(c *fiber.Ctx) error {
 	siteID := c.Query("site_id")
@@ -190,11 +254,20 @@ func (h *PageHandler) ExportCSV(c *fiber.Ctx) error {
 		Offset:    0,
 	}
 
+	if err := applyIndexedRange(c, &query); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: err.Error()})
+	}
+
 	if hp := c.Query("has_player"); hp == "true" || hp == "false" {
 		hasPlayer := hp == "true"
 		query.HasPlayer = &hasPlayer
 	}
 
+	if he := c.Query("has_external_id"); he == "true" || he == "false" {
+		hasExternalID := he == "true"
+		query.HasExternalID = &hasExternalID
+	}
+
 	if hv := c.Query("has_violations"); (hv == "true" || hv == "false") && siteID != "" && h.violationsSvc != nil {
 		pageIDs, err := h.violationsSvc.GetPageIDsBySiteID(c.Context(), siteID)
 		if err != nil {
@@ -258,3 +331,100 @@ func (h *PageHandler) ExportCSV(c *fiber.Ctx) error {
 
 	return c.Send(buf.Bytes())
 }
+
+type PageViolationsResponse struct {
+	PageID     string   `json:"page_id"`
+	ContentIDs []string `json:"content_ids"`
+}
+
+// GetViolations godoc
+// @Summary Get content violating on page
+// @Description Get IDs of all content whose violations reference this page. Non-admin users only see their own content
+// @Tags pages
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Page ID"
+// @Success 200 {object} PageViolationsResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/pages/{id}/violations [get]
+func (h *PageHandler) GetViolations(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	contentIDs, err := h.violationsSvc.GetContentIDsByPageID(c.Context(), id)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch violations"})
+	}
+
+	allowed, err := visibleContentIDs(c, h.userContentRepo)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch user content"})
+	}
+
+	contentIDs = filterContentIDs(contentIDs, "", allowed)
+	if contentIDs == nil {
+		contentIDs = []string{}
+	}
+
+	return c.JSON(PageViolationsResponse{
+		PageID:     id,
+		ContentIDs: contentIDs,
+	})
+}
+
+// GetSnapshot godoc
+// @Summary Download page HTML snapshot
+// @Description Download the HTML of the page as fetched by the parser during the last crawl. Non-admin users need a violation of their own content on this page
+// @Tags pages
+// @Security BearerAuth
+// @Produce html
+// @Param id path string true "Page ID"
+// @Success 200 {file} file
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/pages/{id}/snapshot [get]
+func (h *PageHandler) GetSnapshot(c *fiber.Ctx) error {
+	if h.snapshots == nil {
+		return c.Status(404).JSON(ErrorResponse{Error: "snapshots are disabled"})
+	}
+
+	page, err := h.pageRepo.FindByID(c.Context(), c.Params("id"))
+	if err != nil || page == nil {
+		return c.Status(404).JSON(ErrorResponse{Error: "page not found"})
+	}
+
+	// Снимок — доказательство нарушения: пользователю доступен только по страницам, нарушающим его контент
+	if !middleware.IsAdmin(c) {
+		contentIDs, err := h.violationsSvc.GetContentIDsByPageID(c.Context(), page.ID.Hex())
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch violations"})
+		}
+		allowed, err := visibleContentIDs(c, h.userContentRepo)
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch user content"})
+		}
+		if len(filterContentIDs(contentIDs, "", allowed)) == 0 {
+			return c.Status(403).JSON(ErrorResponse{Error: "access denied"})
+		}
+	}
+
+	if page.SnapshotKey == "" {
+		return c.Status(404).JSON(ErrorResponse{Error: "snapshot not found"})
+	}
+	data, err := h.snapshots.Get(c.Context(), page.SnapshotKey)
+	if errors.Is(err, snapshot.ErrNotFound) {
+		return c.Status(404).JSON(ErrorResponse{Error: "snapshot not found"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch snapshot"})
+	}
+	html, err := snapshot.Decompress(data)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to decompress snapshot"})
+	}
+
+	c.Set("Content-Type", "text/html; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"page-%s.html\"", page.ID.Hex()))
+
+	return c.Send(html)
+}
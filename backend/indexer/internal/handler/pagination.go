@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/indexer/internal/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// parseCursorPage включает keyset-пагинацию, только если передан cursor или
+// первая страница запрошена с paginate=cursor. Без них остаётся offset с прежней сортировкой
+func parseCursorPage(c *fiber.Ctx) (repo.CursorPage, error) {
+	raw := c.Query("cursor")
+	if raw == "" {
+		return repo.CursorPage{Enabled: c.Query("paginate") == "cursor"}, nil
+	}
+
+	after, err := repo.DecodeCursor(raw)
+	if err != nil {
+		return repo.CursorPage{}, err
+	}
+	return repo.CursorPage{Enabled: true, After: after}, nil
+}
+
+// nextCursor возвращает курсор следующей страницы, если она есть
+func nextCursor(page repo.CursorPage, hasMore bool, lastCreatedAt time.Time, lastID primitive.ObjectID) string {
+	if !page.Enabled || !hasMore {
+		return ""
+	}
+	return repo.EncodeCursor(lastCreatedAt, lastID)
+}
+
+// Pagination — общие поля постраничных ответов
+type Pagination struct {
+	// Total нет в ответе при count=false
+	Total   *int64 `json:"total,omitempty"`
+	Limit   int64  `json:"limit"`
+	Offset  int64  `json:"offset"`
+	HasMore bool   `json:"has_more"`
+}
+
+// listPage — limit, offset и count из запроса
+type listPage struct {
+	Limit  int64
+	Offset int64
+	// Count — считать total отдельным запросом; has_more считается и без него
+	Count bool
+}
+
+func parseListPage(c *fiber.Ctx, maxLimit int64) listPage {
+	limit, _ := strconv.ParseInt(c.Query("limit", "20"), 10, 64)
+	offset, _ := strconv.ParseInt(c.Query("offset", "0"), 10, 64)
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return listPage{Limit: limit, Offset: offset, Count: c.QueryBool("count", true)}
+}
+
+// fetchLimit — на один элемент больше limit, чтобы узнать has_more без подсчёта
+func (p listPage) fetchLimit() int64 {
+	return p.Limit + 1
+}
+
+func (p listPage) pagination(total int64, hasMore bool) Pagination {
+	result := Pagination{Limit: p.Limit, Offset: p.Offset, HasMore: hasMore}
+	if p.Count {
+		result.Total = &total
+	}
+	return result
+}
+
+// trimPage отрезает лишний элемент выборки fetchLimit и сообщает, была ли она больше limit
+func trimPage[T any](items []T, limit int64) ([]T, bool) {
+	if int64(len(items)) > limit {
+		return items[:limit], true
+	}
+	return items, false
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/indexer/internal/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTrimPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     []int
+		limit     int64
+		wantLen   int
+		wantAfter bool
+	}{
+		{"empty", nil, 3, 0, false},
+		{"short page", []int{1, 2}, 3, 2, false},
+		{"exactly limit", []int{1, 2, 3}, 3, 3, false},
+		{"extra item", []int{1, 2, 3, 4}, 3, 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, hasMore := trimPage(tt.items, tt.limit)
+			if len(got) != tt.wantLen || hasMore != tt.wantAfter {
+				t.Errorf("trimPage() = %d items, has_more %v; want %d, %v", len(got), hasMore, tt.wantLen, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestListPagePagination(t *testing.T) {
+	tests := []struct {
+		name string
+		page listPage
+		want string
+	}{
+		{"with total", listPage{Limit: 20, Offset: 40, Count: true}, `{"total":0,"limit":20,"offset":40,"has_more":true}`},
+		{"count=false", listPage{Limit: 20}, `{"limit":20,"offset":0,"has_more":true}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.page.pagination(0, true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("pagination() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCursorPage(t *testing.T) {
+	cursor := repo.EncodeCursor(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), primitive.NewObjectID())
+
+	tests := []struct {
+		name        string
+		query       string
+		wantEnabled bool
+		wantAfter   bool
+		wantErr     bool
+	}{
+		{"no params keeps offset", "", false, false, false},
+		{"sort_by without offset keeps offset", "?sort_by=created_at", false, false, false},
+		{"explicit opt-in", "?paginate=cursor", true, false, false},
+		{"cursor", "?cursor=" + cursor, true, true, false},
+		{"invalid cursor", "?cursor=garbage", false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got repo.CursorPage
+			var gotErr error
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				got, gotErr = parseCursorPage(c)
+				return nil
+			})
+			if _, err := app.Test(httptest.NewRequest("GET", "/"+tt.query, nil)); err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Fatalf("parseCursorPage() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if got.Enabled != tt.wantEnabled || (got.After != nil) != tt.wantAfter {
+				t.Errorf("parseCursorPage() = %+v, want enabled %v, after %v", got, tt.wantEnabled, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestParseDateParam(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    *time.Time
+		wantErr bool
+	}{
+		{"absent", "", nil, false},
+		{"date only", "?since=2025-03-01", ptrTime(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)), false},
+		{"rfc3339", "?since=2025-03-01T10:30:00Z", ptrTime(time.Date(2025, 3, 1, 10, 30, 0, 0, time.UTC)), false},
+		{"invalid", "?since=01.03.2025", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *time.Time
+			var gotErr error
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				got, gotErr = parseDateParam(c, "since")
+				return nil
+			})
+			if _, err := app.Test(httptest.NewRequest("GET", "/"+tt.query, nil)); err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Fatalf("parseDateParam() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("parseDateParam() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/nats"
+	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/indexer/internal/middleware"
+)
+
+// PageParser загружает и разбирает страницу, ничего не сохраняя
+type PageParser interface {
+	ParsePage(ctx context.Context, req queue.ParseSyncRequest) (queue.PageResult, error)
+}
+
+// NATSPageParser отправляет разовый парсинг одному из парсеров через request-reply
+type NATSPageParser struct {
+	natsClient *nats.Client
+	// timeout — сколько ждать ответа парсера, включая загрузку страницы браузером
+	timeout time.Duration
+}
+
+func NewNATSPageParser(natsClient *nats.Client, timeout time.Duration) *NATSPageParser {
+	return &NATSPageParser{natsClient: natsClient, timeout: timeout}
+}
+
+func (p *NATSPageParser) ParsePage(ctx context.Context, req queue.ParseSyncRequest) (queue.PageResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var result queue.PageResult
+	err := p.natsClient.Request(ctx, nats.SubjectParseSync, req, &result)
+	return result, err
+}
+
+type ParseHandler struct {
+	parser PageParser
+}
+
+func NewParseHandler(parser PageParser) *ParseHandler {
+	return &ParseHandler{parser: parser}
+}
+
+type ParseResponse struct {
+	URL       string          `json:"url"`
+	Success   bool            `json:"success"`
+	Error     string          `json:"error,omitempty"`
+	IPBlocked bool            `json:"ip_blocked,omitempty"`
+	Captcha   bool            `json:"captcha,omitempty"`
+	Page      *queue.PageData `json:"page,omitempty"`
+}
+
+// Parse godoc
+// @Summary Parse a single URL without saving it
+// @Description Fetch the page through a parser and return extracted fields (title, year, external IDs, players). Nothing is persisted; requests are rate limited per user and need write access, since each one drives a parser browser. For non-admins the host must resolve to public addresses only: loopback, private, link-local and cloud metadata addresses are rejected
+// @Tags pages
+// @Security BearerAuth
+// @Produce json
+// @Param url query string true "Absolute http(s) URL"
+// @Success 200 {object} ParseResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Failure 504 {object} ErrorResponse
+// @Router /api/parse [get]
+func (h *ParseHandler) Parse(c *fiber.Ctx) error {
+	target, ok := parseTargetURL(c.Query("url"))
+	if !ok {
+		return c.Status(400).JSON(ErrorResponse{Error: "url must be an absolute http(s) URL"})
+	}
+	// Парсер ходит изнутри кластера: без проверки через него можно прочитать внутренние сервисы
+	if !middleware.IsAdmin(c) {
+		if err := checkPublicHost(c.Context(), target.Hostname()); err != nil {
+			if errors.Is(err, errNonPublicHost) {
+				return c.Status(400).JSON(ErrorResponse{Error: "url must point to a public host"})
+			}
+			return c.Status(400).JSON(ErrorResponse{Error: "url host does not resolve"})
+		}
+	}
+	pageURL := target.String()
+
+	result, err := h.parser.ParsePage(c.Context(), queue.ParseSyncRequest{URL: pageURL})
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("url", pageURL).Msg("sync parse failed")
+		if errors.Is(err, context.DeadlineExceeded) {
+			return c.Status(504).JSON(ErrorResponse{Error: "parse timed out"})
+		}
+		return c.Status(503).JSON(ErrorResponse{Error: "no parser available"})
+	}
+
+	return c.JSON(ParseResponse{
+		URL:       pageURL,
+		Success:   result.Success,
+		Error:     result.Error,
+		IPBlocked: result.IPBlocked,
+		Captcha:   result.Captcha,
+		Page:      result.Page,
+	})
+}
+
+// parseTargetURL проверяет, что адрес абсолютный http(s) с хостом
+func parseTargetURL(raw string) (*url.URL, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, false
+	}
+	return u, true
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/backend/pkg/queue"
+)
+
+type stubPageParser struct {
+	result queue.PageResult
+	err    error
+	calls  []string
+}
+
+func (s *stubPageParser) ParsePage(_ context.Context, req queue.ParseSyncRequest) (queue.PageResult, error) {
+	s.calls = append(s.calls, req.URL)
+	return s.result, s.err
+}
+
+// stubLookup подменяет резолвер: example.com — публичный адрес, meilisearch — адрес в кластере
+func stubLookup(t *testing.T) {
+	t.Helper()
+	orig := lookupNetIP
+	lookupNetIP = func(_ context.Context, _, host string) ([]netip.Addr, error) {
+		switch host {
+		case "example.com":
+			return []netip.Addr{netip.MustParseAddr("93.184.215.14")}, nil
+		case "meilisearch":
+			return []netip.Addr{netip.MustParseAddr("10.0.3.7")}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	t.Cleanup(func() { lookupNetIP = orig })
+}
+
+func TestParseHandler(t *testing.T) {
+	stubLookup(t)
+	page := &queue.PageData{
+		URL:   "https://example.com/film/1",
+		Title: "Брат 2",
+		Year:  2000,
+		ExternalIDs: map[string]string{
+			"kinopoisk_id": "41519",
+		},
+		PlayerURL: "https://player.example/embed/41519",
+	}
+
+	tests := []struct {
+		name       string
+		url        string
+		parser     *stubPageParser
+		wantStatus int
+		wantCalls  int
+		wantPage   bool
+	}{
+		{
+			name:       "extracted fields returned",
+			url:        "https://example.com/film/1",
+			parser:     &stubPageParser{result: queue.PageResult{Success: true, Page: page}},
+			wantStatus: 200,
+			wantCalls:  1,
+			wantPage:   true,
+		},
+		{
+			name:       "parser failure is not an http error",
+			url:        "https://example.com/film/1",
+			parser:     &stubPageParser{result: queue.PageResult{Error: "empty title"}},
+			wantStatus: 200,
+			wantCalls:  1,
+		},
+		{
+			name:       "missing url",
+			parser:     &stubPageParser{},
+			wantStatus: 400,
+		},
+		{
+			name:       "relative url",
+			url:        "/film/1",
+			parser:     &stubPageParser{},
+			wantStatus: 400,
+		},
+		{
+			name:       "unsupported scheme",
+			url:        "ftp://example.com/film/1",
+			parser:     &stubPageParser{},
+			wantStatus: 400,
+		},
+		{
+			name:       "loopback address",
+			url:        "http://127.0.0.1:7700/indexes",
+			parser:     &stubPageParser{},
+			wantStatus: 400,
+		},
+		{
+			name:       "cloud metadata address",
+			url:        "http://169.254.169.254/latest/meta-data/",
+			parser:     &stubPageParser{},
+			wantStatus: 400,
+		},
+		{
+			name:       "in-cluster service name",
+			url:        "http://meilisearch:7700/keys",
+			parser:     &stubPageParser{},
+			wantStatus: 400,
+		},
+		{
+			name:       "unresolvable host",
+			url:        "https://missing.invalid/film/1",
+			parser:     &stubPageParser{},
+			wantStatus: 400,
+		},
+		{
+			name:       "no parser available",
+			url:        "https://example.com/film/1",
+			parser:     &stubPageParser{err: errors.New("no responders")},
+			wantStatus: 503,
+			wantCalls:  1,
+		},
+		{
+			name:       "parser timed out",
+			url:        "https://example.com/film/1",
+			parser:     &stubPageParser{err: context.DeadlineExceeded},
+			wantStatus: 504,
+			wantCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/api/parse", NewParseHandler(tt.parser).Parse)
+
+			req := httptest.NewRequest("GET", "/api/parse?url="+url.QueryEscape(tt.url), nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if len(tt.parser.calls) != tt.wantCalls {
+				t.Errorf("parser calls = %v, want %d", tt.parser.calls, tt.wantCalls)
+			}
+			if resp.StatusCode != 200 {
+				return
+			}
+
+			var body ParseResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body.URL != tt.url {
+				t.Errorf("url = %q, want %q", body.URL, tt.url)
+			}
+			if body.Success != tt.parser.result.Success || body.Error != tt.parser.result.Error {
+				t.Errorf("success/error = %v/%q, want %v/%q", body.Success, body.Error, tt.parser.result.Success, tt.parser.result.Error)
+			}
+			if (body.Page != nil) != tt.wantPage {
+				t.Fatalf("page = %+v, want present: %v", body.Page, tt.wantPage)
+			}
+			if tt.wantPage && (body.Page.Title != page.Title || body.Page.Year != page.Year || body.Page.ExternalIDs["kinopoisk_id"] != "41519") {
+				t.Errorf("page = %+v, want %+v", body.Page, page)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/indexer/internal/queue"
+)
+
+const maxParserPageWorkers = 100
+
+type ParserHandler struct {
+	publisher *queue.Publisher
+}
+
+func NewParserHandler(publisher *queue.Publisher) *ParserHandler {
+	return &ParserHandler{publisher: publisher}
+}
+
+type ParserConcurrencyRequest struct {
+	PageWorkers int `json:"page_workers"`
+}
+
+type ParserConcurrencyResponse struct {
+	PageWorkers int `json:"page_workers"`
+}
+
+// SetConcurrency godoc
+// @Summary Change parser page worker concurrency (admin only)
+// @Description Broadcast a new page worker count to all running parsers. Applied without restart; when shrinking, workers stop after finishing their current task
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body ParserConcurrencyRequest true "Page worker count"
+// @Success 202 {object} ParserConcurrencyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/parser/concurrency [post]
+func (h *ParserHandler) SetConcurrency(c *fiber.Ctx) error {
+	var req ParserConcurrencyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	if req.PageWorkers < 1 || req.PageWorkers > maxParserPageWorkers {
+		return c.Status(400).JSON(ErrorResponse{Error: "page_workers must be between 1 and 100"})
+	}
+
+	if err := h.publisher.PublishParserConcurrency(req.PageWorkers); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to publish concurrency command"})
+	}
+
+	return c.Status(202).JSON(ParserConcurrencyResponse{PageWorkers: req.PageWorkers})
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/indexer/internal/service"
+)
+
+type QueueHandler struct {
+	monitor *service.QueueMonitor
+}
+
+func NewQueueHandler(monitor *service.QueueMonitor) *QueueHandler {
+	return &QueueHandler{monitor: monitor}
+}
+
+// List godoc
+// @Summary NATS consumer lag (admin only)
+// @Description Pending and ack-pending message counts for every consumer of the task and result streams, as of the last monitor check
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} service.QueueSnapshot
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/queues [get]
+func (h *QueueHandler) List(c *fiber.Ctx) error {
+	return c.JSON(h.monitor.Snapshot())
+}
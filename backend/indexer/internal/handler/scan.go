@@ -40,7 +40,7 @@ type ScanResponse struct {
 
 // StartScan godoc
 // @Summary Start scanning sites
-// @Description Queues sites for crawling and indexing
+// @Description Queues sites for crawling and indexing. With force, sites in delta crawl mode re-fetch only pages without lastmod or with lastmod newer than their last indexing
 // @Tags scan
 // @Accept json
 // @Produce json
@@ -144,7 +144,18 @@ func (h *ScanHandler) StartScan(c *fiber.Ctx) error {
 	for _, info := range tasksToPublish {
 		siteID := info.Site.ID.Hex()
 
-		if req.Force {
+		if req.Force && info.Site.DeltaCrawl {
+			// Delta-обход: страницы с lastmod вернутся в очередь после обхода карты сайта, если изменились
+			deltaReset, err := h.sitemapURLRepo.ResetUncomparableToPending(c.Context(), siteID)
+			if err != nil {
+				log.Warn().Err(err).Str("site", info.Site.Domain).Msg("failed to reset URLs for delta rescan")
+			} else if deltaReset > 0 {
+				log.Info().
+					Str("site", info.Site.Domain).
+					Int64("urls_reset", deltaReset).
+					Msg("delta rescan: URLs without lastmod and errors reset to pending")
+			}
+		} else if req.Force {
 			// Force rescan: reset all indexed/error pages to pending
 			forceReset, err := h.sitemapURLRepo.ResetAllToPending(c.Context(), siteID)
 			if err != nil {
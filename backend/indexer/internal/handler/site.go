@@ -1,6 +1,10 @@
 package handler
 
 import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
 	"net/url"
 	"strconv"
 	"strings"
@@ -15,6 +19,7 @@ import (
 	"github.com/video-analitics/indexer/internal/middleware"
 	"github.com/video-analitics/indexer/internal/queue"
 	"github.com/video-analitics/indexer/internal/repo"
+	"github.com/video-analitics/indexer/internal/service"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -42,35 +47,42 @@ func normalizeDomain(input string) string {
 }
 
 type SiteHandler struct {
-	siteRepo       *repo.SiteRepo
-	pageRepo       *repo.PageRepo
-	taskRepo       *repo.ScanTaskRepo
-	sitemapURLRepo *repo.SitemapURLRepo
-	userSiteRepo   *repo.UserSiteRepo
-	publisher      *queue.Publisher
-	violationsSvc  *violations.Service
-	meili          *meili.Client
+	siteRepo        *repo.SiteRepo
+	pageRepo        *repo.PageRepo
+	taskRepo        *repo.ScanTaskRepo
+	sitemapURLRepo  *repo.SitemapURLRepo
+	userSiteRepo    *repo.UserSiteRepo
+	userContentRepo *repo.UserContentRepo
+	publisher       *queue.Publisher
+	violationsSvc   *violations.Service
+	meili           *meili.Client
+	trash           *service.TrashService
+	imports         *siteImportJobs
 }
 
-func NewSiteHandler(siteRepo *repo.SiteRepo, pageRepo *repo.PageRepo, taskRepo *repo.ScanTaskRepo, sitemapURLRepo *repo.SitemapURLRepo, userSiteRepo *repo.UserSiteRepo, publisher *queue.Publisher, violationsSvc *violations.Service, meiliClient *meili.Client) *SiteHandler {
+func NewSiteHandler(siteRepo *repo.SiteRepo, pageRepo *repo.PageRepo, taskRepo *repo.ScanTaskRepo, sitemapURLRepo *repo.SitemapURLRepo, userSiteRepo *repo.UserSiteRepo, userContentRepo *repo.UserContentRepo, publisher *queue.Publisher, violationsSvc *violations.Service, meiliClient *meili.Client, trash *service.TrashService) *SiteHandler {
 	return &SiteHandler{
-		siteRepo:       siteRepo,
-		pageRepo:       pageRepo,
-		taskRepo:       taskRepo,
-		sitemapURLRepo: sitemapURLRepo,
-		userSiteRepo:   userSiteRepo,
-		publisher:      publisher,
-		meili:          meiliClient,
-		violationsSvc:  violationsSvc,
+		siteRepo:        siteRepo,
+		pageRepo:        pageRepo,
+		taskRepo:        taskRepo,
+		sitemapURLRepo:  sitemapURLRepo,
+		userSiteRepo:    userSiteRepo,
+		userContentRepo: userContentRepo,
+		publisher:       publisher,
+		meili:           meiliClient,
+		violationsSvc:   violationsSvc,
+		trash:           trash,
+		imports:         newSiteImportJobs(),
 	}
 }
 
 type CreateSiteRequest struct {
-	Domain        string   `json:"domain"`
-	CMS           string   `json:"cms,omitempty"`
-	HasSitemap    bool     `json:"has_sitemap"`
-	SitemapURLs   []string `json:"sitemap_urls,omitempty"`
-	ScanIntervalH int      `json:"scan_interval_h,omitempty"`
+	Domain          string   `json:"domain"`
+	CMS             string   `json:"cms,omitempty"`
+	HasSitemap      bool     `json:"has_sitemap"`
+	SitemapURLs     []string `json:"sitemap_urls,omitempty"`
+	SitemapJSONPath string   `json:"sitemap_json_path,omitempty"` // путь к списку URL, если карта сайта в JSON
+	ScanIntervalH   int      `json:"scan_interval_h,omitempty"`
 }
 
 // ActiveTaskProgress - прогресс активной задачи
@@ -129,6 +141,13 @@ func (h *SiteHandler) Create(c *fiber.Ctx) error {
 
 	existing, _ := h.siteRepo.FindByDomain(c.Context(), domain)
 	if existing != nil {
+		// Повторное добавление удалённого сайта достаёт его из корзины
+		if existing.DeletedAt != nil {
+			if _, err := h.trash.RestoreSite(c.Context(), existing.ID.Hex()); err != nil {
+				return c.Status(500).JSON(ErrorResponse{Error: "failed to restore site"})
+			}
+			existing.DeletedAt = nil
+		}
 		if !isAdmin {
 			link, _ := h.userSiteRepo.FindByUserAndSite(c.Context(), userID, existing.ID.Hex())
 			if link != nil {
@@ -165,12 +184,13 @@ func (h *SiteHandler) Create(c *fiber.Ctx) error {
 	}
 
 	site := &repo.Site{
-		OwnerID:       ownerOID,
-		Domain:        domain,
-		CMS:           req.CMS,
-		HasSitemap:    req.HasSitemap,
-		SitemapURLs:   req.SitemapURLs,
-		ScanIntervalH: req.ScanIntervalH,
+		OwnerID:         ownerOID,
+		Domain:          domain,
+		CMS:             req.CMS,
+		HasSitemap:      req.HasSitemap,
+		SitemapURLs:     req.SitemapURLs,
+		SitemapJSONPath: req.SitemapJSONPath,
+		ScanIntervalH:   req.ScanIntervalH,
 	}
 
 	if err := h.siteRepo.Create(c.Context(), site); err != nil {
@@ -187,7 +207,8 @@ func (h *SiteHandler) Create(c *fiber.Ctx) error {
 
 type ListSitesResponse struct {
 	Items []SiteWithStats `json:"items"`
-	Total int64           `json:"total"`
+	Pagination
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // ListSites godoc
@@ -195,31 +216,47 @@ type ListSitesResponse struct {
 // @Description Get list of monitored sites with pagination
 // @Tags sites
 // @Produce json
+// @Param domain query string false "Case-insensitive domain search: substring, or a pattern with * (kino*, *.ru)"
 // @Param status query string false "Filter by status (active, down, dead)"
+// @Param freeze_reason query string false "Filter by freeze reason" Enums(captcha_unsolved, ip_blocked, repeated_timeout, manual)
 // @Param scanned_since query string false "Filter by last scan date (today, week, month)"
 // @Param has_violations query string false "Filter by violations (true, false)"
+// @Param never_scanned query bool false "Only sites without a single completed scan"
 // @Param limit query int false "Limit" default(20)
-// @Param offset query int false "Offset" default(0)
+// @Param offset query int false "Offset (sorted by status)"
+// @Param paginate query string false "Set to cursor to get the first page with next_cursor"
+// @Param cursor query string false "Cursor from next_cursor of the previous page (sorted by created_at desc)"
+// @Param count query bool false "Compute total with a separate count query; has_more is returned either way" default(true)
 // @Success 200 {object} ListSitesResponse
+// @Failure 400 {object} ErrorResponse
 // @Router /api/sites [get]
 func (h *SiteHandler) List(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	isAdmin := middleware.IsAdmin(c)
 
 	statusFilter := c.Query("status")
+	freezeReason := status.FreezeReason(c.Query("freeze_reason"))
+	if freezeReason != "" && !freezeReason.IsValid() {
+		return c.Status(400).JSON(ErrorResponse{Error: "freeze_reason must be one of captcha_unsolved, ip_blocked, repeated_timeout, manual"})
+	}
 	scannedSince := c.Query("scanned_since")
 	hasViolations := c.Query("has_violations")
-	limit, _ := strconv.ParseInt(c.Query("limit", "20"), 10, 64)
-	offset, _ := strconv.ParseInt(c.Query("offset", "0"), 10, 64)
+	lp := parseListPage(c, 100)
 
-	if limit > 100 {
-		limit = 100
+	page, err := parseCursorPage(c)
+	if err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid cursor"})
 	}
 
 	filter := repo.SiteFilter{
-		Status: statusFilter,
-		Limit:  limit,
-		Offset: offset,
+		Domain:       c.Query("domain"),
+		Status:       statusFilter,
+		FreezeReason: freezeReason,
+		NeverScanned: c.QueryBool("never_scanned"),
+		Limit:        lp.fetchLimit(),
+		Offset:       lp.Offset,
+		Cursor:       page,
+		SkipCount:    !lp.Count,
 	}
 
 	now := time.Now()
@@ -249,7 +286,7 @@ func (h *SiteHandler) List(c *fiber.Ctx) error {
 		}
 		logger.Log.Debug().Int("sites_with_violations", len(siteIDsWithViolations)).Msg("filtering sites with violations")
 		if len(siteIDsWithViolations) == 0 {
-			return c.JSON(ListSitesResponse{Items: []SiteWithStats{}, Total: 0})
+			return c.JSON(ListSitesResponse{Items: []SiteWithStats{}, Pagination: lp.pagination(0, false)})
 		}
 		filter.SiteIDs = siteIDsWithViolations
 	} else if hasViolations == "false" {
@@ -266,6 +303,7 @@ func (h *SiteHandler) List(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch sites"})
 	}
+	sites, hasMore := trimPage(sites, lp.Limit)
 
 	// Собираем ID сайтов для batch запросов
 	siteIDs := make([]string, len(sites))
@@ -320,17 +358,27 @@ func (h *SiteHandler) List(c *fiber.Ctx) error {
 		result = append(result, siteWithStats)
 	}
 
-	return c.JSON(ListSitesResponse{
-		Items: result,
-		Total: total,
-	})
+	resp := ListSitesResponse{
+		Items:      result,
+		Pagination: lp.pagination(total, hasMore),
+	}
+	if n := len(sites); n > 0 {
+		resp.NextCursor = nextCursor(page, hasMore, sites[n-1].CreatedAt, sites[n-1].ID)
+	}
+
+	return c.JSON(resp)
 }
 
 func (h *SiteHandler) checkSiteAccess(c *fiber.Ctx, siteID string) (*repo.Site, error) {
+	return checkSiteAccess(c, h.siteRepo, h.userSiteRepo, siteID)
+}
+
+// checkSiteAccess загружает сайт и проверяет доступ текущего пользователя; ошибка уже записана в ответ
+func checkSiteAccess(c *fiber.Ctx, siteRepo *repo.SiteRepo, userSiteRepo *repo.UserSiteRepo, siteID string) (*repo.Site, error) {
 	userID := middleware.GetUserID(c)
 	isAdmin := middleware.IsAdmin(c)
 
-	site, err := h.siteRepo.FindByID(c.Context(), siteID)
+	site, err := siteRepo.FindByID(c.Context(), siteID)
 	if err != nil {
 		return nil, c.Status(500).JSON(ErrorResponse{Error: "failed to fetch site"})
 	}
@@ -338,7 +386,7 @@ func (h *SiteHandler) checkSiteAccess(c *fiber.Ctx, siteID string) (*repo.Site,
 		return nil, c.Status(404).JSON(ErrorResponse{Error: "site not found"})
 	}
 
-	hasAccess, err := h.siteRepo.HasUserAccess(c.Context(), siteID, userID, isAdmin, h.userSiteRepo)
+	hasAccess, err := siteRepo.HasUserAccess(c.Context(), siteID, userID, isAdmin, userSiteRepo)
 	if err != nil {
 		return nil, c.Status(500).JSON(ErrorResponse{Error: "failed to check access"})
 	}
@@ -355,7 +403,10 @@ func (h *SiteHandler) checkSiteAccess(c *fiber.Ctx, siteID string) (*repo.Site,
 // @Tags sites
 // @Produce json
 // @Param id path string true "Site ID"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} SiteWithStats
+// @Header 200 {string} ETag "Payload version"
+// @Success 304 "Not modified"
 // @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /api/sites/{id} [get]
@@ -399,6 +450,7 @@ type SiteViolationResponse struct {
 	URL       string `json:"url"`
 	Title     string `json:"title"`
 	MatchType string `json:"match_type"`
+	Evidence  string `json:"evidence,omitempty"`
 	FoundAt   string `json:"found_at"`
 }
 
@@ -446,6 +498,7 @@ func (h *SiteHandler) GetViolations(c *fiber.Ctx) error {
 			URL:       v.PageURL,
 			Title:     v.PageTitle,
 			MatchType: string(v.MatchType),
+			Evidence:  v.Evidence,
 			FoundAt:   v.FoundAt.Format("2006-01-02T15:04:05Z"),
 		}
 	}
@@ -456,6 +509,57 @@ func (h *SiteHandler) GetViolations(c *fiber.Ctx) error {
 	})
 }
 
+type ListUnmatchedPagesResponse struct {
+	Items []violations.UnmatchedPage `json:"items"`
+	Total int64                      `json:"total"`
+}
+
+// GetUnmatched godoc
+// @Summary Get unmatched pages for site
+// @Description Get indexed pages of the site that carry external IDs but match no tracked content. Useful to discover titles worth tracking.
+// @Tags sites
+// @Produce json
+// @Param id path string true "Site ID"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} ListUnmatchedPagesResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/unmatched [get]
+func (h *SiteHandler) GetUnmatched(c *fiber.Ctx) error {
+	id := c.Params("id")
+	limit, _ := strconv.ParseInt(c.Query("limit", "20"), 10, 64)
+	offset, _ := strconv.ParseInt(c.Query("offset", "0"), 10, 64)
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	_, err := h.checkSiteAccess(c, id)
+	if err != nil {
+		return err
+	}
+
+	pages, total, err := h.violationsSvc.GetUnmatchedPages(c.Context(), id, limit, offset)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch unmatched pages"})
+	}
+	if pages == nil {
+		pages = []violations.UnmatchedPage{}
+	}
+
+	return c.JSON(ListUnmatchedPagesResponse{
+		Items: pages,
+		Total: total,
+	})
+}
+
 // AnalyzeSite godoc
 // @Summary Re-analyze a site
 // @Description Re-run detection for a frozen or pending site
@@ -520,7 +624,7 @@ func (h *SiteHandler) Unfreeze(c *fiber.Ctx) error {
 	c.BodyParser(&req)
 
 	scannerType := status.ScannerType(req.ScannerType)
-	if scannerType != "" && scannerType != status.ScannerHTTP && scannerType != status.ScannerSPA {
+	if !isValidUnfreezeScannerType(scannerType) {
 		return c.Status(400).JSON(ErrorResponse{Error: "invalid scanner_type, must be 'http' or 'spa'"})
 	}
 
@@ -532,6 +636,532 @@ func (h *SiteHandler) Unfreeze(c *fiber.Ctx) error {
 	return c.JSON(site)
 }
 
+// пустой тип — оставить текущий сканер сайта
+func isValidUnfreezeScannerType(t status.ScannerType) bool {
+	return t == "" || t == status.ScannerHTTP || t == status.ScannerSPA
+}
+
+const maxPageWaitMs = 60000
+
+type PageWaitRequest struct {
+	Strategy  string `json:"strategy"` // delay, selector, network_idle, none; пусто — сбросить на глобальную задержку
+	DelayMs   int    `json:"delay_ms,omitempty"`
+	Selector  string `json:"selector,omitempty"`
+	TimeoutMs int    `json:"timeout_ms,omitempty"`
+}
+
+// UpdatePageWait godoc
+// @Summary Set page load wait strategy
+// @Description Configure how the browser waits for site pages to load: fixed delay, CSS selector, network idle or no wait. Empty strategy resets to the parser default strategy
+// @Tags sites
+// @Accept json
+// @Produce json
+// @Param id path string true "Site ID"
+// @Param request body PageWaitRequest true "Wait strategy"
+// @Success 200 {object} repo.Site
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/page-wait [put]
+func (h *SiteHandler) UpdatePageWait(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.checkSiteAccess(c, id); err != nil {
+		return err
+	}
+
+	var req PageWaitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	wait, errMsg := pageWaitFromRequest(req)
+	if errMsg != "" {
+		return c.Status(400).JSON(ErrorResponse{Error: errMsg})
+	}
+
+	if err := h.siteRepo.UpdatePageWait(c.Context(), id, wait); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to update page wait"})
+	}
+
+	site, _ := h.siteRepo.FindByID(c.Context(), id)
+	return c.JSON(site)
+}
+
+func pageWaitFromRequest(req PageWaitRequest) (*repo.PageWait, string) {
+	if req.Strategy == "" {
+		return nil, ""
+	}
+	if req.TimeoutMs < 0 || req.TimeoutMs > maxPageWaitMs {
+		return nil, "timeout_ms must be between 0 and 60000"
+	}
+
+	wait := &repo.PageWait{Strategy: status.PageWait(req.Strategy)}
+	switch wait.Strategy {
+	case status.PageWaitDelay:
+		if req.DelayMs <= 0 || req.DelayMs > maxPageWaitMs {
+			return nil, "delay_ms must be between 1 and 60000"
+		}
+		wait.DelayMs = req.DelayMs
+	case status.PageWaitSelector:
+		if strings.TrimSpace(req.Selector) == "" {
+			return nil, "selector is required"
+		}
+		wait.Selector = strings.TrimSpace(req.Selector)
+		wait.TimeoutMs = req.TimeoutMs
+	case status.PageWaitNetworkIdle:
+		wait.TimeoutMs = req.TimeoutMs
+	case status.PageWaitNone:
+	default:
+		return nil, "invalid strategy, must be 'delay', 'selector', 'network_idle' or 'none'"
+	}
+	return wait, ""
+}
+
+type MaxPagesPerScanRequest struct {
+	MaxPagesPerScan int `json:"max_pages_per_scan"` // 0 — без ограничения
+}
+
+// UpdateMaxPagesPerScan godoc
+// @Summary Set max pages per scan
+// @Description Limit how many pages a single page crawl of the site may process. The crawl stops once the limit is reached and the task completes as capped. 0 removes the limit
+// @Tags sites
+// @Accept json
+// @Produce json
+// @Param id path string true "Site ID"
+// @Param request body MaxPagesPerScanRequest true "Page limit"
+// @Success 200 {object} repo.Site
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/max-pages [put]
+func (h *SiteHandler) UpdateMaxPagesPerScan(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.checkSiteAccess(c, id); err != nil {
+		return err
+	}
+
+	var req MaxPagesPerScanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+	if req.MaxPagesPerScan < 0 {
+		return c.Status(400).JSON(ErrorResponse{Error: "max_pages_per_scan must be >= 0"})
+	}
+
+	if err := h.siteRepo.UpdateMaxPagesPerScan(c.Context(), id, req.MaxPagesPerScan); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to update max pages per scan"})
+	}
+
+	site, _ := h.siteRepo.FindByID(c.Context(), id)
+	return c.JSON(site)
+}
+
+type DeltaCrawlRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// UpdateDeltaCrawl godoc
+// @Summary Set delta crawl mode
+// @Description In delta crawl mode a scan re-fetches indexed pages whose sitemap lastmod is newer than their last indexing. A forced scan re-fetches only such pages, pages without lastmod and failed pages
+// @Tags sites
+// @Accept json
+// @Produce json
+// @Param id path string true "Site ID"
+// @Param request body DeltaCrawlRequest true "Delta crawl flag"
+// @Success 200 {object} repo.Site
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/delta-crawl [put]
+func (h *SiteHandler) UpdateDeltaCrawl(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.checkSiteAccess(c, id); err != nil {
+		return err
+	}
+
+	var req DeltaCrawlRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	if err := h.siteRepo.UpdateDeltaCrawl(c.Context(), id, req.Enabled); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to update delta crawl"})
+	}
+
+	site, _ := h.siteRepo.FindByID(c.Context(), id)
+	return c.JSON(site)
+}
+
+const maxDNSOverrideHosts = 50
+
+type DNSOverrideRequest struct {
+	Resolver string            `json:"resolver,omitempty"` // ip или ip:port DNS-сервера; IPv6 с портом — в скобках
+	Hosts    map[string]string `json:"hosts,omitempty"`    // хост → IPv4/IPv6
+}
+
+// UpdateDNSOverride godoc
+// @Summary Set DNS override for crawling
+// @Description Pin site hosts to fixed IPv4/IPv6 addresses and/or resolve them through a custom DNS server. Applied by the parser to both HTTP and browser fetches. Empty resolver and hosts reset to the system resolver. Only admins may use loopback, private or link-local addresses
+// @Tags sites
+// @Accept json
+// @Produce json
+// @Param id path string true "Site ID"
+// @Param request body DNSOverrideRequest true "DNS override"
+// @Success 200 {object} repo.Site
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/dns-override [put]
+func (h *SiteHandler) UpdateDNSOverride(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.checkSiteAccess(c, id); err != nil {
+		return err
+	}
+
+	var req DNSOverrideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	dns, errMsg := dnsOverrideFromRequest(req, middleware.IsAdmin(c))
+	if errMsg != "" {
+		return c.Status(400).JSON(ErrorResponse{Error: errMsg})
+	}
+
+	if err := h.siteRepo.UpdateDNSOverride(c.Context(), id, dns); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to update dns override"})
+	}
+
+	site, _ := h.siteRepo.FindByID(c.Context(), id)
+	return c.JSON(site)
+}
+
+// dnsOverrideFromRequest проверяет переопределение DNS; allowPrivate разрешает адреса внутренней сети —
+// иначе через краулер можно обойти внутренние сервисы
+func dnsOverrideFromRequest(req DNSOverrideRequest, allowPrivate bool) (*repo.DNSOverride, string) {
+	resolver := strings.TrimSpace(req.Resolver)
+	if resolver == "" && len(req.Hosts) == 0 {
+		return nil, ""
+	}
+	if len(req.Hosts) > maxDNSOverrideHosts {
+		return nil, "too many hosts, max 50"
+	}
+
+	dns := &repo.DNSOverride{}
+	if resolver != "" {
+		host, port, err := net.SplitHostPort(resolver)
+		if err != nil {
+			host, port = strings.Trim(resolver, "[]"), "53"
+		}
+		ip, err := netip.ParseAddr(host)
+		if err != nil {
+			return nil, "resolver must be an IP address with optional port"
+		}
+		if !allowPrivate && !isPublicIP(ip) {
+			return nil, "resolver must be a public IP address"
+		}
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return nil, "invalid resolver port"
+		}
+		dns.Resolver = net.JoinHostPort(host, port)
+	}
+
+	for host, ip := range req.Hosts {
+		host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+		if host == "" || strings.ContainsAny(host, "/:@ ") {
+			return nil, "invalid host: " + host
+		}
+		parsed := net.ParseIP(strings.TrimSpace(ip))
+		if parsed == nil {
+			return nil, "invalid IP for host " + host
+		}
+		if addr, _ := netip.AddrFromSlice(parsed); !allowPrivate && !isPublicIP(addr) {
+			return nil, "IP for host " + host + " must be public"
+		}
+		if dns.Hosts == nil {
+			dns.Hosts = make(map[string]string, len(req.Hosts))
+		}
+		dns.Hosts[host] = parsed.String()
+	}
+	return dns, ""
+}
+
+type ScanWindowRequest struct {
+	StartHour *int   `json:"start_hour"` // 0–23; без start_hour и end_hour окно снимается
+	EndHour   *int   `json:"end_hour"`   // 0–23, не включительно; меньше start_hour — окно через полночь
+	Timezone  string `json:"timezone,omitempty"`
+}
+
+// UpdateScanWindow godoc
+// @Summary Set scan window
+// @Description Restrict scheduled scans to hours [start_hour, end_hour) in the site's timezone, e.g. 2–6 for off-peak scanning. Due scans outside the window are deferred to the next window start. Omit both hours to remove the restriction
+// @Tags sites
+// @Accept json
+// @Produce json
+// @Param id path string true "Site ID"
+// @Param request body ScanWindowRequest true "Scan window"
+// @Success 200 {object} repo.Site
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/scan-window [put]
+func (h *SiteHandler) UpdateScanWindow(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.checkSiteAccess(c, id); err != nil {
+		return err
+	}
+
+	var req ScanWindowRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	var window *repo.ScanWindow
+	if req.StartHour != nil || req.EndHour != nil {
+		if req.StartHour == nil || req.EndHour == nil {
+			return c.Status(400).JSON(ErrorResponse{Error: "start_hour and end_hour are both required"})
+		}
+		window = &repo.ScanWindow{StartHour: *req.StartHour, EndHour: *req.EndHour, Timezone: strings.TrimSpace(req.Timezone)}
+		if err := window.Validate(); err != nil {
+			return c.Status(400).JSON(ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	if err := h.siteRepo.UpdateScanWindow(c.Context(), id, window); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to update scan window"})
+	}
+
+	site, _ := h.siteRepo.FindByID(c.Context(), id)
+	return c.JSON(site)
+}
+
+const (
+	minScanIntervalH = 1
+	maxScanIntervalH = 720
+)
+
+func validateScanInterval(hours int) string {
+	if hours < minScanIntervalH || hours > maxScanIntervalH {
+		return fmt.Sprintf("scan_interval_h must be between %d and %d", minScanIntervalH, maxScanIntervalH)
+	}
+	return ""
+}
+
+type UpdateSiteRequest struct {
+	ScanIntervalH *int `json:"scan_interval_h,omitempty"` // 1–720 часов
+}
+
+// Update godoc
+// @Summary Update site settings
+// @Description Partially update site settings. scan_interval_h (1–720) changes how often the site is scanned; next_scan_at of an active site is recomputed from its last scan
+// @Tags sites
+// @Accept json
+// @Produce json
+// @Param id path string true "Site ID"
+// @Param request body UpdateSiteRequest true "Fields to update"
+// @Success 200 {object} repo.Site
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id} [patch]
+func (h *SiteHandler) Update(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.checkSiteAccess(c, id); err != nil {
+		return err
+	}
+
+	var req UpdateSiteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	if req.ScanIntervalH != nil {
+		if errMsg := validateScanInterval(*req.ScanIntervalH); errMsg != "" {
+			return c.Status(400).JSON(ErrorResponse{Error: errMsg})
+		}
+		if _, err := h.siteRepo.UpdateScanInterval(c.Context(), []string{id}, *req.ScanIntervalH); err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "failed to update scan interval"})
+		}
+	}
+
+	site, _ := h.siteRepo.FindByID(c.Context(), id)
+	return c.JSON(site)
+}
+
+type ScanIntervalBulkRequest struct {
+	SiteIDs       []string `json:"site_ids"`
+	ScanIntervalH int      `json:"scan_interval_h"` // 1–720 часов
+}
+
+type ScanIntervalBulkResponse struct {
+	Updated int64 `json:"updated"`
+	Skipped int   `json:"skipped"`
+}
+
+// UpdateScanIntervalBulk godoc
+// @Summary Set scan interval for multiple sites
+// @Description Change scan interval (1–720 hours) of sites accessible to the user. next_scan_at of active sites is recomputed from their last scan. Inaccessible sites are skipped
+// @Tags sites
+// @Accept json
+// @Produce json
+// @Param request body ScanIntervalBulkRequest true "Site IDs and scan interval"
+// @Success 200 {object} ScanIntervalBulkResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/sites/scan-interval [post]
+func (h *SiteHandler) UpdateScanIntervalBulk(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	isAdmin := middleware.IsAdmin(c)
+
+	var req ScanIntervalBulkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	if len(req.SiteIDs) == 0 {
+		return c.Status(400).JSON(ErrorResponse{Error: "site_ids is required"})
+	}
+	if errMsg := validateScanInterval(req.ScanIntervalH); errMsg != "" {
+		return c.Status(400).JSON(ErrorResponse{Error: errMsg})
+	}
+
+	var resp ScanIntervalBulkResponse
+	allowed := make([]string, 0, len(req.SiteIDs))
+	for _, id := range req.SiteIDs {
+		hasAccess, _ := h.siteRepo.HasUserAccess(c.Context(), id, userID, isAdmin, h.userSiteRepo)
+		if !hasAccess {
+			resp.Skipped++
+			continue
+		}
+		allowed = append(allowed, id)
+	}
+
+	updated, err := h.siteRepo.UpdateScanInterval(c.Context(), allowed, req.ScanIntervalH)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to update scan interval"})
+	}
+	resp.Updated = updated
+	resp.Skipped += len(allowed) - int(updated)
+
+	return c.JSON(resp)
+}
+
+type UnfreezeBulkRequest struct {
+	SiteIDs []string `json:"site_ids"`
+	// AllFrozen — разморозить все доступные пользователю замороженные сайты вместо site_ids
+	AllFrozen   bool   `json:"all_frozen"`
+	ScannerType string `json:"scanner_type"` // "http" или "spa"
+}
+
+type UnfreezeBulkResponse struct {
+	Unfrozen int `json:"unfrozen"`
+	Skipped  int `json:"skipped"`
+}
+
+// UnfreezeBulk godoc
+// @Summary Unfreeze multiple sites
+// @Description Unfreeze frozen sites accessible to the user and optionally change scanner type. Non-frozen and inaccessible sites are skipped. With all_frozen every frozen site accessible to the user is unfrozen and site_ids is ignored
+// @Tags sites
+// @Accept json
+// @Produce json
+// @Param request body UnfreezeBulkRequest true "Site IDs or all_frozen, and scanner type"
+// @Success 200 {object} UnfreezeBulkResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/sites/unfreeze-bulk [post]
+func (h *SiteHandler) UnfreezeBulk(c *fiber.Ctx) error {
+	log := logger.Log
+	userID := middleware.GetUserID(c)
+	isAdmin := middleware.IsAdmin(c)
+
+	var req UnfreezeBulkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	if len(req.SiteIDs) == 0 && !req.AllFrozen {
+		return c.Status(400).JSON(ErrorResponse{Error: "site_ids or all_frozen is required"})
+	}
+
+	scannerType := status.ScannerType(req.ScannerType)
+	if !isValidUnfreezeScannerType(scannerType) {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid scanner_type, must be 'http' or 'spa'"})
+	}
+
+	ids := req.SiteIDs
+	if req.AllFrozen {
+		// nil — все сайты (админ)
+		var scope []string
+		if !isAdmin {
+			accessible, err := h.siteRepo.GetAccessibleSiteIDs(c.Context(), userID, h.userSiteRepo)
+			if err != nil {
+				return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch user sites"})
+			}
+			scope = append([]string{}, accessible...)
+		}
+		frozen, err := h.siteRepo.FindIDsByStatus(c.Context(), status.SiteFrozen, scope)
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch frozen sites"})
+		}
+		ids = frozen
+	}
+
+	resp := unfreezeEach(c.Context(), ids,
+		func(ctx context.Context, id string) bool {
+			hasAccess, _ := h.siteRepo.HasUserAccess(ctx, id, userID, isAdmin, h.userSiteRepo)
+			if !hasAccess {
+				return false
+			}
+			site, err := h.siteRepo.FindByID(ctx, id)
+			return err == nil && site != nil && site.Status == status.SiteFrozen
+		},
+		func(ctx context.Context, id string) error {
+			err := h.siteRepo.Unfreeze(ctx, id, scannerType)
+			if err != nil {
+				log.Warn().Err(err).Str("site_id", id).Msg("failed to unfreeze site")
+			}
+			return err
+		},
+	)
+
+	return c.JSON(resp)
+}
+
+// unfreezeEach размораживает сайты по одному: повторы, недоступные и не замороженные сайты,
+// а также сорвавшиеся на смене статуса пропускаются и не мешают остальным
+func unfreezeEach(
+	ctx context.Context,
+	ids []string,
+	canUnfreeze func(ctx context.Context, id string) bool,
+	unfreeze func(ctx context.Context, id string) error,
+) UnfreezeBulkResponse {
+	var resp UnfreezeBulkResponse
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			resp.Skipped++
+			continue
+		}
+		seen[id] = true
+
+		if !canUnfreeze(ctx, id) {
+			resp.Skipped++
+			continue
+		}
+		if err := unfreeze(ctx, id); err != nil {
+			resp.Skipped++
+			continue
+		}
+		resp.Unfrozen++
+	}
+	return resp
+}
+
 type ScanStageResponse struct {
 	TaskID  string `json:"task_id"`
 	Message string `json:"message"`
@@ -666,7 +1296,7 @@ func (h *SiteHandler) ScanPages(c *fiber.Ctx) error {
 
 // Delete godoc
 // @Summary Delete site
-// @Description Delete a site by ID along with all related pages and tasks
+// @Description Move a site to trash: scanning stops and its pages leave search and violations. The site can be restored until restore_until, then it is deleted with all pages and tasks
 // @Tags sites
 // @Accept json
 // @Produce json
@@ -676,7 +1306,6 @@ func (h *SiteHandler) ScanPages(c *fiber.Ctx) error {
 // @Failure 404 {object} ErrorResponse
 // @Router /api/sites/{id} [delete]
 func (h *SiteHandler) Delete(c *fiber.Ctx) error {
-	log := logger.Log
 	id := c.Params("id")
 
 	_, err := h.checkSiteAccess(c, id)
@@ -684,35 +1313,55 @@ func (h *SiteHandler) Delete(c *fiber.Ctx) error {
 		return err
 	}
 
-	pagesDeleted, _ := h.pageRepo.DeleteBySiteID(c.Context(), id)
-	tasksDeleted, _ := h.taskRepo.DeleteBySiteID(c.Context(), id)
-	h.userSiteRepo.DeleteBySiteID(c.Context(), id)
+	if _, err := h.trash.TrashSite(c.Context(), id, middleware.GetUserID(c)); err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to delete site"})
+	}
 
-	// Удаляем страницы из Meilisearch
-	if h.meili != nil {
-		if err := h.meili.DeleteBySiteID(id); err != nil {
-			log.Warn().Err(err).Str("site_id", id).Msg("failed to delete pages from meilisearch")
-		}
+	return c.JSON(fiber.Map{
+		"message":       "site moved to trash",
+		"restore_until": h.trash.RestoreUntil(time.Now()),
+	})
+}
+
+// Restore godoc
+// @Summary Restore deleted site
+// @Description Take a site out of trash; its pages are reindexed for search and violations are recalculated in background
+// @Tags sites
+// @Produce json
+// @Param id path string true "Site ID"
+// @Success 200 {object} repo.Site
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/restore [post]
+func (h *SiteHandler) Restore(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	site, err := h.siteRepo.FindDeletedByID(c.Context(), id)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch site"})
+	}
+	if site == nil {
+		return c.Status(404).JSON(ErrorResponse{Error: "deleted site not found"})
 	}
 
-	// Удаляем violations
-	if h.violationsSvc != nil {
-		if deleted, err := h.violationsSvc.DeleteBySiteID(c.Context(), id); err != nil {
-			log.Warn().Err(err).Str("site_id", id).Msg("failed to delete violations")
-		} else if deleted > 0 {
-			log.Info().Int64("count", deleted).Str("site_id", id).Msg("violations deleted")
-		}
+	hasAccess, err := h.siteRepo.HasUserAccessToSite(c.Context(), site, middleware.GetUserID(c), middleware.IsAdmin(c), h.userSiteRepo)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to check access"})
+	}
+	if !hasAccess {
+		return c.Status(403).JSON(ErrorResponse{Error: "access denied"})
 	}
 
-	if err := h.siteRepo.Delete(c.Context(), id); err != nil {
-		return c.Status(500).JSON(ErrorResponse{Error: "failed to delete site"})
+	restored, err := h.trash.RestoreSite(c.Context(), id)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to restore site"})
+	}
+	if !restored {
+		return c.Status(404).JSON(ErrorResponse{Error: "deleted site not found"})
 	}
 
-	return c.JSON(fiber.Map{
-		"message":       "site deleted",
-		"pages_deleted": pagesDeleted,
-		"tasks_deleted": tasksDeleted,
-	})
+	site.DeletedAt = nil
+	return c.JSON(site)
 }
 
 type CreateSitesBatchRequest struct {
@@ -727,7 +1376,7 @@ type CreateSitesBatchResponse struct {
 
 // CreateBatch godoc
 // @Summary Create multiple sites
-// @Description Add multiple sites to monitor in a single batch request
+// @Description Add multiple sites to monitor in a single batch request. Detect tasks are published in background chunks; use /sites/import for large batches
 // @Tags sites
 // @Accept json
 // @Produce json
@@ -759,60 +1408,26 @@ func (h *SiteHandler) CreateBatch(c *fiber.Ctx) error {
 
 	var created, failed, linked int
 	var siteIDs []string
+	var targets []detectTarget
 
 	for _, siteReq := range req.Sites {
-		if siteReq.Domain == "" {
-			failed++
-			continue
-		}
-
-		domain := normalizeDomain(siteReq.Domain)
-		if domain == "" {
-			failed++
-			continue
-		}
-
-		existing, _ := h.siteRepo.FindByDomain(c.Context(), domain)
-		if existing != nil {
-			if !isAdmin && !ownerOID.IsZero() {
-				if existing.OwnerID != ownerOID {
-					linkExists, _ := h.userSiteRepo.ExistsByUserAndSite(c.Context(), userID, existing.ID.Hex())
-					if !linkExists {
-						h.userSiteRepo.Create(c.Context(), &repo.UserSite{
-							UserID: ownerOID,
-							SiteID: existing.ID,
-						})
-						linked++
-						siteIDs = append(siteIDs, existing.ID.Hex())
-						continue
-					}
-				}
-			}
+		outcome, site := h.importSite(c.Context(), siteReq, userID, ownerOID, isAdmin)
+		switch outcome {
+		case siteImportCreated:
+			created++
+			siteIDs = append(siteIDs, site.ID.Hex())
+			targets = append(targets, detectTarget{siteID: site.ID.Hex(), domain: site.Domain})
+		case siteImportLinked:
+			linked++
+			siteIDs = append(siteIDs, site.ID.Hex())
+		default:
 			failed++
-			continue
-		}
-
-		site := &repo.Site{
-			OwnerID:       ownerOID,
-			Domain:        domain,
-			CMS:           siteReq.CMS,
-			HasSitemap:    siteReq.HasSitemap,
-			SitemapURLs:   siteReq.SitemapURLs,
-			ScanIntervalH: siteReq.ScanIntervalH,
 		}
-
-		if err := h.siteRepo.Create(c.Context(), site); err != nil {
-			failed++
-			continue
-		}
-
-		taskID := uuid.New().String()
-		h.publisher.PublishDetectTask(c.Context(), taskID, site.ID.Hex(), site.Domain)
-
-		created++
-		siteIDs = append(siteIDs, site.ID.Hex())
 	}
 
+	// Запрос уже завершён, когда пачки ещё публикуются
+	go h.publishDetectChunked(context.Background(), targets, nil)
+
 	return c.Status(201).JSON(CreateSitesBatchResponse{
 		Created: created + linked,
 		Failed:  failed,
@@ -825,14 +1440,13 @@ type DeleteSitesRequest struct {
 }
 
 type DeleteSitesResponse struct {
-	DeletedCount int64 `json:"deleted_count"`
-	PagesDeleted int64 `json:"pages_deleted"`
-	TasksDeleted int64 `json:"tasks_deleted"`
+	DeletedCount int64     `json:"deleted_count"`
+	RestoreUntil time.Time `json:"restore_until"` // до этого момента сайты можно восстановить
 }
 
 // DeleteBulk godoc
 // @Summary Delete multiple sites
-// @Description Delete multiple sites by ID along with all related pages and tasks
+// @Description Move multiple sites to trash; each can be restored until restore_until
 // @Tags sites
 // @Accept json
 // @Produce json
@@ -841,7 +1455,6 @@ type DeleteSitesResponse struct {
 // @Failure 400 {object} ErrorResponse
 // @Router /api/sites/delete [post]
 func (h *SiteHandler) DeleteBulk(c *fiber.Ctx) error {
-	log := logger.Log
 	userID := middleware.GetUserID(c)
 	isAdmin := middleware.IsAdmin(c)
 
@@ -854,39 +1467,20 @@ func (h *SiteHandler) DeleteBulk(c *fiber.Ctx) error {
 		return c.Status(400).JSON(ErrorResponse{Error: "site_ids is required"})
 	}
 
-	var deleted, pagesDeleted, tasksDeleted int64
+	var deleted int64
 	for _, id := range req.SiteIDs {
 		hasAccess, _ := h.siteRepo.HasUserAccess(c.Context(), id, userID, isAdmin, h.userSiteRepo)
 		if !hasAccess {
 			continue
 		}
 
-		pages, _ := h.pageRepo.DeleteBySiteID(c.Context(), id)
-		tasks, _ := h.taskRepo.DeleteBySiteID(c.Context(), id)
-		h.userSiteRepo.DeleteBySiteID(c.Context(), id)
-
-		// Удаляем страницы из Meilisearch
-		if h.meili != nil {
-			if err := h.meili.DeleteBySiteID(id); err != nil {
-				log.Warn().Err(err).Str("site_id", id).Msg("failed to delete pages from meilisearch")
-			}
-		}
-
-		// Удаляем violations
-		if h.violationsSvc != nil {
-			h.violationsSvc.DeleteBySiteID(c.Context(), id)
-		}
-
-		if err := h.siteRepo.Delete(c.Context(), id); err == nil {
+		if ok, err := h.trash.TrashSite(c.Context(), id, userID); err == nil && ok {
 			deleted++
-			pagesDeleted += pages
-			tasksDeleted += tasks
 		}
 	}
 
 	return c.JSON(DeleteSitesResponse{
 		DeletedCount: deleted,
-		PagesDeleted: pagesDeleted,
-		TasksDeleted: tasksDeleted,
+		RestoreUntil: h.trash.RestoreUntil(time.Now()),
 	})
 }
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/violations"
+	"github.com/video-analitics/indexer/internal/repo"
+)
+
+const (
+	ContentCheckRunning   = "running"
+	ContentCheckCompleted = "completed"
+	ContentCheckFailed    = "failed"
+)
+
+// contentCheckJobTTL — сколько хранится завершённая задача
+const contentCheckJobTTL = time.Hour
+
+// ContentCheckJob - проверка всего каталога пользователя по одному сайту
+type ContentCheckJob struct {
+	ID         string     `json:"job_id"`
+	SiteID     string     `json:"site_id"`
+	Status     string     `json:"status"`
+	Total      int        `json:"total"`
+	Checked    int        `json:"checked"`
+	Failed     int        `json:"failed"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// contentCheckJobs — задачи хранятся в памяти процесса и теряются при рестарте
+type contentCheckJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*ContentCheckJob
+}
+
+func newContentCheckJobs() *contentCheckJobs {
+	return &contentCheckJobs{jobs: make(map[string]*ContentCheckJob)}
+}
+
+// start создаёт задачу; если по сайту уже идёт проверка, возвращает её и false
+func (j *contentCheckJobs) start(siteID string, total int) (*ContentCheckJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.pruneLocked(time.Now())
+	for _, job := range j.jobs {
+		if job.SiteID == siteID && job.Status == ContentCheckRunning {
+			return job, false
+		}
+	}
+
+	job := &ContentCheckJob{
+		ID:        uuid.New().String(),
+		SiteID:    siteID,
+		Status:    ContentCheckRunning,
+		Total:     total,
+		StartedAt: time.Now(),
+	}
+	j.jobs[job.ID] = job
+	return job, true
+}
+
+func (j *contentCheckJobs) progress(id string, failed bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if job, ok := j.jobs[id]; ok {
+		job.Checked++
+		if failed {
+			job.Failed++
+		}
+	}
+}
+
+func (j *contentCheckJobs) finish(id string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = ContentCheckCompleted
+	if job.Total > 0 && job.Failed == job.Total {
+		job.Status = ContentCheckFailed
+	}
+}
+
+// get возвращает копию, чтобы не читать поля под записью фоновой горутины
+func (j *contentCheckJobs) get(id string) (ContentCheckJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return ContentCheckJob{}, false
+	}
+	return *job, true
+}
+
+func (j *contentCheckJobs) pruneLocked(now time.Time) {
+	for id, job := range j.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > contentCheckJobTTL {
+			delete(j.jobs, id)
+		}
+	}
+}
+
+type SiteContentCheckHandler struct {
+	siteRepo        *repo.SiteRepo
+	userSiteRepo    *repo.UserSiteRepo
+	contentRepo     *repo.ContentRepo
+	userContentRepo *repo.UserContentRepo
+	violationsSvc   *violations.Service
+	jobs            *contentCheckJobs
+}
+
+func NewSiteContentCheckHandler(siteRepo *repo.SiteRepo, userSiteRepo *repo.UserSiteRepo, contentRepo *repo.ContentRepo, userContentRepo *repo.UserContentRepo, violationsSvc *violations.Service) *SiteContentCheckHandler {
+	return &SiteContentCheckHandler{
+		siteRepo:        siteRepo,
+		userSiteRepo:    userSiteRepo,
+		contentRepo:     contentRepo,
+		userContentRepo: userContentRepo,
+		violationsSvc:   violationsSvc,
+		jobs:            newContentCheckJobs(),
+	}
+}
+
+// CheckAllContent godoc
+// @Summary Check all content against one site
+// @Description Recalculate violations of every accessible content item, matching only pages of this site. Runs in background
+// @Tags sites
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Site ID"
+// @Success 202 {object} ContentCheckJob
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/check-all-content [post]
+func (h *SiteContentCheckHandler) CheckAllContent(c *fiber.Ctx) error {
+	siteID := c.Params("id")
+	if _, err := checkSiteAccess(c, h.siteRepo, h.userSiteRepo, siteID); err != nil {
+		return err
+	}
+
+	allowed, err := visibleContentIDs(c, h.userContentRepo)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch user content"})
+	}
+	contents, err := h.contentRepo.GetAll(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch content"})
+	}
+
+	infos := make([]violations.ContentInfo, 0, len(contents))
+	for _, ct := range contents {
+		if allowed != nil && !allowed[ct.ID.Hex()] {
+			continue
+		}
+		infos = append(infos, violations.ContentInfo{
+			ID:            ct.ID.Hex(),
+			Title:         ct.Title,
+			OriginalTitle: ct.OriginalTitle,
+			Aliases:       ct.Aliases,
+			Year:          ct.Year,
+			KinopoiskID:   ct.KinopoiskID,
+			IMDBID:        ct.IMDBID,
+			MALID:         ct.MALID,
+			ShikimoriID:   ct.ShikimoriID,
+			MyDramaListID: ct.MyDramaListID,
+			Language:      ct.Language,
+			Region:        ct.Region,
+		})
+	}
+
+	job, created := h.jobs.start(siteID, len(infos))
+	if created {
+		go h.run(job.ID, siteID, infos)
+	}
+
+	snapshot, _ := h.jobs.get(job.ID)
+	return c.Status(202).JSON(snapshot)
+}
+
+func (h *SiteContentCheckHandler) run(jobID, siteID string, contents []violations.ContentInfo) {
+	log := logger.Log
+	// Запрос уже завершён, его контекст использовать нельзя
+	ctx := context.Background()
+
+	for _, content := range contents {
+		_, err := h.violationsSvc.RefreshForContentOnSite(ctx, content, siteID)
+		if err != nil {
+			log.Warn().Err(err).Str("site_id", siteID).Str("content_id", content.ID).Msg("site content check failed")
+		}
+		h.jobs.progress(jobID, err != nil)
+	}
+	h.jobs.finish(jobID)
+
+	if job, ok := h.jobs.get(jobID); ok {
+		log.Info().
+			Str("job_id", jobID).
+			Str("site_id", siteID).
+			Int("checked", job.Checked).
+			Int("failed", job.Failed).
+			Msg("site content check finished")
+	}
+}
+
+// GetCheckAllContentJob godoc
+// @Summary Get site content check status
+// @Description Progress of a background check started by check-all-content
+// @Tags sites
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Site ID"
+// @Param job_id path string true "Job ID"
+// @Success 200 {object} ContentCheckJob
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/check-all-content/{job_id} [get]
+func (h *SiteContentCheckHandler) GetCheckAllContentJob(c *fiber.Ctx) error {
+	siteID := c.Params("id")
+	if _, err := checkSiteAccess(c, h.siteRepo, h.userSiteRepo, siteID); err != nil {
+		return err
+	}
+
+	job, ok := h.jobs.get(c.Params("job_id"))
+	if !ok || job.SiteID != siteID {
+		return c.Status(404).JSON(ErrorResponse{Error: "job not found"})
+	}
+	return c.JSON(job)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestContentCheckJobsOnePerSite(t *testing.T) {
+	jobs := newContentCheckJobs()
+
+	first, created := jobs.start("site1", 2)
+	if !created {
+		t.Fatal("first job not created")
+	}
+	again, created := jobs.start("site1", 5)
+	if created || again.ID != first.ID {
+		t.Fatalf("running job for site1 must be reused, got %s", again.ID)
+	}
+	if _, created := jobs.start("site2", 1); !created {
+		t.Fatal("job for another site must be created")
+	}
+
+	jobs.progress(first.ID, false)
+	jobs.progress(first.ID, true)
+	jobs.finish(first.ID)
+
+	got, ok := jobs.get(first.ID)
+	if !ok || got.Status != ContentCheckCompleted || got.Checked != 2 || got.Failed != 1 || got.FinishedAt == nil {
+		t.Fatalf("finished job = %+v", got)
+	}
+	if next, created := jobs.start("site1", 1); !created || next.ID == first.ID {
+		t.Fatal("finished job must not block a new check")
+	}
+}
+
+func TestContentCheckJobsPrune(t *testing.T) {
+	jobs := newContentCheckJobs()
+	job, _ := jobs.start("site1", 1)
+	jobs.finish(job.ID)
+
+	jobs.mu.Lock()
+	jobs.pruneLocked(time.Now().Add(contentCheckJobTTL + time.Minute))
+	jobs.mu.Unlock()
+
+	if _, ok := jobs.get(job.ID); ok {
+		t.Fatal("expired job not pruned")
+	}
+}
@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/indexer/internal/middleware"
+	"github.com/video-analitics/indexer/internal/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// detectPublishChunk — сколько задач детекции публикуется за раз
+	detectPublishChunk = 50
+	// detectBacklogLimit — при таком хвосте в очереди детекции публикация ждёт парсер
+	detectBacklogLimit = 200
+	detectBacklogPoll  = 5 * time.Second
+)
+
+const (
+	SiteImportRunning   = "running"
+	SiteImportCompleted = "completed"
+)
+
+// siteImportJobTTL — сколько хранится завершённый импорт
+const siteImportJobTTL = time.Hour
+
+type siteImportOutcome int
+
+const (
+	siteImportFailed siteImportOutcome = iota
+	siteImportCreated
+	siteImportLinked
+)
+
+type detectTarget struct {
+	siteID string
+	domain string
+}
+
+// SiteImportJob - фоновое добавление пачки сайтов
+type SiteImportJob struct {
+	ID     string `json:"job_id"`
+	UserID string `json:"-"`
+	Status string `json:"status"`
+	Total  int    `json:"total"`
+	// Processed — сколько строк пачки уже разобрано
+	Processed int `json:"processed"`
+	Created   int `json:"created"`
+	Linked    int `json:"linked"`
+	Failed    int `json:"failed"`
+	// DetectQueued — сколько задач детекции уже отправлено парсеру
+	DetectQueued int        `json:"detect_queued"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+// siteImportJobs — задачи хранятся в памяти процесса и теряются при рестарте
+type siteImportJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*SiteImportJob
+}
+
+func newSiteImportJobs() *siteImportJobs {
+	return &siteImportJobs{jobs: make(map[string]*SiteImportJob)}
+}
+
+func (j *siteImportJobs) start(userID string, total int) *SiteImportJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	for id, job := range j.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > siteImportJobTTL {
+			delete(j.jobs, id)
+		}
+	}
+
+	job := &SiteImportJob{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Status:    SiteImportRunning,
+		Total:     total,
+		StartedAt: now,
+	}
+	j.jobs[job.ID] = job
+	return job
+}
+
+func (j *siteImportJobs) update(id string, fn func(job *SiteImportJob)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if job, ok := j.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// get возвращает копию, чтобы не читать поля под записью фоновой горутины
+func (j *siteImportJobs) get(id string) (SiteImportJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return SiteImportJob{}, false
+	}
+	return *job, true
+}
+
+// importSite добавляет один сайт пачки; для созданного сайта нужна детекция
+func (h *SiteHandler) importSite(ctx context.Context, siteReq CreateSiteRequest, userID string, ownerOID primitive.ObjectID, isAdmin bool) (siteImportOutcome, *repo.Site) {
+	if siteReq.Domain == "" {
+		return siteImportFailed, nil
+	}
+
+	domain := normalizeDomain(siteReq.Domain)
+	if domain == "" {
+		return siteImportFailed, nil
+	}
+
+	existing, _ := h.siteRepo.FindByDomain(ctx, domain)
+	if existing != nil && existing.DeletedAt != nil {
+		if _, err := h.trash.RestoreSite(ctx, existing.ID.Hex()); err != nil {
+			return siteImportFailed, nil
+		}
+	}
+	if existing != nil {
+		if !isAdmin && !ownerOID.IsZero() {
+			if existing.OwnerID != ownerOID {
+				linkExists, _ := h.userSiteRepo.ExistsByUserAndSite(ctx, userID, existing.ID.Hex())
+				if !linkExists {
+					h.userSiteRepo.Create(ctx, &repo.UserSite{
+						UserID: ownerOID,
+						SiteID: existing.ID,
+					})
+					return siteImportLinked, existing
+				}
+			}
+		}
+		return siteImportFailed, nil
+	}
+
+	site := &repo.Site{
+		OwnerID:         ownerOID,
+		Domain:          domain,
+		CMS:             siteReq.CMS,
+		HasSitemap:      siteReq.HasSitemap,
+		SitemapURLs:     siteReq.SitemapURLs,
+		SitemapJSONPath: siteReq.SitemapJSONPath,
+		ScanIntervalH:   siteReq.ScanIntervalH,
+	}
+
+	if err := h.siteRepo.Create(ctx, site); err != nil {
+		return siteImportFailed, nil
+	}
+	return siteImportCreated, site
+}
+
+// publishDetectChunked отправляет задачи детекции пачками, дожидаясь, пока парсер
+// разберёт очередь, чтобы большой импорт не забил NATS и пул браузера
+func (h *SiteHandler) publishDetectChunked(ctx context.Context, targets []detectTarget, progress func(queued int)) {
+	log := logger.Log
+
+	for start := 0; start < len(targets); start += detectPublishChunk {
+		if err := waitDetectBacklog(ctx, h.publisher.DetectBacklog, detectBacklogLimit, detectBacklogPoll); err != nil {
+			return
+		}
+
+		end := min(start+detectPublishChunk, len(targets))
+		for _, t := range targets[start:end] {
+			if err := h.publisher.PublishDetectTask(ctx, uuid.New().String(), t.siteID, t.domain); err != nil {
+				log.Warn().Err(err).Str("site_id", t.siteID).Msg("failed to publish detect task")
+			}
+		}
+		if progress != nil {
+			progress(end)
+		}
+	}
+}
+
+// waitDetectBacklog ждёт, пока хвост очереди детекции не станет меньше limit.
+// Если хвост узнать не удалось, публикация не блокируется
+func waitDetectBacklog(ctx context.Context, backlog func(ctx context.Context) (uint64, error), limit uint64, poll time.Duration) error {
+	for {
+		n, err := backlog(ctx)
+		if err != nil || n < limit {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+}
+
+// Import godoc
+// @Summary Import sites in background
+// @Description Add a large batch of sites as a background job. Detect tasks are published in chunks while the parser keeps up
+// @Tags sites
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateSitesBatchRequest true "Sites data"
+// @Success 202 {object} SiteImportJob
+// @Failure 400 {object} ErrorResponse
+// @Router /api/sites/import [post]
+func (h *SiteHandler) Import(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	isAdmin := middleware.IsAdmin(c)
+
+	var req CreateSitesBatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid request body"})
+	}
+
+	if len(req.Sites) == 0 {
+		return c.Status(400).JSON(ErrorResponse{Error: "sites array is required"})
+	}
+
+	var ownerOID primitive.ObjectID
+	if !isAdmin && userID != "" {
+		var err error
+		ownerOID, err = primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "invalid user id"})
+		}
+	}
+
+	job := h.imports.start(userID, len(req.Sites))
+	go h.runImport(job.ID, req.Sites, userID, ownerOID, isAdmin)
+
+	snapshot, _ := h.imports.get(job.ID)
+	return c.Status(202).JSON(snapshot)
+}
+
+func (h *SiteHandler) runImport(jobID string, sites []CreateSiteRequest, userID string, ownerOID primitive.ObjectID, isAdmin bool) {
+	// Запрос уже завершён, его контекст использовать нельзя
+	ctx := context.Background()
+
+	var targets []detectTarget
+	for _, siteReq := range sites {
+		outcome, site := h.importSite(ctx, siteReq, userID, ownerOID, isAdmin)
+		if outcome == siteImportCreated {
+			targets = append(targets, detectTarget{siteID: site.ID.Hex(), domain: site.Domain})
+		}
+		h.imports.update(jobID, func(job *SiteImportJob) {
+			job.Processed++
+			switch outcome {
+			case siteImportCreated:
+				job.Created++
+			case siteImportLinked:
+				job.Linked++
+			default:
+				job.Failed++
+			}
+		})
+	}
+
+	h.publishDetectChunked(ctx, targets, func(queued int) {
+		h.imports.update(jobID, func(job *SiteImportJob) { job.DetectQueued = queued })
+	})
+
+	h.imports.update(jobID, func(job *SiteImportJob) {
+		now := time.Now()
+		job.Status = SiteImportCompleted
+		job.FinishedAt = &now
+	})
+
+	if job, ok := h.imports.get(jobID); ok {
+		logger.Log.Info().
+			Str("job_id", jobID).
+			Int("created", job.Created).
+			Int("linked", job.Linked).
+			Int("failed", job.Failed).
+			Msg("site import finished")
+	}
+}
+
+// GetImportJob godoc
+// @Summary Get site import status
+// @Description Progress of a background import started by POST /sites/import
+// @Tags sites
+// @Produce json
+// @Security BearerAuth
+// @Param job_id path string true "Job ID"
+// @Success 200 {object} SiteImportJob
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/import/{job_id} [get]
+func (h *SiteHandler) GetImportJob(c *fiber.Ctx) error {
+	job, ok := h.imports.get(c.Params("job_id"))
+	if !ok || (!middleware.IsAdmin(c) && job.UserID != middleware.GetUserID(c)) {
+		return c.Status(404).JSON(ErrorResponse{Error: "job not found"})
+	}
+	return c.JSON(job)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitDetectBacklog(t *testing.T) {
+	t.Run("waits until backlog drains", func(t *testing.T) {
+		backlogs := []uint64{500, 300, 10}
+		calls := 0
+		backlog := func(context.Context) (uint64, error) {
+			n := backlogs[calls]
+			calls++
+			return n, nil
+		}
+		if err := waitDetectBacklog(context.Background(), backlog, 200, time.Millisecond); err != nil {
+			t.Fatalf("waitDetectBacklog() error = %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("backlog calls = %d, want 3", calls)
+		}
+	})
+
+	t.Run("does not block when backlog is unknown", func(t *testing.T) {
+		backlog := func(context.Context) (uint64, error) { return 0, errors.New("nats down") }
+		if err := waitDetectBacklog(context.Background(), backlog, 200, time.Hour); err != nil {
+			t.Fatalf("waitDetectBacklog() error = %v", err)
+		}
+	})
+
+	t.Run("stops on cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		backlog := func(context.Context) (uint64, error) { return 1000, nil }
+		if err := waitDetectBacklog(ctx, backlog, 200, time.Hour); !errors.Is(err, context.Canceled) {
+			t.Fatalf("waitDetectBacklog() error = %v, want context.Canceled", err)
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/indexer/internal/middleware"
+)
+
+const (
+	// minClusterLabel — короче этой длины вхождение одной метки в другую — совпадение, а не кластер
+	minClusterLabel = 5
+	// minSharedContent — сколько общего контента нужно сайтам на одной CMS, чтобы считаться связанными
+	minSharedContent = 3
+)
+
+const (
+	RelatedSameLabel        = "same_label"         // lordfilm.org и lordfilm.lat
+	RelatedContainsLabel    = "contains_label"     // lordfilm.org и lordfilmfiwy.lat
+	RelatedSimilarLabel     = "similar_label"      // lordfilm.org и lordfilms.biz
+	RelatedSharedCMSContent = "shared_cms_content" // одна CMS и много общего нарушающего контента
+)
+
+// multiPartSuffixes — составные зоны, в которых регистрируемая метка стоит левее
+var multiPartSuffixes = map[string]bool{
+	"com.ua": true, "org.ua": true, "net.ua": true, "in.ua": true, "kiev.ua": true,
+	"com.ru": true, "org.ru": true, "net.ru": true, "msk.ru": true, "spb.ru": true,
+	"co.uk": true, "org.uk": true, "com.kz": true, "org.kz": true, "com.by": true,
+	"com.br": true, "com.tr": true, "co.in": true, "com.au": true, "co.cc": true,
+}
+
+// registrableLabel — метка домена, которую регистрирует владелец: www.lordfilm.co.uk → lordfilm
+func registrableLabel(domain string) string {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	parts := strings.Split(domain, ".")
+	switch {
+	case len(parts) < 2:
+		return domain
+	case len(parts) >= 3 && multiPartSuffixes[strings.Join(parts[len(parts)-2:], ".")]:
+		return parts[len(parts)-3]
+	default:
+		return parts[len(parts)-2]
+	}
+}
+
+// domainSimilarity объясняет, почему домены похожи на одну сеть зеркал; пустая строка — не похожи
+func domainSimilarity(a, b string) string {
+	la, lb := registrableLabel(a), registrableLabel(b)
+	if la == "" || lb == "" || strings.EqualFold(a, b) {
+		return ""
+	}
+	if la == lb {
+		return RelatedSameLabel
+	}
+
+	short, long := la, lb
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	if len(short) >= minClusterLabel && strings.Contains(long, short) {
+		return RelatedContainsLabel
+	}
+
+	// Допустимые опечатки растут с длиной метки: lordfilm ≈ lordfllm, но kino ≠ kina
+	maxDistance := 1
+	if len(short) >= 8 {
+		maxDistance = 2
+	}
+	if len(short) >= minClusterLabel && editDistance(la, lb) <= maxDistance {
+		return RelatedSimilarLabel
+	}
+	return ""
+}
+
+// editDistance — расстояние Левенштейна по рунам
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+type RelatedSite struct {
+	SiteID string `json:"site_id"`
+	Domain string `json:"domain"`
+	Status string `json:"status"`
+	CMS    string `json:"cms,omitempty"`
+	// Reasons — почему сайт считается частью той же сети
+	Reasons []string `json:"reasons"`
+	// SharedContent — сколько контента нарушают оба сайта
+	SharedContent int64 `json:"shared_content,omitempty"`
+}
+
+type RelatedSitesResponse struct {
+	Items []RelatedSite `json:"items"`
+	Total int           `json:"total"`
+}
+
+// GetRelated godoc
+// @Summary Find related sites
+// @Description Other monitored sites that look like the same mirror network: the registrable domain label is the same, contains the other or differs by a typo, or the sites run the same CMS and violate the same content
+// @Tags sites
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Site ID"
+// @Success 200 {object} RelatedSitesResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/related [get]
+func (h *SiteHandler) GetRelated(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	site, err := h.checkSiteAccess(c, id)
+	if err != nil {
+		return err
+	}
+
+	candidates, err := h.siteRepo.ListDomains(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch sites"})
+	}
+
+	var visible map[string]bool
+	if !middleware.IsAdmin(c) {
+		ids, err := h.siteRepo.GetAccessibleSiteIDs(c.Context(), middleware.GetUserID(c), h.userSiteRepo)
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch accessible sites"})
+		}
+		visible = make(map[string]bool, len(ids))
+		for _, siteID := range ids {
+			visible[siteID] = true
+		}
+	}
+
+	var shared map[string]int64
+	if site.CMS != "" {
+		contentIDs, err := h.violationsSvc.GetDistinctContentIDs(c.Context(), id)
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch site violations"})
+		}
+		// пользователь не должен узнавать о пересечениях по чужому контенту
+		allowed, err := visibleContentIDs(c, h.userContentRepo)
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch user content"})
+		}
+		contentIDs = filterContentIDs(contentIDs, "", allowed)
+		if len(contentIDs) > 0 {
+			if shared, err = h.violationsSvc.CountSharedContentBySite(c.Context(), contentIDs, id); err != nil {
+				return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch shared violations"})
+			}
+		}
+	}
+
+	items := []RelatedSite{}
+	for _, candidate := range candidates {
+		candidateID := candidate.ID.Hex()
+		if candidateID == id || (visible != nil && !visible[candidateID]) {
+			continue
+		}
+
+		var reasons []string
+		if reason := domainSimilarity(site.Domain, candidate.Domain); reason != "" {
+			reasons = append(reasons, reason)
+		}
+		sharedCount := shared[candidateID]
+		if candidate.CMS != "" && strings.EqualFold(candidate.CMS, site.CMS) && sharedCount >= minSharedContent {
+			reasons = append(reasons, RelatedSharedCMSContent)
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+
+		items = append(items, RelatedSite{
+			SiteID:        candidateID,
+			Domain:        candidate.Domain,
+			Status:        string(candidate.Status),
+			CMS:           candidate.CMS,
+			Reasons:       reasons,
+			SharedContent: sharedCount,
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if len(items[i].Reasons) != len(items[j].Reasons) {
+			return len(items[i].Reasons) > len(items[j].Reasons)
+		}
+		return items[i].Domain < items[j].Domain
+	})
+
+	return c.JSON(RelatedSitesResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
@@ -0,0 +1,68 @@
+package handler
+
+import "testing"
+
+func TestRegistrableLabel(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   string
+	}{
+		{"lordfilm.org", "lordfilm"},
+		{"www.lordfilm.org", "lordfilm"},
+		{"LordFilm1.BIZ.", "lordfilm1"},
+		{"hd.lordfilm.com.ua", "lordfilm"},
+		{"kino.co.uk", "kino"},
+		{"localhost", "localhost"},
+	}
+
+	for _, tt := range tests {
+		if got := registrableLabel(tt.domain); got != tt.want {
+			t.Errorf("registrableLabel(%q) = %q, want %q", tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestDomainSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"lordfilm.org", "lordfilm.lat", RelatedSameLabel},
+		{"lordfilm.org", "www.lordfilm.com.ua", RelatedSameLabel},
+		{"lordfilm.org", "lordfilmfiwy.lat", RelatedContainsLabel},
+		{"lordfilm.org", "lordfilm1.biz", RelatedContainsLabel},
+		{"lordfilm.org", "lordfllm.net", RelatedSimilarLabel},
+		{"hdrezka.ag", "hdrezka-x.me", RelatedContainsLabel},
+		{"lordfilm.org", "lordfilm.org", ""},
+		{"lordfilm.org", "kinogo.biz", ""},
+		// короткие метки не кластеризуются по вхождению и опечаткам
+		{"kino.ru", "kinogo.biz", ""},
+		{"kino.ru", "kina.ru", ""},
+	}
+
+	for _, tt := range tests {
+		if got := domainSimilarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("domainSimilarity(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"lordfilm", "lordfilm", 0},
+		{"lordfilm", "lordfllm", 1},
+		{"lordfilm", "lrodfilm", 2},
+		{"кино", "кина", 1},
+		{"", "abc", 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
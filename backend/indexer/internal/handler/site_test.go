@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/video-analitics/backend/pkg/status"
+)
+
+func TestValidateScanInterval(t *testing.T) {
+	tests := []struct {
+		hours int
+		valid bool
+	}{
+		{0, false},
+		{-1, false},
+		{1, true},
+		{24, true},
+		{720, true},
+		{721, false},
+	}
+
+	for _, tt := range tests {
+		if got := validateScanInterval(tt.hours) == ""; got != tt.valid {
+			t.Errorf("validateScanInterval(%d) valid = %v, want %v", tt.hours, got, tt.valid)
+		}
+	}
+}
+
+func TestUnfreezeEachPartialSuccess(t *testing.T) {
+	sites := map[string]status.Site{
+		"frozen-1": status.SiteFrozen,
+		"frozen-2": status.SiteFrozen,
+		"active":   status.SiteActive,
+		"foreign":  status.SiteFrozen,
+		// Статус сменился между проверкой и обновлением
+		"raced": status.SiteFrozen,
+	}
+	accessible := map[string]bool{"frozen-1": true, "frozen-2": true, "active": true, "raced": true}
+
+	var unfrozen []string
+	resp := unfreezeEach(context.Background(),
+		[]string{"frozen-1", "active", "foreign", "missing", "raced", "frozen-2", "frozen-1"},
+		func(_ context.Context, id string) bool {
+			st, ok := sites[id]
+			return ok && accessible[id] && st == status.SiteFrozen
+		},
+		func(_ context.Context, id string) error {
+			if id == "raced" {
+				return status.ErrConcurrentUpdate
+			}
+			unfrozen = append(unfrozen, id)
+			return nil
+		},
+	)
+
+	want := UnfreezeBulkResponse{Unfrozen: 2, Skipped: 5}
+	if resp != want {
+		t.Errorf("unfreezeEach() = %+v, want %+v", resp, want)
+	}
+	if len(unfrozen) != 2 || unfrozen[0] != "frozen-1" || unfrozen[1] != "frozen-2" {
+		t.Errorf("unfrozen = %v, want [frozen-1 frozen-2]", unfrozen)
+	}
+}
+
+func TestDNSOverrideFromRequestPrivateAddresses(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          DNSOverrideRequest
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{"public host ip", DNSOverrideRequest{Hosts: map[string]string{"example.com": "93.184.215.14"}}, false, false},
+		{"public resolver", DNSOverrideRequest{Resolver: "1.1.1.1:53"}, false, false},
+		{"loopback host ip", DNSOverrideRequest{Hosts: map[string]string{"example.com": "127.0.0.1"}}, false, true},
+		{"metadata host ip", DNSOverrideRequest{Hosts: map[string]string{"example.com": "169.254.169.254"}}, false, true},
+		{"private ipv6 host ip", DNSOverrideRequest{Hosts: map[string]string{"example.com": "fd00::1"}}, false, true},
+		{"private resolver", DNSOverrideRequest{Resolver: "10.0.0.2"}, false, true},
+		{"admin may pin private ip", DNSOverrideRequest{Hosts: map[string]string{"example.com": "10.0.0.5"}, Resolver: "[::1]:5353"}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errMsg := dnsOverrideFromRequest(tt.req, tt.allowPrivate)
+			if (errMsg != "") != tt.wantErr {
+				t.Errorf("dnsOverrideFromRequest() error = %q, want error: %v", errMsg, tt.wantErr)
+			}
+		})
+	}
+}
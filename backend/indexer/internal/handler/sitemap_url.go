@@ -1,19 +1,32 @@
 package handler
 
 import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/status"
 	"github.com/video-analitics/indexer/internal/repo"
 )
 
 type SitemapURLHandler struct {
 	sitemapURLRepo *repo.SitemapURLRepo
+	siteRepo       *repo.SiteRepo
+	userSiteRepo   *repo.UserSiteRepo
+	pageRepo       *repo.PageRepo
 }
 
-func NewSitemapURLHandler(sitemapURLRepo *repo.SitemapURLRepo) *SitemapURLHandler {
+func NewSitemapURLHandler(sitemapURLRepo *repo.SitemapURLRepo, siteRepo *repo.SiteRepo, userSiteRepo *repo.UserSiteRepo, pageRepo *repo.PageRepo) *SitemapURLHandler {
 	return &SitemapURLHandler{
 		sitemapURLRepo: sitemapURLRepo,
+		siteRepo:       siteRepo,
+		userSiteRepo:   userSiteRepo,
+		pageRepo:       pageRepo,
 	}
 }
 
@@ -96,9 +109,265 @@ func (h *SitemapURLHandler) Stats(c *fiber.Ctx) error {
 	return c.JSON(stats)
 }
 
+// сбрасываем CSV клиенту каждые N строк, чтобы не копить буфер
+const exportFlushEvery = 1000
+
+// ExportCSV godoc
+// @Summary Export sitemap URLs to CSV
+// @Description Stream all discovered URLs of the site as CSV: url, status, depth, source, last parsed time, error
+// @Tags sites
+// @Produce text/csv
+// @Param id path string true "Site ID"
+// @Success 200 {file} file
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/sitemap-urls/export [get]
+func (h *SitemapURLHandler) ExportCSV(c *fiber.Ctx) error {
+	siteID := c.Params("id")
+
+	site, err := checkSiteAccess(c, h.siteRepo, h.userSiteRepo, siteID)
+	if err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("sitemap_urls_%s.csv", site.Domain)
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+	// Тело пишется после выхода из хендлера — fiber.Ctx внутри использовать нельзя
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		w.Write([]byte{0xEF, 0xBB, 0xBF})
+
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"url", "status", "depth", "source", "last_parsed", "error"})
+
+		rows := 0
+		err := h.sitemapURLRepo.ForEachBySiteID(context.Background(), siteID, func(u *repo.SitemapURL) error {
+			writer.Write(sitemapURLCSVRow(u))
+			rows++
+			if rows%exportFlushEvery == 0 {
+				writer.Flush()
+				if err := writer.Error(); err != nil {
+					return err
+				}
+				return w.Flush()
+			}
+			return nil
+		})
+		writer.Flush()
+		if err != nil {
+			logger.Log.Warn().Err(err).Str("site", siteID).Int("rows", rows).Msg("sitemap urls export interrupted")
+		}
+	})
+
+	return nil
+}
+
+func sitemapURLCSVRow(u *repo.SitemapURL) []string {
+	lastParsed := u.IndexedAt
+	if lastParsed == nil {
+		lastParsed = u.LastAttemptAt
+	}
+	lastParsedStr := ""
+	if lastParsed != nil {
+		lastParsedStr = lastParsed.Format(time.RFC3339)
+	}
+
+	return []string{
+		u.URL,
+		string(u.Status),
+		strconv.Itoa(u.Depth),
+		u.SitemapSource,
+		lastParsedStr,
+		u.Error,
+	}
+}
+
+type PageStatusItem struct {
+	URL           string     `json:"url"`
+	Status        string     `json:"status"`
+	Error         string     `json:"error,omitempty"`
+	RetryCount    int        `json:"retry_count"`
+	Retrying      bool       `json:"retrying"` // ошибка не окончательная, URL ещё будет перепарсен
+	Depth         int        `json:"depth"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	IndexedAt     *time.Time `json:"indexed_at,omitempty"`
+}
+
+type PageStatusesResponse struct {
+	Items []PageStatusItem `json:"items"`
+	Total int64            `json:"total"`
+	Limit int              `json:"limit"`
+	Page  int              `json:"page"`
+}
+
+// ListPageStatuses godoc
+// @Summary List per-URL crawl status
+// @Description Paginated parse status of every site URL with the last error and retry count. status=failed returns URLs whose last parse attempt failed, both terminal errors and URLs waiting for a retry
+// @Tags sites
+// @Produce json
+// @Param id path string true "Site ID"
+// @Param status query string false "Filter: failed, pending, processing, indexed, error, skipped"
+// @Param limit query int false "Items per page" default(50)
+// @Param page query int false "Page number" default(1)
+// @Success 200 {object} PageStatusesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/pages [get]
+func (h *SitemapURLHandler) ListPageStatuses(c *fiber.Ctx) error {
+	siteID := c.Params("id")
+
+	if _, err := checkSiteAccess(c, h.siteRepo, h.userSiteRepo, siteID); err != nil {
+		return err
+	}
+
+	pageStatus := c.Query("status", "")
+	if !isValidPageStatus(pageStatus) {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid status, must be one of failed, pending, processing, indexed, error, skipped"})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if limit < 1 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	urls, total, err := h.sitemapURLRepo.FindPageStatuses(c.Context(), siteID, pageStatus, limit, (page-1)*limit)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch page statuses"})
+	}
+
+	items := make([]PageStatusItem, len(urls))
+	for i, u := range urls {
+		items[i] = PageStatusItem{
+			URL:           u.URL,
+			Status:        string(u.Status),
+			Error:         u.Error,
+			RetryCount:    u.RetryCount,
+			Retrying:      u.Error != "" && u.Status != status.URLError && u.Status != status.URLSkipped,
+			Depth:         u.Depth,
+			LastAttemptAt: u.LastAttemptAt,
+			IndexedAt:     u.IndexedAt,
+		}
+	}
+
+	return c.JSON(PageStatusesResponse{
+		Items: items,
+		Total: total,
+		Limit: limit,
+		Page:  page,
+	})
+}
+
+type FailedURLItem struct {
+	URL           string     `json:"url"`
+	State         string     `json:"state"` // retrying — ждёт повтора, final — попытки исчерпаны
+	Status        string     `json:"status"`
+	Error         string     `json:"error"`
+	RetryCount    int        `json:"retry_count"`
+	SitemapSource string     `json:"sitemap_source,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	NextRetryAt   *time.Time `json:"next_retry_at,omitempty"`
+}
+
+type FailedURLsResponse struct {
+	Items []FailedURLItem `json:"items"`
+	Total int64           `json:"total"`
+	Limit int             `json:"limit"`
+	Page  int             `json:"page"`
+}
+
+// ListFailed godoc
+// @Summary List failing sitemap URLs
+// @Description URLs whose last parse attempt failed, with the error and retry count, latest attempts first. state=retrying keeps URLs waiting for a retry, state=final — URLs that ran out of retries
+// @Tags sites
+// @Produce json
+// @Param id path string true "Site ID"
+// @Param state query string false "Filter by failure state" Enums(retrying, final)
+// @Param limit query int false "Items per page" default(50)
+// @Param page query int false "Page number" default(1)
+// @Success 200 {object} FailedURLsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/sites/{id}/sitemap-urls/failed [get]
+func (h *SitemapURLHandler) ListFailed(c *fiber.Ctx) error {
+	siteID := c.Params("id")
+
+	if _, err := checkSiteAccess(c, h.siteRepo, h.userSiteRepo, siteID); err != nil {
+		return err
+	}
+
+	state := c.Query("state")
+	if state != "" && state != repo.FailedStateRetrying && state != repo.FailedStateFinal {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid state, must be one of retrying, final"})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if limit < 1 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	urls, total, err := h.sitemapURLRepo.FindFailed(c.Context(), siteID, state, limit, (page-1)*limit)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch failed urls"})
+	}
+
+	items := make([]FailedURLItem, len(urls))
+	for i := range urls {
+		u := &urls[i]
+		itemState := repo.FailedStateRetrying
+		if u.Status == status.URLError {
+			itemState = repo.FailedStateFinal
+		}
+		items[i] = FailedURLItem{
+			URL:           u.URL,
+			State:         itemState,
+			Status:        string(u.Status),
+			Error:         u.Error,
+			RetryCount:    u.RetryCount,
+			SitemapSource: u.SitemapSource,
+			LastAttemptAt: u.LastAttemptAt,
+			NextRetryAt:   u.NextRetryAt(),
+		}
+	}
+
+	return c.JSON(FailedURLsResponse{
+		Items: items,
+		Total: total,
+		Limit: limit,
+		Page:  page,
+	})
+}
+
+func isValidPageStatus(s string) bool {
+	switch status.URL(s) {
+	case "", repo.PageStatusFailed, status.URLPending, status.URLProcessing, status.URLIndexed, status.URLError, status.URLSkipped:
+		return true
+	}
+	return false
+}
+
 type PendingURLWithDepth struct {
 	URL   string `json:"url"`
 	Depth int    `json:"depth"`
+	// ETag/LastModified — валидаторы уже проиндексированной страницы; парсер запросит её условно
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
 }
 
 type PendingURLsResponse struct {
@@ -171,11 +440,23 @@ func (h *SitemapURLHandler) GetPending(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	rawURLs := make([]string, len(sitemapURLs))
+	for i, u := range sitemapURLs {
+		rawURLs[i] = u.URL
+	}
+	validators, err := h.pageRepo.FindValidators(c.Context(), siteID, rawURLs)
+	if err != nil {
+		// Без валидаторов парсер просто загрузит страницы целиком
+		validators = nil
+	}
+
 	urls := make([]PendingURLWithDepth, len(sitemapURLs))
 	for i, u := range sitemapURLs {
 		urls[i] = PendingURLWithDepth{
-			URL:   u.URL,
-			Depth: u.Depth,
+			URL:          u.URL,
+			Depth:        u.Depth,
+			ETag:         validators[u.URL].ETag,
+			LastModified: validators[u.URL].LastModified,
 		}
 	}
 
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/backend/pkg/violations"
+	"github.com/video-analitics/indexer/internal/middleware"
+	"github.com/video-analitics/indexer/internal/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultTopSitesLimit = 20
+	maxTopSitesLimit     = 100
+)
+
+type StatsHandler struct {
+	violationsSvc   *violations.Service
+	userContentRepo *repo.UserContentRepo
+	siteRepo        *repo.SiteRepo
+	userSiteRepo    *repo.UserSiteRepo
+}
+
+func NewStatsHandler(violationsSvc *violations.Service, userContentRepo *repo.UserContentRepo, siteRepo *repo.SiteRepo, userSiteRepo *repo.UserSiteRepo) *StatsHandler {
+	return &StatsHandler{
+		violationsSvc:   violationsSvc,
+		userContentRepo: userContentRepo,
+		siteRepo:        siteRepo,
+		userSiteRepo:    userSiteRepo,
+	}
+}
+
+// SiteStatuses godoc
+// @Summary Site counts by status
+// @Description Number of sites in each status (active, pending, down, frozen, moved, dead). Admins see all sites, other users only their own and shared ones
+// @Tags stats
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} repo.SiteStatusCounts
+// @Failure 500 {object} ErrorResponse
+// @Router /api/stats/sites [get]
+func (h *StatsHandler) SiteStatuses(c *fiber.Ctx) error {
+	// nil — все сайты (админ)
+	var siteIDs []string
+	if !middleware.IsAdmin(c) {
+		ids, err := h.siteRepo.GetAccessibleSiteIDs(c.Context(), middleware.GetUserID(c), h.userSiteRepo)
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch user sites"})
+		}
+		// Пустой, но не nil срез — нет доступа ни к одному сайту
+		siteIDs = append([]string{}, ids...)
+	}
+
+	counts, err := h.siteRepo.CountByStatus(c.Context(), siteIDs)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to count sites"})
+	}
+
+	return c.JSON(counts)
+}
+
+type TopSitesResponse struct {
+	Items []violations.TopSite `json:"items"`
+}
+
+// TopSites godoc
+// @Summary Top sites by violations
+// @Description Sites ranked by total violation count. Admins see all content, other users only their own
+// @Tags stats
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Max sites to return (default 20, max 100)"
+// @Success 200 {object} TopSitesResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/stats/top-sites [get]
+func (h *StatsHandler) TopSites(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	if limit <= 0 {
+		limit = defaultTopSitesLimit
+	}
+	if limit > maxTopSitesLimit {
+		limit = maxTopSitesLimit
+	}
+
+	// nil — без ограничения по контенту (админ)
+	var contentIDs []string
+	if !middleware.IsAdmin(c) {
+		userOID, err := primitive.ObjectIDFromHex(middleware.GetUserID(c))
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "invalid user id"})
+		}
+		ids, err := h.userContentRepo.GetContentIDs(c.Context(), userOID)
+		if err != nil {
+			return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch user content"})
+		}
+		if len(ids) == 0 {
+			return c.JSON(TopSitesResponse{Items: []violations.TopSite{}})
+		}
+		contentIDs = make([]string, len(ids))
+		for i, id := range ids {
+			contentIDs[i] = id.Hex()
+		}
+	}
+
+	sites, err := h.violationsSvc.GetTopSites(c.Context(), contentIDs, int64(limit))
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch top sites"})
+	}
+	if sites == nil {
+		sites = []violations.TopSite{}
+	}
+
+	return c.JSON(TopSitesResponse{Items: sites})
+}
+
+// MatchTypes godoc
+// @Summary Violations by match type
+// @Description Histogram of all violations by matcher stage with counts and percentage, to see how much of the data relies on fuzzy stages. since/until limit found_at — when a violation was last confirmed by a recalculation
+// @Tags stats
+// @Security BearerAuth
+// @Produce json
+// @Param since query string false "found_at at or after (RFC3339 or YYYY-MM-DD)"
+// @Param until query string false "found_at before (RFC3339 or YYYY-MM-DD)"
+// @Success 200 {object} violations.MatchTypeHistogram
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/admin/stats/match-types [get]
+func (h *StatsHandler) MatchTypes(c *fiber.Ctx) error {
+	since, err := parseDateParam(c, "since")
+	if err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: err.Error()})
+	}
+	until, err := parseDateParam(c, "until")
+	if err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: err.Error()})
+	}
+	if since != nil && until != nil && !since.Before(*until) {
+		return c.Status(400).JSON(ErrorResponse{Error: "since must be before until"})
+	}
+
+	histogram, err := h.violationsSvc.GetMatchTypeHistogram(c.Context(), since, until)
+	if err != nil {
+		return c.Status(500).JSON(ErrorResponse{Error: "failed to fetch match type stats"})
+	}
+
+	return c.JSON(histogram)
+}
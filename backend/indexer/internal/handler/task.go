@@ -1,8 +1,6 @@
 package handler
 
 import (
-	"strconv"
-
 	"github.com/gofiber/fiber/v2"
 	"github.com/video-analitics/indexer/internal/middleware"
 	"github.com/video-analitics/indexer/internal/repo"
@@ -46,7 +44,8 @@ func (h *TaskHandler) Get(c *fiber.Ctx) error {
 
 type ListTasksResponse struct {
 	Items []repo.ScanTask `json:"items"`
-	Total int64           `json:"total"`
+	Pagination
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // ListTasks godoc
@@ -59,32 +58,42 @@ type ListTasksResponse struct {
 // @Param domain query string false "Filter by domain (partial match)"
 // @Param status query string false "Filter by status (pending, processing, completed, failed, cancelled)"
 // @Param limit query int false "Limit" default(20)
-// @Param offset query int false "Offset" default(0)
+// @Param offset query int false "Offset"
+// @Param paginate query string false "Set to cursor to get the first page with next_cursor"
+// @Param cursor query string false "Cursor from next_cursor of the previous page"
+// @Param count query bool false "Compute total with a separate count query; has_more is returned either way" default(true)
 // @Success 200 {object} ListTasksResponse
+// @Failure 400 {object} ErrorResponse
 // @Router /api/scan-tasks [get]
 func (h *TaskHandler) List(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	isAdmin := middleware.IsAdmin(c)
 
-	siteID := c.Query("site_id")
-	domain := c.Query("domain")
-	status := c.Query("status")
-	limit, _ := strconv.ParseInt(c.Query("limit", "20"), 10, 64)
-	offset, _ := strconv.ParseInt(c.Query("offset", "0"), 10, 64)
+	lp := parseListPage(c, 1000)
 
-	if limit > 1000 {
-		limit = 1000
+	page, err := parseCursorPage(c)
+	if err != nil {
+		return c.Status(400).JSON(ErrorResponse{Error: "invalid cursor"})
+	}
+
+	filter := repo.TaskFilter{
+		SiteID:    c.Query("site_id"),
+		Domain:    c.Query("domain"),
+		Status:    c.Query("status"),
+		Limit:     lp.fetchLimit(),
+		Offset:    lp.Offset,
+		Cursor:    page,
+		SkipCount: !lp.Count,
 	}
 
 	var tasks []repo.ScanTask
 	var total int64
-	var err error
 
 	if isAdmin {
-		tasks, total, err = h.taskRepo.FindWithPagination(c.Context(), siteID, domain, status, limit, offset)
+		tasks, total, err = h.taskRepo.FindWithPagination(c.Context(), filter)
 	} else {
 		// Use aggregation pipeline - efficient even with millions of sites
-		tasks, total, err = h.taskRepo.FindByUserAccess(c.Context(), userID, h.db, siteID, domain, status, limit, offset)
+		tasks, total, err = h.taskRepo.FindByUserAccess(c.Context(), userID, h.db, filter)
 	}
 
 	if err != nil {
@@ -94,8 +103,14 @@ func (h *TaskHandler) List(c *fiber.Ctx) error {
 	if tasks == nil {
 		tasks = []repo.ScanTask{}
 	}
+	tasks, hasMore := trimPage(tasks, lp.Limit)
+
+	resp := ListTasksResponse{Items: tasks, Pagination: lp.pagination(total, hasMore)}
+	if n := len(tasks); n > 0 {
+		resp.NextCursor = nextCursor(page, hasMore, tasks[n-1].CreatedAt, tasks[n-1].ID)
+	}
 
-	return c.JSON(ListTasksResponse{Items: tasks, Total: total})
+	return c.JSON(resp)
 }
 
 type CancelTasksRequest struct {
@@ -6,6 +6,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/video-analitics/indexer/internal/middleware"
 	"github.com/video-analitics/indexer/internal/repo"
 )
 
@@ -41,7 +42,7 @@ type UsersListResponse struct {
 // @Tags users
 // @Security BearerAuth
 // @Produce json
-// @Param role query string false "Filter by role (admin, user)"
+// @Param role query string false "Filter by role (admin, user, viewer)"
 // @Param is_active query bool false "Filter by active status"
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
@@ -104,8 +105,8 @@ func (h *UserHandler) Create(c *fiber.Ctx) error {
 	if req.Password == "" {
 		return c.Status(400).JSON(ErrorResponse{Error: "password is required"})
 	}
-	if req.Role != "" && req.Role != "admin" && req.Role != "user" {
-		return c.Status(400).JSON(ErrorResponse{Error: "role must be 'admin' or 'user'"})
+	if req.Role != "" && !middleware.ValidRole(req.Role) {
+		return c.Status(400).JSON(ErrorResponse{Error: "role must be 'admin', 'user' or 'viewer'"})
 	}
 
 	existing, _ := h.userRepo.FindByLogin(c.Context(), req.Login)
@@ -180,8 +181,8 @@ func (h *UserHandler) Update(c *fiber.Ctx) error {
 	}
 
 	if req.Role != "" {
-		if req.Role != "admin" && req.Role != "user" {
-			return c.Status(400).JSON(ErrorResponse{Error: "role must be 'admin' or 'user'"})
+		if !middleware.ValidRole(req.Role) {
+			return c.Status(400).JSON(ErrorResponse{Error: "role must be 'admin', 'user' or 'viewer'"})
 		}
 		user.Role = req.Role
 	}
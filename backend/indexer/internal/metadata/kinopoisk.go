@@ -0,0 +1,109 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	DefaultKinopoiskAPIURL = "https://kinopoiskapiunofficial.tech"
+	kinopoiskTimeout       = 10 * time.Second
+)
+
+// KinopoiskProvider — метаданные из kinopoiskapiunofficial.tech; умеет искать и по IMDB ID
+type KinopoiskProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewKinopoiskProvider(baseURL, apiKey string) *KinopoiskProvider {
+	if baseURL == "" {
+		baseURL = DefaultKinopoiskAPIURL
+	}
+	return &KinopoiskProvider{
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: kinopoiskTimeout},
+	}
+}
+
+type kinopoiskFilm struct {
+	NameRu       string `json:"nameRu"`
+	NameEn       string `json:"nameEn"`
+	NameOriginal string `json:"nameOriginal"`
+	Year         int    `json:"year"`
+}
+
+type kinopoiskFilmsResponse struct {
+	Items []kinopoiskFilm `json:"items"`
+}
+
+func (p *KinopoiskProvider) Lookup(ctx context.Context, kinopoiskID, imdbID string) (*Metadata, error) {
+	if kinopoiskID != "" {
+		var film kinopoiskFilm
+		found, err := p.get(ctx, "/api/v2.2/films/"+url.PathEscape(kinopoiskID), &film)
+		if err != nil || !found {
+			return nil, err
+		}
+		return film.metadata(), nil
+	}
+
+	if imdbID != "" {
+		var resp kinopoiskFilmsResponse
+		found, err := p.get(ctx, "/api/v2.2/films?imdbId="+url.QueryEscape(imdbID), &resp)
+		if err != nil || !found || len(resp.Items) == 0 {
+			return nil, err
+		}
+		return resp.Items[0].metadata(), nil
+	}
+
+	return nil, nil
+}
+
+func (p *KinopoiskProvider) get(ctx context.Context, path string, out any) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("X-API-KEY", p.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("kinopoisk api: unexpected status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("kinopoisk api: decode: %w", err)
+	}
+	return true, nil
+}
+
+// русское название основное, оригинальное — вторым вариантом для матчера
+func (f kinopoiskFilm) metadata() *Metadata {
+	title := f.NameRu
+	original := f.NameOriginal
+	if original == "" {
+		original = f.NameEn
+	}
+	if title == "" {
+		title = original
+	}
+	if original == title {
+		original = ""
+	}
+	return &Metadata{Title: title, OriginalTitle: original, Year: f.Year}
+}
@@ -0,0 +1,51 @@
+package metadata
+
+import (
+	"context"
+
+	"github.com/video-analitics/indexer/internal/repo"
+)
+
+// Metadata — описание фильма/сериала из внешнего каталога
+type Metadata struct {
+	Title         string
+	OriginalTitle string
+	Year          int
+}
+
+// Provider ищет метаданные по внешним ID контента.
+// Возвращает nil, nil, если каталог ничего не знает об этих ID
+type Provider interface {
+	Lookup(ctx context.Context, kinopoiskID, imdbID string) (*Metadata, error)
+}
+
+// Enrich заполняет пустые Title/OriginalTitle/Year контента из провайдера.
+// Поля, которые указал пользователь, не перезаписываются. Возвращает true, если что-то заполнено
+func Enrich(ctx context.Context, p Provider, content *repo.Content) (bool, error) {
+	if p == nil || (content.KinopoiskID == "" && content.IMDBID == "") {
+		return false, nil
+	}
+	if content.Title != "" && content.OriginalTitle != "" && content.Year > 0 {
+		return false, nil
+	}
+
+	meta, err := p.Lookup(ctx, content.KinopoiskID, content.IMDBID)
+	if err != nil || meta == nil {
+		return false, err
+	}
+
+	filled := false
+	if content.Title == "" && meta.Title != "" {
+		content.Title = meta.Title
+		filled = true
+	}
+	if content.OriginalTitle == "" && meta.OriginalTitle != "" && meta.OriginalTitle != content.Title {
+		content.OriginalTitle = meta.OriginalTitle
+		filled = true
+	}
+	if content.Year <= 0 && meta.Year > 0 {
+		content.Year = meta.Year
+		filled = true
+	}
+	return filled, nil
+}
@@ -0,0 +1,162 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/video-analitics/indexer/internal/repo"
+)
+
+type mockProvider struct {
+	meta  *Metadata
+	err   error
+	calls int
+}
+
+func (p *mockProvider) Lookup(_ context.Context, _, _ string) (*Metadata, error) {
+	p.calls++
+	return p.meta, p.err
+}
+
+func TestEnrich(t *testing.T) {
+	matrix := &Metadata{Title: "Матрица", OriginalTitle: "The Matrix", Year: 1999}
+
+	tests := []struct {
+		name       string
+		content    repo.Content
+		provider   *mockProvider
+		want       repo.Content
+		wantFilled bool
+		wantCalls  int
+		wantErr    bool
+	}{
+		{
+			name:       "only id",
+			content:    repo.Content{KinopoiskID: "301"},
+			provider:   &mockProvider{meta: matrix},
+			want:       repo.Content{KinopoiskID: "301", Title: "Матрица", OriginalTitle: "The Matrix", Year: 1999},
+			wantFilled: true,
+			wantCalls:  1,
+		},
+		{
+			name:       "user fields are kept",
+			content:    repo.Content{IMDBID: "tt0133093", Year: 2000},
+			provider:   &mockProvider{meta: matrix},
+			want:       repo.Content{IMDBID: "tt0133093", Title: "Матрица", OriginalTitle: "The Matrix", Year: 2000},
+			wantFilled: true,
+			wantCalls:  1,
+		},
+		{
+			name:      "nothing missing",
+			content:   repo.Content{KinopoiskID: "301", Title: "Матрица", OriginalTitle: "The Matrix", Year: 1999},
+			provider:  &mockProvider{meta: matrix},
+			want:      repo.Content{KinopoiskID: "301", Title: "Матрица", OriginalTitle: "The Matrix", Year: 1999},
+			wantCalls: 0,
+		},
+		{
+			name:      "no kinopoisk or imdb id",
+			content:   repo.Content{MALID: "5114"},
+			provider:  &mockProvider{meta: matrix},
+			want:      repo.Content{MALID: "5114"},
+			wantCalls: 0,
+		},
+		{
+			name:      "unknown id",
+			content:   repo.Content{KinopoiskID: "1"},
+			provider:  &mockProvider{},
+			want:      repo.Content{KinopoiskID: "1"},
+			wantCalls: 1,
+		},
+		{
+			name:      "provider error",
+			content:   repo.Content{KinopoiskID: "301"},
+			provider:  &mockProvider{err: errors.New("boom")},
+			want:      repo.Content{KinopoiskID: "301"},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := tt.content
+			filled, err := Enrich(context.Background(), tt.provider, &content)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Enrich() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if filled != tt.wantFilled {
+				t.Errorf("Enrich() filled = %v, want %v", filled, tt.wantFilled)
+			}
+			if tt.provider.calls != tt.wantCalls {
+				t.Errorf("provider called %d times, want %d", tt.provider.calls, tt.wantCalls)
+			}
+			if !reflect.DeepEqual(content, tt.want) {
+				t.Errorf("content = %+v, want %+v", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnrichNilProviderIsDisabled(t *testing.T) {
+	content := repo.Content{KinopoiskID: "301"}
+	filled, err := Enrich(context.Background(), nil, &content)
+	if err != nil || filled || content.Title != "" {
+		t.Errorf("Enrich(nil) = %v, %v, content %+v; want no-op", filled, err, content)
+	}
+}
+
+func TestKinopoiskProviderLookup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-KEY") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/api/v2.2/films/301":
+			w.Write([]byte(`{"nameRu":"Матрица","nameOriginal":"The Matrix","year":1999}`))
+		case r.URL.Path == "/api/v2.2/films" && r.URL.Query().Get("imdbId") == "tt0133093":
+			w.Write([]byte(`{"items":[{"nameRu":"Матрица","nameEn":"The Matrix","year":1999}]}`))
+		case r.URL.Path == "/api/v2.2/films":
+			w.Write([]byte(`{"items":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewKinopoiskProvider(srv.URL, "key")
+	want := Metadata{Title: "Матрица", OriginalTitle: "The Matrix", Year: 1999}
+
+	tests := []struct {
+		name        string
+		kinopoiskID string
+		imdbID      string
+		want        *Metadata
+	}{
+		{"by kinopoisk id", "301", "", &want},
+		{"by imdb id", "", "tt0133093", &want},
+		{"unknown kinopoisk id", "404", "", nil},
+		{"unknown imdb id", "", "tt0000000", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Lookup(context.Background(), tt.kinopoiskID, tt.imdbID)
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("Lookup() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewKinopoiskProvider(srv.URL, "wrong").Lookup(context.Background(), "301", ""); err == nil {
+		t.Error("expected error for rejected api key")
+	}
+}
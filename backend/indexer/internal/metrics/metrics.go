@@ -0,0 +1,143 @@
+// Package metrics — метрики индексатора в формате Prometheus
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+
+	"github.com/video-analitics/backend/pkg/nats"
+)
+
+// Registry — реестр, который отдаётся на /metrics
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "indexer_http_requests_total",
+		Help: "API requests by method, route and response status",
+	}, []string{"method", "route", "status"})
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "indexer_http_request_duration_seconds",
+		Help:    "API request duration",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	ScanTasks = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "indexer_scan_tasks_total",
+		Help: "Scan task stages that reached a terminal status",
+	}, []string{"stage", "status"})
+	WorkerMessages = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "indexer_worker_messages_total",
+		Help: "Messages handled by result processors",
+	}, []string{"processor", "outcome"})
+	WorkerMessageDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "indexer_worker_message_duration_seconds",
+		Help:    "Time spent handling one message in a result processor",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"processor"})
+
+	SchedulerViolationsFound = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "indexer_scheduler_violations_found",
+		Help: "Violations found by the last scheduled violations refresh",
+	})
+	SchedulerViolationsFoundTotal = factory.NewCounter(prometheus.CounterOpts{
+		Name: "indexer_scheduler_violations_found_total",
+		Help: "Violations found by scheduled violations refreshes",
+	})
+
+	NATSConsumerPending = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "indexer_nats_consumer_pending",
+		Help: "Messages in the stream not yet delivered to the consumer",
+	}, []string{"stream", "consumer"})
+	NATSConsumerAckPending = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "indexer_nats_consumer_ack_pending",
+		Help: "Messages delivered to the consumer but not yet acknowledged",
+	}, []string{"stream", "consumer"})
+
+	MongoCommandDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "indexer_mongo_command_duration_seconds",
+		Help:    "MongoDB command duration",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"command", "outcome"})
+)
+
+// Handler отдаёт метрики реестра Registry
+var Handler = adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+
+// Middleware считает запросы к API. Маршрут берётся из шаблона (/sites/:id), а не из пути,
+// чтобы число серий не зависело от идентификаторов
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		started := time.Now()
+		err := c.Next()
+
+		// ErrorHandler приложения превращает любую ошибку в 500
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		// fiber переиспользует буферы запроса — метод копируем
+		method := strings.Clone(c.Method())
+		route := c.Route().Path
+		HTTPRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		HTTPRequestDuration.WithLabelValues(method, route).Observe(time.Since(started).Seconds())
+		return err
+	}
+}
+
+// ObserveHandler оборачивает обработчик сообщений NATS счётчиками по результату
+func ObserveHandler(processor string, handler nats.HandlerFunc) nats.HandlerFunc {
+	return func(ctx context.Context, msg *nats.Message) error {
+		started := time.Now()
+		err := handler(ctx, msg)
+		WorkerMessageDuration.WithLabelValues(processor).Observe(time.Since(started).Seconds())
+
+		outcome := "ok"
+		if err != nil {
+			outcome = "error"
+		}
+		WorkerMessages.WithLabelValues(processor, outcome).Inc()
+		return err
+	}
+}
+
+// MongoMonitor замеряет длительность команд MongoDB
+func MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			MongoCommandDuration.WithLabelValues(e.CommandName, "ok").Observe(e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			MongoCommandDuration.WithLabelValues(e.CommandName, "error").Observe(e.Duration.Seconds())
+		},
+	}
+}
+
+// ScanTaskFinished отмечает завершение этапа задачи сканирования
+func ScanTaskFinished(stage, status string) {
+	ScanTasks.WithLabelValues(stage, status).Inc()
+}
+
+// SchedulerRefreshed записывает число нарушений, найденных плановым пересчётом
+func SchedulerRefreshed(found int64) {
+	SchedulerViolationsFound.Set(float64(found))
+	SchedulerViolationsFoundTotal.Add(float64(found))
+}
+
+// ConsumerLag записывает отставание консьюмера NATS
+func ConsumerLag(lag nats.ConsumerLag) {
+	NATSConsumerPending.WithLabelValues(lag.Stream, lag.Consumer).Set(float64(lag.Pending))
+	NATSConsumerAckPending.WithLabelValues(lag.Stream, lag.Consumer).Set(float64(lag.AckPending))
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/video-analitics/backend/pkg/nats"
+)
+
+func TestHelpersUpdateCollectors(t *testing.T) {
+	ScanTaskFinished("page", "completed")
+	ScanTaskFinished("page", "completed")
+	if got := testutil.ToFloat64(ScanTasks.WithLabelValues("page", "completed")); got != 2 {
+		t.Errorf("ScanTasks = %v, want 2", got)
+	}
+
+	SchedulerRefreshed(5)
+	SchedulerRefreshed(3)
+	if got := testutil.ToFloat64(SchedulerViolationsFound); got != 3 {
+		t.Errorf("SchedulerViolationsFound = %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(SchedulerViolationsFoundTotal); got != 8 {
+		t.Errorf("SchedulerViolationsFoundTotal = %v, want 8", got)
+	}
+
+	ConsumerLag(nats.ConsumerLag{Stream: "PAGES", Consumer: `page-"result"`, Pending: 7, AckPending: 2})
+	if got := testutil.ToFloat64(NATSConsumerPending.WithLabelValues("PAGES", `page-"result"`)); got != 7 {
+		t.Errorf("NATSConsumerPending = %v, want 7", got)
+	}
+}
+
+func TestHandlerServesRegistry(t *testing.T) {
+	app := fiber.New()
+	app.Use(Middleware())
+	app.Get("/sites/:id", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+	app.Get("/metrics", Handler)
+
+	if _, err := app.Test(httptest.NewRequest("GET", "/sites/42", nil)); err != nil {
+		t.Fatalf("GET /sites/42: %v", err)
+	}
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+
+	want := `indexer_http_requests_total{method="GET",route="/sites/:id",status="204"} 1`
+	if !strings.Contains(string(body), want) {
+		t.Errorf("GET /metrics body does not contain %q:\n%s", want, body)
+	}
+}
@@ -8,6 +8,23 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Роли пользователей; передаются в claims токена
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+	// RoleViewer — только чтение: просмотр и экспорт без изменений и запуска сканов
+	RoleViewer = "viewer"
+)
+
+// ValidRole — роль, которую можно назначить пользователю
+func ValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleUser, RoleViewer:
+		return true
+	}
+	return false
+}
+
 type Claims struct {
 	UserID string `json:"user_id"`
 	Role   string `json:"role"`
@@ -50,13 +67,36 @@ func AuthMiddleware(secret string) fiber.Handler {
 func AdminOnly() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		role := GetRole(c)
-		if role != "admin" {
+		if role != RoleAdmin {
 			return c.Status(403).JSON(fiber.Map{"error": "admin access required"})
 		}
 		return c.Next()
 	}
 }
 
+// RequireRole пропускает только пользователей с одной из ролей
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role := GetRole(c)
+		for _, r := range roles {
+			if role == r {
+				return c.Next()
+			}
+		}
+		return c.Status(403).JSON(fiber.Map{"error": "insufficient role"})
+	}
+}
+
+// RequireWrite закрывает изменяющие маршруты для роли viewer
+func RequireWrite() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !CanWrite(c) {
+			return c.Status(403).JSON(fiber.Map{"error": "read-only access"})
+		}
+		return c.Next()
+	}
+}
+
 func GetUserID(c *fiber.Ctx) string {
 	if id, ok := c.Locals("user_id").(string); ok {
 		return id
@@ -72,7 +112,12 @@ func GetRole(c *fiber.Ctx) string {
 }
 
 func IsAdmin(c *fiber.Ctx) bool {
-	return GetRole(c) == "admin"
+	return GetRole(c) == RoleAdmin
+}
+
+// CanWrite — роль может изменять данные и запускать сканы; токены без роли считаются user
+func CanWrite(c *fiber.Ctx) bool {
+	return GetRole(c) != RoleViewer
 }
 
 func InternalAuth(token string) fiber.Handler {
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRoleMiddleware(t *testing.T) {
+	tests := []struct {
+		role      string
+		wantWrite int
+		wantAdmin int
+	}{
+		{RoleAdmin, 200, 200},
+		{RoleUser, 200, 403},
+		{RoleViewer, 403, 403},
+		// Токены, выданные до появления ролей, без роли — как user
+		{"", 200, 403},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("role", tt.role)
+				return c.Next()
+			})
+			ok := func(c *fiber.Ctx) error { return c.SendStatus(200) }
+			app.Post("/write", RequireWrite(), ok)
+			app.Get("/admin", RequireRole(RoleAdmin), ok)
+
+			for path, want := range map[string]int{"/write": tt.wantWrite, "/admin": tt.wantAdmin} {
+				method := "GET"
+				if path == "/write" {
+					method = "POST"
+				}
+				resp, err := app.Test(httptest.NewRequest(method, path, nil))
+				if err != nil {
+					t.Fatalf("%s %s: %v", method, path, err)
+				}
+				if resp.StatusCode != want {
+					t.Errorf("%s %s as %q = %d, want %d", method, path, tt.role, resp.StatusCode, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidRole(t *testing.T) {
+	for _, role := range []string{RoleAdmin, RoleUser, RoleViewer} {
+		if !ValidRole(role) {
+			t.Errorf("ValidRole(%q) = false", role)
+		}
+	}
+	for _, role := range []string{"", "root", "Viewer"} {
+		if ValidRole(role) {
+			t.Errorf("ValidRole(%q) = true", role)
+		}
+	}
+}
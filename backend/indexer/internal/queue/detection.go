@@ -0,0 +1,40 @@
+package queue
+
+import (
+	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/backend/pkg/status"
+	"github.com/video-analitics/indexer/internal/repo"
+)
+
+// DetectionUpdateFromResult переводит результат детекции парсера в обновление сайта
+func DetectionUpdateFromResult(result *queue.DetectResultMsg) repo.DetectionUpdate {
+	scannerType := status.ScannerHTTP
+	if result.NeedsSPA {
+		scannerType = status.ScannerSPA
+	}
+
+	var cookies []repo.Cookie
+	for _, c := range result.Cookies {
+		cookies = append(cookies, repo.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  c.Expires,
+			HTTPOnly: c.HTTPOnly,
+			Secure:   c.Secure,
+		})
+	}
+
+	return repo.DetectionUpdate{
+		CMS:           result.CMS,
+		HasSitemap:    result.HasSitemap,
+		SitemapStatus: status.SitemapStatus(result.SitemapStatus),
+		CrawlStrategy: status.CrawlStrategy(result.CrawlStrategy),
+		SitemapURLs:   result.SitemapURLs,
+		SitemapFormat: result.SitemapFormat,
+		ScannerType:   scannerType,
+		CaptchaType:   result.CaptchaType,
+		Cookies:       cookies,
+	}
+}
@@ -11,11 +11,12 @@ import (
 )
 
 type Publisher struct {
-	np *nats.Publisher
+	np     *nats.Publisher
+	client *nats.Client
 }
 
 func NewPublisher(client *nats.Client) *Publisher {
-	return &Publisher{np: nats.NewPublisher(client)}
+	return &Publisher{np: nats.NewPublisher(client), client: client}
 }
 
 type TaskInfo struct {
@@ -87,6 +88,23 @@ func (p *Publisher) PublishDetectTask(ctx context.Context, taskID, siteID, domai
 	return p.np.PublishDetectTask(ctx, task)
 }
 
+// DetectBacklog — сколько задач детекции ещё не разобрано парсером
+func (p *Publisher) DetectBacklog(ctx context.Context) (uint64, error) {
+	lags, err := p.client.StreamsLag(ctx, nats.StreamDetectTasks)
+	var backlog uint64
+	for _, lag := range lags {
+		backlog += lag.Pending + uint64(lag.AckPending)
+	}
+	return backlog, err
+}
+
+// PublishParserConcurrency рассылает всем запущенным парсерам новое число page-воркеров
+func (p *Publisher) PublishParserConcurrency(pageWorkers int) error {
+	return p.client.PublishCore(nats.SubjectParserConcurrency, queue.ParserConcurrencyCommand{
+		PageWorkers: pageWorkers,
+	})
+}
+
 func (p *Publisher) PublishSitemapCrawlTask(ctx context.Context, info TaskInfo) error {
 	var cookies []queue.CookieData
 	for _, c := range info.Site.Cookies {
@@ -107,16 +125,19 @@ func (p *Publisher) PublishSitemapCrawlTask(ctx context.Context, info TaskInfo)
 	}
 
 	task := queue.SitemapCrawlTask{
-		ID:            info.TaskID,
-		SiteID:        info.Site.ID.Hex(),
-		Domain:        info.Site.Domain,
-		SitemapURLs:   info.Site.SitemapURLs,
-		ScannerType:   string(info.Site.ScannerType),
-		CaptchaType:   info.Site.CaptchaType,
-		Cookies:       cookies,
-		AutoContinue:  info.AutoContinue,
-		IndexerAPIURL: indexerAPIURL,
-		CreatedAt:     time.Now(),
+		ID:              info.TaskID,
+		SiteID:          info.Site.ID.Hex(),
+		Domain:          info.Site.Domain,
+		SitemapURLs:     info.Site.SitemapURLs,
+		SitemapFormat:   info.Site.SitemapFormat,
+		SitemapJSONPath: info.Site.SitemapJSONPath,
+		ScannerType:     string(info.Site.ScannerType),
+		CaptchaType:     info.Site.CaptchaType,
+		Cookies:         cookies,
+		DNSOverride:     dnsOverrideToQueue(info.Site.DNSOverride),
+		AutoContinue:    info.AutoContinue,
+		IndexerAPIURL:   indexerAPIURL,
+		CreatedAt:       time.Now(),
 	}
 
 	return p.np.PublishSitemapCrawlTask(ctx, task)
@@ -143,7 +164,10 @@ func (p *Publisher) PublishPageCrawlTask(ctx context.Context, info TaskInfo, ind
 		ScannerType:   string(info.Site.ScannerType),
 		CaptchaType:   info.Site.CaptchaType,
 		Cookies:       cookies,
+		PageWait:      pageWaitToQueue(info.Site.PageWait),
+		DNSOverride:   dnsOverrideToQueue(info.Site.DNSOverride),
 		BatchSize:     batchSize,
+		MaxPages:      info.Site.MaxPagesPerScan,
 		IndexerAPIURL: indexerAPIURL,
 		CreatedAt:     time.Now(),
 	}
@@ -151,6 +175,42 @@ func (p *Publisher) PublishPageCrawlTask(ctx context.Context, info TaskInfo, ind
 	return p.np.PublishPageCrawlTask(ctx, task)
 }
 
+// NewParseSyncRequest — разовый разбор страницы сайта с его ожиданием загрузки, сканером и DNS,
+// как при краулинге; для nil сайта — настройки парсера по умолчанию
+func NewParseSyncRequest(site *repo.Site, pageURL string) queue.ParseSyncRequest {
+	req := queue.ParseSyncRequest{URL: pageURL}
+	if site == nil {
+		return req
+	}
+	req.SiteID = site.ID.Hex()
+	req.ScannerType = string(site.ScannerType)
+	req.PageWait = pageWaitToQueue(site.PageWait)
+	req.DNSOverride = dnsOverrideToQueue(site.DNSOverride)
+	return req
+}
+
+func pageWaitToQueue(w *repo.PageWait) *queue.PageWait {
+	if w == nil {
+		return nil
+	}
+	return &queue.PageWait{
+		Strategy:  string(w.Strategy),
+		DelayMs:   w.DelayMs,
+		Selector:  w.Selector,
+		TimeoutMs: w.TimeoutMs,
+	}
+}
+
+func dnsOverrideToQueue(d *repo.DNSOverride) *queue.DNSOverride {
+	if d == nil {
+		return nil
+	}
+	return &queue.DNSOverride{
+		Resolver: d.Resolver,
+		Hosts:    d.Hosts,
+	}
+}
+
 // PublishPageCrawlTaskSimple - упрощённая версия с дефолтными значениями
 func (p *Publisher) PublishPageCrawlTaskSimple(ctx context.Context, info TaskInfo) error {
 	indexerAPIURL := "http://localhost:8080"
@@ -0,0 +1,346 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/video-analitics/backend/pkg/status"
+)
+
+const violationsCollection = "violations"
+
+type ActivityType string
+
+const (
+	ActivitySiteAdded      ActivityType = "site_added"
+	ActivitySiteFrozen     ActivityType = "site_frozen"
+	ActivityScanCompleted  ActivityType = "scan_completed"
+	ActivityScanFailed     ActivityType = "scan_failed"
+	ActivityViolationFound ActivityType = "violation_found"
+)
+
+// Activity — событие ленты; ID — документ-источник (сайт, задача или нарушение)
+type Activity struct {
+	ID        primitive.ObjectID `json:"id"`
+	Type      ActivityType       `json:"type"`
+	At        time.Time          `json:"at"`
+	SiteID    string             `json:"site_id,omitempty"`
+	Domain    string             `json:"domain,omitempty"`
+	ContentID string             `json:"content_id,omitempty"`
+	PageURL   string             `json:"page_url,omitempty"`
+	Details   string             `json:"details,omitempty"` // причина заморозки (status.FreezeReason) или ошибка скана
+}
+
+type ActivityFilter struct {
+	SiteIDs    []string // nil — все сайты
+	ContentIDs []string // nil — весь контент
+	After      *PageCursor
+	Limit      int64
+}
+
+// ActivityRepo собирает ленту из sites, scan_tasks и violations без отдельной коллекции:
+// из каждого источника берётся не больше Limit событий после курсора, затем они сливаются по времени
+type ActivityRepo struct {
+	db *mongo.Database
+}
+
+func NewActivityRepo(db *mongo.Database) *ActivityRepo {
+	return &ActivityRepo{db: db}
+}
+
+func (r *ActivityRepo) FindRecent(ctx context.Context, f ActivityFilter) ([]Activity, error) {
+	var items []Activity
+
+	if f.SiteIDs == nil || len(f.SiteIDs) > 0 {
+		added, err := r.sitesAdded(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		frozen, err := r.sitesFrozen(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		scans, err := r.scansFinished(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		items = append(append(append(items, added...), frozen...), scans...)
+	}
+
+	if f.ContentIDs == nil || len(f.ContentIDs) > 0 {
+		found, err := r.violationsFound(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+
+	items = mergeActivities(items, f.Limit)
+	if err := r.fillDomains(ctx, items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// mergeActivities сортирует события от новых к старым (при равном времени — по ID) и обрезает до limit
+func mergeActivities(items []Activity, limit int64) []Activity {
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].At.Equal(items[j].At) {
+			return items[i].At.After(items[j].At)
+		}
+		return bytes.Compare(items[i].ID[:], items[j].ID[:]) > 0
+	})
+	if limit > 0 && int64(len(items)) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+// siteFilter — сайты ленты; сайты из корзины в неё не попадают
+func siteFilter(f ActivityFilter) bson.M {
+	filter := notDeleted(bson.M{})
+	if f.SiteIDs != nil {
+		oids := make([]primitive.ObjectID, 0, len(f.SiteIDs))
+		for _, id := range f.SiteIDs {
+			oid, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				continue
+			}
+			oids = append(oids, oid)
+		}
+		filter["_id"] = bson.M{"$in": oids}
+	}
+	return filter
+}
+
+// findSorted выбирает до limit документов после курсора по полю времени field
+func findSorted(ctx context.Context, coll *mongo.Collection, filter bson.M, field string, f ActivityFilter, results interface{}) error {
+	if f.After != nil {
+		filter = bson.M{"$and": bson.A{filter, f.After.matchOn(field)}}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: field, Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(f.Limit)
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, results)
+}
+
+func (r *ActivityRepo) sitesAdded(ctx context.Context, f ActivityFilter) ([]Activity, error) {
+	filter := siteFilter(f)
+
+	var sites []Site
+	if err := findSorted(ctx, r.db.Collection(sitesCollection), filter, "created_at", f, &sites); err != nil {
+		return nil, err
+	}
+
+	items := make([]Activity, len(sites))
+	for i, s := range sites {
+		items[i] = Activity{ID: s.ID, Type: ActivitySiteAdded, At: s.CreatedAt, SiteID: s.ID.Hex(), Domain: s.Domain}
+	}
+	return items, nil
+}
+
+func (r *ActivityRepo) sitesFrozen(ctx context.Context, f ActivityFilter) ([]Activity, error) {
+	filter := siteFilter(f)
+	filter["frozen_at"] = bson.M{"$exists": true}
+
+	var sites []Site
+	if err := findSorted(ctx, r.db.Collection(sitesCollection), filter, "frozen_at", f, &sites); err != nil {
+		return nil, err
+	}
+
+	items := make([]Activity, 0, len(sites))
+	for _, s := range sites {
+		if s.FrozenAt == nil {
+			continue
+		}
+		items = append(items, Activity{
+			ID:      s.ID,
+			Type:    ActivitySiteFrozen,
+			At:      *s.FrozenAt,
+			SiteID:  s.ID.Hex(),
+			Domain:  s.Domain,
+			Details: freezeDetails(s),
+		})
+	}
+	return items, nil
+}
+
+func (r *ActivityRepo) scansFinished(ctx context.Context, f ActivityFilter) ([]Activity, error) {
+	filter := bson.M{
+		"status":      bson.M{"$in": bson.A{status.TaskCompleted, status.TaskFailed}},
+		"finished_at": bson.M{"$exists": true},
+	}
+	if f.SiteIDs != nil {
+		filter["site_id"] = bson.M{"$in": f.SiteIDs}
+	}
+
+	var tasks []ScanTask
+	if err := findSorted(ctx, r.db.Collection(scanTasksCollection), filter, "finished_at", f, &tasks); err != nil {
+		return nil, err
+	}
+
+	items := make([]Activity, 0, len(tasks))
+	for _, t := range tasks {
+		if t.FinishedAt == nil {
+			continue
+		}
+		a := Activity{
+			ID:     t.ID,
+			Type:   ActivityScanCompleted,
+			At:     *t.FinishedAt,
+			SiteID: t.SiteID,
+			Domain: t.Domain,
+		}
+		if t.Status == status.TaskFailed {
+			a.Type = ActivityScanFailed
+			a.Details = scanError(t)
+		}
+		items = append(items, a)
+	}
+	return items, nil
+}
+
+// freezeDetails — причина заморозки и, если есть, исходный текст ошибки
+func freezeDetails(s Site) string {
+	if s.FreezeDetails == "" {
+		return string(s.FreezeReason)
+	}
+	return string(s.FreezeReason) + ": " + s.FreezeDetails
+}
+
+func scanError(t ScanTask) string {
+	if t.PageResult != nil && t.PageResult.Error != "" {
+		return t.PageResult.Error
+	}
+	if t.SitemapResult != nil {
+		return t.SitemapResult.Error
+	}
+	return ""
+}
+
+// violationsFound — новые нарушения. found_at обновляется при каждом пересканировании,
+// поэтому время обнаружения берётся из _id, созданного при первой вставке
+func (r *ActivityRepo) violationsFound(ctx context.Context, f ActivityFilter) ([]Activity, error) {
+	filter := bson.M{}
+	if f.ContentIDs != nil {
+		filter["content_id"] = bson.M{"$in": f.ContentIDs}
+	}
+	if f.After != nil {
+		filter = bson.M{"$and": bson.A{filter, violationCursorMatch(f.After)}}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(f.Limit).
+		SetProjection(bson.M{"content_id": 1, "site_id": 1, "page_url": 1})
+
+	cursor, err := r.db.Collection(violationsCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID        primitive.ObjectID `bson:"_id"`
+		ContentID string             `bson:"content_id"`
+		SiteID    string             `bson:"site_id"`
+		PageURL   string             `bson:"page_url"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	items := make([]Activity, len(docs))
+	for i, d := range docs {
+		items[i] = Activity{
+			ID:        d.ID,
+			Type:      ActivityViolationFound,
+			At:        d.ID.Timestamp().UTC(),
+			SiteID:    d.SiteID,
+			ContentID: d.ContentID,
+			PageURL:   d.PageURL,
+		}
+	}
+	return items, nil
+}
+
+// violationCursorMatch — условие "после курсора" для нарушений, время которых — секунды из _id.
+// Курсор (T, ID) может прийти из любого источника, поэтому:
+// время раньше T — _id меньше первого ObjectID секунды ceil(T);
+// ровно T (только если T — целая секунда) — _id из этой секунды и меньше ID
+func violationCursorMatch(c *PageCursor) bson.M {
+	at := c.CreatedAt
+	sec := at.Truncate(time.Second)
+	if !sec.Equal(at) {
+		return bson.M{"_id": bson.M{"$lt": minObjectID(sec.Add(time.Second))}}
+	}
+
+	return bson.M{"$or": bson.A{
+		bson.M{"_id": bson.M{"$lt": minObjectID(sec)}},
+		bson.M{"$and": bson.A{
+			bson.M{"_id": bson.M{"$lt": c.ID}},
+			bson.M{"_id": bson.M{"$lt": minObjectID(sec.Add(time.Second))}},
+		}},
+	}}
+}
+
+// minObjectID — наименьший ObjectID секунды t. primitive.NewObjectIDFromTimestamp
+// заполняет хвост счётчиком, поэтому как граница диапазона не годится
+func minObjectID(t time.Time) primitive.ObjectID {
+	var id primitive.ObjectID
+	binary.BigEndian.PutUint32(id[0:4], uint32(t.Unix()))
+	return id
+}
+
+// fillDomains подставляет домены для нарушений — в самих нарушениях хранится только site_id
+func (r *ActivityRepo) fillDomains(ctx context.Context, items []Activity) error {
+	var oids []primitive.ObjectID
+	for _, a := range items {
+		if a.Domain != "" || a.SiteID == "" {
+			continue
+		}
+		if oid, err := primitive.ObjectIDFromHex(a.SiteID); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	if len(oids) == 0 {
+		return nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"domain": 1})
+	cursor, err := r.db.Collection(sitesCollection).Find(ctx, bson.M{"_id": bson.M{"$in": oids}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var sites []Site
+	if err := cursor.All(ctx, &sites); err != nil {
+		return err
+	}
+
+	domains := make(map[string]string, len(sites))
+	for _, s := range sites {
+		domains[s.ID.Hex()] = s.Domain
+	}
+	for i := range items {
+		if items[i].Domain == "" {
+			items[i].Domain = domains[items[i].SiteID]
+		}
+	}
+	return nil
+}
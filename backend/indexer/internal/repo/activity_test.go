@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMergeActivities(t *testing.T) {
+	base := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	older := primitive.NewObjectIDFromTimestamp(base.Add(-time.Hour))
+	newer := primitive.NewObjectIDFromTimestamp(base.Add(time.Hour))
+
+	items := []Activity{
+		{ID: older, Type: ActivitySiteAdded, At: base.Add(-time.Minute)},
+		{ID: older, Type: ActivityScanFailed, At: base},
+		{ID: newer, Type: ActivityViolationFound, At: base},
+		{ID: older, Type: ActivitySiteFrozen, At: base.Add(time.Minute)},
+	}
+
+	got := mergeActivities(items, 3)
+
+	want := []ActivityType{ActivitySiteFrozen, ActivityViolationFound, ActivityScanFailed}
+	if len(got) != len(want) {
+		t.Fatalf("len = %d, want %d", len(got), len(want))
+	}
+	for i, typ := range want {
+		if got[i].Type != typ {
+			t.Errorf("item %d = %s, want %s", i, got[i].Type, typ)
+		}
+	}
+}
+
+// matchesID вычисляет условия из violationCursorMatch для одного _id
+func matchesID(t *testing.T, cond bson.M, id primitive.ObjectID) bool {
+	t.Helper()
+	if or, ok := cond["$or"].(bson.A); ok {
+		for _, c := range or {
+			if matchesID(t, c.(bson.M), id) {
+				return true
+			}
+		}
+		return false
+	}
+	if and, ok := cond["$and"].(bson.A); ok {
+		for _, c := range and {
+			if !matchesID(t, c.(bson.M), id) {
+				return false
+			}
+		}
+		return true
+	}
+	lt, ok := cond["_id"].(bson.M)["$lt"].(primitive.ObjectID)
+	if !ok {
+		t.Fatalf("unexpected condition %v", cond)
+	}
+	return bytes.Compare(id[:], lt[:]) < 0
+}
+
+func TestViolationCursorMatch(t *testing.T) {
+	sec := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	cursorID := minObjectID(sec)
+	cursorID[11] = 5
+
+	sameSec := func(last byte) primitive.ObjectID {
+		id := minObjectID(sec)
+		id[11] = last
+		return id
+	}
+
+	tests := []struct {
+		name   string
+		cursor PageCursor
+		id     primitive.ObjectID
+		want   bool
+	}{
+		{"earlier second", PageCursor{CreatedAt: sec, ID: cursorID}, primitive.NewObjectIDFromTimestamp(sec.Add(-time.Second)), true},
+		{"same second, smaller id", PageCursor{CreatedAt: sec, ID: cursorID}, sameSec(4), true},
+		{"same second, cursor itself", PageCursor{CreatedAt: sec, ID: cursorID}, cursorID, false},
+		{"later second", PageCursor{CreatedAt: sec, ID: cursorID}, primitive.NewObjectIDFromTimestamp(sec.Add(time.Second)), false},
+		// Курсор от события другого источника с миллисекундами: нарушение той же секунды — раньше него
+		{"fractional cursor, same second", PageCursor{CreatedAt: sec.Add(300 * time.Millisecond), ID: primitive.NewObjectIDFromTimestamp(sec.Add(-time.Hour))}, sameSec(200), true},
+		{"fractional cursor, next second", PageCursor{CreatedAt: sec.Add(300 * time.Millisecond), ID: cursorID}, primitive.NewObjectIDFromTimestamp(sec.Add(time.Second)), false},
+		// Курсор целой секунды с ID из будущего не должен пропускать нарушения следующей секунды
+		{"whole second cursor, foreign id", PageCursor{CreatedAt: sec, ID: primitive.NewObjectIDFromTimestamp(sec.Add(time.Hour))}, primitive.NewObjectIDFromTimestamp(sec.Add(time.Second)), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesID(t, violationCursorMatch(&tt.cursor), tt.id); got != tt.want {
+				t.Errorf("match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/video-analitics/backend/pkg/violations"
 )
 
 const contentCollection = "content"
@@ -16,15 +18,65 @@ type Content struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Title           string             `bson:"title" json:"title"`
 	OriginalTitle   string             `bson:"original_title,omitempty" json:"original_title,omitempty"`
+	Aliases         []string           `bson:"aliases,omitempty" json:"aliases,omitempty"` // альтернативные названия для матчера
 	Year            int                `bson:"year,omitempty" json:"year,omitempty"`
 	KinopoiskID     string             `bson:"kinopoisk_id,omitempty" json:"kinopoisk_id,omitempty"`
 	IMDBID          string             `bson:"imdb_id,omitempty" json:"imdb_id,omitempty"`
 	MALID           string             `bson:"mal_id,omitempty" json:"mal_id,omitempty"`
 	ShikimoriID     string             `bson:"shikimori_id,omitempty" json:"shikimori_id,omitempty"`
 	MyDramaListID   string             `bson:"mydramalist_id,omitempty" json:"mydramalist_id,omitempty"`
+	Language        string             `bson:"language,omitempty" json:"language,omitempty"` // язык оригинала, ISO 639-1
+	Region          string             `bson:"region,omitempty" json:"region,omitempty"`     // страна производства, ISO 3166-1 alpha-2
+	Priority        string             `bson:"priority,omitempty" json:"priority,omitempty"` // high, normal или low; пусто — normal
 	ViolationsCount int64              `bson:"violations_count" json:"violations_count"`
 	SitesCount      int64              `bson:"sites_count" json:"sites_count"`
 	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	// TitleSearch — ключи поиска по названию (TitleSearchKeys); обновляются вместе с названиями и алиасами
+	TitleSearch []string `bson:"title_search,omitempty" json:"-"`
+	// LastCheckedAt — время последнего пересчёта нарушений; сбрасывается при изменении ID
+	LastCheckedAt *time.Time `bson:"last_checked_at,omitempty" json:"last_checked_at,omitempty"`
+	// NextRefreshAt — когда планировщик пересчитает нарушения; nil — при ближайшем проходе
+	NextRefreshAt *time.Time `bson:"next_refresh_at,omitempty" json:"next_refresh_at,omitempty"`
+	// MatchTypeCounts — разбивка ViolationsCount по этапам матчера
+	MatchTypeCounts map[violations.MatchType]int64 `bson:"match_type_counts,omitempty" json:"match_type_counts,omitempty"`
+	// LastViolationAt — последний пересчёт, нашедший хотя бы одно нарушение
+	LastViolationAt *time.Time `bson:"last_violation_at,omitempty" json:"last_violation_at,omitempty"`
+	// DeletedAt — контент в корзине с этого момента
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy string     `bson:"deleted_by,omitempty" json:"-"`
+}
+
+const (
+	ContentPriorityHigh   = "high"
+	ContentPriorityNormal = "normal"
+	ContentPriorityLow    = "low"
+)
+
+// contentRefreshIntervals — как часто планировщик пересчитывает нарушения контента каждого приоритета
+var contentRefreshIntervals = map[string]time.Duration{
+	ContentPriorityHigh:   6 * time.Hour,
+	ContentPriorityNormal: 24 * time.Hour,
+	ContentPriorityLow:    7 * 24 * time.Hour,
+}
+
+func IsValidContentPriority(p string) bool {
+	_, ok := contentRefreshIntervals[p]
+	return ok
+}
+
+// ContentRefreshInterval — интервал пересчёта для приоритета; неизвестный приоритет считается normal
+func ContentRefreshInterval(priority string) time.Duration {
+	if d, ok := contentRefreshIntervals[priority]; ok {
+		return d
+	}
+	return contentRefreshIntervals[ContentPriorityNormal]
+}
+
+// ContentCounts — кэшированные счётчики нарушений контента
+type ContentCounts struct {
+	ViolationsCount int64      `bson:"violations_count" json:"violations_count"`
+	SitesCount      int64      `bson:"sites_count" json:"sites_count"`
+	LastViolationAt *time.Time `bson:"last_violation_at,omitempty" json:"last_violation_at,omitempty"`
 }
 
 type ContentRepo struct {
@@ -43,9 +95,11 @@ func NewContentRepo(db *mongo.Database) *ContentRepo {
 		{Keys: bson.D{{Key: "mal_id", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
 		{Keys: bson.D{{Key: "shikimori_id", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
 		{Keys: bson.D{{Key: "mydramalist_id", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
-		{Keys: bson.D{{Key: "title", Value: "text"}}},
+		{Keys: bson.D{{Key: "title_search", Value: 1}}},
 		{Keys: bson.D{{Key: "created_at", Value: -1}}},
 		{Keys: bson.D{{Key: "violations_count", Value: -1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "last_checked_at", Value: 1}}},
+		{Keys: bson.D{{Key: "deleted_at", Value: 1}}, Options: options.Index().SetSparse(true)},
 	}
 	coll.Indexes().CreateMany(ctx, indexes)
 
@@ -54,6 +108,7 @@ func NewContentRepo(db *mongo.Database) *ContentRepo {
 
 func (r *ContentRepo) Create(ctx context.Context, content *Content) error {
 	content.CreatedAt = time.Now()
+	content.TitleSearch = contentTitleSearch(content)
 	result, err := r.coll.InsertOne(ctx, content)
 	if err != nil {
 		return err
@@ -69,7 +124,7 @@ func (r *ContentRepo) FindByID(ctx context.Context, id string) (*Content, error)
 	}
 
 	var content Content
-	err = r.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&content)
+	err = r.coll.FindOne(ctx, notDeleted(bson.M{"_id": oid})).Decode(&content)
 	if err == mongo.ErrNoDocuments {
 		return nil, nil
 	}
@@ -83,33 +138,59 @@ type ContentFilter struct {
 	MALID         string
 	ShikimoriID   string
 	MyDramaListID string
+	IDMatch       string // all (по умолчанию) — все заданные внешние ID совпадают, any — хотя бы один
 	HasViolations *bool
-	SortBy        string
+	SortBy        string // violations_count, created_at или relevance (только вместе с Title)
 	SortOrder     string
 	Limit         int64
 	Offset        int64
+	Cursor        CursorPage // при включённом курсоре SortBy/SortOrder игнорируются
+	SkipCount     bool       // не считать total (вернётся 0)
 }
 
-func (r *ContentRepo) FindAll(ctx context.Context, f ContentFilter) ([]Content, int64, error) {
-	filter := bson.M{}
-	if f.Title != "" {
-		filter["$text"] = bson.M{"$search": f.Title}
-	}
-	if f.KinopoiskID != "" {
-		filter["kinopoisk_id"] = f.KinopoiskID
-	}
-	if f.IMDBID != "" {
-		filter["imdb_id"] = f.IMDBID
-	}
-	if f.MALID != "" {
-		filter["mal_id"] = f.MALID
+const (
+	IDMatchAll = "all"
+	IDMatchAny = "any"
+)
+
+// externalIDConditions — условия по заданным в фильтре внешним ID в фиксированном порядке
+func externalIDConditions(f ContentFilter) bson.A {
+	var conds bson.A
+	for _, id := range []struct{ field, value string }{
+		{"kinopoisk_id", f.KinopoiskID},
+		{"imdb_id", f.IMDBID},
+		{"mal_id", f.MALID},
+		{"shikimori_id", f.ShikimoriID},
+		{"mydramalist_id", f.MyDramaListID},
+	} {
+		if id.value != "" {
+			conds = append(conds, bson.M{id.field: id.value})
+		}
 	}
-	if f.ShikimoriID != "" {
-		filter["shikimori_id"] = f.ShikimoriID
+	return conds
+}
+
+// applyExternalIDFilter добавляет фильтры по внешним ID: при IDMatchAny — через $or,
+// иначе каждое поле отдельным условием
+func applyExternalIDFilter(filter bson.M, f ContentFilter) {
+	conds := externalIDConditions(f)
+	if f.IDMatch == IDMatchAny && len(conds) > 1 {
+		filter["$or"] = conds
+		return
+	}
+	for _, cond := range conds {
+		for field, value := range cond.(bson.M) {
+			filter[field] = value
+		}
 	}
-	if f.MyDramaListID != "" {
-		filter["mydramalist_id"] = f.MyDramaListID
+}
+
+func (r *ContentRepo) FindAll(ctx context.Context, f ContentFilter) ([]Content, int64, error) {
+	filter := notDeleted(bson.M{})
+	if f.Title != "" {
+		applyTitleSearch(filter, f.Title)
 	}
+	applyExternalIDFilter(filter, f)
 	if f.HasViolations != nil {
 		if *f.HasViolations {
 			filter["violations_count"] = bson.M{"$gt": 0}
@@ -118,11 +199,16 @@ func (r *ContentRepo) FindAll(ctx context.Context, f ContentFilter) ([]Content,
 		}
 	}
 
-	total, err := r.coll.CountDocuments(ctx, filter)
+	total, err := r.countContent(ctx, filter, f.SkipCount)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	if f.SortBy == SortByRelevance && f.Title != "" && !f.Cursor.Enabled {
+		contents, err := r.findRanked(ctx, filter, f)
+		return contents, total, err
+	}
+
 	sortOrder := -1
 	if f.SortOrder == "asc" {
 		sortOrder = 1
@@ -138,10 +224,13 @@ func (r *ContentRepo) FindAll(ctx context.Context, f ContentFilter) ([]Content,
 		sortDoc = append(sortDoc, bson.E{Key: "created_at", Value: -1})
 	}
 
-	opts := options.Find().
-		SetLimit(f.Limit).
-		SetSkip(f.Offset).
-		SetSort(sortDoc)
+	opts := options.Find().SetLimit(f.Limit)
+	if f.Cursor.Enabled {
+		filter = f.Cursor.apply(filter)
+		opts.SetSort(cursorSort)
+	} else {
+		opts.SetSkip(f.Offset).SetSort(sortDoc)
+	}
 
 	cursor, err := r.coll.Find(ctx, filter, opts)
 	if err != nil {
@@ -157,6 +246,31 @@ func (r *ContentRepo) FindAll(ctx context.Context, f ContentFilter) ([]Content,
 	return contents, total, nil
 }
 
+// SoftDelete переносит контент в корзину; false — контента нет или он уже удалён
+func (r *ContentRepo) SoftDelete(ctx context.Context, id, userID string) (bool, error) {
+	return moveToTrash(ctx, r.coll, id, userID)
+}
+
+// Restore возвращает контент из корзины; false — в корзине его нет
+func (r *ContentRepo) Restore(ctx context.Context, id string) (bool, error) {
+	return restoreFromTrash(ctx, r.coll, id)
+}
+
+// FindDeletedByID возвращает контент из корзины
+func (r *ContentRepo) FindDeletedByID(ctx context.Context, id string) (*Content, error) {
+	var content Content
+	found, err := findInTrash(ctx, r.coll, id, &content)
+	if !found {
+		return nil, err
+	}
+	return &content, nil
+}
+
+// FindExpiredDeleted — id контента, пролежавшего в корзине дольше срока хранения
+func (r *ContentRepo) FindExpiredDeleted(ctx context.Context, cutoff time.Time) ([]string, error) {
+	return expiredTrashIDs(ctx, r.coll, cutoff)
+}
+
 func (r *ContentRepo) Delete(ctx context.Context, id string) error {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -168,7 +282,37 @@ func (r *ContentRepo) Delete(ctx context.Context, id string) error {
 }
 
 func (r *ContentRepo) GetAll(ctx context.Context) ([]Content, error) {
-	cursor, err := r.coll.Find(ctx, bson.M{})
+	cursor, err := r.coll.Find(ctx, notDeleted(bson.M{}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var contents []Content
+	if err := cursor.All(ctx, &contents); err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// FindStale возвращает контент, который не пересчитывался с момента since (или не пересчитывался никогда)
+func (r *ContentRepo) FindStale(ctx context.Context, since time.Time) ([]Content, error) {
+	cursor, err := r.coll.Find(ctx, notDeleted(staleContentFilter(since)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var contents []Content
+	if err := cursor.All(ctx, &contents); err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// FindDueForRefresh возвращает контент, у которого наступил next_refresh_at
+func (r *ContentRepo) FindDueForRefresh(ctx context.Context, now time.Time) ([]Content, error) {
+	cursor, err := r.coll.Find(ctx, notDeleted(dueForRefreshFilter(now)))
 	if err != nil {
 		return nil, err
 	}
@@ -181,42 +325,127 @@ func (r *ContentRepo) GetAll(ctx context.Context) ([]Content, error) {
 	return contents, nil
 }
 
-func (r *ContentRepo) UpdateViolationsCount(ctx context.Context, id string, violationsCount, sitesCount int64) error {
+func dueForRefreshFilter(now time.Time) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"next_refresh_at": nil},
+		bson.M{"next_refresh_at": bson.M{"$lte": now}},
+	}}
+}
+
+// ScheduleRefresh переносит next_refresh_at контента на интервал его приоритета от now
+func (r *ContentRepo) ScheduleRefresh(ctx context.Context, contents []Content, now time.Time) error {
+	if len(contents) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, len(contents))
+	for i, c := range contents {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": c.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"next_refresh_at": now.Add(ContentRefreshInterval(c.Priority))}})
+	}
+	_, err := r.coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// UpdatePriority меняет приоритет; next_refresh_at сбрасывается, и контент пересчитается при ближайшем проходе
+func (r *ContentRepo) UpdatePriority(ctx context.Context, id, priority string) error {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{
-		"$set": bson.M{
-			"violations_count": violationsCount,
-			"sites_count":      sitesCount,
-		},
-	})
+	update := bson.M{"$unset": bson.M{"priority": "", "next_refresh_at": ""}}
+	if priority != "" && priority != ContentPriorityNormal {
+		update = bson.M{
+			"$set":   bson.M{"priority": priority},
+			"$unset": bson.M{"next_refresh_at": ""},
+		}
+	}
+
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, update)
 	return err
 }
 
-func (r *ContentRepo) FindByIDs(ctx context.Context, ids []primitive.ObjectID, f ContentFilter) ([]Content, int64, error) {
-	filter := bson.M{"_id": bson.M{"$in": ids}}
+func staleContentFilter(since time.Time) bson.M {
+	// null совпадает и с отсутствующим полем
+	return bson.M{"$or": bson.A{
+		bson.M{"last_checked_at": nil},
+		bson.M{"last_checked_at": bson.M{"$lt": since}},
+	}}
+}
 
-	if f.Title != "" {
-		filter["$text"] = bson.M{"$search": f.Title}
+func (r *ContentRepo) UpdateViolationsCount(ctx context.Context, id string, violationsCount, sitesCount int64, matchTypeCounts map[violations.MatchType]int64) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
 	}
-	if f.KinopoiskID != "" {
-		filter["kinopoisk_id"] = f.KinopoiskID
+
+	// Разбивка перезаписывается целиком, чтобы не оставались этапы, которые больше ничего не находят
+	if matchTypeCounts == nil {
+		matchTypeCounts = map[violations.MatchType]int64{}
+	}
+
+	now := time.Now()
+	set := bson.M{
+		"violations_count":  violationsCount,
+		"sites_count":       sitesCount,
+		"match_type_counts": matchTypeCounts,
+		"last_checked_at":   now,
 	}
-	if f.IMDBID != "" {
-		filter["imdb_id"] = f.IMDBID
+	if violationsCount > 0 {
+		set["last_violation_at"] = now
+	}
+
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": set})
+	return err
+}
+
+// GetCounts возвращает кэшированные счётчики по id одним запросом; отсутствующие и удалённые в корзину id пропускаются
+func (r *ContentRepo) GetCounts(ctx context.Context, ids []primitive.ObjectID) (map[string]ContentCounts, error) {
+	result := make(map[string]ContentCounts, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{
+		"violations_count":  1,
+		"sites_count":       1,
+		"last_violation_at": 1,
+	})
+	cursor, err := r.coll.Find(ctx, notDeleted(bson.M{"_id": bson.M{"$in": ids}}), opts)
+	if err != nil {
+		return nil, err
 	}
-	if f.MALID != "" {
-		filter["mal_id"] = f.MALID
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID            primitive.ObjectID `bson:"_id"`
+			ContentCounts `bson:",inline"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		result[doc.ID.Hex()] = doc.ContentCounts
 	}
-	if f.ShikimoriID != "" {
-		filter["shikimori_id"] = f.ShikimoriID
+	return result, cursor.Err()
+}
+
+func (r *ContentRepo) countContent(ctx context.Context, filter bson.M, skip bool) (int64, error) {
+	if skip {
+		return 0, nil
 	}
-	if f.MyDramaListID != "" {
-		filter["mydramalist_id"] = f.MyDramaListID
+	return r.coll.CountDocuments(ctx, filter)
+}
+
+func (r *ContentRepo) FindByIDs(ctx context.Context, ids []primitive.ObjectID, f ContentFilter) ([]Content, int64, error) {
+	filter := notDeleted(bson.M{"_id": bson.M{"$in": ids}})
+
+	if f.Title != "" {
+		applyTitleSearch(filter, f.Title)
 	}
+	applyExternalIDFilter(filter, f)
 	if f.HasViolations != nil {
 		if *f.HasViolations {
 			filter["violations_count"] = bson.M{"$gt": 0}
@@ -225,11 +454,16 @@ func (r *ContentRepo) FindByIDs(ctx context.Context, ids []primitive.ObjectID, f
 		}
 	}
 
-	total, err := r.coll.CountDocuments(ctx, filter)
+	total, err := r.countContent(ctx, filter, f.SkipCount)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	if f.SortBy == SortByRelevance && f.Title != "" && !f.Cursor.Enabled {
+		contents, err := r.findRanked(ctx, filter, f)
+		return contents, total, err
+	}
+
 	sortOrder := -1
 	if f.SortOrder == "asc" {
 		sortOrder = 1
@@ -245,10 +479,13 @@ func (r *ContentRepo) FindByIDs(ctx context.Context, ids []primitive.ObjectID, f
 		sortDoc = append(sortDoc, bson.E{Key: "created_at", Value: -1})
 	}
 
-	opts := options.Find().
-		SetLimit(f.Limit).
-		SetSkip(f.Offset).
-		SetSort(sortDoc)
+	opts := options.Find().SetLimit(f.Limit)
+	if f.Cursor.Enabled {
+		filter = f.Cursor.apply(filter)
+		opts.SetSort(cursorSort)
+	} else {
+		opts.SetSkip(f.Offset).SetSort(sortDoc)
+	}
 
 	cursor, err := r.coll.Find(ctx, filter, opts)
 	if err != nil {
@@ -264,6 +501,7 @@ func (r *ContentRepo) FindByIDs(ctx context.Context, ids []primitive.ObjectID, f
 	return contents, total, nil
 }
 
+// FindByExternalID ищет и среди удалённых: внешние ID уникальны, и повторное добавление восстанавливает контент
 func (r *ContentRepo) FindByExternalID(ctx context.Context, c *Content) (*Content, error) {
 	var conditions []bson.M
 
@@ -299,6 +537,7 @@ func (r *ContentRepo) FindByExternalID(ctx context.Context, c *Content) (*Conten
 	return &existing, nil
 }
 
+// EnrichExternalIDs дописывает существующему контенту новые внешние ID и алиасы
 func (r *ContentRepo) EnrichExternalIDs(ctx context.Context, id primitive.ObjectID, c *Content) error {
 	update := bson.M{}
 
@@ -318,10 +557,105 @@ func (r *ContentRepo) EnrichExternalIDs(ctx context.Context, id primitive.Object
 		update["mydramalist_id"] = c.MyDramaListID
 	}
 
-	if len(update) == 0 {
+	if len(update) == 0 && len(c.Aliases) == 0 {
 		return nil
 	}
 
-	_, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	// Новые ID могут дать новые совпадения — контент снова требует пересчёта
+	ops := bson.M{"$unset": bson.M{"last_checked_at": ""}}
+	if len(update) > 0 {
+		ops["$set"] = update
+	}
+	if len(c.Aliases) > 0 {
+		ops["$addToSet"] = bson.M{
+			"aliases":      bson.M{"$each": c.Aliases},
+			"title_search": bson.M{"$each": TitleSearchKeys("", "", c.Aliases)},
+		}
+	}
+	_, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, ops)
+	return err
+}
+
+// UpdateAliases заменяет список алиасов; контент требует пересчёта
+func (r *ContentRepo) UpdateAliases(ctx context.Context, id string, aliases []string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	var names struct {
+		Title         string `bson:"title"`
+		OriginalTitle string `bson:"original_title"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"title": 1, "original_title": 1})
+	if err := r.coll.FindOne(ctx, bson.M{"_id": oid}, opts).Decode(&names); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+	titleSearch := TitleSearchKeys(names.Title, names.OriginalTitle, aliases)
+
+	update := bson.M{
+		"$set":   bson.M{"title_search": titleSearch},
+		"$unset": bson.M{"aliases": "", "last_checked_at": ""},
+	}
+	if len(aliases) > 0 {
+		update = bson.M{
+			"$set":   bson.M{"aliases": aliases, "title_search": titleSearch},
+			"$unset": bson.M{"last_checked_at": ""},
+		}
+	}
+
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	return err
+}
+
+// MigrateTitleSearch заполняет title_search контенту, созданному до появления поля.
+// Повторный запуск ничего не меняет
+func (r *ContentRepo) MigrateTitleSearch(ctx context.Context) (int64, error) {
+	opts := options.Find().SetProjection(bson.M{"title": 1, "original_title": 1, "aliases": 1})
+	cursor, err := r.coll.Find(ctx, bson.M{"title_search": bson.M{"$exists": false}}, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var migrated int64
+	for cursor.Next(ctx) {
+		var c Content
+		if err := cursor.Decode(&c); err != nil {
+			return migrated, err
+		}
+		if _, err := r.coll.UpdateOne(ctx, bson.M{"_id": c.ID}, bson.M{"$set": bson.M{"title_search": contentTitleSearch(&c)}}); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, cursor.Err()
+}
+
+// UpdateLanguage задаёт язык и регион контента; пустые значения удаляются
+func (r *ContentRepo) UpdateLanguage(ctx context.Context, id, language, region string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	set := bson.M{}
+	unset := bson.M{"last_checked_at": ""}
+	for field, value := range map[string]string{"language": language, "region": region} {
+		if value == "" {
+			unset[field] = ""
+		} else {
+			set[field] = value
+		}
+	}
+	update := bson.M{"$unset": unset}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, update)
 	return err
 }
@@ -0,0 +1,112 @@
+//go:build e2e
+// +build e2e
+
+package repo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/violations"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUpdateViolationsCountReplacesMatchTypeCounts_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db := setupTestDB(t, ctx)
+	contentRepo := NewContentRepo(db)
+
+	content := &Content{Title: "Нарко"}
+	if err := contentRepo.Create(ctx, content); err != nil {
+		t.Fatalf("create content: %v", err)
+	}
+	id := content.ID.Hex()
+
+	steps := []struct {
+		name   string
+		counts map[violations.MatchType]int64
+		want   map[violations.MatchType]int64
+	}{
+		{
+			name:   "first refresh",
+			counts: map[violations.MatchType]int64{violations.MatchByKinopoisk: 2, violations.MatchByTitle: 5},
+			want:   map[violations.MatchType]int64{violations.MatchByKinopoisk: 2, violations.MatchByTitle: 5},
+		},
+		{
+			name:   "stage stops matching",
+			counts: map[violations.MatchType]int64{violations.MatchByKinopoisk: 3},
+			want:   map[violations.MatchType]int64{violations.MatchByKinopoisk: 3},
+		},
+		{
+			name:   "no matches",
+			counts: nil,
+			want:   nil,
+		},
+	}
+
+	for _, step := range steps {
+		var total int64
+		for _, n := range step.counts {
+			total += n
+		}
+		if err := contentRepo.UpdateViolationsCount(ctx, id, total, 1, step.counts); err != nil {
+			t.Fatalf("%s: update: %v", step.name, err)
+		}
+
+		got, err := contentRepo.FindByID(ctx, id)
+		if err != nil {
+			t.Fatalf("%s: find: %v", step.name, err)
+		}
+		if len(got.MatchTypeCounts) == 0 && len(step.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got.MatchTypeCounts, step.want) {
+			t.Errorf("%s: match_type_counts = %v, want %v", step.name, got.MatchTypeCounts, step.want)
+		}
+	}
+}
+
+func TestGetCounts_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db := setupTestDB(t, ctx)
+	contentRepo := NewContentRepo(db)
+
+	found := &Content{Title: "Брат"}
+	empty := &Content{Title: "Брат 2"}
+	for _, c := range []*Content{found, empty} {
+		if err := contentRepo.Create(ctx, c); err != nil {
+			t.Fatalf("create content: %v", err)
+		}
+	}
+	if err := contentRepo.UpdateViolationsCount(ctx, found.ID.Hex(), 7, 3, nil); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := contentRepo.UpdateViolationsCount(ctx, empty.ID.Hex(), 0, 0, nil); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	missing := primitive.NewObjectID()
+	counts, err := contentRepo.GetCounts(ctx, []primitive.ObjectID{found.ID, empty.ID, missing})
+	if err != nil {
+		t.Fatalf("GetCounts: %v", err)
+	}
+
+	if len(counts) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(counts), counts)
+	}
+	if got := counts[found.ID.Hex()]; got.ViolationsCount != 7 || got.SitesCount != 3 || got.LastViolationAt == nil {
+		t.Errorf("found counts = %+v, want 7/3 with last_violation_at", got)
+	}
+	if got := counts[empty.ID.Hex()]; got.ViolationsCount != 0 || got.LastViolationAt != nil {
+		t.Errorf("empty counts = %+v, want zero without last_violation_at", got)
+	}
+	if _, ok := counts[missing.Hex()]; ok {
+		t.Error("missing content should be omitted")
+	}
+}
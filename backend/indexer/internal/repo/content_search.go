@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/video-analitics/backend/pkg/violations"
+)
+
+// SortByRelevance — сортировка по близости названия к ContentFilter.Title
+const SortByRelevance = "relevance"
+
+// maxRankedCandidates — сколько совпадений по названию ранжируется в памяти
+const maxRankedCandidates = 1000
+
+// titleQueryWords нормализует поисковый запрос так же, как матчер нормализует названия
+func titleQueryWords(query string) []string {
+	return strings.Fields(violations.NormalizeTitle(query))
+}
+
+// TitleSearchKeys — значения поля title_search: нормализованные название, оригинальное название
+// и алиасы, взятые с начала каждого слова. Слово запроса ищется якорным префиксом по индексу,
+// поэтому обе стороны нормализуются одинаково — "ocean's" находит "Ocean's Eleven", "amelie" — "Amélie"
+func TitleSearchKeys(title, originalTitle string, aliases []string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, name := range append([]string{title, originalTitle}, aliases...) {
+		name = violations.NormalizeTitle(name)
+		prev := ' '
+		for i, r := range name {
+			if r != ' ' && !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+				if key := name[i:]; !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+			prev = r
+		}
+	}
+	return keys
+}
+
+func contentTitleSearch(c *Content) []string {
+	return TitleSearchKeys(c.Title, c.OriginalTitle, c.Aliases)
+}
+
+// titleSearchFilter — каждое слово запроса должно быть началом слова
+// в названии, оригинальном названии или одном из алиасов
+func titleSearchFilter(words []string) bson.M {
+	all := make(bson.A, 0, len(words))
+	for _, word := range words {
+		all = append(all, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(word)})
+	}
+	return bson.M{"$all": all}
+}
+
+// applyTitleSearch добавляет в фильтр поиск по названию; пустой после нормализации запрос игнорируется
+func applyTitleSearch(filter bson.M, query string) {
+	if words := titleQueryWords(query); len(words) > 0 {
+		filter["title_search"] = titleSearchFilter(words)
+	}
+}
+
+// titleRelevance оценивает, насколько название контента похоже на запрос; 0 — не совпадает.
+// Основное название весит чуть больше оригинального и алиасов
+func titleRelevance(query string, c *Content) int {
+	q := violations.NormalizeTitle(query)
+	words := strings.Fields(q)
+	if len(words) == 0 {
+		return 0
+	}
+
+	best := nameRelevance(q, words, violations.NormalizeTitle(c.Title))
+	others := append([]string{c.OriginalTitle}, c.Aliases...)
+	for _, name := range others {
+		if score := nameRelevance(q, words, violations.NormalizeTitle(name)) - 5; score > best {
+			best = score
+		}
+	}
+	if best < 0 {
+		return 0
+	}
+	return best
+}
+
+func nameRelevance(q string, words []string, name string) int {
+	switch {
+	case name == "":
+		return 0
+	case name == q:
+		return 100
+	case strings.HasPrefix(name, q):
+		return 80
+	case hasWordPrefix(name, q):
+		return 60
+	}
+	for _, w := range words {
+		if !hasWordPrefix(name, w) {
+			return 0
+		}
+	}
+	return 40
+}
+
+// hasWordPrefix — prefix встречается в text с начала какого-либо слова
+func hasWordPrefix(text, prefix string) bool {
+	for from := 0; from < len(text); {
+		i := strings.Index(text[from:], prefix)
+		if i < 0 {
+			return false
+		}
+		i += from
+		if i == 0 {
+			return true
+		}
+		if r, _ := utf8.DecodeLastRuneInString(text[:i]); !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return true
+		}
+		from = i + len(prefix)
+	}
+	return false
+}
+
+// rankByRelevance сортирует по убыванию релевантности, при равенстве сохраняет исходный порядок
+func rankByRelevance(query string, contents []Content) {
+	scores := make([]int, len(contents))
+	for i := range contents {
+		scores[i] = titleRelevance(query, &contents[i])
+	}
+	sort.Stable(byRelevance{contents: contents, scores: scores})
+}
+
+type byRelevance struct {
+	contents []Content
+	scores   []int
+}
+
+func (b byRelevance) Len() int           { return len(b.contents) }
+func (b byRelevance) Less(i, j int) bool { return b.scores[i] > b.scores[j] }
+func (b byRelevance) Swap(i, j int) {
+	b.contents[i], b.contents[j] = b.contents[j], b.contents[i]
+	b.scores[i], b.scores[j] = b.scores[j], b.scores[i]
+}
+
+// findRanked выбирает совпадения по названию, ранжирует их и применяет offset/limit.
+// Кандидаты берутся в порядке числа нарушений, поэтому при равной релевантности он сохраняется
+func (r *ContentRepo) findRanked(ctx context.Context, filter bson.M, f ContentFilter) ([]Content, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "violations_count", Value: -1}, {Key: "created_at", Value: -1}}).
+		SetLimit(maxRankedCandidates)
+
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var contents []Content
+	if err := cursor.All(ctx, &contents); err != nil {
+		return nil, err
+	}
+
+	rankByRelevance(f.Title, contents)
+
+	if f.Offset >= int64(len(contents)) {
+		return nil, nil
+	}
+	contents = contents[f.Offset:]
+	if f.Limit > 0 && int64(len(contents)) > f.Limit {
+		contents = contents[:f.Limit]
+	}
+	return contents, nil
+}
@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTitleRelevance(t *testing.T) {
+	lotr := &Content{Title: "Властелин колец: Братство кольца", OriginalTitle: "The Lord of the Rings", Year: 2001}
+
+	tests := []struct {
+		name    string
+		query   string
+		content *Content
+		want    int
+	}{
+		{"exact title", "Властелин колец: Братство кольца", lotr, 100},
+		{"case and quotes are ignored", "«властелин КОЛЕЦ: братство кольца»", lotr, 100},
+		{"year in parens is ignored", "Властелин колец: Братство кольца (2001)", lotr, 100},
+		{"title prefix", "властелин", lotr, 80},
+		{"phrase inside title", "братство кольца", lotr, 60},
+		{"words in any order", "кольца властелин", lotr, 40},
+		{"word prefix", "власт кол", lotr, 40},
+		{"original title ranks below main title", "the lord of the rings", lotr, 95},
+		{"alias", "пуф", &Content{Title: "Во все тяжкие", Aliases: []string{"Пуф"}}, 95},
+		{"middle of a word does not match", "стелин", lotr, 0},
+		{"missing word", "властелин матрица", lotr, 0},
+		{"empty after normalization", "«»", lotr, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := titleRelevance(tt.query, tt.content); got != tt.want {
+				t.Errorf("titleRelevance(%q) = %d, want %d", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankByRelevance(t *testing.T) {
+	// Кандидаты приходят отсортированными по числу нарушений
+	contents := []Content{
+		{Title: "Властелин колец: Две крепости", ViolationsCount: 90},
+		{Title: "Хоббит", Aliases: []string{"Властелин колец: Приквел"}, ViolationsCount: 50},
+		{Title: "Властелин колец", ViolationsCount: 10},
+		{Title: "Властелин колец: Возвращение короля", ViolationsCount: 5},
+	}
+
+	rankByRelevance("властелин колец", contents)
+
+	var got []string
+	for _, c := range contents {
+		got = append(got, c.Title)
+	}
+	want := []string{"Властелин колец", "Властелин колец: Две крепости", "Властелин колец: Возвращение короля", "Хоббит"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rankByRelevance() = %q, want %q", got, want)
+	}
+}
+
+func TestTitleSearchFilterNormalizesQuery(t *testing.T) {
+	words := titleQueryWords(`  «Матрица»  (1999) `)
+	if !reflect.DeepEqual(words, []string{"матрица"}) {
+		t.Fatalf("titleQueryWords() = %q, want [матрица]", words)
+	}
+
+	want := bson.M{"$all": bson.A{primitive.Regex{Pattern: `^c\+\+`}, primitive.Regex{Pattern: `^2`}}}
+	if got := titleSearchFilter([]string{"c++", "2"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("titleSearchFilter() = %v, want %v", got, want)
+	}
+}
+
+func TestTitleSearchKeys(t *testing.T) {
+	got := TitleSearchKeys("Властелин колец: Братство кольца", "The Lord of the Rings", []string{"ВЛАСТЕЛИН КОЛЕЦ: БРАТСТВО КОЛЬЦА"})
+	want := []string{
+		"властелин колец: братство кольца", "колец: братство кольца", "братство кольца", "кольца",
+		"the lord of the rings", "lord of the rings", "of the rings", "the rings", "rings",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TitleSearchKeys() = %q, want %q", got, want)
+	}
+}
+
+// TestTitleSearchMatchesNormalizedTitles повторяет в памяти запрос title_search: каждое слово
+// запроса должно быть префиксом одного из ключей
+func TestTitleSearchMatchesNormalizedTitles(t *testing.T) {
+	matches := func(query string, c Content) bool {
+		keys := contentTitleSearch(&c)
+		for _, word := range titleQueryWords(query) {
+			found := false
+			for _, key := range keys {
+				if strings.HasPrefix(key, word) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		content Content
+		want    bool
+	}{
+		{"apostrophe", "ocean's", Content{Title: "Ocean's Eleven"}, true},
+		{"apostrophe dropped in query", "oceans ele", Content{Title: "Ocean's Eleven"}, true},
+		{"diacritics folded", "amelie", Content{Title: "Amélie"}, true},
+		{"diacritics in query", "Amélie", Content{Title: "Amelie"}, true},
+		{"word from alias", "пуф", Content{Title: "Во все тяжкие", Aliases: []string{"Пуф"}}, true},
+		{"words across title and original", "брат brother", Content{Title: "Брат", OriginalTitle: "Brother"}, true},
+		{"word after punctuation", "братство", Content{Title: "Властелин колец:Братство кольца"}, true},
+		{"middle of a word", "стелин", Content{Title: "Властелин колец"}, false},
+		{"missing word", "властелин матрица", Content{Title: "Властелин колец"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matches(tt.query, tt.content); got != tt.want {
+				t.Errorf("match(%q, %q) = %v, want %v", tt.query, tt.content.Title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTitleSearchKeepsCursorCondition(t *testing.T) {
+	filter := notDeleted(bson.M{})
+	applyTitleSearch(filter, "матрица")
+
+	after := PageCursor{CreatedAt: time.Unix(100, 0), ID: primitive.NewObjectID()}
+	got := CursorPage{Enabled: true, After: &after}.apply(filter)
+	if _, ok := got["title_search"]; !ok {
+		t.Errorf("title search must survive cursor pagination, got %v", got)
+	}
+	if and, _ := got["$and"].(bson.A); len(and) != 1 {
+		t.Errorf("cursor condition missing, got %v", got["$and"])
+	}
+}
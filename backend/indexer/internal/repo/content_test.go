@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestStaleContentFilter(t *testing.T) {
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	want := bson.M{"$or": bson.A{
+		bson.M{"last_checked_at": nil},
+		bson.M{"last_checked_at": bson.M{"$lt": since}},
+	}}
+
+	if got := staleContentFilter(since); !reflect.DeepEqual(got, want) {
+		t.Errorf("staleContentFilter() = %v, want %v", got, want)
+	}
+}
+
+func TestDueForRefreshFilter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	want := bson.M{"$or": bson.A{
+		bson.M{"next_refresh_at": nil},
+		bson.M{"next_refresh_at": bson.M{"$lte": now}},
+	}}
+
+	if got := dueForRefreshFilter(now); !reflect.DeepEqual(got, want) {
+		t.Errorf("dueForRefreshFilter() = %v, want %v", got, want)
+	}
+}
+
+func TestContentRefreshInterval(t *testing.T) {
+	tests := []struct {
+		priority string
+		want     time.Duration
+	}{
+		{ContentPriorityHigh, 6 * time.Hour},
+		{ContentPriorityNormal, 24 * time.Hour},
+		{"", 24 * time.Hour},
+		{"urgent", 24 * time.Hour},
+		{ContentPriorityLow, 7 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := ContentRefreshInterval(tt.priority); got != tt.want {
+			t.Errorf("ContentRefreshInterval(%q) = %v, want %v", tt.priority, got, tt.want)
+		}
+	}
+}
+
+func TestApplyExternalIDFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter ContentFilter
+		want   bson.M
+	}{
+		{
+			name:   "no ids",
+			filter: ContentFilter{IDMatch: IDMatchAny},
+			want:   bson.M{},
+		},
+		{
+			name:   "all is the default",
+			filter: ContentFilter{KinopoiskID: "123", IMDBID: "tt1"},
+			want:   bson.M{"kinopoisk_id": "123", "imdb_id": "tt1"},
+		},
+		{
+			name:   "explicit all",
+			filter: ContentFilter{KinopoiskID: "123", IMDBID: "tt1", IDMatch: IDMatchAll},
+			want:   bson.M{"kinopoisk_id": "123", "imdb_id": "tt1"},
+		},
+		{
+			name:   "any ors the ids",
+			filter: ContentFilter{KinopoiskID: "123", IMDBID: "tt1", MALID: "5", IDMatch: IDMatchAny},
+			want: bson.M{"$or": bson.A{
+				bson.M{"kinopoisk_id": "123"},
+				bson.M{"imdb_id": "tt1"},
+				bson.M{"mal_id": "5"},
+			}},
+		},
+		{
+			name:   "any with single id is a plain condition",
+			filter: ContentFilter{ShikimoriID: "42", IDMatch: IDMatchAny},
+			want:   bson.M{"shikimori_id": "42"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bson.M{}
+			applyExternalIDFilter(got, tt.filter)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyExternalIDFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyExternalIDFilterKeepsTitleSearch(t *testing.T) {
+	filter := bson.M{}
+	applyTitleSearch(filter, "Нарко")
+	applyExternalIDFilter(filter, ContentFilter{KinopoiskID: "1", IMDBID: "tt1", IDMatch: IDMatchAny})
+
+	if _, ok := filter["title_search"]; !ok {
+		t.Errorf("title search condition lost: %v", filter)
+	}
+	if _, ok := filter["$or"]; !ok {
+		t.Errorf("external id $or missing: %v", filter)
+	}
+}
@@ -0,0 +1,118 @@
+package repo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursorSort — порядок keyset-пагинации; _id разрешает одинаковые created_at
+var cursorSort = bson.D{
+	{Key: "created_at", Value: -1},
+	{Key: "_id", Value: -1},
+}
+
+// PageCursor — последний элемент предыдущей страницы
+type PageCursor struct {
+	CreatedAt time.Time
+	ID        primitive.ObjectID
+}
+
+// CursorPage включает keyset-пагинацию по (created_at, _id) вместо skip.
+// В отличие от offset, страницы не съезжают при вставке новых записей
+type CursorPage struct {
+	Enabled bool
+	After   *PageCursor // nil — первая страница
+}
+
+// EncodeCursor кодирует позицию в непрозрачную строку для next_cursor.
+// MongoDB хранит даты с точностью до миллисекунд, поэтому её и сохраняем
+func EncodeCursor(createdAt time.Time, id primitive.ObjectID) string {
+	raw := strconv.FormatInt(createdAt.UnixMilli(), 10) + ":" + id.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(s string) (*PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	ms, hexID, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	millis, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &PageCursor{CreatedAt: time.UnixMilli(millis).UTC(), ID: id}, nil
+}
+
+// match возвращает условие "строго после курсора" в порядке cursorSort
+func (c *PageCursor) match() bson.M {
+	return c.matchOn("created_at")
+}
+
+// matchOn — то же для сортировки по другому полю времени (field desc, _id desc)
+func (c *PageCursor) matchOn(field string) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{"$lt": c.CreatedAt}},
+		bson.M{field: c.CreatedAt, "_id": bson.M{"$lt": c.ID}},
+	}}
+}
+
+// apply возвращает копию filter с условием курсора
+func (p CursorPage) apply(filter bson.M) bson.M {
+	if p.After == nil {
+		return filter
+	}
+
+	result := make(bson.M, len(filter)+1)
+	for k, v := range filter {
+		result[k] = v
+	}
+	// $and может уже содержать поиск по названию — дополняем, а не заменяем
+	and, _ := filter["$and"].(bson.A)
+	result["$and"] = append(append(bson.A{}, and...), p.After.match())
+	return result
+}
+
+// aggregateCount считает документы на выходе pipeline; skip — не считать (для count=false)
+func aggregateCount(ctx context.Context, coll *mongo.Collection, pipeline mongo.Pipeline, skip bool) (int64, error) {
+	if skip {
+		return 0, nil
+	}
+
+	countPipeline := append(pipeline[:len(pipeline):len(pipeline)], bson.D{{Key: "$count", Value: "total"}})
+	cursor, err := coll.Aggregate(ctx, countPipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Total int64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return 0, err
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	return result[0].Total, nil
+}
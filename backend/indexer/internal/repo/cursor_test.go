@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	createdAt := time.Date(2025, 3, 14, 15, 9, 26, 535_000_000, time.UTC)
+
+	got, err := DecodeCursor(EncodeCursor(createdAt, id))
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if !got.CreatedAt.Equal(createdAt) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, createdAt)
+	}
+	if got.ID != id {
+		t.Errorf("ID = %v, want %v", got.ID, id)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"not base64!",
+		"MTIz",                         // "123" без разделителя
+		"YWJjOjY1ZjAwMDAwMDAwMDAwMDAw", // нечисловое время
+		"MTIzOnh5eg",                   // "123:xyz" — битый ObjectID
+	}
+
+	for _, s := range tests {
+		if _, err := DecodeCursor(s); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestCursorPageApply(t *testing.T) {
+	filter := bson.M{"status": "active"}
+
+	if got := (CursorPage{Enabled: true}).apply(filter); len(got) != 1 {
+		t.Errorf("first page must not add cursor condition, got %v", got)
+	}
+
+	after := &PageCursor{CreatedAt: time.Now(), ID: primitive.NewObjectID()}
+	got := CursorPage{Enabled: true, After: after}.apply(filter)
+	if _, ok := got["$and"]; !ok {
+		t.Errorf("cursor condition missing: %v", got)
+	}
+	if got["status"] != "active" {
+		t.Errorf("original condition lost: %v", got)
+	}
+	if _, ok := filter["$and"]; ok {
+		t.Error("apply must not mutate the original filter")
+	}
+
+	titled := bson.M{"$and": bson.A{bson.M{"title": "x"}}}
+	got = CursorPage{Enabled: true, After: after}.apply(titled)
+	if and := got["$and"].(bson.A); len(and) != 2 {
+		t.Errorf("existing $and conditions must be kept, got %v", and)
+	}
+	if len(titled["$and"].(bson.A)) != 1 {
+		t.Error("apply must not mutate the original $and")
+	}
+}
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"strings"
+
+	"github.com/video-analitics/backend/pkg/status"
+)
+
+// Признаки причин в свободном тексте; порядок проверки — капча, блокировка, недоступность
+var (
+	captchaMarkers = []string{"captcha", "капч"}
+	blockedMarkers = []string{"403", "429", "503", "blocked", "forbidden", "access denied", "доступ запрещ"}
+	timeoutMarkers = []string{"timeout", "timed out", "deadline", "retries", "no such host", "dns", "connection refused", "unreachable"}
+)
+
+// FreezeReasonFromText подбирает ближайшую причину заморозки по тексту ошибки.
+// Нужна для старых записей со свободным текстом и для ошибок парсера, где причина не типизирована
+func FreezeReasonFromText(text string) status.FreezeReason {
+	text = strings.ToLower(text)
+	switch {
+	case containsAny(text, captchaMarkers):
+		return status.FreezeCaptchaUnsolved
+	case containsAny(text, blockedMarkers):
+		return status.FreezeIPBlocked
+	case containsAny(text, timeoutMarkers):
+		return status.FreezeRepeatedTimeout
+	}
+	return status.FreezeManual
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
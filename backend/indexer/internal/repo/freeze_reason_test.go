@@ -0,0 +1,33 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/video-analitics/backend/pkg/status"
+)
+
+func TestFreezeReasonFromText(t *testing.T) {
+	tests := []struct {
+		text string
+		want status.FreezeReason
+	}{
+		{"blocked: captcha solve failed: timeout", status.FreezeCaptchaUnsolved},
+		{"Капча не решена", status.FreezeCaptchaUnsolved},
+		{"Blocked 12 requests (403/429/503)", status.FreezeIPBlocked},
+		{"HTTP 403/429/503 detected", status.FreezeIPBlocked},
+		{"detection failed after 3 retries: 403 Forbidden", status.FreezeIPBlocked},
+		{"max scan retries exceeded", status.FreezeRepeatedTimeout},
+		{"dial tcp: lookup kino.example: no such host", status.FreezeRepeatedTimeout},
+		{"context deadline exceeded", status.FreezeRepeatedTimeout},
+		{"", status.FreezeManual},
+		{"проверить вручную", status.FreezeManual},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := FreezeReasonFromText(tt.text); got != tt.want {
+				t.Errorf("FreezeReasonFromText(%q) = %s, want %s", tt.text, got, tt.want)
+			}
+		})
+	}
+}
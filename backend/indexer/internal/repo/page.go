@@ -27,6 +27,8 @@ func NewPageRepo(db *mongo.Database) *PageRepo {
 	indexes := []mongo.IndexModel{
 		// Уникальность страниц (site_id + url)
 		{Keys: bson.D{{Key: "site_id", Value: 1}, {Key: "url", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// Для FindByURL
+		{Keys: bson.D{{Key: "url", Value: 1}}},
 		// Для FindBySiteID + CountBySiteID + пагинация
 		{Keys: bson.D{{Key: "site_id", Value: 1}, {Key: "indexed_at", Value: -1}}},
 		// Для Search по KPID + пагинация
@@ -66,6 +68,55 @@ func (r *PageRepo) FindBySiteID(ctx context.Context, siteID string, limit, offse
 	return pages, total, nil
 }
 
+// ForEachBySiteID обходит все страницы сайта курсором
+func (r *PageRepo) ForEachBySiteID(ctx context.Context, siteID string, fn func(p *models.Page) error) error {
+	cursor, err := r.coll.Find(ctx, bson.M{"site_id": siteID}, options.Find().SetBatchSize(1000))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var page models.Page
+		if err := cursor.Decode(&page); err != nil {
+			return err
+		}
+		if err := fn(&page); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (r *PageRepo) FindByID(ctx context.Context, id string) (*models.Page, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var page models.Page
+	err = r.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&page)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (r *PageRepo) FindByURL(ctx context.Context, url string) (*models.Page, error) {
+	var page models.Page
+	err := r.coll.FindOne(ctx, bson.M{"url": url}).Decode(&page)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
 func (r *PageRepo) FindByExternalID(ctx context.Context, idType, idValue string, limit, offset int64) ([]models.Page, int64, error) {
 	fieldName := "external_ids." + idType
 	filter := bson.M{fieldName: idValue}
@@ -95,55 +146,7 @@ func (r *PageRepo) FindByExternalID(ctx context.Context, idType, idValue string,
 }
 
 func (r *PageRepo) Search(ctx context.Context, query PageQuery) ([]models.Page, int64, error) {
-	filter := bson.M{}
-
-	if query.SiteID != "" {
-		filter["site_id"] = query.SiteID
-	}
-	if query.KinopoiskID != "" {
-		filter["external_ids.kinopoisk_id"] = query.KinopoiskID
-	}
-	if query.IMDBID != "" {
-		filter["external_ids.imdb_id"] = query.IMDBID
-	}
-	if query.Title != "" {
-		filter["title"] = bson.M{"$regex": query.Title, "$options": "i"}
-	}
-	if query.Year > 0 {
-		filter["year"] = query.Year
-	}
-	if query.HasPlayer != nil {
-		if *query.HasPlayer {
-			filter["player_url"] = bson.M{"$ne": ""}
-		} else {
-			filter["$or"] = []bson.M{
-				{"player_url": ""},
-				{"player_url": bson.M{"$exists": false}},
-			}
-		}
-	}
-	if len(query.PageIDs) > 0 {
-		oids := make([]primitive.ObjectID, 0, len(query.PageIDs))
-		for _, id := range query.PageIDs {
-			if oid, err := primitive.ObjectIDFromHex(id); err == nil {
-				oids = append(oids, oid)
-			}
-		}
-		if len(oids) > 0 {
-			filter["_id"] = bson.M{"$in": oids}
-		}
-	}
-	if len(query.ExcludePageIDs) > 0 {
-		oids := make([]primitive.ObjectID, 0, len(query.ExcludePageIDs))
-		for _, id := range query.ExcludePageIDs {
-			if oid, err := primitive.ObjectIDFromHex(id); err == nil {
-				oids = append(oids, oid)
-			}
-		}
-		if len(oids) > 0 {
-			filter["_id"] = bson.M{"$nin": oids}
-		}
-	}
+	filter := pageSearchFilter(query)
 
 	total, err := r.coll.CountDocuments(ctx, filter)
 	if err != nil {
@@ -180,6 +183,21 @@ func (r *PageRepo) Search(ctx context.Context, query PageQuery) ([]models.Page,
 	return pages, total, nil
 }
 
+// indexedAtRange — условие на indexed_at: since включительно, before исключительно; nil — без ограничений
+func indexedAtRange(since, before *time.Time) bson.M {
+	if since == nil && before == nil {
+		return nil
+	}
+	cond := bson.M{}
+	if since != nil {
+		cond["$gte"] = *since
+	}
+	if before != nil {
+		cond["$lt"] = *before
+	}
+	return cond
+}
+
 func (r *PageRepo) CountBySiteID(ctx context.Context, siteID string) (int64, error) {
 	return r.coll.CountDocuments(ctx, bson.M{"site_id": siteID})
 }
@@ -200,7 +218,7 @@ func (r *PageRepo) GetStats(ctx context.Context, siteID string) (*PageStats, err
 
 	kpFilter := bson.M{"external_ids.kpid": bson.M{"$ne": ""}}
 	imdbFilter := bson.M{"external_ids.imdb_id": bson.M{"$ne": ""}}
-	playerFilter := bson.M{"player_url": bson.M{"$ne": ""}}
+	playerFilter := presenceFilter("player_url", true)
 
 	if siteID != "" {
 		kpFilter["site_id"] = siteID
@@ -225,16 +243,107 @@ type PageQuery struct {
 	KinopoiskID    string
 	IMDBID         string
 	Title          string
-	Year           int      // фильтр по году
-	HasPlayer      *bool    // только с плеером
-	PageIDs        []string // фильтр по ID (для has_violations=true)
-	ExcludePageIDs []string // исключить ID (для has_violations=false)
-	SortBy         string   // "indexed_at", "year"
-	SortOrder      string   // "asc", "desc"
+	Year           int        // фильтр по году
+	HasPlayer      *bool      // только с плеером
+	HasExternalID  *bool      // только с каким-либо внешним ID
+	IndexedSince   *time.Time // проиндексированы не раньше
+	IndexedBefore  *time.Time // проиндексированы раньше
+	PageIDs        []string   // фильтр по ID (для has_violations=true)
+	ExcludePageIDs []string   // исключить ID (для has_violations=false)
+	SortBy         string     // "indexed_at", "year"
+	SortOrder      string     // "asc", "desc"
 	Limit          int64
 	Offset         int64
 }
 
+// pageSearchFilter строит фильтр Search по всем условиям запроса, кроме пагинации и сортировки
+func pageSearchFilter(query PageQuery) bson.M {
+	filter := bson.M{}
+
+	if query.SiteID != "" {
+		filter["site_id"] = query.SiteID
+	}
+	if query.KinopoiskID != "" {
+		filter["external_ids.kinopoisk_id"] = query.KinopoiskID
+	}
+	if query.IMDBID != "" {
+		filter["external_ids.imdb_id"] = query.IMDBID
+	}
+	if query.Title != "" {
+		filter["title"] = bson.M{"$regex": query.Title, "$options": "i"}
+	}
+	if query.Year > 0 {
+		filter["year"] = query.Year
+	}
+	if indexedAt := indexedAtRange(query.IndexedSince, query.IndexedBefore); indexedAt != nil {
+		filter["indexed_at"] = indexedAt
+	}
+	var conds []bson.M
+	if query.HasPlayer != nil {
+		conds = append(conds, presenceFilter("player_url", *query.HasPlayer))
+	}
+	if query.HasExternalID != nil {
+		conds = append(conds, externalIDPresenceFilter(*query.HasExternalID))
+	}
+	if len(conds) > 0 {
+		filter["$and"] = conds
+	}
+	if len(query.PageIDs) > 0 {
+		oids := make([]primitive.ObjectID, 0, len(query.PageIDs))
+		for _, id := range query.PageIDs {
+			if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+				oids = append(oids, oid)
+			}
+		}
+		if len(oids) > 0 {
+			filter["_id"] = bson.M{"$in": oids}
+		}
+	}
+	if len(query.ExcludePageIDs) > 0 {
+		oids := make([]primitive.ObjectID, 0, len(query.ExcludePageIDs))
+		for _, id := range query.ExcludePageIDs {
+			if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+				oids = append(oids, oid)
+			}
+		}
+		if len(oids) > 0 {
+			filter["_id"] = bson.M{"$nin": oids}
+		}
+	}
+	return filter
+}
+
+// pageExternalIDFields — внешние ID, по которым страница опознаётся как страница фильма, а не навигация
+var pageExternalIDFields = []string{
+	"external_ids.kinopoisk_id",
+	"external_ids.imdb_id",
+	"external_ids.tmdb_id",
+	"external_ids.mal_id",
+	"external_ids.shikimori_id",
+	"external_ids.mydramalist_id",
+}
+
+// presenceFilter — поле заполнено (has) или пустое. Поля сохраняются с omitempty,
+// а null в $in/$nin совпадает и с отсутствующим полем
+func presenceFilter(field string, has bool) bson.M {
+	if has {
+		return bson.M{field: bson.M{"$nin": bson.A{nil, ""}}}
+	}
+	return bson.M{field: bson.M{"$in": bson.A{nil, ""}}}
+}
+
+// externalIDPresenceFilter — заполнен хотя бы один внешний ID (has) или ни одного
+func externalIDPresenceFilter(has bool) bson.M {
+	conds := make(bson.A, len(pageExternalIDFields))
+	for i, field := range pageExternalIDFields {
+		conds[i] = presenceFilter(field, has)
+	}
+	if has {
+		return bson.M{"$or": conds}
+	}
+	return bson.M{"$and": conds}
+}
+
 type PageStats struct {
 	Total      int64 `json:"total"`
 	WithKPID   int64 `json:"with_kpid"`
@@ -250,6 +359,58 @@ func (r *PageRepo) DeleteBySiteID(ctx context.Context, siteID string) (int64, er
 	return result.DeletedCount, nil
 }
 
+// DeleteByID удаляет страницу; отсутствующая страница — не ошибка
+func (r *PageRepo) DeleteByID(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = r.coll.DeleteOne(ctx, bson.M{"_id": oid})
+	return err
+}
+
+// PageValidators — сохранённые ETag и Last-Modified страницы
+type PageValidators struct {
+	ETag         string `bson:"etag"`
+	LastModified string `bson:"last_modified"`
+}
+
+// FindValidators возвращает валидаторы уже проиндексированных страниц сайта по URL;
+// страниц без валидаторов в ответе нет
+func (r *PageRepo) FindValidators(ctx context.Context, siteID string, urls []string) (map[string]PageValidators, error) {
+	result := make(map[string]PageValidators)
+	if len(urls) == 0 {
+		return result, nil
+	}
+
+	filter := bson.M{
+		"site_id": siteID,
+		"url":     bson.M{"$in": urls},
+		"$or": bson.A{
+			presenceFilter("etag", true),
+			presenceFilter("last_modified", true),
+		},
+	}
+	opts := options.Find().SetProjection(bson.M{"url": 1, "etag": 1, "last_modified": 1})
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		URL            string `bson:"url"`
+		PageValidators `bson:",inline"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.URL] = row.PageValidators
+	}
+	return result, nil
+}
+
 func (r *PageRepo) Upsert(ctx context.Context, page *models.Page) error {
 	filter := bson.M{"site_id": page.SiteID, "url": page.URL}
 	update := bson.M{"$set": page}
@@ -263,3 +424,15 @@ func (r *PageRepo) Upsert(ctx context.Context, page *models.Page) error {
 	page.ID = result.ID
 	return nil
 }
+
+// ClearSnapshotsBefore убирает у страниц ссылки на снимки, снятые раньше cutoff
+func (r *PageRepo) ClearSnapshotsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.coll.UpdateMany(ctx,
+		bson.M{"snapshot_at": bson.M{"$lt": cutoff}},
+		bson.M{"$unset": bson.M{"snapshot_key": "", "snapshot_at": ""}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestIndexedAtRange(t *testing.T) {
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		since, before *time.Time
+		want          bson.M
+	}{
+		{"no bounds", nil, nil, nil},
+		{"since only", &since, nil, bson.M{"$gte": since}},
+		{"before only", nil, &before, bson.M{"$lt": before}},
+		{"both", &since, &before, bson.M{"$gte": since, "$lt": before}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := indexedAtRange(tt.since, tt.before); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("indexedAtRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageSearchFilterPresence(t *testing.T) {
+	yes, no := true, false
+	hasPlayer := bson.M{"player_url": bson.M{"$nin": bson.A{nil, ""}}}
+	noPlayer := bson.M{"player_url": bson.M{"$in": bson.A{nil, ""}}}
+	anyID := bson.M{"$or": bson.A{
+		bson.M{"external_ids.kinopoisk_id": bson.M{"$nin": bson.A{nil, ""}}},
+		bson.M{"external_ids.imdb_id": bson.M{"$nin": bson.A{nil, ""}}},
+		bson.M{"external_ids.tmdb_id": bson.M{"$nin": bson.A{nil, ""}}},
+		bson.M{"external_ids.mal_id": bson.M{"$nin": bson.A{nil, ""}}},
+		bson.M{"external_ids.shikimori_id": bson.M{"$nin": bson.A{nil, ""}}},
+		bson.M{"external_ids.mydramalist_id": bson.M{"$nin": bson.A{nil, ""}}},
+	}}
+	noID := bson.M{"$and": bson.A{
+		bson.M{"external_ids.kinopoisk_id": bson.M{"$in": bson.A{nil, ""}}},
+		bson.M{"external_ids.imdb_id": bson.M{"$in": bson.A{nil, ""}}},
+		bson.M{"external_ids.tmdb_id": bson.M{"$in": bson.A{nil, ""}}},
+		bson.M{"external_ids.mal_id": bson.M{"$in": bson.A{nil, ""}}},
+		bson.M{"external_ids.shikimori_id": bson.M{"$in": bson.A{nil, ""}}},
+		bson.M{"external_ids.mydramalist_id": bson.M{"$in": bson.A{nil, ""}}},
+	}}
+
+	tests := []struct {
+		name  string
+		query PageQuery
+		want  bson.M
+	}{
+		{"no filters", PageQuery{SiteID: "s1"}, bson.M{"site_id": "s1"}},
+		{"has player", PageQuery{HasPlayer: &yes}, bson.M{"$and": []bson.M{hasPlayer}}},
+		{"no player", PageQuery{HasPlayer: &no}, bson.M{"$and": []bson.M{noPlayer}}},
+		{"has external id", PageQuery{HasExternalID: &yes}, bson.M{"$and": []bson.M{anyID}}},
+		{"no external id", PageQuery{HasExternalID: &no}, bson.M{"$and": []bson.M{noID}}},
+		{
+			name:  "both negative filters combine",
+			query: PageQuery{SiteID: "s1", HasPlayer: &no, HasExternalID: &no},
+			want:  bson.M{"site_id": "s1", "$and": []bson.M{noPlayer, noID}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pageSearchFilter(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pageSearchFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
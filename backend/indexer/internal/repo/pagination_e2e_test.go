@@ -0,0 +1,150 @@
+//go:build e2e
+// +build e2e
+
+package repo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func setupTestDB(t *testing.T, ctx context.Context) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("failed to connect to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Skipf("MongoDB is not available at %s: %v", uri, err)
+	}
+
+	db := client.Database(fmt.Sprintf("video_analitics_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return db
+}
+
+func TestSiteCursorPaginationStableAcrossInserts_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db := setupTestDB(t, ctx)
+	siteRepo := NewSiteRepo(db)
+
+	// Одинаковый created_at у части сайтов проверяет разрешение коллизий по _id
+	base := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	for i := 0; i < 25; i++ {
+		_, err := db.Collection("sites").InsertOne(ctx, Site{
+			ID:        primitive.NewObjectID(),
+			Domain:    fmt.Sprintf("site-%02d.test", i),
+			CreatedAt: base.Add(time.Duration(i/3) * time.Second),
+		})
+		if err != nil {
+			t.Fatalf("insert site: %v", err)
+		}
+	}
+
+	const limit = 10
+	seen := make(map[primitive.ObjectID]bool)
+	page := CursorPage{Enabled: true}
+
+	for pageNum := 0; ; pageNum++ {
+		sites, total, err := siteRepo.FindAll(ctx, SiteFilter{Limit: limit, Cursor: page})
+		if err != nil {
+			t.Fatalf("FindAll page %d: %v", pageNum, err)
+		}
+		if pageNum == 0 && total != 25 {
+			t.Fatalf("total = %d, want 25", total)
+		}
+
+		for _, s := range sites {
+			if seen[s.ID] {
+				t.Fatalf("site %s returned twice", s.Domain)
+			}
+			seen[s.ID] = true
+		}
+
+		// Новые сайты между страницами не должны сдвигать выдачу
+		if pageNum == 0 {
+			for i := 0; i < 5; i++ {
+				if err := siteRepo.Create(ctx, &Site{Domain: fmt.Sprintf("new-%d.test", i)}); err != nil {
+					t.Fatalf("create site: %v", err)
+				}
+			}
+		}
+
+		if len(sites) < limit {
+			break
+		}
+		last := sites[len(sites)-1]
+		after, err := DecodeCursor(EncodeCursor(last.CreatedAt, last.ID))
+		if err != nil {
+			t.Fatalf("DecodeCursor: %v", err)
+		}
+		page = CursorPage{Enabled: true, After: after}
+	}
+
+	if len(seen) != 25 {
+		t.Errorf("paged through %d sites, want 25 original sites exactly once", len(seen))
+	}
+}
+
+func TestScanTaskCursorPaginationOrder_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db := setupTestDB(t, ctx)
+	taskRepo := NewScanTaskRepo(db)
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	for i := 0; i < 7; i++ {
+		_, err := db.Collection(scanTasksCollection).InsertOne(ctx, ScanTask{
+			ID:        primitive.NewObjectID(),
+			SiteID:    "site-1",
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatalf("insert task: %v", err)
+		}
+	}
+
+	var prev *ScanTask
+	page := CursorPage{Enabled: true}
+	count := 0
+	for {
+		tasks, _, err := taskRepo.FindWithPagination(ctx, TaskFilter{SiteID: "site-1", Limit: 3, Cursor: page})
+		if err != nil {
+			t.Fatalf("FindWithPagination: %v", err)
+		}
+		for i := range tasks {
+			if prev != nil && !tasks[i].CreatedAt.Before(prev.CreatedAt) {
+				t.Fatalf("tasks not in created_at desc order: %v after %v", tasks[i].CreatedAt, prev.CreatedAt)
+			}
+			prev = &tasks[i]
+			count++
+		}
+		if len(tasks) < 3 {
+			break
+		}
+		page = CursorPage{Enabled: true, After: &PageCursor{CreatedAt: prev.CreatedAt, ID: prev.ID}}
+	}
+
+	if count != 7 {
+		t.Errorf("paged through %d tasks, want 7", count)
+	}
+}
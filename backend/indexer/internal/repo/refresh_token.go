@@ -81,13 +81,14 @@ func (r *RefreshTokenRepo) FindByUserID(ctx context.Context, userID string) (*Re
 	return &token, err
 }
 
+// DeleteByUserID отзывает все refresh-токены пользователя
 func (r *RefreshTokenRepo) DeleteByUserID(ctx context.Context, userID string) error {
 	oid, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.coll.DeleteOne(ctx, bson.M{"user_id": oid})
+	_, err = r.coll.DeleteMany(ctx, bson.M{"user_id": oid})
 	return err
 }
 
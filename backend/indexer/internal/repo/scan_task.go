@@ -57,6 +57,7 @@ func NewScanTaskRepo(db *mongo.Database) *ScanTaskRepo {
 		{Keys: bson.D{{Key: "site_id", Value: 1}, {Key: "status", Value: 1}}},
 		{Keys: bson.D{{Key: "stage", Value: 1}, {Key: "created_at", Value: -1}}},
 		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_retry_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "finished_at", Value: -1}}},
 	}
 	coll.Indexes().CreateMany(ctx, indexes)
 
@@ -153,27 +154,49 @@ func (r *ScanTaskRepo) FindRecent(ctx context.Context, limit int64) ([]ScanTask,
 	return tasks, nil
 }
 
-func (r *ScanTaskRepo) FindWithPagination(ctx context.Context, siteID, domain, taskStatus string, limit, offset int64) ([]ScanTask, int64, error) {
-	filter := bson.M{}
-	if siteID != "" {
-		filter["site_id"] = siteID
+// TaskFilter — фильтр и пагинация списка задач
+type TaskFilter struct {
+	SiteID    string
+	Domain    string // частичное совпадение без учёта регистра
+	Status    string
+	Limit     int64
+	Offset    int64
+	Cursor    CursorPage
+	SkipCount bool // не считать total (вернётся 0)
+}
+
+func (f TaskFilter) match() bson.M {
+	match := bson.M{}
+	if f.SiteID != "" {
+		match["site_id"] = f.SiteID
 	}
-	if domain != "" {
-		filter["domain"] = bson.M{"$regex": domain, "$options": "i"}
+	if f.Domain != "" {
+		match["domain"] = bson.M{"$regex": f.Domain, "$options": "i"}
 	}
-	if taskStatus != "" {
-		filter["status"] = status.Task(taskStatus)
+	if f.Status != "" {
+		match["status"] = status.Task(f.Status)
 	}
+	return match
+}
 
-	total, err := r.coll.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, 0, err
+func (r *ScanTaskRepo) FindWithPagination(ctx context.Context, f TaskFilter) ([]ScanTask, int64, error) {
+	filter := f.match()
+
+	var total int64
+	if !f.SkipCount {
+		var err error
+		if total, err = r.coll.CountDocuments(ctx, filter); err != nil {
+			return nil, 0, err
+		}
 	}
 
-	opts := options.Find().
-		SetLimit(limit).
-		SetSkip(offset).
-		SetSort(bson.D{{Key: "created_at", Value: -1}})
+	opts := options.Find().SetLimit(f.Limit)
+	if f.Cursor.Enabled {
+		filter = f.Cursor.apply(filter)
+		opts.SetSort(cursorSort)
+	} else {
+		opts.SetSkip(f.Offset).SetSort(bson.D{{Key: "created_at", Value: -1}})
+	}
 
 	cursor, err := r.coll.Find(ctx, filter, opts)
 	if err != nil {
@@ -190,23 +213,14 @@ func (r *ScanTaskRepo) FindWithPagination(ctx context.Context, siteID, domain, t
 
 // FindByUserAccess returns tasks filtered by user access to sites using aggregation
 // This is efficient even with millions of sites - filtering happens in MongoDB
-func (r *ScanTaskRepo) FindByUserAccess(ctx context.Context, userID string, db *mongo.Database, siteID, domain, taskStatus string, limit, offset int64) ([]ScanTask, int64, error) {
+func (r *ScanTaskRepo) FindByUserAccess(ctx context.Context, userID string, db *mongo.Database, f TaskFilter) ([]ScanTask, int64, error) {
 	userOID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Build match stage for tasks
-	taskMatch := bson.M{}
-	if siteID != "" {
-		taskMatch["site_id"] = siteID
-	}
-	if domain != "" {
-		taskMatch["domain"] = bson.M{"$regex": domain, "$options": "i"}
-	}
-	if taskStatus != "" {
-		taskMatch["status"] = status.Task(taskStatus)
-	}
+	taskMatch := f.match()
 
 	// Pipeline: join with sites, filter by owner_id or user_sites
 	pipeline := mongo.Pipeline{
@@ -259,30 +273,27 @@ func (r *ScanTaskRepo) FindByUserAccess(ctx context.Context, userID string, db *
 	}
 
 	// Count total (without pagination)
-	countPipeline := append(pipeline, bson.D{{Key: "$count", Value: "total"}})
-	countCursor, err := r.coll.Aggregate(ctx, countPipeline)
+	total, err := aggregateCount(ctx, r.coll, pipeline, f.SkipCount)
 	if err != nil {
 		return nil, 0, err
 	}
-	var countResult []struct {
-		Total int64 `bson:"total"`
-	}
-	if err := countCursor.All(ctx, &countResult); err != nil {
-		return nil, 0, err
-	}
-	countCursor.Close(ctx)
-
-	var total int64
-	if len(countResult) > 0 {
-		total = countResult[0].Total
-	}
 
 	// Add sort, skip, limit for data query
-	pipeline = append(pipeline,
-		bson.D{{Key: "$sort", Value: bson.M{"created_at": -1}}},
-		bson.D{{Key: "$skip", Value: offset}},
-		bson.D{{Key: "$limit", Value: limit}},
-	)
+	if f.Cursor.Enabled {
+		if f.Cursor.After != nil {
+			pipeline = append(pipeline, bson.D{{Key: "$match", Value: f.Cursor.After.match()}})
+		}
+		pipeline = append(pipeline,
+			bson.D{{Key: "$sort", Value: cursorSort}},
+			bson.D{{Key: "$limit", Value: f.Limit}},
+		)
+	} else {
+		pipeline = append(pipeline,
+			bson.D{{Key: "$sort", Value: bson.M{"created_at": -1}}},
+			bson.D{{Key: "$skip", Value: f.Offset}},
+			bson.D{{Key: "$limit", Value: f.Limit}},
+		)
+	}
 
 	cursor, err := r.coll.Aggregate(ctx, pipeline)
 	if err != nil {
@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"errors"
+	"time"
+)
+
+// ScanWindow — часы, в которые сайт можно сканировать (по местному времени сайта).
+// Окно [StartHour, EndHour) может переходить через полночь: 22–4 означает с 22:00 до 04:00
+type ScanWindow struct {
+	StartHour int    `bson:"start_hour" json:"start_hour"`
+	EndHour   int    `bson:"end_hour" json:"end_hour"`
+	Timezone  string `bson:"timezone,omitempty" json:"timezone,omitempty"` // IANA, например Europe/Moscow; пусто — UTC
+}
+
+func (w ScanWindow) Validate() error {
+	if w.StartHour < 0 || w.StartHour > 23 || w.EndHour < 0 || w.EndHour > 23 {
+		return errors.New("start_hour and end_hour must be between 0 and 23")
+	}
+	if w.StartHour == w.EndHour {
+		return errors.New("start_hour and end_hour must differ")
+	}
+	if _, err := time.LoadLocation(w.Timezone); err != nil {
+		return errors.New("unknown timezone: " + w.Timezone)
+	}
+	return nil
+}
+
+func (w ScanWindow) location() *time.Location {
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Contains — попадает ли момент t в окно
+func (w ScanWindow) Contains(t time.Time) bool {
+	h := t.In(w.location()).Hour()
+	if w.StartHour < w.EndHour {
+		return h >= w.StartHour && h < w.EndHour
+	}
+	return h >= w.StartHour || h < w.EndHour
+}
+
+// NextStart — ближайшее начало окна строго после t
+func (w ScanWindow) NextStart(t time.Time) time.Time {
+	local := t.In(w.location())
+	start := time.Date(local.Year(), local.Month(), local.Day(), w.StartHour, 0, 0, 0, local.Location())
+	if !start.After(t) {
+		start = time.Date(local.Year(), local.Month(), local.Day()+1, w.StartHour, 0, 0, 0, local.Location())
+	}
+	return start
+}
+
+// ScanDeferredUntil возвращает начало следующего окна, если сейчас сайт сканировать нельзя
+func (s *Site) ScanDeferredUntil(now time.Time) (time.Time, bool) {
+	if s.ScanWindow == nil || s.ScanWindow.Contains(now) {
+		return time.Time{}, false
+	}
+	return s.ScanWindow.NextStart(now), true
+}
@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScanWindowContains(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip("tzdata unavailable:", err)
+	}
+
+	tests := []struct {
+		name   string
+		window ScanWindow
+		at     time.Time
+		want   bool
+	}{
+		{"start is inclusive", ScanWindow{StartHour: 2, EndHour: 6}, time.Date(2025, 5, 1, 2, 0, 0, 0, time.UTC), true},
+		{"inside", ScanWindow{StartHour: 2, EndHour: 6}, time.Date(2025, 5, 1, 5, 59, 59, 0, time.UTC), true},
+		{"end is exclusive", ScanWindow{StartHour: 2, EndHour: 6}, time.Date(2025, 5, 1, 6, 0, 0, 0, time.UTC), false},
+		{"before start", ScanWindow{StartHour: 2, EndHour: 6}, time.Date(2025, 5, 1, 1, 59, 0, 0, time.UTC), false},
+		{"over midnight, late evening", ScanWindow{StartHour: 22, EndHour: 4}, time.Date(2025, 5, 1, 23, 0, 0, 0, time.UTC), true},
+		{"over midnight, early morning", ScanWindow{StartHour: 22, EndHour: 4}, time.Date(2025, 5, 1, 3, 0, 0, 0, time.UTC), true},
+		{"over midnight, daytime", ScanWindow{StartHour: 22, EndHour: 4}, time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC), false},
+		// 23:30 UTC = 02:30 МСК
+		{"timezone inside", ScanWindow{StartHour: 2, EndHour: 6, Timezone: "Europe/Moscow"}, time.Date(2025, 5, 1, 23, 30, 0, 0, time.UTC), true},
+		// 04:00 UTC = 07:00 МСК
+		{"timezone outside", ScanWindow{StartHour: 2, EndHour: 6, Timezone: "Europe/Moscow"}, time.Date(2025, 5, 1, 4, 0, 0, 0, time.UTC), false},
+		{"time in another zone", ScanWindow{StartHour: 2, EndHour: 6}, time.Date(2025, 5, 1, 5, 0, 0, 0, moscow), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Contains(tt.at); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanWindowNextStart(t *testing.T) {
+	if _, err := time.LoadLocation("America/New_York"); err != nil {
+		t.Skip("tzdata unavailable:", err)
+	}
+
+	tests := []struct {
+		name   string
+		window ScanWindow
+		at     time.Time
+		want   time.Time
+	}{
+		{"later today", ScanWindow{StartHour: 2, EndHour: 6}, time.Date(2025, 5, 1, 1, 0, 0, 0, time.UTC), time.Date(2025, 5, 1, 2, 0, 0, 0, time.UTC)},
+		{"tomorrow after window", ScanWindow{StartHour: 2, EndHour: 6}, time.Date(2025, 5, 1, 6, 0, 0, 0, time.UTC), time.Date(2025, 5, 2, 2, 0, 0, 0, time.UTC)},
+		{"exactly at start moves to next day", ScanWindow{StartHour: 2, EndHour: 6}, time.Date(2025, 5, 1, 2, 0, 0, 0, time.UTC), time.Date(2025, 5, 2, 2, 0, 0, 0, time.UTC)},
+		{"over midnight", ScanWindow{StartHour: 22, EndHour: 4}, time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC), time.Date(2025, 5, 1, 22, 0, 0, 0, time.UTC)},
+		// 12:00 UTC = 15:00 МСК, следующее окно — 02:00 МСК = 23:00 UTC
+		{"timezone", ScanWindow{StartHour: 2, EndHour: 6, Timezone: "Europe/Moscow"}, time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC), time.Date(2025, 5, 1, 23, 0, 0, 0, time.UTC)},
+		// Переход на летнее время 9 марта 2025: 03:00 EDT = 07:00 UTC вместо 08:00
+		{"dst switch", ScanWindow{StartHour: 3, EndHour: 6, Timezone: "America/New_York"}, time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC), time.Date(2025, 3, 9, 7, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.NextStart(tt.at); !got.Equal(tt.want) {
+				t.Errorf("NextStart(%v) = %v, want %v", tt.at, got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSiteScanDeferredUntil(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, deferred := (&Site{}).ScanDeferredUntil(now); deferred {
+		t.Error("site without window must not be deferred")
+	}
+
+	site := &Site{ScanWindow: &ScanWindow{StartHour: 2, EndHour: 6}}
+	until, deferred := site.ScanDeferredUntil(now)
+	if !deferred || !until.Equal(time.Date(2025, 5, 2, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("ScanDeferredUntil = %v, %v", until, deferred)
+	}
+
+	site.ScanWindow = &ScanWindow{StartHour: 10, EndHour: 14}
+	if _, deferred := site.ScanDeferredUntil(now); deferred {
+		t.Error("site inside window must not be deferred")
+	}
+}
+
+func TestScanWindowValidate(t *testing.T) {
+	tests := []struct {
+		window  ScanWindow
+		wantErr bool
+	}{
+		{ScanWindow{StartHour: 2, EndHour: 6}, false},
+		{ScanWindow{StartHour: 22, EndHour: 4, Timezone: "UTC"}, false},
+		{ScanWindow{StartHour: 2, EndHour: 2}, true},
+		{ScanWindow{StartHour: -1, EndHour: 6}, true},
+		{ScanWindow{StartHour: 2, EndHour: 24}, true},
+		{ScanWindow{StartHour: 2, EndHour: 6, Timezone: "Mars/Olympus"}, true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.window.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) = %v, wantErr %v", tt.window, err, tt.wantErr)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package repo
 
 import (
 	"context"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -33,6 +35,20 @@ type Cookie struct {
 	Secure   bool   `bson:"secure" json:"secure"`
 }
 
+// PageWait — как браузер ждёт загрузки страниц сайта перед извлечением HTML
+type PageWait struct {
+	Strategy  status.PageWait `bson:"strategy" json:"strategy"`
+	DelayMs   int             `bson:"delay_ms,omitempty" json:"delay_ms,omitempty"`
+	Selector  string          `bson:"selector,omitempty" json:"selector,omitempty"`
+	TimeoutMs int             `bson:"timeout_ms,omitempty" json:"timeout_ms,omitempty"`
+}
+
+// DNSOverride — резолвинг доменов сайта в обход системного DNS: фиксированные IP и/или свой резолвер
+type DNSOverride struct {
+	Resolver string            `bson:"resolver,omitempty" json:"resolver,omitempty"` // ip[:port] DNS-сервера
+	Hosts    map[string]string `bson:"hosts,omitempty" json:"hosts,omitempty"`       // хост → IPv4/IPv6
+}
+
 type Site struct {
 	ID               primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
 	OwnerID          primitive.ObjectID   `bson:"owner_id,omitempty" json:"owner_id,omitempty"`
@@ -43,6 +59,8 @@ type Site struct {
 	SitemapStatus    status.SitemapStatus `bson:"sitemap_status" json:"sitemap_status"`
 	CrawlStrategy    status.CrawlStrategy `bson:"crawl_strategy" json:"crawl_strategy"`
 	SitemapURLs      []string             `bson:"sitemap_urls,omitempty" json:"sitemap_urls,omitempty"`
+	SitemapFormat    string               `bson:"sitemap_format,omitempty" json:"sitemap_format,omitempty"`       // xml, text, json, html — определяется при детекции
+	SitemapJSONPath  string               `bson:"sitemap_json_path,omitempty" json:"sitemap_json_path,omitempty"` // путь к списку URL в JSON-карте
 	Sitemaps         []SitemapInfo        `bson:"sitemaps,omitempty" json:"sitemaps,omitempty"`
 	TotalURLsCount   int                  `bson:"total_urls_count" json:"total_urls_count"`
 	LastScanAt       *time.Time           `bson:"last_scan_at,omitempty" json:"last_scan_at,omitempty"`
@@ -51,12 +69,23 @@ type Site struct {
 	ScanIntervalH    int                  `bson:"scan_interval_h" json:"scan_interval_h"`
 	ScannerType      status.ScannerType   `bson:"scanner_type" json:"scanner_type"`
 	CaptchaType      string               `bson:"captcha_type,omitempty" json:"captcha_type,omitempty"`
+	PageWait         *PageWait            `bson:"page_wait,omitempty" json:"page_wait,omitempty"`                   // nil — стратегия парсера по умолчанию
+	MaxPagesPerScan  int                  `bson:"max_pages_per_scan,omitempty" json:"max_pages_per_scan,omitempty"` // 0 — без ограничения
+	DNSOverride      *DNSOverride         `bson:"dns_override,omitempty" json:"dns_override,omitempty"`             // nil — системный DNS парсера
+	ScanWindow       *ScanWindow          `bson:"scan_window,omitempty" json:"scan_window,omitempty"`               // nil — сканировать в любое время
+	DeltaCrawl       bool                 `bson:"delta_crawl,omitempty" json:"delta_crawl,omitempty"`               // повторно обходить только страницы с новым lastmod
 	Cookies          []Cookie             `bson:"cookies,omitempty" json:"-"`
 	CookiesUpdatedAt *time.Time           `bson:"cookies_updated_at,omitempty" json:"cookies_updated_at,omitempty"`
-	FreezeReason     string               `bson:"freeze_reason,omitempty" json:"freeze_reason,omitempty"`
+	FreezeReason     status.FreezeReason  `bson:"freeze_reason,omitempty" json:"freeze_reason,omitempty"`
+	FreezeDetails    string               `bson:"freeze_details,omitempty" json:"freeze_details,omitempty"` // исходный текст ошибки
+	FrozenAt         *time.Time           `bson:"frozen_at,omitempty" json:"frozen_at,omitempty"`           // последняя заморозка; не сбрасывается при разморозке
+	DeadReason       status.DeadReason    `bson:"dead_reason,omitempty" json:"dead_reason,omitempty"`
+	DeadDetails      string               `bson:"dead_details,omitempty" json:"dead_details,omitempty"` // сработавший признак парковки
 	MovedToDomain    string               `bson:"moved_to_domain,omitempty" json:"moved_to_domain,omitempty"`
 	MovedAt          *time.Time           `bson:"moved_at,omitempty" json:"moved_at,omitempty"`
 	OriginalDomain   string               `bson:"original_domain,omitempty" json:"original_domain,omitempty"`
+	DeletedAt        *time.Time           `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"` // в корзине с этого момента
+	DeletedBy        string               `bson:"deleted_by,omitempty" json:"-"`
 	CreatedAt        time.Time            `bson:"created_at" json:"created_at"`
 	Version          int                  `bson:"version" json:"-"`
 }
@@ -76,6 +105,9 @@ func NewSiteRepo(db *mongo.Database) *SiteRepo {
 		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
 		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_scan_at", Value: 1}}},
 		{Keys: bson.D{{Key: "owner_id", Value: 1}}},
+		{Keys: bson.D{{Key: "frozen_at", Value: -1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "freeze_reason", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "deleted_at", Value: 1}}, Options: options.Index().SetSparse(true)},
 	}
 	coll.Indexes().CreateMany(ctx, indexes)
 
@@ -108,13 +140,14 @@ func (r *SiteRepo) FindByID(ctx context.Context, id string) (*Site, error) {
 	}
 
 	var site Site
-	err = r.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&site)
+	err = r.coll.FindOne(ctx, notDeleted(bson.M{"_id": oid})).Decode(&site)
 	if err == mongo.ErrNoDocuments {
 		return nil, nil
 	}
 	return &site, err
 }
 
+// FindByDomain ищет и среди удалённых: домен уникален, и повторное добавление восстанавливает сайт
 func (r *SiteRepo) FindByDomain(ctx context.Context, domain string) (*Site, error) {
 	var site Site
 	err := r.coll.FindOne(ctx, bson.M{"domain": domain}).Decode(&site)
@@ -125,19 +158,50 @@ func (r *SiteRepo) FindByDomain(ctx context.Context, domain string) (*Site, erro
 }
 
 type SiteFilter struct {
+	// Domain — часть домена без учёта регистра; * задаёт шаблон по всему домену ("kino*", "*.ru")
+	Domain       string
 	Status       string
+	FreezeReason status.FreezeReason
 	ScannedSince *time.Time
+	NeverScanned bool // ни одной успешно завершённой задачи сканирования
 	SiteIDs      []string
 	ExcludeIDs   []string
 	Limit        int64
 	Offset       int64
+	Cursor       CursorPage
+	SkipCount    bool // не считать total (вернётся 0)
+}
+
+// domainSearchRegex переводит поиск по домену в регулярное выражение Mongo: без * — подстрока,
+// со * — шаблон по всему домену. Спецсимволы запроса экранируются; пустой запрос — nil
+func domainSearchRegex(q string) bson.M {
+	q = strings.ToLower(strings.TrimSpace(q))
+	if strings.Trim(q, "*") == "" {
+		return nil
+	}
+
+	pattern := regexp.QuoteMeta(q)
+	if strings.Contains(q, "*") {
+		parts := strings.Split(q, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		pattern = "^" + strings.Join(parts, ".*") + "$"
+	}
+	return bson.M{"$regex": pattern, "$options": "i"}
 }
 
 func (r *SiteRepo) FindAll(ctx context.Context, filter SiteFilter) ([]Site, int64, error) {
-	query := bson.M{}
+	query := notDeleted(bson.M{})
+	if re := domainSearchRegex(filter.Domain); re != nil {
+		query["domain"] = re
+	}
 	if filter.Status != "" {
 		query["status"] = filter.Status
 	}
+	if filter.FreezeReason != "" {
+		query["freeze_reason"] = filter.FreezeReason
+	}
 	if filter.ScannedSince != nil {
 		query["last_scan_at"] = bson.M{"$gte": *filter.ScannedSince}
 	}
@@ -159,19 +223,32 @@ func (r *SiteRepo) FindAll(ctx context.Context, filter SiteFilter) ([]Site, int6
 		}
 		query["_id"] = bson.M{"$nin": oids}
 	}
+	if filter.NeverScanned {
+		match, err := r.neverScannedMatch(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		query["$and"] = bson.A{match}
+	}
 
-	total, err := r.coll.CountDocuments(ctx, query)
-	if err != nil {
-		return nil, 0, err
+	var total int64
+	if !filter.SkipCount {
+		var err error
+		if total, err = r.coll.CountDocuments(ctx, query); err != nil {
+			return nil, 0, err
+		}
 	}
 
-	opts := options.Find().
-		SetLimit(filter.Limit).
-		SetSkip(filter.Offset).
-		SetSort(bson.D{
+	opts := options.Find().SetLimit(filter.Limit)
+	if filter.Cursor.Enabled {
+		query = filter.Cursor.apply(query)
+		opts.SetSort(cursorSort)
+	} else {
+		opts.SetSkip(filter.Offset).SetSort(bson.D{
 			{Key: "status", Value: 1},
 			{Key: "created_at", Value: -1},
 		})
+	}
 
 	cursor, err := r.coll.Find(ctx, query, opts)
 	if err != nil {
@@ -197,7 +274,7 @@ func (r *SiteRepo) FindByIDs(ctx context.Context, ids []string) ([]Site, error)
 		oids = append(oids, oid)
 	}
 
-	cursor, err := r.coll.Find(ctx, bson.M{"_id": bson.M{"$in": oids}})
+	cursor, err := r.coll.Find(ctx, notDeleted(bson.M{"_id": bson.M{"$in": oids}}))
 	if err != nil {
 		return nil, err
 	}
@@ -349,10 +426,10 @@ func (r *SiteRepo) FindDueForScan(ctx context.Context, limit int64) ([]Site, err
 		SetLimit(limit).
 		SetSort(bson.D{{Key: "next_scan_at", Value: 1}})
 
-	cursor, err := r.coll.Find(ctx, bson.M{
+	cursor, err := r.coll.Find(ctx, notDeleted(bson.M{
 		"status":       bson.M{"$in": status.ScannableSiteStatuses()},
 		"next_scan_at": bson.M{"$lte": now},
-	}, opts)
+	}), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -392,15 +469,69 @@ func (r *SiteRepo) MarkQueued(ctx context.Context, siteIDs []string) error {
 	return err
 }
 
-func (r *SiteRepo) MarkFrozen(ctx context.Context, siteID string, reason string) error {
+func (r *SiteRepo) MarkFrozen(ctx context.Context, siteID string, reason status.FreezeReason, details string) error {
 	now := time.Now()
 	return r.SafeUpdateStatusFromAny(ctx, siteID, status.SiteFrozen, bson.M{
-		"last_scan_at":  now,
-		"freeze_reason": reason,
+		"last_scan_at":   now,
+		"freeze_reason":  reason,
+		"freeze_details": details,
+		"frozen_at":      now,
+		"failure_count":  0,
+	})
+}
+
+// MarkParked помечает сайт мёртвым сразу, без повторных попыток: домен просрочен и показывает парковку
+func (r *SiteRepo) MarkParked(ctx context.Context, siteID, signature string) error {
+	return r.SafeUpdateStatusFromAny(ctx, siteID, status.SiteDead, bson.M{
+		"last_scan_at":  time.Now(),
+		"dead_reason":   status.DeadParked,
+		"dead_details":  signature,
 		"failure_count": 0,
 	})
 }
 
+// MigrateFreezeReasons переводит старые свободные причины заморозки в status.FreezeReason;
+// исходный текст сохраняется в freeze_details. Повторный запуск ничего не меняет
+func (r *SiteRepo) MigrateFreezeReasons(ctx context.Context) (int64, error) {
+	known := bson.A{""}
+	for _, reason := range status.AllFreezeReasons() {
+		known = append(known, reason)
+	}
+
+	opts := options.Find().SetProjection(bson.M{"freeze_reason": 1, "freeze_details": 1})
+	cursor, err := r.coll.Find(ctx, bson.M{"freeze_reason": bson.M{"$exists": true, "$nin": known}}, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var migrated int64
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID            primitive.ObjectID `bson:"_id"`
+			FreezeReason  string             `bson:"freeze_reason"`
+			FreezeDetails string             `bson:"freeze_details"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return migrated, err
+		}
+
+		details := doc.FreezeDetails
+		if details == "" {
+			details = doc.FreezeReason
+		}
+		_, err := r.coll.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$set": bson.M{
+			"freeze_reason":  FreezeReasonFromText(doc.FreezeReason),
+			"freeze_details": details,
+		}})
+		if err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, cursor.Err()
+}
+
 func (r *SiteRepo) MarkAsMoved(ctx context.Context, siteID, movedToDomain string) error {
 	now := time.Now()
 	return r.SafeUpdateStatusFromAny(ctx, siteID, status.SiteMoved, bson.M{
@@ -412,9 +543,10 @@ func (r *SiteRepo) MarkAsMoved(ctx context.Context, siteID, movedToDomain string
 func (r *SiteRepo) Unfreeze(ctx context.Context, siteID string, scannerType status.ScannerType) error {
 	now := time.Now()
 	updates := bson.M{
-		"freeze_reason": "",
-		"next_scan_at":  now,
-		"failure_count": 0,
+		"freeze_reason":  "",
+		"freeze_details": "",
+		"next_scan_at":   now,
+		"failure_count":  0,
 	}
 	if scannerType != "" {
 		updates["scanner_type"] = scannerType
@@ -423,15 +555,42 @@ func (r *SiteRepo) Unfreeze(ctx context.Context, siteID string, scannerType stat
 	return r.SafeUpdateStatus(ctx, siteID, status.SiteFrozen, status.SiteActive, updates)
 }
 
+// FindIDsByStatus возвращает ID сайтов в статусе st; siteIDs == nil — среди всех сайтов
+func (r *SiteRepo) FindIDsByStatus(ctx context.Context, st status.Site, siteIDs []string) ([]string, error) {
+	filter := notDeleted(bson.M{"status": st})
+	if siteIDs != nil {
+		oids := make([]primitive.ObjectID, 0, len(siteIDs))
+		for _, id := range siteIDs {
+			if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+				oids = append(oids, oid)
+			}
+		}
+		filter["_id"] = bson.M{"$in": oids}
+	}
+
+	raw, err := r.coll.Distinct(ctx, "_id", filter)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if oid, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, oid.Hex())
+		}
+	}
+	return ids, nil
+}
+
 type DetectionUpdate struct {
-	CMS           string
-	HasSitemap    bool
-	SitemapStatus status.SitemapStatus
-	CrawlStrategy status.CrawlStrategy
-	SitemapURLs   []string
-	ScannerType   status.ScannerType
-	CaptchaType   string
-	Cookies       []Cookie
+	CMS           string               `json:"cms"`
+	HasSitemap    bool                 `json:"has_sitemap"`
+	SitemapStatus status.SitemapStatus `json:"sitemap_status"`
+	CrawlStrategy status.CrawlStrategy `json:"crawl_strategy"`
+	SitemapURLs   []string             `json:"sitemap_urls,omitempty"`
+	SitemapFormat string               `json:"sitemap_format,omitempty"`
+	ScannerType   status.ScannerType   `json:"scanner_type"`
+	CaptchaType   string               `json:"captcha_type,omitempty"`
+	Cookies       []Cookie             `json:"cookies,omitempty"`
 }
 
 func (r *SiteRepo) UpdateFromDetection(ctx context.Context, siteID string, update DetectionUpdate) error {
@@ -446,6 +605,9 @@ func (r *SiteRepo) UpdateFromDetection(ctx context.Context, siteID string, updat
 	if len(update.SitemapURLs) > 0 {
 		setUpdate["sitemap_urls"] = update.SitemapURLs
 	}
+	if update.SitemapFormat != "" {
+		setUpdate["sitemap_format"] = update.SitemapFormat
+	}
 	if update.ScannerType != "" {
 		setUpdate["scanner_type"] = update.ScannerType
 	}
@@ -552,6 +714,168 @@ func (r *SiteRepo) UpdateCookies(ctx context.Context, siteID string, cookies []C
 	return err
 }
 
+// UpdatePageWait задаёт стратегию ожидания загрузки; nil сбрасывает на стратегию парсера по умолчанию
+func (r *SiteRepo) UpdatePageWait(ctx context.Context, siteID string, wait *PageWait) error {
+	oid, err := primitive.ObjectIDFromHex(siteID)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$unset": bson.M{"page_wait": ""}}
+	if wait != nil {
+		update = bson.M{"$set": bson.M{"page_wait": wait}}
+	}
+	update["$inc"] = bson.M{"version": 1}
+
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	return err
+}
+
+// UpdateDNSOverride задаёт переопределение DNS; nil сбрасывает на системный резолвер
+func (r *SiteRepo) UpdateDNSOverride(ctx context.Context, siteID string, dns *DNSOverride) error {
+	oid, err := primitive.ObjectIDFromHex(siteID)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$unset": bson.M{"dns_override": ""}}
+	if dns != nil {
+		update = bson.M{"$set": bson.M{"dns_override": dns}}
+	}
+	update["$inc"] = bson.M{"version": 1}
+
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	return err
+}
+
+// UpdateScanWindow задаёт часы сканирования; nil снимает ограничение
+func (r *SiteRepo) UpdateScanWindow(ctx context.Context, siteID string, window *ScanWindow) error {
+	oid, err := primitive.ObjectIDFromHex(siteID)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$unset": bson.M{"scan_window": ""}}
+	if window != nil {
+		update = bson.M{"$set": bson.M{"scan_window": window}}
+	}
+	update["$inc"] = bson.M{"version": 1}
+
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	return err
+}
+
+// DeferScan переносит плановый скан сайта на указанное время
+func (r *SiteRepo) DeferScan(ctx context.Context, siteID string, until time.Time) error {
+	oid, err := primitive.ObjectIDFromHex(siteID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{
+		"$set": bson.M{"next_scan_at": until},
+		"$inc": bson.M{"version": 1},
+	})
+	return err
+}
+
+// SwitchToSPA переводит сайт с HTTP-сканера на SPA и ставит его в очередь на немедленный
+// повторный скан; false — сайт уже на SPA-сканере
+func (r *SiteRepo) SwitchToSPA(ctx context.Context, siteID string) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(siteID)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": oid, "scanner_type": bson.M{"$ne": status.ScannerSPA}},
+		bson.M{
+			"$set": bson.M{
+				"scanner_type":  status.ScannerSPA,
+				"next_scan_at":  time.Now(),
+				"failure_count": 0,
+			},
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// UpdateDeltaCrawl включает обход только изменившихся по lastmod страниц
+func (r *SiteRepo) UpdateDeltaCrawl(ctx context.Context, siteID string, enabled bool) error {
+	oid, err := primitive.ObjectIDFromHex(siteID)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$unset": bson.M{"delta_crawl": ""}}
+	if enabled {
+		update = bson.M{"$set": bson.M{"delta_crawl": true}}
+	}
+	update["$inc"] = bson.M{"version": 1}
+
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	return err
+}
+
+// UpdateMaxPagesPerScan задаёт лимит страниц за один обход; 0 снимает ограничение
+func (r *SiteRepo) UpdateMaxPagesPerScan(ctx context.Context, siteID string, maxPages int) error {
+	oid, err := primitive.ObjectIDFromHex(siteID)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$unset": bson.M{"max_pages_per_scan": ""}}
+	if maxPages > 0 {
+		update = bson.M{"$set": bson.M{"max_pages_per_scan": maxPages}}
+	}
+	update["$inc"] = bson.M{"version": 1}
+
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	return err
+}
+
+// UpdateScanInterval меняет интервал сканирования сайтов; у активных сайтов, уже
+// сканировавшихся хотя бы раз, next_scan_at пересчитывается от last_scan_at
+func (r *SiteRepo) UpdateScanInterval(ctx context.Context, siteIDs []string, hours int) (int64, error) {
+	var oids []primitive.ObjectID
+	for _, id := range siteIDs {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			continue
+		}
+		oids = append(oids, oid)
+	}
+	if len(oids) == 0 {
+		return 0, nil
+	}
+
+	interval := time.Duration(hours) * time.Hour
+	res, err := r.coll.UpdateMany(ctx, notDeleted(bson.M{"_id": bson.M{"$in": oids}}), bson.A{
+		bson.M{"$set": bson.M{
+			"scan_interval_h": hours,
+			"next_scan_at": bson.M{
+				"$cond": bson.A{
+					bson.M{"$and": bson.A{
+						bson.M{"$eq": bson.A{"$status", status.SiteActive}},
+						bson.M{"$eq": bson.A{bson.M{"$type": "$last_scan_at"}, "date"}},
+					}},
+					bson.M{"$add": bson.A{"$last_scan_at", interval.Milliseconds()}},
+					"$next_scan_at",
+				},
+			},
+			"version": bson.M{"$add": bson.A{"$version", 1}},
+		}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.MatchedCount, nil
+}
+
 func (r *SiteRepo) GetCookies(ctx context.Context, siteID string) ([]Cookie, error) {
 	site, err := r.FindByID(ctx, siteID)
 	if err != nil {
@@ -563,6 +887,85 @@ func (r *SiteRepo) GetCookies(ctx context.Context, siteID string) ([]Cookie, err
 	return site.Cookies, nil
 }
 
+// SoftDelete переносит сайт в корзину; false — сайта нет или он уже удалён
+func (r *SiteRepo) SoftDelete(ctx context.Context, id, userID string) (bool, error) {
+	return moveToTrash(ctx, r.coll, id, userID)
+}
+
+// Restore возвращает сайт из корзины; false — в корзине его нет
+func (r *SiteRepo) Restore(ctx context.Context, id string) (bool, error) {
+	return restoreFromTrash(ctx, r.coll, id)
+}
+
+// FindDeletedByID возвращает сайт из корзины
+func (r *SiteRepo) FindDeletedByID(ctx context.Context, id string) (*Site, error) {
+	var site Site
+	found, err := findInTrash(ctx, r.coll, id, &site)
+	if !found {
+		return nil, err
+	}
+	return &site, nil
+}
+
+// FindExpiredDeleted — id сайтов, пролежавших в корзине дольше срока хранения
+func (r *SiteRepo) FindExpiredDeleted(ctx context.Context, cutoff time.Time) ([]string, error) {
+	return expiredTrashIDs(ctx, r.coll, cutoff)
+}
+
+// ListDomains — все сайты вне корзины с доменом, CMS и статусом, без тяжёлых полей
+func (r *SiteRepo) ListDomains(ctx context.Context) ([]Site, error) {
+	opts := options.Find().SetProjection(bson.M{"domain": 1, "cms": 1, "status": 1})
+	cursor, err := r.coll.Find(ctx, notDeleted(bson.M{}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sites []Site
+	if err := cursor.All(ctx, &sites); err != nil {
+		return nil, err
+	}
+	return sites, nil
+}
+
+// ActiveIDs — id всех сайтов вне корзины; страницы остальных сайтов в Meilisearch — сироты
+func (r *SiteRepo) ActiveIDs(ctx context.Context) ([]string, error) {
+	ids, err := r.coll.Distinct(ctx, "_id", notDeleted(bson.M{}))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			result = append(result, oid.Hex())
+		}
+	}
+	return result, nil
+}
+
+// ActiveDomains возвращает домены сайтов вне корзины по ID сайта
+func (r *SiteRepo) ActiveDomains(ctx context.Context) (map[string]string, error) {
+	cursor, err := r.coll.Find(ctx, notDeleted(bson.M{}), options.Find().SetProjection(bson.M{"domain": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	domains := make(map[string]string)
+	for cursor.Next(ctx) {
+		var site struct {
+			ID     primitive.ObjectID `bson:"_id"`
+			Domain string             `bson:"domain"`
+		}
+		if err := cursor.Decode(&site); err != nil {
+			return nil, err
+		}
+		domains[site.ID.Hex()] = site.Domain
+	}
+	return domains, cursor.Err()
+}
+
 func (r *SiteRepo) Delete(ctx context.Context, id string) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -574,8 +977,11 @@ func (r *SiteRepo) Delete(ctx context.Context, id string) error {
 
 func (r *SiteRepo) ResetToPending(ctx context.Context, siteID string) error {
 	return r.SafeUpdateStatusFromAny(ctx, siteID, status.SitePending, bson.M{
-		"failure_count": 0,
-		"freeze_reason": "",
+		"failure_count":  0,
+		"freeze_reason":  "",
+		"freeze_details": "",
+		"dead_reason":    "",
+		"dead_details":   "",
 	})
 }
 
@@ -602,10 +1008,10 @@ func (r *SiteRepo) FindPendingSites(ctx context.Context, olderThan time.Duration
 		SetLimit(limit).
 		SetSort(bson.D{{Key: "created_at", Value: 1}})
 
-	cursor, err := r.coll.Find(ctx, bson.M{
+	cursor, err := r.coll.Find(ctx, notDeleted(bson.M{
 		"status":     status.SitePending,
 		"created_at": bson.M{"$lte": threshold},
-	}, opts)
+	}), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -618,6 +1024,24 @@ func (r *SiteRepo) FindPendingSites(ctx context.Context, olderThan time.Duration
 	return sites, nil
 }
 
+// neverScannedMatch — сайты без единой завершённой задачи сканирования.
+// last_scan_at для этого не подходит: он выставляется и при неудачном скане, и при заморозке
+func (r *SiteRepo) neverScannedMatch(ctx context.Context) (bson.M, error) {
+	ids, err := r.coll.Database().Collection(scanTasksCollection).Distinct(ctx, "site_id", bson.M{"status": status.TaskCompleted})
+	if err != nil {
+		return nil, err
+	}
+
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		hex, _ := id.(string)
+		if oid, err := primitive.ObjectIDFromHex(hex); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	return bson.M{"_id": bson.M{"$nin": oids}}, nil
+}
+
 // FindByUserAccess returns sites the user has access to using aggregation
 // Efficient even with millions of user_sites records - filtering happens in MongoDB
 func (r *SiteRepo) FindByUserAccess(ctx context.Context, userID string, isAdmin bool, filter SiteFilter) ([]Site, int64, error) {
@@ -631,10 +1055,16 @@ func (r *SiteRepo) FindByUserAccess(ctx context.Context, userID string, isAdmin
 	}
 
 	// Build initial match stage
-	initialMatch := bson.M{}
+	initialMatch := notDeleted(bson.M{})
+	if re := domainSearchRegex(filter.Domain); re != nil {
+		initialMatch["domain"] = re
+	}
 	if filter.Status != "" {
 		initialMatch["status"] = filter.Status
 	}
+	if filter.FreezeReason != "" {
+		initialMatch["freeze_reason"] = filter.FreezeReason
+	}
 	if filter.ScannedSince != nil {
 		initialMatch["last_scan_at"] = bson.M{"$gte": *filter.ScannedSince}
 	}
@@ -656,6 +1086,13 @@ func (r *SiteRepo) FindByUserAccess(ctx context.Context, userID string, isAdmin
 		}
 		initialMatch["_id"] = bson.M{"$nin": oids}
 	}
+	if filter.NeverScanned {
+		match, err := r.neverScannedMatch(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		initialMatch["$and"] = bson.A{match}
+	}
 
 	// Pipeline: join with user_sites to check shared access
 	pipeline := mongo.Pipeline{}
@@ -694,33 +1131,30 @@ func (r *SiteRepo) FindByUserAccess(ctx context.Context, userID string, isAdmin
 	)
 
 	// Count total
-	countPipeline := append(pipeline, bson.D{{Key: "$count", Value: "total"}})
-	countCursor, err := r.coll.Aggregate(ctx, countPipeline)
+	total, err := aggregateCount(ctx, r.coll, pipeline, filter.SkipCount)
 	if err != nil {
 		return nil, 0, err
 	}
-	var countResult []struct {
-		Total int64 `bson:"total"`
-	}
-	if err := countCursor.All(ctx, &countResult); err != nil {
-		return nil, 0, err
-	}
-	countCursor.Close(ctx)
-
-	var total int64
-	if len(countResult) > 0 {
-		total = countResult[0].Total
-	}
 
 	// Add sort, skip, limit
-	pipeline = append(pipeline,
-		bson.D{{Key: "$sort", Value: bson.D{
-			{Key: "status", Value: 1},
-			{Key: "created_at", Value: -1},
-		}}},
-		bson.D{{Key: "$skip", Value: filter.Offset}},
-		bson.D{{Key: "$limit", Value: filter.Limit}},
-	)
+	if filter.Cursor.Enabled {
+		if filter.Cursor.After != nil {
+			pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter.Cursor.After.match()}})
+		}
+		pipeline = append(pipeline,
+			bson.D{{Key: "$sort", Value: cursorSort}},
+			bson.D{{Key: "$limit", Value: filter.Limit}},
+		)
+	} else {
+		pipeline = append(pipeline,
+			bson.D{{Key: "$sort", Value: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: -1},
+			}}},
+			bson.D{{Key: "$skip", Value: filter.Offset}},
+			bson.D{{Key: "$limit", Value: filter.Limit}},
+		)
+	}
 
 	cursor, err := r.coll.Aggregate(ctx, pipeline)
 	if err != nil {
@@ -749,6 +1183,15 @@ func (r *SiteRepo) HasUserAccess(ctx context.Context, siteID, userID string, isA
 		return false, nil
 	}
 
+	return r.HasUserAccessToSite(ctx, site, userID, isAdmin, userSiteRepo)
+}
+
+// HasUserAccessToSite — проверка доступа к уже загруженному сайту, в том числе из корзины
+func (r *SiteRepo) HasUserAccessToSite(ctx context.Context, site *Site, userID string, isAdmin bool, userSiteRepo *UserSiteRepo) (bool, error) {
+	if isAdmin {
+		return true, nil
+	}
+
 	userOID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return false, err
@@ -758,7 +1201,7 @@ func (r *SiteRepo) HasUserAccess(ctx context.Context, siteID, userID string, isA
 		return true, nil
 	}
 
-	exists, err := userSiteRepo.ExistsByUserAndSite(ctx, userID, siteID)
+	exists, err := userSiteRepo.ExistsByUserAndSite(ctx, userID, site.ID.Hex())
 	if err != nil {
 		return false, err
 	}
@@ -772,43 +1215,105 @@ func (r *SiteRepo) GetAccessibleSiteIDs(ctx context.Context, userID string, user
 		return nil, err
 	}
 
-	// Get sites owned by the user
-	cursor, err := r.coll.Find(ctx, bson.M{"owner_id": userOID}, options.Find().SetProjection(bson.M{"_id": 1}))
+	// Sites owned by the user or shared with them via user_sites;
+	// owned sites are returned even if the shared query fails
+	access := bson.A{bson.M{"owner_id": userOID}}
+	sharedSiteIDs, err := userSiteRepo.GetSiteIDsForUser(ctx, userID)
+	if err == nil && len(sharedSiteIDs) > 0 {
+		access = append(access, bson.M{"_id": bson.M{"$in": sharedSiteIDs}})
+	}
+
+	// Сайты из корзины недоступны, даже если связь с пользователем ещё не удалена
+	cursor, err := r.coll.Find(ctx, notDeleted(bson.M{"$or": access}), options.Find().SetProjection(bson.M{"_id": 1}))
 	if err != nil {
 		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	var siteIDs []string
+	result := []string{}
 	for cursor.Next(ctx) {
-		var result struct {
+		var site struct {
 			ID primitive.ObjectID `bson:"_id"`
 		}
-		if err := cursor.Decode(&result); err != nil {
+		if err := cursor.Decode(&site); err != nil {
 			continue
 		}
-		siteIDs = append(siteIDs, result.ID.Hex())
+		result = append(result, site.ID.Hex())
 	}
-	cursor.Close(ctx)
+	return result, nil
+}
 
-	// Get sites shared with the user via user_sites
-	sharedSiteIDs, err := userSiteRepo.GetSiteIDsByUserID(ctx, userID)
-	if err != nil {
-		return siteIDs, nil // Return owned sites even if shared query fails
+// SiteStatusCounts — число сайтов в каждом статусе
+type SiteStatusCounts struct {
+	Total   int64 `json:"total"`
+	Active  int64 `json:"active"`
+	Pending int64 `json:"pending"`
+	Down    int64 `json:"down"`
+	Frozen  int64 `json:"frozen"`
+	Moved   int64 `json:"moved"`
+	Dead    int64 `json:"dead"`
+}
+
+type siteStatusGroup struct {
+	Status status.Site `bson:"_id"`
+	Count  int64       `bson:"count"`
+}
+
+// CountByStatus считает сайты по статусам; siteIDs == nil — все сайты, пустой срез — ни одного
+func (r *SiteRepo) CountByStatus(ctx context.Context, siteIDs []string) (*SiteStatusCounts, error) {
+	if siteIDs != nil && len(siteIDs) == 0 {
+		return &SiteStatusCounts{}, nil
 	}
 
-	// Merge and deduplicate
-	siteIDSet := make(map[string]bool)
-	for _, id := range siteIDs {
-		siteIDSet[id] = true
+	cursor, err := r.coll.Aggregate(ctx, siteStatusPipeline(siteIDs))
+	if err != nil {
+		return nil, err
 	}
-	for _, id := range sharedSiteIDs {
-		siteIDSet[id] = true
+	defer cursor.Close(ctx)
+
+	var groups []siteStatusGroup
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
 	}
+	return foldSiteStatusCounts(groups), nil
+}
 
-	result := make([]string, 0, len(siteIDSet))
-	for id := range siteIDSet {
-		result = append(result, id)
+func siteStatusPipeline(siteIDs []string) mongo.Pipeline {
+	match := notDeleted(bson.M{})
+	if siteIDs != nil {
+		oids := make([]primitive.ObjectID, 0, len(siteIDs))
+		for _, id := range siteIDs {
+			if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+				oids = append(oids, oid)
+			}
+		}
+		match["_id"] = bson.M{"$in": oids}
 	}
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	}
+}
 
-	return result, nil
+// foldSiteStatusCounts раскладывает группы агрегации по полям; неизвестные статусы входят только в Total
+func foldSiteStatusCounts(groups []siteStatusGroup) *SiteStatusCounts {
+	counts := &SiteStatusCounts{}
+	for _, g := range groups {
+		counts.Total += g.Count
+		switch g.Status {
+		case status.SiteActive:
+			counts.Active += g.Count
+		case status.SitePending:
+			counts.Pending += g.Count
+		case status.SiteDown:
+			counts.Down += g.Count
+		case status.SiteFrozen:
+			counts.Frozen += g.Count
+		case status.SiteMoved:
+			counts.Moved += g.Count
+		case status.SiteDead:
+			counts.Dead += g.Count
+		}
+	}
+	return counts
 }
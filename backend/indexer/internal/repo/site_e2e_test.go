@@ -0,0 +1,185 @@
+//go:build e2e
+// +build e2e
+
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/status"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUpdateScanInterval_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db := setupTestDB(t, ctx)
+	siteRepo := NewSiteRepo(db)
+
+	lastScan := time.Now().Add(-2 * time.Hour).Truncate(time.Millisecond)
+	oldNext := lastScan.Add(24 * time.Hour)
+
+	active := &Site{Domain: "active.example"}
+	frozen := &Site{Domain: "frozen.example"}
+	fresh := &Site{Domain: "fresh.example"}
+	for _, s := range []*Site{active, frozen, fresh} {
+		if err := siteRepo.Create(ctx, s); err != nil {
+			t.Fatalf("create %s: %v", s.Domain, err)
+		}
+	}
+	for _, s := range []*Site{active, frozen} {
+		st := status.SiteActive
+		if s == frozen {
+			st = status.SiteFrozen
+		}
+		if _, err := siteRepo.coll.UpdateOne(ctx, bson.M{"_id": s.ID}, bson.M{"$set": bson.M{
+			"status":       st,
+			"last_scan_at": lastScan,
+			"next_scan_at": oldNext,
+		}}); err != nil {
+			t.Fatalf("prepare %s: %v", s.Domain, err)
+		}
+	}
+
+	ids := []string{active.ID.Hex(), frozen.ID.Hex(), fresh.ID.Hex(), "not-an-id"}
+	updated, err := siteRepo.UpdateScanInterval(ctx, ids, 6)
+	if err != nil {
+		t.Fatalf("UpdateScanInterval: %v", err)
+	}
+	if updated != 3 {
+		t.Errorf("updated = %d, want 3", updated)
+	}
+
+	got, _ := siteRepo.FindByID(ctx, active.ID.Hex())
+	if got.ScanIntervalH != 6 {
+		t.Errorf("active interval = %d, want 6", got.ScanIntervalH)
+	}
+	if want := lastScan.Add(6 * time.Hour); got.NextScanAt == nil || !got.NextScanAt.Equal(want) {
+		t.Errorf("active next_scan_at = %v, want %v", got.NextScanAt, want)
+	}
+
+	// Неактивный сайт получает интервал, но расписание не трогается
+	got, _ = siteRepo.FindByID(ctx, frozen.ID.Hex())
+	if got.ScanIntervalH != 6 || got.NextScanAt == nil || !got.NextScanAt.Equal(oldNext) {
+		t.Errorf("frozen site = interval %d, next %v", got.ScanIntervalH, got.NextScanAt)
+	}
+
+	// Без last_scan_at next_scan_at остаётся прежним
+	got, _ = siteRepo.FindByID(ctx, fresh.ID.Hex())
+	if got.ScanIntervalH != 6 || got.NextScanAt != nil {
+		t.Errorf("fresh site = interval %d, next %v", got.ScanIntervalH, got.NextScanAt)
+	}
+}
+
+func TestCountByStatus_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db := setupTestDB(t, ctx)
+	siteRepo := NewSiteRepo(db)
+
+	statuses := map[string]status.Site{
+		"a1.example": status.SiteActive,
+		"a2.example": status.SiteActive,
+		"f.example":  status.SiteFrozen,
+		"m.example":  status.SiteMoved,
+		"d.example":  status.SiteDead,
+		"p.example":  status.SitePending,
+	}
+	ids := make(map[string]string, len(statuses))
+	for domain, st := range statuses {
+		site := &Site{Domain: domain}
+		if err := siteRepo.Create(ctx, site); err != nil {
+			t.Fatalf("create %s: %v", domain, err)
+		}
+		if _, err := siteRepo.coll.UpdateOne(ctx, bson.M{"_id": site.ID}, bson.M{"$set": bson.M{"status": st}}); err != nil {
+			t.Fatalf("prepare %s: %v", domain, err)
+		}
+		ids[domain] = site.ID.Hex()
+	}
+	// Сайты в корзине не считаются
+	if _, err := siteRepo.SoftDelete(ctx, ids["d.example"], ""); err != nil {
+		t.Fatalf("soft delete: %v", err)
+	}
+
+	all, err := siteRepo.CountByStatus(ctx, nil)
+	if err != nil {
+		t.Fatalf("CountByStatus(nil): %v", err)
+	}
+	want := SiteStatusCounts{Total: 5, Active: 2, Pending: 1, Frozen: 1, Moved: 1}
+	if *all != want {
+		t.Errorf("CountByStatus(nil) = %+v, want %+v", *all, want)
+	}
+
+	scoped, err := siteRepo.CountByStatus(ctx, []string{ids["a1.example"], ids["f.example"]})
+	if err != nil {
+		t.Fatalf("CountByStatus(scoped): %v", err)
+	}
+	want = SiteStatusCounts{Total: 2, Active: 1, Frozen: 1}
+	if *scoped != want {
+		t.Errorf("CountByStatus(scoped) = %+v, want %+v", *scoped, want)
+	}
+
+	none, err := siteRepo.CountByStatus(ctx, []string{})
+	if err != nil {
+		t.Fatalf("CountByStatus(empty): %v", err)
+	}
+	if *none != (SiteStatusCounts{}) {
+		t.Errorf("CountByStatus(empty) = %+v, want zero", *none)
+	}
+}
+
+func TestFindIDsByStatus_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db := setupTestDB(t, ctx)
+	siteRepo := NewSiteRepo(db)
+
+	ids := make(map[string]string)
+	for domain, st := range map[string]status.Site{
+		"frozen-1.example": status.SiteFrozen,
+		"frozen-2.example": status.SiteFrozen,
+		"trash.example":    status.SiteFrozen,
+		"active.example":   status.SiteActive,
+	} {
+		site := &Site{Domain: domain}
+		if err := siteRepo.Create(ctx, site); err != nil {
+			t.Fatalf("create %s: %v", domain, err)
+		}
+		if _, err := siteRepo.coll.UpdateOne(ctx, bson.M{"_id": site.ID}, bson.M{"$set": bson.M{"status": st}}); err != nil {
+			t.Fatalf("prepare %s: %v", domain, err)
+		}
+		ids[domain] = site.ID.Hex()
+	}
+	if _, err := siteRepo.SoftDelete(ctx, ids["trash.example"], ""); err != nil {
+		t.Fatalf("soft delete: %v", err)
+	}
+
+	all, err := siteRepo.FindIDsByStatus(ctx, status.SiteFrozen, nil)
+	if err != nil {
+		t.Fatalf("FindIDsByStatus(nil): %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("FindIDsByStatus(nil) = %v, want 2 frozen sites outside trash", all)
+	}
+
+	scoped, err := siteRepo.FindIDsByStatus(ctx, status.SiteFrozen, []string{ids["frozen-1.example"], ids["active.example"]})
+	if err != nil {
+		t.Fatalf("FindIDsByStatus(scoped): %v", err)
+	}
+	if len(scoped) != 1 || scoped[0] != ids["frozen-1.example"] {
+		t.Errorf("FindIDsByStatus(scoped) = %v, want [%s]", scoped, ids["frozen-1.example"])
+	}
+
+	none, err := siteRepo.FindIDsByStatus(ctx, status.SiteFrozen, []string{})
+	if err != nil {
+		t.Fatalf("FindIDsByStatus(empty): %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("FindIDsByStatus(empty) = %v, want none", none)
+	}
+}
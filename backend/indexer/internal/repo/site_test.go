@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/video-analitics/backend/pkg/status"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFoldSiteStatusCounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []siteStatusGroup
+		want   SiteStatusCounts
+	}{
+		{"empty", nil, SiteStatusCounts{}},
+		{
+			name: "all statuses",
+			groups: []siteStatusGroup{
+				{status.SiteActive, 5},
+				{status.SitePending, 1},
+				{status.SiteDown, 2},
+				{status.SiteFrozen, 3},
+				{status.SiteMoved, 4},
+				{status.SiteDead, 6},
+			},
+			want: SiteStatusCounts{Total: 21, Active: 5, Pending: 1, Down: 2, Frozen: 3, Moved: 4, Dead: 6},
+		},
+		{
+			name:   "unknown status counted in total only",
+			groups: []siteStatusGroup{{status.SiteActive, 2}, {"", 1}},
+			want:   SiteStatusCounts{Total: 3, Active: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := foldSiteStatusCounts(tt.groups); *got != tt.want {
+				t.Errorf("foldSiteStatusCounts() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSiteStatusPipeline(t *testing.T) {
+	oid := primitive.NewObjectID()
+
+	tests := []struct {
+		name    string
+		siteIDs []string
+		want    bson.M
+	}{
+		{"all sites", nil, bson.M{"deleted_at": nil}},
+		{
+			name:    "scoped, invalid ids dropped",
+			siteIDs: []string{oid.Hex(), "not-an-id"},
+			want:    bson.M{"deleted_at": nil, "_id": bson.M{"$in": []primitive.ObjectID{oid}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline := siteStatusPipeline(tt.siteIDs)
+			if len(pipeline) != 2 {
+				t.Fatalf("pipeline has %d stages, want 2", len(pipeline))
+			}
+			if got := pipeline[0][0].Value; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("$match = %v, want %v", got, tt.want)
+			}
+			wantGroup := bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}
+			if got := pipeline[1][0].Value; !reflect.DeepEqual(got, wantGroup) {
+				t.Errorf("$group = %v, want %v", got, wantGroup)
+			}
+		})
+	}
+}
+
+func TestDomainSearchRegex(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"only wildcards", " ** ", ""},
+		{"substring", "kino", "kino"},
+		{"lowercased and trimmed", "  KinoGo ", "kinogo"},
+		{"dots escaped", "kino.net", `kino\.net`},
+		{"prefix", "kino*", `^kino.*$`},
+		{"suffix", "*.ru", `^.*\.ru$`},
+		{"inner wildcard", "film*.club", `^film.*\.club$`},
+		{"regex metacharacters escaped", "a+b(", `a\+b\(`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := domainSearchRegex(tt.query)
+			if tt.want == "" {
+				if got != nil {
+					t.Fatalf("domainSearchRegex(%q) = %v, want nil", tt.query, got)
+				}
+				return
+			}
+			if got == nil || got["$regex"] != tt.want || got["$options"] != "i" {
+				t.Errorf("domainSearchRegex(%q) = %v, want regex %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ type SitemapURL struct {
 	IsXML         bool               `bson:"is_xml" json:"is_xml"`
 	RetryCount    int                `bson:"retry_count" json:"retry_count"`
 	LastAttemptAt *time.Time         `bson:"last_attempt_at,omitempty" json:"last_attempt_at,omitempty"`
+	RetryAt       *time.Time         `bson:"next_retry_at,omitempty" json:"next_retry_at,omitempty"`
 	LockedUntil   *time.Time         `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
 
 	// Глубина: 0 = из sitemap/главная, 1-3 = найдены при парсинге страниц
@@ -54,7 +55,8 @@ type SitemapURLStats struct {
 }
 
 type SitemapURLRepo struct {
-	coll *mongo.Collection
+	coll  *mongo.Collection
+	retry SitemapRetryPolicy
 }
 
 func NewSitemapURLRepo(db *mongo.Database) *SitemapURLRepo {
@@ -82,10 +84,105 @@ func NewSitemapURLRepo(db *mongo.Database) *SitemapURLRepo {
 				{Key: "last_attempt_at", Value: 1},
 			},
 		},
+		{
+			Keys: bson.D{
+				{Key: "site_id", Value: 1},
+				{Key: "status", Value: 1},
+				{Key: "next_retry_at", Value: 1},
+			},
+		},
 	}
 	coll.Indexes().CreateMany(ctx, indexes)
 
-	return &SitemapURLRepo{coll: coll}
+	return &SitemapURLRepo{coll: coll, retry: DefaultSitemapRetryPolicy}
+}
+
+// SitemapRetryPolicy — повторы парсинга упавших URL. Задержка растёт вдвое с каждой
+// неудачей, после MaxAttempts неудач URL переходит в status=error и больше не выбирается
+type SitemapRetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultSitemapRetryPolicy = SitemapRetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   5 * time.Minute,
+	MaxDelay:    6 * time.Hour,
+}
+
+// SetRetryPolicy задаёт политику повторов; нулевые поля берутся из DefaultSitemapRetryPolicy
+func (r *SitemapURLRepo) SetRetryPolicy(p SitemapRetryPolicy) {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultSitemapRetryPolicy.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultSitemapRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+	r.retry = p
+}
+
+// Delay — пауза после attempts-й неудачи: base, 2·base, 4·base... но не больше MaxDelay
+func (p SitemapRetryPolicy) Delay(attempts int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempts && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// afterFailure — статус URL и время следующей попытки после attempts-й неудачи;
+// исчерпавший попытки URL становится окончательной ошибкой без повтора
+func (p SitemapRetryPolicy) afterFailure(attempts int, now time.Time) (status.URL, *time.Time) {
+	if attempts >= p.MaxAttempts {
+		return status.URLError, nil
+	}
+	next := now.Add(p.Delay(attempts))
+	return status.URLPending, &next
+}
+
+// retryableFilter — pending URL сайта, у которых остались попытки и подошло время повтора
+func retryableFilter(siteID string, p SitemapRetryPolicy, now time.Time) bson.M {
+	return bson.M{
+		"site_id": siteID,
+		"status":  status.URLPending,
+		"$or": []bson.M{
+			{"retry_count": bson.M{"$exists": false}},
+			{"retry_count": bson.M{"$lt": p.MaxAttempts}},
+		},
+		"$and": []bson.M{
+			{"$or": []bson.M{
+				{"next_retry_at": nil},
+				{"next_retry_at": bson.M{"$lte": now}},
+			}},
+		},
+	}
+}
+
+// exhaustedFilter — pending URL сайта, исчерпавшие попытки: такие остаются после
+// снижения MaxAttempts или возврата зависших URL из processing
+func exhaustedFilter(siteID string, p SitemapRetryPolicy) bson.M {
+	return bson.M{
+		"site_id":     siteID,
+		"status":      status.URLPending,
+		"retry_count": bson.M{"$gte": p.MaxAttempts},
+	}
+}
+
+// failExhausted переводит исчерпавшие попытки pending URL в status=error,
+// иначе retryableFilter их не выбирает и они навсегда остаются в очереди
+func (r *SitemapURLRepo) failExhausted(ctx context.Context, siteID string) error {
+	_, err := r.coll.UpdateMany(ctx, exhaustedFilter(siteID, r.retry), bson.M{
+		"$set":   bson.M{"status": status.URLError},
+		"$unset": bson.M{"next_retry_at": "", "locked_until": ""},
+	})
+	return err
 }
 
 func (r *SitemapURLRepo) UpsertBatch(ctx context.Context, siteID string, sitemapSource string, urls []SitemapURLInput) (int, int, error) {
@@ -141,28 +238,13 @@ func (r *SitemapURLRepo) UpsertBatch(ctx context.Context, siteID string, sitemap
 	return inserted, updated, nil
 }
 
-const maxRetryCount = 5
-const retryDelay = 5 * time.Minute
-
 func (r *SitemapURLRepo) FindPending(ctx context.Context, siteID string, limit int) ([]SitemapURL, error) {
-	retryThreshold := time.Now().Add(-retryDelay)
-
-	filter := bson.M{
-		"site_id": siteID,
-		"status":  status.URLPending,
-		"$or": []bson.M{
-			{"retry_count": bson.M{"$exists": false}},
-			{"retry_count": bson.M{"$lt": maxRetryCount}},
-		},
-		"$and": []bson.M{
-			{"$or": []bson.M{
-				{"last_attempt_at": nil},
-				{"last_attempt_at": bson.M{"$exists": false}},
-				{"last_attempt_at": bson.M{"$lt": retryThreshold}},
-			}},
-		},
+	if err := r.failExhausted(ctx, siteID); err != nil {
+		return nil, err
 	}
 
+	filter := retryableFilter(siteID, r.retry, time.Now())
+
 	opts := options.Find().
 		SetSort(bson.D{{Key: "discovered_at", Value: 1}}).
 		SetLimit(int64(limit))
@@ -185,30 +267,19 @@ const lockDuration = 5 * time.Minute
 
 func (r *SitemapURLRepo) FindPendingAndLock(ctx context.Context, siteID string, limit int) ([]SitemapURL, error) {
 	now := time.Now()
-	retryThreshold := now.Add(-retryDelay)
 	lockUntil := now.Add(lockDuration)
 
-	filter := bson.M{
-		"site_id": siteID,
-		"status":  status.URLPending,
-		"$or": []bson.M{
-			{"retry_count": bson.M{"$exists": false}},
-			{"retry_count": bson.M{"$lt": maxRetryCount}},
-		},
-		"$and": []bson.M{
-			{"$or": []bson.M{
-				{"last_attempt_at": nil},
-				{"last_attempt_at": bson.M{"$exists": false}},
-				{"last_attempt_at": bson.M{"$lt": retryThreshold}},
-			}},
-			{"$or": []bson.M{
-				{"locked_until": nil},
-				{"locked_until": bson.M{"$exists": false}},
-				{"locked_until": bson.M{"$lt": now}},
-			}},
-		},
+	if err := r.failExhausted(ctx, siteID); err != nil {
+		return nil, err
 	}
 
+	filter := retryableFilter(siteID, r.retry, now)
+	filter["$and"] = append(filter["$and"].([]bson.M), bson.M{"$or": []bson.M{
+		{"locked_until": nil},
+		{"locked_until": bson.M{"$exists": false}},
+		{"locked_until": bson.M{"$lt": now}},
+	}})
+
 	opts := options.Find().
 		SetSort(bson.D{{Key: "discovered_at", Value: 1}}).
 		SetLimit(int64(limit))
@@ -266,25 +337,29 @@ func (r *SitemapURLRepo) MarkError(ctx context.Context, siteID, url, errMsg stri
 	now := time.Now()
 	filter := bson.M{"site_id": siteID, "url": url}
 
-	// Return to pending for retry, increment retry_count
-	_, err := r.coll.UpdateOne(ctx, filter, bson.M{
+	var u SitemapURL
+	err := r.coll.FindOneAndUpdate(ctx, filter, bson.M{
 		"$inc":   bson.M{"retry_count": 1},
-		"$set":   bson.M{"error": errMsg, "last_attempt_at": now, "status": status.URLPending},
+		"$set":   bson.M{"error": errMsg, "last_attempt_at": now},
 		"$unset": bson.M{"locked_until": ""},
-	})
+	}, options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"retry_count": 1}),
+	).Decode(&u)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	// If max retries exceeded, mark as error (terminal state)
-	_, err = r.coll.UpdateOne(ctx, bson.M{
-		"site_id":     siteID,
-		"url":         url,
-		"retry_count": bson.M{"$gte": maxRetryCount},
-	}, bson.M{
-		"$set": bson.M{"status": status.URLError},
-	})
-
+	// Назад в pending с отложенным повтором либо окончательная ошибка, если попытки исчерпаны
+	urlStatus, retryAt := r.retry.afterFailure(u.RetryCount, now)
+	update := bson.M{"$set": bson.M{"status": urlStatus, "next_retry_at": retryAt}}
+	if retryAt == nil {
+		update = bson.M{"$set": bson.M{"status": urlStatus}, "$unset": bson.M{"next_retry_at": ""}}
+	}
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": u.ID}, update)
 	return err
 }
 
@@ -361,6 +436,93 @@ func (r *SitemapURLRepo) FindByFilter(ctx context.Context, siteID string, urlSta
 	return urls, total, nil
 }
 
+// PageStatusFailed — все URL, последняя попытка парсинга которых упала:
+// и окончательно (status=error), и ожидающие повтора (pending/processing с ошибкой)
+const PageStatusFailed = "failed"
+
+// pageStatusFilter строит фильтр по статусу парсинга; пустой статус — все URL сайта
+func pageStatusFilter(siteID, pageStatus string) bson.M {
+	filter := bson.M{"site_id": siteID}
+	switch pageStatus {
+	case "":
+	case PageStatusFailed:
+		return failedURLFilter(siteID, "")
+	default:
+		filter["status"] = pageStatus
+	}
+	return filter
+}
+
+// Состояния упавших URL для FindFailed
+const (
+	FailedStateRetrying = "retrying" // ждёт повтора: pending/processing с ошибкой
+	FailedStateFinal    = "final"    // попытки исчерпаны, status=error
+)
+
+// failedURLFilter — URL, последняя попытка парсинга которых упала; state сужает выборку
+// до ожидающих повтора или окончательных, пустой — оба
+func failedURLFilter(siteID, state string) bson.M {
+	statuses := []status.URL{status.URLError, status.URLPending, status.URLProcessing}
+	switch state {
+	case FailedStateRetrying:
+		statuses = []status.URL{status.URLPending, status.URLProcessing}
+	case FailedStateFinal:
+		statuses = []status.URL{status.URLError}
+	}
+	return bson.M{
+		"site_id": siteID,
+		"error":   bson.M{"$exists": true, "$ne": ""},
+		"status":  bson.M{"$in": statuses},
+	}
+}
+
+// NextRetryAt — когда URL снова попадёт в выборку на парсинг; nil, если повтора не будет
+func (u *SitemapURL) NextRetryAt() *time.Time {
+	if u.Error == "" || u.Status == status.URLError || u.Status == status.URLSkipped || u.LastAttemptAt == nil {
+		return nil
+	}
+	if u.RetryAt != nil {
+		return u.RetryAt
+	}
+	// Записи до появления next_retry_at выбираются сразу
+	return u.LastAttemptAt
+}
+
+// FindPageStatuses возвращает URL сайта со статусом парсинга, свежие попытки первыми
+func (r *SitemapURLRepo) FindPageStatuses(ctx context.Context, siteID, pageStatus string, limit, offset int) ([]SitemapURL, int64, error) {
+	return r.findByLastAttempt(ctx, pageStatusFilter(siteID, pageStatus), limit, offset)
+}
+
+// FindFailed возвращает упавшие URL сайта с последней ошибкой, свежие попытки первыми
+func (r *SitemapURLRepo) FindFailed(ctx context.Context, siteID, state string, limit, offset int) ([]SitemapURL, int64, error) {
+	return r.findByLastAttempt(ctx, failedURLFilter(siteID, state), limit, offset)
+}
+
+func (r *SitemapURLRepo) findByLastAttempt(ctx context.Context, filter bson.M, limit, offset int) ([]SitemapURL, int64, error) {
+	total, err := r.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_attempt_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var urls []SitemapURL
+	if err := cursor.All(ctx, &urls); err != nil {
+		return nil, 0, err
+	}
+
+	return urls, total, nil
+}
+
 func (r *SitemapURLRepo) ExistsURL(ctx context.Context, siteID, url string) (bool, error) {
 	filter := bson.M{"site_id": siteID, "url": url}
 	count, err := r.coll.CountDocuments(ctx, filter, options.Count().SetLimit(1))
@@ -445,6 +607,7 @@ func (r *SitemapURLRepo) ResetErrorsToPending(ctx context.Context, siteID string
 			},
 			"$unset": bson.M{
 				"last_attempt_at": "",
+				"next_retry_at":   "",
 				"locked_until":    "",
 			},
 		},
@@ -467,6 +630,7 @@ func (r *SitemapURLRepo) ResetPendingRetryDelay(ctx context.Context, siteID stri
 			},
 			"$unset": bson.M{
 				"last_attempt_at": "",
+				"next_retry_at":   "",
 				"locked_until":    "",
 			},
 		},
@@ -478,24 +642,58 @@ func (r *SitemapURLRepo) ResetPendingRetryDelay(ctx context.Context, siteID stri
 }
 
 func (r *SitemapURLRepo) ResetAllToPending(ctx context.Context, siteID string) (int64, error) {
-	result, err := r.coll.UpdateMany(ctx,
-		bson.M{
-			"site_id": siteID,
-			"status":  bson.M{"$in": []status.URL{status.URLIndexed, status.URLError}},
+	return r.resetToPending(ctx, bson.M{
+		"site_id": siteID,
+		"status":  bson.M{"$in": []status.URL{status.URLIndexed, status.URLError}},
+	})
+}
+
+// ResetChangedToPending возвращает в очередь проиндексированные страницы, у которых
+// lastmod из карты сайта новее последней успешной индексации (delta-обход)
+func (r *SitemapURLRepo) ResetChangedToPending(ctx context.Context, siteID string) (int64, error) {
+	return r.resetToPending(ctx, changedURLFilter(siteID))
+}
+
+// ResetUncomparableToPending — принудительный delta-обход: ошибки и страницы без lastmod
+// обходятся заново целиком, остальные ждут сравнения lastmod после обхода карты сайта
+func (r *SitemapURLRepo) ResetUncomparableToPending(ctx context.Context, siteID string) (int64, error) {
+	return r.resetToPending(ctx, uncomparableURLFilter(siteID))
+}
+
+func changedURLFilter(siteID string) bson.M {
+	return bson.M{
+		"site_id":    siteID,
+		"status":     status.URLIndexed,
+		"lastmod":    bson.M{"$ne": nil},
+		"indexed_at": bson.M{"$ne": nil},
+		"$expr":      bson.M{"$gt": bson.A{"$lastmod", "$indexed_at"}},
+	}
+}
+
+func uncomparableURLFilter(siteID string) bson.M {
+	return bson.M{
+		"site_id": siteID,
+		"$or": []bson.M{
+			{"status": status.URLError},
+			{"status": status.URLIndexed, "lastmod": nil},
 		},
-		bson.M{
-			"$set": bson.M{
-				"status":      status.URLPending,
-				"error":       "",
-				"retry_count": 0,
-			},
-			"$unset": bson.M{
-				"indexed_at":      "",
-				"last_attempt_at": "",
-				"locked_until":    "",
-			},
+	}
+}
+
+func (r *SitemapURLRepo) resetToPending(ctx context.Context, filter bson.M) (int64, error) {
+	result, err := r.coll.UpdateMany(ctx, filter, bson.M{
+		"$set": bson.M{
+			"status":      status.URLPending,
+			"error":       "",
+			"retry_count": 0,
 		},
-	)
+		"$unset": bson.M{
+			"indexed_at":      "",
+			"last_attempt_at": "",
+			"next_retry_at":   "",
+			"locked_until":    "",
+		},
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -528,6 +726,29 @@ func (r *SitemapURLRepo) GetAllURLStrings(ctx context.Context, siteID string) ([
 	return urls, nil
 }
 
+// ForEachBySiteID проходит курсором по всем URL сайта, не загружая их в память целиком.
+// Остановка на первой ошибке fn
+func (r *SitemapURLRepo) ForEachBySiteID(ctx context.Context, siteID string, fn func(u *SitemapURL) error) error {
+	opts := options.Find().SetBatchSize(1000)
+
+	cursor, err := r.coll.Find(ctx, bson.M{"site_id": siteID}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var u SitemapURL
+		if err := cursor.Decode(&u); err != nil {
+			return err
+		}
+		if err := fn(&u); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
 func isXMLURL(url string) bool {
 	lowerURL := strings.ToLower(url)
 
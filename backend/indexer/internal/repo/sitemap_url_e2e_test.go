@@ -0,0 +1,194 @@
+//go:build e2e
+// +build e2e
+
+package repo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/status"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFindFailedPagination_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db := setupTestDB(t, ctx)
+	urlRepo := NewSitemapURLRepo(db)
+
+	// 7 окончательных ошибок, 5 ожидающих повтора и URL без ошибок, которые не должны попасть в выборку
+	base := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	var docs []interface{}
+	for i := 0; i < 15; i++ {
+		attempt := base.Add(time.Duration(i) * time.Minute)
+		u := SitemapURL{
+			SiteID:        "site-1",
+			URL:           fmt.Sprintf("https://kino.test/film/%02d", i),
+			Status:        status.URLIndexed,
+			DiscoveredAt:  base,
+			LastAttemptAt: &attempt,
+		}
+		switch {
+		case i < 7:
+			u.Status, u.Error, u.RetryCount = status.URLError, "http 500", DefaultSitemapRetryPolicy.MaxAttempts
+		case i < 12:
+			u.Status, u.Error, u.RetryCount = status.URLPending, "timeout", 1
+		}
+		docs = append(docs, u)
+	}
+	other := SitemapURL{SiteID: "site-2", URL: "https://other.test/", Status: status.URLError, Error: "http 500"}
+	if _, err := db.Collection(sitemapURLsCollection).InsertMany(ctx, append(docs, other)); err != nil {
+		t.Fatalf("insert urls: %v", err)
+	}
+
+	tests := []struct {
+		state     string
+		wantTotal int64
+	}{
+		{"", 12},
+		{FailedStateRetrying, 5},
+		{FailedStateFinal, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run("state="+tt.state, func(t *testing.T) {
+			seen := make(map[string]bool)
+			var prev *time.Time
+			for offset := 0; ; offset += 5 {
+				urls, total, err := urlRepo.FindFailed(ctx, "site-1", tt.state, 5, offset)
+				if err != nil {
+					t.Fatalf("FindFailed: %v", err)
+				}
+				if total != tt.wantTotal {
+					t.Fatalf("total = %d, want %d", total, tt.wantTotal)
+				}
+				if len(urls) == 0 {
+					break
+				}
+				for _, u := range urls {
+					if seen[u.URL] {
+						t.Fatalf("url %s returned twice", u.URL)
+					}
+					seen[u.URL] = true
+					if u.Error == "" {
+						t.Errorf("url %s has no error", u.URL)
+					}
+					if prev != nil && u.LastAttemptAt.After(*prev) {
+						t.Errorf("url %s is out of order", u.URL)
+					}
+					prev = u.LastAttemptAt
+				}
+			}
+			if int64(len(seen)) != tt.wantTotal {
+				t.Errorf("paged through %d urls, want %d", len(seen), tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestMarkErrorRetryPolicy_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db := setupTestDB(t, ctx)
+	urlRepo := NewSitemapURLRepo(db)
+	urlRepo.SetRetryPolicy(SitemapRetryPolicy{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour})
+
+	if _, _, err := urlRepo.UpsertBatch(ctx, "site-1", "sitemap.xml", []SitemapURLInput{{URL: "https://kino.test/film/1"}}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	find := func() SitemapURL {
+		var u SitemapURL
+		if err := db.Collection(sitemapURLsCollection).FindOne(ctx, bson.M{"site_id": "site-1"}).Decode(&u); err != nil {
+			t.Fatalf("find url: %v", err)
+		}
+		return u
+	}
+
+	// Первая неудача: попытка засчитана, повтор отложен и URL не выбирается
+	before := time.Now()
+	if err := urlRepo.MarkError(ctx, "site-1", "https://kino.test/film/1", "timeout"); err != nil {
+		t.Fatalf("MarkError: %v", err)
+	}
+	u := find()
+	if u.RetryCount != 1 || u.Status != status.URLPending || u.RetryAt == nil || u.RetryAt.Before(before.Add(time.Minute)) {
+		t.Fatalf("after first failure: %+v", u)
+	}
+	if pending, err := urlRepo.FindPending(ctx, "site-1", 10); err != nil || len(pending) != 0 {
+		t.Fatalf("FindPending before retry time = %d urls, err %v", len(pending), err)
+	}
+
+	// Вторая неудача исчерпывает попытки
+	if err := urlRepo.MarkError(ctx, "site-1", "https://kino.test/film/1", "timeout"); err != nil {
+		t.Fatalf("MarkError: %v", err)
+	}
+	u = find()
+	if u.RetryCount != 2 || u.Status != status.URLError || u.RetryAt != nil {
+		t.Fatalf("after last failure: %+v", u)
+	}
+
+	if _, err := urlRepo.ResetErrorsToPending(ctx, "site-1"); err != nil {
+		t.Fatalf("ResetErrorsToPending: %v", err)
+	}
+	if pending, err := urlRepo.FindPending(ctx, "site-1", 10); err != nil || len(pending) != 1 {
+		t.Fatalf("FindPending after reset = %d urls, err %v", len(pending), err)
+	}
+}
+
+func TestDeltaCrawlReset_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db := setupTestDB(t, ctx)
+	urlRepo := NewSitemapURLRepo(db)
+
+	indexed := time.Now().Add(-24 * time.Hour).Truncate(time.Millisecond)
+	older, newer := indexed.Add(-time.Hour), indexed.Add(time.Hour)
+	urls := map[string]SitemapURL{
+		"unchanged":  {Status: status.URLIndexed, LastMod: &older, IndexedAt: &indexed},
+		"same":       {Status: status.URLIndexed, LastMod: &indexed, IndexedAt: &indexed},
+		"changed":    {Status: status.URLIndexed, LastMod: &newer, IndexedAt: &indexed},
+		"no-lastmod": {Status: status.URLIndexed, IndexedAt: &indexed},
+		"error":      {Status: status.URLError, Error: "http 500", LastMod: &older},
+	}
+	var docs []interface{}
+	for name, u := range urls {
+		u.SiteID, u.URL = "site-1", "https://kino.test/"+name
+		docs = append(docs, u)
+	}
+	if _, err := db.Collection(sitemapURLsCollection).InsertMany(ctx, docs); err != nil {
+		t.Fatalf("insert urls: %v", err)
+	}
+
+	pending := func() map[string]bool {
+		got := make(map[string]bool)
+		urlRepo.ForEachBySiteID(ctx, "site-1", func(u *SitemapURL) error {
+			if u.Status == status.URLPending {
+				got[u.URL[len("https://kino.test/"):]] = true
+			}
+			return nil
+		})
+		return got
+	}
+
+	// Принудительный delta-обход: заново только то, что нельзя сравнить по lastmod
+	if n, err := urlRepo.ResetUncomparableToPending(ctx, "site-1"); err != nil || n != 2 {
+		t.Fatalf("ResetUncomparableToPending = %d, %v; want 2", n, err)
+	}
+	if got := pending(); len(got) != 2 || !got["no-lastmod"] || !got["error"] {
+		t.Fatalf("pending after forced delta = %v", got)
+	}
+
+	// После обхода карты сайта — только страницы с lastmod новее индексации
+	if n, err := urlRepo.ResetChangedToPending(ctx, "site-1"); err != nil || n != 1 {
+		t.Fatalf("ResetChangedToPending = %d, %v; want 1", n, err)
+	}
+	if got := pending(); len(got) != 3 || !got["changed"] || got["unchanged"] || got["same"] {
+		t.Fatalf("pending after delta = %v", got)
+	}
+}
@@ -0,0 +1,204 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/video-analitics/backend/pkg/status"
+)
+
+func TestPageStatusFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		want   bson.M
+	}{
+		{
+			name:   "all urls",
+			status: "",
+			want:   bson.M{"site_id": "s1"},
+		},
+		{
+			name:   "failed includes urls waiting for retry",
+			status: PageStatusFailed,
+			want: bson.M{
+				"site_id": "s1",
+				"error":   bson.M{"$exists": true, "$ne": ""},
+				"status":  bson.M{"$in": []status.URL{status.URLError, status.URLPending, status.URLProcessing}},
+			},
+		},
+		{
+			name:   "plain status",
+			status: string(status.URLIndexed),
+			want:   bson.M{"site_id": "s1", "status": "indexed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pageStatusFilter("s1", tt.status); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pageStatusFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailedURLFilter(t *testing.T) {
+	hasError := bson.M{"$exists": true, "$ne": ""}
+
+	tests := []struct {
+		state string
+		want  []status.URL
+	}{
+		{"", []status.URL{status.URLError, status.URLPending, status.URLProcessing}},
+		{FailedStateRetrying, []status.URL{status.URLPending, status.URLProcessing}},
+		{FailedStateFinal, []status.URL{status.URLError}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			want := bson.M{"site_id": "s1", "error": hasError, "status": bson.M{"$in": tt.want}}
+			if got := failedURLFilter("s1", tt.state); !reflect.DeepEqual(got, want) {
+				t.Errorf("failedURLFilter() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDeltaCrawlFilters(t *testing.T) {
+	changed := bson.M{
+		"site_id":    "s1",
+		"status":     status.URLIndexed,
+		"lastmod":    bson.M{"$ne": nil},
+		"indexed_at": bson.M{"$ne": nil},
+		"$expr":      bson.M{"$gt": bson.A{"$lastmod", "$indexed_at"}},
+	}
+	if got := changedURLFilter("s1"); !reflect.DeepEqual(got, changed) {
+		t.Errorf("changedURLFilter() = %v, want %v", got, changed)
+	}
+
+	// Без lastmod сравнивать не с чем — такие страницы обходятся целиком
+	uncomparable := bson.M{
+		"site_id": "s1",
+		"$or": []bson.M{
+			{"status": status.URLError},
+			{"status": status.URLIndexed, "lastmod": nil},
+		},
+	}
+	if got := uncomparableURLFilter("s1"); !reflect.DeepEqual(got, uncomparable) {
+		t.Errorf("uncomparableURLFilter() = %v, want %v", got, uncomparable)
+	}
+}
+
+func TestSitemapURLNextRetryAt(t *testing.T) {
+	attempt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		url  SitemapURL
+		want *time.Time
+	}{
+		{"retrying", SitemapURL{Status: status.URLPending, Error: "timeout", LastAttemptAt: &attempt, RetryAt: ptrTime(attempt.Add(20 * time.Minute))}, ptrTime(attempt.Add(20 * time.Minute))},
+		{"legacy without next_retry_at", SitemapURL{Status: status.URLPending, Error: "timeout", LastAttemptAt: &attempt}, &attempt},
+		{"final error", SitemapURL{Status: status.URLError, Error: "timeout", LastAttemptAt: &attempt}, nil},
+		{"no error", SitemapURL{Status: status.URLPending, LastAttemptAt: &attempt}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.url.NextRetryAt(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NextRetryAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSitemapRetryPolicyDelay(t *testing.T) {
+	p := SitemapRetryPolicy{MaxAttempts: 10, BaseDelay: 5 * time.Minute, MaxDelay: time.Hour}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Minute},
+		{2, 10 * time.Minute},
+		{3, 20 * time.Minute},
+		{4, 40 * time.Minute},
+		{5, time.Hour},
+		{60, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := p.Delay(tt.attempts); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSitemapRetryPolicyAfterFailure(t *testing.T) {
+	p := SitemapRetryPolicy{MaxAttempts: 3, BaseDelay: 5 * time.Minute, MaxDelay: time.Hour}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		attempts   int
+		wantStatus status.URL
+		wantRetry  *time.Time
+	}{
+		{1, status.URLPending, ptrTime(now.Add(5 * time.Minute))},
+		{2, status.URLPending, ptrTime(now.Add(10 * time.Minute))},
+		{3, status.URLError, nil},
+		{4, status.URLError, nil},
+	}
+
+	for _, tt := range tests {
+		gotStatus, gotRetry := p.afterFailure(tt.attempts, now)
+		if gotStatus != tt.wantStatus || !reflect.DeepEqual(gotRetry, tt.wantRetry) {
+			t.Errorf("afterFailure(%d) = %s, %v; want %s, %v", tt.attempts, gotStatus, gotRetry, tt.wantStatus, tt.wantRetry)
+		}
+	}
+}
+
+func TestSetRetryPolicyDefaults(t *testing.T) {
+	r := &SitemapURLRepo{}
+	r.SetRetryPolicy(SitemapRetryPolicy{BaseDelay: 2 * time.Hour})
+
+	want := SitemapRetryPolicy{MaxAttempts: DefaultSitemapRetryPolicy.MaxAttempts, BaseDelay: 2 * time.Hour, MaxDelay: 2 * time.Hour}
+	if r.retry != want {
+		t.Errorf("retry policy = %+v, want %+v", r.retry, want)
+	}
+}
+
+func TestRetryableFilter(t *testing.T) {
+	p := SitemapRetryPolicy{MaxAttempts: 3}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	f := retryableFilter("s1", p, now)
+	if f["status"] != status.URLPending {
+		t.Errorf("status = %v, want pending", f["status"])
+	}
+	attempts := f["$or"].([]bson.M)[1]["retry_count"]
+	if !reflect.DeepEqual(attempts, bson.M{"$lt": 3}) {
+		t.Errorf("retry_count = %v, want $lt 3", attempts)
+	}
+	due := f["$and"].([]bson.M)[0]["$or"].([]bson.M)[1]["next_retry_at"]
+	if !reflect.DeepEqual(due, bson.M{"$lte": now}) {
+		t.Errorf("next_retry_at = %v, want $lte now", due)
+	}
+}
+
+func TestExhaustedFilter(t *testing.T) {
+	f := exhaustedFilter("s1", SitemapRetryPolicy{MaxAttempts: 3})
+	if f["status"] != status.URLPending {
+		t.Errorf("status = %v, want pending", f["status"])
+	}
+	if !reflect.DeepEqual(f["retry_count"], bson.M{"$gte": 3}) {
+		t.Errorf("retry_count = %v, want $gte 3", f["retry_count"])
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
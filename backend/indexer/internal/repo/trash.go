@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Корзина: удалённые сайты и контент получают deleted_at и пропадают из выборок.
+// В течение срока хранения их можно восстановить, потом планировщик удаляет их окончательно
+
+// notDeleted добавляет к фильтру условие «не в корзине»; null совпадает и с отсутствующим полем
+func notDeleted(filter bson.M) bson.M {
+	filter["deleted_at"] = nil
+	return filter
+}
+
+// inTrash — документ с id, лежащий в корзине
+func inTrash(oid primitive.ObjectID) bson.M {
+	return bson.M{"_id": oid, "deleted_at": bson.M{"$ne": nil}}
+}
+
+// moveToTrash помечает документ удалённым; false — документа нет или он уже в корзине
+func moveToTrash(ctx context.Context, coll *mongo.Collection, id, deletedBy string) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+	res, err := coll.UpdateOne(ctx, notDeleted(bson.M{"_id": oid}), bson.M{
+		"$set": bson.M{"deleted_at": time.Now(), "deleted_by": deletedBy},
+	})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// restoreFromTrash снимает пометку удаления; false — документа в корзине нет
+func restoreFromTrash(ctx context.Context, coll *mongo.Collection, id string) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+	res, err := coll.UpdateOne(ctx, inTrash(oid), bson.M{
+		"$unset": bson.M{"deleted_at": "", "deleted_by": ""},
+	})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// findInTrash загружает документ из корзины в out; false — не найден
+func findInTrash(ctx context.Context, coll *mongo.Collection, id string, out interface{}) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+	err = coll.FindOne(ctx, inTrash(oid)).Decode(out)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// expiredTrashIDs возвращает id документов, удалённых раньше cutoff
+func expiredTrashIDs(ctx context.Context, coll *mongo.Collection, cutoff time.Time) ([]string, error) {
+	cursor, err := coll.Find(ctx, bson.M{"deleted_at": bson.M{"$lt": cutoff}}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ids []string
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids = append(ids, doc.ID.Hex())
+	}
+	return ids, cursor.Err()
+}
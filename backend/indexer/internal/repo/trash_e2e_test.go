@@ -0,0 +1,97 @@
+//go:build e2e
+// +build e2e
+
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestContentSoftDeleteRestore_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db := setupTestDB(t, ctx)
+	contentRepo := NewContentRepo(db)
+
+	content := &Content{Title: "Интерстеллар", KinopoiskID: "258687"}
+	if err := contentRepo.Create(ctx, content); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	id := content.ID.Hex()
+
+	if ok, err := contentRepo.SoftDelete(ctx, id, "user-1"); err != nil || !ok {
+		t.Fatalf("SoftDelete = %v, %v", ok, err)
+	}
+	if ok, _ := contentRepo.SoftDelete(ctx, id, "user-1"); ok {
+		t.Fatal("second SoftDelete must report nothing to delete")
+	}
+
+	// Из выборок пропадает, но находится по внешнему ID и в корзине
+	if got, _ := contentRepo.FindByID(ctx, id); got != nil {
+		t.Fatal("FindByID returned deleted content")
+	}
+	if _, total, _ := contentRepo.FindAll(ctx, ContentFilter{Limit: 10}); total != 0 {
+		t.Fatalf("FindAll total = %d, want 0", total)
+	}
+	if got, _ := contentRepo.FindByExternalID(ctx, &Content{KinopoiskID: "258687"}); got == nil || got.DeletedAt == nil {
+		t.Fatalf("FindByExternalID = %+v, want deleted content", got)
+	}
+	if got, _ := contentRepo.FindDeletedByID(ctx, id); got == nil || got.DeletedBy != "user-1" {
+		t.Fatalf("FindDeletedByID = %+v", got)
+	}
+
+	if ids, _ := contentRepo.FindExpiredDeleted(ctx, time.Now().Add(-time.Hour)); len(ids) != 0 {
+		t.Fatalf("fresh deletion reported as expired: %v", ids)
+	}
+	if ids, _ := contentRepo.FindExpiredDeleted(ctx, time.Now().Add(time.Second)); len(ids) != 1 || ids[0] != id {
+		t.Fatalf("FindExpiredDeleted = %v, want [%s]", ids, id)
+	}
+
+	if ok, err := contentRepo.Restore(ctx, id); err != nil || !ok {
+		t.Fatalf("Restore = %v, %v", ok, err)
+	}
+	if got, _ := contentRepo.FindByID(ctx, id); got == nil || got.DeletedAt != nil {
+		t.Fatalf("FindByID after restore = %+v", got)
+	}
+	if ok, _ := contentRepo.Restore(ctx, id); ok {
+		t.Fatal("Restore of live content must report nothing to restore")
+	}
+}
+
+func TestSiteSoftDeleteHidesFromScans_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db := setupTestDB(t, ctx)
+	siteRepo := NewSiteRepo(db)
+
+	site := &Site{Domain: "kino.test"}
+	if err := siteRepo.Create(ctx, site); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	id := site.ID.Hex()
+
+	if ok, err := siteRepo.SoftDelete(ctx, id, "user-1"); err != nil || !ok {
+		t.Fatalf("SoftDelete = %v, %v", ok, err)
+	}
+
+	if sites, _ := siteRepo.FindPendingSites(ctx, -time.Hour, 10); len(sites) != 0 {
+		t.Fatalf("FindPendingSites returned deleted site")
+	}
+	if _, total, _ := siteRepo.FindAll(ctx, SiteFilter{Limit: 10}); total != 0 {
+		t.Fatalf("FindAll total = %d, want 0", total)
+	}
+	if got, _ := siteRepo.FindByDomain(ctx, "kino.test"); got == nil || got.DeletedAt == nil {
+		t.Fatalf("FindByDomain = %+v, want deleted site", got)
+	}
+
+	if ok, err := siteRepo.Restore(ctx, id); err != nil || !ok {
+		t.Fatalf("Restore = %v, %v", ok, err)
+	}
+	if got, _ := siteRepo.FindByID(ctx, id); got == nil {
+		t.Fatal("restored site not found")
+	}
+}
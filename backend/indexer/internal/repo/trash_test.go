@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNotDeleted(t *testing.T) {
+	got := notDeleted(bson.M{"status": "active"})
+	want := bson.M{"status": "active", "deleted_at": nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("notDeleted() = %v, want %v", got, want)
+	}
+
+	// Условия $or фильтра не затираются
+	stale := notDeleted(staleContentFilter(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)))
+	if _, ok := stale["$or"]; !ok {
+		t.Errorf("notDeleted(staleContentFilter) lost $or: %v", stale)
+	}
+}
+
+func TestInTrash(t *testing.T) {
+	oid := primitive.NewObjectID()
+	want := bson.M{"_id": oid, "deleted_at": bson.M{"$ne": nil}}
+	if got := inTrash(oid); !reflect.DeepEqual(got, want) {
+		t.Errorf("inTrash() = %v, want %v", got, want)
+	}
+}
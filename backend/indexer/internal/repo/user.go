@@ -17,7 +17,7 @@ type User struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Login        string             `bson:"login" json:"login"`
 	PasswordHash string             `bson:"password_hash" json:"-"`
-	Role         string             `bson:"role" json:"role"`
+	Role         string             `bson:"role" json:"role"` // admin, user или viewer (только чтение)
 	IsActive     bool               `bson:"is_active" json:"is_active"`
 	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
 }
@@ -132,6 +132,26 @@ func (r *UserRepo) Update(ctx context.Context, user *User) error {
 	return err
 }
 
+// UpdatePassword хеширует и сохраняет новый пароль пользователя
+func (r *UserRepo) UpdatePassword(ctx context.Context, id, password string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.coll.UpdateOne(
+		ctx,
+		bson.M{"_id": oid},
+		bson.M{"$set": bson.M{"password_hash": hash}},
+	)
+	return err
+}
+
 func (r *UserRepo) SoftDelete(ctx context.Context, id string) error {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -159,15 +179,24 @@ func (r *UserRepo) SeedAdmin(ctx context.Context, login, password string) error
 		return nil
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, err := HashPassword(password)
 	if err != nil {
 		return err
 	}
 
 	return r.Create(ctx, &User{
 		Login:        login,
-		PasswordHash: string(hash),
+		PasswordHash: hash,
 		Role:         "admin",
 		IsActive:     true,
 	})
 }
+
+// HashPassword — bcrypt-хеш пароля для хранения в password_hash
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
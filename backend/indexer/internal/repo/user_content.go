@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"bytes"
 	"context"
 	"time"
 
@@ -19,6 +20,16 @@ type UserContent struct {
 	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
 }
 
+// ContentOwner — владелец контента: первый связавший его пользователь
+// (контент, созданный админом, связывается с админом при создании)
+type ContentOwner struct {
+	UserID   primitive.ObjectID `json:"user_id"`
+	Login    string             `json:"login,omitempty"`
+	LinkedAt time.Time          `json:"linked_at"`
+	// UsersCount — сколько пользователей связали контент с собой
+	UsersCount int64 `json:"users_count"`
+}
+
 type UserContentRepo struct {
 	coll *mongo.Collection
 }
@@ -118,3 +129,77 @@ func (r *UserContentRepo) DeleteByContentID(ctx context.Context, contentID primi
 func (r *UserContentRepo) CountByContentID(ctx context.Context, contentID primitive.ObjectID) (int64, error) {
 	return r.coll.CountDocuments(ctx, bson.M{"content_id": contentID})
 }
+
+// GetOwners возвращает владельцев контента по content_id; контент без связей в результат не попадает
+func (r *UserContentRepo) GetOwners(ctx context.Context, contentIDs []primitive.ObjectID) (map[primitive.ObjectID]ContentOwner, error) {
+	if len(contentIDs) == 0 {
+		return map[primitive.ObjectID]ContentOwner{}, nil
+	}
+
+	cursor, err := r.coll.Find(ctx, bson.M{"content_id": bson.M{"$in": contentIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var links []UserContent
+	if err := cursor.All(ctx, &links); err != nil {
+		return nil, err
+	}
+
+	owners := pickOwners(links)
+	if len(owners) == 0 {
+		return owners, nil
+	}
+
+	userIDs := make([]primitive.ObjectID, 0, len(owners))
+	for _, owner := range owners {
+		userIDs = append(userIDs, owner.UserID)
+	}
+
+	users := r.coll.Database().Collection(usersCollection)
+	userCursor, err := users.Find(ctx,
+		bson.M{"_id": bson.M{"$in": userIDs}},
+		options.Find().SetProjection(bson.M{"login": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer userCursor.Close(ctx)
+
+	var found []User
+	if err := userCursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	logins := make(map[primitive.ObjectID]string, len(found))
+	for _, u := range found {
+		logins[u.ID] = u.Login
+	}
+	for contentID, owner := range owners {
+		owner.Login = logins[owner.UserID]
+		owners[contentID] = owner
+	}
+	return owners, nil
+}
+
+// pickOwners выбирает для каждого контента самую раннюю связь; при равном времени — с меньшим _id
+func pickOwners(links []UserContent) map[primitive.ObjectID]ContentOwner {
+	owners := make(map[primitive.ObjectID]ContentOwner)
+	first := make(map[primitive.ObjectID]UserContent)
+
+	for _, link := range links {
+		owner := owners[link.ContentID]
+		owner.UsersCount++
+
+		prev, seen := first[link.ContentID]
+		if !seen || link.CreatedAt.Before(prev.CreatedAt) ||
+			(link.CreatedAt.Equal(prev.CreatedAt) && bytes.Compare(link.ID[:], prev.ID[:]) < 0) {
+			first[link.ContentID] = link
+			owner.UserID = link.UserID
+			owner.LinkedAt = link.CreatedAt
+		}
+		owners[link.ContentID] = owner
+	}
+	return owners
+}
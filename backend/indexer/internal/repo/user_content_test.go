@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPickOwners(t *testing.T) {
+	oid := func(hex string) primitive.ObjectID {
+		id, _ := primitive.ObjectIDFromHex(hex)
+		return id
+	}
+	var (
+		shared  = oid("650000000000000000000001")
+		private = oid("650000000000000000000002")
+		admin   = oid("660000000000000000000001")
+		alice   = oid("660000000000000000000002")
+		bob     = oid("660000000000000000000003")
+		created = time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	)
+
+	links := []UserContent{
+		// bob связал общий контент позже админа, создавшего его
+		{ID: oid("670000000000000000000003"), UserID: bob, ContentID: shared, CreatedAt: created.Add(48 * time.Hour)},
+		{ID: oid("670000000000000000000001"), UserID: admin, ContentID: shared, CreatedAt: created},
+		{ID: oid("670000000000000000000002"), UserID: alice, ContentID: shared, CreatedAt: created.Add(time.Hour)},
+		{ID: oid("670000000000000000000005"), UserID: bob, ContentID: private, CreatedAt: created},
+		// одинаковое время — побеждает меньший _id
+		{ID: oid("670000000000000000000004"), UserID: alice, ContentID: private, CreatedAt: created},
+	}
+
+	want := map[primitive.ObjectID]ContentOwner{
+		shared:  {UserID: admin, LinkedAt: created, UsersCount: 3},
+		private: {UserID: alice, LinkedAt: created, UsersCount: 2},
+	}
+
+	if got := pickOwners(links); !reflect.DeepEqual(got, want) {
+		t.Errorf("pickOwners() = %+v, want %+v", got, want)
+	}
+
+	if got := pickOwners(nil); len(got) != 0 {
+		t.Errorf("pickOwners(nil) = %+v, want empty", got)
+	}
+}
@@ -6,10 +6,14 @@ import (
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/meili"
+	"github.com/video-analitics/backend/pkg/snapshot"
 	"github.com/video-analitics/backend/pkg/status"
 	"github.com/video-analitics/backend/pkg/violations"
+	"github.com/video-analitics/indexer/internal/metrics"
 	indexerQueue "github.com/video-analitics/indexer/internal/queue"
 	"github.com/video-analitics/indexer/internal/repo"
+	"github.com/video-analitics/indexer/internal/service"
 )
 
 type Scheduler struct {
@@ -19,7 +23,13 @@ type Scheduler struct {
 	contentRepo    *repo.ContentRepo
 	publisher      *indexerQueue.Publisher
 	violationsSvc  *violations.Service
-	scheduler      gocron.Scheduler
+	trash          *service.TrashService
+	meili          *meili.Client
+	snapshots      snapshot.Store
+	pageRepo       *repo.PageRepo
+	// snapshotRetention — сколько хранятся снимки HTML страниц
+	snapshotRetention time.Duration
+	scheduler         gocron.Scheduler
 }
 
 func New(siteRepo *repo.SiteRepo, taskRepo *repo.ScanTaskRepo, sitemapURLRepo *repo.SitemapURLRepo, contentRepo *repo.ContentRepo, publisher *indexerQueue.Publisher, violationsSvc *violations.Service) (*Scheduler, error) {
@@ -39,12 +49,33 @@ func New(siteRepo *repo.SiteRepo, taskRepo *repo.ScanTaskRepo, sitemapURLRepo *r
 	}, nil
 }
 
+// SetTrash включает ежечасную очистку корзины от записей с истёкшим сроком хранения
+func (s *Scheduler) SetTrash(trash *service.TrashService) {
+	s.trash = trash
+}
+
+// SetMeili включает ежедневную очистку Meilisearch от страниц удалённых сайтов
+func (s *Scheduler) SetMeili(client *meili.Client) {
+	s.meili = client
+}
+
+// SetSnapshots включает ежедневное удаление снимков HTML старше retention
+func (s *Scheduler) SetSnapshots(store snapshot.Store, pageRepo *repo.PageRepo, retention time.Duration) {
+	s.snapshots = store
+	s.pageRepo = pageRepo
+	s.snapshotRetention = retention
+}
+
 const (
 	pendingDetectionTimeout    = 5 * time.Minute
 	staleTaskPendingTimeout    = 30 * time.Minute
 	staleTaskProcessingTimeout = 2 * time.Hour
 	maxTaskRetries             = 3
 	baseRetryDelay             = 5 * time.Minute
+	// violationsRefreshTick — как часто ищется контент с наступившим next_refresh_at
+	violationsRefreshTick = 15 * time.Minute
+	meiliOrphansInterval  = 24 * time.Hour
+	snapshotPurgeInterval = 24 * time.Hour
 )
 
 func (s *Scheduler) Start(ctx context.Context) error {
@@ -100,16 +131,54 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		return err
 	}
 
+	// Пересчёт может идти дольше тика — следующий запуск ждёт окончания текущего
 	_, err = s.scheduler.NewJob(
-		gocron.DurationJob(24*time.Hour),
+		gocron.DurationJob(violationsRefreshTick),
 		gocron.NewTask(func() {
-			s.refreshAllViolations(ctx)
+			s.refreshDueViolations(ctx)
 		}),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
 	)
 	if err != nil {
 		return err
 	}
 
+	if s.trash != nil {
+		_, err = s.scheduler.NewJob(
+			gocron.DurationJob(time.Hour),
+			gocron.NewTask(func() {
+				s.purgeTrash(ctx)
+			}),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.meili != nil {
+		_, err = s.scheduler.NewJob(
+			gocron.DurationJob(meiliOrphansInterval),
+			gocron.NewTask(func() {
+				s.deleteMeiliOrphans(ctx)
+			}),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.snapshots != nil {
+		_, err = s.scheduler.NewJob(
+			gocron.DurationJob(snapshotPurgeInterval),
+			gocron.NewTask(func() {
+				s.purgeSnapshots(ctx)
+			}),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	s.scheduler.Start()
 	log.Info().Msg("scheduler started")
 
@@ -141,10 +210,21 @@ func (s *Scheduler) queueDueSites(ctx context.Context) {
 
 	queued := 0
 	var queuedSiteIDs []string
+	now := time.Now()
 
 	for i := range sites {
 		site := &sites[i]
 
+		// Вне окна сканирования — переносим на ближайшее начало окна
+		if until, deferred := site.ScanDeferredUntil(now); deferred {
+			if err := s.siteRepo.DeferScan(ctx, site.ID.Hex(), until); err != nil {
+				log.Warn().Err(err).Str("site", site.Domain).Msg("failed to defer scan to window")
+			} else {
+				log.Info().Str("site", site.Domain).Time("next_scan_at", until).Msg("site outside scan window, scan deferred")
+			}
+			continue
+		}
+
 		hasActive, err := s.taskRepo.HasActiveTask(ctx, site.ID.Hex())
 		if err != nil {
 			log.Warn().Err(err).Str("site", site.Domain).Msg("failed to check active task")
@@ -256,14 +336,17 @@ func (s *Scheduler) recoverStaleTasks(ctx context.Context) {
 	}
 }
 
-func (s *Scheduler) refreshAllViolations(ctx context.Context) {
+// refreshDueViolations пересчитывает нарушения контента, чей срок наступил;
+// частота пересчёта зависит от приоритета контента
+func (s *Scheduler) refreshDueViolations(ctx context.Context) {
 	log := logger.Log
 
 	if s.violationsSvc == nil || s.contentRepo == nil {
 		return
 	}
 
-	contents, err := s.contentRepo.GetAll(ctx)
+	now := time.Now()
+	contents, err := s.contentRepo.FindDueForRefresh(ctx, now)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to get contents for violations refresh")
 		return
@@ -273,29 +356,97 @@ func (s *Scheduler) refreshAllViolations(ctx context.Context) {
 		return
 	}
 
+	// Срок переносится до пересчёта: ошибка или долгий проход не вернут тот же контент
+	// на следующем тике, и каталог не будет пересчитываться по кругу
+	if err := s.contentRepo.ScheduleRefresh(ctx, contents, now); err != nil {
+		log.Error().Err(err).Msg("failed to schedule next violations refresh")
+		return
+	}
+
 	contentInfos := make([]violations.ContentInfo, len(contents))
 	for i, c := range contents {
 		contentInfos[i] = violations.ContentInfo{
 			ID:            c.ID.Hex(),
 			Title:         c.Title,
 			OriginalTitle: c.OriginalTitle,
+			Aliases:       c.Aliases,
 			Year:          c.Year,
 			KinopoiskID:   c.KinopoiskID,
 			IMDBID:        c.IMDBID,
 			MALID:         c.MALID,
 			ShikimoriID:   c.ShikimoriID,
 			MyDramaListID: c.MyDramaListID,
+			Language:      c.Language,
+			Region:        c.Region,
 		}
 	}
 
-	updated, err := s.violationsSvc.RefreshAll(ctx, contentInfos)
+	var found int64
+	updated, err := s.violationsSvc.RefreshAllWithOptions(ctx, contentInfos, violations.RefreshOptions{
+		OnProgress: func(p violations.RefreshProgress) { found = p.Violations },
+	})
 	if err != nil {
 		log.Error().Err(err).Msg("failed to refresh violations")
 		return
 	}
 
+	metrics.SchedulerRefreshed(found)
+
 	if updated > 0 {
-		log.Info().Int64("count", updated).Msg("violations refreshed")
+		log.Info().Int64("count", updated).Int64("violations", found).Msg("violations refreshed")
+	}
+}
+
+func (s *Scheduler) purgeTrash(ctx context.Context) {
+	log := logger.Log
+
+	sites, contents, err := s.trash.PurgeExpired(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to purge trash")
+	}
+	if sites > 0 || contents > 0 {
+		log.Info().Int("sites", sites).Int("contents", contents).Msg("trash purged")
+	}
+}
+
+// deleteMeiliOrphans удаляет из поиска страницы сайтов, которых больше нет в базе,
+// например удалённых в обход корзины
+func (s *Scheduler) deleteMeiliOrphans(ctx context.Context) {
+	log := logger.Log
+
+	siteIDs, err := s.siteRepo.ActiveIDs(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get site ids for meilisearch cleanup")
+		return
+	}
+
+	deleted, err := s.meili.DeleteOrphans(ctx, siteIDs)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to delete orphaned meilisearch documents")
+		return
+	}
+	if deleted > 0 {
+		log.Info().Int64("count", deleted).Msg("orphaned meilisearch documents deleted")
+	}
+}
+
+// purgeSnapshots удаляет снимки HTML старше срока хранения и ссылки на них у страниц
+func (s *Scheduler) purgeSnapshots(ctx context.Context) {
+	log := logger.Log
+	cutoff := time.Now().Add(-s.snapshotRetention)
+
+	deleted, err := s.snapshots.DeleteOlderThan(ctx, cutoff)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to delete expired html snapshots")
+		return
+	}
+	cleared, err := s.pageRepo.ClearSnapshotsBefore(ctx, cutoff)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to clear expired snapshot references")
+		return
+	}
+	if deleted > 0 || cleared > 0 {
+		log.Info().Int64("deleted", deleted).Int64("pages", cleared).Msg("expired html snapshots purged")
 	}
 }
 
@@ -318,7 +469,7 @@ func (s *Scheduler) retryFailedTasks(ctx context.Context) {
 		// Check if max retries exceeded - freeze the site
 		if task.RetryCount >= maxTaskRetries {
 			reason := "max scan retries exceeded"
-			if err := s.siteRepo.MarkFrozen(ctx, task.SiteID, reason); err != nil {
+			if err := s.siteRepo.MarkFrozen(ctx, task.SiteID, status.FreezeRepeatedTimeout, reason); err != nil {
 				log.Warn().Err(err).Str("site", task.Domain).Msg("failed to freeze site after max retries")
 			} else {
 				log.Warn().
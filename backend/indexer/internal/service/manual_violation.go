@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/meili"
+	"github.com/video-analitics/backend/pkg/models"
+	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/backend/pkg/violations"
+	indexerQueue "github.com/video-analitics/indexer/internal/queue"
+	"github.com/video-analitics/indexer/internal/repo"
+)
+
+var (
+	// ErrSiteNotMonitored — домен адреса не добавлен в мониторинг, сайт в корзине или недоступен пользователю
+	ErrSiteNotMonitored = errors.New("site is not monitored")
+	// ErrParserUnavailable — ни один парсер не ответил на запрос разбора
+	ErrParserUnavailable = errors.New("no parser available")
+	// ErrPageNotMatched — страница проиндексирована, но ни один этап матчера её не принял
+	ErrPageNotMatched = errors.New("page does not match content")
+	// ErrViolationNotFound — у контента нет нарушения на этой странице
+	ErrViolationNotFound = errors.New("violation not found")
+)
+
+// PageFetchError — парсер ответил, но загрузить или разобрать страницу не смог
+type PageFetchError struct {
+	Reason string
+}
+
+func (e *PageFetchError) Error() string {
+	return "failed to fetch page: " + e.Reason
+}
+
+// PageParser разово загружает и разбирает страницу (NATS request-reply к парсеру)
+type PageParser interface {
+	ParsePage(ctx context.Context, req queue.ParseSyncRequest) (queue.PageResult, error)
+}
+
+// ManualViolation — итог ручного добавления адреса
+type ManualViolation struct {
+	Page      *models.Page
+	Violation *violations.Violation
+	// Created — нарушение записано этим запросом, а не существовало раньше
+	Created bool
+	// Matched — страница совпала с контентом по одному из этапов матчера
+	Matched bool
+	Stages  []violations.StageVerdict
+}
+
+// ManualViolationService добавляет в отслеживание адреса, найденные пользователем вручную:
+// страница загружается парсером, индексируется как при обходе и записывается нарушением типа manual
+type ManualViolationService struct {
+	siteRepo      *repo.SiteRepo
+	userSiteRepo  *repo.UserSiteRepo
+	pageRepo      *repo.PageRepo
+	violationsSvc *violations.Service
+	meili         *meili.Client
+	parser        PageParser
+}
+
+func NewManualViolationService(siteRepo *repo.SiteRepo, userSiteRepo *repo.UserSiteRepo, pageRepo *repo.PageRepo, violationsSvc *violations.Service, meiliClient *meili.Client, parser PageParser) *ManualViolationService {
+	return &ManualViolationService{
+		siteRepo:      siteRepo,
+		userSiteRepo:  userSiteRepo,
+		pageRepo:      pageRepo,
+		violationsSvc: violationsSvc,
+		meili:         meiliClient,
+		parser:        parser,
+	}
+}
+
+// Add загружает и индексирует страницу и записывает нарушение, если она совпала с контентом.
+// force записывает нарушение и без совпадения; без него несовпадение возвращает ErrPageNotMatched
+// вместе с объяснением по этапам. Адрес должен принадлежать сайту, доступному пользователю userID
+func (s *ManualViolationService) Add(ctx context.Context, content violations.ContentInfo, pageURL, userID string, isAdmin, force bool) (*ManualViolation, error) {
+	site, err := s.findSite(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	hasAccess, err := s.siteRepo.HasUserAccessToSite(ctx, site, userID, isAdmin, s.userSiteRepo)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAccess {
+		// чужой сайт не отличаем от неотслеживаемого, чтобы не раскрывать список доменов
+		return nil, ErrSiteNotMonitored
+	}
+
+	result, err := s.parser.ParsePage(ctx, indexerQueue.NewParseSyncRequest(site, pageURL))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParserUnavailable, err)
+	}
+	if !result.Success || result.Page == nil {
+		reason := result.Error
+		if reason == "" {
+			reason = "no page data"
+		}
+		return nil, &PageFetchError{Reason: reason}
+	}
+	if !sameSiteHost(pageURL, result.Page.URL) {
+		return nil, &PageFetchError{Reason: "redirected to another host: " + result.Page.URL}
+	}
+
+	page := PageFromData(site.ID.Hex(), result.Page)
+	// парсер возвращает адрес после редиректа; страница записывается под адресом, который добавил пользователь
+	page.URL = pageURL
+	if err := s.pageRepo.Upsert(ctx, page); err != nil {
+		return nil, fmt.Errorf("save page: %w", err)
+	}
+
+	doc := meili.NewPageDocument(page, site.Domain)
+	if s.meili != nil {
+		if err := s.meili.IndexPages([]meili.PageDocument{doc}); err != nil {
+			logger.Log.Warn().Err(err).Str("url", page.URL).Msg("meili indexing failed")
+		}
+	}
+
+	out := &ManualViolation{Page: page, Stages: s.violationsSvc.ExplainMatch(content, doc)}
+	for _, st := range out.Stages {
+		if st.Verdict == violations.VerdictMatched {
+			out.Matched = true
+			break
+		}
+	}
+	if !out.Matched && !force {
+		return out, ErrPageNotMatched
+	}
+
+	out.Violation, out.Created, err = s.violationsSvc.RecordManual(ctx, violations.Violation{
+		ContentID: content.ID,
+		SiteID:    page.SiteID,
+		PageID:    page.ID.Hex(),
+		PageURL:   page.URL,
+		PageTitle: page.Title,
+		Season:    page.Season,
+		Episodes:  page.Episodes,
+		Evidence:  manualEvidence(out.Stages),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("record violation: %w", err)
+	}
+	return out, nil
+}
+
+// VerifyResult — итог живой перепроверки нарушения перед жалобой
+type VerifyResult struct {
+	// Present — страница доступна и по-прежнему совпадает с контентом
+	Present bool
+	// Title — заголовок страницы сейчас; пусто, если загрузить её не удалось
+	Title string
+	// Reason — почему нарушение считается снятым: признак снятой страницы или "content no longer matches"
+	Reason string
+	Stages []violations.StageVerdict
+	// Resolved — нарушение снято этой проверкой
+	Resolved bool
+	// Gone — страница снята (404/410, парковка); иначе она загрузилась, но контента на ней нет
+	Gone bool
+
+	// page — загруженная сейчас версия страницы
+	page *models.Page
+}
+
+// Verify заново загружает страницу нарушения парсером и проверяет её матчером.
+// Если страница снята или больше не содержит контент, нарушение снимается
+func (s *ManualViolationService) Verify(ctx context.Context, content violations.ContentInfo, pageID string) (*VerifyResult, error) {
+	v, err := s.violationsSvc.GetByContentAndPage(ctx, content.ID, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrViolationNotFound
+	}
+	return verifyViolation(ctx, s.parser, s.violationsSvc.ExplainMatch, content, v, s.siteByID(ctx, v.SiteID), s.resolveRemoved)
+}
+
+// resolveRemoved снимает нарушение так, чтобы пересчёт его не вернул: снятая страница удаляется
+// из базы и индекса, изменившаяся сохраняется и переиндексируется в текущем виде
+func (s *ManualViolationService) resolveRemoved(ctx context.Context, v *violations.Violation, domain string, out *VerifyResult) error {
+	switch {
+	case out.Gone:
+		if err := s.pageRepo.DeleteByID(ctx, v.PageID); err != nil {
+			return fmt.Errorf("delete page: %w", err)
+		}
+		if s.meili != nil {
+			if err := s.meili.DeletePage(v.PageID); err != nil {
+				return fmt.Errorf("delete page from index: %w", err)
+			}
+		}
+	case out.page != nil:
+		page := out.page
+		// парсер мог вернуть адрес после редиректа, а обновить нужно страницу нарушения
+		page.URL = v.PageURL
+		if err := s.pageRepo.Upsert(ctx, page); err != nil {
+			return fmt.Errorf("save page: %w", err)
+		}
+		if s.meili != nil {
+			if err := s.meili.IndexPages([]meili.PageDocument{meili.NewPageDocument(page, domain)}); err != nil {
+				return fmt.Errorf("index page: %w", err)
+			}
+		}
+	}
+
+	if err := s.violationsSvc.Resolve(ctx, v.ContentID, v.PageID); err != nil {
+		return fmt.Errorf("resolve violation: %w", err)
+	}
+	return nil
+}
+
+// siteByID — сайт нарушения: домен для документа поиска и настройки загрузки; nil, если сайт не найден
+func (s *ManualViolationService) siteByID(ctx context.Context, siteID string) *repo.Site {
+	if site, err := s.siteRepo.FindByID(ctx, siteID); err == nil && site != nil {
+		return site
+	}
+	return nil
+}
+
+var errNotVerified = errors.New("not verified")
+
+// VerifyWorkers — сколько страниц VerifyAll проверяет одновременно; каждая занимает вкладку браузера в парсере
+const VerifyWorkers = 4
+
+// VerifyError — нарушение, которое не удалось перепроверить
+type VerifyError struct {
+	PageID string `json:"page_id"`
+	Error  string `json:"error"`
+}
+
+// VerifySummary — итог перепроверки всех нарушений контента
+type VerifySummary struct {
+	Total   int           `json:"total"`
+	Present int           `json:"present"`
+	Removed int           `json:"removed"`
+	Errored int           `json:"errored"`
+	Errors  []VerifyError `json:"errors,omitempty"`
+}
+
+// VerifyProgress получает число проверенных страниц и общее число; вызывается из горутин проверки
+type VerifyProgress func(checked, total int)
+
+// VerifyAll перепроверяет все текущие нарушения контента, не более workers страниц одновременно.
+// Снимаются только нарушения на определённо снятых или изменившихся страницах; страницы, которые
+// не удалось загрузить (таймаут, блокировка, капча), считаются ошибками и остаются как есть.
+// progress (может быть nil) вызывается до начала проверки и после каждой страницы
+func (s *ManualViolationService) VerifyAll(ctx context.Context, content violations.ContentInfo, workers int, progress VerifyProgress) (*VerifySummary, error) {
+	all, err := s.violationsSvc.GetAllByContentID(ctx, content.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sites := make(map[string]*repo.Site)
+	for _, v := range all {
+		if _, ok := sites[v.SiteID]; !ok {
+			sites[v.SiteID] = s.siteByID(ctx, v.SiteID)
+		}
+	}
+	if progress != nil {
+		progress(0, len(all))
+	}
+	return verifyViolations(ctx, s.parser, s.violationsSvc.ExplainMatch, content, all, sites, workers, s.resolveRemoved, progress), nil
+}
+
+// verifyViolations перепроверяет нарушения через verifyPages; sites — сайты по site_id
+func verifyViolations(ctx context.Context, parser PageParser, explain explainFunc, content violations.ContentInfo, all []violations.Violation, sites map[string]*repo.Site, workers int, resolve func(context.Context, *violations.Violation, string, *VerifyResult) error, progress VerifyProgress) *VerifySummary {
+	byPage := make(map[string]*violations.Violation, len(all))
+	pageIDs := make([]string, len(all))
+	for i := range all {
+		byPage[all[i].PageID] = &all[i]
+		pageIDs[i] = all[i].PageID
+	}
+	var checked atomic.Int64
+	return verifyPages(ctx, pageIDs, workers, func(ctx context.Context, pageID string) (*VerifyResult, error) {
+		v := byPage[pageID]
+		out, err := verifyViolation(ctx, parser, explain, content, v, sites[v.SiteID], resolve)
+		if progress != nil {
+			progress(int(checked.Add(1)), len(pageIDs))
+		}
+		return out, err
+	})
+}
+
+// verifyPages вызывает verify для страниц не более чем в workers горутинах и сводит итоги.
+// После отмены ctx новые страницы не проверяются и считаются ошибками
+func verifyPages(ctx context.Context, pageIDs []string, workers int, verify func(context.Context, string) (*VerifyResult, error)) *VerifySummary {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(pageIDs) {
+		workers = len(pageIDs)
+	}
+
+	results := make([]*VerifyResult, len(pageIDs))
+	errs := make([]error, len(pageIDs))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = verify(ctx, pageIDs[i])
+			}
+		}()
+	}
+
+feed:
+	for i := range pageIDs {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	summary := &VerifySummary{Total: len(pageIDs)}
+	for i, pageID := range pageIDs {
+		err := errs[i]
+		if err == nil && results[i] == nil {
+			// страница не дошла до проверки из-за отмены
+			err = ctx.Err()
+			if err == nil {
+				err = errNotVerified
+			}
+		}
+		switch {
+		case err != nil:
+			summary.Errored++
+			summary.Errors = append(summary.Errors, VerifyError{PageID: pageID, Error: err.Error()})
+		case results[i].Present:
+			summary.Present++
+		default:
+			summary.Removed++
+		}
+	}
+	return summary
+}
+
+// explainFunc объясняет по этапам матчера, совпадает ли документ с контентом
+type explainFunc func(violations.ContentInfo, meili.PageDocument) []violations.StageVerdict
+
+// verifyViolation перепроверяет страницу нарушения; resolve вызывается, только если страница
+// определённо снята или загрузилась без контента. Не удалось проверить — ошибка, нарушение остаётся.
+// Страница загружается с настройками сайта, иначе недорисованная SPA выглядит снятой
+func verifyViolation(ctx context.Context, parser PageParser, explain explainFunc, content violations.ContentInfo, v *violations.Violation, site *repo.Site, resolve func(context.Context, *violations.Violation, string, *VerifyResult) error) (*VerifyResult, error) {
+	var domain string
+	if site != nil {
+		domain = site.Domain
+	}
+	out, err := checkLive(ctx, parser, explain, content, v.SiteID, domain, indexerQueue.NewParseSyncRequest(site, v.PageURL))
+	if err != nil {
+		return nil, err
+	}
+	if out.Present {
+		return out, nil
+	}
+
+	if err := resolve(ctx, v, domain, out); err != nil {
+		return nil, err
+	}
+	out.Resolved = true
+	return out, nil
+}
+
+// checkLive загружает страницу и решает, на месте ли контент. Снятой страница считается только
+// по явному признаку парсера (404/410, парковка); прочие неудачи загрузки — таймаут, блокировка,
+// капча, пустой заголовок — значат, что проверить не удалось
+func checkLive(ctx context.Context, parser PageParser, explain explainFunc, content violations.ContentInfo, siteID, domain string, req queue.ParseSyncRequest) (*VerifyResult, error) {
+	result, err := parser.ParsePage(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParserUnavailable, err)
+	}
+	if !result.Success || result.Page == nil {
+		reason := result.Error
+		if reason == "" {
+			reason = "no page data"
+		}
+		if !result.Gone {
+			return nil, &PageFetchError{Reason: reason}
+		}
+		return &VerifyResult{Reason: reason, Gone: true}, nil
+	}
+
+	page := PageFromData(siteID, result.Page)
+	out := &VerifyResult{Title: page.Title, Stages: explain(content, meili.NewPageDocument(page, domain)), page: page}
+	for _, st := range out.Stages {
+		if st.Verdict == violations.VerdictMatched {
+			out.Present = true
+			return out, nil
+		}
+	}
+	out.Reason = "content no longer matches"
+	return out, nil
+}
+
+// findSite ищет сайт по хосту адреса, с www и без
+func (s *ManualViolationService) findSite(ctx context.Context, pageURL string) (*repo.Site, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, ErrSiteNotMonitored
+	}
+	host := strings.ToLower(u.Hostname())
+
+	candidates := []string{host, "www." + host}
+	if bare, ok := strings.CutPrefix(host, "www."); ok {
+		candidates = []string{host, bare}
+	}
+	for _, domain := range candidates {
+		site, err := s.siteRepo.FindByDomain(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		if site != nil && site.DeletedAt == nil {
+			return site, nil
+		}
+	}
+	return nil, ErrSiteNotMonitored
+}
+
+// sameSiteHost — адреса на одном хосте с точностью до www
+func sameSiteHost(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	hostA := strings.TrimPrefix(strings.ToLower(ua.Hostname()), "www.")
+	hostB := strings.TrimPrefix(strings.ToLower(ub.Hostname()), "www.")
+	return hostA == hostB
+}
+
+// manualEvidence — этапы, по которым совпала добавленная вручную страница; "forced" — записана без совпадения
+func manualEvidence(stages []violations.StageVerdict) string {
+	var matched []string
+	for _, st := range stages {
+		if st.Verdict == violations.VerdictMatched && !slices.Contains(matched, string(st.Stage)) {
+			matched = append(matched, string(st.Stage))
+		}
+	}
+	if len(matched) == 0 {
+		return "forced"
+	}
+	return "matched_stages=" + strings.Join(matched, ",")
+}
+
+// PageFromData переводит разобранную парсером страницу в документ коллекции pages
+func PageFromData(siteID string, pd *queue.PageData) *models.Page {
+	externalIDs := models.ExternalIDs{}
+	if pd.ExternalIDs != nil {
+		externalIDs.KinopoiskID = pd.ExternalIDs["kinopoisk_id"]
+		externalIDs.IMDBID = pd.ExternalIDs["imdb_id"]
+		externalIDs.TMDBID = pd.ExternalIDs["tmdb_id"]
+		externalIDs.MALID = pd.ExternalIDs["mal_id"]
+		externalIDs.ShikimoriID = pd.ExternalIDs["shikimori_id"]
+		externalIDs.MyDramaListID = pd.ExternalIDs["mydramalist_id"]
+	}
+
+	return &models.Page{
+		SiteID:       siteID,
+		URL:          pd.URL,
+		Title:        pd.Title,
+		Description:  pd.Description,
+		MainText:     pd.MainText,
+		Year:         pd.Year,
+		Season:       pd.Season,
+		Episodes:     pd.Episodes,
+		PlayerURL:    pd.PlayerURL,
+		LinksText:    pd.LinksText,
+		ExternalIDs:  externalIDs,
+		HTTPStatus:   200,
+		IndexedAt:    time.Now(),
+		ETag:         pd.ETag,
+		LastModified: pd.LastModified,
+	}
+}
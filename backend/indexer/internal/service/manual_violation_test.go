@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/meili"
+	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/backend/pkg/status"
+	"github.com/video-analitics/backend/pkg/violations"
+	"github.com/video-analitics/indexer/internal/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestManualEvidence(t *testing.T) {
+	tests := []struct {
+		name   string
+		stages []violations.StageVerdict
+		want   string
+	}{
+		{"no stages", nil, "forced"},
+		{
+			name: "nothing matched",
+			stages: []violations.StageVerdict{
+				{Stage: violations.MatchByKinopoisk, Verdict: violations.VerdictIDMismatch},
+				{Stage: violations.MatchByTitle, Verdict: violations.VerdictFilteredByPhrase},
+			},
+			want: "forced",
+		},
+		{
+			name: "matched stages deduplicated in order",
+			stages: []violations.StageVerdict{
+				{Stage: violations.MatchByKinopoisk, Verdict: violations.VerdictMatched},
+				{Stage: violations.MatchByIMDB, Verdict: violations.VerdictNoID},
+				{Stage: violations.MatchByTitle, Verdict: violations.VerdictMatched},
+				{Stage: violations.MatchByTitle, Verdict: violations.VerdictMatched},
+			},
+			want: "matched_stages=kinopoisk,title",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := manualEvidence(tt.stages); got != tt.want {
+				t.Errorf("manualEvidence() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type stubPageParser struct {
+	result queue.PageResult
+	err    error
+}
+
+func (s stubPageParser) ParsePage(context.Context, queue.ParseSyncRequest) (queue.PageResult, error) {
+	return s.result, s.err
+}
+
+// recordingPageParser запоминает запросы разбора
+type recordingPageParser struct {
+	stubPageParser
+	reqs chan queue.ParseSyncRequest
+}
+
+func (s recordingPageParser) ParsePage(ctx context.Context, req queue.ParseSyncRequest) (queue.PageResult, error) {
+	s.reqs <- req
+	return s.stubPageParser.ParsePage(ctx, req)
+}
+
+// explainByKinopoisk совпадает, если kinopoisk_id страницы равен ID контента
+func explainByKinopoisk(content violations.ContentInfo, page meili.PageDocument) []violations.StageVerdict {
+	verdict := violations.VerdictIDMismatch
+	if page.KinopoiskID == content.KinopoiskID {
+		verdict = violations.VerdictMatched
+	}
+	return []violations.StageVerdict{{Stage: violations.MatchByKinopoisk, Verdict: verdict}}
+}
+
+func TestCheckLive(t *testing.T) {
+	content := violations.ContentInfo{ID: "c1", Title: "Брат 2", KinopoiskID: "41519"}
+	page := func(kpID string) *queue.PageData {
+		return &queue.PageData{
+			URL:         "https://example.com/film/1",
+			Title:       "Брат 2 смотреть онлайн",
+			ExternalIDs: map[string]string{"kinopoisk_id": kpID},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		parser      stubPageParser
+		wantErr     error
+		wantFetch   bool
+		wantPresent bool
+		wantGone    bool
+		wantTitle   string
+		wantReason  string
+	}{
+		{
+			name:        "still present",
+			parser:      stubPageParser{result: queue.PageResult{Success: true, Page: page("41519")}},
+			wantPresent: true,
+			wantTitle:   "Брат 2 смотреть онлайн",
+		},
+		{
+			name:       "page gone",
+			parser:     stubPageParser{result: queue.PageResult{Error: "http status 404", Gone: true}},
+			wantGone:   true,
+			wantReason: "http status 404",
+		},
+		{
+			name:       "parked domain",
+			parser:     stubPageParser{result: queue.PageResult{Error: "parked domain: sedoparking.com", Gone: true}},
+			wantGone:   true,
+			wantReason: "parked domain: sedoparking.com",
+		},
+		{
+			name:       "content replaced",
+			parser:     stubPageParser{result: queue.PageResult{Success: true, Page: page("111")}},
+			wantTitle:  "Брат 2 смотреть онлайн",
+			wantReason: "content no longer matches",
+		},
+		{
+			name:      "no page data is not a removal",
+			parser:    stubPageParser{result: queue.PageResult{Success: true}},
+			wantFetch: true,
+		},
+		{
+			name:      "empty title is not a removal",
+			parser:    stubPageParser{result: queue.PageResult{Error: "empty title"}},
+			wantFetch: true,
+		},
+		{
+			name:      "timeout is not a removal",
+			parser:    stubPageParser{result: queue.PageResult{Error: "fetch page: context deadline exceeded"}},
+			wantFetch: true,
+		},
+		{
+			name:      "unsolved captcha is not a removal",
+			parser:    stubPageParser{result: queue.PageResult{Error: "blocked: captcha solve failed", IPBlocked: true, Captcha: true}},
+			wantFetch: true,
+		},
+		{
+			name:      "ip blocked is not a removal",
+			parser:    stubPageParser{result: queue.PageResult{IPBlocked: true, Error: "captcha"}},
+			wantFetch: true,
+		},
+		{
+			name:    "parser unavailable",
+			parser:  stubPageParser{err: errors.New("no responders")},
+			wantErr: ErrParserUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkLive(context.Background(), tt.parser, explainByKinopoisk, content, "s1", "example.com", queue.ParseSyncRequest{URL: "https://example.com/film/1"})
+
+			var fetchErr *PageFetchError
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.wantFetch:
+				if !errors.As(err, &fetchErr) {
+					t.Fatalf("err = %v, want PageFetchError", err)
+				}
+				return
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.Present != tt.wantPresent {
+				t.Errorf("Present = %v, want %v", got.Present, tt.wantPresent)
+			}
+			if got.Gone != tt.wantGone {
+				t.Errorf("Gone = %v, want %v", got.Gone, tt.wantGone)
+			}
+			if got.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", got.Title, tt.wantTitle)
+			}
+			if got.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", got.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestVerifyPagesSummary(t *testing.T) {
+	outcomes := map[string]func() (*VerifyResult, error){
+		"live":    func() (*VerifyResult, error) { return &VerifyResult{Present: true}, nil },
+		"gone":    func() (*VerifyResult, error) { return &VerifyResult{Reason: "http status 404", Resolved: true}, nil },
+		"blocked": func() (*VerifyResult, error) { return nil, &PageFetchError{Reason: "captcha"} },
+	}
+	verify := func(_ context.Context, pageID string) (*VerifyResult, error) {
+		kind, _, _ := strings.Cut(pageID, "-")
+		return outcomes[kind]()
+	}
+
+	tests := []struct {
+		name    string
+		pageIDs []string
+		want    VerifySummary
+	}{
+		{"empty", nil, VerifySummary{}},
+		{
+			name:    "mixed outcomes",
+			pageIDs: []string{"live-1", "gone-1", "live-2", "blocked-1", "gone-2"},
+			want: VerifySummary{
+				Total: 5, Present: 2, Removed: 2, Errored: 1,
+				Errors: []VerifyError{{PageID: "blocked-1", Error: "failed to fetch page: captcha"}},
+			},
+		},
+		{"all present", []string{"live-1", "live-2"}, VerifySummary{Total: 2, Present: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := verifyPages(context.Background(), tt.pageIDs, 3, verify)
+			if fmt.Sprint(*got) != fmt.Sprint(tt.want) {
+				t.Errorf("verifyPages() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyPagesConcurrencyBound(t *testing.T) {
+	tests := []struct {
+		name    string
+		pages   int
+		workers int
+		limit   int32
+	}{
+		{"bounded", 40, 4, 4},
+		{"zero workers falls back to one", 5, 0, 1},
+		{"more workers than pages", 3, 16, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pageIDs := make([]string, tt.pages)
+			for i := range pageIDs {
+				pageIDs[i] = fmt.Sprintf("p%d", i)
+			}
+
+			var running, peak, calls atomic.Int32
+			summary := verifyPages(context.Background(), pageIDs, tt.workers, func(context.Context, string) (*VerifyResult, error) {
+				calls.Add(1)
+				cur := running.Add(1)
+				for {
+					p := peak.Load()
+					if cur <= p || peak.CompareAndSwap(p, cur) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+				return &VerifyResult{Present: true}, nil
+			})
+
+			if int(calls.Load()) != tt.pages || summary.Present != tt.pages {
+				t.Errorf("calls = %d, present = %d, want %d", calls.Load(), summary.Present, tt.pages)
+			}
+			if peak.Load() > tt.limit {
+				t.Errorf("peak concurrency %d exceeds %d", peak.Load(), tt.limit)
+			}
+		})
+	}
+}
+
+func TestVerifyPagesCancelCountsRestAsErrored(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pageIDs := make([]string, 100)
+	for i := range pageIDs {
+		pageIDs[i] = fmt.Sprintf("p%d", i)
+	}
+
+	var calls atomic.Int32
+	summary := verifyPages(ctx, pageIDs, 2, func(context.Context, string) (*VerifyResult, error) {
+		if calls.Add(1) == 5 {
+			cancel()
+		}
+		time.Sleep(time.Millisecond)
+		return &VerifyResult{Present: true}, nil
+	})
+
+	if summary.Total != 100 || summary.Present+summary.Errored != 100 {
+		t.Fatalf("summary = %+v, want every page accounted for", *summary)
+	}
+	if summary.Errored == 0 || summary.Errors[0].Error != context.Canceled.Error() {
+		t.Errorf("unchecked pages should be errored with %v, got %+v", context.Canceled, summary.Errors)
+	}
+}
+
+func TestVerifyViolationsResolvesOnlyDefinitiveRemovals(t *testing.T) {
+	content := violations.ContentInfo{ID: "c1", Title: "Брат 2", KinopoiskID: "41519"}
+	all := make([]violations.Violation, 20)
+	for i := range all {
+		all[i] = violations.Violation{
+			ContentID: "c1",
+			SiteID:    "s1",
+			PageID:    fmt.Sprintf("p%d", i),
+			PageURL:   fmt.Sprintf("https://example.com/film/%d", i),
+		}
+	}
+	other := &queue.PageData{URL: "https://example.com/film/1", Title: "Другой фильм", ExternalIDs: map[string]string{"kinopoisk_id": "111"}}
+
+	tests := []struct {
+		name         string
+		parser       stubPageParser
+		wantResolved int
+		wantRemoved  int
+		wantErrored  int
+	}{
+		{
+			name:        "timeout",
+			parser:      stubPageParser{result: queue.PageResult{Error: "fetch page: context deadline exceeded"}},
+			wantErrored: 20,
+		},
+		{
+			name:        "captcha",
+			parser:      stubPageParser{result: queue.PageResult{Error: "blocked: captcha solve failed", IPBlocked: true, Captcha: true}},
+			wantErrored: 20,
+		},
+		{
+			name:        "parser unavailable",
+			parser:      stubPageParser{err: errors.New("no responders")},
+			wantErrored: 20,
+		},
+		{
+			name:         "page gone",
+			parser:       stubPageParser{result: queue.PageResult{Error: "http status 410", Gone: true}},
+			wantResolved: 20,
+			wantRemoved:  20,
+		},
+		{
+			name:         "content replaced",
+			parser:       stubPageParser{result: queue.PageResult{Success: true, Page: other}},
+			wantResolved: 20,
+			wantRemoved:  20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resolved atomic.Int32
+			resolve := func(_ context.Context, v *violations.Violation, domain string, _ *VerifyResult) error {
+				if domain != "example.com" {
+					t.Errorf("resolve(%s) domain = %q", v.PageID, domain)
+				}
+				resolved.Add(1)
+				return nil
+			}
+
+			var checked atomic.Int32
+			progress := func(_, total int) {
+				if total != len(all) {
+					t.Errorf("progress total = %d, want %d", total, len(all))
+				}
+				checked.Add(1)
+			}
+
+			summary := verifyViolations(context.Background(), tt.parser, explainByKinopoisk, content, all, map[string]*repo.Site{"s1": {Domain: "example.com"}}, 4, resolve, progress)
+
+			if int(checked.Load()) != len(all) {
+				t.Errorf("progress calls = %d, want %d", checked.Load(), len(all))
+			}
+
+			if int(resolved.Load()) != tt.wantResolved {
+				t.Errorf("resolved = %d, want %d", resolved.Load(), tt.wantResolved)
+			}
+			if summary.Removed != tt.wantRemoved || summary.Errored != tt.wantErrored || summary.Present != 0 {
+				t.Errorf("summary = %+v, want removed %d, errored %d", *summary, tt.wantRemoved, tt.wantErrored)
+			}
+		})
+	}
+}
+
+func TestVerifyViolationUsesSiteSettings(t *testing.T) {
+	content := violations.ContentInfo{ID: "c1", KinopoiskID: "41519"}
+	site := &repo.Site{
+		ID:          primitive.NewObjectID(),
+		Domain:      "example.com",
+		ScannerType: status.ScannerSPA,
+		PageWait:    &repo.PageWait{Strategy: status.PageWaitSelector, Selector: "#player"},
+		DNSOverride: &repo.DNSOverride{Hosts: map[string]string{"example.com": "93.184.215.14"}},
+	}
+	v := &violations.Violation{ContentID: "c1", SiteID: site.ID.Hex(), PageID: "p1", PageURL: "https://example.com/film/1"}
+	parser := recordingPageParser{
+		stubPageParser: stubPageParser{result: queue.PageResult{Error: "empty title"}},
+		reqs:           make(chan queue.ParseSyncRequest, 1),
+	}
+	resolve := func(context.Context, *violations.Violation, string, *VerifyResult) error {
+		t.Error("page without title must not be resolved")
+		return nil
+	}
+
+	if _, err := verifyViolation(context.Background(), parser, explainByKinopoisk, content, v, site, resolve); err == nil {
+		t.Fatal("expected fetch error for a page without title")
+	}
+
+	req := <-parser.reqs
+	if req.URL != v.PageURL || req.SiteID != site.ID.Hex() || req.ScannerType != string(status.ScannerSPA) {
+		t.Errorf("request = %+v, want site %s with SPA scanner", req, site.ID.Hex())
+	}
+	if req.PageWait == nil || req.PageWait.Selector != "#player" {
+		t.Errorf("PageWait = %+v, want selector #player", req.PageWait)
+	}
+	if req.DNSOverride == nil || req.DNSOverride.Hosts["example.com"] != "93.184.215.14" {
+		t.Errorf("DNSOverride = %+v, want site hosts", req.DNSOverride)
+	}
+}
+
+func TestSameSiteHost(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"https://example.com/film/1", "https://example.com/film/1/", true},
+		{"https://example.com/film/1", "https://www.Example.com/film-1", true},
+		{"http://example.com/film/1", "https://example.com/film/1", true},
+		{"https://example.com/film/1", "https://other.com/film/1", false},
+		{"https://example.com/film/1", "https://example.com.evil.net/film/1", false},
+		{"https://example.com/film/1", "::bad", false},
+	}
+
+	for _, tt := range tests {
+		if got := sameSiteHost(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameSiteHost(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/nats"
+	"github.com/video-analitics/indexer/internal/metrics"
+)
+
+const queueWebhookTimeout = 5 * time.Second
+
+type QueueMonitorConfig struct {
+	Interval time.Duration
+	// AlertThreshold — pending консьюмера, после которого отправляется вебхук; 0 — алерты выключены
+	AlertThreshold uint64
+	WebhookURL     string
+}
+
+// QueueSnapshot — последний снятый срез отставания консьюмеров
+type QueueSnapshot struct {
+	Consumers      []nats.ConsumerLag `json:"consumers"`
+	AlertThreshold uint64             `json:"alert_threshold,omitempty"`
+	CheckedAt      time.Time          `json:"checked_at"`
+	Error          string             `json:"error,omitempty"`
+}
+
+// QueueLagAlert — тело вебхука о превышении порога
+type QueueLagAlert struct {
+	Event      string    `json:"event"`
+	Stream     string    `json:"stream"`
+	Consumer   string    `json:"consumer"`
+	Pending    uint64    `json:"pending"`
+	AckPending int       `json:"ack_pending"`
+	Threshold  uint64    `json:"threshold"`
+	At         time.Time `json:"at"`
+}
+
+// QueueMonitor периодически снимает отставание консьюмеров JetStream,
+// отдаёт его в /metrics и /api/admin/queues и шлёт вебхук при превышении порога
+type QueueMonitor struct {
+	client     *nats.Client
+	streams    []string
+	cfg        QueueMonitorConfig
+	httpClient *http.Client
+
+	mu       sync.RWMutex
+	snapshot QueueSnapshot
+	// alerting — консьюмеры выше порога; повторный алерт только после возврата ниже порога
+	alerting map[string]bool
+}
+
+func NewQueueMonitor(client *nats.Client, streams []string, cfg QueueMonitorConfig) *QueueMonitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	return &QueueMonitor{
+		client:     client,
+		streams:    streams,
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: queueWebhookTimeout},
+		alerting:   make(map[string]bool),
+	}
+}
+
+// Run снимает срезы до отмены ctx
+func (m *QueueMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		m.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Snapshot возвращает последний срез
+func (m *QueueMonitor) Snapshot() QueueSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.snapshot
+}
+
+func (m *QueueMonitor) check(ctx context.Context) {
+	log := logger.Log
+
+	reqCtx, cancel := context.WithTimeout(ctx, m.cfg.Interval)
+	defer cancel()
+
+	lags, err := m.client.StreamsLag(reqCtx, m.streams...)
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	snapshot := QueueSnapshot{
+		Consumers:      lags,
+		AlertThreshold: m.cfg.AlertThreshold,
+		CheckedAt:      time.Now(),
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to collect queue lag")
+		snapshot.Error = err.Error()
+	}
+	if snapshot.Consumers == nil {
+		snapshot.Consumers = []nats.ConsumerLag{}
+	}
+
+	for _, lag := range lags {
+		metrics.ConsumerLag(lag)
+	}
+
+	m.mu.Lock()
+	m.snapshot = snapshot
+	var alerts []nats.ConsumerLag
+	if m.cfg.AlertThreshold > 0 {
+		alerts = lagAlerts(m.alerting, lags, m.cfg.AlertThreshold)
+	}
+	m.mu.Unlock()
+
+	for _, lag := range alerts {
+		log.Warn().
+			Str("stream", lag.Stream).
+			Str("consumer", lag.Consumer).
+			Uint64("pending", lag.Pending).
+			Uint64("threshold", m.cfg.AlertThreshold).
+			Msg("queue lag above threshold")
+
+		if m.cfg.WebhookURL == "" {
+			continue
+		}
+		if err := m.sendAlert(ctx, lag); err != nil {
+			log.Warn().Err(err).Str("consumer", lag.Consumer).Msg("failed to send queue lag webhook")
+		}
+	}
+}
+
+// lagAlerts возвращает консьюмеры, только что перешедшие порог, и обновляет alerting.
+// Консьюмер, опустившийся до порога и ниже, снова может вызвать алерт
+func lagAlerts(alerting map[string]bool, lags []nats.ConsumerLag, threshold uint64) []nats.ConsumerLag {
+	var alerts []nats.ConsumerLag
+	for _, lag := range lags {
+		key := lag.Stream + "/" + lag.Consumer
+		if lag.Pending <= threshold {
+			delete(alerting, key)
+			continue
+		}
+		if !alerting[key] {
+			alerting[key] = true
+			alerts = append(alerts, lag)
+		}
+	}
+	return alerts
+}
+
+func (m *QueueMonitor) sendAlert(ctx context.Context, lag nats.ConsumerLag) error {
+	body, err := json.Marshal(QueueLagAlert{
+		Event:      "queue_lag",
+		Stream:     lag.Stream,
+		Consumer:   lag.Consumer,
+		Pending:    lag.Pending,
+		AckPending: lag.AckPending,
+		Threshold:  m.cfg.AlertThreshold,
+		At:         time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/video-analitics/backend/pkg/nats"
+)
+
+func TestLagAlerts(t *testing.T) {
+	lag := func(consumer string, pending uint64) nats.ConsumerLag {
+		return nats.ConsumerLag{Stream: "PAGE_CRAWL_TASKS", Consumer: consumer, Pending: pending}
+	}
+
+	alerting := make(map[string]bool)
+	steps := []struct {
+		name string
+		lags []nats.ConsumerLag
+		want []nats.ConsumerLag
+	}{
+		{
+			name: "below threshold",
+			lags: []nats.ConsumerLag{lag("page-worker", 100)},
+		},
+		{
+			name: "crossing fires once",
+			lags: []nats.ConsumerLag{lag("page-worker", 101), lag("other", 5)},
+			want: []nats.ConsumerLag{lag("page-worker", 101)},
+		},
+		{
+			name: "still above stays quiet",
+			lags: []nats.ConsumerLag{lag("page-worker", 5000)},
+		},
+		{
+			name: "recovery re-arms",
+			lags: []nats.ConsumerLag{lag("page-worker", 100)},
+		},
+		{
+			name: "crossing again fires",
+			lags: []nats.ConsumerLag{lag("page-worker", 300)},
+			want: []nats.ConsumerLag{lag("page-worker", 300)},
+		},
+	}
+
+	for _, step := range steps {
+		got := lagAlerts(alerting, step.lags, 100)
+		if !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("%s: lagAlerts() = %+v, want %+v", step.name, got, step.want)
+		}
+	}
+}
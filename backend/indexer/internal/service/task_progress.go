@@ -6,6 +6,7 @@ import (
 
 	"github.com/video-analitics/backend/pkg/logger"
 	"github.com/video-analitics/backend/pkg/status"
+	"github.com/video-analitics/indexer/internal/metrics"
 	"github.com/video-analitics/indexer/internal/repo"
 )
 
@@ -78,12 +79,20 @@ func (s *TaskProgressService) CompleteSitemapStage(ctx context.Context, taskID,
 // Сам получает реальное количество URL из sitemap_urls
 func (s *TaskProgressService) CompleteSitemapStageOnly(ctx context.Context, taskID, siteID string) error {
 	total := s.getSitemapURLCount(ctx, siteID)
-	return s.taskRepo.CompleteSitemapStageOnly(ctx, taskID, total)
+	if err := s.taskRepo.CompleteSitemapStageOnly(ctx, taskID, total); err != nil {
+		return err
+	}
+	metrics.ScanTaskFinished(string(status.StageSitemap), string(status.TaskCompleted))
+	return nil
 }
 
 // FailSitemapStage помечает этап sitemap как failed
 func (s *TaskProgressService) FailSitemapStage(ctx context.Context, taskID string, sitemapResult *repo.StageResult) error {
-	return s.taskRepo.FailSitemapStage(ctx, taskID, sitemapResult)
+	if err := s.taskRepo.FailSitemapStage(ctx, taskID, sitemapResult); err != nil {
+		return err
+	}
+	metrics.ScanTaskFinished(string(status.StageSitemap), string(status.TaskFailed))
+	return nil
 }
 
 // CompletePageStage завершает этап page и всю задачу
@@ -92,7 +101,11 @@ func (s *TaskProgressService) CompletePageStage(ctx context.Context, taskID stri
 		Status: status.TaskCompleted,
 		Error:  errorMsg,
 	}
-	return s.taskRepo.CompletePageStage(ctx, taskID, pageResult)
+	if err := s.taskRepo.CompletePageStage(ctx, taskID, pageResult); err != nil {
+		return err
+	}
+	metrics.ScanTaskFinished(string(status.StagePage), string(status.TaskCompleted))
+	return nil
 }
 
 // FailPageStage помечает этап page как failed
@@ -101,6 +114,10 @@ func (s *TaskProgressService) FailPageStage(ctx context.Context, taskID string,
 		Status: status.TaskFailed,
 		Error:  errorMsg,
 	}
-	return s.taskRepo.FailPageStage(ctx, taskID, pageResult)
+	if err := s.taskRepo.FailPageStage(ctx, taskID, pageResult); err != nil {
+		return err
+	}
+	metrics.ScanTaskFinished(string(status.StagePage), string(status.TaskFailed))
+	return nil
 }
 
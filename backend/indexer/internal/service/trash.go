@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/meili"
+	"github.com/video-analitics/backend/pkg/models"
+	"github.com/video-analitics/backend/pkg/violations"
+	"github.com/video-analitics/indexer/internal/repo"
+)
+
+// DefaultTrashRetention — сколько удалённые сайты и контент можно восстановить
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+const reindexBatchSize = 1000
+
+// TrashService — мягкое удаление сайтов и контента, восстановление и окончательная очистка корзины.
+// В корзине остаются исходные данные (страницы, связи с пользователями), а производные —
+// нарушения и документы поиска — удаляются сразу и пересобираются при восстановлении
+type TrashService struct {
+	siteRepo        *repo.SiteRepo
+	pageRepo        *repo.PageRepo
+	sitemapURLRepo  *repo.SitemapURLRepo
+	taskRepo        *repo.ScanTaskRepo
+	userSiteRepo    *repo.UserSiteRepo
+	contentRepo     *repo.ContentRepo
+	userContentRepo *repo.UserContentRepo
+	violationsSvc   *violations.Service
+	meili           *meili.Client
+	retention       time.Duration
+}
+
+func NewTrashService(siteRepo *repo.SiteRepo, pageRepo *repo.PageRepo, sitemapURLRepo *repo.SitemapURLRepo, taskRepo *repo.ScanTaskRepo, userSiteRepo *repo.UserSiteRepo, contentRepo *repo.ContentRepo, userContentRepo *repo.UserContentRepo, violationsSvc *violations.Service, meiliClient *meili.Client, retention time.Duration) *TrashService {
+	if retention <= 0 {
+		retention = DefaultTrashRetention
+	}
+	return &TrashService{
+		siteRepo:        siteRepo,
+		pageRepo:        pageRepo,
+		sitemapURLRepo:  sitemapURLRepo,
+		taskRepo:        taskRepo,
+		userSiteRepo:    userSiteRepo,
+		contentRepo:     contentRepo,
+		userContentRepo: userContentRepo,
+		violationsSvc:   violationsSvc,
+		meili:           meiliClient,
+		retention:       retention,
+	}
+}
+
+// RestoreUntil — до какого момента удалённое можно восстановить
+func (s *TrashService) RestoreUntil(deletedAt time.Time) time.Time {
+	return deletedAt.Add(s.retention)
+}
+
+// TrashSite переносит сайт в корзину: сканирование останавливается, страницы пропадают
+// из поиска и нарушений; false — сайт не найден или уже удалён
+func (s *TrashService) TrashSite(ctx context.Context, siteID, userID string) (bool, error) {
+	log := logger.Log
+
+	ok, err := s.siteRepo.SoftDelete(ctx, siteID, userID)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	if _, err := s.taskRepo.CancelBySiteID(ctx, siteID); err != nil {
+		log.Warn().Err(err).Str("site_id", siteID).Msg("failed to cancel tasks of deleted site")
+	}
+	if s.meili != nil {
+		if err := s.meili.DeleteBySiteID(siteID); err != nil {
+			log.Warn().Err(err).Str("site_id", siteID).Msg("failed to delete pages from meilisearch")
+		}
+	}
+	if s.violationsSvc != nil {
+		if _, err := s.violationsSvc.DeleteBySiteID(ctx, siteID); err != nil {
+			log.Warn().Err(err).Str("site_id", siteID).Msg("failed to delete violations")
+		}
+	}
+	return true, nil
+}
+
+// RestoreSite возвращает сайт из корзины и в фоне пересобирает его поиск и нарушения
+func (s *TrashService) RestoreSite(ctx context.Context, siteID string) (bool, error) {
+	ok, err := s.siteRepo.Restore(ctx, siteID)
+	if err != nil || !ok {
+		return ok, err
+	}
+	go s.rebuildSite(context.Background(), siteID)
+	return true, nil
+}
+
+func (s *TrashService) rebuildSite(ctx context.Context, siteID string) {
+	log := logger.Log
+
+	site, err := s.siteRepo.FindByID(ctx, siteID)
+	if err != nil || site == nil {
+		log.Warn().Err(err).Str("site_id", siteID).Msg("restored site not found")
+		return
+	}
+
+	if s.meili != nil {
+		batch := make([]meili.PageDocument, 0, reindexBatchSize)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			err := s.meili.IndexPages(batch)
+			batch = batch[:0]
+			return err
+		}
+		err := s.pageRepo.ForEachBySiteID(ctx, siteID, func(p *models.Page) error {
+			batch = append(batch, meili.NewPageDocument(p, site.Domain))
+			if len(batch) >= reindexBatchSize {
+				return flush()
+			}
+			return nil
+		})
+		if err == nil {
+			err = flush()
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("site_id", siteID).Msg("failed to reindex restored site")
+			return
+		}
+		// Поиск по сайту должен увидеть все страницы до пересчёта нарушений
+		if err := s.meili.WaitForTasks(ctx); err != nil {
+			log.Warn().Err(err).Str("site_id", siteID).Msg("failed to wait for reindex")
+		}
+	}
+
+	if s.violationsSvc == nil {
+		return
+	}
+	contents, err := s.contentRepo.GetAll(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to get contents for restored site")
+		return
+	}
+	contentInfos := make([]violations.ContentInfo, len(contents))
+	for i, c := range contents {
+		contentInfos[i] = violations.ContentInfo{
+			ID:            c.ID.Hex(),
+			Title:         c.Title,
+			OriginalTitle: c.OriginalTitle,
+			Aliases:       c.Aliases,
+			Year:          c.Year,
+			KinopoiskID:   c.KinopoiskID,
+			IMDBID:        c.IMDBID,
+			MALID:         c.MALID,
+			ShikimoriID:   c.ShikimoriID,
+			MyDramaListID: c.MyDramaListID,
+			Language:      c.Language,
+			Region:        c.Region,
+		}
+	}
+	if _, err := s.violationsSvc.RefreshForSite(ctx, siteID, contentInfos); err != nil {
+		log.Warn().Err(err).Str("site_id", siteID).Msg("failed to refresh violations of restored site")
+	}
+}
+
+// TrashContent переносит контент в корзину вместе со связями пользователей; нарушения удаляются сразу
+func (s *TrashService) TrashContent(ctx context.Context, contentID, userID string) (bool, error) {
+	ok, err := s.contentRepo.SoftDelete(ctx, contentID, userID)
+	if err != nil || !ok {
+		return ok, err
+	}
+	if s.violationsSvc != nil {
+		if err := s.violationsSvc.DeleteByContentID(ctx, contentID); err != nil {
+			logger.Log.Warn().Err(err).Str("content_id", contentID).Msg("failed to delete violations")
+		}
+	}
+	return true, nil
+}
+
+// RestoreContent возвращает контент из корзины и в фоне пересчитывает его нарушения
+func (s *TrashService) RestoreContent(ctx context.Context, content *repo.Content) (bool, error) {
+	ok, err := s.contentRepo.Restore(ctx, content.ID.Hex())
+	if err != nil || !ok {
+		return ok, err
+	}
+	content.DeletedAt = nil
+	if s.violationsSvc != nil {
+		info := violations.ContentInfo{
+			ID:            content.ID.Hex(),
+			Title:         content.Title,
+			OriginalTitle: content.OriginalTitle,
+			Aliases:       content.Aliases,
+			Year:          content.Year,
+			KinopoiskID:   content.KinopoiskID,
+			IMDBID:        content.IMDBID,
+			MALID:         content.MALID,
+			ShikimoriID:   content.ShikimoriID,
+			MyDramaListID: content.MyDramaListID,
+			Language:      content.Language,
+			Region:        content.Region,
+		}
+		go s.violationsSvc.RefreshForContent(context.Background(), info, 0)
+	}
+	return true, nil
+}
+
+// PurgeExpired окончательно удаляет всё, что пролежало в корзине дольше срока хранения
+func (s *TrashService) PurgeExpired(ctx context.Context) (sites, contents int, err error) {
+	log := logger.Log
+	cutoff := time.Now().Add(-s.retention)
+
+	siteIDs, err := s.siteRepo.FindExpiredDeleted(ctx, cutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, id := range siteIDs {
+		if err := s.purgeSite(ctx, id); err != nil {
+			log.Warn().Err(err).Str("site_id", id).Msg("failed to purge site")
+			continue
+		}
+		sites++
+	}
+
+	contentIDs, err := s.contentRepo.FindExpiredDeleted(ctx, cutoff)
+	if err != nil {
+		return sites, 0, err
+	}
+	for _, id := range contentIDs {
+		if err := s.purgeContent(ctx, id); err != nil {
+			log.Warn().Err(err).Str("content_id", id).Msg("failed to purge content")
+			continue
+		}
+		contents++
+	}
+	return sites, contents, nil
+}
+
+func (s *TrashService) purgeSite(ctx context.Context, siteID string) error {
+	// Сайт удаляется последним: при ошибке он остаётся в корзине и очистка повторится
+	if _, err := s.pageRepo.DeleteBySiteID(ctx, siteID); err != nil {
+		return fmt.Errorf("delete pages: %w", err)
+	}
+	if err := s.sitemapURLRepo.DeleteBySiteID(ctx, siteID); err != nil {
+		return fmt.Errorf("delete sitemap urls: %w", err)
+	}
+	if _, err := s.taskRepo.DeleteBySiteID(ctx, siteID); err != nil {
+		return fmt.Errorf("delete tasks: %w", err)
+	}
+	if _, err := s.userSiteRepo.DeleteBySiteID(ctx, siteID); err != nil {
+		return fmt.Errorf("delete user links: %w", err)
+	}
+	// Поиск и нарушения очищены при удалении в корзину, повторяем на случай сбоя тогда
+	if s.meili != nil {
+		if err := s.meili.DeleteBySiteID(siteID); err != nil {
+			return err
+		}
+	}
+	if s.violationsSvc != nil {
+		if _, err := s.violationsSvc.DeleteBySiteID(ctx, siteID); err != nil {
+			return err
+		}
+	}
+	return s.siteRepo.Delete(ctx, siteID)
+}
+
+func (s *TrashService) purgeContent(ctx context.Context, contentID string) error {
+	content, err := s.contentRepo.FindDeletedByID(ctx, contentID)
+	if err != nil || content == nil {
+		return err
+	}
+	if s.violationsSvc != nil {
+		if err := s.violationsSvc.DeleteByContentID(ctx, contentID); err != nil {
+			return err
+		}
+		if err := s.violationsSvc.DeleteSuppressionsByContentID(ctx, contentID); err != nil {
+			return err
+		}
+		if err := s.violationsSvc.DeleteRemovalsByContentID(ctx, contentID); err != nil {
+			return err
+		}
+	}
+	if err := s.userContentRepo.DeleteByContentID(ctx, content.ID); err != nil {
+		return err
+	}
+	return s.contentRepo.Delete(ctx, contentID)
+}
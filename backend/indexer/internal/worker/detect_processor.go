@@ -10,6 +10,7 @@ import (
 	"github.com/video-analitics/backend/pkg/nats"
 	"github.com/video-analitics/backend/pkg/queue"
 	"github.com/video-analitics/backend/pkg/status"
+	"github.com/video-analitics/indexer/internal/metrics"
 	indexerQueue "github.com/video-analitics/indexer/internal/queue"
 	"github.com/video-analitics/indexer/internal/repo"
 )
@@ -45,7 +46,7 @@ func (p *DetectProcessor) Run(ctx context.Context) error {
 
 	log.Info().Msg("detect processor started")
 
-	return consumer.Consume(ctx, func(ctx context.Context, msg *nats.Message) error {
+	return consumer.Consume(ctx, metrics.ObserveHandler("detect-processor", func(ctx context.Context, msg *nats.Message) error {
 		var result queue.DetectResultMsg
 		if err := msg.Unmarshal(&result); err != nil {
 			log.Error().Err(err).Msg("failed to unmarshal detect result")
@@ -54,7 +55,7 @@ func (p *DetectProcessor) Run(ctx context.Context) error {
 
 		p.processResult(ctx, &result)
 		return nil
-	})
+	}))
 }
 
 func (p *DetectProcessor) processResult(ctx context.Context, result *queue.DetectResultMsg) {
@@ -73,6 +74,25 @@ func (p *DetectProcessor) processResult(ctx context.Context, result *queue.Detec
 		return
 	}
 
+	if result.Success && result.IsParked {
+		log.Warn().
+			Str("site", result.SiteID).
+			Str("signature", result.ParkedSignature).
+			Msg("parked domain detected, marking site dead")
+
+		if err := p.siteRepo.MarkParked(ctx, result.SiteID, result.ParkedSignature); err != nil {
+			log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to mark site as parked")
+		}
+		if p.taskRepo != nil {
+			if cancelled, err := p.taskRepo.CancelBySiteID(ctx, result.SiteID); err != nil {
+				log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to cancel tasks")
+			} else if cancelled > 0 {
+				log.Info().Int64("cancelled", cancelled).Str("site", result.SiteID).Msg("tasks cancelled for parked site")
+			}
+		}
+		return
+	}
+
 	if !result.Success {
 		// DNS errors should freeze immediately without retries
 		if p.isPermanentError(result.Error) {
@@ -81,7 +101,7 @@ func (p *DetectProcessor) processResult(ctx context.Context, result *queue.Detec
 				Str("error", result.Error).
 				Msg("permanent error detected, freezing site immediately")
 
-			if err := p.siteRepo.MarkFrozen(ctx, result.SiteID, result.Error); err != nil {
+			if err := p.siteRepo.MarkFrozen(ctx, result.SiteID, status.FreezeRepeatedTimeout, result.Error); err != nil {
 				log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to mark site as frozen")
 			}
 			// Cancel all active tasks for this site
@@ -111,7 +131,12 @@ func (p *DetectProcessor) processResult(ctx context.Context, result *queue.Detec
 				Int("retries", newFailureCount).
 				Msg("detection failed after max retries, freezing site")
 
-			if err := p.siteRepo.MarkFrozen(ctx, result.SiteID, "detection failed after 3 retries: "+result.Error); err != nil {
+			// 403 и капча при детекции — блокировка, остальное считаем недоступностью
+			reason := repo.FreezeReasonFromText(result.Error)
+			if reason == status.FreezeManual {
+				reason = status.FreezeRepeatedTimeout
+			}
+			if err := p.siteRepo.MarkFrozen(ctx, result.SiteID, reason, "detection failed after 3 retries: "+result.Error); err != nil {
 				log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to mark site as frozen")
 			}
 			// Cancel all active tasks for this site
@@ -137,34 +162,10 @@ func (p *DetectProcessor) processResult(ctx context.Context, result *queue.Detec
 		return
 	}
 
-	scannerType := status.ScannerHTTP
-	if result.NeedsSPA {
-		scannerType = status.ScannerSPA
-	}
-
-	var cookies []repo.Cookie
-	for _, c := range result.Cookies {
-		cookies = append(cookies, repo.Cookie{
-			Name:     c.Name,
-			Value:    c.Value,
-			Domain:   c.Domain,
-			Path:     c.Path,
-			Expires:  c.Expires,
-			HTTPOnly: c.HTTPOnly,
-			Secure:   c.Secure,
-		})
-	}
+	update := indexerQueue.DetectionUpdateFromResult(result)
+	scannerType := update.ScannerType
 
-	if err := p.siteRepo.UpdateFromDetection(ctx, result.SiteID, repo.DetectionUpdate{
-		CMS:           result.CMS,
-		HasSitemap:    result.HasSitemap,
-		SitemapStatus: status.SitemapStatus(result.SitemapStatus),
-		CrawlStrategy: status.CrawlStrategy(result.CrawlStrategy),
-		SitemapURLs:   result.SitemapURLs,
-		ScannerType:   scannerType,
-		CaptchaType:   result.CaptchaType,
-		Cookies:       cookies,
-	}); err != nil {
+	if err := p.siteRepo.UpdateFromDetection(ctx, result.SiteID, update); err != nil {
 		log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to update site from detection")
 		return
 	}
@@ -7,7 +7,9 @@ import (
 	"github.com/video-analitics/backend/pkg/logger"
 	"github.com/video-analitics/backend/pkg/nats"
 	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/backend/pkg/status"
 	"github.com/video-analitics/backend/pkg/violations"
+	"github.com/video-analitics/indexer/internal/metrics"
 	"github.com/video-analitics/indexer/internal/repo"
 	"github.com/video-analitics/indexer/internal/service"
 )
@@ -19,6 +21,8 @@ type PageResultProcessor struct {
 	progressSvc    *service.TaskProgressService
 	contentRepo    *repo.ContentRepo
 	violationsSvc  *violations.Service
+	// upgradeThreshold — пустых заголовков за обход для перевода на SPA; 0 — выключено
+	upgradeThreshold int
 }
 
 func NewPageResultProcessor(
@@ -39,6 +43,12 @@ func NewPageResultProcessor(
 	}
 }
 
+// SetScannerUpgradeThreshold включает перевод HTTP-сайтов на SPA-сканер,
+// когда обход даёт не меньше threshold страниц без заголовка
+func (p *PageResultProcessor) SetScannerUpgradeThreshold(threshold int) {
+	p.upgradeThreshold = threshold
+}
+
 func (p *PageResultProcessor) Run(ctx context.Context) error {
 	log := logger.Log
 
@@ -52,7 +62,7 @@ func (p *PageResultProcessor) Run(ctx context.Context) error {
 
 	log.Info().Msg("page result processor started")
 
-	return consumer.Consume(ctx, func(ctx context.Context, msg *nats.Message) error {
+	return consumer.Consume(ctx, metrics.ObserveHandler("page-result-processor", func(ctx context.Context, msg *nats.Message) error {
 		var result queue.PageCrawlResult
 		if err := msg.Unmarshal(&result); err != nil {
 			log.Error().Err(err).Msg("failed to unmarshal page crawl result")
@@ -61,7 +71,7 @@ func (p *PageResultProcessor) Run(ctx context.Context) error {
 
 		p.processResult(ctx, &result)
 		return nil
-	})
+	}))
 }
 
 func (p *PageResultProcessor) processResult(ctx context.Context, result *queue.PageCrawlResult) {
@@ -120,7 +130,12 @@ func (p *PageResultProcessor) processResult(ctx context.Context, result *queue.P
 	// Update task based on result
 	if p.progressSvc != nil {
 		if result.Success {
-			if err := p.progressSvc.CompletePageStage(ctx, result.TaskID, ""); err != nil {
+			// Обход остановлен по лимиту сайта — задача завершена, оставшиеся URL дождутся следующего скана
+			note := ""
+			if result.Capped {
+				note = "capped: max pages per scan reached"
+			}
+			if err := p.progressSvc.CompletePageStage(ctx, result.TaskID, note); err != nil {
 				log.Warn().Err(err).Str("task", result.TaskID).Msg("failed to complete page stage")
 			}
 		} else {
@@ -135,7 +150,11 @@ func (p *PageResultProcessor) processResult(ctx context.Context, result *queue.P
 	// Only mark failure if NO pages succeeded at all
 	if result.IPBlocked {
 		log.Warn().Str("site", result.SiteID).Str("reason", result.BlockReason).Msg("IP blocked, freezing site")
-		if err := p.siteRepo.MarkFrozen(ctx, result.SiteID, result.BlockReason); err != nil {
+		reason := status.FreezeIPBlocked
+		if repo.FreezeReasonFromText(result.BlockReason) == status.FreezeCaptchaUnsolved {
+			reason = status.FreezeCaptchaUnsolved
+		}
+		if err := p.siteRepo.MarkFrozen(ctx, result.SiteID, reason, result.BlockReason); err != nil {
 			log.Error().Err(err).Str("site", result.SiteID).Msg("failed to freeze site")
 		}
 		skipped, err := p.sitemapURLRepo.SkipPendingBySiteID(ctx, result.SiteID, result.BlockReason)
@@ -149,9 +168,11 @@ func (p *PageResultProcessor) processResult(ctx context.Context, result *queue.P
 			log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to mark site success")
 		}
 	} else if result.PagesTotal > 0 && result.PagesFailed == result.PagesTotal {
-		// All pages failed - mark as failure
-		if err := p.siteRepo.MarkFailure(ctx, result.SiteID, false); err != nil {
-			log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to mark site failure")
+		// All pages failed - mark as failure, unless the site was just switched to SPA scanner
+		if !p.trySwitchToSPA(ctx, result) {
+			if err := p.siteRepo.MarkFailure(ctx, result.SiteID, false); err != nil {
+				log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to mark site failure")
+			}
 		}
 	}
 
@@ -162,6 +183,7 @@ func (p *PageResultProcessor) processResult(ctx context.Context, result *queue.P
 		Int("total", result.PagesTotal).
 		Int("success_count", result.PagesSuccess).
 		Int("failed_count", result.PagesFailed).
+		Bool("capped", result.Capped).
 		Msg("page stage completed")
 
 	// Refresh violations if pages were successfully parsed
@@ -170,6 +192,46 @@ func (p *PageResultProcessor) processResult(ctx context.Context, result *queue.P
 	}
 }
 
+// trySwitchToSPA переводит HTTP-сайт на SPA-сканер, если обход упёрся в пустые заголовки,
+// и возвращает упавшие URL в очередь для повторного обхода
+func (p *PageResultProcessor) trySwitchToSPA(ctx context.Context, result *queue.PageCrawlResult) bool {
+	if p.upgradeThreshold <= 0 || result.PagesEmptyTitle == 0 {
+		return false
+	}
+
+	log := logger.Log
+
+	site, err := p.siteRepo.FindByID(ctx, result.SiteID)
+	if err != nil || site == nil {
+		log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to load site for scanner upgrade")
+		return false
+	}
+	if !shouldSwitchToSPA(site.ScannerType, result.PagesEmptyTitle, result.PagesSuccess, p.upgradeThreshold) {
+		return false
+	}
+
+	switched, err := p.siteRepo.SwitchToSPA(ctx, result.SiteID)
+	if err != nil {
+		log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to switch site to SPA scanner")
+		return false
+	}
+	if !switched {
+		return false
+	}
+
+	reset, err := p.sitemapURLRepo.ResetErrorsToPending(ctx, result.SiteID)
+	if err != nil {
+		log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to reset failed URLs after scanner upgrade")
+	}
+
+	log.Info().
+		Str("site", result.SiteID).
+		Int("empty_titles", result.PagesEmptyTitle).
+		Int64("reset", reset).
+		Msg("site switched from HTTP to SPA scanner")
+	return true
+}
+
 func (p *PageResultProcessor) refreshViolationsForSite(ctx context.Context, siteID string) {
 	if p.contentRepo == nil || p.violationsSvc == nil {
 		return
@@ -177,6 +239,14 @@ func (p *PageResultProcessor) refreshViolationsForSite(ctx context.Context, site
 
 	log := logger.Log
 
+	// Удалённому в корзину сайту нарушения не пересчитываются
+	if site, err := p.siteRepo.FindByID(ctx, siteID); err != nil || site == nil {
+		if err != nil {
+			log.Warn().Err(err).Str("site", siteID).Msg("failed to load site for violations refresh")
+		}
+		return
+	}
+
 	contents, err := p.contentRepo.GetAll(ctx)
 	if err != nil {
 		log.Warn().Err(err).Msg("failed to get contents for violations refresh")
@@ -193,12 +263,15 @@ func (p *PageResultProcessor) refreshViolationsForSite(ctx context.Context, site
 			ID:            c.ID.Hex(),
 			Title:         c.Title,
 			OriginalTitle: c.OriginalTitle,
+			Aliases:       c.Aliases,
 			Year:          c.Year,
 			KinopoiskID:   c.KinopoiskID,
 			IMDBID:        c.IMDBID,
 			MALID:         c.MALID,
 			ShikimoriID:   c.ShikimoriID,
 			MyDramaListID: c.MyDramaListID,
+			Language:      c.Language,
+			Region:        c.Region,
 		}
 	}
 
@@ -10,6 +10,8 @@ import (
 	"github.com/video-analitics/backend/pkg/models"
 	"github.com/video-analitics/backend/pkg/nats"
 	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/backend/pkg/snapshot"
+	"github.com/video-analitics/indexer/internal/metrics"
 	"github.com/video-analitics/indexer/internal/repo"
 	"github.com/video-analitics/indexer/internal/service"
 )
@@ -21,6 +23,9 @@ type PageSingleProcessor struct {
 	sitemapURLRepo *repo.SitemapURLRepo
 	progressSvc    *service.TaskProgressService
 	meili          *meili.Client
+	// ackWait — сколько NATS ждёт подтверждения результата до повторной доставки
+	ackWait   time.Duration
+	snapshots snapshot.Store
 }
 
 func NewPageSingleProcessor(
@@ -30,6 +35,7 @@ func NewPageSingleProcessor(
 	sitemapURLRepo *repo.SitemapURLRepo,
 	progressSvc *service.TaskProgressService,
 	meili *meili.Client,
+	ackWait time.Duration,
 ) *PageSingleProcessor {
 	return &PageSingleProcessor{
 		natsClient:     natsClient,
@@ -38,9 +44,15 @@ func NewPageSingleProcessor(
 		sitemapURLRepo: sitemapURLRepo,
 		progressSvc:    progressSvc,
 		meili:          meili,
+		ackWait:        ackWait,
 	}
 }
 
+// SetSnapshots включает сохранение присланного парсером HTML страниц
+func (p *PageSingleProcessor) SetSnapshots(store snapshot.Store) {
+	p.snapshots = store
+}
+
 func (p *PageSingleProcessor) Run(ctx context.Context) error {
 	log := logger.Log
 
@@ -48,7 +60,7 @@ func (p *PageSingleProcessor) Run(ctx context.Context) error {
 		Stream:        nats.StreamPageSingleResults,
 		Consumer:      "page-single-processor",
 		MaxAckPending: 50,
-		AckWait:       30 * time.Second,
+		AckWait:       p.ackWait,
 	})
 	if err != nil {
 		return fmt.Errorf("create consumer: %w", err)
@@ -56,27 +68,50 @@ func (p *PageSingleProcessor) Run(ctx context.Context) error {
 
 	log.Info().Msg("page single result processor started")
 
-	return consumer.ConsumePool(ctx, 5, func(ctx context.Context, msg *nats.Message) error {
+	return consumer.ConsumePool(ctx, 5, metrics.ObserveHandler("page-single-processor", func(ctx context.Context, msg *nats.Message) error {
 		var result queue.PageSingleResult
 		if err := msg.Unmarshal(&result); err != nil {
 			log.Error().Err(err).Msg("failed to unmarshal page single result")
 			return err
 		}
 
-		p.processResult(ctx, &result)
-		return nil
-	})
+		return p.processResult(ctx, &result)
+	}))
 }
 
-func (p *PageSingleProcessor) processResult(ctx context.Context, result *queue.PageSingleResult) {
+// processResult сохраняет и индексирует страницу. Ошибка возвращается, только когда
+// результат стоит доставить повторно
+func (p *PageSingleProcessor) processResult(ctx context.Context, result *queue.PageSingleResult) error {
 	log := logger.Log
 
+	// Сайт могли удалить в корзину, пока парсер обходил страницы: такие результаты
+	// не сохраняются, иначе они вернутся в поиск и нарушения
+	site, err := p.siteRepo.FindByID(ctx, result.SiteID)
+	if err != nil {
+		log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to load site for page result")
+		return err
+	}
+	if site == nil {
+		log.Debug().Str("site", result.SiteID).Str("url", result.URL).Msg("site missing or deleted, page result dropped")
+		return nil
+	}
+
 	if !result.Success {
 		if err := p.sitemapURLRepo.MarkError(ctx, result.SiteID, result.URL, result.Error); err != nil {
 			log.Warn().Err(err).Str("url", result.URL).Msg("failed to mark url error")
 		}
 		p.incrementProgress(ctx, result.TaskID, false)
-		return
+		return nil
+	}
+
+	// Страница не изменилась с прошлого индексирования: документ в базе и поиске актуален
+	if result.NotModified {
+		if err := p.sitemapURLRepo.MarkIndexed(ctx, result.SiteID, result.URL); err != nil {
+			log.Warn().Err(err).Str("url", result.URL).Msg("failed to mark url indexed")
+		}
+		log.Debug().Str("url", result.URL).Msg("page not modified")
+		p.incrementProgress(ctx, result.TaskID, true)
+		return nil
 	}
 
 	if result.Page == nil {
@@ -84,10 +119,11 @@ func (p *PageSingleProcessor) processResult(ctx context.Context, result *queue.P
 			log.Warn().Err(err).Str("url", result.URL).Msg("failed to mark url error")
 		}
 		p.incrementProgress(ctx, result.TaskID, false)
-		return
+		return nil
 	}
 
-	page := p.convertPageData(result.SiteID, result.Page)
+	page := service.PageFromData(result.SiteID, result.Page)
+	p.saveSnapshot(ctx, page, result.Page.HTMLSnapshot)
 
 	if err := p.pageRepo.Upsert(ctx, page); err != nil {
 		log.Warn().Err(err).Str("url", result.URL).Msg("failed to save page")
@@ -95,7 +131,7 @@ func (p *PageSingleProcessor) processResult(ctx context.Context, result *queue.P
 			log.Warn().Err(err).Str("url", result.URL).Msg("failed to mark url error")
 		}
 		p.incrementProgress(ctx, result.TaskID, false)
-		return
+		return nil
 	}
 
 	if err := p.sitemapURLRepo.MarkIndexed(ctx, result.SiteID, result.URL); err != nil {
@@ -103,31 +139,7 @@ func (p *PageSingleProcessor) processResult(ctx context.Context, result *queue.P
 	}
 
 	if p.meili != nil {
-		site, _ := p.siteRepo.FindByID(ctx, result.SiteID)
-		domain := ""
-		if site != nil {
-			domain = site.Domain
-		}
-
-		doc := meili.PageDocument{
-			ID:            page.ID.Hex(),
-			SiteID:        page.SiteID,
-			Domain:        domain,
-			URL:           page.URL,
-			Title:         page.Title,
-			Description:   page.Description,
-			MainText:      page.MainText,
-			Year:          page.Year,
-			KinopoiskID:   page.ExternalIDs.KinopoiskID,
-			IMDBID:        page.ExternalIDs.IMDBID,
-			MALID:         page.ExternalIDs.MALID,
-			ShikimoriID:   page.ExternalIDs.ShikimoriID,
-			MyDramaListID: page.ExternalIDs.MyDramaListID,
-			LinksText:     page.LinksText,
-			PlayerURLs:    []string{page.PlayerURL},
-			IndexedAt:     page.IndexedAt.Format(time.RFC3339),
-		}
-
+		doc := meili.NewPageDocument(page, site.Domain)
 		if err := p.meili.IndexPages([]meili.PageDocument{doc}); err != nil {
 			log.Warn().Err(err).Str("url", result.URL).Msg("meili indexing failed")
 		}
@@ -135,32 +147,23 @@ func (p *PageSingleProcessor) processResult(ctx context.Context, result *queue.P
 
 	log.Debug().Str("url", result.URL).Msg("page indexed")
 	p.incrementProgress(ctx, result.TaskID, true)
+	return nil
 }
 
-func (p *PageSingleProcessor) convertPageData(siteID string, pd *queue.PageData) *models.Page {
-	externalIDs := models.ExternalIDs{}
-	if pd.ExternalIDs != nil {
-		externalIDs.KinopoiskID = pd.ExternalIDs["kinopoisk_id"]
-		externalIDs.IMDBID = pd.ExternalIDs["imdb_id"]
-		externalIDs.TMDBID = pd.ExternalIDs["tmdb_id"]
-		externalIDs.MALID = pd.ExternalIDs["mal_id"]
-		externalIDs.ShikimoriID = pd.ExternalIDs["shikimori_id"]
-		externalIDs.MyDramaListID = pd.ExternalIDs["mydramalist_id"]
+// saveSnapshot сохраняет сжатый HTML и проставляет странице ссылку на него;
+// ошибка хранилища не мешает индексированию
+func (p *PageSingleProcessor) saveSnapshot(ctx context.Context, page *models.Page, data []byte) {
+	if p.snapshots == nil || len(data) == 0 {
+		return
 	}
-
-	return &models.Page{
-		SiteID:      siteID,
-		URL:         pd.URL,
-		Title:       pd.Title,
-		Description: pd.Description,
-		MainText:    pd.MainText,
-		Year:        pd.Year,
-		PlayerURL:   pd.PlayerURL,
-		LinksText:   pd.LinksText,
-		ExternalIDs: externalIDs,
-		HTTPStatus:  200,
-		IndexedAt:   time.Now(),
+	key := snapshot.Key(page.SiteID, page.URL)
+	if err := p.snapshots.Put(ctx, key, data); err != nil {
+		logger.Log.Warn().Err(err).Str("url", page.URL).Msg("failed to save html snapshot")
+		return
 	}
+	now := time.Now()
+	page.SnapshotKey = key
+	page.SnapshotAt = &now
 }
 
 func (p *PageSingleProcessor) incrementProgress(ctx context.Context, taskID string, success bool) {
@@ -7,6 +7,7 @@ import (
 	"github.com/video-analitics/backend/pkg/logger"
 	"github.com/video-analitics/backend/pkg/nats"
 	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/indexer/internal/metrics"
 	"github.com/video-analitics/indexer/internal/repo"
 )
 
@@ -35,7 +36,7 @@ func (p *ProgressProcessor) Run(ctx context.Context) error {
 
 	log.Info().Msg("progress processor started")
 
-	return consumer.Consume(ctx, func(ctx context.Context, msg *nats.Message) error {
+	return consumer.Consume(ctx, metrics.ObserveHandler("progress-processor", func(ctx context.Context, msg *nats.Message) error {
 		var progress queue.CrawlProgress
 		if err := msg.Unmarshal(&progress); err != nil {
 			log.Error().Err(err).Msg("failed to unmarshal crawl progress")
@@ -54,5 +55,5 @@ func (p *ProgressProcessor) Run(ctx context.Context) error {
 			Msg("progress updated")
 
 		return nil
-	})
+	}))
 }
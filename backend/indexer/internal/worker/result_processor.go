@@ -7,7 +7,9 @@ import (
 	"github.com/video-analitics/backend/pkg/logger"
 	"github.com/video-analitics/backend/pkg/nats"
 	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/backend/pkg/status"
 	"github.com/video-analitics/backend/pkg/violations"
+	"github.com/video-analitics/indexer/internal/metrics"
 	"github.com/video-analitics/indexer/internal/repo"
 )
 
@@ -44,7 +46,7 @@ func (p *ResultProcessor) Run(ctx context.Context) error {
 
 	log.Info().Msg("result processor started")
 
-	return consumer.Consume(ctx, func(ctx context.Context, msg *nats.Message) error {
+	return consumer.Consume(ctx, metrics.ObserveHandler("result-processor", func(ctx context.Context, msg *nats.Message) error {
 		var result queue.CrawlResult
 		if err := msg.Unmarshal(&result); err != nil {
 			log.Error().Err(err).Msg("failed to unmarshal crawl result")
@@ -53,7 +55,7 @@ func (p *ResultProcessor) Run(ctx context.Context) error {
 
 		p.processResult(ctx, &result)
 		return nil
-	})
+	}))
 }
 
 func (p *ResultProcessor) processResult(ctx context.Context, result *queue.CrawlResult) {
@@ -132,7 +134,7 @@ func (p *ResultProcessor) processResult(ctx context.Context, result *queue.Crawl
 		if result.BlockedCount > 0 {
 			reason = fmt.Sprintf("Blocked %d requests (403/429/503)", result.BlockedCount)
 		}
-		if err := p.siteRepo.MarkFrozen(ctx, result.SiteID, reason); err != nil {
+		if err := p.siteRepo.MarkFrozen(ctx, result.SiteID, status.FreezeIPBlocked, reason); err != nil {
 			log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to freeze site")
 		} else {
 			log.Warn().Str("site", result.SiteID).Str("reason", reason).Msg("site frozen due to blocking")
@@ -188,6 +190,14 @@ func (p *ResultProcessor) refreshViolationsForSite(ctx context.Context, siteID s
 
 	log := logger.Log
 
+	// Удалённому в корзину сайту нарушения не пересчитываются
+	if site, err := p.siteRepo.FindByID(ctx, siteID); err != nil || site == nil {
+		if err != nil {
+			log.Warn().Err(err).Str("site", siteID).Msg("failed to load site for violations refresh")
+		}
+		return
+	}
+
 	contents, err := p.contentRepo.GetAll(ctx)
 	if err != nil {
 		log.Warn().Err(err).Msg("failed to get contents for violations refresh")
@@ -204,12 +214,15 @@ func (p *ResultProcessor) refreshViolationsForSite(ctx context.Context, siteID s
 			ID:            c.ID.Hex(),
 			Title:         c.Title,
 			OriginalTitle: c.OriginalTitle,
+			Aliases:       c.Aliases,
 			Year:          c.Year,
 			KinopoiskID:   c.KinopoiskID,
 			IMDBID:        c.IMDBID,
 			MALID:         c.MALID,
 			ShikimoriID:   c.ShikimoriID,
 			MyDramaListID: c.MyDramaListID,
+			Language:      c.Language,
+			Region:        c.Region,
 		}
 	}
 
@@ -0,0 +1,16 @@
+package worker
+
+import "github.com/video-analitics/backend/pkg/status"
+
+// shouldSwitchToSPA решает, переводить ли сайт на SPA-сканер по итогам обхода:
+// HTTP-сканер вернул не меньше threshold страниц без заголовка и ни одной удачной —
+// вероятно, контент рендерится на клиенте. threshold <= 0 отключает переключение
+func shouldSwitchToSPA(scanner status.ScannerType, emptyTitles, success, threshold int) bool {
+	if threshold <= 0 || success > 0 {
+		return false
+	}
+	if scanner != status.ScannerHTTP && scanner != "" {
+		return false
+	}
+	return emptyTitles >= threshold
+}
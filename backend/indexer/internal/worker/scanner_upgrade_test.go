@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/video-analitics/backend/pkg/status"
+)
+
+func TestShouldSwitchToSPA(t *testing.T) {
+	tests := []struct {
+		name        string
+		scanner     status.ScannerType
+		emptyTitles int
+		success     int
+		threshold   int
+		want        bool
+	}{
+		{"run of empty titles on http", status.ScannerHTTP, 10, 0, 10, true},
+		{"more than threshold", status.ScannerHTTP, 25, 0, 10, true},
+		{"legacy site without scanner type", "", 12, 0, 10, true},
+		{"below threshold", status.ScannerHTTP, 9, 0, 10, false},
+		{"some pages succeeded", status.ScannerHTTP, 30, 1, 10, false},
+		{"already spa", status.ScannerSPA, 30, 0, 10, false},
+		{"disabled", status.ScannerHTTP, 30, 0, 0, false},
+		{"no empty titles", status.ScannerHTTP, 0, 0, 10, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shouldSwitchToSPA(tt.scanner, tt.emptyTitles, tt.success, tt.threshold)
+			if got != tt.want {
+				t.Errorf("shouldSwitchToSPA(%q, %d, %d, %d) = %v, want %v",
+					tt.scanner, tt.emptyTitles, tt.success, tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/video-analitics/backend/pkg/logger"
 	"github.com/video-analitics/backend/pkg/nats"
 	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/indexer/internal/metrics"
 	"github.com/video-analitics/indexer/internal/repo"
 )
 
@@ -35,7 +36,7 @@ func (p *SitemapBatchProcessor) Run(ctx context.Context) error {
 
 	log.Info().Msg("sitemap batch processor started")
 
-	return consumer.Consume(ctx, func(ctx context.Context, msg *nats.Message) error {
+	return consumer.Consume(ctx, metrics.ObserveHandler("sitemap-batch-processor", func(ctx context.Context, msg *nats.Message) error {
 		var batch queue.SitemapURLBatch
 		if err := msg.Unmarshal(&batch); err != nil {
 			log.Error().Err(err).Msg("failed to unmarshal sitemap url batch")
@@ -44,7 +45,7 @@ func (p *SitemapBatchProcessor) Run(ctx context.Context) error {
 
 		p.processBatch(ctx, &batch)
 		return nil
-	})
+	}))
 }
 
 func (p *SitemapBatchProcessor) processBatch(ctx context.Context, batch *queue.SitemapURLBatch) {
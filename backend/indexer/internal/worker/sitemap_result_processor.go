@@ -8,29 +8,33 @@ import (
 	"github.com/video-analitics/backend/pkg/logger"
 	"github.com/video-analitics/backend/pkg/nats"
 	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/indexer/internal/metrics"
 	indexerQueue "github.com/video-analitics/indexer/internal/queue"
 	"github.com/video-analitics/indexer/internal/repo"
 	"github.com/video-analitics/indexer/internal/service"
 )
 
 type SitemapResultProcessor struct {
-	natsClient  *nats.Client
-	siteRepo    *repo.SiteRepo
-	progressSvc *service.TaskProgressService
-	publisher   *indexerQueue.Publisher
+	natsClient     *nats.Client
+	siteRepo       *repo.SiteRepo
+	sitemapURLRepo *repo.SitemapURLRepo
+	progressSvc    *service.TaskProgressService
+	publisher      *indexerQueue.Publisher
 }
 
 func NewSitemapResultProcessor(
 	natsClient *nats.Client,
 	siteRepo *repo.SiteRepo,
+	sitemapURLRepo *repo.SitemapURLRepo,
 	progressSvc *service.TaskProgressService,
 	publisher *indexerQueue.Publisher,
 ) *SitemapResultProcessor {
 	return &SitemapResultProcessor{
-		natsClient:  natsClient,
-		siteRepo:    siteRepo,
-		progressSvc: progressSvc,
-		publisher:   publisher,
+		natsClient:     natsClient,
+		siteRepo:       siteRepo,
+		sitemapURLRepo: sitemapURLRepo,
+		progressSvc:    progressSvc,
+		publisher:      publisher,
 	}
 }
 
@@ -47,7 +51,7 @@ func (p *SitemapResultProcessor) Run(ctx context.Context) error {
 
 	log.Info().Msg("sitemap result processor started")
 
-	return consumer.Consume(ctx, func(ctx context.Context, msg *nats.Message) error {
+	return consumer.Consume(ctx, metrics.ObserveHandler("sitemap-result-processor", func(ctx context.Context, msg *nats.Message) error {
 		var result queue.SitemapCrawlResult
 		if err := msg.Unmarshal(&result); err != nil {
 			log.Error().Err(err).Msg("failed to unmarshal sitemap crawl result")
@@ -56,7 +60,7 @@ func (p *SitemapResultProcessor) Run(ctx context.Context) error {
 
 		p.processResult(ctx, &result)
 		return nil
-	})
+	}))
 }
 
 func (p *SitemapResultProcessor) processResult(ctx context.Context, result *queue.SitemapCrawlResult) {
@@ -138,6 +142,9 @@ func (p *SitemapResultProcessor) processResult(ctx context.Context, result *queu
 		log.Warn().Err(err).Str("site", result.SiteID).Msg("failed to reset site status")
 	}
 
+	// Карта сайта обновила lastmod — для delta-обхода возвращаем в очередь изменившиеся страницы
+	p.requeueChangedURLs(ctx, result.SiteID)
+
 	// Если AutoContinue=false, завершаем sitemap стадию БЕЗ старта page crawl
 	if !result.AutoContinue {
 		if p.progressSvc != nil {
@@ -194,3 +201,23 @@ func (p *SitemapResultProcessor) processResult(ctx context.Context, result *queu
 		Int("pending_urls", result.TotalURLs).
 		Msg("page crawl task published")
 }
+
+// requeueChangedURLs ставит в очередь страницы, изменившиеся с последней индексации, если у сайта включён delta-обход
+func (p *SitemapResultProcessor) requeueChangedURLs(ctx context.Context, siteID string) {
+	if p.sitemapURLRepo == nil {
+		return
+	}
+	site, err := p.siteRepo.FindByID(ctx, siteID)
+	if err != nil || site == nil || !site.DeltaCrawl {
+		return
+	}
+
+	changed, err := p.sitemapURLRepo.ResetChangedToPending(ctx, siteID)
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("site", siteID).Msg("failed to requeue changed URLs")
+		return
+	}
+	if changed > 0 {
+		logger.Log.Info().Str("site", siteID).Int64("urls", changed).Msg("delta crawl: changed URLs requeued")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/chromedp/chromedp"
+	"github.com/video-analitics/parser/internal/browser"
 )
 
 func main() {
@@ -33,7 +34,9 @@ func main() {
 
 	err := chromedp.Run(ctx,
 		chromedp.Navigate(url),
-		chromedp.Sleep(5*time.Second), // Wait for JS to execute
+		chromedp.WaitReady("body", chromedp.ByQuery),
+		// Ждём плеер, отрисованный JS; по таймауту берём страницу как есть
+		browser.WaitForSelector("iframe, video", 5*time.Second),
 		chromedp.OuterHTML("html", &html),
 	)
 
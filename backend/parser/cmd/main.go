@@ -2,14 +2,18 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/video-analitics/backend/pkg/captcha"
 	"github.com/video-analitics/backend/pkg/logger"
 	"github.com/video-analitics/backend/pkg/nats"
+	"github.com/video-analitics/backend/pkg/queue"
 	"github.com/video-analitics/parser/internal/api"
 	"github.com/video-analitics/parser/internal/browser"
 	"github.com/video-analitics/parser/internal/config"
@@ -29,7 +33,18 @@ func main() {
 
 	// Initialize global browser
 	solver := captcha.NewPirateSolver()
-	if err := browser.Init(context.Background(), solver, cfg.PageLoadDelay, cfg.MaxBrowserTabs); err != nil {
+	defaultWait := browser.WaitOptions{
+		Strategy: browser.WaitStrategy(cfg.PageWaitStrategy),
+		Delay:    cfg.PageLoadDelay,
+		Selector: cfg.PageWaitSelector,
+		Timeout:  cfg.PageWaitTimeout,
+	}
+	block := browser.ResourceBlock{
+		Enabled: cfg.BlockResources,
+		Types:   strings.Split(cfg.BlockResourceTypes, ","),
+		Domains: strings.Split(cfg.BlockResourceDomains, ","),
+	}
+	if err := browser.Init(context.Background(), solver, defaultWait, block, cfg.MaxBrowserTabs); err != nil {
 		log.Fatal().Err(err).Msg("failed to initialize browser")
 	}
 	defer browser.Close()
@@ -40,6 +55,7 @@ func main() {
 		DisableStartupMessage: true,
 		BodyLimit:             10 * 1024 * 1024,
 	})
+	api.SetFetchTimeouts(cfg.APIFetchTimeout, cfg.APIMaxFetchTimeout)
 	api.SetupRoutes(app)
 	go func() {
 		addr := ":" + cfg.HTTPPort
@@ -50,9 +66,39 @@ func main() {
 	}()
 
 	crawlWorker := worker.New(natsClient)
+	crawlWorker.SetAckWait(cfg.CrawlAckWait)
 	detectWorker := worker.NewDetectWorker(natsClient)
+	detectWorker.SetParkingSignatures(strings.Split(cfg.ParkingSignatures, ","))
+	detectWorker.SetPerDomainLimit(cfg.DetectPerDomain)
+	detectWorker.SetAckWait(cfg.DetectAckWait)
+	timeouts := worker.Timeouts{
+		SitemapInactivity: cfg.SitemapInactivityTimeout,
+		SitemapTask:       cfg.SitemapTaskTimeout,
+		PageFetch:         cfg.PageFetchTimeout,
+		PageRevalidate:    cfg.PageRevalidateTimeout,
+		PageAckWait:       cfg.PageAckWait,
+		SitemapAckWait:    cfg.SitemapAckWait,
+	}
 	sitemapWorker := worker.NewSitemapWorker(natsClient)
+	sitemapWorker.SetTimeouts(timeouts)
 	pageWorker := worker.NewPageWorker(natsClient, cfg.InternalAPIToken)
+	pageWorker.SetTimeouts(timeouts)
+	pageWorker.SetThrottleConfig(worker.ThrottleConfig{
+		MinBatch:           cfg.PageBatchMin,
+		MaxBatch:           cfg.PageBatchMax,
+		Window:             cfg.PageThrottleWindow,
+		BlockRateThreshold: cfg.PageBlockRateThreshold,
+		DelayStep:          cfg.PageThrottleDelayStep,
+		MaxDelay:           cfg.PageThrottleMaxDelay,
+	})
+	pageWorker.SetConditionalFetch(cfg.PageConditionalFetch)
+	pageWorker.SetHTMLSnapshots(cfg.PageHTMLSnapshots)
+	pageWorker.SetInternalHTTP(worker.InternalHTTPConfig{
+		MaxIdleConnsPerHost: cfg.IndexerMaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.IndexerMaxConnsPerHost,
+		IdleConnTimeout:     cfg.IndexerIdleConnTimeout,
+		HTTP2:               cfg.IndexerHTTP2,
+	})
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -71,13 +117,13 @@ func main() {
 		Msg("parser started")
 
 	go func() {
-		if err := detectWorker.Run(ctx); err != nil && err != context.Canceled {
+		if err := detectWorker.RunPool(ctx, cfg.DetectWorkers); err != nil && err != context.Canceled {
 			log.Error().Err(err).Msg("detect worker error")
 		}
 	}()
 
 	go func() {
-		if err := sitemapWorker.RunPool(ctx, 2); err != nil && err != context.Canceled {
+		if err := sitemapWorker.RunPool(ctx, cfg.SitemapWorkers); err != nil && err != context.Canceled {
 			log.Error().Err(err).Msg("sitemap worker error")
 		}
 	}()
@@ -88,6 +134,54 @@ func main() {
 		}
 	}()
 
+	// Изменение числа page-воркеров на лету (POST /api/admin/parser/concurrency в индексере)
+	unsubscribe, err := natsClient.Subscribe(nats.SubjectParserConcurrency, func(data []byte) {
+		var cmd queue.ParserConcurrencyCommand
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			log.Warn().Err(err).Msg("invalid parser concurrency command")
+			return
+		}
+		if err := pageWorker.SetConcurrency(cmd.PageWorkers); err != nil {
+			log.Warn().Err(err).Int("page_workers", cmd.PageWorkers).Msg("failed to change page worker concurrency")
+		}
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to subscribe to parser control")
+	} else {
+		defer unsubscribe()
+	}
+
+	// Синхронная детекция для POST /api/detect в индексере
+	stopDetectSync, err := natsClient.Respond(nats.SubjectDetectSync, "detect-sync", func(data []byte) any {
+		var req queue.DetectSyncRequest
+		if err := json.Unmarshal(data, &req); err != nil || req.Domain == "" {
+			return queue.DetectResultMsg{Error: "invalid detect request", FinishedAt: time.Now()}
+		}
+
+		detectCtx, cancel := context.WithTimeout(ctx, cfg.DetectSyncTimeout)
+		defer cancel()
+		return detectWorker.DetectDomain(detectCtx, req.Domain)
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to subscribe to sync detection")
+	} else {
+		defer stopDetectSync()
+	}
+
+	// Разовый парсинг URL для GET /api/parse в индексере
+	stopParseSync, err := natsClient.Respond(nats.SubjectParseSync, "parse-sync", func(data []byte) any {
+		var req queue.ParseSyncRequest
+		if err := json.Unmarshal(data, &req); err != nil || req.URL == "" {
+			return queue.PageResult{Error: "invalid parse request"}
+		}
+		return pageWorker.ParsePage(req)
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to subscribe to sync parsing")
+	} else {
+		defer stopParseSync()
+	}
+
 	if err := crawlWorker.RunPool(ctx, cfg.WorkerCount); err != nil && err != context.Canceled {
 		log.Fatal().Err(err).Msg("crawl worker error")
 	}
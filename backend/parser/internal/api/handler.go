@@ -6,24 +6,33 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/queue"
 	"github.com/video-analitics/parser/internal/browser"
+	"github.com/video-analitics/parser/internal/extractor"
 )
 
 type FetchRequest struct {
-	URL  string `json:"url" query:"url"`
-	Mode string `json:"mode" query:"mode"` // "browser" (default) or "http"
+	URL     string `json:"url" query:"url"`
+	Mode    string `json:"mode" query:"mode"`       // "browser" (default) or "http"
+	Extract bool   `json:"extract" query:"extract"` // разобрать страницу экстрактором и вернуть поля в page
+	// OmitHTML — не возвращать сырой HTML, html_length остаётся
+	OmitHTML bool `json:"omit_html" query:"omit_html"`
+	// TimeoutMs — дедлайн запроса, по умолчанию defaultFetchTimeout, не больше maxFetchTimeout
+	TimeoutMs int64 `json:"timeout_ms" query:"timeout_ms"`
 }
 
 type FetchResponse struct {
-	URL         string   `json:"url"`
-	FinalURL    string   `json:"final_url"`
-	HTML        string   `json:"html"`
-	HTMLLength  int      `json:"html_length"`
-	Blocked     bool     `json:"blocked"`
-	IsCaptcha   bool     `json:"is_captcha,omitempty"`
-	BlockReason string   `json:"block_reason,omitempty"`
-	Cookies     []Cookie `json:"cookies,omitempty"`
-	FetchTimeMs int64    `json:"fetch_time_ms"`
+	URL          string          `json:"url"`
+	FinalURL     string          `json:"final_url"`
+	HTML         string          `json:"html,omitempty"`
+	HTMLLength   int             `json:"html_length"`
+	Page         *queue.PageData `json:"page,omitempty"`
+	ExtractError string          `json:"extract_error,omitempty"`
+	Blocked      bool            `json:"blocked"`
+	IsCaptcha    bool            `json:"is_captcha,omitempty"`
+	BlockReason  string          `json:"block_reason,omitempty"`
+	Cookies      []Cookie        `json:"cookies,omitempty"`
+	FetchTimeMs  int64           `json:"fetch_time_ms"`
 }
 
 type Cookie struct {
@@ -35,16 +44,65 @@ type Cookie struct {
 	Secure   bool   `json:"secure"`
 }
 
+// fetchPage загружает страницу браузером или HTTP; подменяется в тестах
+var fetchPage = func(ctx context.Context, url, mode string) (*browser.FetchResult, error) {
+	if mode == "http" {
+		return browser.FetchPageHTTP(ctx, url)
+	}
+	return browser.Get().FetchPage(ctx, url)
+}
+
+var pageExtractor = extractor.New()
+
+var (
+	defaultFetchTimeout = 90 * time.Second
+	maxFetchTimeout     = 5 * time.Minute
+)
+
+// SetFetchTimeouts задаёт дедлайн /api/fetch по умолчанию и верхнюю границу timeout_ms;
+// неположительные значения оставляют текущие
+func SetFetchTimeouts(def, maxTimeout time.Duration) {
+	if def > 0 {
+		defaultFetchTimeout = def
+	}
+	if maxTimeout > 0 {
+		maxFetchTimeout = maxTimeout
+	}
+	if defaultFetchTimeout > maxFetchTimeout {
+		defaultFetchTimeout = maxFetchTimeout
+	}
+}
+
+// fetchTimeout переводит timeout_ms в дедлайн запроса с ограничением сверху
+func fetchTimeout(ms int64) time.Duration {
+	if ms <= 0 {
+		return defaultFetchTimeout
+	}
+	// Сравниваем до умножения: огромный timeout_ms переполняет Duration
+	if ms >= maxFetchTimeout.Milliseconds() {
+		return maxFetchTimeout
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
 func SetupRoutes(app *fiber.App) {
 	app.Get("/api/fetch", handleFetch)
 	app.Post("/api/fetch", handleFetch)
 	app.Get("/health", handleHealth)
+	app.Get("/metrics", handleMetrics)
 }
 
 func handleHealth(c *fiber.Ctx) error {
+	pool := browser.Stats()
 	return c.JSON(fiber.Map{
 		"status":  "ok",
 		"browser": browser.IsInitialized(),
+		"pool": fiber.Map{
+			"total":     pool.Total,
+			"in_use":    pool.InUse,
+			"waiting":   pool.Waiting,
+			"saturated": pool.Saturated(),
+		},
 	})
 }
 
@@ -59,26 +117,23 @@ func handleFetch(c *fiber.Ctx) error {
 	} else {
 		req.URL = c.Query("url")
 		req.Mode = c.Query("mode", "browser")
+		req.Extract = c.QueryBool("extract")
+		req.OmitHTML = c.QueryBool("omit_html")
+		req.TimeoutMs = int64(c.QueryInt("timeout_ms"))
 	}
 
 	if req.URL == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "url is required"})
 	}
 
-	log.Info().Str("url", req.URL).Str("mode", req.Mode).Msg("fetch request received")
+	timeout := fetchTimeout(req.TimeoutMs)
+	log.Info().Str("url", req.URL).Str("mode", req.Mode).Dur("timeout", timeout).Msg("fetch request received")
 
-	ctx, cancel := context.WithTimeout(c.Context(), 90*time.Second)
+	ctx, cancel := context.WithTimeout(c.Context(), timeout)
 	defer cancel()
 
 	start := time.Now()
-	var result *browser.FetchResult
-	var err error
-
-	if req.Mode == "http" {
-		result, err = browser.FetchPageHTTP(ctx, req.URL)
-	} else {
-		result, err = browser.Get().FetchPage(ctx, req.URL)
-	}
+	result, err := fetchPage(ctx, req.URL, req.Mode)
 	elapsed := time.Since(start)
 
 	if err != nil {
@@ -114,6 +169,21 @@ func handleFetch(c *fiber.Ctx) error {
 		FetchTimeMs: elapsed.Milliseconds(),
 	}
 
+	if req.Extract && !result.Blocked {
+		pageURL := result.FinalURL
+		if pageURL == "" {
+			pageURL = req.URL
+		}
+		if page, err := pageExtractor.Extract(result.HTML, pageURL, "", 200); err != nil {
+			resp.ExtractError = err.Error()
+		} else {
+			resp.Page = extractor.ToPageData(page)
+		}
+	}
+	if req.OmitHTML {
+		resp.HTML = ""
+	}
+
 	log.Info().
 		Str("url", req.URL).
 		Int("html_len", len(result.HTML)).
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/parser/internal/browser"
+)
+
+func TestHandleFetchExtract(t *testing.T) {
+	html, err := os.ReadFile("testdata/film.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origFetch := fetchPage
+	defer func() { fetchPage = origFetch }()
+	fetchPage = func(_ context.Context, url, _ string) (*browser.FetchResult, error) {
+		return &browser.FetchResult{HTML: string(html), FinalURL: url}, nil
+	}
+
+	app := fiber.New()
+	SetupRoutes(app)
+
+	tests := []struct {
+		name     string
+		query    string
+		wantPage bool
+		wantHTML bool
+	}{
+		{name: "html only by default", query: "", wantHTML: true},
+		{name: "extract with html", query: "&extract=true", wantPage: true, wantHTML: true},
+		{name: "extract without html", query: "&extract=true&omit_html=true", wantPage: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/fetch?url=https://example.com/film/brat-2"+tt.query, nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != 200 {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+
+			var body FetchResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+
+			if (body.HTML != "") != tt.wantHTML {
+				t.Errorf("html present = %v, want %v", body.HTML != "", tt.wantHTML)
+			}
+			if body.HTMLLength != len(html) {
+				t.Errorf("html_length = %d, want %d", body.HTMLLength, len(html))
+			}
+			if (body.Page != nil) != tt.wantPage {
+				t.Fatalf("page = %+v, want present: %v", body.Page, tt.wantPage)
+			}
+			if !tt.wantPage {
+				return
+			}
+
+			if body.Page.Title != "Брат 2" || body.Page.Year != 2000 {
+				t.Errorf("title/year = %q/%d, want %q/%d", body.Page.Title, body.Page.Year, "Брат 2", 2000)
+			}
+			if got := body.Page.ExternalIDs["kinopoisk_id"]; got != "41519" {
+				t.Errorf("kinopoisk_id = %q, want 41519", got)
+			}
+			if got := body.Page.ExternalIDs["imdb_id"]; got != "tt0238883" {
+				t.Errorf("imdb_id = %q, want tt0238883", got)
+			}
+			if body.Page.PlayerURL != "https://player.example.com/embed/41519" {
+				t.Errorf("player_url = %q", body.Page.PlayerURL)
+			}
+		})
+	}
+}
+
+func TestHandleFetchTimeout(t *testing.T) {
+	var got time.Duration
+	origFetch := fetchPage
+	defer func() { fetchPage = origFetch }()
+	fetchPage = func(ctx context.Context, url, _ string) (*browser.FetchResult, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			t.Fatal("fetch context has no deadline")
+		}
+		got = time.Until(deadline)
+		return &browser.FetchResult{FinalURL: url}, nil
+	}
+
+	app := fiber.New()
+	SetupRoutes(app)
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want time.Duration
+	}{
+		{"default", httptest.NewRequest("GET", "/api/fetch?url=https://example.com", nil), defaultFetchTimeout},
+		{"get override", httptest.NewRequest("GET", "/api/fetch?url=https://example.com&timeout_ms=5000", nil), 5 * time.Second},
+		{"get clamped", httptest.NewRequest("GET", "/api/fetch?url=https://example.com&timeout_ms=3600000", nil), maxFetchTimeout},
+		{"post override", jsonRequest(`{"url":"https://example.com","timeout_ms":2000}`), 2 * time.Second},
+		{"post clamped", jsonRequest(`{"url":"https://example.com","timeout_ms":3600000}`), maxFetchTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = 0
+			resp, err := app.Test(tt.req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != 200 {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+			// Дедлайн отсчитан чуть раньше вызова fetchPage
+			if got > tt.want || got < tt.want-time.Second {
+				t.Errorf("timeout = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func jsonRequest(body string) *http.Request {
+	req := httptest.NewRequest("POST", "/api/fetch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestSetFetchTimeouts(t *testing.T) {
+	defer SetFetchTimeouts(defaultFetchTimeout, maxFetchTimeout)
+
+	tests := []struct {
+		name             string
+		def, max         time.Duration
+		wantDef, wantMax time.Duration
+	}{
+		{"both set", 2 * time.Minute, 10 * time.Minute, 2 * time.Minute, 10 * time.Minute},
+		{"zero keeps current", 0, 0, 2 * time.Minute, 10 * time.Minute},
+		{"default capped by max", 20 * time.Minute, 0, 10 * time.Minute, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetFetchTimeouts(tt.def, tt.max)
+			if defaultFetchTimeout != tt.wantDef || maxFetchTimeout != tt.wantMax {
+				t.Errorf("timeouts = %v/%v, want %v/%v", defaultFetchTimeout, maxFetchTimeout, tt.wantDef, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		ms   int64
+		want time.Duration
+	}{
+		{"zero uses default", 0, defaultFetchTimeout},
+		{"within limit", 1500, 1500 * time.Millisecond},
+		{"capped by max", maxFetchTimeout.Milliseconds() + 1, maxFetchTimeout},
+		{"overflow capped by max", math.MaxInt64, maxFetchTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fetchTimeout(tt.ms); got != tt.want {
+				t.Errorf("fetchTimeout(%d) = %v, want %v", tt.ms, got, tt.want)
+			}
+		})
+	}
+}
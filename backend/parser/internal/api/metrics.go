@@ -0,0 +1,28 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/video-analitics/parser/internal/browser"
+)
+
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// handleMetrics отдаёт загруженность пула вкладок в текстовом формате Prometheus
+func handleMetrics(c *fiber.Ctx) error {
+	pool := browser.Stats()
+
+	var b strings.Builder
+	writeGauge(&b, "parser_browser_tabs_total", "Maximum number of concurrent browser tabs.", int64(pool.Total))
+	writeGauge(&b, "parser_browser_tabs_in_use", "Browser tabs currently in use.", int64(pool.InUse))
+	writeGauge(&b, "parser_browser_tab_waiters", "Fetches waiting for a free browser tab.", pool.Waiting)
+
+	c.Set(fiber.HeaderContentType, metricsContentType)
+	return c.SendString(b.String())
+}
+
+func writeGauge(b *strings.Builder, name, help string, value int64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, value)
+}
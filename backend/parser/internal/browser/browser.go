@@ -6,7 +6,6 @@ import (
 	"os"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/chromedp/chromedp"
 	"github.com/video-analitics/backend/pkg/captcha"
@@ -28,14 +27,20 @@ type GlobalBrowser struct {
 	browserCtx    context.Context
 	browserCancel context.CancelFunc
 	solver        *captcha.PirateSolver
-	semaphore     chan struct{} // limits concurrent tabs
-	pageLoadDelay time.Duration
+	tabs          *tabPool         // limits concurrent tabs
+	defaultWait   WaitOptions      // для сайтов без своей стратегии; Delay — ещё и пауза для карт сайта
+	blocker       *resourceBlocker // nil — страницы грузятся целиком
+
+	// Отдельные экземпляры Chrome для сайтов с переопределением DNS, по конфигурации переопределения
+	parentCtx   context.Context
+	remote      bool
+	dnsBrowsers *dnsBrowserCache
 }
 
 // Init initializes the global browser singleton
 // Must be called once at application startup
 // Set BROWSER_CDP_URL env to use remote browser (e.g., ws://chrome:9222)
-func Init(ctx context.Context, solver *captcha.PirateSolver, pageLoadDelay time.Duration, maxTabs int) error {
+func Init(ctx context.Context, solver *captcha.PirateSolver, defaultWait WaitOptions, block ResourceBlock, maxTabs int) error {
 	mu.Lock()
 	defer mu.Unlock()
 
@@ -43,6 +48,11 @@ func Init(ctx context.Context, solver *captcha.PirateSolver, pageLoadDelay time.
 		return fmt.Errorf("browser already initialized")
 	}
 
+	blocker, err := newResourceBlocker(block)
+	if err != nil {
+		return fmt.Errorf("resource block: %w", err)
+	}
+
 	if maxTabs < 1 {
 		maxTabs = 10
 	}
@@ -92,11 +102,18 @@ func Init(ctx context.Context, solver *captcha.PirateSolver, pageLoadDelay time.
 		browserCtx:    browserCtx,
 		browserCancel: browserCancel,
 		solver:        solver,
-		semaphore:     make(chan struct{}, maxTabs),
-		pageLoadDelay: pageLoadDelay,
+		tabs:          newTabPool(maxTabs),
+		defaultWait:   defaultWait,
+		blocker:       blocker,
+		parentCtx:     ctx,
+		remote:        cdpURL != "",
+	}
+	global.dnsBrowsers = newDNSBrowserCache(global.startDNSBrowser)
+	if !global.remote {
+		go global.dnsBrowsers.runJanitor(ctx)
 	}
 
-	logger.Log.Info().Int("max_tabs", maxTabs).Dur("page_load_delay", pageLoadDelay).Msg("global browser initialized")
+	logger.Log.Info().Int("max_tabs", maxTabs).Str("wait_strategy", string(defaultWait.Strategy)).Dur("page_load_delay", defaultWait.Delay).Bool("block_resources", blocker != nil).Msg("global browser initialized")
 	return nil
 }
 
@@ -128,6 +145,7 @@ func Close() {
 		return
 	}
 
+	global.dnsBrowsers.closeAll()
 	if global.browserCancel != nil {
 		global.browserCancel()
 	}
@@ -149,17 +167,12 @@ func (b *GlobalBrowser) Solver() *captcha.PirateSolver {
 	return b.solver
 }
 
-// AcquireWithContext acquires a slot in the semaphore, respecting context cancellation
+// AcquireWithContext acquires a tab slot, respecting context cancellation
 func (b *GlobalBrowser) AcquireWithContext(ctx context.Context) error {
-	select {
-	case b.semaphore <- struct{}{}:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
-	}
+	return b.tabs.acquire(ctx)
 }
 
-// Release releases a slot in the semaphore
+// Release releases a tab slot
 func (b *GlobalBrowser) Release() {
-	<-b.semaphore
+	b.tabs.release()
 }
@@ -0,0 +1,103 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// Validators — ETag и Last-Modified из прошлого ответа страницы
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// Revalidation — итог условного запроса
+type Revalidation struct {
+	// NotModified — сервер ответил 304, рендерить страницу заново не нужно
+	NotModified bool
+	// Validators — актуальные валидаторы для сохранения вместе со страницей
+	Validators Validators
+}
+
+// RevalidateHTTP отправляет HEAD с If-None-Match/If-Modified-Since. Без сохранённых валидаторов
+// запрос просто узнаёт текущие. Любой ответ кроме 304 означает полную загрузку страницы
+func RevalidateHTTP(ctx context.Context, url string, stored Validators) (Revalidation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return Revalidation{}, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	setConditionalHeaders(req.Header, stored)
+
+	client := httpClient
+	if o := dnsOverrideFrom(ctx); !o.IsZero() {
+		client = &http.Client{
+			Timeout:   httpClient.Timeout,
+			Jar:       httpClient.Jar,
+			Transport: o.transport(httpClient.Transport.(*http.Transport)),
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Revalidation{}, fmt.Errorf("http request: %w", err)
+	}
+	resp.Body.Close()
+
+	return revalidationFromResponse(resp.StatusCode, resp.Header, stored), nil
+}
+
+func setConditionalHeaders(h http.Header, v Validators) {
+	if v.ETag != "" {
+		h.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		h.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+// validatorsFromHeaders достаёт валидаторы из заголовков ответа браузера; имена в них
+// приходят в регистре сервера (в HTTP/2 — строчными)
+func validatorsFromHeaders(h network.Headers) Validators {
+	var v Validators
+	for name, value := range h {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.EqualFold(name, "ETag"):
+			v.ETag = s
+		case strings.EqualFold(name, "Last-Modified"):
+			v.LastModified = s
+		}
+	}
+	return v
+}
+
+// revalidationFromResponse решает, можно ли пропустить рендер. 304 без валидаторов
+// в ответе оставляет сохранённые; ошибки и блокировки валидаторов не дают
+func revalidationFromResponse(status int, h http.Header, stored Validators) Revalidation {
+	current := Validators{ETag: h.Get("ETag"), LastModified: h.Get("Last-Modified")}
+
+	switch {
+	case status == http.StatusNotModified && !stored.IsZero():
+		if current.IsZero() {
+			current = stored
+		}
+		return Revalidation{NotModified: true, Validators: current}
+	case status >= 200 && status < 300:
+		return Revalidation{Validators: current}
+	default:
+		return Revalidation{}
+	}
+}
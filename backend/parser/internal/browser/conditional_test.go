@@ -0,0 +1,138 @@
+package browser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestRevalidationFromResponse(t *testing.T) {
+	stored := Validators{ETag: `"v1"`, LastModified: "Mon, 06 Oct 2025 10:00:00 GMT"}
+	fresh := http.Header{}
+	fresh.Set("ETag", `"v2"`)
+
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		stored Validators
+		want   Revalidation
+	}{
+		{
+			name:   "304 keeps stored validators",
+			status: http.StatusNotModified,
+			header: http.Header{},
+			stored: stored,
+			want:   Revalidation{NotModified: true, Validators: stored},
+		},
+		{
+			name:   "304 with new validators",
+			status: http.StatusNotModified,
+			header: fresh,
+			stored: stored,
+			want:   Revalidation{NotModified: true, Validators: Validators{ETag: `"v2"`}},
+		},
+		{
+			name:   "304 without stored validators is not trusted",
+			status: http.StatusNotModified,
+			header: fresh,
+			want:   Revalidation{},
+		},
+		{
+			name:   "200 means full fetch with current validators",
+			status: http.StatusOK,
+			header: fresh,
+			stored: stored,
+			want:   Revalidation{Validators: Validators{ETag: `"v2"`}},
+		},
+		{
+			name:   "error status gives nothing",
+			status: http.StatusForbidden,
+			header: fresh,
+			stored: stored,
+			want:   Revalidation{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := revalidationFromResponse(tt.status, tt.header, tt.stored); got != tt.want {
+				t.Errorf("revalidationFromResponse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevalidateHTTP(t *testing.T) {
+	const etag = `"abc"`
+	honoring := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer honoring.Close()
+
+	ignoring := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ignoring.Close()
+
+	ctx := context.Background()
+	stored := Validators{ETag: etag}
+
+	got, err := RevalidateHTTP(ctx, honoring.URL, stored)
+	if err != nil {
+		t.Fatalf("RevalidateHTTP: %v", err)
+	}
+	if !got.NotModified {
+		t.Errorf("server honoring If-None-Match: NotModified = false, want true")
+	}
+
+	got, err = RevalidateHTTP(ctx, honoring.URL, Validators{})
+	if err != nil {
+		t.Fatalf("RevalidateHTTP: %v", err)
+	}
+	if got.NotModified || got.Validators.ETag != etag {
+		t.Errorf("first fetch = %+v, want validators learned without 304", got)
+	}
+
+	got, err = RevalidateHTTP(ctx, ignoring.URL, stored)
+	if err != nil {
+		t.Fatalf("RevalidateHTTP: %v", err)
+	}
+	if got.NotModified {
+		t.Errorf("server ignoring conditionals: NotModified = true, want full fetch")
+	}
+}
+
+func TestValidatorsFromHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers network.Headers
+		want    Validators
+	}{
+		{"none", network.Headers{"Content-Type": "text/html"}, Validators{}},
+		{
+			name:    "http/1.1 casing",
+			headers: network.Headers{"ETag": `"v1"`, "Last-Modified": "Mon, 06 Oct 2025 10:00:00 GMT"},
+			want:    Validators{ETag: `"v1"`, LastModified: "Mon, 06 Oct 2025 10:00:00 GMT"},
+		},
+		{"http/2 lowercase", network.Headers{"etag": `W/"v2"`}, Validators{ETag: `W/"v2"`}},
+		{"non-string value", network.Headers{"etag": 42}, Validators{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validatorsFromHeaders(tt.headers); got != tt.want {
+				t.Errorf("validatorsFromHeaders() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	cdpopts "github.com/video-analitics/backend/pkg/chromedp"
+	"github.com/video-analitics/backend/pkg/logger"
+)
+
+// maxDNSBrowsers — сколько отдельных Chrome с --host-resolver-rules держится одновременно
+const maxDNSBrowsers = 8
+
+const (
+	// dnsBrowserIdleTTL — через сколько простоя закрывается Chrome с переопределением DNS
+	dnsBrowserIdleTTL = 10 * time.Minute
+	// dnsBrowserMaxAge — после этого свободный экземпляр перезапускается, чтобы адреса резолвера обновились
+	dnsBrowserMaxAge = time.Hour
+)
+
+const dnsLookupTimeout = 10 * time.Second
+
+// DNSOverride — резолвинг доменов сайта в обход системного DNS:
+// фиксированные IP для хостов и/или свой DNS-сервер для остальных
+type DNSOverride struct {
+	Resolver string            // host:port DNS-сервера; пусто — системный резолвер
+	Hosts    map[string]string // хост → IPv4/IPv6
+}
+
+// ParseDNSOverride проверяет и нормализует переопределение: IP должны быть валидны,
+// резолвер — IP с необязательным портом (по умолчанию 53)
+func ParseDNSOverride(resolver string, hosts map[string]string) (DNSOverride, error) {
+	var o DNSOverride
+
+	if resolver = strings.TrimSpace(resolver); resolver != "" {
+		host, port, err := net.SplitHostPort(resolver)
+		if err != nil {
+			host, port = strings.Trim(resolver, "[]"), "53"
+		}
+		if net.ParseIP(host) == nil {
+			return DNSOverride{}, fmt.Errorf("invalid resolver %q", resolver)
+		}
+		o.Resolver = net.JoinHostPort(host, port)
+	}
+
+	for host, ip := range hosts {
+		host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+		parsed := net.ParseIP(strings.Trim(strings.TrimSpace(ip), "[]"))
+		if host == "" || parsed == nil {
+			return DNSOverride{}, fmt.Errorf("invalid host override %q → %q", host, ip)
+		}
+		if o.Hosts == nil {
+			o.Hosts = make(map[string]string, len(hosts))
+		}
+		o.Hosts[host] = parsed.String()
+	}
+
+	return o, nil
+}
+
+func (o DNSOverride) IsZero() bool {
+	return o.Resolver == "" && len(o.Hosts) == 0
+}
+
+type dnsOverrideKey struct{}
+
+// WithDNSOverride включает переопределение DNS для всех загрузок с этим контекстом
+// (браузер, HTTP-фетчер, HTTP-фолбэк карт сайта)
+func WithDNSOverride(ctx context.Context, o DNSOverride) context.Context {
+	if o.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, dnsOverrideKey{}, o)
+}
+
+func dnsOverrideFrom(ctx context.Context) DNSOverride {
+	o, _ := ctx.Value(dnsOverrideKey{}).(DNSOverride)
+	return o
+}
+
+func (o DNSOverride) resolver() *net.Resolver {
+	if o.Resolver == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, o.Resolver)
+		},
+	}
+}
+
+// lookup возвращает IP хоста: из Hosts или через резолвер
+func (o DNSOverride) lookup(ctx context.Context, host string) (string, error) {
+	if ip, ok := o.Hosts[strings.ToLower(host)]; ok {
+		return ip, nil
+	}
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		return ip.String(), nil
+	}
+	ips, err := o.resolver().LookupIP(ctx, "ip", host)
+	if err != nil {
+		return "", err
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("no addresses for %s", host)
+	}
+	return ips[0].String(), nil
+}
+
+func (o DNSOverride) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ip, err := o.lookup(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", host, err)
+		}
+		return dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+	}
+}
+
+// transport — копия base, соединения которой идут на адреса из переопределения
+func (o DNSOverride) transport(base *http.Transport) *http.Transport {
+	t := base.Clone()
+	t.DialContext = o.dialContext(&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second})
+	return t
+}
+
+// HTTPTransport — транспорт с переопределением DNS из ctx; nil, если переопределения нет
+func HTTPTransport(ctx context.Context) http.RoundTripper {
+	o := dnsOverrideFrom(ctx)
+	if o.IsZero() {
+		return nil
+	}
+	return o.transport(http.DefaultTransport.(*http.Transport))
+}
+
+// hostResolverRules строит значение флага Chrome --host-resolver-rules:
+// все хосты из Hosts плюс хост страницы, разрешённый через резолвер
+func (o DNSOverride) hostResolverRules(ctx context.Context, pageURL string) (string, error) {
+	ips := make(map[string]string, len(o.Hosts)+1)
+	for host, ip := range o.Hosts {
+		ips[host] = ip
+	}
+
+	if o.Resolver != "" {
+		u, err := url.Parse(pageURL)
+		if err != nil {
+			return "", err
+		}
+		if host := strings.ToLower(u.Hostname()); host != "" {
+			if _, ok := ips[host]; !ok {
+				lookupCtx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+				defer cancel()
+				ip, err := o.lookup(lookupCtx, host)
+				if err != nil {
+					return "", fmt.Errorf("resolve %s: %w", host, err)
+				}
+				ips[host] = ip
+			}
+		}
+	}
+
+	rules := make([]string, 0, len(ips))
+	for host, ip := range ips {
+		if strings.Contains(ip, ":") {
+			ip = "[" + ip + "]"
+		}
+		rules = append(rules, "MAP "+host+" "+ip)
+	}
+	sort.Strings(rules)
+	return strings.Join(rules, ", "), nil
+}
+
+// cacheKey — ключ экземпляра Chrome: сама конфигурация переопределения, а не разрешённые IP,
+// чтобы DNS с ротацией адресов не плодил браузеры. Хост страницы входит в ключ, только если
+// его адрес берётся у резолвера
+func (o DNSOverride) cacheKey(pageURL string) string {
+	parts := make([]string, 0, len(o.Hosts)+2)
+	for host, ip := range o.Hosts {
+		parts = append(parts, host+"="+ip)
+	}
+	sort.Strings(parts)
+	if o.Resolver != "" {
+		parts = append(parts, "resolver="+o.Resolver)
+		if u, err := url.Parse(pageURL); err == nil {
+			if host := strings.ToLower(u.Hostname()); host != "" {
+				if _, ok := o.Hosts[host]; !ok {
+					parts = append(parts, "page="+host)
+				}
+			}
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// tabParent возвращает браузер, в котором открывать вкладку для pageURL, и release, который
+// вызывается после закрытия вкладки. С переопределением DNS это отдельный локальный Chrome
+// с --host-resolver-rules: правила задаются только при запуске, поэтому на каждую конфигурацию — свой экземпляр
+func (b *GlobalBrowser) tabParent(ctx context.Context, pageURL string) (context.Context, func(), error) {
+	o := dnsOverrideFrom(ctx)
+	if o.IsZero() {
+		return b.browserCtx, func() {}, nil
+	}
+	if b.remote {
+		return nil, nil, fmt.Errorf("dns override is not supported with remote browser")
+	}
+
+	// Переопределять нечего: хостов нет, а у адреса нет хоста для резолвера
+	if u, err := url.Parse(pageURL); len(o.Hosts) == 0 && (err != nil || u.Hostname() == "") {
+		return b.browserCtx, func() {}, nil
+	}
+
+	return b.dnsBrowsers.acquire(o.cacheKey(pageURL), func() (string, error) {
+		return o.hostResolverRules(ctx, pageURL)
+	})
+}
+
+// startDNSBrowser запускает локальный Chrome с правилами резолвинга
+func (b *GlobalBrowser) startDNSBrowser(rules string) (*dnsBrowser, error) {
+	opts := append(cdpopts.GetExecAllocatorOptions(), chromedp.Flag("host-resolver-rules", rules))
+	allocCtx, allocCancel := chromedp.NewExecAllocator(b.parentCtx, opts...)
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+	if err := chromedp.Run(browserCtx); err != nil {
+		browserCancel()
+		allocCancel()
+		return nil, fmt.Errorf("start dns override browser: %w", err)
+	}
+
+	logger.Log.Info().Str("rules", rules).Msg("started browser with dns override")
+	return &dnsBrowser{
+		browserCtx: browserCtx,
+		cancel: func() {
+			browserCancel()
+			allocCancel()
+		},
+	}, nil
+}
+
+type dnsBrowser struct {
+	browserCtx context.Context
+	cancel     context.CancelFunc
+	// inUse — открытые сейчас вкладки; занятый экземпляр не закрывается
+	inUse     int
+	startedAt time.Time
+	lastUsed  time.Time
+}
+
+// dnsBrowserCache держит не больше max экземпляров Chrome с переопределением DNS. Простаивающий
+// дольше idleTTL или живущий дольше maxAge свободный экземпляр закрывается (адреса по резолверу
+// разрешаются заново при следующем запуске); при заполнении вытесняется давно не использованный свободный
+type dnsBrowserCache struct {
+	mu      sync.Mutex
+	max     int
+	idleTTL time.Duration
+	maxAge  time.Duration
+	entries map[string]*dnsBrowser
+	start   func(rules string) (*dnsBrowser, error)
+	now     func() time.Time
+}
+
+func newDNSBrowserCache(start func(rules string) (*dnsBrowser, error)) *dnsBrowserCache {
+	return &dnsBrowserCache{
+		max:     maxDNSBrowsers,
+		idleTTL: dnsBrowserIdleTTL,
+		maxAge:  dnsBrowserMaxAge,
+		entries: make(map[string]*dnsBrowser),
+		start:   start,
+		now:     time.Now,
+	}
+}
+
+// acquire возвращает браузер для key и release; при промахе строит правила и запускает новый экземпляр
+func (c *dnsBrowserCache) acquire(key string, rules func() (string, error)) (context.Context, func(), error) {
+	if ctx, release, ok := c.lookup(key); ok {
+		return ctx, release, nil
+	}
+
+	// Резолвинг — без блокировки: он может занять до dnsLookupTimeout
+	r, err := rules()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if inst, ok := c.entries[key]; ok {
+		return inst.browserCtx, c.use(inst), nil
+	}
+	c.sweepLocked()
+	if len(c.entries) >= c.max && !c.evictLRULocked() {
+		return nil, nil, fmt.Errorf("all dns override browsers are busy (max %d)", c.max)
+	}
+
+	inst, err := c.start(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	inst.startedAt = c.now()
+	c.entries[key] = inst
+	return inst.browserCtx, c.use(inst), nil
+}
+
+func (c *dnsBrowserCache) lookup(key string) (context.Context, func(), bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweepLocked()
+	inst, ok := c.entries[key]
+	if !ok {
+		return nil, nil, false
+	}
+	return inst.browserCtx, c.use(inst), true
+}
+
+// use отмечает вкладку в экземпляре; вызывается под mu
+func (c *dnsBrowserCache) use(inst *dnsBrowser) func() {
+	inst.inUse++
+	inst.lastUsed = c.now()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			inst.inUse--
+			inst.lastUsed = c.now()
+		})
+	}
+}
+
+// sweep закрывает простаивающие и устаревшие экземпляры
+func (c *dnsBrowserCache) sweep() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweepLocked()
+}
+
+func (c *dnsBrowserCache) sweepLocked() {
+	now := c.now()
+	for key, inst := range c.entries {
+		if inst.inUse > 0 {
+			continue
+		}
+		if now.Sub(inst.lastUsed) >= c.idleTTL || now.Sub(inst.startedAt) >= c.maxAge {
+			inst.cancel()
+			delete(c.entries, key)
+		}
+	}
+}
+
+// evictLRULocked закрывает давно не использованный свободный экземпляр; false — все заняты
+func (c *dnsBrowserCache) evictLRULocked() bool {
+	var oldestKey string
+	var oldest *dnsBrowser
+	for key, inst := range c.entries {
+		if inst.inUse == 0 && (oldest == nil || inst.lastUsed.Before(oldest.lastUsed)) {
+			oldestKey, oldest = key, inst
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	oldest.cancel()
+	delete(c.entries, oldestKey)
+	return true
+}
+
+// runJanitor периодически закрывает простаивающие экземпляры, пока жив ctx
+func (c *dnsBrowserCache) runJanitor(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.sweep()
+		}
+	}
+}
+
+func (c *dnsBrowserCache) closeAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, inst := range c.entries {
+		inst.cancel()
+		delete(c.entries, key)
+	}
+}
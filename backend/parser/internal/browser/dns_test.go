@@ -0,0 +1,196 @@
+package browser
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDNSOverride(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver string
+		hosts    map[string]string
+		want     DNSOverride
+		wantErr  bool
+	}{
+		{name: "empty", want: DNSOverride{}},
+		{name: "resolver default port", resolver: "1.1.1.1", want: DNSOverride{Resolver: "1.1.1.1:53"}},
+		{name: "resolver with port", resolver: "8.8.8.8:5353", want: DNSOverride{Resolver: "8.8.8.8:5353"}},
+		{name: "ipv6 resolver", resolver: "2606:4700::1111", want: DNSOverride{Resolver: "[2606:4700::1111]:53"}},
+		{name: "ipv6 resolver with port", resolver: "[2606:4700::1111]:853", want: DNSOverride{Resolver: "[2606:4700::1111]:853"}},
+		{name: "resolver hostname", resolver: "dns.google", wantErr: true},
+		{
+			name:  "hosts normalized",
+			hosts: map[string]string{" Mirror.Example.COM. ": "10.0.0.1", "v6.example.com": "[2001:db8::0001]"},
+			want:  DNSOverride{Hosts: map[string]string{"mirror.example.com": "10.0.0.1", "v6.example.com": "2001:db8::1"}},
+		},
+		{name: "invalid ip", hosts: map[string]string{"example.com": "10.0.0"}, wantErr: true},
+		{name: "empty host", hosts: map[string]string{"": "10.0.0.1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDNSOverride(tt.resolver, tt.hosts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostResolverRules(t *testing.T) {
+	o := DNSOverride{Hosts: map[string]string{
+		"www.example.com": "2001:db8::1",
+		"example.com":     "10.0.0.1",
+	}}
+	got, err := o.hostResolverRules(context.Background(), "https://example.com/film/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "MAP example.com 10.0.0.1, MAP www.example.com [2001:db8::1]"; got != want {
+		t.Errorf("rules = %q, want %q", got, want)
+	}
+}
+
+func TestDNSOverrideDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	o := DNSOverride{Hosts: map[string]string{"pinned.invalid": "127.0.0.1"}}
+
+	conn, err := o.dialContext(&net.Dialer{})(context.Background(), "tcp", net.JoinHostPort("Pinned.invalid", port))
+	if err != nil {
+		t.Fatalf("dial pinned host: %v", err)
+	}
+	conn.Close()
+}
+
+func TestDNSOverrideCacheKey(t *testing.T) {
+	pinned := DNSOverride{Hosts: map[string]string{"example.com": "10.0.0.1", "www.example.com": "10.0.0.2"}}
+	if pinned.cacheKey("https://example.com/a") != pinned.cacheKey("https://www.example.com/b") {
+		t.Error("pinned hosts should share one browser regardless of page host")
+	}
+
+	resolved := DNSOverride{Resolver: "1.1.1.1:53"}
+	if resolved.cacheKey("https://example.com/a") != resolved.cacheKey("https://EXAMPLE.com/b") {
+		t.Error("same page host through resolver should share one browser")
+	}
+	if resolved.cacheKey("https://example.com/a") == resolved.cacheKey("https://mirror.example.net/a") {
+		t.Error("different hosts through resolver need separate browsers")
+	}
+}
+
+// fakeDNSBrowsers — кэш без Chrome: start считает запуски, cancel — закрытия
+type fakeDNSBrowsers struct {
+	cache   *dnsBrowserCache
+	now     time.Time
+	started int
+	closed  int
+}
+
+func newFakeDNSBrowsers(max int) *fakeDNSBrowsers {
+	f := &fakeDNSBrowsers{now: time.Unix(0, 0)}
+	f.cache = newDNSBrowserCache(func(string) (*dnsBrowser, error) {
+		f.started++
+		return &dnsBrowser{browserCtx: context.Background(), cancel: func() { f.closed++ }}, nil
+	})
+	f.cache.max = max
+	f.cache.now = func() time.Time { return f.now }
+	return f
+}
+
+func (f *fakeDNSBrowsers) acquire(t *testing.T, key string) func() {
+	t.Helper()
+	_, release, err := f.cache.acquire(key, func() (string, error) { return "MAP " + key + " 10.0.0.1", nil })
+	if err != nil {
+		t.Fatalf("acquire(%s): %v", key, err)
+	}
+	return release
+}
+
+func TestDNSBrowserCacheReusesByKey(t *testing.T) {
+	f := newFakeDNSBrowsers(2)
+	lookups := 0
+	for i := 0; i < 5; i++ {
+		_, release, err := f.cache.acquire("site-a", func() (string, error) {
+			lookups++
+			return "MAP example.com 10.0.0." + string(rune('1'+i)), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	if f.started != 1 || lookups != 1 {
+		t.Errorf("started = %d, lookups = %d, want one browser for a rotating resolver", f.started, lookups)
+	}
+}
+
+func TestDNSBrowserCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	f := newFakeDNSBrowsers(2)
+
+	f.acquire(t, "a")()
+	f.now = f.now.Add(time.Second)
+	f.acquire(t, "b")()
+	f.now = f.now.Add(time.Second)
+	f.acquire(t, "a")()
+	f.now = f.now.Add(time.Second)
+
+	f.acquire(t, "c")()
+	if f.started != 3 || f.closed != 1 {
+		t.Fatalf("started = %d, closed = %d, want b evicted for c", f.started, f.closed)
+	}
+	if _, ok := f.cache.entries["b"]; ok {
+		t.Error("least recently used browser b should be evicted")
+	}
+}
+
+func TestDNSBrowserCacheBusyBrowsersAreKept(t *testing.T) {
+	f := newFakeDNSBrowsers(1)
+
+	release := f.acquire(t, "a")
+	if _, _, err := f.cache.acquire("b", func() (string, error) { return "MAP b 10.0.0.1", nil }); err == nil {
+		t.Fatal("acquire should fail while the only browser has an open tab")
+	}
+	if f.closed != 0 {
+		t.Fatal("busy browser must not be closed")
+	}
+
+	release()
+	f.acquire(t, "b")()
+	if f.closed != 1 {
+		t.Errorf("closed = %d, want a evicted once free", f.closed)
+	}
+}
+
+func TestDNSBrowserCacheSweepsIdle(t *testing.T) {
+	f := newFakeDNSBrowsers(4)
+
+	f.acquire(t, "idle")()
+	busy := f.acquire(t, "busy")
+	defer busy()
+
+	f.now = f.now.Add(dnsBrowserIdleTTL)
+	f.cache.sweep()
+	if _, ok := f.cache.entries["idle"]; ok {
+		t.Error("idle browser should be closed")
+	}
+	if _, ok := f.cache.entries["busy"]; !ok {
+		t.Error("browser with open tabs should be kept")
+	}
+}
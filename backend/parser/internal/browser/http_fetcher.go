@@ -54,7 +54,16 @@ func FetchPageHTTP(ctx context.Context, url string) (*FetchResult, error) {
 	req.Header.Set("Upgrade-Insecure-Requests", "1")
 	req.Header.Set("DNT", "1")
 
-	resp, err := httpClient.Do(req)
+	client := httpClient
+	if o := dnsOverrideFrom(ctx); !o.IsZero() {
+		client = &http.Client{
+			Timeout:   httpClient.Timeout,
+			Jar:       httpClient.Jar,
+			Transport: o.transport(httpClient.Transport.(*http.Transport)),
+		}
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("http request: %w", err)
 	}
@@ -90,5 +99,6 @@ func FetchPageHTTP(ctx context.Context, url string) (*FetchResult, error) {
 		IsCaptcha:   blockResult.IsCaptcha,
 		BlockReason: blockResult.Reason,
 		Cookies:     cookies,
+		StatusCode:  resp.StatusCode,
 	}, nil
 }
@@ -0,0 +1,70 @@
+package browser
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// PoolStats — загруженность пула вкладок
+type PoolStats struct {
+	Total   int   `json:"total"`
+	InUse   int   `json:"in_use"`
+	Waiting int64 `json:"waiting"`
+}
+
+// Saturated — все вкладки заняты или кто-то ждёт слот
+func (s PoolStats) Saturated() bool {
+	return s.Total > 0 && (s.InUse >= s.Total || s.Waiting > 0)
+}
+
+// tabPool ограничивает число одновременных вкладок и считает ожидающих
+type tabPool struct {
+	slots   chan struct{}
+	waiting atomic.Int64
+}
+
+func newTabPool(size int) *tabPool {
+	return &tabPool{slots: make(chan struct{}, size)}
+}
+
+func (p *tabPool) acquire(ctx context.Context) error {
+	// Свободный слот берём сразу, не попадая в счётчик ожидающих
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	p.waiting.Add(1)
+	defer p.waiting.Add(-1)
+
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *tabPool) release() {
+	<-p.slots
+}
+
+func (p *tabPool) stats() PoolStats {
+	return PoolStats{
+		Total:   cap(p.slots),
+		InUse:   len(p.slots),
+		Waiting: p.waiting.Load(),
+	}
+}
+
+// Stats возвращает загруженность пула; нули, если браузер не инициализирован
+func Stats() PoolStats {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if global == nil {
+		return PoolStats{}
+	}
+	return global.tabs.stats()
+}
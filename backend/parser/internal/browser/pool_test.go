@@ -0,0 +1,65 @@
+package browser
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func waitForWaiters(t *testing.T, p *tabPool, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for p.stats().Waiting != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("waiting = %d, want %d", p.stats().Waiting, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTabPoolStats(t *testing.T) {
+	p := newTabPool(2)
+	ctx := context.Background()
+
+	if got := p.stats(); got != (PoolStats{Total: 2}) {
+		t.Fatalf("empty pool = %+v", got)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := p.acquire(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := p.stats(); got.InUse != 2 || got.Waiting != 0 || !got.Saturated() {
+		t.Fatalf("full pool = %+v", got)
+	}
+
+	// Ожидающий учитывается, пока не получит слот
+	acquired := make(chan error)
+	go func() { acquired <- p.acquire(ctx) }()
+	waitForWaiters(t, p, 1)
+
+	p.release()
+	if err := <-acquired; err != nil {
+		t.Fatal(err)
+	}
+	if got := p.stats(); got.InUse != 2 || got.Waiting != 0 {
+		t.Fatalf("after handoff = %+v", got)
+	}
+
+	// Отменённое ожидание тоже снимается со счётчика
+	cancelCtx, cancel := context.WithCancel(ctx)
+	go func() { acquired <- p.acquire(cancelCtx) }()
+	waitForWaiters(t, p, 1)
+	cancel()
+	if err := <-acquired; err != context.Canceled {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	waitForWaiters(t, p, 0)
+
+	p.release()
+	p.release()
+	if got := p.stats(); got != (PoolStats{Total: 2}) || got.Saturated() {
+		t.Fatalf("released pool = %+v", got)
+	}
+}
@@ -0,0 +1,132 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// blockableTypes — типы ресурсов, которые можно обрывать: без них HTML и JS страницы
+// работают как обычно. Document, Script, XHR и Fetch по типу не блокируются никогда
+var blockableTypes = map[string]network.ResourceType{
+	"image":      network.ResourceTypeImage,
+	"media":      network.ResourceTypeMedia,
+	"font":       network.ResourceTypeFont,
+	"stylesheet": network.ResourceTypeStylesheet,
+	"texttrack":  network.ResourceTypeTextTrack,
+	"manifest":   network.ResourceTypeManifest,
+	"ping":       network.ResourceTypePing,
+}
+
+// defaultBlockedDomains — реклама и счётчики, которые чаще всего встречаются на пиратских сайтах
+var defaultBlockedDomains = []string{
+	"google-analytics.com",
+	"googletagmanager.com",
+	"googlesyndication.com",
+	"doubleclick.net",
+	"mc.yandex.ru",
+	"an.yandex.ru",
+	"yandexadexchange.net",
+	"facebook.net",
+	"top-fwz1.mail.ru",
+	"counter.yadro.ru",
+	"adfox.ru",
+	"adriver.ru",
+}
+
+// ResourceBlock — какие запросы вкладки обрывать при загрузке страницы
+type ResourceBlock struct {
+	Enabled bool
+	Types   []string // image, media, font, stylesheet, texttrack, manifest, ping
+	Domains []string // дополнительно к defaultBlockedDomains; поддомены тоже блокируются
+}
+
+type resourceBlocker struct {
+	types   map[network.ResourceType]bool
+	domains []string
+}
+
+// newResourceBlocker проверяет настройки; nil — блокировка выключена
+func newResourceBlocker(cfg ResourceBlock) (*resourceBlocker, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	rb := &resourceBlocker{types: make(map[network.ResourceType]bool, len(cfg.Types))}
+	for _, name := range cfg.Types {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		t, ok := blockableTypes[name]
+		if !ok {
+			return nil, fmt.Errorf("resource type %q cannot be blocked", name)
+		}
+		rb.types[t] = true
+	}
+
+	for _, d := range append(append([]string{}, defaultBlockedDomains...), cfg.Domains...) {
+		d = strings.ToLower(strings.Trim(strings.TrimSpace(d), "."))
+		if d != "" {
+			rb.domains = append(rb.domains, d)
+		}
+	}
+
+	return rb, nil
+}
+
+// shouldAbort решает судьбу запроса: документы (страница и iframe с плеером) пропускаются всегда,
+// остальное обрывается по типу ресурса или по домену из списка
+func (rb *resourceBlocker) shouldAbort(rawURL string, resourceType network.ResourceType) bool {
+	if resourceType == network.ResourceTypeDocument {
+		return false
+	}
+	if rb.types[resourceType] {
+		return true
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, d := range rb.domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// intercept перехватывает запросы вкладки; вызывать до enable и навигации
+func (rb *resourceBlocker) intercept(tabCtx context.Context) {
+	chromedp.ListenTarget(tabCtx, func(ev interface{}) {
+		e, ok := ev.(*fetch.EventRequestPaused)
+		if !ok {
+			return
+		}
+		// Обработчик событий нельзя блокировать CDP-вызовами
+		go func() {
+			c := chromedp.FromContext(tabCtx)
+			if c == nil || c.Target == nil {
+				return
+			}
+			ctx := cdp.WithExecutor(tabCtx, c.Target)
+			if rb.shouldAbort(e.Request.URL, e.ResourceType) {
+				fetch.FailRequest(e.RequestID, network.ErrorReasonBlockedByClient).Do(ctx)
+				return
+			}
+			fetch.ContinueRequest(e.RequestID).Do(ctx)
+		}()
+	})
+}
+
+func (rb *resourceBlocker) enable() chromedp.Action {
+	return fetch.Enable()
+}
@@ -0,0 +1,60 @@
+package browser
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestResourceBlockerShouldAbort(t *testing.T) {
+	rb, err := newResourceBlocker(ResourceBlock{
+		Enabled: true,
+		Types:   []string{"image", " Font ", "media", ""},
+		Domains: []string{"ads.example.net."},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		url          string
+		resourceType network.ResourceType
+		want         bool
+	}{
+		{"page document", "https://film.example.com/brat-2", network.ResourceTypeDocument, false},
+		{"player iframe on ad domain", "https://ads.example.net/embed/1", network.ResourceTypeDocument, false},
+		{"page script", "https://film.example.com/app.js", network.ResourceTypeScript, false},
+		{"api xhr", "https://film.example.com/api/player", network.ResourceTypeXHR, false},
+		{"stylesheet not configured", "https://film.example.com/style.css", network.ResourceTypeStylesheet, false},
+		{"image", "https://film.example.com/poster.jpg", network.ResourceTypeImage, true},
+		{"font type is case-insensitive", "https://fonts.example.com/a.woff2", network.ResourceTypeFont, true},
+		{"media", "https://cdn.example.com/trailer.mp4", network.ResourceTypeMedia, true},
+		{"built-in tracker", "https://mc.yandex.ru/metrika/tag.js", network.ResourceTypeScript, true},
+		{"tracker subdomain", "https://www.googletagmanager.com/gtm.js", network.ResourceTypeScript, true},
+		{"configured ad domain", "https://ads.example.net/banner.js", network.ResourceTypeScript, true},
+		{"domain suffix is not a subdomain", "https://notads.example.net/app.js", network.ResourceTypeScript, false},
+		{"domain as path is allowed", "https://film.example.com/doubleclick.net/x.js", network.ResourceTypeScript, false},
+		{"unparsable url", "http://%zz", network.ResourceTypeScript, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rb.shouldAbort(tt.url, tt.resourceType); got != tt.want {
+				t.Errorf("shouldAbort(%q, %s) = %v, want %v", tt.url, tt.resourceType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewResourceBlocker(t *testing.T) {
+	if rb, err := newResourceBlocker(ResourceBlock{Types: []string{"image"}}); rb != nil || err != nil {
+		t.Errorf("disabled: blocker = %v, err = %v, want nil/nil", rb, err)
+	}
+
+	for _, typ := range []string{"script", "document", "xhr", "bogus"} {
+		if _, err := newResourceBlocker(ResourceBlock{Enabled: true, Types: []string{typ}}); err == nil {
+			t.Errorf("type %q: expected error", typ)
+		}
+	}
+}
@@ -26,10 +26,21 @@ type FetchResult struct {
 	IsCaptcha   bool
 	BlockReason string
 	Cookies     []captcha.Cookie
+	// CaptchaSolved — страница отдала капчу, но она решена и HTML получен
+	CaptchaSolved bool
+	// StatusCode — HTTP-статус основного документа; 0 — неизвестен (например, после решения капчи)
+	StatusCode int
+	// Validators — ETag и Last-Modified основного документа для условных запросов при перекрауле
+	Validators Validators
 }
 
 // FetchPage loads a page in a new tab, handles blocking/captcha, returns clean HTML
 func (b *GlobalBrowser) FetchPage(ctx context.Context, url string) (*FetchResult, error) {
+	return b.FetchPageWithWait(ctx, url, WaitOptions{})
+}
+
+// FetchPageWithWait is FetchPage with a per-site page load wait strategy
+func (b *GlobalBrowser) FetchPageWithWait(ctx context.Context, url string, wait WaitOptions) (*FetchResult, error) {
 	log := logger.Log
 
 	if err := b.AcquireWithContext(ctx); err != nil {
@@ -40,13 +51,26 @@ func (b *GlobalBrowser) FetchPage(ctx context.Context, url string) (*FetchResult
 	var html string
 	var finalURL string
 
+	parent, release, err := b.tabParent(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// Create new tab context
-	tabCtx, tabCancel := chromedp.NewContext(b.browserCtx)
+	tabCtx, tabCancel := chromedp.NewContext(parent)
 	defer tabCancel()
 
 	tabTimeoutCtx, tabTimeoutCancel := context.WithTimeout(tabCtx, defaultTabTimeout)
 	defer tabTimeoutCancel()
 
+	wait = resolveWait(wait, b.defaultWait)
+
+	var idle <-chan struct{}
+	if wait.Strategy == WaitNetworkIdle {
+		idle = listenNetworkIdle(tabCtx)
+	}
+
 	tasks := chromedp.Tasks{
 		// Set User-Agent via emulation API (more reliable than command-line flag)
 		chromedp.ActionFunc(func(ctx context.Context) error {
@@ -55,17 +79,6 @@ func (b *GlobalBrowser) FetchPage(ctx context.Context, url string) (*FetchResult
 				WithPlatform("macOS").
 				Do(ctx)
 		}),
-		// Block unnecessary resources (images, videos, fonts, stylesheets)
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			return network.SetBlockedURLs([]string{
-				"*.png", "*.jpg", "*.jpeg", "*.gif", "*.webp", "*.svg", "*.ico",
-				"*.mp4", "*.webm", "*.avi", "*.mov",
-				"*.woff", "*.woff2", "*.ttf", "*.eot", "*.otf",
-				"*.css",
-				"*google-analytics*", "*googletagmanager*", "*facebook*", "*yandex*metrika*",
-				"*ads*", "*analytics*", "*tracking*",
-			}).Do(ctx)
-		}),
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			return network.SetExtraHTTPHeaders(network.Headers{
 				"Accept-Language":           "ru-RU,ru;q=0.9,en;q=0.8",
@@ -80,14 +93,37 @@ func (b *GlobalBrowser) FetchPage(ctx context.Context, url string) (*FetchResult
 			_, err := page.AddScriptToEvaluateOnNewDocument(cdpopts.GetStealthScripts()).Do(ctx)
 			return err
 		}),
-		chromedp.Navigate(url),
+	}
+	if idle != nil {
+		tasks = append(tasks, enableLifecycleEvents())
+	}
+	// Картинки, медиа, шрифты и рекламные домены обрываются — HTML и JS страницы не трогаем
+	if b.blocker != nil {
+		b.blocker.intercept(tabCtx)
+		tasks = append(tasks, b.blocker.enable())
+	}
+	if err := chromedp.Run(tabTimeoutCtx, tasks); err != nil {
+		return nil, fmt.Errorf("fetch page: %w", err)
+	}
+
+	// Навигация отдельно, чтобы получить статус документа: по 404/410 видно, что страница снята
+	resp, err := chromedp.RunResponse(tabTimeoutCtx, chromedp.Navigate(url))
+	if err != nil {
+		return nil, fmt.Errorf("fetch page: %w", err)
+	}
+	statusCode := 0
+	var validators Validators
+	if resp != nil {
+		statusCode = int(resp.Status)
+		validators = validatorsFromHeaders(resp.Headers)
+	}
+
+	if err := chromedp.Run(tabTimeoutCtx,
 		chromedp.WaitReady("body", chromedp.ByQuery),
-		chromedp.Sleep(b.pageLoadDelay),
+		waitAction(wait, idle),
 		chromedp.Location(&finalURL),
 		chromedp.OuterHTML("html", &html),
-	}
-
-	if err := chromedp.Run(tabTimeoutCtx, tasks); err != nil {
+	); err != nil {
 		return nil, fmt.Errorf("fetch page: %w", err)
 	}
 
@@ -136,9 +172,10 @@ func (b *GlobalBrowser) FetchPage(ctx context.Context, url string) (*FetchResult
 
 			log.Info().Str("url", url).Int("cookies", len(cookies)).Msg("captcha solved successfully")
 			return &FetchResult{
-				HTML:     html,
-				FinalURL: finalURL,
-				Cookies:  cookies,
+				HTML:          html,
+				FinalURL:      finalURL,
+				Cookies:       cookies,
+				CaptchaSolved: true,
 			}, nil
 		}
 
@@ -154,9 +191,11 @@ func (b *GlobalBrowser) FetchPage(ctx context.Context, url string) (*FetchResult
 	cookies, _ := b.getCookies(tabTimeoutCtx)
 
 	return &FetchResult{
-		HTML:     html,
-		FinalURL: finalURL,
-		Cookies:  cookies,
+		HTML:       html,
+		FinalURL:   finalURL,
+		Cookies:    cookies,
+		StatusCode: statusCode,
+		Validators: validators,
 	}, nil
 }
 
@@ -169,7 +208,13 @@ func (b *GlobalBrowser) FetchSitemap(ctx context.Context, sitemapURL string) (*F
 
 	log := logger.Log
 
-	tabCtx, tabCancel := chromedp.NewContext(b.browserCtx)
+	parent, release, err := b.tabParent(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	tabCtx, tabCancel := chromedp.NewContext(parent)
 	defer tabCancel()
 
 	timeoutCtx, timeoutCancel := context.WithTimeout(tabCtx, defaultTabTimeout)
@@ -199,7 +244,7 @@ func (b *GlobalBrowser) FetchSitemap(ctx context.Context, sitemapURL string) (*F
 			return err
 		}),
 		chromedp.Navigate(sitemapURL),
-		chromedp.Sleep(b.pageLoadDelay),
+		chromedp.Sleep(b.defaultWait.Delay),
 		chromedp.Evaluate(getSitemapExtractScript(), &body),
 	}
 
@@ -234,7 +279,7 @@ func (b *GlobalBrowser) FetchSitemap(ctx context.Context, sitemapURL string) (*F
 		var newBody string
 		refetchTasks := chromedp.Tasks{
 			chromedp.Navigate(sitemapURL),
-			chromedp.Sleep(b.pageLoadDelay),
+			chromedp.Sleep(b.defaultWait.Delay),
 			chromedp.Evaluate(getSitemapExtractScript(), &newBody),
 		}
 
@@ -0,0 +1,158 @@
+package browser
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/video-analitics/backend/pkg/logger"
+)
+
+// WaitStrategy определяет, как дождаться загрузки страницы перед извлечением HTML
+type WaitStrategy string
+
+const (
+	WaitDefault     WaitStrategy = ""             // стратегия парсера по умолчанию (PAGE_WAIT_STRATEGY)
+	WaitNone        WaitStrategy = "none"         // статичные сайты — без ожидания
+	WaitDelay       WaitStrategy = "delay"        // фиксированная задержка
+	WaitSelector    WaitStrategy = "selector"     // до появления элемента
+	WaitNetworkIdle WaitStrategy = "network_idle" // до затишья сетевых запросов
+)
+
+const defaultWaitTimeout = 15 * time.Second
+
+// WaitOptions — настройки ожидания загрузки страницы для конкретного сайта
+type WaitOptions struct {
+	Strategy WaitStrategy
+	Delay    time.Duration // для WaitDelay
+	Selector string        // CSS-селектор для WaitSelector
+	Timeout  time.Duration // максимум ожидания для selector и network_idle
+}
+
+func (o WaitOptions) timeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	return defaultWaitTimeout
+}
+
+// usable — хватает ли настроек, чтобы применить стратегию
+func (o WaitOptions) usable() bool {
+	switch o.Strategy {
+	case WaitNone, WaitDelay, WaitNetworkIdle:
+		return true
+	case WaitSelector:
+		return o.Selector != ""
+	}
+	return false
+}
+
+// resolveWait выбирает стратегию для загрузки: настройка сайта, если она применима,
+// иначе стратегия по умолчанию, а если и она не задана — фиксированная задержка def.Delay
+func resolveWait(site, def WaitOptions) WaitOptions {
+	if site.usable() {
+		return site
+	}
+	if def.usable() {
+		return def
+	}
+	return WaitOptions{Strategy: WaitDelay, Delay: def.Delay}
+}
+
+// waitAction возвращает действие ожидания после навигации для уже выбранной стратегии.
+// idle — канал от listenNetworkIdle, нужен только для WaitNetworkIdle
+func waitAction(opts WaitOptions, idle <-chan struct{}) chromedp.Action {
+	switch opts.Strategy {
+	case WaitNone:
+		return chromedp.ActionFunc(func(context.Context) error { return nil })
+	case WaitSelector:
+		return WaitForSelector(opts.Selector, opts.timeout())
+	case WaitNetworkIdle:
+		if idle != nil {
+			return waitForNetworkIdle(idle, opts.timeout())
+		}
+	}
+	return chromedp.Sleep(opts.Delay)
+}
+
+// WaitForSelector ждёт появления видимого элемента по CSS-селектору.
+// По таймауту не падает: страница извлекается как есть, чтобы не терять
+// сайты, у которых поменялась вёрстка
+func WaitForSelector(selector string, timeout time.Duration) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		return waitWithTimeout(ctx, timeout, chromedp.WaitVisible(selector, chromedp.ByQuery).Do, func(err error) {
+			logger.Log.Debug().Err(err).Str("selector", selector).Dur("timeout", timeout).Msg("wait for selector timed out")
+		})
+	})
+}
+
+// waitWithTimeout выполняет wait не дольше timeout; истёкший таймаут не ошибка
+// (onTimeout только логирует), ошибкой считается лишь отмена внешнего ctx
+func waitWithTimeout(ctx context.Context, timeout time.Duration, wait func(context.Context) error, onTimeout func(error)) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := wait(waitCtx)
+	if err != nil && ctx.Err() == nil {
+		onTimeout(err)
+		return nil
+	}
+	return err
+}
+
+// listenNetworkIdle подписывается на lifecycle-события вкладки до навигации.
+// Канал закрывается, когда главный фрейм нового документа получает networkIdle
+func listenNetworkIdle(ctx context.Context) <-chan struct{} {
+	idle := make(chan struct{})
+
+	var (
+		mu      sync.Mutex
+		frameID cdp.FrameID
+		closed  bool
+	)
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		e, ok := ev.(*page.EventLifecycleEvent)
+		if !ok {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case e.Name == "init" && frameID == "":
+			// Первый init после включения событий — главный фрейм открываемой страницы
+			frameID = e.FrameID
+		case e.Name == "networkIdle" && frameID != "" && e.FrameID == frameID && !closed:
+			closed = true
+			close(idle)
+		}
+	})
+
+	return idle
+}
+
+func enableLifecycleEvents() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		return page.SetLifecycleEventsEnabled(true).Do(ctx)
+	})
+}
+
+func waitForNetworkIdle(idle <-chan struct{}, timeout time.Duration) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case <-idle:
+		case <-timer.C:
+			logger.Log.Debug().Dur("timeout", timeout).Msg("wait for network idle timed out")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	})
+}
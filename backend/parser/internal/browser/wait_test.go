@@ -0,0 +1,130 @@
+package browser
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitForNetworkIdle(t *testing.T) {
+	t.Run("returns when idle", func(t *testing.T) {
+		idle := make(chan struct{})
+		close(idle)
+		if err := waitForNetworkIdle(idle, time.Minute).Do(context.Background()); err != nil {
+			t.Errorf("error = %v, want nil", err)
+		}
+	})
+
+	t.Run("timeout does not fail fetch", func(t *testing.T) {
+		start := time.Now()
+		if err := waitForNetworkIdle(make(chan struct{}), 20*time.Millisecond).Do(context.Background()); err != nil {
+			t.Errorf("error = %v, want nil", err)
+		}
+		if time.Since(start) > time.Second {
+			t.Error("wait did not respect timeout")
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := waitForNetworkIdle(make(chan struct{}), time.Minute).Do(ctx); err != context.Canceled {
+			t.Errorf("error = %v, want context.Canceled", err)
+		}
+	})
+}
+
+func TestWaitOptionsTimeout(t *testing.T) {
+	if got := (WaitOptions{}).timeout(); got != defaultWaitTimeout {
+		t.Errorf("default timeout = %v, want %v", got, defaultWaitTimeout)
+	}
+	if got := (WaitOptions{Timeout: 3 * time.Second}).timeout(); got != 3*time.Second {
+		t.Errorf("timeout = %v, want 3s", got)
+	}
+}
+
+func TestResolveWait(t *testing.T) {
+	def := WaitOptions{Strategy: WaitNetworkIdle, Delay: 2 * time.Second, Timeout: 5 * time.Second}
+
+	tests := []struct {
+		name string
+		site WaitOptions
+		def  WaitOptions
+		want WaitOptions
+	}{
+		{
+			name: "site strategy wins",
+			site: WaitOptions{Strategy: WaitSelector, Selector: ".player"},
+			def:  def,
+			want: WaitOptions{Strategy: WaitSelector, Selector: ".player"},
+		},
+		{
+			name: "no site strategy uses default",
+			def:  def,
+			want: def,
+		},
+		{
+			name: "selector without selector uses default",
+			site: WaitOptions{Strategy: WaitSelector},
+			def:  def,
+			want: def,
+		},
+		{
+			name: "unknown strategy uses default",
+			site: WaitOptions{Strategy: "magic"},
+			def:  def,
+			want: def,
+		},
+		{
+			name: "unusable default falls back to fixed delay",
+			def:  WaitOptions{Strategy: WaitSelector, Delay: time.Second},
+			want: WaitOptions{Strategy: WaitDelay, Delay: time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveWait(tt.site, tt.def); got != tt.want {
+				t.Errorf("resolveWait() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitWithTimeout(t *testing.T) {
+	t.Run("selector present", func(t *testing.T) {
+		timedOut := false
+		err := waitWithTimeout(context.Background(), time.Minute, func(context.Context) error {
+			return nil
+		}, func(error) { timedOut = true })
+		if err != nil || timedOut {
+			t.Errorf("err = %v, timed out = %v, want nil/false", err, timedOut)
+		}
+	})
+
+	t.Run("selector missing times out without error", func(t *testing.T) {
+		timedOut := false
+		start := time.Now()
+		err := waitWithTimeout(context.Background(), 20*time.Millisecond, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, func(error) { timedOut = true })
+		if err != nil || !timedOut {
+			t.Errorf("err = %v, timed out = %v, want nil/true", err, timedOut)
+		}
+		if time.Since(start) > time.Second {
+			t.Error("wait did not respect timeout")
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := waitWithTimeout(ctx, time.Minute, func(ctx context.Context) error {
+			return ctx.Err()
+		}, func(error) { t.Error("cancellation reported as timeout") })
+		if err != context.Canceled {
+			t.Errorf("error = %v, want context.Canceled", err)
+		}
+	})
+}
@@ -13,6 +13,56 @@ type Config struct {
 	HTTPPort         string
 	InternalAPIToken string
 	PageLoadDelay    time.Duration
+
+	// Ожидание загрузки страницы для сайтов без своей стратегии: delay, none, selector, network_idle
+	PageWaitStrategy string
+	PageWaitSelector string
+	PageWaitTimeout  time.Duration
+
+	// Обрыв картинок, медиа, шрифтов и рекламных доменов при загрузке страниц браузером
+	BlockResources       bool
+	BlockResourceTypes   string // через запятую: image,media,font,stylesheet,texttrack,manifest,ping
+	BlockResourceDomains string // через запятую, дополнительно к встроенному списку
+
+	DetectSyncTimeout time.Duration
+	DetectAckWait     time.Duration
+	DetectWorkers     int    // размер пула воркеров детекции
+	DetectPerDomain   int    // одновременных детекций одного домена
+	ParkingSignatures string // через запятую, дополнительно к detector.DefaultParkingSignatures
+
+	// Консьюмеры остальных потоков: размер пула карт сайта и подтверждение задач обхода
+	SitemapWorkers int
+	CrawlAckWait   time.Duration
+
+	// Адаптивный размер батча page-воркера
+	PageBatchMin           int
+	PageBatchMax           int
+	PageThrottleWindow     int
+	PageBlockRateThreshold float64
+	PageThrottleDelayStep  time.Duration
+	PageThrottleMaxDelay   time.Duration
+
+	// Условный HEAD перед рендером: на 304 страница не открывается в браузере
+	PageConditionalFetch bool
+
+	// Передавать сжатый HTML страниц в индексер для хранения снимков
+	PageHTMLSnapshots bool
+
+	// Пул соединений page-воркера к внутреннему API индексера
+	IndexerMaxIdleConnsPerHost int
+	IndexerMaxConnsPerHost     int // 0 — без ограничения
+	IndexerIdleConnTimeout     time.Duration
+	IndexerHTTP2               bool // HTTP/2 через TLS, если индексер за прокси с его поддержкой
+
+	// Таймауты этапов обхода
+	SitemapInactivityTimeout time.Duration // задача карты сайта без прогресса
+	SitemapTaskTimeout       time.Duration // жёсткий предел задачи карты сайта
+	PageFetchTimeout         time.Duration
+	PageRevalidateTimeout    time.Duration
+	PageAckWait              time.Duration
+	SitemapAckWait           time.Duration
+	APIFetchTimeout          time.Duration // /api/fetch без timeout_ms
+	APIMaxFetchTimeout       time.Duration // верхняя граница timeout_ms
 }
 
 func Load() *Config {
@@ -23,6 +73,48 @@ func Load() *Config {
 		HTTPPort:         getEnv("HTTP_PORT", "8082"),
 		InternalAPIToken: getEnv("INTERNAL_API_TOKEN", ""),
 		PageLoadDelay:    getEnvDuration("PAGE_LOAD_DELAY", 2*time.Second),
+
+		PageWaitStrategy: getEnv("PAGE_WAIT_STRATEGY", "delay"),
+		PageWaitSelector: getEnv("PAGE_WAIT_SELECTOR", ""),
+		PageWaitTimeout:  getEnvDuration("PAGE_WAIT_TIMEOUT", 15*time.Second),
+
+		BlockResources:       getEnvBool("BROWSER_BLOCK_RESOURCES", true),
+		BlockResourceTypes:   getEnv("BROWSER_BLOCK_TYPES", "image,media,font,stylesheet"),
+		BlockResourceDomains: getEnv("BROWSER_BLOCK_DOMAINS", ""),
+
+		DetectSyncTimeout: getEnvDuration("DETECT_SYNC_TIMEOUT", 90*time.Second),
+		DetectAckWait:     getEnvDuration("DETECT_ACK_WAIT", 5*time.Minute),
+		DetectWorkers:     getEnvInt("DETECT_WORKERS", 3),
+		DetectPerDomain:   getEnvInt("DETECT_PER_DOMAIN", 1),
+		ParkingSignatures: getEnv("PARKING_SIGNATURES", ""),
+
+		SitemapWorkers: getEnvInt("SITEMAP_WORKERS", 2),
+		CrawlAckWait:   getEnvDuration("CRAWL_ACK_WAIT", 5*time.Minute),
+
+		PageBatchMin:           getEnvInt("PAGE_BATCH_MIN", 5),
+		PageBatchMax:           getEnvInt("PAGE_BATCH_MAX", 100),
+		PageThrottleWindow:     getEnvInt("PAGE_THROTTLE_WINDOW", 20),
+		PageBlockRateThreshold: getEnvFloat("PAGE_BLOCK_RATE_THRESHOLD", 0.2),
+		PageThrottleDelayStep:  getEnvDuration("PAGE_THROTTLE_DELAY_STEP", 2*time.Second),
+		PageThrottleMaxDelay:   getEnvDuration("PAGE_THROTTLE_MAX_DELAY", 30*time.Second),
+
+		PageConditionalFetch: getEnvBool("PAGE_CONDITIONAL_FETCH", true),
+
+		PageHTMLSnapshots: getEnvBool("PAGE_HTML_SNAPSHOTS", false),
+
+		IndexerMaxIdleConnsPerHost: getEnvInt("INDEXER_MAX_IDLE_CONNS_PER_HOST", 100),
+		IndexerMaxConnsPerHost:     getEnvInt("INDEXER_MAX_CONNS_PER_HOST", 0),
+		IndexerIdleConnTimeout:     getEnvDuration("INDEXER_IDLE_CONN_TIMEOUT", 90*time.Second),
+		IndexerHTTP2:               getEnvBool("INDEXER_HTTP2", true),
+
+		SitemapInactivityTimeout: getEnvDuration("SITEMAP_INACTIVITY_TIMEOUT", 5*time.Minute),
+		SitemapTaskTimeout:       getEnvDuration("SITEMAP_TASK_TIMEOUT", 30*time.Minute),
+		PageFetchTimeout:         getEnvDuration("PAGE_FETCH_TIMEOUT", 60*time.Second),
+		PageRevalidateTimeout:    getEnvDuration("PAGE_REVALIDATE_TIMEOUT", 10*time.Second),
+		PageAckWait:              getEnvDuration("PAGE_ACK_WAIT", 5*time.Minute),
+		SitemapAckWait:           getEnvDuration("SITEMAP_ACK_WAIT", 5*time.Minute),
+		APIFetchTimeout:          getEnvDuration("API_FETCH_TIMEOUT", 90*time.Second),
+		APIMaxFetchTimeout:       getEnvDuration("API_MAX_FETCH_TIMEOUT", 5*time.Minute),
 	}
 }
 
@@ -42,6 +134,24 @@ func getEnvInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+func getEnvBool(key string, defaultVal bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
+func getEnvFloat(key string, defaultVal float64) float64 {
+	if val := os.Getenv(key); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			return f
+		}
+	}
+	return defaultVal
+}
+
 func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
 	if val := os.Getenv(key); val != "" {
 		if d, err := time.ParseDuration(val); err == nil {
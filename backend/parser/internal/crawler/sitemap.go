@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path"
 	"regexp"
 	"strings"
 	"time"
@@ -26,6 +27,18 @@ type URL struct {
 	LastMod    string `xml:"lastmod"`
 	ChangeFreq string `xml:"changefreq"`
 	Priority   string `xml:"priority"`
+	// Расширения Google image/video sitemap; пространство имён не проверяется — префиксы на сайтах бывают любые
+	Images []SitemapImage `xml:"image"`
+	Videos []SitemapVideo `xml:"video"`
+}
+
+type SitemapImage struct {
+	Loc string `xml:"loc"`
+}
+
+type SitemapVideo struct {
+	PlayerLoc  string `xml:"player_loc"`
+	ContentLoc string `xml:"content_loc"`
 }
 
 type SitemapIndex struct {
@@ -87,6 +100,9 @@ func parseSitemapWithMetadataAndDepth(ctx context.Context, sitemapURL string, de
 	if err != nil {
 		return nil, err
 	}
+	if body, err = DecompressSitemap(body); err != nil {
+		return nil, err
+	}
 
 	var index SitemapIndex
 	if err := xml.Unmarshal(body, &index); err == nil && len(index.Sitemaps) > 0 {
@@ -193,6 +209,9 @@ func parseSitemapWithDepth(ctx context.Context, sitemapURL string, depth int, vi
 	if err != nil {
 		return nil, err
 	}
+	if body, err = DecompressSitemap(body); err != nil {
+		return nil, err
+	}
 
 	var index SitemapIndex
 	if err := xml.Unmarshal(body, &index); err == nil && len(index.Sitemaps) > 0 {
@@ -249,21 +268,41 @@ func parsePlainTextSitemap(body string) []string {
 
 // SitemapParseResult contains parsed sitemap data with separation of nested sitemaps and page URLs
 type SitemapParseResult struct {
-	NestedSitemaps []string // URLs of nested sitemaps to process recursively
-	PageURLs       []string // Actual page URLs
+	NestedSitemaps []string      // URLs of nested sitemaps to process recursively
+	PageURLs       []string      // Actual page URLs
+	Format         SitemapFormat // Detected content format
 }
 
 // ParseSitemapXML parses sitemap content from string (no HTTP fetch)
 // Supports: XML urlset, sitemap index, JSON from browser script, plain text
 // Returns separate lists of nested sitemaps and page URLs for recursive processing
 func ParseSitemapXML(body string, sitemapURL string) (*SitemapParseResult, error) {
-	log := logger.Log
-
 	// Check if it's JSON from browser sitemap script
 	if strings.HasPrefix(strings.TrimSpace(body), "{") {
 		return parseBrowserSitemapJSON(body, sitemapURL)
 	}
 
+	if result, ok := parseXMLSitemap(body, sitemapURL); ok {
+		return result, nil
+	}
+
+	// Try plain text format
+	if result, ok := parsePlainTextSitemapResult(body); ok {
+		return result, nil
+	}
+
+	// Try HTML format (Chrome XML tree view or HTML page with links)
+	if result, ok := parseRenderedXMLSitemap(body, sitemapURL); ok {
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("cannot parse sitemap content")
+}
+
+// parseXMLSitemap parses standard XML sitemap index or urlset
+func parseXMLSitemap(body, sitemapURL string) (*SitemapParseResult, bool) {
+	log := logger.Log
+
 	// Try as sitemap index
 	var index SitemapIndex
 	if err := xml.Unmarshal([]byte(body), &index); err == nil && len(index.Sitemaps) > 0 {
@@ -272,38 +311,98 @@ func ParseSitemapXML(body string, sitemapURL string) (*SitemapParseResult, error
 		for _, sm := range index.Sitemaps {
 			sitemaps = append(sitemaps, sm.Loc)
 		}
-		return &SitemapParseResult{NestedSitemaps: sitemaps}, nil
+		return &SitemapParseResult{NestedSitemaps: sitemaps, Format: SitemapFormatXML}, true
 	}
 
 	// Try as URL set
 	var urlset URLSet
 	if err := xml.Unmarshal([]byte(body), &urlset); err == nil && len(urlset.URLs) > 0 {
 		var urls []string
+		seen := make(map[string]bool)
+		add := func(u string) {
+			if u != "" && !seen[u] {
+				seen[u] = true
+				urls = append(urls, u)
+			}
+		}
 		for _, u := range urlset.URLs {
-			urls = append(urls, u.Loc)
+			add(strings.TrimSpace(u.Loc))
+			for _, ext := range extensionPageURLs(u, sitemapURL) {
+				add(ext)
+			}
 		}
-		log.Debug().Int("total", len(urlset.URLs)).Msg("sitemap urlset parsed")
-		return &SitemapParseResult{PageURLs: urls}, nil
+		log.Debug().Int("total", len(urlset.URLs)).Int("urls", len(urls)).Msg("sitemap urlset parsed")
+		return &SitemapParseResult{PageURLs: urls, Format: SitemapFormatXML}, true
 	}
 
-	// Try plain text format
+	return nil, false
+}
+
+// mediaExtensions — файлы, а не страницы: такие ссылки из image/video-расширений не обходим
+var mediaExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".avif": true, ".svg": true, ".bmp": true,
+	".mp4": true, ".m4v": true, ".webm": true, ".mkv": true, ".avi": true, ".mov": true, ".flv": true,
+	".m3u8": true, ".mpd": true, ".ts": true, ".mp3": true,
+}
+
+// extensionPageURLs достаёт страницы из image:loc, video:player_loc и video:content_loc.
+// Берутся только ссылки на домен страницы (или карты, если <loc> пуст) и не на медиафайлы:
+// плеер на стороннем CDN или постер страницей сайта не являются
+func extensionPageURLs(u URL, sitemapURL string) []string {
+	if len(u.Images) == 0 && len(u.Videos) == 0 {
+		return nil
+	}
+
+	base, err := url.Parse(strings.TrimSpace(u.Loc))
+	if err != nil || base.Host == "" {
+		if base, err = url.Parse(sitemapURL); err != nil {
+			return nil
+		}
+	}
+
+	var candidates []string
+	for _, img := range u.Images {
+		candidates = append(candidates, img.Loc)
+	}
+	for _, v := range u.Videos {
+		candidates = append(candidates, v.PlayerLoc, v.ContentLoc)
+	}
+
+	var pages []string
+	for _, raw := range candidates {
+		link, ok := resolveSiteLink(raw, base, false)
+		if !ok {
+			continue
+		}
+		if p, err := url.Parse(link); err == nil && mediaExtensions[strings.ToLower(path.Ext(p.Path))] {
+			continue
+		}
+		pages = append(pages, link)
+	}
+	return pages
+}
+
+func parsePlainTextSitemapResult(body string) (*SitemapParseResult, bool) {
 	urls := parsePlainTextSitemap(body)
-	if len(urls) > 0 {
-		log.Debug().Int("accepted", len(urls)).Msg("sitemap parsed as plain text")
-		return &SitemapParseResult{PageURLs: urls}, nil
+	if len(urls) == 0 {
+		return nil, false
 	}
+	logger.Log.Debug().Int("accepted", len(urls)).Msg("sitemap parsed as plain text")
+	return &SitemapParseResult{PageURLs: urls, Format: SitemapFormatText}, true
+}
 
-	// Try HTML format (Chrome XML tree view or HTML page with links)
+// parseRenderedXMLSitemap extracts URLs from XML sitemap rendered by browser as HTML
+func parseRenderedXMLSitemap(body string, sitemapURL string) (*SitemapParseResult, bool) {
 	result := parseHTMLSitemap(body, sitemapURL)
-	if len(result.PageURLs) > 0 || len(result.NestedSitemaps) > 0 {
-		log.Debug().
-			Int("urls", len(result.PageURLs)).
-			Int("sitemaps", len(result.NestedSitemaps)).
-			Msg("sitemap parsed from HTML")
-		return result, nil
+	if len(result.PageURLs) == 0 && len(result.NestedSitemaps) == 0 {
+		return nil, false
 	}
-
-	return nil, fmt.Errorf("cannot parse sitemap content")
+	logger.Log.Debug().
+		Int("urls", len(result.PageURLs)).
+		Int("sitemaps", len(result.NestedSitemaps)).
+		Msg("sitemap parsed from HTML")
+	result.Format = SitemapFormatXML
+	return result, true
 }
 
 // parseBrowserSitemapJSON parses JSON output from browser sitemap extraction script
@@ -322,6 +421,7 @@ func parseBrowserSitemapJSON(body string, _ string) (*SitemapParseResult, error)
 	result := &SitemapParseResult{
 		NestedSitemaps: data.Sitemaps,
 		PageURLs:       data.URLs,
+		Format:         SitemapFormatXML,
 	}
 
 	logger.Log.Debug().
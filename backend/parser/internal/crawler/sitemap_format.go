@@ -0,0 +1,252 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/video-analitics/backend/pkg/logger"
+)
+
+// SitemapFormat — формат содержимого карты сайта
+type SitemapFormat string
+
+const (
+	SitemapFormatAuto SitemapFormat = ""
+	SitemapFormatXML  SitemapFormat = "xml"
+	SitemapFormatText SitemapFormat = "text"
+	SitemapFormatJSON SitemapFormat = "json"
+	SitemapFormatHTML SitemapFormat = "html"
+)
+
+// SitemapOptions задаёт ожидаемый формат карты сайта
+type SitemapOptions struct {
+	// Format пробуется первым; если не подошёл — формат определяется автоматически
+	Format SitemapFormat
+	// JSONPath — путь к списку URL в JSON, например "data.items[*].url".
+	// Пустой — берутся все ссылки на домен сайта из документа
+	JSONPath string
+}
+
+// Браузер оборачивает JSON-ответ в <pre>
+var preRegex = regexp.MustCompile(`(?is)<pre[^>]*>(.*?)</pre>`)
+
+// ParseSitemapContent parses sitemap content of any supported format.
+// Auto detection order: browser script JSON, XML, JSON, plain text, HTML links, rendered XML
+func ParseSitemapContent(body string, sitemapURL string, opts SitemapOptions) (*SitemapParseResult, error) {
+	if opts.Format != SitemapFormatAuto {
+		if result, ok := parseSitemapAs(opts.Format, body, sitemapURL, opts.JSONPath); ok {
+			return result, nil
+		}
+	}
+
+	if isBrowserSitemapJSON(body) {
+		return parseBrowserSitemapJSON(body, sitemapURL)
+	}
+
+	for _, format := range []SitemapFormat{SitemapFormatXML, SitemapFormatJSON, SitemapFormatText, SitemapFormatHTML} {
+		if result, ok := parseSitemapAs(format, body, sitemapURL, opts.JSONPath); ok {
+			return result, nil
+		}
+	}
+
+	// Chrome XML tree view
+	if result, ok := parseRenderedXMLSitemap(body, sitemapURL); ok {
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("cannot parse sitemap content")
+}
+
+func parseSitemapAs(format SitemapFormat, body, sitemapURL, jsonPath string) (*SitemapParseResult, bool) {
+	switch format {
+	case SitemapFormatXML:
+		return parseXMLSitemap(body, sitemapURL)
+	case SitemapFormatText:
+		return parsePlainTextSitemapResult(body)
+	case SitemapFormatJSON:
+		return parseJSONSitemap(body, sitemapURL, jsonPath)
+	case SitemapFormatHTML:
+		return parseHTMLLinksSitemap(body, sitemapURL)
+	}
+	return nil, false
+}
+
+func isBrowserSitemapJSON(body string) bool {
+	if !strings.HasPrefix(strings.TrimSpace(body), "{") {
+		return false
+	}
+	var data struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal([]byte(body), &data) == nil && data.Type != ""
+}
+
+// parseJSONSitemap извлекает URL страниц из JSON-фида сайта
+func parseJSONSitemap(body, sitemapURL, jsonPath string) (*SitemapParseResult, bool) {
+	payload := strings.TrimSpace(body)
+	if m := preRegex.FindStringSubmatch(payload); m != nil {
+		payload = strings.TrimSpace(html.UnescapeString(m[1]))
+	}
+	if !strings.HasPrefix(payload, "{") && !strings.HasPrefix(payload, "[") {
+		return nil, false
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(payload), &data); err != nil {
+		return nil, false
+	}
+
+	base, err := url.Parse(sitemapURL)
+	if err != nil {
+		return nil, false
+	}
+
+	var values []string
+	if jsonPath != "" {
+		collectJSONPath(data, splitJSONPath(jsonPath), &values)
+	} else {
+		collectJSONStrings(data, &values)
+	}
+
+	var links []string
+	for _, v := range values {
+		if link, ok := resolveSiteLink(v, base, jsonPath != ""); ok {
+			links = append(links, link)
+		}
+	}
+
+	result := classifySitemapLinks(links)
+	if len(result.PageURLs) == 0 && len(result.NestedSitemaps) == 0 {
+		return nil, false
+	}
+	result.Format = SitemapFormatJSON
+
+	logger.Log.Debug().
+		Str("json_path", jsonPath).
+		Int("urls", len(result.PageURLs)).
+		Int("sitemaps", len(result.NestedSitemaps)).
+		Msg("sitemap parsed as JSON")
+	return result, true
+}
+
+// splitJSONPath разбирает упрощённый JSONPath: "$.data.items[*].url".
+// Массивы разворачиваются автоматически, поэтому [*] можно не указывать
+func splitJSONPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var segs []string
+	for _, seg := range strings.Split(path, ".") {
+		seg = strings.TrimSuffix(strings.TrimSuffix(seg, "[*]"), "[]")
+		if seg != "" {
+			segs = append(segs, seg)
+		}
+	}
+	return segs
+}
+
+func collectJSONPath(v interface{}, segs []string, out *[]string) {
+	if arr, ok := v.([]interface{}); ok {
+		for _, item := range arr {
+			collectJSONPath(item, segs, out)
+		}
+		return
+	}
+	if len(segs) == 0 {
+		if s, ok := v.(string); ok {
+			*out = append(*out, s)
+		}
+		return
+	}
+	if obj, ok := v.(map[string]interface{}); ok {
+		collectJSONPath(obj[segs[0]], segs[1:], out)
+	}
+}
+
+// collectJSONStrings собирает все строки документа, похожие на абсолютные URL
+func collectJSONStrings(v interface{}, out *[]string) {
+	switch val := v.(type) {
+	case string:
+		if strings.HasPrefix(val, "http://") || strings.HasPrefix(val, "https://") {
+			*out = append(*out, val)
+		}
+	case []interface{}:
+		for _, item := range val {
+			collectJSONStrings(item, out)
+		}
+	case map[string]interface{}:
+		for _, item := range val {
+			collectJSONStrings(item, out)
+		}
+	}
+}
+
+// resolveSiteLink оставляет только ссылки на домен сайта.
+// Относительные пути принимаются, только если путь к ним указан явно
+func resolveSiteLink(raw string, base *url.URL, allowRelative bool) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if allowRelative && strings.HasPrefix(raw, "/") && !strings.HasPrefix(raw, "//") {
+		raw = base.Scheme + "://" + base.Host + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+	if u.Host != base.Host {
+		return "", false
+	}
+	u.Fragment = ""
+	return u.String(), true
+}
+
+// parseHTMLLinksSitemap берёт ссылки <a href> на домен сайта со страницы HTML-карты
+func parseHTMLLinksSitemap(body, sitemapURL string) (*SitemapParseResult, bool) {
+	var links []string
+	for _, link := range ExtractLinksFromHTML(body, sitemapURL) {
+		if link != sitemapURL {
+			links = append(links, link)
+		}
+	}
+
+	result := classifySitemapLinks(links)
+	if len(result.PageURLs) == 0 && len(result.NestedSitemaps) == 0 {
+		return nil, false
+	}
+	result.Format = SitemapFormatHTML
+
+	logger.Log.Debug().
+		Int("urls", len(result.PageURLs)).
+		Int("sitemaps", len(result.NestedSitemaps)).
+		Msg("sitemap parsed from HTML links")
+	return result, true
+}
+
+func classifySitemapLinks(links []string) *SitemapParseResult {
+	result := &SitemapParseResult{}
+	seen := make(map[string]bool)
+	for _, link := range links {
+		if seen[link] {
+			continue
+		}
+		seen[link] = true
+
+		if isNestedSitemapURL(link) {
+			result.NestedSitemaps = append(result.NestedSitemaps, link)
+		} else {
+			result.PageURLs = append(result.PageURLs, link)
+		}
+	}
+	return result
+}
+
+func isNestedSitemapURL(link string) bool {
+	path := strings.ToLower(link)
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	return strings.Contains(path, "sitemap") &&
+		(strings.HasSuffix(path, ".xml") || strings.HasSuffix(path, ".json"))
+}
@@ -0,0 +1,130 @@
+package crawler
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+const testSitemapURL = "https://example.com/sitemap"
+
+func TestParseSitemapContentFormats(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		opts       SitemapOptions
+		wantFormat SitemapFormat
+		wantPages  []string
+		wantNested []string
+	}{
+		{
+			name:       "xml urlset",
+			body:       `<?xml version="1.0"?><urlset><url><loc>https://example.com/film/1</loc></url></urlset>`,
+			wantFormat: SitemapFormatXML,
+			wantPages:  []string{"https://example.com/film/1"},
+		},
+		{
+			name:       "xml index",
+			body:       `<sitemapindex><sitemap><loc>https://example.com/sitemap-films.xml</loc></sitemap></sitemapindex>`,
+			wantFormat: SitemapFormatXML,
+			wantNested: []string{"https://example.com/sitemap-films.xml"},
+		},
+		{
+			name:       "plain text",
+			body:       "https://example.com/film/1\nhttps://example.com/film/2\n",
+			wantFormat: SitemapFormatText,
+			wantPages:  []string{"https://example.com/film/1", "https://example.com/film/2"},
+		},
+		{
+			name:       "json without path takes site links",
+			body:       `{"items":[{"url":"https://example.com/film/1","poster":"https://cdn.other.com/1.jpg"},{"url":"https://example.com/film/2"}]}`,
+			wantFormat: SitemapFormatJSON,
+			wantPages:  []string{"https://example.com/film/1", "https://example.com/film/2"},
+		},
+		{
+			name:       "json with path resolves relative links",
+			body:       `{"data":{"items":[{"link":"/film/1","title":"https://example.com/ignored"},{"link":"/film/2"}]}}`,
+			opts:       SitemapOptions{JSONPath: "$.data.items[*].link"},
+			wantFormat: SitemapFormatJSON,
+			wantPages:  []string{"https://example.com/film/1", "https://example.com/film/2"},
+		},
+		{
+			name:       "json top level array wrapped by browser",
+			body:       `<html><head></head><body><pre style="word-wrap: break-word;">["https://example.com/film/1","https://example.com/sitemap-2.json"]</pre></body></html>`,
+			wantFormat: SitemapFormatJSON,
+			wantPages:  []string{"https://example.com/film/1"},
+			wantNested: []string{"https://example.com/sitemap-2.json"},
+		},
+		{
+			name:       "html page keeps only site links",
+			body:       `<html><body><a href="/film/1">One</a><a href="https://example.com/film/2#comments">Two</a><a href="https://other.com/x">Ext</a><a href="#top">Top</a></body></html>`,
+			wantFormat: SitemapFormatHTML,
+			wantPages:  []string{"https://example.com/film/1", "https://example.com/film/2"},
+		},
+		{
+			name:       "forced format falls back to detection",
+			body:       `<urlset><url><loc>https://example.com/film/1</loc></url></urlset>`,
+			opts:       SitemapOptions{Format: SitemapFormatJSON},
+			wantFormat: SitemapFormatXML,
+			wantPages:  []string{"https://example.com/film/1"},
+		},
+		{
+			name:       "browser script json",
+			body:       `{"type":"yoast","sitemaps":["https://example.com/post-sitemap.xml"],"urls":[]}`,
+			wantFormat: SitemapFormatXML,
+			wantNested: []string{"https://example.com/post-sitemap.xml"},
+			wantPages:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSitemapContent(tt.body, testSitemapURL, tt.opts)
+			if err != nil {
+				t.Fatalf("ParseSitemapContent() error = %v", err)
+			}
+			if got.Format != tt.wantFormat {
+				t.Errorf("Format = %q, want %q", got.Format, tt.wantFormat)
+			}
+			if !reflect.DeepEqual(got.PageURLs, tt.wantPages) {
+				t.Errorf("PageURLs = %v, want %v", got.PageURLs, tt.wantPages)
+			}
+			if !reflect.DeepEqual(got.NestedSitemaps, tt.wantNested) {
+				t.Errorf("NestedSitemaps = %v, want %v", got.NestedSitemaps, tt.wantNested)
+			}
+		})
+	}
+}
+
+func TestParseSitemapContentJSONPathNoMatch(t *testing.T) {
+	body := `{"data":{"items":[{"link":"/film/1"}]}}`
+	if _, err := ParseSitemapContent(body, testSitemapURL, SitemapOptions{JSONPath: "data.missing"}); err == nil {
+		t.Error("expected error when JSON path matches nothing")
+	}
+}
+
+func TestParseSitemapContentImageVideoExtensions(t *testing.T) {
+	body, err := os.ReadFile("testdata/sitemap_video.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ParseSitemapContent(string(body), "https://kino.example/sitemap-video.xml", SitemapOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Постеры, mp4 и плеер на чужом CDN отброшены, повтор <loc> в content_loc не дублируется
+	want := []string{
+		"https://kino.example/film/brat-2",
+		"https://kino.example/watch/brat-2?player=1",
+		"https://kino.example/film/sestry",
+		"https://kino.example/serial/zhmurki",
+	}
+	if result.Format != SitemapFormatXML {
+		t.Errorf("format = %q, want xml", result.Format)
+	}
+	if !reflect.DeepEqual(result.PageURLs, want) {
+		t.Errorf("pages = %v, want %v", result.PageURLs, want)
+	}
+}
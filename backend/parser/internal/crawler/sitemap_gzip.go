@@ -0,0 +1,46 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// maxSitemapSize — предел распакованной карты сайта по протоколу sitemaps.org (50 МБ)
+const maxSitemapSize = 50 << 20
+
+// IsGzipSitemapURL — карта сайта отдаётся архивом (sitemap.xml.gz); браузер такие не отображает
+func IsGzipSitemapURL(sitemapURL string) bool {
+	u, err := url.Parse(sitemapURL)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(strings.ToLower(u.Path), ".gz")
+}
+
+// DecompressSitemap распаковывает gzip-карту сайта, остальное возвращает как есть.
+// Формат определяется по сигнатуре, а не по расширению: сервер мог отдать .gz
+// с Content-Encoding, и тогда тело уже распаковано HTTP-клиентом
+func DecompressSitemap(body []byte) ([]byte, error) {
+	if len(body) < 2 || body[0] != 0x1f || body[1] != 0x8b {
+		return body, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("gzip sitemap: %w", err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(io.LimitReader(zr, maxSitemapSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("gzip sitemap: %w", err)
+	}
+	if len(data) > maxSitemapSize {
+		return nil, fmt.Errorf("gzip sitemap: decompressed size exceeds %d bytes", maxSitemapSize)
+	}
+	return data, nil
+}
@@ -22,6 +22,7 @@ func (e *Extractor) Extract(html string, url string, siteID string, httpStatus i
 	}
 
 	titleResult := ExtractTitle(doc, html)
+	seasonInfo := ExtractSeasonInfo(titleResult.Title)
 	detector := idextractor.NewIDDetector()
 	externalIDs := detector.Detect(doc, html).ToExternalIDs()
 	playerURL := ExtractPlayerURL(doc, html)
@@ -39,6 +40,8 @@ func (e *Extractor) Extract(html string, url string, siteID string, httpStatus i
 		Description: description,
 		MainText:    mainText,
 		Year:        titleResult.Year,
+		Season:      seasonInfo.Season,
+		Episodes:    seasonInfo.Episodes,
 		ExternalIDs: externalIDs,
 		PlayerURL:   playerURL,
 		LinksText:   linksText,
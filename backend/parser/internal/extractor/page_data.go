@@ -0,0 +1,42 @@
+package extractor
+
+import (
+	"github.com/video-analitics/backend/pkg/models"
+	"github.com/video-analitics/backend/pkg/queue"
+)
+
+// ToPageData переводит извлечённую страницу в формат очереди; пустые ID не попадают в external_ids
+func ToPageData(page *models.Page) *queue.PageData {
+	externalIDs := make(map[string]string)
+	if page.ExternalIDs.KinopoiskID != "" {
+		externalIDs["kinopoisk_id"] = page.ExternalIDs.KinopoiskID
+	}
+	if page.ExternalIDs.IMDBID != "" {
+		externalIDs["imdb_id"] = page.ExternalIDs.IMDBID
+	}
+	if page.ExternalIDs.TMDBID != "" {
+		externalIDs["tmdb_id"] = page.ExternalIDs.TMDBID
+	}
+	if page.ExternalIDs.MALID != "" {
+		externalIDs["mal_id"] = page.ExternalIDs.MALID
+	}
+	if page.ExternalIDs.ShikimoriID != "" {
+		externalIDs["shikimori_id"] = page.ExternalIDs.ShikimoriID
+	}
+	if page.ExternalIDs.MyDramaListID != "" {
+		externalIDs["mydramalist_id"] = page.ExternalIDs.MyDramaListID
+	}
+
+	return &queue.PageData{
+		URL:         page.URL,
+		Title:       page.Title,
+		Description: page.Description,
+		MainText:    page.MainText,
+		Year:        page.Year,
+		Season:      page.Season,
+		Episodes:    page.Episodes,
+		PlayerURL:   page.PlayerURL,
+		LinksText:   page.LinksText,
+		ExternalIDs: externalIDs,
+	}
+}
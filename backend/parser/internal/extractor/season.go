@@ -0,0 +1,87 @@
+package extractor
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// maxEpisodeRange ограничивает разворачивание диапазонов вида "1-24 серия"
+const maxEpisodeRange = 100
+
+var (
+	seasonEpisodeCodeRegex  = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])s(\d{1,2})\s?e(\d{1,3})(?:\s?-\s?e?(\d{1,3}))?(?:$|[^0-9])`)
+	seasonRangeRegex        = regexp.MustCompile(`(?i)(?:^|[^\d])\d{1,2}\s*-\s*\d{1,2}\s+(?:сезон|season)`)
+	seasonRuRegex           = regexp.MustCompile(`(?i)(?:^|[^\d])(\d{1,2})(?:-?[йя])?\s+сезон|сезон\s+(\d{1,2})(?:$|[^\d])`)
+	seasonEnRegex           = regexp.MustCompile(`(?i)(?:^|[^a-z])season\s+(\d{1,2})(?:$|[^\d])|(?:^|[^\d])(\d{1,2})(?:st|nd|rd|th)?\s+season`)
+	episodeRuWordFirstRegex = regexp.MustCompile(`(?i)сери[яи]\s+(\d{1,3})(?:\s*-\s*(\d{1,3}))?`)
+	episodeRuRegex          = regexp.MustCompile(`(?i)(?:^|[^\d])(\d{1,3})(?:\s*-\s*(\d{1,3}))?(?:-?[яй])?\s+сери[яий]`)
+	episodeEnRegex          = regexp.MustCompile(`(?i)(?:^|[^a-z])episodes?\s+(\d{1,3})(?:\s*-\s*(\d{1,3}))?`)
+)
+
+type SeasonInfo struct {
+	Season   int
+	Episodes []int
+}
+
+// ExtractSeasonInfo разбирает маркеры сезона/серии из заголовка:
+// "Нарко 3 сезон 5 серия", "сезон 2 серии 1-4", "Narcos S03E05", "Season 3 Episode 5".
+// Диапазон сезонов ("1-7 сезон") не привязывается к конкретному сезону.
+func ExtractSeasonInfo(title string) SeasonInfo {
+	var info SeasonInfo
+
+	if m := seasonEpisodeCodeRegex.FindStringSubmatch(title); m != nil {
+		info.Season = atoiSafe(m[1])
+		info.Episodes = expandEpisodeRange(atoiSafe(m[2]), atoiSafe(m[3]))
+		return info
+	}
+
+	if !seasonRangeRegex.MatchString(title) {
+		info.Season = firstSubmatchInt(seasonRuRegex, title)
+		if info.Season == 0 {
+			info.Season = firstSubmatchInt(seasonEnRegex, title)
+		}
+	}
+
+	// "сезон 4 серия 12": форма "серия N" проверяется первой, иначе номер сезона примется за серию
+	for _, re := range []*regexp.Regexp{episodeRuWordFirstRegex, episodeRuRegex, episodeEnRegex} {
+		if m := re.FindStringSubmatch(title); m != nil {
+			info.Episodes = expandEpisodeRange(atoiSafe(m[1]), atoiSafe(m[2]))
+			break
+		}
+	}
+
+	return info
+}
+
+func firstSubmatchInt(re *regexp.Regexp, s string) int {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+	for _, group := range m[1:] {
+		if n := atoiSafe(group); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+func expandEpisodeRange(from, to int) []int {
+	if from <= 0 {
+		return nil
+	}
+	if to < from || to-from >= maxEpisodeRange {
+		return []int{from}
+	}
+
+	episodes := make([]int, 0, to-from+1)
+	for e := from; e <= to; e++ {
+		episodes = append(episodes, e)
+	}
+	return episodes
+}
+
+func atoiSafe(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,101 @@
+package extractor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractSeasonInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		season   int
+		episodes []int
+	}{
+		{
+			name:   "russian season only",
+			title:  "Нарко 3 сезон смотреть онлайн бесплатно!",
+			season: 3,
+		},
+		{
+			name:   "russian season with suffix",
+			title:  "Во все тяжкие 2-й сезон",
+			season: 2,
+		},
+		{
+			name:   "russian season word first",
+			title:  "Сверхъестественное сезон 15 все серии",
+			season: 15,
+		},
+		{
+			name:     "russian season and episode",
+			title:    "Нарко 3 сезон 5 серия",
+			season:   3,
+			episodes: []int{5},
+		},
+		{
+			name:     "russian episode range",
+			title:    "Игра престолов 8 сезон 1-3 серии",
+			season:   8,
+			episodes: []int{1, 2, 3},
+		},
+		{
+			name:     "russian episode word first",
+			title:    "Доктор Хаус сезон 4 серия 12",
+			season:   4,
+			episodes: []int{12},
+		},
+		{
+			name:     "english code",
+			title:    "Narcos S03E05 watch online",
+			season:   3,
+			episodes: []int{5},
+		},
+		{
+			name:     "english code lowercase with range",
+			title:    "narcos s1e1-e3",
+			season:   1,
+			episodes: []int{1, 2, 3},
+		},
+		{
+			name:     "english words",
+			title:    "Narcos Season 3 Episode 5",
+			season:   3,
+			episodes: []int{5},
+		},
+		{
+			name:   "english ordinal",
+			title:  "Narcos 2nd season",
+			season: 2,
+		},
+		{
+			name:  "season range is not attributed",
+			title: "Безумцы (сериал 2007 1-7 сезон)",
+		},
+		{
+			name:  "year is not a season",
+			title: "Аватар (2009) смотреть онлайн",
+		},
+		{
+			name:  "movie without markers",
+			title: "Властелин колец: Братство кольца",
+		},
+		{
+			name:     "huge episode range is not expanded",
+			title:    "Ван-Пис 1-500 серия",
+			episodes: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ExtractSeasonInfo(tt.title)
+			if info.Season != tt.season {
+				t.Errorf("ExtractSeasonInfo(%q).Season = %d, want %d", tt.title, info.Season, tt.season)
+			}
+			if !reflect.DeepEqual(info.Episodes, tt.episodes) {
+				t.Errorf("ExtractSeasonInfo(%q).Episodes = %v, want %v", tt.title, info.Episodes, tt.episodes)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/parser/internal/browser"
+)
+
+// SetConditionalFetch включает условные запросы перед рендером: страница, которую сервер
+// подтвердил неизменной (304), не открывается в браузере
+func (w *PageWorker) SetConditionalFetch(enabled bool) {
+	w.conditionalFetch = enabled
+}
+
+// revalidate делает условный запрос перед рендером. Ошибка запроса не мешает полной загрузке.
+// Без сохранённых валидаторов HEAD не отправляется: 304 на него не получить, а валидаторы
+// придут с ответом на саму загрузку страницы
+func (w *PageWorker) revalidate(ctx context.Context, u pendingURLWithDepth) browser.Revalidation {
+	stored := browser.Validators{ETag: u.ETag, LastModified: u.LastModified}
+	if !w.conditionalFetch || stored.IsZero() {
+		return browser.Revalidation{}
+	}
+
+	// Условный запрос не должен задерживать рендер надолго
+	ctx, cancel := context.WithTimeout(ctx, w.timeouts.PageRevalidate)
+	defer cancel()
+
+	rev, err := browser.RevalidateHTTP(ctx, u.URL, stored)
+	if err != nil {
+		logger.Log.Debug().Err(err).Str("url", u.URL).Msg("conditional request failed, fetching page")
+		return browser.Revalidation{}
+	}
+	return rev
+}
+
+// withValidators сохраняет валидаторы ответа вместе со страницей для следующего перекраула
+func withValidators(result *queue.PageResult, v browser.Validators) {
+	if result.Page == nil {
+		return
+	}
+	result.Page.ETag = v.ETag
+	result.Page.LastModified = v.LastModified
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRevalidateSkipsHeadWithoutValidators(t *testing.T) {
+	var heads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		heads.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+	}))
+	defer srv.Close()
+
+	w := &PageWorker{conditionalFetch: true, timeouts: Timeouts{PageRevalidate: 5 * time.Second}}
+	ctx := context.Background()
+
+	if rev := w.revalidate(ctx, pendingURLWithDepth{URL: srv.URL}); rev.NotModified || !rev.Validators.IsZero() {
+		t.Errorf("without validators = %+v, want full fetch", rev)
+	}
+	if n := heads.Load(); n != 0 {
+		t.Fatalf("HEAD requests without validators = %d, want 0", n)
+	}
+
+	if rev := w.revalidate(ctx, pendingURLWithDepth{URL: srv.URL, ETag: `"v1"`}); !rev.NotModified {
+		t.Errorf("with stored ETag = %+v, want not modified", rev)
+	}
+	if n := heads.Load(); n != 1 {
+		t.Errorf("HEAD requests with validators = %d, want 1", n)
+	}
+}
@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/video-analitics/backend/pkg/nats"
+)
+
+// defaultAckWait — подтверждение задачи по умолчанию, как у nats.NewConsumer
+const defaultAckWait = 5 * time.Minute
+
+// pageMaxAckPending — общий на все экземпляры парсера лимит задач page-воркера в работе
+const pageMaxAckPending = 100
+
+// poolConsumerConfig — консьюмер пула из workers воркеров: MaxAckPending не даёт выбрать
+// из очереди больше, чем пул успевает разобрать; ackWait <= 0 — значение по умолчанию
+func poolConsumerConfig(stream, name string, workers int, ackWait time.Duration) nats.ConsumerConfig {
+	if workers < 1 {
+		workers = 1
+	}
+	if ackWait <= 0 {
+		ackWait = defaultAckWait
+	}
+	return nats.ConsumerConfig{
+		Stream:        stream,
+		Consumer:      name,
+		AckWait:       ackWait,
+		MaxAckPending: workers * 2,
+	}
+}
+
+func (w *Worker) consumerConfig(workers int) nats.ConsumerConfig {
+	return poolConsumerConfig(nats.StreamCrawlTasks, "crawl-worker", workers, w.ackWait)
+}
+
+func (w *DetectWorker) consumerConfig(workers int) nats.ConsumerConfig {
+	return poolConsumerConfig(nats.StreamDetectTasks, "detect-worker", workers, w.ackWait)
+}
+
+func (w *SitemapWorker) consumerConfig(workers int) nats.ConsumerConfig {
+	return poolConsumerConfig(nats.StreamSitemapCrawlTasks, "sitemap-worker", workers, w.timeouts.SitemapAckWait)
+}
+
+// consumerConfig page-воркера не зависит от размера пула: лимит задач в работе общий
+// на все экземпляры парсера, а число воркеров меняется на лету
+func (w *PageWorker) consumerConfig() nats.ConsumerConfig {
+	cfg := poolConsumerConfig(nats.StreamPageCrawlTasks, "page-worker", 1, w.timeouts.PageAckWait)
+	cfg.MaxAckPending = pageMaxAckPending
+	return cfg
+}
+
+// SetAckWait задаёт, сколько NATS ждёт подтверждения задачи обхода до повторной доставки
+func (w *Worker) SetAckWait(d time.Duration) {
+	w.ackWait = d
+}
+
+// SetAckWait задаёт, сколько NATS ждёт подтверждения задачи детекции до повторной доставки
+func (w *DetectWorker) SetAckWait(d time.Duration) {
+	w.ackWait = d
+}
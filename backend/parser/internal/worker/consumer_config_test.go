@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/nats"
+)
+
+func TestConsumerConfigWiring(t *testing.T) {
+	// Конструкторы требуют подключения к NATS; значения по умолчанию те же, что они выставляют
+	sitemap := &SitemapWorker{timeouts: DefaultTimeouts()}
+	page := &PageWorker{timeouts: DefaultTimeouts()}
+	detect := &DetectWorker{}
+	crawl := &Worker{}
+
+	tunedSitemap := &SitemapWorker{}
+	tunedSitemap.SetTimeouts(Timeouts{SitemapAckWait: 40 * time.Minute})
+	tunedPage := &PageWorker{}
+	tunedPage.SetTimeouts(Timeouts{PageAckWait: 30 * time.Minute})
+	tunedDetect := &DetectWorker{}
+	tunedDetect.SetAckWait(90 * time.Second)
+	tunedCrawl := &Worker{}
+	tunedCrawl.SetAckWait(time.Hour)
+
+	tests := []struct {
+		name string
+		got  nats.ConsumerConfig
+		want nats.ConsumerConfig
+	}{
+		{
+			name: "sitemap defaults",
+			got:  sitemap.consumerConfig(2),
+			want: nats.ConsumerConfig{Stream: nats.StreamSitemapCrawlTasks, Consumer: "sitemap-worker", AckWait: defaultAckWait, MaxAckPending: 4},
+		},
+		{
+			name: "sitemap tuned",
+			got:  tunedSitemap.consumerConfig(6),
+			want: nats.ConsumerConfig{Stream: nats.StreamSitemapCrawlTasks, Consumer: "sitemap-worker", AckWait: 40 * time.Minute, MaxAckPending: 12},
+		},
+		{
+			name: "page defaults, ack pending shared across instances",
+			got:  page.consumerConfig(),
+			want: nats.ConsumerConfig{Stream: nats.StreamPageCrawlTasks, Consumer: "page-worker", AckWait: 5 * time.Minute, MaxAckPending: pageMaxAckPending},
+		},
+		{
+			name: "page tuned",
+			got:  tunedPage.consumerConfig(),
+			want: nats.ConsumerConfig{Stream: nats.StreamPageCrawlTasks, Consumer: "page-worker", AckWait: 30 * time.Minute, MaxAckPending: pageMaxAckPending},
+		},
+		{
+			name: "detect defaults",
+			got:  detect.consumerConfig(3),
+			want: nats.ConsumerConfig{Stream: nats.StreamDetectTasks, Consumer: "detect-worker", AckWait: defaultAckWait, MaxAckPending: 6},
+		},
+		{
+			name: "detect tuned",
+			got:  tunedDetect.consumerConfig(3),
+			want: nats.ConsumerConfig{Stream: nats.StreamDetectTasks, Consumer: "detect-worker", AckWait: 90 * time.Second, MaxAckPending: 6},
+		},
+		{
+			name: "crawl zero workers falls back to one",
+			got:  crawl.consumerConfig(0),
+			want: nats.ConsumerConfig{Stream: nats.StreamCrawlTasks, Consumer: "crawl-worker", AckWait: defaultAckWait, MaxAckPending: 2},
+		},
+		{
+			name: "crawl tuned",
+			got:  tunedCrawl.consumerConfig(5),
+			want: nats.ConsumerConfig{Stream: nats.StreamCrawlTasks, Consumer: "crawl-worker", AckWait: time.Hour, MaxAckPending: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("consumerConfig() = %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
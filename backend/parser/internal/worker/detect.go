@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net/url"
-	"regexp"
 	"strings"
 	"time"
 
@@ -22,6 +21,13 @@ type DetectWorker struct {
 	cmsDetector     *detector.CMSDetector
 	renderDetector  *detector.RenderDetector
 	captchaDetector *detector.CaptchaDetector
+	parkingDetector *detector.ParkingDetector
+	domains         *domainLimiter
+	ackWait         time.Duration
+
+	// Загрузка страниц и карт сайта; подменяется в тестах
+	fetchPage    func(ctx context.Context, url string) (*browser.FetchResult, error)
+	fetchSitemap func(ctx context.Context, url string) (*browser.FetchResult, error)
 }
 
 func NewDetectWorker(natsClient *nats.Client) *DetectWorker {
@@ -31,35 +37,75 @@ func NewDetectWorker(natsClient *nats.Client) *DetectWorker {
 		cmsDetector:     detector.NewCMSDetector(),
 		renderDetector:  detector.NewRenderDetector(),
 		captchaDetector: detector.NewCaptchaDetector(),
+		parkingDetector: detector.NewParkingDetector(nil),
+		domains:         newDomainLimiter(1),
+		fetchPage: func(ctx context.Context, url string) (*browser.FetchResult, error) {
+			return browser.Get().FetchPage(ctx, url)
+		},
+		fetchSitemap: func(ctx context.Context, url string) (*browser.FetchResult, error) {
+			return browser.Get().FetchSitemap(ctx, url)
+		},
 	}
 }
 
+// SetParkingSignatures добавляет признаки страниц парковки к встроенным
+func (w *DetectWorker) SetParkingSignatures(extra []string) {
+	w.parkingDetector = detector.NewParkingDetector(extra)
+}
+
+// SetPerDomainLimit задаёт, сколько детекций одного домена может идти одновременно
+func (w *DetectWorker) SetPerDomainLimit(limit int) {
+	w.domains = newDomainLimiter(limit)
+}
+
 func (w *DetectWorker) Run(ctx context.Context) error {
+	return w.RunPool(ctx, 1)
+}
+
+// RunPool обрабатывает задачи детекции пулом; MaxAckPending не даёт выбрать из очереди больше,
+// чем пул успевает разобрать
+func (w *DetectWorker) RunPool(ctx context.Context, workerCount int) error {
 	log := logger.Log
 
-	consumer, err := nats.NewConsumer(w.natsClient, nats.ConsumerConfig{
-		Stream:   nats.StreamDetectTasks,
-		Consumer: "detect-worker",
-	})
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	consumer, err := nats.NewConsumer(w.natsClient, w.consumerConfig(workerCount))
 	if err != nil {
 		return fmt.Errorf("create consumer: %w", err)
 	}
 
-	log.Info().Msg("detect worker started")
+	log.Info().Int("workers", workerCount).Msg("detect worker pool started")
 
-	return consumer.Consume(ctx, func(ctx context.Context, msg *nats.Message) error {
+	return consumer.ConsumePool(ctx, workerCount, func(ctx context.Context, msg *nats.Message) error {
 		var task queue.DetectTask
 		if err := msg.Unmarshal(&task); err != nil {
 			log.Error().Err(err).Msg("failed to unmarshal detect task")
 			return err
 		}
 
+		if err := w.domains.acquire(ctx, task.Domain); err != nil {
+			return err
+		}
+		defer w.domains.release(task.Domain)
+
 		w.processTask(ctx, &task)
 		return nil
 	})
 }
 
 func (w *DetectWorker) processTask(ctx context.Context, task *queue.DetectTask) {
+	result := w.detect(ctx, task)
+	w.sendResult(ctx, &result)
+}
+
+// DetectDomain выполняет детекцию синхронно, без публикации результата в очередь
+func (w *DetectWorker) DetectDomain(ctx context.Context, domain string) queue.DetectResultMsg {
+	return w.detect(ctx, &queue.DetectTask{Domain: domain})
+}
+
+func (w *DetectWorker) detect(ctx context.Context, task *queue.DetectTask) queue.DetectResultMsg {
 	log := logger.Log
 
 	log.Info().Str("site", task.SiteID).Str("domain", task.Domain).Msg("detection started")
@@ -72,15 +118,14 @@ func (w *DetectWorker) processTask(ctx context.Context, task *queue.DetectTask)
 
 	baseURL := "https://" + task.Domain
 
-	// Fetch homepage via global browser
-	fetchResult, err := browser.Get().FetchPage(ctx, baseURL)
+	// Fetch homepage
+	fetchResult, err := w.fetchPage(ctx, baseURL)
 	if err != nil {
 		log.Error().Err(err).Str("domain", task.Domain).Msg("detection fetch failed")
 		result.Success = false
 		result.Error = err.Error()
 		result.FinishedAt = time.Now()
-		w.sendResult(ctx, &result)
-		return
+		return result
 	}
 
 	if fetchResult.Blocked {
@@ -90,8 +135,7 @@ func (w *DetectWorker) processTask(ctx context.Context, task *queue.DetectTask)
 			result.Success = false
 			result.Error = "blocked: " + fetchResult.BlockReason
 			result.FinishedAt = time.Now()
-			w.sendResult(ctx, &result)
-			return
+			return result
 		}
 	}
 
@@ -109,13 +153,27 @@ func (w *DetectWorker) processTask(ctx context.Context, task *queue.DetectTask)
 			result.HasDomainRedirect = true
 			result.RedirectToDomain = targetDomain
 			result.FinishedAt = time.Now()
-			w.sendResult(ctx, &result)
-			return
+			return result
 		}
 	}
 
 	html := fetchResult.HTML
 
+	// Просроченный домен отдаёт 200 со страницей парковки — сканировать там нечего
+	if parking := w.parkingDetector.Detect(html); parking.Parked {
+		log.Info().
+			Str("site", task.SiteID).
+			Str("domain", task.Domain).
+			Str("signature", parking.Signature).
+			Msg("parked domain detected")
+
+		result.Success = true
+		result.IsParked = true
+		result.ParkedSignature = parking.Signature
+		result.FinishedAt = time.Now()
+		return result
+	}
+
 	// Detect CMS
 	cmsResult := w.cmsDetector.Detect(html, make(map[string]string))
 	result.CMS = string(cmsResult.CMS)
@@ -140,6 +198,7 @@ func (w *DetectWorker) processTask(ctx context.Context, task *queue.DetectTask)
 	result.SitemapStatus = string(sitemapResult.SitemapStatus)
 	result.CrawlStrategy = string(sitemapResult.CrawlStrategy)
 	result.SitemapURLs = sitemapResult.SitemapURLs
+	result.SitemapFormat = string(sitemapResult.SitemapFormat)
 
 	// Add cookies from fetch
 	for _, c := range fetchResult.Cookies {
@@ -167,7 +226,7 @@ func (w *DetectWorker) processTask(ctx context.Context, task *queue.DetectTask)
 		Str("captcha", result.CaptchaType).
 		Msg("detection completed")
 
-	w.sendResult(ctx, &result)
+	return result
 }
 
 type sitemapDetectionResult struct {
@@ -175,6 +234,7 @@ type sitemapDetectionResult struct {
 	SitemapStatus detector.SitemapStatus
 	CrawlStrategy detector.CrawlStrategy
 	SitemapURLs   []string
+	SitemapFormat crawler.SitemapFormat
 }
 
 func (w *DetectWorker) detectSitemapsWithValidation(ctx context.Context, domain string) sitemapDetectionResult {
@@ -186,41 +246,14 @@ func (w *DetectWorker) detectSitemapsWithValidation(ctx context.Context, domain
 
 	baseURL := "https://" + domain
 
-	// 1. Check robots.txt for sitemap references
+	// 1. robots.txt первым, затем стандартные пути
 	robotsSitemaps := w.checkRobotsTxt(ctx, baseURL)
 	log.Debug().Strs("robots_sitemaps", robotsSitemaps).Str("domain", domain).Msg("robots.txt check")
+	allCandidates := sitemapCandidates(baseURL, robotsSitemaps)
 
-	// 2. Standard sitemap paths (fallback if nothing in robots.txt)
-	standardPaths := []string{
-		"/sitemap.xml",
-		"/sitemap_index.xml",
-		"/sitemap-index.xml",
-		"/wp-sitemap.xml", // WordPress 5.5+
-		"/sitemap.txt",
-		"/post-sitemap.xml", // Yoast SEO
-	}
-
-	// Build candidates list: robots.txt sitemaps first, then standard paths
-	var allCandidates []string
-	seen := make(map[string]bool)
-
-	for _, u := range robotsSitemaps {
-		if !seen[u] {
-			allCandidates = append(allCandidates, u)
-			seen[u] = true
-		}
-	}
-	for _, path := range standardPaths {
-		u := baseURL + path
-		if !seen[u] {
-			allCandidates = append(allCandidates, u)
-			seen[u] = true
-		}
-	}
-
-	// 3. Validate each candidate using the real sitemap parser
+	// 2. Validate each candidate using the real sitemap parser
 	for _, sitemapURL := range allCandidates {
-		fetchResult, err := browser.Get().FetchSitemap(ctx, sitemapURL)
+		fetchResult, err := w.fetchSitemap(ctx, sitemapURL)
 		if err != nil {
 			log.Debug().Err(err).Str("url", sitemapURL).Msg("sitemap fetch failed")
 			continue
@@ -232,7 +265,7 @@ func (w *DetectWorker) detectSitemapsWithValidation(ctx context.Context, domain
 		}
 
 		// Use the real sitemap parser (supports XML, JSON, HTML, plain text)
-		parsed, err := crawler.ParseSitemapXML(fetchResult.HTML, sitemapURL)
+		parsed, err := crawler.ParseSitemapContent(fetchResult.HTML, sitemapURL, crawler.SitemapOptions{})
 		if err != nil {
 			log.Debug().Err(err).Str("url", sitemapURL).Msg("sitemap parse failed")
 			if result.SitemapStatus == detector.SitemapNone {
@@ -254,10 +287,12 @@ func (w *DetectWorker) detectSitemapsWithValidation(ctx context.Context, domain
 		if urlsCount > 0 {
 			result.SitemapStatus = detector.SitemapValid
 			result.CrawlStrategy = detector.CrawlStrategySitemap
+			result.SitemapFormat = parsed.Format
 			log.Info().
 				Str("url", sitemapURL).
 				Int("page_urls", len(parsed.PageURLs)).
 				Int("nested_sitemaps", len(parsed.NestedSitemaps)).
+				Str("format", string(parsed.Format)).
 				Msg("valid sitemap found")
 			break
 		} else {
@@ -269,27 +304,11 @@ func (w *DetectWorker) detectSitemapsWithValidation(ctx context.Context, domain
 }
 
 func (w *DetectWorker) checkRobotsTxt(ctx context.Context, baseURL string) []string {
-	var sitemapURLs []string
-
-	robotsURL := baseURL + "/robots.txt"
-	fetchResult, err := browser.Get().FetchPage(ctx, robotsURL)
+	fetchResult, err := w.fetchPage(ctx, baseURL+"/robots.txt")
 	if err != nil || fetchResult.Blocked {
-		return sitemapURLs
-	}
-
-	// FetchPage returns HTML wrapper, use regex to find Sitemap: directives
-	// Works for both raw text and HTML-wrapped content
-	// Supports .xml and .txt sitemaps
-	sitemapRegex := regexp.MustCompile(`(?i)sitemap:\s*(https?://[^\s<>"\n\r]+\.(xml|txt))`)
-	matches := sitemapRegex.FindAllStringSubmatch(fetchResult.HTML, -1)
-
-	for _, match := range matches {
-		if len(match) > 1 {
-			sitemapURLs = append(sitemapURLs, strings.TrimSpace(match[1]))
-		}
+		return nil
 	}
-
-	return sitemapURLs
+	return parseRobotsSitemaps(fetchResult.HTML)
 }
 
 func (w *DetectWorker) sendResult(ctx context.Context, result *queue.DetectResultMsg) {
@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/video-analitics/backend/pkg/detector"
+	"github.com/video-analitics/parser/internal/browser"
+)
+
+// stubDetectWorker отдаёт страницы из pages; остальные URL — ошибка загрузки
+func stubDetectWorker(pages map[string]*browser.FetchResult) *DetectWorker {
+	fetch := func(_ context.Context, url string) (*browser.FetchResult, error) {
+		if res, ok := pages[url]; ok {
+			return res, nil
+		}
+		return nil, errors.New("404")
+	}
+	return &DetectWorker{
+		cmsDetector:     detector.NewCMSDetector(),
+		renderDetector:  detector.NewRenderDetector(),
+		captchaDetector: detector.NewCaptchaDetector(),
+		parkingDetector: detector.NewParkingDetector(nil),
+		fetchPage:       fetch,
+		fetchSitemap:    fetch,
+	}
+}
+
+func TestDetectDomain(t *testing.T) {
+	const homepage = `<html><head><meta name="generator" content="DataLife Engine (http://dle-news.ru)">
+<script src="https://www.google.com/recaptcha/api.js"></script></head>
+<body><h1>Фильмы онлайн</h1><p>Смотреть новинки кино бесплатно в хорошем качестве без регистрации и рекламы.</p></body></html>`
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>https://kino.example/film/1</loc></url>
+<url><loc>https://kino.example/film/2</loc></url>
+</urlset>`
+
+	tests := []struct {
+		name  string
+		pages map[string]*browser.FetchResult
+		check func(t *testing.T, w *DetectWorker)
+	}{
+		{
+			name: "cms, captcha and sitemap from robots.txt",
+			pages: map[string]*browser.FetchResult{
+				"https://kino.example":               {HTML: homepage, FinalURL: "https://kino.example"},
+				"https://kino.example/robots.txt":    {HTML: "User-agent: *\nSitemap: https://kino.example/map/films.xml\n"},
+				"https://kino.example/map/films.xml": {HTML: sitemap},
+			},
+			check: func(t *testing.T, w *DetectWorker) {
+				res := w.DetectDomain(context.Background(), "kino.example")
+				if !res.Success {
+					t.Fatalf("detection failed: %s", res.Error)
+				}
+				if res.CMS != string(detector.CMSDLE) {
+					t.Errorf("cms = %q, want dle", res.CMS)
+				}
+				if res.CaptchaType != string(detector.CaptchaReCAPTCHA) {
+					t.Errorf("captcha = %q, want recaptcha", res.CaptchaType)
+				}
+				if !res.HasSitemap || res.SitemapStatus != string(detector.SitemapValid) {
+					t.Errorf("sitemap = %v/%q, want valid", res.HasSitemap, res.SitemapStatus)
+				}
+				if len(res.SitemapURLs) != 1 || res.SitemapURLs[0] != "https://kino.example/map/films.xml" {
+					t.Errorf("sitemap urls = %v", res.SitemapURLs)
+				}
+				if res.TaskID != "" || res.SiteID != "" {
+					t.Errorf("sync detection must not reference a task or site: %q/%q", res.TaskID, res.SiteID)
+				}
+			},
+		},
+		{
+			name: "domain redirect",
+			pages: map[string]*browser.FetchResult{
+				"https://old.example": {HTML: homepage, FinalURL: "https://www.new.example/"},
+			},
+			check: func(t *testing.T, w *DetectWorker) {
+				res := w.DetectDomain(context.Background(), "old.example")
+				if !res.Success || !res.HasDomainRedirect || res.RedirectToDomain != "new.example" {
+					t.Errorf("redirect = %v/%v/%q, want new.example", res.Success, res.HasDomainRedirect, res.RedirectToDomain)
+				}
+			},
+		},
+		{
+			name: "parked domain",
+			pages: map[string]*browser.FetchResult{
+				"https://kino.example": {
+					HTML:     `<html><body><a href="https://sedo.com/search/details/?domain=kino.example">This domain may be for sale!</a></body></html>`,
+					FinalURL: "https://kino.example",
+				},
+			},
+			check: func(t *testing.T, w *DetectWorker) {
+				res := w.DetectDomain(context.Background(), "kino.example")
+				if !res.Success || !res.IsParked || res.ParkedSignature != "sedo.com/search/details" {
+					t.Errorf("result = %v/%v/%q, want parked", res.Success, res.IsParked, res.ParkedSignature)
+				}
+				if res.HasSitemap {
+					t.Error("parked domain must not be probed for sitemaps")
+				}
+			},
+		},
+		{
+			name: "blocked without cookies",
+			pages: map[string]*browser.FetchResult{
+				"https://kino.example": {Blocked: true, BlockReason: "cloudflare"},
+			},
+			check: func(t *testing.T, w *DetectWorker) {
+				res := w.DetectDomain(context.Background(), "kino.example")
+				if res.Success || res.Error != "blocked: cloudflare" {
+					t.Errorf("result = %v/%q, want blocked", res.Success, res.Error)
+				}
+			},
+		},
+		{
+			name:  "fetch error",
+			pages: map[string]*browser.FetchResult{},
+			check: func(t *testing.T, w *DetectWorker) {
+				if res := w.DetectDomain(context.Background(), "kino.example"); res.Success || res.Error == "" {
+					t.Errorf("result = %v/%q, want failure", res.Success, res.Error)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, stubDetectWorker(tt.pages))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// domainLimiter ограничивает число одновременных задач на один домен,
+// чтобы пул воркеров не открывал к одному сайту много вкладок сразу
+type domainLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	active  map[string]int
+	changed chan struct{}
+}
+
+func newDomainLimiter(limit int) *domainLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &domainLimiter{
+		limit:   limit,
+		active:  make(map[string]int),
+		changed: make(chan struct{}),
+	}
+}
+
+// acquire ждёт свободного слота для домена; release нужно вызвать, только если ошибки нет
+func (l *domainLimiter) acquire(ctx context.Context, domain string) error {
+	key := domainKey(domain)
+	for {
+		l.mu.Lock()
+		if l.active[key] < l.limit {
+			l.active[key]++
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (l *domainLimiter) release(domain string) {
+	key := domainKey(domain)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active[key]--
+	if l.active[key] <= 0 {
+		delete(l.active, key)
+	}
+	// Будим всех ждущих: слот мог освободиться для любого из них
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+func domainKey(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(domain), "www.")
+}
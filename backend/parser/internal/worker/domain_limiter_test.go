@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDomainLimiter(t *testing.T) {
+	l := newDomainLimiter(1)
+	ctx := context.Background()
+
+	if err := l.acquire(ctx, "example.com"); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	// Другой домен не ждёт
+	if err := l.acquire(ctx, "other.com"); err != nil {
+		t.Fatalf("acquire(other) error = %v", err)
+	}
+
+	// www. — тот же домен, слот занят
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(short, "WWW.example.com"); err == nil {
+		t.Fatal("acquire() on busy domain succeeded, want timeout")
+	}
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- l.acquire(ctx, "example.com") }()
+	l.release("example.com")
+
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("acquire() after release error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire() not woken by release")
+	}
+}
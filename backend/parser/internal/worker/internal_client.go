@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// InternalHTTPConfig — пул соединений к внутреннему API индексера (pending-urls, all-urls).
+// Все запросы идут на один хост, поэтому лимит простаивающих соединений на хост должен
+// быть не меньше числа page-воркеров, иначе лишние соединения закрываются после каждого ответа
+type InternalHTTPConfig struct {
+	Timeout             time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost — потолок одновременных соединений к индексеру; 0 — без ограничения
+	MaxConnsPerHost int
+	IdleConnTimeout time.Duration
+	// HTTP2 — договариваться об HTTP/2 через TLS (ALPN), если индексер стоит за прокси с его
+	// поддержкой; сам Fiber HTTP/2 не умеет, и по http:// запросы идут по HTTP/1.1 с keep-alive
+	HTTP2 bool
+}
+
+func DefaultInternalHTTPConfig() InternalHTTPConfig {
+	return InternalHTTPConfig{
+		Timeout:             60 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+		HTTP2:               true,
+	}
+}
+
+func (c InternalHTTPConfig) normalized() InternalHTTPConfig {
+	def := DefaultInternalHTTPConfig()
+	if c.Timeout <= 0 {
+		c.Timeout = def.Timeout
+	}
+	if c.MaxIdleConnsPerHost <= 0 {
+		c.MaxIdleConnsPerHost = def.MaxIdleConnsPerHost
+	}
+	if c.MaxIdleConns < c.MaxIdleConnsPerHost {
+		c.MaxIdleConns = c.MaxIdleConnsPerHost
+	}
+	if c.MaxConnsPerHost < 0 {
+		c.MaxConnsPerHost = 0
+	}
+	if c.IdleConnTimeout <= 0 {
+		c.IdleConnTimeout = def.IdleConnTimeout
+	}
+	return c
+}
+
+// newInternalClient создаёт клиент к индексеру; один на воркер, его пул делят все горутины пула
+func newInternalClient(cfg InternalHTTPConfig) *http.Client {
+	cfg = cfg.normalized()
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConns:        cfg.MaxIdleConns,
+			MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     cfg.MaxConnsPerHost,
+			IdleConnTimeout:     cfg.IdleConnTimeout,
+			ForceAttemptHTTP2:   cfg.HTTP2,
+		},
+	}
+}
+
+// SetInternalHTTP пересоздаёт клиент к внутреннему API индексера; вызывается до запуска пула
+func (w *PageWorker) SetInternalHTTP(cfg InternalHTTPConfig) {
+	w.httpClient = newInternalClient(cfg)
+}
+
+// drainClose дочитывает тело ответа перед закрытием: недочитанное тело не даёт
+// вернуть соединение в пул keep-alive
+func drainClose(body io.ReadCloser) {
+	io.Copy(io.Discard, body)
+	body.Close()
+}
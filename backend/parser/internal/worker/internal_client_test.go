@@ -0,0 +1,117 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInternalHTTPConfigNormalized(t *testing.T) {
+	def := DefaultInternalHTTPConfig()
+
+	tests := []struct {
+		name string
+		in   InternalHTTPConfig
+		want InternalHTTPConfig
+	}{
+		{
+			name: "zero falls back to defaults",
+			in:   InternalHTTPConfig{},
+			want: InternalHTTPConfig{
+				Timeout:             def.Timeout,
+				MaxIdleConns:        def.MaxIdleConnsPerHost,
+				MaxIdleConnsPerHost: def.MaxIdleConnsPerHost,
+				IdleConnTimeout:     def.IdleConnTimeout,
+			},
+		},
+		{
+			name: "total idle cap raised to per-host cap",
+			in:   InternalHTTPConfig{Timeout: time.Second, MaxIdleConns: 10, MaxIdleConnsPerHost: 200, IdleConnTimeout: time.Minute, HTTP2: true},
+			want: InternalHTTPConfig{Timeout: time.Second, MaxIdleConns: 200, MaxIdleConnsPerHost: 200, IdleConnTimeout: time.Minute, HTTP2: true},
+		},
+		{
+			name: "negative conns per host means unlimited",
+			in:   InternalHTTPConfig{MaxIdleConnsPerHost: 20, MaxConnsPerHost: -1},
+			want: InternalHTTPConfig{
+				Timeout:             def.Timeout,
+				MaxIdleConns:        20,
+				MaxIdleConnsPerHost: 20,
+				IdleConnTimeout:     def.IdleConnTimeout,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.in.normalized(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalized() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newPendingURLsServer отвечает как индексер на pending-urls и считает открытые соединения
+func newPendingURLsServer(tb testing.TB, latency time.Duration) (*httptest.Server, *atomic.Int64) {
+	var conns atomic.Int64
+	body := `{"urls":[` + strings.TrimSuffix(strings.Repeat(`{"url":"https://example.com/film/1","depth":1},`, 50), ",") + `]}` + "\n"
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(latency) // запрос индексера к Mongo
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	tb.Cleanup(srv.Close)
+	return srv, &conns
+}
+
+func TestFetchPendingURLsReusesConnections(t *testing.T) {
+	srv, conns := newPendingURLsServer(t, 0)
+	w := &PageWorker{httpClient: newInternalClient(DefaultInternalHTTPConfig())}
+
+	for i := 0; i < 20; i++ {
+		if _, err := w.fetchPendingURLs(context.Background(), srv.URL, "s1", 50); err != nil {
+			t.Fatalf("fetchPendingURLs() error = %v", err)
+		}
+	}
+	if n := conns.Load(); n != 1 {
+		t.Errorf("sequential requests opened %d connections, want 1", n)
+	}
+}
+
+// BenchmarkFetchPendingURLs сравнивает старый лимит простаивающих соединений на хост (10)
+// с новым для пула из десятков page-воркеров: между запросами воркер обрабатывает батч,
+// и соединения одновременно простаивают. conns/op — новые TCP-соединения на запрос:
+// при малом лимите лишние закрываются и на следующем запросе открываются заново
+func BenchmarkFetchPendingURLs(b *testing.B) {
+	for _, idle := range []int{10, 100} {
+		b.Run(fmt.Sprintf("idle_per_host=%d", idle), func(b *testing.B) {
+			srv, conns := newPendingURLsServer(b, time.Millisecond)
+			w := &PageWorker{httpClient: newInternalClient(InternalHTTPConfig{MaxIdleConnsPerHost: idle})}
+
+			b.SetParallelism(64)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := w.fetchPendingURLs(context.Background(), srv.URL, "s1", 50); err != nil {
+						b.Error(err)
+						return
+					}
+					time.Sleep(2 * time.Millisecond) // обработка батча страниц
+				}
+			})
+			b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+		})
+	}
+}
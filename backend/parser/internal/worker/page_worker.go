@@ -15,9 +15,9 @@ import (
 	"github.com/video-analitics/backend/pkg/captcha"
 	"github.com/video-analitics/backend/pkg/detector"
 	"github.com/video-analitics/backend/pkg/logger"
-	"github.com/video-analitics/backend/pkg/models"
 	"github.com/video-analitics/backend/pkg/nats"
 	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/backend/pkg/status"
 	"github.com/video-analitics/parser/internal/browser"
 	"github.com/video-analitics/parser/internal/cache"
 	"github.com/video-analitics/parser/internal/crawler"
@@ -36,6 +36,15 @@ type PageWorker struct {
 	siteCookies   map[string][]captcha.Cookie
 	siteStrategy  map[string]string
 	cookiesMu     sync.RWMutex
+
+	pool   *nats.DynamicPool
+	poolMu sync.Mutex
+
+	throttle ThrottleConfig
+	timeouts Timeouts
+
+	conditionalFetch bool
+	htmlSnapshots    bool
 }
 
 const (
@@ -43,6 +52,10 @@ const (
 	strategyBrowserOnly = "browser_only"
 )
 
+// errEmptyTitle — ошибка страницы без заголовка; индексатор считает такие страницы
+// для автоматического перевода сайта на SPA-сканер
+const errEmptyTitle = "empty title"
+
 func NewPageWorker(natsClient *nats.Client, internalToken string) *PageWorker {
 	indexerAPIURL := os.Getenv("INDEXER_API_URL")
 	return &PageWorker{
@@ -51,20 +64,20 @@ func NewPageWorker(natsClient *nats.Client, internalToken string) *PageWorker {
 		extractor:     extractor.New(),
 		internalToken: internalToken,
 		indexerAPIURL: indexerAPIURL,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
-		},
-		httpFetcher:  detector.NewFetcher(detector.WithTimeout(30 * time.Second)),
-		siteCookies:  make(map[string][]captcha.Cookie),
-		siteStrategy: make(map[string]string),
+		httpClient:    newInternalClient(DefaultInternalHTTPConfig()),
+		httpFetcher:   detector.NewFetcher(detector.WithTimeout(30 * time.Second)),
+		siteCookies:   make(map[string][]captcha.Cookie),
+		siteStrategy:  make(map[string]string),
+		throttle:      DefaultThrottleConfig(),
+		timeouts:      DefaultTimeouts(),
 	}
 }
 
+// SetThrottleConfig задаёт границы адаптивного батча; применяется к следующим задачам
+func (w *PageWorker) SetThrottleConfig(cfg ThrottleConfig) {
+	w.throttle = cfg.normalized()
+}
+
 func (w *PageWorker) Run(ctx context.Context) error {
 	return w.RunPool(ctx, 1)
 }
@@ -76,19 +89,12 @@ func (w *PageWorker) RunPool(ctx context.Context, workerCount int) error {
 		workerCount = 1
 	}
 
-	consumer, err := nats.NewConsumer(w.natsClient, nats.ConsumerConfig{
-		Stream:        nats.StreamPageCrawlTasks,
-		Consumer:      "page-worker",
-		MaxAckPending: 100, // Shared across all parser instances
-		AckWait:       5 * time.Minute,
-	})
+	consumer, err := nats.NewConsumer(w.natsClient, w.consumerConfig())
 	if err != nil {
 		return fmt.Errorf("create consumer: %w", err)
 	}
 
-	log.Info().Int("workers", workerCount).Msg("page worker pool started")
-
-	return consumer.ConsumePool(ctx, workerCount, func(ctx context.Context, msg *nats.Message) error {
+	pool := consumer.NewDynamicPool(func(ctx context.Context, msg *nats.Message) error {
 		var task queue.PageCrawlTask
 		if err := msg.Unmarshal(&task); err != nil {
 			log.Error().Err(err).Msg("failed to unmarshal page crawl task")
@@ -98,6 +104,35 @@ func (w *PageWorker) RunPool(ctx context.Context, workerCount int) error {
 		w.processTask(&task)
 		return nil
 	})
+
+	w.poolMu.Lock()
+	w.pool = pool
+	w.poolMu.Unlock()
+
+	log.Info().Int("workers", workerCount).Msg("page worker pool started")
+
+	return pool.Run(ctx, workerCount)
+}
+
+// SetConcurrency меняет число воркеров запущенного пула.
+// При уменьшении воркеры останавливаются после завершения текущей задачи
+func (w *PageWorker) SetConcurrency(workerCount int) error {
+	w.poolMu.Lock()
+	pool := w.pool
+	w.poolMu.Unlock()
+
+	if pool == nil {
+		return fmt.Errorf("page worker pool is not running")
+	}
+
+	prev := pool.Size()
+	pool.Resize(workerCount)
+
+	logger.Log.Info().
+		Int("from", prev).
+		Int("to", pool.Size()).
+		Msg("page worker concurrency changed")
+	return nil
 }
 
 func (w *PageWorker) processTask(task *queue.PageCrawlTask) {
@@ -107,6 +142,7 @@ func (w *PageWorker) processTask(task *queue.PageCrawlTask) {
 		Str("site", task.SiteID).
 		Str("domain", task.Domain).
 		Int("batch_size", task.BatchSize).
+		Int("max_pages", task.MaxPages).
 		Msg("page crawl started")
 
 	batchSize := task.BatchSize
@@ -151,6 +187,7 @@ func (w *PageWorker) processTask(task *queue.PageCrawlTask) {
 		Int("success", totalSuccess).
 		Int("failed", totalFailed).
 		Bool("result_success", result.Success).
+		Bool("capped", result.Capped).
 		Msg("page crawl completed")
 }
 
@@ -180,10 +217,38 @@ func (w *PageWorker) processPages(task *queue.PageCrawlTask, result *queue.PageC
 		log.Info().Int("urls_loaded", len(existingURLs)).Msg("bloom filter initialized")
 	}
 
-	log.Info().Str("domain", task.Domain).Msg("starting page processing")
+	wait := pageWaitOptions(task.PageWait, task.ScannerType)
+	taskCtx := dnsOverrideContext(bgCtx, task.SiteID, task.DNSOverride)
+
+	log.Info().Str("domain", task.Domain).Str("page_wait", string(wait.Strategy)).Msg("starting page processing")
+
+	throttle := newPageThrottle(w.throttle, batchSize)
+	var delay time.Duration
 
 	for {
-		fetchResult, err := w.fetchPendingURLs(bgCtx, apiURL, task.SiteID, batchSize)
+		limit, nextDelay := throttle.Adjust()
+		if limit != batchSize || nextDelay != delay {
+			log.Info().
+				Str("site", task.SiteID).
+				Float64("block_rate", throttle.BlockRate()).
+				Int("batch_size", limit).
+				Dur("page_delay", nextDelay).
+				Msg("page crawl throttle adjusted")
+		}
+		batchSize, delay = limit, nextDelay
+
+		if task.MaxPages > 0 {
+			remaining := task.MaxPages - *totalProcessed
+			if remaining <= 0 {
+				log.Info().Str("site", task.SiteID).Int("max_pages", task.MaxPages).Msg("max pages per scan reached, stopping crawl")
+				result.Capped = true
+				break
+			}
+			// Не забираем у индексатора больше URL, чем осталось по лимиту
+			limit = min(limit, remaining)
+		}
+
+		fetchResult, err := w.fetchPendingURLs(bgCtx, apiURL, task.SiteID, limit)
 		if err != nil {
 			log.Error().Err(err).Msg("failed to fetch pending urls")
 			result.Success = false
@@ -204,7 +269,30 @@ func (w *PageWorker) processPages(task *queue.PageCrawlTask, result *queue.PageC
 		urls := fetchResult.URLs
 
 		for _, urlData := range urls {
-			pageResult, html := w.parsePageSPAWithHTML(urlData.URL, task.SiteID, newCookies)
+			if *totalProcessed > 0 && delay > 0 {
+				time.Sleep(delay)
+			}
+			rev := w.revalidate(taskCtx, urlData)
+			if rev.NotModified {
+				notModified := queue.PageSingleResult{
+					TaskID:      task.ID,
+					SiteID:      task.SiteID,
+					URL:         urlData.URL,
+					Success:     true,
+					NotModified: true,
+					Timestamp:   time.Now(),
+				}
+				if err := w.publisher.PublishPageSingleResult(bgCtx, notModified); err != nil {
+					log.Warn().Err(err).Str("url", urlData.URL).Msg("failed to publish single result")
+				}
+				throttle.Record(false)
+				*totalSuccess++
+				*totalProcessed++
+				continue
+			}
+
+			pageResult, html := w.parsePageSPAWithHTML(taskCtx, urlData.URL, task.SiteID, wait, newCookies)
+			w.attachSnapshot(&pageResult, html)
 
 			// Публикуем результат сразу после парсинга
 			singleResult := queue.PageSingleResult{
@@ -242,11 +330,16 @@ func (w *PageWorker) processPages(task *queue.PageCrawlTask, result *queue.PageC
 				w.extractAndPublishLinks(bgCtx, task.ID, task.SiteID, pageDomain, task.Domain, urlData.URL, html, urlData.Depth, bloomFilter)
 			}
 
+			throttle.Record(isSoftBlock(pageResult))
+
 			if pageResult.Success {
 				*totalSuccess++
 			} else {
 				log.Debug().Str("url", urlData.URL).Str("error", pageResult.Error).Msg("page failed, continuing")
 				*totalFailed++
+				if pageResult.Error == errEmptyTitle {
+					result.PagesEmptyTitle++
+				}
 			}
 			*totalProcessed++
 		}
@@ -264,6 +357,9 @@ func (w *PageWorker) processPages(task *queue.PageCrawlTask, result *queue.PageC
 type pendingURLWithDepth struct {
 	URL   string `json:"url"`
 	Depth int    `json:"depth"`
+	// ETag/LastModified — валидаторы страницы с прошлого индексирования
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
 }
 
 type pendingURLsResponse struct {
@@ -297,7 +393,7 @@ func (w *PageWorker) fetchPendingURLs(ctx context.Context, apiURL, siteID string
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainClose(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
@@ -338,7 +434,7 @@ func (w *PageWorker) fetchAllURLs(ctx context.Context, apiURL, siteID string) ([
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainClose(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
@@ -353,12 +449,21 @@ func (w *PageWorker) fetchAllURLs(ctx context.Context, apiURL, siteID string) ([
 	return result.URLs, nil
 }
 
-func (w *PageWorker) parsePageSPA(pageURL, siteID string, newCookies *[]captcha.Cookie) queue.PageResult {
-	result, _ := w.parsePageSPAWithHTML(pageURL, siteID, newCookies)
+// ParsePage загружает и разбирает одну страницу вне задачи краула, ничего не публикуя;
+// ожидание и DNS берутся из настроек сайта, как при краулинге
+func (w *PageWorker) ParsePage(req queue.ParseSyncRequest) queue.PageResult {
+	var cookies []captcha.Cookie
+	wait := pageWaitOptions(req.PageWait, req.ScannerType)
+	ctx := dnsOverrideContext(context.Background(), req.SiteID, req.DNSOverride)
+	return w.parsePageSPA(ctx, req.URL, req.SiteID, wait, &cookies)
+}
+
+func (w *PageWorker) parsePageSPA(parent context.Context, pageURL, siteID string, wait browser.WaitOptions, newCookies *[]captcha.Cookie) queue.PageResult {
+	result, _ := w.parsePageSPAWithHTML(parent, pageURL, siteID, wait, newCookies)
 	return result
 }
 
-func (w *PageWorker) parsePageSPAWithHTML(pageURL, siteID string, newCookies *[]captcha.Cookie) (queue.PageResult, string) {
+func (w *PageWorker) parsePageSPAWithHTML(parent context.Context, pageURL, siteID string, wait browser.WaitOptions, newCookies *[]captcha.Cookie) (queue.PageResult, string) {
 	log := logger.Log
 
 	result := queue.PageResult{
@@ -366,11 +471,11 @@ func (w *PageWorker) parsePageSPAWithHTML(pageURL, siteID string, newCookies *[]
 		Success: false,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(parent, w.timeouts.PageFetch)
 	defer cancel()
 
 	// Use hybrid fetch: HTTP first, then browser if needed
-	fetchResult, err := w.fetchPageHybrid(ctx, pageURL, siteID, newCookies)
+	fetchResult, err := w.fetchPageHybrid(ctx, pageURL, siteID, wait, newCookies)
 	if err != nil {
 		log.Warn().Err(err).Str("url", pageURL).Msg("page fetch failed")
 		result.Error = err.Error()
@@ -387,6 +492,19 @@ func (w *PageWorker) parsePageSPAWithHTML(pageURL, siteID string, newCookies *[]
 		*newCookies = fetchResult.Cookies
 		log.Info().Str("url", pageURL).Int("cookies", len(fetchResult.Cookies)).Msg("new cookies from page")
 	}
+	result.Captcha = fetchResult.CaptchaSolved
+
+	if fetchResult.StatusCode == http.StatusNotFound || fetchResult.StatusCode == http.StatusGone {
+		result.Error = fmt.Sprintf("http status %d", fetchResult.StatusCode)
+		result.Gone = true
+		return result, fetchResult.HTML
+	}
+
+	if parking := pageParking.Detect(fetchResult.HTML); parking.Parked {
+		result.Error = "parked domain: " + parking.Signature
+		result.Gone = true
+		return result, fetchResult.HTML
+	}
 
 	page, err := w.extractor.Extract(fetchResult.HTML, pageURL, siteID, 200)
 	if err != nil {
@@ -396,7 +514,7 @@ func (w *PageWorker) parsePageSPAWithHTML(pageURL, siteID string, newCookies *[]
 	page.IndexedAt = time.Now()
 
 	if page.Title == "" {
-		result.Error = "empty title"
+		result.Error = errEmptyTitle
 		return result, fetchResult.HTML
 	}
 
@@ -406,44 +524,12 @@ func (w *PageWorker) parsePageSPAWithHTML(pageURL, siteID string, newCookies *[]
 	}
 
 	result.Success = true
-	result.Page = w.convertPageToQueueData(page)
+	result.Page = extractor.ToPageData(page)
+	withValidators(&result, fetchResult.Validators)
 
 	return result, fetchResult.HTML
 }
 
-func (w *PageWorker) convertPageToQueueData(page *models.Page) *queue.PageData {
-	externalIDs := make(map[string]string)
-	if page.ExternalIDs.KinopoiskID != "" {
-		externalIDs["kinopoisk_id"] = page.ExternalIDs.KinopoiskID
-	}
-	if page.ExternalIDs.IMDBID != "" {
-		externalIDs["imdb_id"] = page.ExternalIDs.IMDBID
-	}
-	if page.ExternalIDs.TMDBID != "" {
-		externalIDs["tmdb_id"] = page.ExternalIDs.TMDBID
-	}
-	if page.ExternalIDs.MALID != "" {
-		externalIDs["mal_id"] = page.ExternalIDs.MALID
-	}
-	if page.ExternalIDs.ShikimoriID != "" {
-		externalIDs["shikimori_id"] = page.ExternalIDs.ShikimoriID
-	}
-	if page.ExternalIDs.MyDramaListID != "" {
-		externalIDs["mydramalist_id"] = page.ExternalIDs.MyDramaListID
-	}
-
-	return &queue.PageData{
-		URL:         page.URL,
-		Title:       page.Title,
-		Description: page.Description,
-		MainText:    page.MainText,
-		Year:        page.Year,
-		PlayerURL:   page.PlayerURL,
-		LinksText:   page.LinksText,
-		ExternalIDs: externalIDs,
-	}
-}
-
 func (w *PageWorker) convertTaskCookies(taskCookies []queue.CookieData) []captcha.Cookie {
 	cookies := make([]captcha.Cookie, len(taskCookies))
 	for i, tc := range taskCookies {
@@ -485,6 +571,9 @@ var badTitlePatterns = []string{
 	"доступ запрещен",
 }
 
+// pageParking распознаёт страницы парковки домена вместо сайта
+var pageParking = detector.NewParkingDetector(nil)
+
 func isBadTitle(title string) bool {
 	lower := strings.ToLower(strings.TrimSpace(title))
 	for _, pattern := range badTitlePatterns {
@@ -659,11 +748,42 @@ func (w *PageWorker) convertCookiesToDetector(cookies []captcha.Cookie) []detect
 	return result
 }
 
+// pageWaitOptions переводит настройку сайта из задачи в опции браузера;
+// без явной настройки SPA-сайты ждут затишья сети, чтобы успел отработать JS
+func pageWaitOptions(w *queue.PageWait, scannerType string) browser.WaitOptions {
+	if w == nil {
+		if scannerType == string(status.ScannerSPA) {
+			return browser.WaitOptions{Strategy: browser.WaitNetworkIdle}
+		}
+		return browser.WaitOptions{}
+	}
+	return browser.WaitOptions{
+		Strategy: browser.WaitStrategy(w.Strategy),
+		Delay:    time.Duration(w.DelayMs) * time.Millisecond,
+		Selector: w.Selector,
+		Timeout:  time.Duration(w.TimeoutMs) * time.Millisecond,
+	}
+}
+
+// dnsOverrideContext включает переопределение DNS сайта для загрузок с этим контекстом;
+// невалидное переопределение пропускается, сайт обходится через системный DNS
+func dnsOverrideContext(ctx context.Context, siteID string, o *queue.DNSOverride) context.Context {
+	if o == nil {
+		return ctx
+	}
+	dns, err := browser.ParseDNSOverride(o.Resolver, o.Hosts)
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("site", siteID).Msg("invalid dns override, using system resolver")
+		return ctx
+	}
+	return browser.WithDNSOverride(ctx, dns)
+}
+
 // fetchPageHybrid tries HTTP first, falls back to browser if blocked/captcha
 // TODO: HTTP fetcher disabled for now - using browser only
-func (w *PageWorker) fetchPageHybrid(ctx context.Context, pageURL, siteID string, newCookies *[]captcha.Cookie) (*browser.FetchResult, error) {
+func (w *PageWorker) fetchPageHybrid(ctx context.Context, pageURL, siteID string, wait browser.WaitOptions, newCookies *[]captcha.Cookie) (*browser.FetchResult, error) {
 	// Browser only mode
-	browserResult, err := browser.Get().FetchPage(ctx, pageURL)
+	browserResult, err := browser.Get().FetchPageWithWait(ctx, pageURL, wait)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// standardSitemapPaths — где карту сайта ищут, если robots.txt о ней молчит
+var standardSitemapPaths = []string{
+	"/sitemap.xml",
+	"/sitemap_index.xml",
+	"/sitemap-index.xml",
+	"/wp-sitemap.xml", // WordPress 5.5+
+	"/sitemap.txt",
+	"/post-sitemap.xml", // Yoast SEO
+	"/sitemap.json",
+	"/sitemap.html",
+}
+
+// Браузер отдаёт robots.txt обёрнутым в HTML, поэтому ищем директивы по всему тексту, а не по строкам
+var robotsSitemapRegex = regexp.MustCompile(`(?i)\bsitemap\s*:\s*(https?://[^\s<>"']+)`)
+
+// parseRobotsSitemaps извлекает URL из директив Sitemap: без повторов, в порядке появления
+func parseRobotsSitemaps(body string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, match := range robotsSitemapRegex.FindAllStringSubmatch(body, -1) {
+		u := strings.TrimSpace(html.UnescapeString(match[1]))
+		if !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// sitemapCandidates — порядок проверки: карты из robots.txt, затем стандартные пути
+func sitemapCandidates(baseURL string, robotsSitemaps []string) []string {
+	candidates := make([]string, 0, len(robotsSitemaps)+len(standardSitemapPaths))
+	seen := make(map[string]bool)
+	add := func(u string) {
+		if !seen[u] {
+			seen[u] = true
+			candidates = append(candidates, u)
+		}
+	}
+
+	for _, u := range robotsSitemaps {
+		add(u)
+	}
+	for _, path := range standardSitemapPaths {
+		add(baseURL + path)
+	}
+	return candidates
+}
@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRobotsSitemaps(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "plain robots.txt",
+			body: "User-agent: *\nDisallow: /admin\nSitemap: https://kino.example/sitemap.xml\nsitemap:https://kino.example/films.txt\n",
+			want: []string{"https://kino.example/sitemap.xml", "https://kino.example/films.txt"},
+		},
+		{
+			name: "browser html wrapper",
+			body: `<html><head></head><body><pre style="word-wrap: break-word;">User-agent: *
+SITEMAP: https://kino.example/sitemap.php?type=films&amp;page=1
+Host: kino.example</pre></body></html>`,
+			want: []string{"https://kino.example/sitemap.php?type=films&page=1"},
+		},
+		{
+			name: "no extension and duplicates",
+			body: "Sitemap: https://kino.example/sitemap\nSitemap: https://kino.example/sitemap\n",
+			want: []string{"https://kino.example/sitemap"},
+		},
+		{
+			name: "relative and commented out are ignored",
+			body: "Sitemap: /sitemap.xml\n# no sitemaps here\nUser-agent: *",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRobotsSitemaps(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRobotsSitemaps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSitemapCandidates(t *testing.T) {
+	got := sitemapCandidates("https://kino.example", []string{
+		"https://cdn.example/maps/films.xml",
+		"https://kino.example/sitemap.xml",
+	})
+
+	want := []string{
+		"https://cdn.example/maps/films.xml",
+		"https://kino.example/sitemap.xml",
+		"https://kino.example/sitemap_index.xml",
+		"https://kino.example/sitemap-index.xml",
+		"https://kino.example/wp-sitemap.xml",
+		"https://kino.example/sitemap.txt",
+		"https://kino.example/post-sitemap.xml",
+		"https://kino.example/sitemap.json",
+		"https://kino.example/sitemap.html",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sitemapCandidates() =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -19,11 +19,7 @@ import (
 	"github.com/video-analitics/parser/internal/crawler"
 )
 
-const (
-	urlBatchSize        = 1000
-	inactivityTimeout   = 5 * time.Minute  // таймаут на неактивность (нет прогресса)
-	maxSitemapTaskTime  = 30 * time.Minute // максимальное время на задачу (hard limit)
-)
+const urlBatchSize = 1000
 
 // sitemapBlacklistRe matches sitemap URLs we want to skip (character, people, author, tag, category)
 var sitemapBlacklistRe = regexp.MustCompile(`(?i)(character|people|author|tag|category).*\.xml`)
@@ -36,6 +32,10 @@ func shouldSkipSitemap(sitemapURL string) bool {
 type SitemapWorker struct {
 	natsClient *nats.Client
 	publisher  *nats.Publisher
+	timeouts   Timeouts
+
+	// Загрузка карты сайта через браузер; подменяется в тестах
+	fetchSitemap func(ctx context.Context, url string) (*browser.FetchResult, error)
 }
 
 // inactivityContext creates a context that cancels after inactivity period
@@ -89,6 +89,10 @@ func NewSitemapWorker(natsClient *nats.Client) *SitemapWorker {
 	return &SitemapWorker{
 		natsClient: natsClient,
 		publisher:  nats.NewPublisher(natsClient),
+		timeouts:   DefaultTimeouts(),
+		fetchSitemap: func(ctx context.Context, url string) (*browser.FetchResult, error) {
+			return browser.Get().FetchSitemap(ctx, url)
+		},
 	}
 }
 
@@ -103,11 +107,7 @@ func (w *SitemapWorker) RunPool(ctx context.Context, workerCount int) error {
 		workerCount = 1
 	}
 
-	consumer, err := nats.NewConsumer(w.natsClient, nats.ConsumerConfig{
-		Stream:        nats.StreamSitemapCrawlTasks,
-		Consumer:      "sitemap-worker",
-		MaxAckPending: workerCount * 2,
-	})
+	consumer, err := nats.NewConsumer(w.natsClient, w.consumerConfig(workerCount))
 	if err != nil {
 		return fmt.Errorf("create consumer: %w", err)
 	}
@@ -135,7 +135,8 @@ func (w *SitemapWorker) processTask(ctx context.Context, task *queue.SitemapCraw
 		Int("sitemaps", len(task.SitemapURLs)).
 		Msg("sitemap crawl task received")
 
-	ic := newInactivityContext(ctx, inactivityTimeout, maxSitemapTaskTime)
+	ctx = dnsOverrideContext(ctx, task.SiteID, task.DNSOverride)
+	ic := newInactivityContext(ctx, w.timeouts.SitemapInactivity, w.timeouts.SitemapTask)
 	defer ic.stop()
 
 	// Если нет sitemap URLs, добавляем главную страницу как seed
@@ -201,6 +202,11 @@ func (w *SitemapWorker) processSitemapCrawl(ic *inactivityContext, task *queue.S
 	cookies := w.convertTaskCookies(task.Cookies)
 	var newCookies []captcha.Cookie
 
+	sitemapOpts := crawler.SitemapOptions{
+		Format:   crawler.SitemapFormat(task.SitemapFormat),
+		JSONPath: task.SitemapJSONPath,
+	}
+
 	var totalURLs int32
 	var batchNumber int32
 	var sitemapStats []queue.SitemapStat
@@ -251,7 +257,7 @@ func (w *SitemapWorker) processSitemapCrawl(ic *inactivityContext, task *queue.S
 		stat := queue.SitemapStat{URL: sitemapURL}
 		urlsBeforeParse := atomic.LoadInt32(&totalURLs)
 
-		parsedCookies, err := w.parseSitemapStreaming(ctx, sitemapURL, cookies, ic.keepAlive, onURLs)
+		parsedCookies, err := w.parseSitemapStreaming(ctx, sitemapURL, sitemapOpts, cookies, ic.keepAlive, onURLs)
 
 		urlsAfterParse := atomic.LoadInt32(&totalURLs)
 		stat.URLsFound = int(urlsAfterParse - urlsBeforeParse)
@@ -290,10 +296,10 @@ func (w *SitemapWorker) processSitemapCrawl(ic *inactivityContext, task *queue.S
 	if timedOut {
 		if finalTotalURLs > 0 {
 			result.Success = true // partial success - we collected some URLs before timeout
-			result.Error = fmt.Sprintf("task timed out (inactivity: %v, max: %v), collected %d urls", inactivityTimeout, maxSitemapTaskTime, finalTotalURLs)
+			result.Error = fmt.Sprintf("task timed out (inactivity: %v, max: %v), collected %d urls", w.timeouts.SitemapInactivity, w.timeouts.SitemapTask, finalTotalURLs)
 		} else {
 			result.Success = false
-			result.Error = fmt.Sprintf("task timed out (inactivity: %v, max: %v), no urls collected", inactivityTimeout, maxSitemapTaskTime)
+			result.Error = fmt.Sprintf("task timed out (inactivity: %v, max: %v), no urls collected", w.timeouts.SitemapInactivity, w.timeouts.SitemapTask)
 		}
 	} else if finalTotalURLs == 0 && len(task.SitemapURLs) > 0 {
 		result.Success = false
@@ -323,12 +329,12 @@ type urlsCallback func(urls []crawler.ParsedURL, source string)
 
 // parseSitemapStreaming parses sitemap and calls onURLs callback immediately for each leaf sitemap
 // This allows publishing URL batches as soon as they're available, not waiting for all sitemaps
-func (w *SitemapWorker) parseSitemapStreaming(ctx context.Context, sitemapURL string, cookies []captcha.Cookie, onProgress progressCallback, onURLs urlsCallback) ([]captcha.Cookie, error) {
+func (w *SitemapWorker) parseSitemapStreaming(ctx context.Context, sitemapURL string, opts crawler.SitemapOptions, cookies []captcha.Cookie, onProgress progressCallback, onURLs urlsCallback) ([]captcha.Cookie, error) {
 	visited := make(map[string]bool)
-	return w.parseSitemapStreamingRecursive(ctx, sitemapURL, cookies, 0, visited, onProgress, onURLs)
+	return w.parseSitemapStreamingRecursive(ctx, sitemapURL, opts, cookies, 0, visited, onProgress, onURLs)
 }
 
-func (w *SitemapWorker) parseSitemapStreamingRecursive(ctx context.Context, sitemapURL string, cookies []captcha.Cookie, depth int, visited map[string]bool, onProgress progressCallback, onURLs urlsCallback) ([]captcha.Cookie, error) {
+func (w *SitemapWorker) parseSitemapStreamingRecursive(ctx context.Context, sitemapURL string, opts crawler.SitemapOptions, cookies []captcha.Cookie, depth int, visited map[string]bool, onProgress progressCallback, onURLs urlsCallback) ([]captcha.Cookie, error) {
 	log := logger.Log
 
 	if depth > maxSitemapDepth {
@@ -348,14 +354,19 @@ func (w *SitemapWorker) parseSitemapStreamingRecursive(ctx context.Context, site
 		}
 	}
 
+	// Архив браузер не отобразит — сразу качаем по HTTP и распаковываем
+	if crawler.IsGzipSitemapURL(sitemapURL) {
+		return w.fetchSitemapHTTP(ctx, sitemapURL, opts, cookies, depth, visited, onProgress, onURLs)
+	}
+
 	log.Debug().Str("url", sitemapURL).Int("depth", depth).Msg("fetching sitemap via global browser")
 
-	result, err := browser.Get().FetchSitemap(ctx, sitemapURL)
+	result, err := w.fetchSitemap(ctx, sitemapURL)
 	if err != nil {
 		// If browser timed out, try HTTP stream as fallback (faster for large XML files)
 		if ctx.Err() == nil && (strings.Contains(err.Error(), "deadline exceeded") || strings.Contains(err.Error(), "TIMED_OUT")) {
 			log.Info().Str("url", sitemapURL).Msg("browser timeout, trying HTTP fallback")
-			return w.fetchSitemapHTTP(ctx, sitemapURL, cookies, depth, visited, onProgress, onURLs)
+			return w.fetchSitemapHTTP(ctx, sitemapURL, opts, cookies, depth, visited, onProgress, onURLs)
 		}
 		return cookies, err
 	}
@@ -374,8 +385,8 @@ func (w *SitemapWorker) parseSitemapStreamingRecursive(ctx context.Context, site
 		newCookies = result.Cookies
 	}
 
-	// Parse XML from fetched content
-	parsed, err := crawler.ParseSitemapXML(result.HTML, sitemapURL)
+	// Parse fetched content (XML, JSON, HTML, plain text)
+	parsed, err := crawler.ParseSitemapContent(result.HTML, sitemapURL, opts)
 	if err != nil {
 		return newCookies, err
 	}
@@ -412,7 +423,7 @@ func (w *SitemapWorker) parseSitemapStreamingRecursive(ctx context.Context, site
 				log.Info().Str("sitemap", nestedURL).Msg("skipping blacklisted sitemap")
 				continue
 			}
-			updatedCookies, err := w.parseSitemapStreamingRecursive(ctx, nestedURL, newCookies, depth+1, visited, onProgress, onURLs)
+			updatedCookies, err := w.parseSitemapStreamingRecursive(ctx, nestedURL, opts, newCookies, depth+1, visited, onProgress, onURLs)
 			if err != nil {
 				log.Warn().Err(err).Str("sitemap", nestedURL).Msg("nested sitemap failed")
 				if ctx.Err() != nil {
@@ -437,10 +448,10 @@ func (w *SitemapWorker) parseSitemapStreamingRecursive(ctx context.Context, site
 }
 
 // fetchSitemapHTTP fetches sitemap via HTTP (fallback for large files that timeout in browser)
-func (w *SitemapWorker) fetchSitemapHTTP(ctx context.Context, sitemapURL string, cookies []captcha.Cookie, depth int, visited map[string]bool, onProgress progressCallback, onURLs urlsCallback) ([]captcha.Cookie, error) {
+func (w *SitemapWorker) fetchSitemapHTTP(ctx context.Context, sitemapURL string, opts crawler.SitemapOptions, cookies []captcha.Cookie, depth int, visited map[string]bool, onProgress progressCallback, onURLs urlsCallback) ([]captcha.Cookie, error) {
 	log := logger.Log
 
-	client := &http.Client{Timeout: 2 * time.Minute}
+	client := &http.Client{Timeout: 2 * time.Minute, Transport: browser.HTTPTransport(ctx)}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", sitemapURL, nil)
 	if err != nil {
@@ -476,6 +487,9 @@ func (w *SitemapWorker) fetchSitemapHTTP(ctx context.Context, sitemapURL string,
 	if err != nil {
 		return cookies, fmt.Errorf("read body: %w", err)
 	}
+	if body, err = crawler.DecompressSitemap(body); err != nil {
+		return cookies, err
+	}
 
 	log.Info().Str("url", sitemapURL).Int("size", len(body)).Msg("sitemap fetched via HTTP fallback")
 
@@ -483,10 +497,10 @@ func (w *SitemapWorker) fetchSitemapHTTP(ctx context.Context, sitemapURL string,
 		onProgress()
 	}
 
-	// Parse XML
-	parsed, err := crawler.ParseSitemapXML(string(body), sitemapURL)
+	// Parse sitemap content
+	parsed, err := crawler.ParseSitemapContent(string(body), sitemapURL, opts)
 	if err != nil {
-		return cookies, fmt.Errorf("parse xml: %w", err)
+		return cookies, fmt.Errorf("parse sitemap: %w", err)
 	}
 
 	// Publish page URLs immediately
@@ -504,8 +518,12 @@ func (w *SitemapWorker) fetchSitemapHTTP(ctx context.Context, sitemapURL string,
 		if ctx.Err() != nil {
 			break
 		}
+		if shouldSkipSitemap(nestedURL) {
+			log.Info().Str("sitemap", nestedURL).Msg("skipping blacklisted sitemap")
+			continue
+		}
 		// For nested sitemaps from HTTP fallback, try browser first again
-		_, err := w.parseSitemapStreamingRecursive(ctx, nestedURL, cookies, depth+1, visited, onProgress, onURLs)
+		_, err := w.parseSitemapStreamingRecursive(ctx, nestedURL, opts, cookies, depth+1, visited, onProgress, onURLs)
 		if err != nil {
 			log.Warn().Err(err).Str("sitemap", nestedURL).Msg("nested sitemap failed")
 		}
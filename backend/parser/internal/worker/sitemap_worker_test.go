@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/video-analitics/parser/internal/browser"
+	"github.com/video-analitics/parser/internal/crawler"
+)
+
+func gzipBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseSitemapStreamingGzipChild(t *testing.T) {
+	var skippedHits atomic.Int32
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	films := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>%[1]s/film/1</loc></url>
+  <url><loc>%[1]s/film/2</loc></url>
+</urlset>`, srv.URL)
+	filmsGz := gzipBytes(t, films)
+
+	mux.HandleFunc("/sitemap-films.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-gzip")
+		w.Write(filmsGz)
+	})
+	mux.HandleFunc("/people-sitemap.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		skippedHits.Add(1)
+		w.Write(gzipBytes(t, films))
+	})
+
+	index := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>%[1]s/sitemap-films.xml.gz</loc></sitemap>
+  <sitemap><loc>%[1]s/people-sitemap.xml.gz</loc></sitemap>
+</sitemapindex>`, srv.URL)
+
+	var browserFetched []string
+	w := &SitemapWorker{
+		fetchSitemap: func(ctx context.Context, url string) (*browser.FetchResult, error) {
+			browserFetched = append(browserFetched, url)
+			return &browser.FetchResult{HTML: index}, nil
+		},
+	}
+
+	published := map[string][]string{}
+	onURLs := func(urls []crawler.ParsedURL, source string) {
+		for _, u := range urls {
+			published[source] = append(published[source], u.Loc)
+		}
+	}
+
+	indexURL := srv.URL + "/sitemap.xml"
+	if _, err := w.parseSitemapStreaming(context.Background(), indexURL, crawler.SitemapOptions{}, nil, nil, onURLs); err != nil {
+		t.Fatal(err)
+	}
+
+	// Архивы в браузер не отправляются
+	if !reflect.DeepEqual(browserFetched, []string{indexURL}) {
+		t.Errorf("browser fetched %v, want only the index", browserFetched)
+	}
+	want := map[string][]string{
+		srv.URL + "/sitemap-films.xml.gz": {srv.URL + "/film/1", srv.URL + "/film/2"},
+	}
+	if !reflect.DeepEqual(published, want) {
+		t.Errorf("published = %v, want %v", published, want)
+	}
+	if n := skippedHits.Load(); n != 0 {
+		t.Errorf("blacklisted sitemap requested %d times", n)
+	}
+}
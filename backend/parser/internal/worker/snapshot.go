@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"github.com/video-analitics/backend/pkg/logger"
+	"github.com/video-analitics/backend/pkg/queue"
+	"github.com/video-analitics/backend/pkg/snapshot"
+)
+
+// maxSnapshotBytes — более крупные сжатые снимки не передаются: сообщение NATS ограничено 1 МБ
+const maxSnapshotBytes = 512 << 10
+
+// SetHTMLSnapshots включает передачу HTML страниц в индексер для хранения как доказательства
+func (w *PageWorker) SetHTMLSnapshots(enabled bool) {
+	w.htmlSnapshots = enabled
+}
+
+// attachSnapshot прикладывает к странице сжатый HTML, из которого она разобрана
+func (w *PageWorker) attachSnapshot(result *queue.PageResult, html string) {
+	if !w.htmlSnapshots || result.Page == nil || html == "" {
+		return
+	}
+	data, err := snapshot.Compress([]byte(html))
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("url", result.URL).Msg("failed to compress html snapshot")
+		return
+	}
+	if len(data) > maxSnapshotBytes {
+		logger.Log.Debug().Str("url", result.URL).Int("bytes", len(data)).Msg("html snapshot too large, skipped")
+		return
+	}
+	result.Page.HTMLSnapshot = data
+}
@@ -0,0 +1,139 @@
+package worker
+
+import (
+	"strings"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/queue"
+)
+
+// ThrottleConfig — границы адаптивного размера батча и паузы между страницами
+type ThrottleConfig struct {
+	MinBatch int
+	MaxBatch int
+	// Window — по скольким последним страницам считается доля блокировок
+	Window int
+	// BlockRateThreshold — при доле блокировок выше порога батч уменьшается, пауза растёт
+	BlockRateThreshold float64
+	// DelayStep — на сколько растёт пауза между страницами при каждом замедлении
+	DelayStep time.Duration
+	MaxDelay  time.Duration
+}
+
+func DefaultThrottleConfig() ThrottleConfig {
+	return ThrottleConfig{
+		MinBatch:           5,
+		MaxBatch:           100,
+		Window:             20,
+		BlockRateThreshold: 0.2,
+		DelayStep:          2 * time.Second,
+		MaxDelay:           30 * time.Second,
+	}
+}
+
+func (c ThrottleConfig) normalized() ThrottleConfig {
+	def := DefaultThrottleConfig()
+	if c.MinBatch <= 0 {
+		c.MinBatch = def.MinBatch
+	}
+	if c.MaxBatch < c.MinBatch {
+		c.MaxBatch = max(def.MaxBatch, c.MinBatch)
+	}
+	if c.Window <= 0 {
+		c.Window = def.Window
+	}
+	if c.BlockRateThreshold <= 0 || c.BlockRateThreshold >= 1 {
+		c.BlockRateThreshold = def.BlockRateThreshold
+	}
+	if c.DelayStep < 0 {
+		c.DelayStep = 0
+	}
+	if c.MaxDelay < 0 {
+		c.MaxDelay = 0
+	}
+	return c
+}
+
+// pageThrottle подстраивает размер батча и паузу под долю блокировок/капч
+// в последних Window страницах: при росте блокировок батч делится пополам,
+// а пауза растёт на DelayStep; пока всё спокойно — батч растёт до MaxBatch, пауза снимается
+type pageThrottle struct {
+	cfg      ThrottleConfig
+	outcomes []bool // кольцевой буфер: true — страница заблокирована
+	next     int
+	filled   int
+	blocked  int
+	batch    int
+	delay    time.Duration
+}
+
+func newPageThrottle(cfg ThrottleConfig, batch int) *pageThrottle {
+	cfg = cfg.normalized()
+	return &pageThrottle{
+		cfg:      cfg,
+		outcomes: make([]bool, cfg.Window),
+		batch:    min(max(batch, cfg.MinBatch), cfg.MaxBatch),
+	}
+}
+
+// Record учитывает результат очередной страницы
+func (t *pageThrottle) Record(blocked bool) {
+	if t.filled == len(t.outcomes) {
+		if t.outcomes[t.next] {
+			t.blocked--
+		}
+	} else {
+		t.filled++
+	}
+	t.outcomes[t.next] = blocked
+	if blocked {
+		t.blocked++
+	}
+	t.next = (t.next + 1) % len(t.outcomes)
+}
+
+func (t *pageThrottle) BlockRate() float64 {
+	if t.filled == 0 {
+		return 0
+	}
+	return float64(t.blocked) / float64(t.filled)
+}
+
+// Adjust пересчитывает размер следующего батча и паузу между страницами
+func (t *pageThrottle) Adjust() (batch int, delay time.Duration) {
+	if t.filled == 0 {
+		return t.batch, t.delay
+	}
+
+	rate := t.BlockRate()
+	switch {
+	case rate > t.cfg.BlockRateThreshold:
+		t.batch = max(t.batch/2, t.cfg.MinBatch)
+		t.delay = min(t.delay+t.cfg.DelayStep, t.cfg.MaxDelay)
+	case rate <= t.cfg.BlockRateThreshold/2:
+		// Растём плавно, чтобы не вернуться сразу под блокировку
+		t.batch = min(t.batch+max(t.batch/4, 1), t.cfg.MaxBatch)
+		t.delay = max(t.delay-t.cfg.DelayStep, 0)
+	}
+	return t.batch, t.delay
+}
+
+var accessDeniedTitlePatterns = []string{
+	"403 forbidden",
+	"access denied",
+	"доступ запрещен",
+}
+
+// isSoftBlock — страница не остановила краул, но похожа на реакцию антибота
+func isSoftBlock(result queue.PageResult) bool {
+	if result.Captcha {
+		return true
+	}
+	lower := strings.ToLower(result.Error)
+	for _, pattern := range accessDeniedTitlePatterns {
+		if strings.Contains(lower, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/queue"
+)
+
+func TestPageThrottleAdjust(t *testing.T) {
+	cfg := ThrottleConfig{
+		MinBatch:           5,
+		MaxBatch:           60,
+		Window:             10,
+		BlockRateThreshold: 0.2,
+		DelayStep:          time.Second,
+		MaxDelay:           3 * time.Second,
+	}
+
+	type step struct {
+		blocked   int // сколько из 10 страниц батча заблокировано
+		wantBatch int
+		wantDelay time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		start int
+		steps []step
+	}{
+		{
+			name:  "smooth crawl grows to max",
+			start: 40,
+			steps: []step{
+				{blocked: 0, wantBatch: 50},
+				{blocked: 0, wantBatch: 60},
+				{blocked: 0, wantBatch: 60},
+			},
+		},
+		{
+			name:  "blocks shrink batch and add delay",
+			start: 40,
+			steps: []step{
+				{blocked: 5, wantBatch: 20, wantDelay: time.Second},
+				{blocked: 5, wantBatch: 10, wantDelay: 2 * time.Second},
+				{blocked: 5, wantBatch: 5, wantDelay: 3 * time.Second},
+				{blocked: 5, wantBatch: 5, wantDelay: 3 * time.Second},
+			},
+		},
+		{
+			name:  "recovers after blocks stop",
+			start: 40,
+			steps: []step{
+				{blocked: 10, wantBatch: 20, wantDelay: time.Second},
+				{blocked: 0, wantBatch: 25},
+				{blocked: 0, wantBatch: 31},
+			},
+		},
+		{
+			name:  "moderate block rate holds",
+			start: 40,
+			steps: []step{
+				{blocked: 2, wantBatch: 40},
+			},
+		},
+		{
+			name:  "start clamped to bounds",
+			start: 500,
+			steps: []step{
+				{blocked: 0, wantBatch: 60},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := newPageThrottle(cfg, tt.start)
+			for i, s := range tt.steps {
+				for p := 0; p < 10; p++ {
+					th.Record(p < s.blocked)
+				}
+				batch, delay := th.Adjust()
+				if batch != s.wantBatch || delay != s.wantDelay {
+					t.Errorf("step %d: got (%d, %v), want (%d, %v)", i, batch, delay, s.wantBatch, s.wantDelay)
+				}
+			}
+		})
+	}
+}
+
+func TestPageThrottleWindow(t *testing.T) {
+	th := newPageThrottle(ThrottleConfig{Window: 4}, 50)
+	for _, blocked := range []bool{true, true, false, false, false, false} {
+		th.Record(blocked)
+	}
+	if got := th.BlockRate(); got != 0 {
+		t.Errorf("BlockRate() = %v, want 0 after blocks left the window", got)
+	}
+
+	if batch, _ := newPageThrottle(ThrottleConfig{}, 50).Adjust(); batch != 50 {
+		t.Errorf("Adjust() without pages = %d, want 50", batch)
+	}
+}
+
+func TestIsSoftBlock(t *testing.T) {
+	tests := []struct {
+		result queue.PageResult
+		want   bool
+	}{
+		{queue.PageResult{Success: true}, false},
+		{queue.PageResult{Success: true, Captcha: true}, true},
+		{queue.PageResult{Error: "error page title: 403 Forbidden"}, true},
+		{queue.PageResult{Error: "error page title: Доступ запрещен"}, true},
+		{queue.PageResult{Error: "error page title: 404 Not Found"}, false},
+		{queue.PageResult{Error: "empty title"}, false},
+	}
+	for _, tt := range tests {
+		if got := isSoftBlock(tt.result); got != tt.want {
+			t.Errorf("isSoftBlock(%+v) = %v, want %v", tt.result, got, tt.want)
+		}
+	}
+}
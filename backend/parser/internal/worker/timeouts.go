@@ -0,0 +1,63 @@
+package worker
+
+import "time"
+
+// Timeouts — таймауты этапов обхода; нулевые и отрицательные значения заменяются значениями по умолчанию
+type Timeouts struct {
+	// SitemapInactivity — сколько задача карты сайта может идти без прогресса
+	SitemapInactivity time.Duration
+	// SitemapTask — жёсткий предел одной задачи карты сайта
+	SitemapTask time.Duration
+	// PageFetch — загрузка и разбор одной страницы (HTTP и браузер вместе)
+	PageFetch time.Duration
+	// PageRevalidate — условный HEAD перед рендером
+	PageRevalidate time.Duration
+	// PageAckWait — сколько NATS ждёт подтверждения задачи page-воркера до повторной доставки
+	PageAckWait time.Duration
+	// SitemapAckWait — то же для задач карты сайта
+	SitemapAckWait time.Duration
+}
+
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		SitemapInactivity: 5 * time.Minute,
+		SitemapTask:       30 * time.Minute,
+		PageFetch:         60 * time.Second,
+		PageRevalidate:    10 * time.Second,
+		PageAckWait:       5 * time.Minute,
+		SitemapAckWait:    defaultAckWait,
+	}
+}
+
+func (t Timeouts) normalized() Timeouts {
+	def := DefaultTimeouts()
+	if t.SitemapInactivity <= 0 {
+		t.SitemapInactivity = def.SitemapInactivity
+	}
+	if t.SitemapTask <= 0 {
+		t.SitemapTask = def.SitemapTask
+	}
+	if t.PageFetch <= 0 {
+		t.PageFetch = def.PageFetch
+	}
+	if t.PageRevalidate <= 0 {
+		t.PageRevalidate = def.PageRevalidate
+	}
+	if t.PageAckWait <= 0 {
+		t.PageAckWait = def.PageAckWait
+	}
+	if t.SitemapAckWait <= 0 {
+		t.SitemapAckWait = def.SitemapAckWait
+	}
+	return t
+}
+
+// SetTimeouts задаёт таймауты page-воркера; AckWait применяется при следующем запуске пула
+func (w *PageWorker) SetTimeouts(t Timeouts) {
+	w.timeouts = t.normalized()
+}
+
+// SetTimeouts задаёт таймауты задач карты сайта; AckWait применяется при следующем запуске пула
+func (w *SitemapWorker) SetTimeouts(t Timeouts) {
+	w.timeouts = t.normalized()
+}
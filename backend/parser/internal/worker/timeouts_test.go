@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimeoutsNormalized(t *testing.T) {
+	def := DefaultTimeouts()
+
+	tests := []struct {
+		name string
+		in   Timeouts
+		want Timeouts
+	}{
+		{"zero falls back to defaults", Timeouts{}, def},
+		{
+			name: "negative falls back, positive kept",
+			in:   Timeouts{SitemapInactivity: -time.Second, SitemapTask: time.Hour, PageFetch: 2 * time.Minute},
+			want: Timeouts{
+				SitemapInactivity: def.SitemapInactivity,
+				SitemapTask:       time.Hour,
+				PageFetch:         2 * time.Minute,
+				PageRevalidate:    def.PageRevalidate,
+				PageAckWait:       def.PageAckWait,
+				SitemapAckWait:    def.SitemapAckWait,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.in.normalized(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalized() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
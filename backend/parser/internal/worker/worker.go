@@ -17,6 +17,7 @@ import (
 type Worker struct {
 	natsClient *nats.Client
 	publisher  *nats.Publisher
+	ackWait    time.Duration
 }
 
 func New(natsClient *nats.Client) *Worker {
@@ -37,11 +38,7 @@ func (w *Worker) RunPool(ctx context.Context, workerCount int) error {
 		workerCount = 1
 	}
 
-	consumer, err := nats.NewConsumer(w.natsClient, nats.ConsumerConfig{
-		Stream:        nats.StreamCrawlTasks,
-		Consumer:      "crawl-worker",
-		MaxAckPending: workerCount * 2,
-	})
+	consumer, err := nats.NewConsumer(w.natsClient, w.consumerConfig(workerCount))
 	if err != nil {
 		return fmt.Errorf("create consumer: %w", err)
 	}
@@ -0,0 +1,77 @@
+package detector
+
+import "strings"
+
+// DefaultParkingSignatures — признаки страниц парковки регистраторов и площадок продажи доменов.
+// Совпадение любой из них считается парковкой независимо от размера страницы
+var DefaultParkingSignatures = []string{
+	"sedoparking.com",
+	"sedo.com/search/details",
+	"parkingcrew.net",
+	"bodis.com",
+	"above.com/marketplace",
+	"dan.com/buy-domain",
+	"afternic.com/forsale",
+	"hugedomains.com/domain_profile",
+	"img1.wsimg.com/parking-lander", // GoDaddy
+	"/adsense/domains/caf.js",       // Google AdSense for Domains — реклама вместо сайта
+	"parklogic.com",
+	"reg.ru/domain/shop/lot",
+	"домен припаркован",
+}
+
+// parkingPhrases — фразы о продаже домена. На полноценных сайтах встречаются в рекламе и
+// комментариях, поэтому учитываются только на почти пустой странице
+var parkingPhrases = []string{
+	"this domain may be for sale",
+	"this domain is for sale",
+	"buy this domain",
+	"domain is parked",
+	"related searches",
+	"домен продается",
+	"домен продаётся",
+	"купить домен",
+}
+
+// parkingMaxText — сколько видимого текста может быть на странице парковки
+const parkingMaxText = 1500
+
+type ParkingResult struct {
+	Parked    bool
+	Signature string // сработавший признак
+}
+
+type ParkingDetector struct {
+	signatures []string
+}
+
+// NewParkingDetector создаёт детектор со встроенными признаками и дополнительными extra
+func NewParkingDetector(extra []string) *ParkingDetector {
+	d := &ParkingDetector{}
+	for _, s := range append(append([]string{}, DefaultParkingSignatures...), extra...) {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			d.signatures = append(d.signatures, s)
+		}
+	}
+	return d
+}
+
+func (d *ParkingDetector) Detect(html string) ParkingResult {
+	lower := strings.ToLower(html)
+	for _, s := range d.signatures {
+		if strings.Contains(lower, s) {
+			return ParkingResult{Parked: true, Signature: s}
+		}
+	}
+
+	text := strings.ToLower(extractTextContent(html))
+	if len([]rune(text)) > parkingMaxText {
+		return ParkingResult{}
+	}
+	for _, p := range parkingPhrases {
+		if strings.Contains(text, p) {
+			return ParkingResult{Parked: true, Signature: p}
+		}
+	}
+	return ParkingResult{}
+}
@@ -0,0 +1,73 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParkingDetector(t *testing.T) {
+	detector := NewParkingDetector(nil)
+
+	tests := []struct {
+		file          string
+		wantSignature string
+	}{
+		{"parking_sedo.html", "sedo.com/search/details"},
+		{"parking_godaddy.html", "img1.wsimg.com/parking-lander"},
+		{"parking_ru.html", "домен продаётся"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			html, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := detector.Detect(string(html))
+			if !result.Parked || result.Signature != tt.wantSignature {
+				t.Errorf("Detect() = %+v, want parked by %q", result, tt.wantSignature)
+			}
+		})
+	}
+}
+
+func TestParkingDetectorLiveSites(t *testing.T) {
+	detector := NewParkingDetector(nil)
+
+	// Фраза о продаже на полноценной странице — реклама, а не парковка
+	longPage := `<html><body><div class="comments">` +
+		strings.Repeat(`<p>Смотреть фильм онлайн в хорошем качестве бесплатно и без регистрации.</p>`, 40) +
+		`<p>Купить домен дёшево — реклама партнёра</p></div></body></html>`
+
+	tests := []struct {
+		name string
+		html string
+	}{
+		{"tiny page without markers", `<html><body><h1>Скоро открытие</h1></body></html>`},
+		{"phrase on a full page", longPage},
+		{"phrase only in script", `<html><body><script>var s = "buy this domain";</script><div id="app"></div></body></html>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := detector.Detect(tt.html); result.Parked {
+				t.Errorf("Detect() = %+v, want not parked", result)
+			}
+		})
+	}
+}
+
+func TestParkingDetectorExtraSignatures(t *testing.T) {
+	html := `<html><body><iframe src="https://parking.example-registrar.com/lander"></iframe></body></html>`
+
+	if result := NewParkingDetector(nil).Detect(html); result.Parked {
+		t.Fatalf("built-in signatures matched: %+v", result)
+	}
+
+	result := NewParkingDetector([]string{" ", "Parking.Example-Registrar.com"}).Detect(html)
+	if !result.Parked || result.Signature != "parking.example-registrar.com" {
+		t.Errorf("Detect() = %+v, want parked by extra signature", result)
+	}
+}
@@ -32,7 +32,7 @@ func (d *RenderDetector) Detect(html string, contentLength int64) RenderResult {
 		markers = append(markers, frameworkMarkers...)
 	}
 
-	textContent := d.extractTextContent(html)
+	textContent := extractTextContent(html)
 	hasContent := len(textContent) >= minTextContentLength
 
 	hasSPAMarkers := d.hasSPAMarkers(html)
@@ -135,7 +135,8 @@ func (d *RenderDetector) hasSPAMarkers(html string) bool {
 	return reactRootPattern.MatchString(html) || vueAppPattern.MatchString(html)
 }
 
-func (d *RenderDetector) extractTextContent(html string) string {
+// extractTextContent — видимый текст страницы без скриптов, стилей и тегов
+func extractTextContent(html string) string {
 	text := scriptTagPattern.ReplaceAllString(html, "")
 	text = styleTagPattern.ReplaceAllString(text, "")
 	text = htmlTagPattern.ReplaceAllString(text, " ")
@@ -1,16 +1,27 @@
 package meili
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/meilisearch/meilisearch-go"
 	"github.com/video-analitics/backend/pkg/logger"
 )
 
 const (
+	// PagesIndex — индекс страниц по умолчанию, если MEILI_INDEX не задан
 	PagesIndex = "pages"
+	primaryKey = "id"
+
+	// MaxTotalHits — сколько результатов поиска можно выбрать через offset/limit
+	MaxTotalHits int64 = 100000
 )
 
 // PageDocument представляет документ страницы в Meilisearch
@@ -23,6 +34,8 @@ type PageDocument struct {
 	Description   string   `json:"description"`
 	MainText      string   `json:"main_text"`
 	Year          int      `json:"year,omitempty"`
+	Season        int      `json:"season,omitempty"`
+	Episodes      []int    `json:"episodes,omitempty"`
 	KinopoiskID   string   `json:"kinopoisk_id,omitempty"`
 	IMDBID        string   `json:"imdb_id,omitempty"`
 	MALID         string   `json:"mal_id,omitempty"`
@@ -33,13 +46,17 @@ type PageDocument struct {
 	IndexedAt     string   `json:"indexed_at"`
 }
 
-// Client обёртка над meilisearch-go клиентом
+// Client обёртка над meilisearch-go клиентом, привязанная к одному индексу страниц
 type Client struct {
 	client meilisearch.ServiceManager
+	url    string
+	apiKey string
+	index  string
 }
 
-// New создаёт новый клиент Meilisearch
-func New(url, apiKey string) (*Client, error) {
+// New создаёт новый клиент Meilisearch для индекса index; пустое имя — PagesIndex.
+// Разные имена позволяют нескольким окружениям делить один инстанс
+func New(url, apiKey, index string) (*Client, error) {
 	client := meilisearch.New(url, meilisearch.WithAPIKey(apiKey))
 
 	// Проверяем соединение
@@ -47,7 +64,10 @@ func New(url, apiKey string) (*Client, error) {
 		return nil, err
 	}
 
-	c := &Client{client: client}
+	if index == "" {
+		index = PagesIndex
+	}
+	c := &Client{client: client, url: strings.TrimRight(url, "/"), apiKey: apiKey, index: index}
 
 	// Настраиваем индексы
 	if err := c.setupIndexes(); err != nil {
@@ -57,20 +77,114 @@ func New(url, apiKey string) (*Client, error) {
 	return c, nil
 }
 
+// Index возвращает имя индекса страниц клиента
+func (c *Client) Index() string {
+	return c.index
+}
+
+// ForIndex возвращает клиент того же инстанса для другого индекса, создавая и настраивая его.
+// Используется для переиндексации во временный индекс перед SwapIndex
+func (c *Client) ForIndex(index string) (*Client, error) {
+	other := &Client{client: c.client, url: c.url, apiKey: c.apiKey, index: index}
+	if err := other.setupIndexes(); err != nil {
+		return nil, err
+	}
+	return other, nil
+}
+
+// SwapIndex меняет местами документы и настройки индекса клиента и индекса name и ждёт
+// завершения. Задачи индексации, поставленные раньше, Meilisearch выполняет до обмена,
+// поэтому после переиндексации в name поиск мгновенно переключается на новые данные
+func (c *Client) SwapIndex(ctx context.Context, name string) error {
+	// Запрос отправляется напрямую: SDK всегда передаёт поле rename, которого нет в API до v1.18
+	body, err := json.Marshal([]map[string][]string{{"indexes": {c.index, name}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/swap-indexes", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var info meilisearch.TaskInfo
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("swap indexes %s and %s: status %d", c.index, name, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return err
+	}
+
+	task, err := c.client.WaitForTaskWithContext(ctx, info.TaskUID, 500*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	if task.Status != meilisearch.TaskStatusSucceeded {
+		return fmt.Errorf("swap indexes %s and %s: %s", c.index, name, task.Error.Message)
+	}
+	return nil
+}
+
+// DeleteIndex удаляет индекс name целиком — например, старые данные после SwapIndex
+func (c *Client) DeleteIndex(name string) error {
+	_, err := c.client.DeleteIndex(name)
+	return err
+}
+
+// WaitForTasks ждёт, пока в индексе клиента не останется поставленных и выполняющихся задач
+func (c *Client) WaitForTasks(ctx context.Context) error {
+	query := &meilisearch.TasksQuery{
+		Statuses: []meilisearch.TaskStatus{meilisearch.TaskStatusEnqueued, meilisearch.TaskStatusProcessing},
+		Limit:    1,
+	}
+	for {
+		res, err := c.client.Index(c.index).GetTasksWithContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		if res.Total == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// CountDocuments возвращает число документов в индексе клиента
+func (c *Client) CountDocuments(ctx context.Context) (int64, error) {
+	stats, err := c.client.Index(c.index).GetStatsWithContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return stats.NumberOfDocuments, nil
+}
+
 func (c *Client) setupIndexes() error {
 	log := logger.Log
 
 	_, err := c.client.CreateIndex(&meilisearch.IndexConfig{
-		Uid:        PagesIndex,
-		PrimaryKey: "id",
+		Uid:        c.index,
+		PrimaryKey: primaryKey,
 	})
 	if err != nil {
-		log.Debug().Str("index", PagesIndex).Msg("index already exists")
+		log.Debug().Str("index", c.index).Msg("index already exists")
 	} else {
-		log.Info().Str("index", PagesIndex).Msg("index created")
+		log.Info().Str("index", c.index).Msg("index created")
 	}
 
-	pagesIndex := c.client.Index(PagesIndex)
+	pagesIndex := c.client.Index(c.index)
 
 	// Получаем текущие настройки
 	currentSettings, err := pagesIndex.GetSettings()
@@ -90,7 +204,7 @@ func (c *Client) setupIndexes() error {
 	}
 
 	// 2. Filterable attributes
-	filterable := []string{"site_id", "domain", "year", "kinopoisk_id", "imdb_id", "mal_id", "shikimori_id", "mydramalist_id"}
+	filterable := []string{"site_id", "domain", "year", "season", "kinopoisk_id", "imdb_id", "mal_id", "shikimori_id", "mydramalist_id"}
 	if !stringSlicesEqual(currentSettings.FilterableAttributes, filterable) {
 		filterableIface := make([]interface{}, len(filterable))
 		for i, v := range filterable {
@@ -137,7 +251,16 @@ func (c *Client) setupIndexes() error {
 		}
 	}
 
-	log.Info().Str("index", PagesIndex).Msg("meilisearch index configured")
+	// 5. Pagination: по умолчанию Meilisearch отдаёт не больше 1000 результатов
+	if currentSettings.Pagination == nil || currentSettings.Pagination.MaxTotalHits != MaxTotalHits {
+		if _, err := pagesIndex.UpdatePagination(&meilisearch.Pagination{MaxTotalHits: MaxTotalHits}); err != nil {
+			log.Warn().Err(err).Msg("failed to update pagination")
+		} else {
+			log.Info().Int64("max_total_hits", MaxTotalHits).Msg("pagination updated")
+		}
+	}
+
+	log.Info().Str("index", c.index).Msg("meilisearch index configured")
 	return nil
 }
 
@@ -175,8 +298,8 @@ func typoToleranceEqual(a, b *meilisearch.TypoTolerance) bool {
 // IndexPage индексирует страницу
 func (c *Client) IndexPage(doc *PageDocument) error {
 	docs := []map[string]interface{}{docToMap(doc)}
-	pk := "id"
-	_, err := c.client.Index(PagesIndex).AddDocuments(docs, &pk)
+	pk := primaryKey
+	_, err := c.client.Index(c.index).AddDocuments(docs, &pk)
 	return err
 }
 
@@ -189,8 +312,8 @@ func (c *Client) IndexPages(docs []PageDocument) error {
 	for i := range docs {
 		maps[i] = docToMap(&docs[i])
 	}
-	pk := "id"
-	_, err := c.client.Index(PagesIndex).AddDocuments(maps, &pk)
+	pk := primaryKey
+	_, err := c.client.Index(c.index).AddDocuments(maps, &pk)
 	return err
 }
 
@@ -203,15 +326,22 @@ type SearchResult struct {
 
 // SearchPages ищет страницы по запросу
 func (c *Client) SearchPages(query string, filters string, limit int64) (*SearchResult, error) {
+	return c.SearchPagesWithOffset(context.Background(), query, filters, limit, 0)
+}
+
+// SearchPagesWithOffset — SearchPages со смещением для постраничной выборки.
+// Дальше MaxTotalHits результатов Meilisearch не отдаёт. Отмена ctx прерывает HTTP-запрос
+func (c *Client) SearchPagesWithOffset(ctx context.Context, query string, filters string, limit, offset int64) (*SearchResult, error) {
 	searchParams := &meilisearch.SearchRequest{
-		Query: query,
-		Limit: limit,
+		Query:  query,
+		Limit:  limit,
+		Offset: offset,
 	}
 	if filters != "" {
 		searchParams.Filter = filters
 	}
 
-	resp, err := c.client.Index(PagesIndex).Search(query, searchParams)
+	resp, err := c.client.Index(c.index).SearchWithContext(ctx, query, searchParams)
 	if err != nil {
 		return nil, err
 	}
@@ -355,22 +485,63 @@ func (c *Client) CountUniqueSitesByContent(title, originalTitle, kpid, imdbID st
 
 // DeletePage удаляет страницу из индекса
 func (c *Client) DeletePage(id string) error {
-	_, err := c.client.Index(PagesIndex).DeleteDocument(id)
+	_, err := c.client.Index(c.index).DeleteDocument(id)
 	return err
 }
 
-// DeleteAllDocuments удаляет все документы из индекса pages
+// DeleteAllDocuments удаляет все документы из индекса страниц
 func (c *Client) DeleteAllDocuments() error {
-	_, err := c.client.Index(PagesIndex).DeleteAllDocuments()
+	_, err := c.client.Index(c.index).DeleteAllDocuments()
 	return err
 }
 
 // DeleteBySiteID удаляет все страницы сайта из индекса
 func (c *Client) DeleteBySiteID(siteID string) error {
-	_, err := c.client.Index(PagesIndex).DeleteDocumentsByFilter("site_id = \"" + siteID + "\"")
+	_, err := c.client.Index(c.index).DeleteDocumentsByFilter("site_id = \"" + siteID + "\"")
 	return err
 }
 
+// DeleteOrphans удаляет страницы, чей site_id не входит в validSiteIDs, и возвращает
+// примерное число удалённых. Пустой список ничего не удаляет: скорее всего, это сбой
+// чтения сайтов, а не пустая база
+func (c *Client) DeleteOrphans(ctx context.Context, validSiteIDs []string) (int64, error) {
+	filter := orphanFilter(validSiteIDs)
+	if filter == "" {
+		return 0, nil
+	}
+
+	res, err := c.SearchPagesWithOffset(ctx, "", filter, 1, 0)
+	if err != nil {
+		return 0, err
+	}
+	if res.TotalHits == 0 {
+		return 0, nil
+	}
+
+	if _, err := c.client.Index(c.index).DeleteDocumentsByFilterWithContext(ctx, filter); err != nil {
+		return 0, err
+	}
+	return res.TotalHits, nil
+}
+
+// orphanFilter — фильтр страниц сайтов вне validSiteIDs; пустая строка — фильтровать нечего
+func orphanFilter(validSiteIDs []string) string {
+	seen := make(map[string]bool, len(validSiteIDs))
+	var ids []string
+	for _, id := range validSiteIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, strconv.Quote(id))
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+	sort.Strings(ids)
+	return "site_id NOT IN [" + strings.Join(ids, ", ") + "]"
+}
+
 // docToMap конвертирует PageDocument в map
 func docToMap(doc *PageDocument) map[string]interface{} {
 	m := map[string]interface{}{
@@ -386,6 +557,12 @@ func docToMap(doc *PageDocument) map[string]interface{} {
 	if doc.Year > 0 {
 		m["year"] = doc.Year
 	}
+	if doc.Season > 0 {
+		m["season"] = doc.Season
+	}
+	if len(doc.Episodes) > 0 {
+		m["episodes"] = doc.Episodes
+	}
 	if doc.KinopoiskID != "" {
 		m["kinopoisk_id"] = doc.KinopoiskID
 	}
@@ -0,0 +1,45 @@
+package meili
+
+import "testing"
+
+func TestOrphanFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []string
+		want string
+	}{
+		{
+			name: "no sites deletes nothing",
+			ids:  nil,
+			want: "",
+		},
+		{
+			name: "only empty ids",
+			ids:  []string{"", ""},
+			want: "",
+		},
+		{
+			name: "single site",
+			ids:  []string{"65a1"},
+			want: `site_id NOT IN ["65a1"]`,
+		},
+		{
+			name: "sorted and deduplicated",
+			ids:  []string{"65b2", "65a1", "", "65b2"},
+			want: `site_id NOT IN ["65a1", "65b2"]`,
+		},
+		{
+			name: "quotes are escaped",
+			ids:  []string{`a"b`},
+			want: `site_id NOT IN ["a\"b"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orphanFilter(tt.ids); got != tt.want {
+				t.Errorf("orphanFilter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
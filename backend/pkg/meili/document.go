@@ -0,0 +1,32 @@
+package meili
+
+import (
+	"time"
+
+	"github.com/video-analitics/backend/pkg/models"
+)
+
+// NewPageDocument строит документ поиска из страницы. domain — домен сайта страницы,
+// а не хост URL: страницы с www. и без него должны попадать под один домен
+func NewPageDocument(page *models.Page, domain string) PageDocument {
+	return PageDocument{
+		ID:            page.ID.Hex(),
+		SiteID:        page.SiteID,
+		Domain:        domain,
+		URL:           page.URL,
+		Title:         page.Title,
+		Description:   page.Description,
+		MainText:      page.MainText,
+		Year:          page.Year,
+		Season:        page.Season,
+		Episodes:      page.Episodes,
+		KinopoiskID:   page.ExternalIDs.KinopoiskID,
+		IMDBID:        page.ExternalIDs.IMDBID,
+		MALID:         page.ExternalIDs.MALID,
+		ShikimoriID:   page.ExternalIDs.ShikimoriID,
+		MyDramaListID: page.ExternalIDs.MyDramaListID,
+		LinksText:     page.LinksText,
+		PlayerURLs:    []string{page.PlayerURL},
+		IndexedAt:     page.IndexedAt.Format(time.RFC3339),
+	}
+}
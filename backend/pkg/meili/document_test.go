@@ -0,0 +1,43 @@
+package meili
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNewPageDocument(t *testing.T) {
+	id := primitive.NewObjectID()
+	page := &models.Page{
+		ID:          id,
+		SiteID:      "site-1",
+		URL:         "https://www.example.com/film/1",
+		Title:       "Film",
+		Year:        2021,
+		Episodes:    []int{1, 2},
+		ExternalIDs: models.ExternalIDs{KinopoiskID: "123", IMDBID: "tt1"},
+		PlayerURL:   "https://player.example.com/1",
+		IndexedAt:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	got := NewPageDocument(page, "example.com")
+	want := PageDocument{
+		ID:          id.Hex(),
+		SiteID:      "site-1",
+		Domain:      "example.com",
+		URL:         "https://www.example.com/film/1",
+		Title:       "Film",
+		Year:        2021,
+		Episodes:    []int{1, 2},
+		KinopoiskID: "123",
+		IMDBID:      "tt1",
+		PlayerURLs:  []string{"https://player.example.com/1"},
+		IndexedAt:   "2024-03-01T12:00:00Z",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewPageDocument() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+//go:build e2e
+// +build e2e
+
+package meili_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/video-analitics/backend/pkg/meili"
+)
+
+// TestConfiguredIndexIsolation проверяет, что индексация, поиск и удаление
+// затрагивают только индекс клиента, а не соседний индекс того же инстанса
+func TestConfiguredIndexIsolation(t *testing.T) {
+	ctx := context.Background()
+	live, cleanup := setupMeilisearch(t, ctx)
+	defer cleanup()
+
+	require.Equal(t, meili.PagesIndex, live.Index())
+
+	staging, err := live.ForIndex("pages_staging")
+	require.NoError(t, err)
+	require.Equal(t, "pages_staging", staging.Index())
+
+	now := time.Now().Format(time.RFC3339)
+	require.NoError(t, live.IndexPages([]meili.PageDocument{
+		{ID: "live-1", SiteID: "site-a", Domain: "a.example", Title: "Интерстеллар", IndexedAt: now},
+	}))
+	require.NoError(t, staging.IndexPages([]meili.PageDocument{
+		{ID: "staging-1", SiteID: "site-a", Domain: "a.example", Title: "Интерстеллар", IndexedAt: now},
+		{ID: "staging-2", SiteID: "site-b", Domain: "b.example", Title: "Интерстеллар", IndexedAt: now},
+	}))
+	require.NoError(t, live.WaitForTasks(ctx))
+	require.NoError(t, staging.WaitForTasks(ctx))
+
+	liveHits, err := live.SearchPages("Интерстеллар", "", 10)
+	require.NoError(t, err)
+	require.Len(t, liveHits.Hits, 1)
+	assert.Equal(t, "live-1", liveHits.Hits[0].ID)
+
+	stagingHits, err := staging.SearchPages("Интерстеллар", "", 10)
+	require.NoError(t, err)
+	assert.Len(t, stagingHits.Hits, 2)
+
+	require.NoError(t, staging.DeleteBySiteID("site-a"))
+	require.NoError(t, staging.WaitForTasks(ctx))
+
+	n, err := live.CountDocuments(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "DeleteBySiteID must not touch another index")
+
+	n, err = staging.CountDocuments(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	require.NoError(t, staging.DeleteAllDocuments())
+	require.NoError(t, staging.WaitForTasks(ctx))
+
+	n, err = live.CountDocuments(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "DeleteAllDocuments must not touch another index")
+
+	n, err = staging.CountDocuments(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+}
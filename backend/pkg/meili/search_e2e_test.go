@@ -45,7 +45,7 @@ func setupMeilisearch(t *testing.T, ctx context.Context) (*meili.Client, func())
 
 	url := fmt.Sprintf("http://%s:%s", host, port.Port())
 
-	client, err := meili.New(url, "testMasterKey")
+	client, err := meili.New(url, "testMasterKey", "")
 	require.NoError(t, err, "failed to create meilisearch client")
 
 	cleanup := func() {
@@ -14,11 +14,19 @@ type Page struct {
 	Description string             `bson:"description,omitempty" json:"description,omitempty"`
 	MainText    string             `bson:"main_text,omitempty" json:"main_text,omitempty"`
 	Year        int                `bson:"year,omitempty" json:"year,omitempty"`
+	Season      int                `bson:"season,omitempty" json:"season,omitempty"`
+	Episodes    []int              `bson:"episodes,omitempty" json:"episodes,omitempty"`
 	ExternalIDs ExternalIDs        `bson:"external_ids" json:"external_ids"`
 	PlayerURL   string             `bson:"player_url,omitempty" json:"player_url,omitempty"`
 	LinksText   string             `bson:"links_text,omitempty" json:"links_text,omitempty"`
 	HTTPStatus  int                `bson:"http_status" json:"http_status"`
 	IndexedAt   time.Time          `bson:"indexed_at" json:"indexed_at"`
+	// ETag/LastModified — валидаторы HTTP-ответа; при перекрауле страница запрашивается условно
+	ETag         string `bson:"etag,omitempty" json:"etag,omitempty"`
+	LastModified string `bson:"last_modified,omitempty" json:"last_modified,omitempty"`
+	// SnapshotKey — ключ сжатого HTML в хранилище снимков (snapshot.Key); SnapshotAt — когда снят
+	SnapshotKey string     `bson:"snapshot_key,omitempty" json:"snapshot_key,omitempty"`
+	SnapshotAt  *time.Time `bson:"snapshot_at,omitempty" json:"snapshot_at,omitempty"`
 }
 
 type ExternalIDs struct {
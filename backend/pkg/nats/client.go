@@ -2,6 +2,8 @@ package nats
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -42,13 +44,47 @@ const (
 	SubjectPageCrawlTasks      = "page.crawl.tasks"
 	SubjectPageSingleResults   = "page.single.results"
 	SubjectPageCrawlResults    = "page.crawl.results"
+
+	// Subjects управления (core NATS, без JetStream — доставляются только живым подписчикам)
+	SubjectParserConcurrency = "parser.control.concurrency"
+
+	// Синхронная детекция (core NATS request-reply, отвечает один парсер из queue group)
+	SubjectDetectSync = "detect.sync"
+
+	// Разовый парсинг URL без сохранения (core NATS request-reply)
+	SubjectParseSync = "parse.sync"
 )
 
+// WorkStreams — стримы задач и результатов (всё, кроме DLQ), у которых отслеживается отставание консьюмеров
+var WorkStreams = []string{
+	StreamCrawlTasks,
+	StreamCrawlResults,
+	StreamCrawlProgress,
+	StreamDetectTasks,
+	StreamDetectResults,
+	StreamSitemapCrawlTasks,
+	StreamSitemapURLBatches,
+	StreamSitemapCrawlResults,
+	StreamPageCrawlTasks,
+	StreamPageSingleResults,
+	StreamPageCrawlResults,
+}
+
 type Client struct {
 	nc *nats.Conn
 	js jetstream.JetStream
 }
 
+// ConsumerLag — отставание durable-консьюмера от своего стрима
+type ConsumerLag struct {
+	Stream   string `json:"stream"`
+	Consumer string `json:"consumer"`
+	// Pending — сообщения в стриме, ещё не выданные консьюмеру
+	Pending uint64 `json:"pending"`
+	// AckPending — выданные, но ещё не подтверждённые сообщения
+	AckPending int `json:"ack_pending"`
+}
+
 func New(url string) (*Client, error) {
 	log := logger.Log
 
@@ -236,10 +272,102 @@ func (c *Client) ensureStreams(ctx context.Context) error {
 	return nil
 }
 
+// StreamsLag возвращает отставание всех durable-консьюмеров указанных стримов,
+// включая консьюмеры других сервисов (parser). Ошибка одного стрима не прерывает обход остальных
+func (c *Client) StreamsLag(ctx context.Context, streams ...string) ([]ConsumerLag, error) {
+	var (
+		lags []ConsumerLag
+		errs []error
+	)
+	for _, name := range streams {
+		stream, err := c.js.Stream(ctx, name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("get stream %s: %w", name, err))
+			continue
+		}
+
+		consumers := stream.ListConsumers(ctx)
+		for info := range consumers.Info() {
+			lags = append(lags, ConsumerLag{
+				Stream:     name,
+				Consumer:   info.Name,
+				Pending:    info.NumPending,
+				AckPending: info.NumAckPending,
+			})
+		}
+		if err := consumers.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("list consumers %s: %w", name, err))
+		}
+	}
+	return lags, errors.Join(errs...)
+}
+
 func (c *Client) JetStream() jetstream.JetStream {
 	return c.js
 }
 
+// PublishCore отправляет сообщение в core NATS всем текущим подписчикам subject
+func (c *Client) PublishCore(subject string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if err := c.nc.Publish(subject, payload); err != nil {
+		return fmt.Errorf("publish to %s: %w", subject, err)
+	}
+	return nil
+}
+
+// Subscribe подписывается на core NATS subject, возвращает функцию отписки
+func (c *Client) Subscribe(subject string, handler func(data []byte)) (func() error, error) {
+	sub, err := c.nc.Subscribe(subject, func(msg *nats.Msg) {
+		handler(msg.Data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to %s: %w", subject, err)
+	}
+	return sub.Unsubscribe, nil
+}
+
+// Request отправляет запрос и декодирует JSON-ответ в resp. Таймаут задаётся через ctx
+func (c *Client) Request(ctx context.Context, subject string, req any, resp any) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	msg, err := c.nc.RequestWithContext(ctx, subject, payload)
+	if err != nil {
+		return fmt.Errorf("request to %s: %w", subject, err)
+	}
+
+	if err := json.Unmarshal(msg.Data, resp); err != nil {
+		return fmt.Errorf("unmarshal reply: %w", err)
+	}
+	return nil
+}
+
+// Respond обслуживает запросы subject в queue group: каждый запрос получает один подписчик.
+// handler выполняется в отдельной горутине, его результат отправляется ответом в JSON
+func (c *Client) Respond(subject, queue string, handler func(data []byte) any) (func() error, error) {
+	sub, err := c.nc.QueueSubscribe(subject, queue, func(msg *nats.Msg) {
+		go func() {
+			payload, err := json.Marshal(handler(msg.Data))
+			if err != nil {
+				logger.Log.Error().Err(err).Str("subject", subject).Msg("failed to marshal reply")
+				return
+			}
+			if err := msg.Respond(payload); err != nil {
+				logger.Log.Warn().Err(err).Str("subject", subject).Msg("failed to send reply")
+			}
+		}()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to %s: %w", subject, err)
+	}
+	return sub.Unsubscribe, nil
+}
+
 func (c *Client) Close() {
 	c.nc.Close()
 }
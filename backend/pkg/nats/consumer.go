@@ -139,12 +139,20 @@ func (c *Consumer) FetchOne(ctx context.Context) (*Message, error) {
 type HandlerFunc func(ctx context.Context, msg *Message) error
 
 func (c *Consumer) Consume(ctx context.Context, handler HandlerFunc) error {
+	return c.consume(ctx, nil, handler)
+}
+
+// consume обрабатывает сообщения до отмены ctx или закрытия stop.
+// stop проверяется только между сообщениями, поэтому начатое сообщение всегда дообрабатывается
+func (c *Consumer) consume(ctx context.Context, stop <-chan struct{}, handler HandlerFunc) error {
 	log := logger.Log
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-stop:
+			return nil
 		default:
 		}
 
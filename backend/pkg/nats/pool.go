@@ -0,0 +1,84 @@
+package nats
+
+import (
+	"context"
+	"sync"
+)
+
+// DynamicPool — пул обработчиков consumer'а, размер которого можно менять без перезапуска
+type DynamicPool struct {
+	run func(ctx context.Context, stop <-chan struct{}) error
+
+	mu    sync.Mutex
+	ctx   context.Context
+	stops []chan struct{}
+	wg    sync.WaitGroup
+}
+
+func (c *Consumer) NewDynamicPool(handler HandlerFunc) *DynamicPool {
+	return newDynamicPool(func(ctx context.Context, stop <-chan struct{}) error {
+		return c.consume(ctx, stop, handler)
+	})
+}
+
+func newDynamicPool(run func(ctx context.Context, stop <-chan struct{}) error) *DynamicPool {
+	return &DynamicPool{run: run}
+}
+
+// Run запускает workers обработчиков и блокируется до отмены ctx,
+// после чего дожидается завершения сообщений, которые уже в работе
+func (p *DynamicPool) Run(ctx context.Context, workers int) error {
+	p.mu.Lock()
+	p.ctx = ctx
+	p.mu.Unlock()
+
+	p.Resize(workers)
+
+	<-ctx.Done()
+
+	p.mu.Lock()
+	p.stops = nil
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return ctx.Err()
+}
+
+// Resize меняет число обработчиков. Лишние обработчики останавливаются после
+// текущего сообщения, вызов не ждёт их завершения
+func (p *DynamicPool) Resize(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ctx == nil || p.ctx.Err() != nil {
+		return
+	}
+
+	ctx := p.ctx
+	for len(p.stops) < workers {
+		stop := make(chan struct{})
+		p.stops = append(p.stops, stop)
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.run(ctx, stop)
+		}()
+	}
+
+	for len(p.stops) > workers {
+		last := len(p.stops) - 1
+		close(p.stops[last])
+		p.stops = p.stops[:last]
+	}
+}
+
+// Size возвращает текущее число обработчиков
+func (p *DynamicPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.stops)
+}
@@ -0,0 +1,93 @@
+package nats
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDynamicPoolResize(t *testing.T) {
+	var active atomic.Int32
+	pool := newDynamicPool(func(ctx context.Context, stop <-chan struct{}) error {
+		active.Add(1)
+		defer active.Add(-1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stop:
+			return nil
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- pool.Run(ctx, 3) }()
+
+	waitFor(t, func() bool { return active.Load() == 3 })
+
+	steps := []int{5, 2, 0, 4}
+	for _, n := range steps {
+		pool.Resize(n)
+		want := n
+		if want < 1 {
+			want = 1
+		}
+		if got := pool.Size(); got != want {
+			t.Fatalf("Resize(%d): Size() = %d, want %d", n, got, want)
+		}
+		waitFor(t, func() bool { return int(active.Load()) == want })
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	if active.Load() != 0 {
+		t.Errorf("active workers after shutdown = %d, want 0", active.Load())
+	}
+}
+
+func TestDynamicPoolShrinkFinishesInFlight(t *testing.T) {
+	release := make(chan struct{})
+	var finished atomic.Int32
+	var started atomic.Int32
+
+	pool := newDynamicPool(func(ctx context.Context, stop <-chan struct{}) error {
+		// эмулируем сообщение в работе: stop проверяется только после его завершения
+		started.Add(1)
+		<-release
+		finished.Add(1)
+		<-stop
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pool.Run(ctx, 2)
+
+	waitFor(t, func() bool { return started.Load() == 2 })
+	pool.Resize(1)
+	if finished.Load() != 0 {
+		t.Fatal("in-flight work interrupted by shrink")
+	}
+
+	close(release)
+	waitFor(t, func() bool { return finished.Load() == 2 })
+}
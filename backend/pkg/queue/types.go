@@ -17,12 +17,12 @@ type CrawlTask struct {
 	SiteID        string       `json:"site_id"`
 	Domain        string       `json:"domain"`
 	HasSitemap    bool         `json:"has_sitemap"`
-	CrawlStrategy string       `json:"crawl_strategy"`           // sitemap или recursive
+	CrawlStrategy string       `json:"crawl_strategy"` // sitemap или recursive
 	SitemapURLs   []string     `json:"sitemap_urls,omitempty"`
 	CMS           string       `json:"cms,omitempty"`
-	ScannerType   string       `json:"scanner_type,omitempty"`   // "http" или "spa"
-	CaptchaType   string       `json:"captcha_type,omitempty"`   // тип капчи для обхода
-	Cookies       []CookieData `json:"cookies,omitempty"`        // сохранённые cookies для обхода капчи
+	ScannerType   string       `json:"scanner_type,omitempty"` // "http" или "spa"
+	CaptchaType   string       `json:"captcha_type,omitempty"` // тип капчи для обхода
+	Cookies       []CookieData `json:"cookies,omitempty"`      // сохранённые cookies для обхода капчи
 	ScanIntervalH int          `json:"scan_interval_h,omitempty"`
 	CreatedAt     time.Time    `json:"created_at"`
 }
@@ -73,6 +73,20 @@ type DetectTask struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// DetectSyncRequest - запрос синхронной детекции домена (без создания сайта)
+type DetectSyncRequest struct {
+	Domain string `json:"domain"`
+}
+
+// ParseSyncRequest - запрос разового парсинга страницы (без сохранения); ответ — PageResult
+type ParseSyncRequest struct {
+	URL         string       `json:"url"`
+	SiteID      string       `json:"site_id,omitempty"`
+	ScannerType string       `json:"scanner_type,omitempty"`
+	PageWait    *PageWait    `json:"page_wait,omitempty"`    // nil — стратегия парсера по умолчанию
+	DNSOverride *DNSOverride `json:"dns_override,omitempty"` // nil — системный DNS
+}
+
 type DetectResultMsg struct {
 	TaskID            string       `json:"task_id"`
 	SiteID            string       `json:"site_id"`
@@ -84,11 +98,14 @@ type DetectResultMsg struct {
 	SitemapStatus     string       `json:"sitemap_status"` // none, valid, invalid, empty
 	CrawlStrategy     string       `json:"crawl_strategy"` // sitemap, recursive
 	SitemapURLs       []string     `json:"sitemap_urls,omitempty"`
+	SitemapFormat     string       `json:"sitemap_format,omitempty"` // xml, text, json, html
 	NeedsSPA          bool         `json:"needs_spa"`
 	CaptchaType       string       `json:"captcha_type,omitempty"`
 	Cookies           []CookieData `json:"cookies,omitempty"`
 	HasDomainRedirect bool         `json:"has_domain_redirect,omitempty"`
 	RedirectToDomain  string       `json:"redirect_to_domain,omitempty"`
+	IsParked          bool         `json:"is_parked,omitempty"`        // главная — страница парковки или продажи домена
+	ParkedSignature   string       `json:"parked_signature,omitempty"` // сработавший признак парковки
 	FinishedAt        time.Time    `json:"finished_at"`
 }
 
@@ -97,16 +114,19 @@ type DetectResultMsg struct {
 // ============================================
 
 type SitemapCrawlTask struct {
-	ID            string       `json:"id"`
-	SiteID        string       `json:"site_id"`
-	Domain        string       `json:"domain"`
-	SitemapURLs   []string     `json:"sitemap_urls"`
-	ScannerType   string       `json:"scanner_type"`
-	CaptchaType   string       `json:"captcha_type,omitempty"`
-	Cookies       []CookieData `json:"cookies,omitempty"`
-	AutoContinue  bool         `json:"auto_continue"`   // если true, автоматически запустить page crawl после завершения
-	IndexerAPIURL string       `json:"indexer_api_url"` // URL indexer API для получения уже известных URL
-	CreatedAt     time.Time    `json:"created_at"`
+	ID              string       `json:"id"`
+	SiteID          string       `json:"site_id"`
+	Domain          string       `json:"domain"`
+	SitemapURLs     []string     `json:"sitemap_urls"`
+	SitemapFormat   string       `json:"sitemap_format,omitempty"`    // формат, найденный при детекции
+	SitemapJSONPath string       `json:"sitemap_json_path,omitempty"` // путь к списку URL в JSON-карте
+	ScannerType     string       `json:"scanner_type"`
+	CaptchaType     string       `json:"captcha_type,omitempty"`
+	Cookies         []CookieData `json:"cookies,omitempty"`
+	DNSOverride     *DNSOverride `json:"dns_override,omitempty"` // nil — системный DNS
+	AutoContinue    bool         `json:"auto_continue"`          // если true, автоматически запустить page crawl после завершения
+	IndexerAPIURL   string       `json:"indexer_api_url"`        // URL indexer API для получения уже известных URL
+	CreatedAt       time.Time    `json:"created_at"`
 }
 
 type SitemapURLBatch struct {
@@ -137,17 +157,39 @@ type PageCrawlTask struct {
 	ScannerType   string       `json:"scanner_type"`
 	CaptchaType   string       `json:"captcha_type,omitempty"`
 	Cookies       []CookieData `json:"cookies,omitempty"`
+	PageWait      *PageWait    `json:"page_wait,omitempty"`    // nil — стратегия парсера по умолчанию
+	DNSOverride   *DNSOverride `json:"dns_override,omitempty"` // nil — системный DNS
 	BatchSize     int          `json:"batch_size"`
+	MaxPages      int          `json:"max_pages,omitempty"` // 0 — без ограничения
 	IndexerAPIURL string       `json:"indexer_api_url"`
 	CreatedAt     time.Time    `json:"created_at"`
 }
 
+// PageWait — стратегия ожидания загрузки страницы в браузере
+type PageWait struct {
+	Strategy  string `json:"strategy"` // delay, selector, network_idle, none
+	DelayMs   int    `json:"delay_ms,omitempty"`
+	Selector  string `json:"selector,omitempty"`
+	TimeoutMs int    `json:"timeout_ms,omitempty"`
+}
+
+// DNSOverride — резолвинг доменов сайта в обход системного DNS
+type DNSOverride struct {
+	Resolver string            `json:"resolver,omitempty"` // ip[:port] DNS-сервера
+	Hosts    map[string]string `json:"hosts,omitempty"`    // хост → IPv4/IPv6
+}
+
 type PageResult struct {
 	URL       string    `json:"url"`
 	Success   bool      `json:"success"`
 	Error     string    `json:"error,omitempty"`
 	Page      *PageData `json:"page,omitempty"`
 	IPBlocked bool      `json:"ip_blocked,omitempty"`
+	// Captcha — перед загрузкой страницы пришлось решать капчу
+	Captcha bool `json:"captcha,omitempty"`
+	// Gone — страница определённо снята: 404/410, парковка домена или пустая страница.
+	// Прочие неудачи (таймаут, блокировка, капча) ничего не говорят о самой странице
+	Gone bool `json:"gone,omitempty"`
 }
 
 type PageData struct {
@@ -156,9 +198,16 @@ type PageData struct {
 	Description string            `json:"description,omitempty"`
 	MainText    string            `json:"main_text,omitempty"`
 	Year        int               `json:"year,omitempty"`
+	Season      int               `json:"season,omitempty"`
+	Episodes    []int             `json:"episodes,omitempty"`
 	PlayerURL   string            `json:"player_url,omitempty"`
 	LinksText   string            `json:"links_text,omitempty"`
 	ExternalIDs map[string]string `json:"external_ids,omitempty"`
+	// ETag/LastModified — валидаторы ответа для условного перекраула
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// HTMLSnapshot — HTML страницы, сжатый gzip; передаётся, только если в парсере включены снимки
+	HTMLSnapshot []byte `json:"html_snapshot,omitempty"`
 }
 
 type PageBatchResult struct {
@@ -184,6 +233,8 @@ type PageCrawlResult struct {
 	IndexedCount    int          `json:"indexed_count,omitempty"`
 	IPBlocked       bool         `json:"ip_blocked,omitempty"`
 	BlockReason     string       `json:"block_reason,omitempty"`
+	Capped          bool         `json:"capped,omitempty"`            // обход остановлен по лимиту MaxPages
+	PagesEmptyTitle int          `json:"pages_empty_title,omitempty"` // страницы без заголовка — признак клиентского рендеринга
 }
 
 // PageSingleResult - результат парсинга одной страницы
@@ -197,4 +248,11 @@ type PageSingleResult struct {
 	Page      *PageData `json:"page,omitempty"`
 	IPBlocked bool      `json:"ip_blocked,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
+	// NotModified — сервер ответил 304 на условный запрос, страница не перерендеривалась и Page пуст
+	NotModified bool `json:"not_modified,omitempty"`
+}
+
+// ParserConcurrencyCommand - команда парсерам изменить число page-воркеров без перезапуска
+type ParserConcurrencyCommand struct {
+	PageWorkers int `json:"page_workers"`
 }
@@ -0,0 +1,93 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const bucketName = "page_snapshots"
+
+// GridFSStore хранит снимки в GridFS той же базы MongoDB; имя файла — ключ снимка
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+func NewGridFSStore(db *mongo.Database) (*GridFSStore, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		return nil, fmt.Errorf("create gridfs bucket: %w", err)
+	}
+	return &GridFSStore{bucket: bucket}, nil
+}
+
+func (s *GridFSStore) Put(ctx context.Context, key string, data []byte) error {
+	previous, err := s.fileIDs(ctx, bson.M{"filename": key})
+	if err != nil {
+		return err
+	}
+	if _, err := s.bucket.UploadFromStream(key, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("upload snapshot: %w", err)
+	}
+	// Старые версии удаляются после загрузки новой, чтобы снимок не пропадал при ошибке
+	for _, id := range previous {
+		if err := s.bucket.DeleteContext(ctx, id); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return fmt.Errorf("delete previous snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *GridFSStore) Get(ctx context.Context, key string) ([]byte, error) {
+	stream, err := s.bucket.OpenDownloadStreamByName(key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open snapshot: %w", err)
+	}
+	defer stream.Close()
+	return io.ReadAll(stream)
+}
+
+func (s *GridFSStore) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	ids, err := s.fileIDs(ctx, bson.M{"uploadDate": bson.M{"$lt": cutoff}})
+	if err != nil {
+		return 0, err
+	}
+	var deleted int64
+	for _, id := range ids {
+		if err := s.bucket.DeleteContext(ctx, id); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return deleted, fmt.Errorf("delete snapshot: %w", err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (s *GridFSStore) fileIDs(ctx context.Context, filter bson.M) ([]primitive.ObjectID, error) {
+	cursor, err := s.bucket.FindContext(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("find snapshots: %w", err)
+	}
+	var files []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(files))
+	for i, f := range files {
+		ids[i] = f.ID
+	}
+	return ids, nil
+}
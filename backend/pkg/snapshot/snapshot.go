@@ -0,0 +1,56 @@
+// Package snapshot хранит сжатый HTML страниц в том виде, в каком его получил парсер, —
+// как доказательство для жалоб правообладателей
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ErrNotFound = errors.New("snapshot not found")
+
+// Store — объектное хранилище снимков; ключ строится через Key
+type Store interface {
+	// Put сохраняет снимок, заменяя прежний с тем же ключом
+	Put(ctx context.Context, key string, data []byte) error
+	// Get возвращает сжатый снимок или ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// DeleteOlderThan удаляет снимки, сохранённые раньше cutoff
+	DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// Key — ключ снимка страницы: сайт и хеш адреса, чтобы ключ не зависел от длины и символов URL
+func Key(siteID, pageURL string) string {
+	sum := sha256.Sum256([]byte(pageURL))
+	return siteID + "/" + hex.EncodeToString(sum[:]) + ".html.gz"
+}
+
+// Compress сжимает HTML в gzip
+func Compress(html []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(html); err != nil {
+		return nil, fmt.Errorf("gzip write: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("gzip close: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress распаковывает снимок, сжатый Compress
+func Decompress(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("gzip reader: %w", err)
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
@@ -0,0 +1,60 @@
+package snapshot
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	keyRe := regexp.MustCompile(`^site-1/[0-9a-f]{64}\.html\.gz$`)
+
+	a := Key("site-1", "https://example.com/film/1?utm=x")
+	if !keyRe.MatchString(a) {
+		t.Errorf("Key() = %q, want site prefix and sha256 hex", a)
+	}
+	if b := Key("site-1", "https://example.com/film/1?utm=x"); a != b {
+		t.Errorf("Key() not stable: %q != %q", a, b)
+	}
+	if b := Key("site-1", "https://example.com/film/2"); a == b {
+		t.Errorf("different urls share key %q", a)
+	}
+	if b := Key("site-2", "https://example.com/film/1?utm=x"); a == b {
+		t.Errorf("different sites share key %q", a)
+	}
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		html []byte
+	}{
+		{"empty", []byte{}},
+		{"page", []byte(`<html><head><meta charset="utf-8"><title>Интерстеллар (2014) смотреть онлайн</title></head><body></body></html>`)},
+		{"large", []byte(strings.Repeat("<div class=\"item\">фильм</div>\n", 20000))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := Compress(tt.html)
+			if err != nil {
+				t.Fatalf("Compress: %v", err)
+			}
+			got, err := Decompress(compressed)
+			if err != nil {
+				t.Fatalf("Decompress: %v", err)
+			}
+			if !bytes.Equal(got, tt.html) {
+				t.Errorf("round trip changed html: got %d bytes, want %d", len(got), len(tt.html))
+			}
+			if len(tt.html) > 1000 && len(compressed) >= len(tt.html) {
+				t.Errorf("compressed %d bytes, not smaller than %d", len(compressed), len(tt.html))
+			}
+		})
+	}
+
+	if _, err := Decompress([]byte("not gzip")); err == nil {
+		t.Error("Decompress of plain bytes should fail")
+	}
+}
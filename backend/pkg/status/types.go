@@ -50,6 +50,39 @@ const (
 	ScannerSPA  ScannerType = "spa"  // chromedp for SPA/protected sites
 )
 
+// PageWait represents how the browser waits for a page to load before extraction
+// @Description Page load wait strategy
+// @enum delay,selector,network_idle,none
+type PageWait string
+
+const (
+	PageWaitDelay       PageWait = "delay"        // fixed delay
+	PageWaitSelector    PageWait = "selector"     // wait for CSS selector to become visible
+	PageWaitNetworkIdle PageWait = "network_idle" // wait until network requests settle
+	PageWaitNone        PageWait = "none"         // static sites, no waiting
+)
+
+// FreezeReason represents why a site was frozen
+// @Description Site freeze reason
+// @enum captcha_unsolved,ip_blocked,repeated_timeout,manual
+type FreezeReason string
+
+const (
+	FreezeCaptchaUnsolved FreezeReason = "captcha_unsolved" // captcha could not be solved
+	FreezeIPBlocked       FreezeReason = "ip_blocked"       // 403/429/503 or access denied for parser IP
+	FreezeRepeatedTimeout FreezeReason = "repeated_timeout" // site unreachable after retries (timeouts, DNS)
+	FreezeManual          FreezeReason = "manual"           // frozen by operator or reason unknown
+)
+
+// DeadReason represents why a site was marked dead without repeated failures
+// @Description Site dead reason
+// @enum parked
+type DeadReason string
+
+const (
+	DeadParked DeadReason = "parked" // domain expired and shows a registrar parking / for-sale page
+)
+
 // Stage represents the current stage of a scan task
 // @Description Task stage
 // @enum sitemap,page,done
@@ -93,6 +126,21 @@ func AllTaskStatuses() []Task {
 	return []Task{TaskPending, TaskProcessing, TaskCompleted, TaskFailed, TaskCancelled}
 }
 
+// AllFreezeReasons returns all valid freeze reasons
+func AllFreezeReasons() []FreezeReason {
+	return []FreezeReason{FreezeCaptchaUnsolved, FreezeIPBlocked, FreezeRepeatedTimeout, FreezeManual}
+}
+
+// IsValid returns true if the freeze reason is one of the known values
+func (r FreezeReason) IsValid() bool {
+	for _, v := range AllFreezeReasons() {
+		if r == v {
+			return true
+		}
+	}
+	return false
+}
+
 // AllURLStatuses returns all valid URL statuses
 func AllURLStatuses() []URL {
 	return []URL{URLPending, URLProcessing, URLIndexed, URLError, URLSkipped}
@@ -17,40 +17,24 @@ func NewCalculator(repo *Repository, matcher *Matcher) *Calculator {
 	}
 }
 
-func (c *Calculator) CalculateForContent(ctx context.Context, content ContentInfo) (*ContentStats, error) {
-	matches, err := c.matcher.FindAllMatches(ctx, content)
+// CalculateForContent пересчитывает нарушения контента; maxPerStage — потолок выдачи одного
+// поискового запроса, 0 — потолок матчера
+func (c *Calculator) CalculateForContent(ctx context.Context, content ContentInfo, maxPerStage int64) (*ContentStats, error) {
+	matches, err := c.matcher.FindAllMatches(ctx, content, maxPerStage)
 	if err != nil {
 		return nil, err
 	}
-
-	if len(matches) == 0 {
-		if err := c.repo.DeleteByContentID(ctx, content.ID); err != nil {
-			return nil, err
-		}
-		return &ContentStats{
-			ContentID:       content.ID,
-			ViolationsCount: 0,
-			SitesCount:      0,
-		}, nil
+	if matches, err = c.withoutSuppressed(ctx, content.ID, matches); err != nil {
+		return nil, err
 	}
 
 	now := time.Now()
 	violations := make([]Violation, len(matches))
 	pageIDs := make([]string, len(matches))
-	siteSet := make(map[string]struct{})
 
 	for i, match := range matches {
-		violations[i] = Violation{
-			ContentID: content.ID,
-			SiteID:    match.SiteID,
-			PageID:    match.PageID,
-			PageURL:   match.URL,
-			PageTitle: match.Title,
-			MatchType: match.MatchType,
-			FoundAt:   now,
-		}
+		violations[i] = newViolation(content.ID, match, now)
 		pageIDs[i] = match.PageID
-		siteSet[match.SiteID] = struct{}{}
 	}
 
 	if err := c.repo.UpsertMany(ctx, violations); err != nil {
@@ -61,71 +45,132 @@ func (c *Calculator) CalculateForContent(ctx context.Context, content ContentInf
 		return nil, err
 	}
 
-	siteIDs := make([]string, 0, len(siteSet))
-	for siteID := range siteSet {
-		siteIDs = append(siteIDs, siteID)
+	// Ручные нарушения пересчёт не снимает, поэтому счётчики берутся из базы, а не из совпадений
+	return c.repo.GetContentStats(ctx, content.ID)
+}
+
+// PreviewForContent находит нарушения контента так же, как CalculateForContent, но ничего не сохраняет
+func (c *Calculator) PreviewForContent(ctx context.Context, content ContentInfo) ([]Violation, error) {
+	matches, err := c.matcher.FindAllMatches(ctx, content, 0)
+	if err != nil {
+		return nil, err
+	}
+	if matches, err = c.withoutSuppressed(ctx, content.ID, matches); err != nil {
+		return nil, err
 	}
 
-	return &ContentStats{
-		ContentID:       content.ID,
-		ViolationsCount: int64(len(matches)),
-		SitesCount:      int64(len(siteIDs)),
-		PageIDs:         pageIDs,
-		SiteIDs:         siteIDs,
-	}, nil
+	now := time.Now()
+	violations := make([]Violation, len(matches))
+	for i, match := range matches {
+		violations[i] = newViolation(content.ID, match, now)
+	}
+	return violations, nil
 }
 
-func (c *Calculator) CalculateForAllContent(ctx context.Context, contents []ContentInfo) (int64, error) {
-	var updated int64
-	for _, content := range contents {
-		_, err := c.CalculateForContent(ctx, content)
-		if err != nil {
-			continue
+func newViolation(contentID string, match PageMatch, now time.Time) Violation {
+	return Violation{
+		ContentID:   contentID,
+		SiteID:      match.SiteID,
+		PageID:      match.PageID,
+		PageURL:     match.URL,
+		PageTitle:   match.Title,
+		MatchType:   match.MatchType,
+		Evidence:    match.Evidence,
+		MatchQuery:  match.Query,
+		MatchFilter: match.Filter,
+		Season:      match.Season,
+		Episodes:    match.Episodes,
+		FoundAt:     now,
+	}
+}
+
+// withoutSuppressed отбрасывает страницы, отмеченные для контента ложными срабатываниями
+func (c *Calculator) withoutSuppressed(ctx context.Context, contentID string, matches []PageMatch) ([]PageMatch, error) {
+	if len(matches) == 0 {
+		return matches, nil
+	}
+	suppressed, err := c.repo.SuppressedURLs(ctx, contentID)
+	if err != nil {
+		return nil, err
+	}
+	return filterSuppressed(matches, suppressed), nil
+}
+
+func filterSuppressed(matches []PageMatch, suppressed map[string]bool) []PageMatch {
+	if len(suppressed) == 0 {
+		return matches
+	}
+	kept := matches[:0:0]
+	for _, m := range matches {
+		if !suppressed[m.URL] {
+			kept = append(kept, m)
 		}
-		updated++
 	}
-	return updated, nil
+	return kept
+}
+
+// countMatchTypes считает нарушения по этапам матчера; nil для пустого списка
+func countMatchTypes(types []MatchType) map[MatchType]int64 {
+	if len(types) == 0 {
+		return nil
+	}
+	counts := make(map[MatchType]int64)
+	for _, t := range types {
+		counts[t]++
+	}
+	return counts
 }
 
 // CalculateForSite обновляет violations только для страниц конкретного сайта
 func (c *Calculator) CalculateForSite(ctx context.Context, siteID string, contents []ContentInfo) (int64, error) {
 	var updated int64
-	now := time.Now()
-
 	for _, content := range contents {
-		matches, err := c.matcher.FindAllMatchesForSite(ctx, content, siteID)
+		n, err := c.CalculateForContentOnSite(ctx, content, siteID)
 		if err != nil {
 			continue
 		}
+		updated += n
+	}
+	return updated, nil
+}
 
-		var pageIDs []string
-		var violations []Violation
-
-		for _, match := range matches {
-			violations = append(violations, Violation{
-				ContentID: content.ID,
-				SiteID:    match.SiteID,
-				PageID:    match.PageID,
-				PageURL:   match.URL,
-				PageTitle: match.Title,
-				MatchType: match.MatchType,
-				FoundAt:   now,
-			})
-			pageIDs = append(pageIDs, match.PageID)
-		}
+// CalculateForContentOnSite сверяет violations контента только на одном сайте.
+// Нарушения на остальных сайтах не ищутся заново и не меняются
+func (c *Calculator) CalculateForContentOnSite(ctx context.Context, content ContentInfo, siteID string) (int64, error) {
+	matches, err := c.matcher.FindAllMatchesForSite(ctx, content, siteID)
+	if err != nil {
+		return 0, err
+	}
+	if matches, err = c.withoutSuppressed(ctx, content.ID, matches); err != nil {
+		return 0, err
+	}
 
-		if len(violations) > 0 {
-			if err := c.repo.UpsertMany(ctx, violations); err != nil {
-				continue
-			}
-		}
+	violations, pageIDs := siteViolations(content.ID, siteID, matches, time.Now())
 
-		if err := c.repo.DeleteByContentAndSiteNotInPageIDs(ctx, content.ID, siteID, pageIDs); err != nil {
-			continue
+	if len(violations) > 0 {
+		if err := c.repo.UpsertMany(ctx, violations); err != nil {
+			return 0, err
 		}
+	}
 
-		updated += int64(len(violations))
+	if err := c.repo.DeleteByContentAndSiteNotInPageIDs(ctx, content.ID, siteID, pageIDs); err != nil {
+		return 0, err
 	}
 
-	return updated, nil
+	return int64(len(violations)), nil
+}
+
+// siteViolations строит violations из совпадений, отбрасывая страницы чужих сайтов
+func siteViolations(contentID, siteID string, matches []PageMatch, now time.Time) ([]Violation, []string) {
+	var violations []Violation
+	var pageIDs []string
+
+	for _, match := range matches {
+		if match.SiteID != siteID {
+			continue
+		}
+		violations = append(violations, newViolation(contentID, match, now))
+		pageIDs = append(pageIDs, match.PageID)
+	}
+	return violations, pageIDs
 }
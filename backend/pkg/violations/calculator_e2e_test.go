@@ -0,0 +1,284 @@
+//go:build e2e
+// +build e2e
+
+package violations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/video-analitics/backend/pkg/meili"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func setupMongo(t *testing.T, ctx context.Context) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+	}
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("failed to connect to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Skipf("MongoDB is not available at %s: %v", uri, err)
+	}
+
+	db := client.Database(fmt.Sprintf("violations_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return db
+}
+
+func TestSuppressionPreventsReAddOnRecalc_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	repo := NewRepository(setupMongo(t, ctx))
+	searcher := &fakeSearcher{docs: []meili.PageDocument{
+		{ID: "p1", SiteID: "s1", URL: "https://s1.com/film-1", KinopoiskID: "123"},
+		{ID: "p2", SiteID: "s1", URL: "https://s1.com/film-2", KinopoiskID: "123"},
+		{ID: "p3", SiteID: "s2", URL: "https://s2.com/film", KinopoiskID: "123"},
+	}}
+	calc := NewCalculator(repo, &Matcher{meili: searcher, maxHits: DefaultMaxSearchHits})
+	content := ContentInfo{ID: "c1", KinopoiskID: "123"}
+
+	if stats, err := calc.CalculateForContent(ctx, content, 0); err != nil || stats.ViolationsCount != 3 {
+		t.Fatalf("first calculation = %+v, %v", stats, err)
+	}
+
+	if _, err := repo.SetReviewStatus(ctx, "c1", []string{"p1"}, ReviewReviewed); err != nil {
+		t.Fatalf("SetReviewStatus: %v", err)
+	}
+	if deleted, err := repo.Suppress(ctx, "c1", []string{"p2"}); err != nil || deleted != 1 {
+		t.Fatalf("Suppress = %d, %v", deleted, err)
+	}
+
+	// Страница переиндексирована под новым ID — подавление по адресу продолжает действовать
+	searcher.docs[1].ID = "p2-reindexed"
+
+	// Полный и посайтовый пересчёт не возвращают ложное срабатывание
+	for i := 0; i < 2; i++ {
+		stats, err := calc.CalculateForContent(ctx, content, 0)
+		if err != nil {
+			t.Fatalf("recalculation: %v", err)
+		}
+		if stats.ViolationsCount != 2 {
+			t.Fatalf("violations after recalc %d = %d, want 2", i, stats.ViolationsCount)
+		}
+	}
+	if _, err := calc.CalculateForContentOnSite(ctx, content, "s1"); err != nil {
+		t.Fatalf("site recalculation: %v", err)
+	}
+
+	found, total, err := repo.FindByContentID(ctx, "c1", 10, 0)
+	if err != nil || total != 2 {
+		t.Fatalf("FindByContentID total = %d, err %v", total, err)
+	}
+	for _, v := range found {
+		if v.PageURL == "https://s1.com/film-2" {
+			t.Fatal("suppressed page re-added")
+		}
+		// Отметка проверки переживает пересчёт
+		if v.PageID == "p1" && v.ReviewStatus != ReviewReviewed {
+			t.Errorf("review status of p1 = %q, want reviewed", v.ReviewStatus)
+		}
+	}
+
+	suppressions, total, err := repo.FindSuppressions(ctx, "c1", 10, 0)
+	if err != nil || total != 1 {
+		t.Fatalf("FindSuppressions total = %d, err %v", total, err)
+	}
+	if s := suppressions[0]; s.PageURL != "https://s1.com/film-2" || s.SiteID != "s1" {
+		t.Errorf("suppression = %+v", s)
+	}
+
+	// После снятия подавления страница возвращается
+	if ok, err := repo.DeleteSuppression(ctx, "c1", suppressions[0].ID.Hex()); err != nil || !ok {
+		t.Fatalf("DeleteSuppression = %v, %v", ok, err)
+	}
+	if ok, _ := repo.DeleteSuppression(ctx, "c1", suppressions[0].ID.Hex()); ok {
+		t.Error("suppression deleted twice")
+	}
+	if stats, err := calc.CalculateForContent(ctx, content, 0); err != nil || stats.ViolationsCount != 3 {
+		t.Fatalf("calculation after unsuppress = %+v, %v", stats, err)
+	}
+}
+
+func TestUnsuppressByPageID_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	repo := NewRepository(setupMongo(t, ctx))
+	searcher := &fakeSearcher{docs: []meili.PageDocument{
+		{ID: "p1", SiteID: "s1", URL: "https://s1.com/film", KinopoiskID: "123"},
+	}}
+	calc := NewCalculator(repo, &Matcher{meili: searcher, maxHits: DefaultMaxSearchHits})
+	content := ContentInfo{ID: "c1", KinopoiskID: "123"}
+
+	if _, err := calc.CalculateForContent(ctx, content, 0); err != nil {
+		t.Fatalf("calculation: %v", err)
+	}
+	if _, err := repo.Suppress(ctx, "c1", []string{"p1"}); err != nil {
+		t.Fatalf("Suppress: %v", err)
+	}
+	if n, err := repo.Unsuppress(ctx, "c1", []string{"p1"}); err != nil || n != 1 {
+		t.Fatalf("Unsuppress = %d, %v", n, err)
+	}
+	if stats, err := calc.CalculateForContent(ctx, content, 0); err != nil || stats.ViolationsCount != 1 {
+		t.Fatalf("calculation after unsuppress = %+v, %v", stats, err)
+	}
+}
+
+// countingUpdater считает обновления счётчиков контента
+type countingUpdater struct{ calls int }
+
+func (u *countingUpdater) UpdateViolationsCount(ctx context.Context, id string, violationsCount, sitesCount int64, matchTypeCounts map[MatchType]int64) error {
+	u.calls++
+	return nil
+}
+
+func TestPreviewDoesNotPersist_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	repo := NewRepository(setupMongo(t, ctx))
+	searcher := &fakeSearcher{docs: []meili.PageDocument{
+		{ID: "p1", SiteID: "s1", URL: "https://s1.com/film", KinopoiskID: "123"},
+	}}
+	matcher := &Matcher{meili: searcher, maxHits: DefaultMaxSearchHits}
+	updater := &countingUpdater{}
+	svc := &Service{repo: repo, matcher: matcher, calculator: NewCalculator(repo, matcher), contentUpdater: updater}
+	content := ContentInfo{ID: "c1", KinopoiskID: "123"}
+
+	if _, err := svc.RefreshForContent(ctx, content, 0); err != nil {
+		t.Fatalf("RefreshForContent: %v", err)
+	}
+	updater.calls = 0
+
+	// Новые страницы и ложное срабатывание видны в превью, но в базу не попадают
+	searcher.docs = append(searcher.docs,
+		meili.PageDocument{ID: "p2", SiteID: "s2", URL: "https://s2.com/film", KinopoiskID: "123"},
+		meili.PageDocument{ID: "p3", SiteID: "s3", URL: "https://s3.com/film", KinopoiskID: "123"},
+	)
+	if _, err := repo.Suppress(ctx, "c1", []string{"p1"}); err != nil {
+		t.Fatalf("Suppress: %v", err)
+	}
+
+	preview, err := svc.PreviewForContent(ctx, content)
+	if err != nil {
+		t.Fatalf("PreviewForContent: %v", err)
+	}
+	var got []string
+	for _, v := range preview {
+		got = append(got, v.PageID)
+		if v.Evidence != "kinopoisk_id=123" {
+			t.Errorf("preview %s evidence = %q", v.PageID, v.Evidence)
+		}
+	}
+	if len(got) != 2 || got[0] != "p2" || got[1] != "p3" {
+		t.Fatalf("preview pages = %v, want [p2 p3]", got)
+	}
+
+	stored, err := repo.FindAllByContentID(ctx, "c1")
+	if err != nil {
+		t.Fatalf("FindAllByContentID: %v", err)
+	}
+	if len(stored) != 0 {
+		t.Errorf("preview persisted %d violations", len(stored))
+	}
+	if updater.calls != 0 {
+		t.Errorf("preview updated content counts %d times", updater.calls)
+	}
+}
+
+func TestRecalcRecordsRemovedViolations_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	repo := NewRepository(setupMongo(t, ctx))
+	searcher := &fakeSearcher{docs: []meili.PageDocument{
+		{ID: "p1", SiteID: "s1", URL: "https://s1.com/film", KinopoiskID: "123"},
+		{ID: "p2", SiteID: "s2", URL: "https://s2.com/film", KinopoiskID: "123"},
+	}}
+	matcher := &Matcher{meili: searcher, maxHits: DefaultMaxSearchHits}
+	svc := &Service{repo: repo, matcher: matcher, calculator: NewCalculator(repo, matcher)}
+	content := ContentInfo{ID: "c1", KinopoiskID: "123"}
+
+	if _, err := svc.RefreshForContent(ctx, content, 0); err != nil {
+		t.Fatalf("RefreshForContent: %v", err)
+	}
+
+	// Страница p2 пропала: нарушение снимается, но остаётся в истории
+	searcher.docs = searcher.docs[:1]
+	if _, err := svc.RefreshForContent(ctx, content, 0); err != nil {
+		t.Fatalf("RefreshForContent: %v", err)
+	}
+
+	timeline, err := svc.GetTimeline(ctx, "c1")
+	if err != nil {
+		t.Fatalf("GetTimeline: %v", err)
+	}
+	if len(timeline) != 2 {
+		t.Fatalf("got %d timeline entries, want 2", len(timeline))
+	}
+	for _, e := range timeline {
+		removed := e.RemovedAt != nil
+		if removed != (e.PageID == "p2") {
+			t.Errorf("page %s removed=%v", e.PageID, removed)
+		}
+		if e.FirstSeenAt.IsZero() {
+			t.Errorf("page %s has no first_seen_at", e.PageID)
+		}
+	}
+}
+
+func TestManualViolationSurvivesRecalc_E2E(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	repo := NewRepository(setupMongo(t, ctx))
+	searcher := &fakeSearcher{docs: []meili.PageDocument{
+		{ID: "p1", SiteID: "s1", URL: "https://s1.com/film", KinopoiskID: "123"},
+	}}
+	calc := NewCalculator(repo, &Matcher{meili: searcher, maxHits: DefaultMaxSearchHits})
+	svc := &Service{repo: repo, calculator: calc}
+	content := ContentInfo{ID: "c1", KinopoiskID: "123"}
+
+	manual := Violation{ContentID: "c1", SiteID: "s2", PageID: "p-manual", PageURL: "https://s2.com/watch"}
+	if _, created, err := svc.RecordManual(ctx, manual); err != nil || !created {
+		t.Fatalf("RecordManual = %v, %v", created, err)
+	}
+	if _, created, err := svc.RecordManual(ctx, manual); err != nil || created {
+		t.Fatalf("second RecordManual = %v, %v, want existing", created, err)
+	}
+
+	// Матчер находит только p1, но ручное нарушение остаётся и учитывается в счётчиках
+	stats, err := calc.CalculateForContent(ctx, content, 0)
+	if err != nil {
+		t.Fatalf("calculation: %v", err)
+	}
+	if stats.ViolationsCount != 2 || stats.SitesCount != 2 || stats.MatchTypeCounts[MatchManual] != 1 {
+		t.Fatalf("stats = %+v, want p1 and manual page", stats)
+	}
+
+	searcher.docs = nil
+	if stats, err := calc.CalculateForContent(ctx, content, 0); err != nil || stats.ViolationsCount != 1 {
+		t.Fatalf("calculation without matches = %+v, %v", stats, err)
+	}
+	if _, err := calc.CalculateForContentOnSite(ctx, content, "s2"); err != nil {
+		t.Fatalf("site recalculation: %v", err)
+	}
+	v, err := repo.FindByContentAndPage(ctx, "c1", "p-manual")
+	if err != nil || v == nil || v.MatchType != MatchManual {
+		t.Fatalf("manual violation after recalc = %+v, %v", v, err)
+	}
+}
@@ -0,0 +1,133 @@
+package violations
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSiteViolationsIgnoresOtherSites(t *testing.T) {
+	now := time.Now()
+	matches := []PageMatch{
+		{PageID: "p1", SiteID: "s1", MatchType: MatchByKinopoisk},
+		{PageID: "p2", SiteID: "s2", MatchType: MatchByKinopoisk},
+		{PageID: "p3", SiteID: "s1", MatchType: MatchByTitle},
+	}
+
+	violations, pageIDs := siteViolations("c1", "s1", matches, now)
+
+	if len(violations) != 2 {
+		t.Fatalf("got %d violations, want 2", len(violations))
+	}
+	for _, v := range violations {
+		if v.SiteID != "s1" || v.ContentID != "c1" || !v.FoundAt.Equal(now) {
+			t.Errorf("unexpected violation %+v", v)
+		}
+	}
+	if strings.Join(pageIDs, ",") != "p1,p3" {
+		t.Errorf("pageIDs = %v, want [p1 p3]", pageIDs)
+	}
+}
+
+func TestFindAllMatchesForSiteScopesEverySearch(t *testing.T) {
+	searcher := &recordingSearcher{}
+	m := &Matcher{meili: searcher, maxHits: DefaultMaxSearchHits}
+	content := ContentInfo{KinopoiskID: "123", IMDBID: "tt0120737", MALID: "5114", Title: "Властелин колец", Year: 2001}
+
+	if _, err := m.FindAllMatchesForSite(context.Background(), content, "s1"); err != nil {
+		t.Fatalf("FindAllMatchesForSite() error = %v", err)
+	}
+	if len(searcher.calls) == 0 {
+		t.Fatal("expected searches")
+	}
+	for _, call := range searcher.calls {
+		if !strings.Contains(call, `site_id = "s1"`) {
+			t.Errorf("search %q is not scoped to the site", call)
+		}
+	}
+}
+
+func TestCountMatchTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []MatchType
+		want  map[MatchType]int64
+	}{
+		{"empty", nil, nil},
+		{
+			name:  "mixed stages",
+			types: []MatchType{MatchByKinopoisk, MatchByTitle, MatchByKinopoisk, MatchByTitleFuzzyYear, MatchByKinopoisk},
+			want:  map[MatchType]int64{MatchByKinopoisk: 3, MatchByTitle: 1, MatchByTitleFuzzyYear: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countMatchTypes(tt.types); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("countMatchTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type recordingUpdater struct {
+	calls []map[MatchType]int64
+}
+
+func (u *recordingUpdater) UpdateViolationsCount(_ context.Context, _ string, _, _ int64, matchTypeCounts map[MatchType]int64) error {
+	u.calls = append(u.calls, matchTypeCounts)
+	return nil
+}
+
+func TestUpdateContentCountsPassesMatchTypeBreakdown(t *testing.T) {
+	updater := &recordingUpdater{}
+	s := &Service{contentUpdater: updater}
+
+	first := &ContentStats{ContentID: "c1", ViolationsCount: 3, MatchTypeCounts: map[MatchType]int64{MatchByIMDB: 1, MatchByTitle: 2}}
+	s.updateContentCounts(context.Background(), first)
+	// Повторный пересчёт без совпадений должен обнулить разбивку, а не оставить старую
+	s.updateContentCounts(context.Background(), &ContentStats{ContentID: "c1"})
+	s.updateContentCounts(context.Background(), nil)
+
+	if len(updater.calls) != 2 {
+		t.Fatalf("got %d updates, want 2", len(updater.calls))
+	}
+	if !reflect.DeepEqual(updater.calls[0], first.MatchTypeCounts) {
+		t.Errorf("first update = %v, want %v", updater.calls[0], first.MatchTypeCounts)
+	}
+	if len(updater.calls[1]) != 0 {
+		t.Errorf("second update = %v, want empty breakdown", updater.calls[1])
+	}
+}
+
+func TestFilterSuppressed(t *testing.T) {
+	matches := []PageMatch{
+		{PageID: "p1", URL: "https://a.com/1"},
+		{PageID: "p2", URL: "https://a.com/2"},
+		{PageID: "p3", URL: "https://b.com/3"},
+	}
+
+	got := filterSuppressed(matches, map[string]bool{"https://a.com/2": true, "https://c.com/": true})
+	if len(got) != 2 || got[0].PageID != "p1" || got[1].PageID != "p3" {
+		t.Errorf("filterSuppressed() = %v, want p1, p3", got)
+	}
+	if len(matches) != 3 || matches[1].PageID != "p2" {
+		t.Errorf("input slice modified: %v", matches)
+	}
+	if got := filterSuppressed(matches, nil); len(got) != 3 {
+		t.Errorf("without suppressions got %d matches, want 3", len(got))
+	}
+}
+
+func TestParseReviewStatus(t *testing.T) {
+	for _, s := range []string{"", "reviewed", "false_positive"} {
+		if got, ok := ParseReviewStatus(s); !ok || string(got) != s {
+			t.Errorf("ParseReviewStatus(%q) = %q, %v", s, got, ok)
+		}
+	}
+	if _, ok := ParseReviewStatus("ignored"); ok {
+		t.Error("unknown status accepted")
+	}
+}
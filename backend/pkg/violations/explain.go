@@ -0,0 +1,183 @@
+package violations
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/video-analitics/backend/pkg/meili"
+)
+
+// Verdict — итог проверки одного этапа матчера для конкретной страницы
+type Verdict string
+
+const (
+	VerdictMatched           Verdict = "matched"
+	VerdictDisabled          Verdict = "disabled"            // этап выключен в StageConfig
+	VerdictNoID              Verdict = "no_id"               // у контента нет нужного ID
+	VerdictIDMismatch        Verdict = "id_mismatch"         // ID страницы не совпадает / не найден в ссылках
+	VerdictNoYear            Verdict = "no_year"             // у контента не указан год
+	VerdictYearMismatch      Verdict = "year_mismatch"       // год страницы отличается / не найден в заголовке
+	VerdictInvalidTitle      Verdict = "invalid_title"       // пустое или мусорное название
+	VerdictSkippedSingleWord Verdict = "skipped_single_word" // однословное название, этап не выполняется
+	VerdictFilteredByPhrase  Verdict = "filtered_by_phrase"  // отброшено filterHitsByPhrase
+	VerdictTitleWordsMissing Verdict = "title_words_missing" // отброшено containsTitleWithoutStopWords
+)
+
+// StageVerdict — объяснение решения одного этапа
+type StageVerdict struct {
+	Stage   MatchType `json:"stage"`
+	Verdict Verdict   `json:"verdict"`
+	// Вариант названия контента, который проверялся (title, original_title или алиас)
+	Title               string `json:"title,omitempty"`
+	NormalizedTitle     string `json:"normalized_title,omitempty"`
+	NormalizedPageTitle string `json:"normalized_page_title,omitempty"`
+	// Для коротких названий после фразы допустимы только стоп-слова
+	ShortPhrase bool   `json:"short_phrase,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+}
+
+// Explain прогоняет этапы матчера против одной страницы и объясняет решение каждого.
+// Проверяются только локальные условия и пост-фильтры — попадание страницы в выдачу Meilisearch не проверяется
+func (m *Matcher) Explain(content ContentInfo, page meili.PageDocument) []StageVerdict {
+	stages := m.stages.forContent(content)
+	var verdicts []StageVerdict
+
+	verdicts = append(verdicts, explainExactID(stages, MatchByKinopoisk, content.KinopoiskID, page.KinopoiskID))
+	verdicts = append(verdicts, explainExactID(stages, MatchByIMDB, content.IMDBID, page.IMDBID))
+
+	for _, idSearch := range []struct {
+		id        string
+		matchType MatchType
+		regex     *regexp.Regexp
+	}{
+		{content.MALID, MatchByMAL, malURLRegex},
+		{content.ShikimoriID, MatchByShikimori, shikimoriURLRegex},
+		{content.MyDramaListID, MatchByMyDramaList, mdlURLRegex},
+	} {
+		verdicts = append(verdicts, explainLinksID(stages, idSearch.matchType, idSearch.id, page.LinksText, idSearch.regex))
+	}
+
+	// Алиасы проверяются только этапами title_year и title
+	for _, title := range append(contentTitles(content), aliasTitles(content)...) {
+		verdicts = append(verdicts, explainTitleYear(stages, title, content.Year, m.yearTolerance, page))
+	}
+	for _, title := range append(contentTitles(content), aliasTitles(content)...) {
+		verdicts = append(verdicts, explainTitle(stages, title, page, m.singleWordAllowlist[NormalizeTitle(title)]))
+	}
+	for _, title := range contentTitles(content) {
+		verdicts = append(verdicts, explainFuzzyYear(stages, title, content.Year, m.yearTolerance, page))
+	}
+
+	return verdicts
+}
+
+// contentTitles — варианты названия, которые проверяют title-этапы
+func contentTitles(content ContentInfo) []string {
+	titles := []string{content.Title}
+	if isValidTitle(content.OriginalTitle) && content.OriginalTitle != content.Title {
+		titles = append(titles, content.OriginalTitle)
+	}
+	return titles
+}
+
+func explainExactID(stages StageConfig, stage MatchType, contentID, pageID string) StageVerdict {
+	v := StageVerdict{Stage: stage}
+	switch {
+	case !stages.Enabled(stage):
+		v.Verdict = VerdictDisabled
+	case contentID == "":
+		v.Verdict = VerdictNoID
+	case contentID != pageID:
+		v.Verdict = VerdictIDMismatch
+		v.Detail = "page has " + strconv.Quote(pageID) + ", content has " + strconv.Quote(contentID)
+	default:
+		v.Verdict = VerdictMatched
+	}
+	return v
+}
+
+func explainLinksID(stages StageConfig, stage MatchType, id, linksText string, regex *regexp.Regexp) StageVerdict {
+	v := StageVerdict{Stage: stage}
+	switch {
+	case !stages.Enabled(stage):
+		v.Verdict = VerdictDisabled
+	case len(id) < 3:
+		v.Verdict = VerdictNoID
+	case !containsIDInURL(linksText, id, regex):
+		v.Verdict = VerdictIDMismatch
+		v.Detail = "no link with id " + strconv.Quote(id) + " on page"
+	default:
+		v.Verdict = VerdictMatched
+	}
+	return v
+}
+
+// tolerance — допустимое расхождение года страницы с годом контента
+func explainTitleYear(stages StageConfig, title string, year, tolerance int, page meili.PageDocument) StageVerdict {
+	v := titleVerdict(MatchByTitleYear, title, page)
+	v.ShortPhrase = isShortPhrase(title)
+	switch {
+	case !stages.Enabled(MatchByTitleYear):
+		v.Verdict = VerdictDisabled
+	case year <= 0:
+		v.Verdict = VerdictNoYear
+	case !isValidTitle(title):
+		v.Verdict = VerdictInvalidTitle
+	case yearDistance(page.Year, year) > tolerance:
+		v.Verdict = VerdictYearMismatch
+		v.Detail = "page year " + strconv.Itoa(page.Year) + ", content year " + strconv.Itoa(year)
+	case len(filterHitsByPhrase([]meili.PageDocument{page}, title)) == 0:
+		v.Verdict = VerdictFilteredByPhrase
+	default:
+		v.Verdict = VerdictMatched
+	}
+	return v
+}
+
+// allowSingleWord — название в списке однословных, разрешённых для этапа title
+func explainTitle(stages StageConfig, title string, page meili.PageDocument, allowSingleWord bool) StageVerdict {
+	v := titleVerdict(MatchByTitle, title, page)
+	v.ShortPhrase = isShortPhrase(title)
+	switch {
+	case !stages.Enabled(MatchByTitle):
+		v.Verdict = VerdictDisabled
+	case !isValidTitle(title):
+		v.Verdict = VerdictInvalidTitle
+	case isSingleWordTitle(title) && !allowSingleWord:
+		v.Verdict = VerdictSkippedSingleWord
+	case len(filterHitsByPhrase([]meili.PageDocument{page}, title)) == 0:
+		v.Verdict = VerdictFilteredByPhrase
+	default:
+		v.Verdict = VerdictMatched
+	}
+	return v
+}
+
+func explainFuzzyYear(stages StageConfig, title string, year, tolerance int, page meili.PageDocument) StageVerdict {
+	v := titleVerdict(MatchByTitleFuzzyYear, title, page)
+	switch {
+	case !stages.Enabled(MatchByTitleFuzzyYear):
+		v.Verdict = VerdictDisabled
+	case year <= 0:
+		v.Verdict = VerdictNoYear
+	case !isValidTitle(title):
+		v.Verdict = VerdictInvalidTitle
+	case !containsTitleWithoutStopWords(page.Title, title):
+		v.Verdict = VerdictTitleWordsMissing
+	case !containsYearWithin(page.Title, year, tolerance):
+		v.Verdict = VerdictYearMismatch
+		v.Detail = "year " + strconv.Itoa(year) + " not found in page title"
+	default:
+		v.Verdict = VerdictMatched
+	}
+	return v
+}
+
+func titleVerdict(stage MatchType, title string, page meili.PageDocument) StageVerdict {
+	return StageVerdict{
+		Stage:               stage,
+		Title:               title,
+		NormalizedTitle:     NormalizeTitle(title),
+		NormalizedPageTitle: NormalizeTitle(page.Title),
+	}
+}
@@ -0,0 +1,80 @@
+package violations
+
+import (
+	"testing"
+
+	"github.com/video-analitics/backend/pkg/meili"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name    string
+		stages  string
+		content ContentInfo
+		page    meili.PageDocument
+		want    map[MatchType]Verdict
+	}{
+		{
+			name:    "kinopoisk matched, imdb missing",
+			content: ContentInfo{KinopoiskID: "123", Title: "Властелин колец", Year: 2001},
+			page:    meili.PageDocument{KinopoiskID: "123", Title: "Властелин колец смотреть онлайн", Year: 2001},
+			want: map[MatchType]Verdict{
+				MatchByKinopoisk:      VerdictMatched,
+				MatchByIMDB:           VerdictNoID,
+				MatchByTitleYear:      VerdictMatched,
+				MatchByTitle:          VerdictMatched,
+				MatchByTitleFuzzyYear: VerdictYearMismatch,
+			},
+		},
+		{
+			name:    "single word title is not searched by phrase",
+			content: ContentInfo{Title: "Аватар", Year: 2009},
+			page:    meili.PageDocument{Title: "Аватар 2009", Year: 2010},
+			want: map[MatchType]Verdict{
+				MatchByTitleYear:      VerdictYearMismatch,
+				MatchByTitle:          VerdictSkippedSingleWord,
+				MatchByTitleFuzzyYear: VerdictMatched,
+			},
+		},
+		{
+			name:    "short title followed by other words is filtered",
+			content: ContentInfo{Title: "Между нами", Year: 2020},
+			page:    meili.PageDocument{Title: "Между нами горы", Year: 2020},
+			want: map[MatchType]Verdict{
+				MatchByTitleYear: VerdictFilteredByPhrase,
+				MatchByTitle:     VerdictFilteredByPhrase,
+			},
+		},
+		{
+			name:    "mal id not in links and disabled title stage",
+			stages:  "title",
+			content: ContentInfo{MALID: "5114", Title: "Стальной алхимик"},
+			page:    meili.PageDocument{Title: "Стальной алхимик", LinksText: "https://myanimelist.net/anime/1"},
+			want: map[MatchType]Verdict{
+				MatchByMAL:            VerdictIDMismatch,
+				MatchByTitle:          VerdictDisabled,
+				MatchByTitleFuzzyYear: VerdictNoYear,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages, err := ParseStageConfig(tt.stages)
+			if err != nil {
+				t.Fatalf("ParseStageConfig() error = %v", err)
+			}
+			m := &Matcher{stages: stages}
+
+			got := make(map[MatchType]Verdict)
+			for _, v := range m.Explain(tt.content, tt.page) {
+				got[v.Stage] = v.Verdict
+			}
+			for stage, want := range tt.want {
+				if got[stage] != want {
+					t.Errorf("stage %s verdict = %s, want %s", stage, got[stage], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package violations
+
+import "strings"
+
+// languageIDStages — этапы по профильным базам для языков, где транслитерированные
+// названия часто совпадают с русскими фильмами: аниме — MAL/Shikimori, дорамы — MyDramaList
+var languageIDStages = map[string][]MatchType{
+	"ja": {MatchByMAL, MatchByShikimori, MatchByMyDramaList},
+	"ko": {MatchByMyDramaList},
+	"zh": {MatchByMyDramaList},
+	"th": {MatchByMyDramaList},
+}
+
+// regionLanguages — язык по стране производства, если язык не указан
+var regionLanguages = map[string]string{
+	"JP": "ja",
+	"KR": "ko",
+	"CN": "zh",
+	"TW": "zh",
+	"HK": "zh",
+	"TH": "th",
+}
+
+// contentLanguage возвращает код языка контента, при необходимости выводя его из региона
+func contentLanguage(content ContentInfo) string {
+	if lang := strings.ToLower(strings.TrimSpace(content.Language)); lang != "" {
+		return lang
+	}
+	return regionLanguages[strings.ToUpper(strings.TrimSpace(content.Region))]
+}
+
+// hasStageID сообщает, есть ли у контента ID для этапа по внешней базе
+func hasStageID(content ContentInfo, stage MatchType) bool {
+	switch stage {
+	case MatchByMAL:
+		return content.MALID != ""
+	case MatchByShikimori:
+		return content.ShikimoriID != ""
+	case MatchByMyDramaList:
+		return content.MyDramaListID != ""
+	}
+	return false
+}
+
+// forContent учитывает язык контента как мягкий сигнал. Для языков с профильными базами
+// совпадения только по названию ненадёжны: при ID профильной базы этапы только по названию
+// не выполняются, без него пропускается только поиск по названию без года
+func (c StageConfig) forContent(content ContentInfo) StageConfig {
+	preferred, ok := languageIDStages[contentLanguage(content)]
+	if !ok {
+		return c
+	}
+
+	skip := []MatchType{MatchByTitle}
+	for _, stage := range preferred {
+		if c.Enabled(stage) && hasStageID(content, stage) {
+			skip = append(skip, MatchByTitleFuzzyYear)
+			break
+		}
+	}
+
+	disabled := make(map[MatchType]bool, len(c.Disabled)+len(skip))
+	for stage, off := range c.Disabled {
+		disabled[stage] = off
+	}
+	for _, stage := range skip {
+		disabled[stage] = true
+	}
+	c.Disabled = disabled
+	return c
+}
@@ -5,9 +5,12 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/video-analitics/backend/pkg/meili"
+	"golang.org/x/text/unicode/norm"
 )
 
 var stopWords = map[string]bool{
@@ -42,18 +45,160 @@ var (
 	mdlURLRegex       = regexp.MustCompile(`mydramalist\.com/(\d+)`)
 )
 
+const (
+	// searchPageSize — размер одной страницы выдачи Meilisearch
+	searchPageSize int64 = 1000
+	// DefaultMaxSearchHits — потолок результатов одного поискового запроса
+	DefaultMaxSearchHits int64 = 10000
+	// DefaultSearchTimeout — таймаут одного запроса, чтобы зависший Meilisearch не блокировал пересчёт
+	DefaultSearchTimeout = 30 * time.Second
+)
+
+// pageSearcher — часть meili.Client, нужная матчеру
+type pageSearcher interface {
+	SearchPagesWithOffset(ctx context.Context, query, filters string, limit, offset int64) (*meili.SearchResult, error)
+}
+
 type Matcher struct {
-	meili *meili.Client
+	meili         pageSearcher
+	stages        StageConfig
+	maxHits       int64
+	searchTimeout time.Duration
+	// yearTolerance — допустимое расхождение года в этапах title_year и title_fuzzy_year
+	yearTolerance int
+	// singleWordAllowlist — однословные названия (нормализованные), которые этап title всё же ищет
+	singleWordAllowlist map[string]bool
+	// minFuzzyHits — сколько страниц сайта должен найти только title_fuzzy_year, чтобы им поверить
+	minFuzzyHits int
+}
+
+func NewMatcher(meiliClient *meili.Client, stages StageConfig) *Matcher {
+	m := &Matcher{stages: stages, maxHits: DefaultMaxSearchHits, searchTimeout: DefaultSearchTimeout}
+	if meiliClient != nil {
+		m.meili = meiliClient
+	}
+	return m
 }
 
-func NewMatcher(meiliClient *meili.Client) *Matcher {
-	return &Matcher{meili: meiliClient}
+// SetSearchTimeout задаёт таймаут одного запроса к Meilisearch
+func (m *Matcher) SetSearchTimeout(d time.Duration) {
+	if d > 0 {
+		m.searchTimeout = d
+	}
+}
+
+// SetMaxHits задаёт потолок результатов одного поискового запроса
+func (m *Matcher) SetMaxHits(n int64) {
+	if n > 0 {
+		m.maxHits = n
+	}
+}
+
+// SetYearTolerance задаёт допуск по году: сайты и базы часто расходятся на год
+// (фестивальный и широкий прокат). 0 — строгое совпадение
+func (m *Matcher) SetYearTolerance(n int) {
+	if n >= 0 {
+		m.yearTolerance = n
+	}
+}
+
+// SetSingleWordAllowlist разрешает этапу title искать перечисленные однословные названия и алиасы,
+// например редкие "Аватар" или "Дюна"; остальные однословные проверяются только с годом
+func (m *Matcher) SetSingleWordAllowlist(titles []string) {
+	allow := make(map[string]bool, len(titles))
+	for _, title := range titles {
+		if key := NormalizeTitle(title); key != "" {
+			allow[key] = true
+		}
+	}
+	m.singleWordAllowlist = allow
+}
+
+// SetMinFuzzyHits требует не меньше n страниц сайта, найденных только этапом title_fuzzy_year:
+// одиночное нечёткое совпадение на сайте чаще оказывается случайным. 1 и меньше — без ограничения
+func (m *Matcher) SetMinFuzzyHits(n int) {
+	m.minFuzzyHits = n
+}
+
+// titleStageCandidate сообщает, ищет ли этап title это название
+func (m *Matcher) titleStageCandidate(title string) bool {
+	if !isValidTitle(title) {
+		return false
+	}
+	return !isSingleWordTitle(title) || m.singleWordAllowlist[NormalizeTitle(title)]
+}
+
+// yearFilter строит фильтр Meilisearch по году с учётом yearTolerance
+func (m *Matcher) yearFilter(year int) string {
+	if m.yearTolerance == 0 {
+		return "year = " + itoa(year)
+	}
+	return "year >= " + itoa(year-m.yearTolerance) + " AND year <= " + itoa(year+m.yearTolerance)
+}
+
+// stageLimit возвращает потолок результатов одного запроса: maxPerStage, если задан, иначе настроенный в матчере
+func (m *Matcher) stageLimit(maxPerStage int64) int64 {
+	if maxPerStage > 0 {
+		return maxPerStage
+	}
+	return m.maxHits
+}
+
+// searchPage выполняет один запрос к Meilisearch с таймаутом searchTimeout.
+// Отмена ctx прерывает запрос, не дожидаясь ответа
+func (m *Matcher) searchPage(ctx context.Context, query, filter string, limit, offset int64) (*meili.SearchResult, error) {
+	if m.searchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.searchTimeout)
+		defer cancel()
+	}
+	return m.meili.SearchPagesWithOffset(ctx, query, filter, limit, offset)
+}
+
+// searchAll выбирает все результаты запроса страницами по searchPageSize, но не больше maxHits.
+// Дубли между страницами (если индекс меняется во время выборки) отбрасываются.
+// Пустая выдача запоминается в negativeCache пересчёта, если он есть в ctx
+func (m *Matcher) searchAll(ctx context.Context, query, filter string, maxHits int64) ([]meili.PageDocument, error) {
+	cache := negativeCacheFrom(ctx)
+	cacheKey := negativeCacheKey(query, filter)
+	if cache != nil && cache.has(cacheKey) {
+		return nil, nil
+	}
+
+	var hits []meili.PageDocument
+	seen := make(map[string]bool)
+
+	for offset := int64(0); offset < maxHits; offset += searchPageSize {
+		limit := searchPageSize
+		if rest := maxHits - offset; rest < limit {
+			limit = rest
+		}
+
+		result, err := m.searchPage(ctx, query, filter, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range result.Hits {
+			if !seen[hit.ID] {
+				seen[hit.ID] = true
+				hits = append(hits, hit)
+			}
+		}
+		if int64(len(result.Hits)) < limit {
+			break
+		}
+	}
+
+	if cache != nil && len(hits) == 0 {
+		cache.add(cacheKey)
+	}
+	return hits, nil
 }
 
 // FindMatches ищет все совпадения для контента, возвращая лучший MatchType
 // (для обратной совместимости)
 func (m *Matcher) FindMatches(ctx context.Context, content ContentInfo) ([]PageMatch, MatchType, error) {
-	return m.findMatchesWithSiteFilter(ctx, content, "")
+	return m.findMatchesWithSiteFilter(ctx, content, "", m.stages.forContext(ctx).forContent(content), m.maxHits)
 }
 
 // FindMatchesForSite ищет совпадения только на конкретном сайте
@@ -61,21 +206,86 @@ func (m *Matcher) FindMatchesForSite(ctx context.Context, content ContentInfo, s
 	if siteID == "" {
 		return m.FindMatches(ctx, content)
 	}
-	return m.findMatchesWithSiteFilter(ctx, content, siteID)
+	return m.findMatchesWithSiteFilter(ctx, content, siteID, m.stages.forContext(ctx).forContent(content), m.maxHits)
 }
 
 // FindAllMatches собирает ВСЕ совпадения со всех этапов поиска.
 // Каждый PageMatch содержит свой MatchType, показывающий как был найден.
-func (m *Matcher) FindAllMatches(ctx context.Context, content ContentInfo) ([]PageMatch, error) {
-	return m.findAllMatchesWithSiteFilter(ctx, content, "")
+// maxPerStage ограничивает выдачу одного поискового запроса; 0 — потолок матчера
+func (m *Matcher) FindAllMatches(ctx context.Context, content ContentInfo, maxPerStage int64) ([]PageMatch, error) {
+	return m.findAllMatchesWithSiteFilter(ctx, content, "", m.stages.forContext(ctx).forContent(content), m.stageLimit(maxPerStage))
 }
 
 // FindAllMatchesForSite собирает все совпадения только на конкретном сайте
 func (m *Matcher) FindAllMatchesForSite(ctx context.Context, content ContentInfo, siteID string) ([]PageMatch, error) {
-	return m.findAllMatchesWithSiteFilter(ctx, content, siteID)
+	return m.findAllMatchesWithSiteFilter(ctx, content, siteID, m.stages.forContext(ctx).forContent(content), m.maxHits)
+}
+
+// matchStage — один этап поиска. Этапы независимы и выполняются параллельно
+type matchStage struct {
+	matchType MatchType
+	run       func(ctx context.Context) ([]PageMatch, error)
 }
 
-func (m *Matcher) findAllMatchesWithSiteFilter(ctx context.Context, content ContentInfo, siteID string) ([]PageMatch, error) {
+// runStages выполняет этапы параллельно; первая ошибка отменяет остальные.
+// Результаты лежат в порядке stages, независимо от порядка завершения
+func runStages(ctx context.Context, stages []matchStage) ([][]PageMatch, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]PageMatch, len(stages))
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i, stage := range stages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			matches, err := stage.run(ctx)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = matches
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// matchSet собирает совпадения без повторов страниц, безопасен для конкурентного использования
+type matchSet struct {
+	mu      sync.Mutex
+	seen    map[string]bool
+	matches []PageMatch
+}
+
+func newMatchSet() *matchSet {
+	return &matchSet{seen: make(map[string]bool)}
+}
+
+func (s *matchSet) add(matches []PageMatch, matchType MatchType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range matches {
+		if !s.seen[m.PageID] {
+			s.seen[m.PageID] = true
+			m.MatchType = matchType
+			s.matches = append(s.matches, m)
+		}
+	}
+}
+
+func (m *Matcher) findAllMatchesWithSiteFilter(ctx context.Context, content ContentInfo, siteID string, stages StageConfig, maxPerStage int64) ([]PageMatch, error) {
 	if m.meili == nil {
 		return nil, nil
 	}
@@ -85,43 +295,28 @@ func (m *Matcher) findAllMatchesWithSiteFilter(ctx context.Context, content Cont
 		siteFilter = `site_id = "` + siteID + `"`
 	}
 
-	seen := make(map[string]bool)
-	var allMatches []PageMatch
-
-	addMatches := func(matches []PageMatch, matchType MatchType) {
-		for _, m := range matches {
-			if !seen[m.PageID] {
-				seen[m.PageID] = true
-				m.MatchType = matchType
-				allMatches = append(allMatches, m)
-			}
-		}
-	}
+	var active []matchStage
 
 	// Stage 1: exact match by Kinopoisk ID
-	if content.KinopoiskID != "" {
+	if stages.Enabled(MatchByKinopoisk) && content.KinopoiskID != "" {
 		filter := `kinopoisk_id = "` + content.KinopoiskID + `"`
 		if siteFilter != "" {
 			filter = filter + " AND " + siteFilter
 		}
-		matches, err := m.searchByFilter(filter, 10000)
-		if err != nil {
-			return nil, err
-		}
-		addMatches(matches, MatchByKinopoisk)
+		active = append(active, matchStage{MatchByKinopoisk, func(ctx context.Context) ([]PageMatch, error) {
+			return m.searchByFilter(ctx, filter, idEvidence("kinopoisk_id", content.KinopoiskID), maxPerStage)
+		}})
 	}
 
 	// Stage 2: exact match by IMDB
-	if content.IMDBID != "" {
+	if stages.Enabled(MatchByIMDB) && content.IMDBID != "" {
 		filter := `imdb_id = "` + content.IMDBID + `"`
 		if siteFilter != "" {
 			filter = filter + " AND " + siteFilter
 		}
-		matches, err := m.searchByFilter(filter, 10000)
-		if err != nil {
-			return nil, err
-		}
-		addMatches(matches, MatchByIMDB)
+		active = append(active, matchStage{MatchByIMDB, func(ctx context.Context) ([]PageMatch, error) {
+			return m.searchByFilter(ctx, filter, idEvidence("imdb_id", content.IMDBID), maxPerStage)
+		}})
 	}
 
 	// Stage 3-5: MAL, Shikimori, MyDramaList (search in links_text)
@@ -133,72 +328,114 @@ func (m *Matcher) findAllMatchesWithSiteFilter(ctx context.Context, content Cont
 		{content.ShikimoriID, MatchByShikimori},
 		{content.MyDramaListID, MatchByMyDramaList},
 	} {
-		if idSearch.id != "" && len(idSearch.id) >= 3 {
-			matches, err := m.searchByIDInLinksText(idSearch.id, siteFilter, idSearch.matchType, 10000)
-			if err != nil {
-				return nil, err
-			}
-			addMatches(matches, idSearch.matchType)
+		if stages.Enabled(idSearch.matchType) && idSearch.id != "" && len(idSearch.id) >= 3 {
+			active = append(active, matchStage{idSearch.matchType, func(ctx context.Context) ([]PageMatch, error) {
+				return m.searchByIDInLinksText(ctx, idSearch.id, siteFilter, idSearch.matchType, maxPerStage)
+			}})
 		}
 	}
 
 	// Stage 6: title + year (structured field)
-	if content.Year > 0 && content.Title != "" {
-		matches, err := m.searchByTitleAndYearWithSite(content.Title, content.Year, siteFilter, 10000)
-		if err != nil {
-			return nil, err
-		}
-		addMatches(matches, MatchByTitleYear)
-
-		if isValidTitle(content.OriginalTitle) {
-			matches, err = m.searchByTitleAndYearWithSite(content.OriginalTitle, content.Year, siteFilter, 10000)
+	if stages.Enabled(MatchByTitleYear) && content.Year > 0 && content.Title != "" {
+		active = append(active, matchStage{MatchByTitleYear, func(ctx context.Context) ([]PageMatch, error) {
+			matches, err := m.searchByTitleAndYearWithSite(ctx, content.Title, content.Year, siteFilter, maxPerStage)
 			if err != nil {
 				return nil, err
 			}
-			addMatches(matches, MatchByTitleYear)
-		}
+			if isValidTitle(content.OriginalTitle) {
+				more, err := m.searchByTitleAndYearWithSite(ctx, content.OriginalTitle, content.Year, siteFilter, maxPerStage)
+				if err != nil {
+					return nil, err
+				}
+				matches = append(matches, more...)
+			}
+			for _, alias := range aliasTitles(content) {
+				more, err := m.searchByTitleAndYearWithSite(ctx, alias, content.Year, siteFilter, maxPerStage)
+				if err != nil {
+					return nil, err
+				}
+				matches = append(matches, more...)
+			}
+			return matches, nil
+		}})
 	}
 
 	// Stage 7: title only (exact phrase)
 	// Для однословных названий пропускаем - слишком много ложных срабатываний
 	// Используем только kinopoisk_id/imdb_id/title+year для них
-	if isValidTitle(content.Title) && !isSingleWordTitle(content.Title) {
-		matches, err := m.searchExactPhrase(content.Title, siteFilter, 10000)
-		if err != nil {
-			return nil, err
+	if stages.Enabled(MatchByTitle) {
+		var titles []string
+		for _, title := range append([]string{content.Title, content.OriginalTitle}, aliasTitles(content)...) {
+			if m.titleStageCandidate(title) {
+				titles = append(titles, title)
+			}
 		}
-		addMatches(matches, MatchByTitle)
-	}
-
-	if isValidTitle(content.OriginalTitle) && !isSingleWordTitle(content.OriginalTitle) {
-		matches, err := m.searchExactPhrase(content.OriginalTitle, siteFilter, 10000)
-		if err != nil {
-			return nil, err
+		if len(titles) > 0 {
+			active = append(active, matchStage{MatchByTitle, func(ctx context.Context) ([]PageMatch, error) {
+				var all []PageMatch
+				for _, title := range titles {
+					matches, err := m.searchExactPhrase(ctx, title, siteFilter, maxPerStage)
+					if err != nil {
+						return nil, err
+					}
+					all = append(all, matches...)
+				}
+				return all, nil
+			}})
 		}
-		addMatches(matches, MatchByTitle)
 	}
 
 	// Stage 8: fuzzy title + год в тексте (title/description)
-	if content.Year > 0 && isValidTitle(content.Title) {
-		matches, err := m.searchFuzzyWithYearInText(content.Title, content.Year, siteFilter, 10000)
-		if err != nil {
-			return nil, err
-		}
-		addMatches(matches, MatchByTitleFuzzyYear)
-
-		if isValidTitle(content.OriginalTitle) {
-			matches, err = m.searchFuzzyWithYearInText(content.OriginalTitle, content.Year, siteFilter, 10000)
-			if err != nil {
-				return nil, err
+	if stages.Enabled(MatchByTitleFuzzyYear) && content.Year > 0 && isValidTitle(content.Title) {
+		active = append(active, matchStage{MatchByTitleFuzzyYear, func(ctx context.Context) ([]PageMatch, error) {
+			matches, err := m.searchFuzzyWithYearInText(ctx, content.Title, content.Year, siteFilter, maxPerStage)
+			if err != nil || !isValidTitle(content.OriginalTitle) {
+				return matches, err
 			}
-			addMatches(matches, MatchByTitleFuzzyYear)
+			more, err := m.searchFuzzyWithYearInText(ctx, content.OriginalTitle, content.Year, siteFilter, maxPerStage)
+			return append(matches, more...), err
+		}})
+	}
+
+	results, err := runStages(ctx, active)
+	if err != nil {
+		return nil, err
+	}
+
+	// Сливаем в порядке приоритета этапов: при повторе страницы остаётся тип более точного этапа
+	set := newMatchSet()
+	for i, stage := range active {
+		set.add(results[i], stage.matchType)
+	}
+	return dropThinFuzzySites(set.matches, m.minFuzzyHits), nil
+}
+
+// dropThinFuzzySites убирает совпадения title_fuzzy_year на сайтах, где этот этап нашёл меньше
+// minHits страниц. Совпадения остальных этапов не трогаются: страница, найденная и точным
+// этапом, к этому времени уже несёт его тип
+func dropThinFuzzySites(matches []PageMatch, minHits int) []PageMatch {
+	if minHits <= 1 {
+		return matches
+	}
+
+	fuzzyBySite := make(map[string]int)
+	for _, pm := range matches {
+		if pm.MatchType == MatchByTitleFuzzyYear {
+			fuzzyBySite[pm.SiteID]++
 		}
 	}
 
-	return allMatches, nil
+	kept := make([]PageMatch, 0, len(matches))
+	for _, pm := range matches {
+		if pm.MatchType == MatchByTitleFuzzyYear && fuzzyBySite[pm.SiteID] < minHits {
+			continue
+		}
+		kept = append(kept, pm)
+	}
+	return kept
 }
 
-func (m *Matcher) findMatchesWithSiteFilter(ctx context.Context, content ContentInfo, siteID string) ([]PageMatch, MatchType, error) {
+func (m *Matcher) findMatchesWithSiteFilter(ctx context.Context, content ContentInfo, siteID string, stages StageConfig, maxPerStage int64) ([]PageMatch, MatchType, error) {
 	if m.meili == nil {
 		return nil, "", nil
 	}
@@ -208,34 +445,42 @@ func (m *Matcher) findMatchesWithSiteFilter(ctx context.Context, content Content
 		siteFilter = `site_id = "` + siteID + `"`
 	}
 
+	// firstNonEmpty перебирает варианты поиска этапа до первого непустого результата
+	firstNonEmpty := func(ctx context.Context, searches ...func(context.Context) ([]PageMatch, error)) ([]PageMatch, error) {
+		for _, search := range searches {
+			matches, err := search(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) > 0 {
+				return matches, nil
+			}
+		}
+		return nil, nil
+	}
+
+	var active []matchStage
+
 	// Priority 1: exact match by Kinopoisk ID
-	if content.KinopoiskID != "" {
+	if stages.Enabled(MatchByKinopoisk) && content.KinopoiskID != "" {
 		filter := `kinopoisk_id = "` + content.KinopoiskID + `"`
 		if siteFilter != "" {
 			filter = filter + " AND " + siteFilter
 		}
-		matches, err := m.searchByFilterWithType(filter, MatchByKinopoisk, 10000)
-		if err != nil {
-			return nil, "", err
-		}
-		if len(matches) > 0 {
-			return matches, MatchByKinopoisk, nil
-		}
+		active = append(active, matchStage{MatchByKinopoisk, func(ctx context.Context) ([]PageMatch, error) {
+			return m.searchByFilterWithType(ctx, filter, MatchByKinopoisk, idEvidence("kinopoisk_id", content.KinopoiskID), maxPerStage)
+		}})
 	}
 
 	// Priority 2: exact match by IMDB
-	if content.IMDBID != "" {
+	if stages.Enabled(MatchByIMDB) && content.IMDBID != "" {
 		filter := `imdb_id = "` + content.IMDBID + `"`
 		if siteFilter != "" {
 			filter = filter + " AND " + siteFilter
 		}
-		matches, err := m.searchByFilterWithType(filter, MatchByIMDB, 10000)
-		if err != nil {
-			return nil, "", err
-		}
-		if len(matches) > 0 {
-			return matches, MatchByIMDB, nil
-		}
+		active = append(active, matchStage{MatchByIMDB, func(ctx context.Context) ([]PageMatch, error) {
+			return m.searchByFilterWithType(ctx, filter, MatchByIMDB, idEvidence("imdb_id", content.IMDBID), maxPerStage)
+		}})
 	}
 
 	// Priority 3-5: MAL, Shikimori, MyDramaList (search in links_text)
@@ -247,101 +492,101 @@ func (m *Matcher) findMatchesWithSiteFilter(ctx context.Context, content Content
 		{content.ShikimoriID, MatchByShikimori},
 		{content.MyDramaListID, MatchByMyDramaList},
 	} {
-		if idSearch.id != "" && len(idSearch.id) >= 3 {
-			matches, err := m.searchByIDInLinksText(idSearch.id, siteFilter, idSearch.matchType, 10000)
-			if err != nil {
-				return nil, "", err
-			}
-			if len(matches) > 0 {
-				return matches, idSearch.matchType, nil
-			}
+		if stages.Enabled(idSearch.matchType) && idSearch.id != "" && len(idSearch.id) >= 3 {
+			active = append(active, matchStage{idSearch.matchType, func(ctx context.Context) ([]PageMatch, error) {
+				return m.searchByIDInLinksText(ctx, idSearch.id, siteFilter, idSearch.matchType, maxPerStage)
+			}})
 		}
 	}
 
 	// Priority 6: title + year
-	if content.Year > 0 && isValidTitle(content.Title) {
-		matches, err := m.searchByTitleAndYearWithSiteAndType(content.Title, content.Year, siteFilter, MatchByTitleYear, 10000)
-		if err != nil {
-			return nil, "", err
+	if stages.Enabled(MatchByTitleYear) && content.Year > 0 && isValidTitle(content.Title) {
+		searches := []func(context.Context) ([]PageMatch, error){
+			func(ctx context.Context) ([]PageMatch, error) {
+				return m.searchByTitleAndYearWithSiteAndType(ctx, content.Title, content.Year, siteFilter, MatchByTitleYear, maxPerStage)
+			},
 		}
-		if len(matches) > 0 {
-			return matches, MatchByTitleYear, nil
-		}
-
 		if isValidTitle(content.OriginalTitle) {
-			matches, err = m.searchByTitleAndYearWithSiteAndType(content.OriginalTitle, content.Year, siteFilter, MatchByTitleYear, 10000)
-			if err != nil {
-				return nil, "", err
-			}
-			if len(matches) > 0 {
-				return matches, MatchByTitleYear, nil
-			}
+			searches = append(searches, func(ctx context.Context) ([]PageMatch, error) {
+				return m.searchByTitleAndYearWithSiteAndType(ctx, content.OriginalTitle, content.Year, siteFilter, MatchByTitleYear, maxPerStage)
+			})
 		}
+		for _, alias := range aliasTitles(content) {
+			searches = append(searches, func(ctx context.Context) ([]PageMatch, error) {
+				return m.searchByTitleAndYearWithSiteAndType(ctx, alias, content.Year, siteFilter, MatchByTitleYear, maxPerStage)
+			})
+		}
+		active = append(active, matchStage{MatchByTitleYear, func(ctx context.Context) ([]PageMatch, error) {
+			return firstNonEmpty(ctx, searches...)
+		}})
 	}
 
 	// Priority 7: title only (exact phrase)
 	// Пропускаем для однословных названий - слишком много ложных срабатываний
-	if isValidTitle(content.Title) && !isSingleWordTitle(content.Title) {
-		matches, err := m.searchExactPhraseWithType(content.Title, siteFilter, MatchByTitle, 10000)
-		if err != nil {
-			return nil, "", err
+	if stages.Enabled(MatchByTitle) {
+		var searches []func(context.Context) ([]PageMatch, error)
+		for _, title := range append([]string{content.Title, content.OriginalTitle}, aliasTitles(content)...) {
+			if m.titleStageCandidate(title) {
+				searches = append(searches, func(ctx context.Context) ([]PageMatch, error) {
+					return m.searchExactPhraseWithType(ctx, title, siteFilter, MatchByTitle, maxPerStage)
+				})
+			}
 		}
-		if len(matches) > 0 {
-			return matches, MatchByTitle, nil
+		if len(searches) > 0 {
+			active = append(active, matchStage{MatchByTitle, func(ctx context.Context) ([]PageMatch, error) {
+				return firstNonEmpty(ctx, searches...)
+			}})
 		}
 	}
 
-	if isValidTitle(content.OriginalTitle) && !isSingleWordTitle(content.OriginalTitle) {
-		matches, err := m.searchExactPhraseWithType(content.OriginalTitle, siteFilter, MatchByTitle, 10000)
-		if err != nil {
-			return nil, "", err
+	// Priority 8: fuzzy title + год в тексте (title/description)
+	if stages.Enabled(MatchByTitleFuzzyYear) && content.Year > 0 && isValidTitle(content.Title) {
+		searches := []func(context.Context) ([]PageMatch, error){
+			func(ctx context.Context) ([]PageMatch, error) {
+				return m.searchFuzzyWithYearInText(ctx, content.Title, content.Year, siteFilter, maxPerStage)
+			},
 		}
-		if len(matches) > 0 {
-			return matches, MatchByTitle, nil
+		if isValidTitle(content.OriginalTitle) {
+			searches = append(searches, func(ctx context.Context) ([]PageMatch, error) {
+				return m.searchFuzzyWithYearInText(ctx, content.OriginalTitle, content.Year, siteFilter, maxPerStage)
+			})
 		}
+		active = append(active, matchStage{MatchByTitleFuzzyYear, func(ctx context.Context) ([]PageMatch, error) {
+			return firstNonEmpty(ctx, searches...)
+		}})
 	}
 
-	// Priority 8: fuzzy title + год в тексте (title/description)
-	if content.Year > 0 && isValidTitle(content.Title) {
-		matches, err := m.searchFuzzyWithYearInText(content.Title, content.Year, siteFilter, 10000)
-		if err != nil {
-			return nil, "", err
-		}
-		if len(matches) > 0 {
-			return matches, MatchByTitleFuzzyYear, nil
-		}
+	results, err := runStages(ctx, active)
+	if err != nil {
+		return nil, "", err
+	}
 
-		if isValidTitle(content.OriginalTitle) {
-			matches, err = m.searchFuzzyWithYearInText(content.OriginalTitle, content.Year, siteFilter, 10000)
-			if err != nil {
-				return nil, "", err
-			}
-			if len(matches) > 0 {
-				return matches, MatchByTitleFuzzyYear, nil
-			}
+	// Этапы выполнялись параллельно, но побеждает первый по приоритету непустой
+	for i, stage := range active {
+		if len(results[i]) > 0 {
+			return results[i], stage.matchType, nil
 		}
 	}
-
 	return nil, "", nil
 }
 
-func (m *Matcher) searchByFilter(filter string, limit int64) ([]PageMatch, error) {
-	result, err := m.meili.SearchPages("", filter, limit)
+func (m *Matcher) searchByFilter(ctx context.Context, filter, evidence string, maxPerStage int64) ([]PageMatch, error) {
+	hits, err := m.searchAll(ctx, "", filter, maxPerStage)
 	if err != nil {
 		return nil, err
 	}
-	return hitsToMatches(result.Hits), nil
+	return withQuery(withEvidence(hitsToMatches(hits), evidence), "", filter), nil
 }
 
-func (m *Matcher) searchByFilterWithType(filter string, matchType MatchType, limit int64) ([]PageMatch, error) {
-	result, err := m.meili.SearchPages("", filter, limit)
+func (m *Matcher) searchByFilterWithType(ctx context.Context, filter string, matchType MatchType, evidence string, maxPerStage int64) ([]PageMatch, error) {
+	hits, err := m.searchAll(ctx, "", filter, maxPerStage)
 	if err != nil {
 		return nil, err
 	}
-	return hitsToMatchesWithType(result.Hits, matchType), nil
+	return withQuery(withEvidence(hitsToMatchesWithType(hits, matchType), evidence), "", filter), nil
 }
 
-func (m *Matcher) searchByIDInLinksText(id, siteFilter string, matchType MatchType, limit int64) ([]PageMatch, error) {
+func (m *Matcher) searchByIDInLinksText(ctx context.Context, id, siteFilter string, matchType MatchType, maxPerStage int64) ([]PageMatch, error) {
 	if len(id) < 2 {
 		return nil, nil
 	}
@@ -358,16 +603,18 @@ func (m *Matcher) searchByIDInLinksText(id, siteFilter string, matchType MatchTy
 		return nil, nil
 	}
 
-	result, err := m.meili.SearchPages(id, siteFilter, limit)
+	hits, err := m.searchAll(ctx, id, siteFilter, maxPerStage)
 	if err != nil {
 		return nil, err
 	}
 
 	var matches []PageMatch
-	for _, hit := range result.Hits {
+	for _, hit := range hits {
 		if containsIDInURL(hit.LinksText, id, regex) {
 			match := hitToMatch(hit)
 			match.MatchType = matchType
+			match.Evidence = idEvidence(linksIDFields[matchType], id)
+			match.Query, match.Filter = id, siteFilter
 			matches = append(matches, match)
 		}
 	}
@@ -384,64 +631,72 @@ func containsIDInURL(linksText, id string, regex *regexp.Regexp) bool {
 	return false
 }
 
-func (m *Matcher) searchByTitleAndYear(title string, year int, limit int64) ([]PageMatch, error) {
-	return m.searchByTitleAndYearWithSite(title, year, "", limit)
+func (m *Matcher) searchByTitleAndYear(ctx context.Context, title string, year int, maxPerStage int64) ([]PageMatch, error) {
+	return m.searchByTitleAndYearWithSite(ctx, title, year, "", maxPerStage)
 }
 
-func (m *Matcher) searchByTitleAndYearWithSite(title string, year int, siteFilter string, limit int64) ([]PageMatch, error) {
+func (m *Matcher) searchByTitleAndYearWithSite(ctx context.Context, title string, year int, siteFilter string, maxPerStage int64) ([]PageMatch, error) {
 	title = strings.TrimSpace(title)
 	query := `"` + title + `"`
-	filter := "year = " + itoa(year)
+	filter := m.yearFilter(year)
 	if siteFilter != "" {
 		filter = filter + " AND " + siteFilter
 	}
-	result, err := m.meili.SearchPages(query, filter, limit)
+	hits, err := m.searchAll(ctx, query, filter, maxPerStage)
 	if err != nil {
 		return nil, err
 	}
 	// Пост-фильтрация: убеждаемся что title реально присутствует
-	filtered := filterHitsByPhrase(result.Hits, title)
-	return hitsToMatches(filtered), nil
+	filtered := filterHitsByPhrase(hits, title)
+	matches := hitsToMatches(filtered)
+	for i, hit := range filtered {
+		matches[i].Evidence = titleYearEvidence(title, hit.Year, year)
+	}
+	return withQuery(matches, query, filter), nil
 }
 
-func (m *Matcher) searchByTitleAndYearWithSiteAndType(title string, year int, siteFilter string, matchType MatchType, limit int64) ([]PageMatch, error) {
+func (m *Matcher) searchByTitleAndYearWithSiteAndType(ctx context.Context, title string, year int, siteFilter string, matchType MatchType, maxPerStage int64) ([]PageMatch, error) {
 	title = strings.TrimSpace(title)
 	query := `"` + title + `"`
-	filter := "year = " + itoa(year)
+	filter := m.yearFilter(year)
 	if siteFilter != "" {
 		filter = filter + " AND " + siteFilter
 	}
-	result, err := m.meili.SearchPages(query, filter, limit)
+	hits, err := m.searchAll(ctx, query, filter, maxPerStage)
 	if err != nil {
 		return nil, err
 	}
 	// Пост-фильтрация: убеждаемся что title реально присутствует
-	filtered := filterHitsByPhrase(result.Hits, title)
-	return hitsToMatchesWithType(filtered, matchType), nil
+	filtered := filterHitsByPhrase(hits, title)
+	matches := hitsToMatchesWithType(filtered, matchType)
+	for i, hit := range filtered {
+		matches[i].Evidence = titleYearEvidence(title, hit.Year, year)
+	}
+	return withQuery(matches, query, filter), nil
 }
 
-func (m *Matcher) searchExactPhrase(phrase, extraFilter string, limit int64) ([]PageMatch, error) {
+func (m *Matcher) searchExactPhrase(ctx context.Context, phrase, extraFilter string, maxPerStage int64) ([]PageMatch, error) {
 	phrase = strings.TrimSpace(phrase)
 	query := `"` + phrase + `"`
-	result, err := m.meili.SearchPages(query, extraFilter, limit)
+	hits, err := m.searchAll(ctx, query, extraFilter, maxPerStage)
 	if err != nil {
 		return nil, err
 	}
 	// Пост-фильтрация: убеждаемся что фраза реально присутствует в title или description
-	filtered := filterHitsByPhrase(result.Hits, phrase)
-	return hitsToMatches(filtered), nil
+	filtered := filterHitsByPhrase(hits, phrase)
+	return withQuery(withEvidence(hitsToMatches(filtered), titleEvidence(phrase)), query, extraFilter), nil
 }
 
-func (m *Matcher) searchExactPhraseWithType(phrase, extraFilter string, matchType MatchType, limit int64) ([]PageMatch, error) {
+func (m *Matcher) searchExactPhraseWithType(ctx context.Context, phrase, extraFilter string, matchType MatchType, maxPerStage int64) ([]PageMatch, error) {
 	phrase = strings.TrimSpace(phrase)
 	query := `"` + phrase + `"`
-	result, err := m.meili.SearchPages(query, extraFilter, limit)
+	hits, err := m.searchAll(ctx, query, extraFilter, maxPerStage)
 	if err != nil {
 		return nil, err
 	}
 	// Пост-фильтрация: убеждаемся что фраза реально присутствует в title или description
-	filtered := filterHitsByPhrase(result.Hits, phrase)
-	return hitsToMatchesWithType(filtered, matchType), nil
+	filtered := filterHitsByPhrase(hits, phrase)
+	return withQuery(withEvidence(hitsToMatchesWithType(filtered, matchType), titleEvidence(phrase)), query, extraFilter), nil
 }
 
 // filterHitsByPhrase отфильтровывает результаты, оставляя только те где фраза
@@ -452,7 +707,7 @@ func (m *Matcher) searchExactPhraseWithType(phrase, extraFilter string, matchTyp
 // - после названия могут быть только стоп-слова (смотреть, онлайн и т.д.) или год
 // - это отсекает "Между нами горы" при поиске "Между нами"
 func filterHitsByPhrase(hits []meili.PageDocument, phrase string) []meili.PageDocument {
-	phraseNorm := normalizeTitle(phrase)
+	phraseNorm := NormalizeTitle(phrase)
 	if phraseNorm == "" {
 		return nil
 	}
@@ -461,7 +716,7 @@ func filterHitsByPhrase(hits []meili.PageDocument, phrase string) []meili.PageDo
 
 	var filtered []meili.PageDocument
 	for _, hit := range hits {
-		titleNorm := normalizeTitle(hit.Title)
+		titleNorm := NormalizeTitle(hit.Title)
 		if shortPhrase {
 			// Для коротких названий требуем:
 			// 1. Название начинает заголовок
@@ -586,13 +841,13 @@ func titleStartsWithWord(text, word string) bool {
 	return firstWord == word
 }
 
-// normalizeTitle очищает title для сравнения:
+// NormalizeTitle очищает title для сравнения:
 // - lowercase
 // - убирает пробелы по краям
 // - убирает кавычки «»"'
 // - убирает год в скобках (2013)
 // - убирает лишние пробелы
-func normalizeTitle(s string) string {
+func NormalizeTitle(s string) string {
 	s = strings.ToLower(strings.TrimSpace(s))
 	// Убираем кавычки
 	s = strings.ReplaceAll(s, "«", "")
@@ -602,11 +857,50 @@ func normalizeTitle(s string) string {
 	s = strings.ReplaceAll(s, "`", "")
 	// Убираем год в скобках (1999)-(2029)
 	s = yearInParensRegex.ReplaceAllString(s, "")
+	s = foldLatinDiacritics(s)
 	// Убираем лишние пробелы
 	s = strings.Join(strings.Fields(s), " ")
 	return s
 }
 
+// foldLatinDiacritics снимает диакритику с латиницы: "amélie" → "amelie".
+// Кириллица не трогается, иначе "й" превратилась бы в "и"
+func foldLatinDiacritics(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if r <= unicode.MaxASCII || !unicode.Is(unicode.Latin, r) {
+			b.WriteRune(r)
+			continue
+		}
+		for _, d := range norm.NFD.String(string(r)) {
+			if !unicode.Is(unicode.Mn, d) {
+				b.WriteRune(d)
+			}
+		}
+	}
+	return b.String()
+}
+
+// aliasTitles — валидные алиасы контента без повторов основного и оригинального названия
+func aliasTitles(content ContentInfo) []string {
+	seen := map[string]bool{
+		NormalizeTitle(content.Title):         true,
+		NormalizeTitle(content.OriginalTitle): true,
+	}
+	var aliases []string
+	for _, alias := range content.Aliases {
+		alias = strings.TrimSpace(alias)
+		key := NormalizeTitle(alias)
+		if !isValidTitle(alias) || seen[key] {
+			continue
+		}
+		seen[key] = true
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
 // isValidTitle проверяет что название не является мусорным значением
 func isValidTitle(title string) bool {
 	title = strings.TrimSpace(title)
@@ -625,22 +919,23 @@ func isValidTitle(title string) bool {
 
 var yearInParensRegex = regexp.MustCompile(`\s*\((19[5-9]\d|20[0-2]\d)\)\s*`)
 
-func (m *Matcher) searchFuzzyWithYearInText(title string, year int, extraFilter string, limit int64) ([]PageMatch, error) {
+func (m *Matcher) searchFuzzyWithYearInText(ctx context.Context, title string, year int, extraFilter string, maxPerStage int64) ([]PageMatch, error) {
 	title = strings.TrimSpace(title)
-	result, err := m.meili.SearchPages(title, extraFilter, limit)
+	hits, err := m.searchAll(ctx, title, extraFilter, maxPerStage)
 	if err != nil {
 		return nil, err
 	}
 
-	yearStr := strconv.Itoa(year)
 	var filtered []PageMatch
-	for _, hit := range result.Hits {
+	for _, hit := range hits {
 		// Проверяем только title - description содержит слишком много мусора
 		titleMatch := containsTitleWithoutStopWords(hit.Title, title)
-		yearMatch := containsYear(hit.Title, yearStr)
+		pageYear, yearMatch := closestYear(hit.Title, year, m.yearTolerance)
 		if titleMatch && yearMatch {
 			match := hitToMatch(hit)
 			match.MatchType = MatchByTitleFuzzyYear
+			match.Evidence = fuzzyYearEvidence(title, pageYear)
+			match.Query, match.Filter = title, extraFilter
 			filtered = append(filtered, match)
 		}
 	}
@@ -706,6 +1001,76 @@ func containsYear(text, year string) bool {
 	return false
 }
 
+// closestYear ищет в тексте год, отличающийся от year не больше чем на tolerance;
+// при нескольких подходящих берётся ближайший
+func closestYear(text string, year, tolerance int) (int, bool) {
+	best, found := 0, false
+	for _, m := range yearRegex.FindAllString(text, -1) {
+		y, err := strconv.Atoi(m)
+		if err != nil || yearDistance(y, year) > tolerance {
+			continue
+		}
+		if !found || yearDistance(y, year) < yearDistance(best, year) {
+			best, found = y, true
+		}
+	}
+	return best, found
+}
+
+func containsYearWithin(text string, year, tolerance int) bool {
+	_, ok := closestYear(text, year, tolerance)
+	return ok
+}
+
+func yearDistance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// linksIDFields — поле контента, ID из которого ищется в ссылках страницы
+var linksIDFields = map[MatchType]string{
+	MatchByMAL:         "mal_id",
+	MatchByShikimori:   "shikimori_id",
+	MatchByMyDramaList: "mydramalist_id",
+}
+
+func idEvidence(field, id string) string {
+	return field + "=" + id
+}
+
+// titleYearEvidence — год берётся со страницы: при допуске по году он может отличаться от года контента
+func titleYearEvidence(title string, pageYear, year int) string {
+	if pageYear == 0 {
+		pageYear = year
+	}
+	return "title+year '" + title + "' " + itoa(pageYear)
+}
+
+func titleEvidence(title string) string {
+	return "title '" + title + "'"
+}
+
+func fuzzyYearEvidence(title string, year int) string {
+	return "title~year '" + title + "' " + itoa(year)
+}
+
+func withEvidence(matches []PageMatch, evidence string) []PageMatch {
+	for i := range matches {
+		matches[i].Evidence = evidence
+	}
+	return matches
+}
+
+func withQuery(matches []PageMatch, query, filter string) []PageMatch {
+	for i := range matches {
+		matches[i].Query = query
+		matches[i].Filter = filter
+	}
+	return matches
+}
+
 func hitToMatch(hit meili.PageDocument) PageMatch {
 	return PageMatch{
 		PageID:    hit.ID,
@@ -713,6 +1078,8 @@ func hitToMatch(hit meili.PageDocument) PageMatch {
 		Domain:    hit.Domain,
 		URL:       hit.URL,
 		Title:     hit.Title,
+		Season:    hit.Season,
+		Episodes:  hit.Episodes,
 		IndexedAt: parseTime(hit.IndexedAt),
 	}
 }
@@ -726,6 +1093,8 @@ func hitsToMatches(hits []meili.PageDocument) []PageMatch {
 			Domain:    hit.Domain,
 			URL:       hit.URL,
 			Title:     hit.Title,
+			Season:    hit.Season,
+			Episodes:  hit.Episodes,
 			IndexedAt: parseTime(hit.IndexedAt),
 		}
 	}
@@ -742,6 +1111,8 @@ func hitsToMatchesWithType(hits []meili.PageDocument, matchType MatchType) []Pag
 			URL:       hit.URL,
 			Title:     hit.Title,
 			MatchType: matchType,
+			Season:    hit.Season,
+			Episodes:  hit.Episodes,
 			IndexedAt: parseTime(hit.IndexedAt),
 		}
 	}
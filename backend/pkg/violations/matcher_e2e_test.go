@@ -111,7 +111,7 @@ func setupMeilisearch(t *testing.T, ctx context.Context) (*meili.Client, func())
 
 	url := fmt.Sprintf("http://%s:%s", host, port.Port())
 
-	client, err := meili.New(url, "testMasterKey")
+	client, err := meili.New(url, "testMasterKey", "")
 	require.NoError(t, err, "failed to create meilisearch client")
 
 	cleanup := func() {
@@ -157,7 +157,7 @@ func TestMatcher_NarkoTV_E2E(t *testing.T) {
 	allPages := append(narkoTVPages, lordfilmPages...)
 	indexPages(t, client, allPages)
 
-	matcher := violations.NewMatcher(client)
+	matcher := violations.NewMatcher(client, violations.StageConfig{})
 
 	t.Run("FindByExactTitle", func(t *testing.T) {
 		content := violations.ContentInfo{
@@ -192,7 +192,7 @@ func TestMatcher_NarkoTV_E2E(t *testing.T) {
 			Year:          2015,
 		}
 
-		matches, err := matcher.FindAllMatches(ctx, content)
+		matches, err := matcher.FindAllMatches(ctx, content, 0)
 		require.NoError(t, err)
 
 		t.Logf("Found %d total matches from all stages", len(matches))
@@ -341,7 +341,7 @@ func TestMatcher_NarkoTV_E2E(t *testing.T) {
 			Year:          2008,
 		}
 
-		matches, err := matcher.FindAllMatches(ctx, content)
+		matches, err := matcher.FindAllMatches(ctx, content, 0)
 		require.NoError(t, err)
 
 		t.Logf("Found %d matches", len(matches))
@@ -402,7 +402,7 @@ func TestMatcher_StopWords_E2E(t *testing.T) {
 
 	indexPages(t, client, pages)
 
-	matcher := violations.NewMatcher(client)
+	matcher := violations.NewMatcher(client, violations.StageConfig{})
 
 	t.Run("MatchesDespiteStopWords", func(t *testing.T) {
 		content := violations.ContentInfo{
@@ -468,7 +468,7 @@ func TestMatcher_YearInText_E2E(t *testing.T) {
 
 	indexPages(t, client, pages)
 
-	matcher := violations.NewMatcher(client)
+	matcher := violations.NewMatcher(client, violations.StageConfig{})
 
 	t.Run("FindByYearInTitle", func(t *testing.T) {
 		content := violations.ContentInfo{
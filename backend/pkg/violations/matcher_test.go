@@ -1,8 +1,15 @@
 package violations
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/video-analitics/backend/pkg/meili"
 )
@@ -79,6 +86,50 @@ func TestFilterHitsByPhrase(t *testing.T) {
 	}
 }
 
+func TestNormalizeTitleDiacritics(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Amélie", "amelie"},
+		{"Pokémon", "pokemon"},
+		{"Crème Brûlée", "creme brulee"},
+		{"Ñandú (2021)", "nandu"},
+		// Кириллица остаётся как есть
+		{"Йеллоустоун", "йеллоустоун"},
+		{"Ёлки", "ёлки"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := NormalizeTitle(tt.input); got != tt.want {
+				t.Errorf("NormalizeTitle(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterHitsByPhraseDiacritics(t *testing.T) {
+	tests := []struct {
+		phrase string
+		title  string
+	}{
+		{"Amelie", "Amélie смотреть онлайн"},
+		{"Amélie", "Amelie (2001)"},
+		{"Pokemon", "Pokémon смотреть онлайн"},
+		{"Pokémon", "Pokemon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phrase+"/"+tt.title, func(t *testing.T) {
+			hits := []meili.PageDocument{{ID: "1", Title: tt.title}}
+			if got := filterHitsByPhrase(hits, tt.phrase); len(got) != 1 {
+				t.Errorf("filterHitsByPhrase(%q) missed %q", tt.phrase, tt.title)
+			}
+		})
+	}
+}
+
 func TestContainsTitleWithoutStopWords(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -853,3 +904,844 @@ func TestFilterHitsByPhraseWithStopWords(t *testing.T) {
 		})
 	}
 }
+
+// fakeSearcher отдаёт docs постранично, как Meilisearch с offset/limit
+type fakeSearcher struct {
+	mu      sync.Mutex
+	docs    []meili.PageDocument
+	limits  []int64
+	offsets []int64
+}
+
+func (f *fakeSearcher) SearchPagesWithOffset(ctx context.Context, query, filters string, limit, offset int64) (*meili.SearchResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.limits = append(f.limits, limit)
+	f.offsets = append(f.offsets, offset)
+
+	total := int64(len(f.docs))
+	if offset >= total {
+		return &meili.SearchResult{}, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return &meili.SearchResult{Hits: f.docs[offset:end]}, nil
+}
+
+func makePageDocs(n int) []meili.PageDocument {
+	docs := make([]meili.PageDocument, n)
+	for i := range docs {
+		docs[i] = meili.PageDocument{ID: "page-" + itoa(i), SiteID: "site-1", KinopoiskID: "123"}
+	}
+	return docs
+}
+
+func TestFindAllMatchesPaginatesBeyondSinglePage(t *testing.T) {
+	searcher := &fakeSearcher{docs: makePageDocs(12345)}
+	m := &Matcher{meili: searcher, maxHits: DefaultMaxSearchHits}
+
+	matches, err := m.FindAllMatches(context.Background(), ContentInfo{KinopoiskID: "123"}, 20000)
+	if err != nil {
+		t.Fatalf("FindAllMatches() error = %v", err)
+	}
+	if len(matches) != 12345 {
+		t.Errorf("got %d matches, want 12345", len(matches))
+	}
+	if len(searcher.offsets) != 13 {
+		t.Errorf("got %d search calls, want 13 pages", len(searcher.offsets))
+	}
+	for _, match := range matches {
+		if match.MatchType != MatchByKinopoisk {
+			t.Fatalf("match %s has type %s, want %s", match.PageID, match.MatchType, MatchByKinopoisk)
+		}
+	}
+}
+
+func TestFindAllMatchesDedupAcrossPages(t *testing.T) {
+	docs := makePageDocs(11000)
+	// Индекс обновился между запросами: документ со стыка страниц попал в обе
+	docs[int(searchPageSize)] = docs[int(searchPageSize)-1]
+	docs[5*int(searchPageSize)] = docs[2]
+
+	m := &Matcher{meili: &fakeSearcher{docs: docs}, maxHits: DefaultMaxSearchHits}
+
+	matches, err := m.FindAllMatches(context.Background(), ContentInfo{KinopoiskID: "123"}, 20000)
+	if err != nil {
+		t.Fatalf("FindAllMatches() error = %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		if seen[match.PageID] {
+			t.Fatalf("page %s returned twice", match.PageID)
+		}
+		seen[match.PageID] = true
+	}
+	if len(matches) != 10998 {
+		t.Errorf("got %d matches, want 10998 unique", len(matches))
+	}
+}
+
+func TestSearchAllRespectsMaxHits(t *testing.T) {
+	searcher := &fakeSearcher{docs: makePageDocs(12345)}
+	m := &Matcher{meili: searcher}
+	m.SetMaxHits(2500)
+
+	hits, err := m.searchAll(context.Background(), "", "", m.maxHits)
+	if err != nil {
+		t.Fatalf("searchAll() error = %v", err)
+	}
+	if len(hits) != 2500 {
+		t.Errorf("got %d hits, want 2500", len(hits))
+	}
+	wantLimits := []int64{1000, 1000, 500}
+	if !reflect.DeepEqual(searcher.limits, wantLimits) {
+		t.Errorf("limits = %v, want %v", searcher.limits, wantLimits)
+	}
+}
+
+func TestFindAllMatchesMaxPerStage(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxPerStage int64
+		want        int
+	}{
+		{"lower than configured", 1500, 1500},
+		{"higher than configured", 12000, 12000},
+		{"zero keeps configured", 0, 2500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Matcher{meili: &fakeSearcher{docs: makePageDocs(12345)}}
+			m.SetMaxHits(2500)
+
+			matches, err := m.FindAllMatches(context.Background(), ContentInfo{KinopoiskID: "123"}, tt.maxPerStage)
+			if err != nil {
+				t.Fatalf("FindAllMatches() error = %v", err)
+			}
+			if len(matches) != tt.want {
+				t.Errorf("got %d matches, want %d", len(matches), tt.want)
+			}
+		})
+	}
+}
+
+// hangingSearcher имитирует зависший Meilisearch: отвечает только по отмене контекста
+type hangingSearcher struct{}
+
+func (hangingSearcher) SearchPagesWithOffset(ctx context.Context, query, filters string, limit, offset int64) (*meili.SearchResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestFindAllMatchesAbortsOnCancel(t *testing.T) {
+	m := &Matcher{meili: hangingSearcher{}, maxHits: DefaultMaxSearchHits, searchTimeout: time.Minute}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := m.FindAllMatches(ctx, ContentInfo{KinopoiskID: "123", Title: "Властелин колец", Year: 2001}, 0)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("search was not aborted after context cancel")
+	}
+}
+
+func TestSearchPageTimeout(t *testing.T) {
+	m := &Matcher{meili: hangingSearcher{}, maxHits: DefaultMaxSearchHits}
+	m.SetSearchTimeout(20 * time.Millisecond)
+
+	start := time.Now()
+	_, _, err := m.FindMatches(context.Background(), ContentInfo{KinopoiskID: "123"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("per-call timeout was not applied")
+	}
+}
+
+// recordingSearcher запоминает запросы и ничего не находит
+type recordingSearcher struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recordingSearcher) SearchPagesWithOffset(ctx context.Context, query, filters string, limit, offset int64) (*meili.SearchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, query+"|"+filters)
+	return &meili.SearchResult{}, nil
+}
+
+// sortedCalls — этапы выполняются параллельно, порядок запросов не определён
+func (r *recordingSearcher) sortedCalls() []string {
+	calls := append([]string(nil), r.calls...)
+	sort.Strings(calls)
+	return calls
+}
+
+func TestMatcherSkipsDisabledStages(t *testing.T) {
+	content := ContentInfo{KinopoiskID: "123", MALID: "5114", Title: "Властелин колец", Year: 2001}
+
+	tests := []struct {
+		name     string
+		disabled string
+		want     []string
+	}{
+		{
+			name: "all enabled",
+			want: []string{
+				`|kinopoisk_id = "123"`,
+				"5114|",
+				`"Властелин колец"|year = 2001`,
+				`"Властелин колец"|`,
+				"Властелин колец|",
+			},
+		},
+		{
+			name:     "kinopoisk and title disabled",
+			disabled: "kinopoisk, title",
+			want: []string{
+				"5114|",
+				`"Властелин колец"|year = 2001`,
+				"Властелин колец|",
+			},
+		},
+		{
+			name:     "anime stage disabled",
+			disabled: "mal,title_fuzzy_year",
+			want: []string{
+				`|kinopoisk_id = "123"`,
+				`"Властелин колец"|year = 2001`,
+				`"Властелин колец"|`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort.Strings(tt.want)
+			stages, err := ParseStageConfig(tt.disabled)
+			if err != nil {
+				t.Fatalf("ParseStageConfig() error = %v", err)
+			}
+
+			all := &recordingSearcher{}
+			m := &Matcher{meili: all, stages: stages, maxHits: DefaultMaxSearchHits}
+			if _, err := m.FindAllMatches(context.Background(), content, 0); err != nil {
+				t.Fatalf("FindAllMatches() error = %v", err)
+			}
+			if !reflect.DeepEqual(all.sortedCalls(), tt.want) {
+				t.Errorf("FindAllMatches calls = %q, want %q", all.sortedCalls(), tt.want)
+			}
+
+			first := &recordingSearcher{}
+			m.meili = first
+			if _, _, err := m.FindMatches(context.Background(), content); err != nil {
+				t.Fatalf("FindMatches() error = %v", err)
+			}
+			if !reflect.DeepEqual(first.sortedCalls(), tt.want) {
+				t.Errorf("FindMatches calls = %q, want %q", first.sortedCalls(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStageConfigRejectsUnknownStage(t *testing.T) {
+	if _, err := ParseStageConfig("title,kinopoisk_id"); err == nil {
+		t.Error("expected error for unknown stage")
+	}
+}
+
+// delayedSearcher отвечает фиксированной выдачей по ключу "query|filter" с заданной задержкой,
+// чтобы этапы завершались не в порядке приоритета
+type delayedSearcher struct {
+	results map[string][]meili.PageDocument
+	delays  map[string]time.Duration
+}
+
+func (d *delayedSearcher) SearchPagesWithOffset(ctx context.Context, query, filters string, limit, offset int64) (*meili.SearchResult, error) {
+	key := query + "|" + filters
+	select {
+	case <-time.After(d.delays[key]):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if offset > 0 {
+		return &meili.SearchResult{}, nil
+	}
+	return &meili.SearchResult{Hits: d.results[key]}, nil
+}
+
+func newDelayedSearcher() *delayedSearcher {
+	doc := func(id, title string) meili.PageDocument {
+		return meili.PageDocument{ID: id, SiteID: "site-1", Title: title}
+	}
+	return &delayedSearcher{
+		results: map[string][]meili.PageDocument{
+			`|kinopoisk_id = "123"`:         {doc("p1", "LOTR"), doc("p2", "Властелин колец")},
+			`"Властелин колец"|year = 2001`: {doc("p2", "Властелин колец"), doc("p3", "Властелин колец")},
+			`"Властелин колец"|`:            {doc("p3", "Властелин колец"), doc("p4", "Властелин колец смотреть")},
+			"Властелин колец|":              {doc("p4", "Властелин колец смотреть"), doc("p5", "Властелин колец 2001")},
+		},
+		// Приоритетные этапы отвечают последними
+		delays: map[string]time.Duration{
+			`|kinopoisk_id = "123"`:         30 * time.Millisecond,
+			`|kinopoisk_id = "999"`:         30 * time.Millisecond,
+			`"Властелин колец"|year = 2001`: 20 * time.Millisecond,
+			`"Властелин колец"|`:            10 * time.Millisecond,
+		},
+	}
+}
+
+func TestFindAllMatchesConcurrentKeepsStagePriority(t *testing.T) {
+	m := &Matcher{meili: newDelayedSearcher(), maxHits: DefaultMaxSearchHits}
+	content := ContentInfo{KinopoiskID: "123", Title: "Властелин колец", Year: 2001}
+
+	// Результат последовательного прогона этапов
+	want := []struct {
+		pageID    string
+		matchType MatchType
+	}{
+		{"p1", MatchByKinopoisk},
+		{"p2", MatchByKinopoisk},
+		{"p3", MatchByTitleYear},
+		{"p4", MatchByTitle},
+		{"p5", MatchByTitleFuzzyYear},
+	}
+
+	for run := 0; run < 5; run++ {
+		matches, err := m.FindAllMatches(context.Background(), content, 0)
+		if err != nil {
+			t.Fatalf("FindAllMatches() error = %v", err)
+		}
+		if len(matches) != len(want) {
+			t.Fatalf("got %d matches, want %d", len(matches), len(want))
+		}
+		for i, w := range want {
+			if matches[i].PageID != w.pageID || matches[i].MatchType != w.matchType {
+				t.Errorf("run %d: match[%d] = %s/%s, want %s/%s", run, i, matches[i].PageID, matches[i].MatchType, w.pageID, w.matchType)
+			}
+		}
+	}
+}
+
+func TestFindMatchesConcurrentReturnsHighestPriority(t *testing.T) {
+	m := &Matcher{meili: newDelayedSearcher(), maxHits: DefaultMaxSearchHits}
+
+	tests := []struct {
+		name     string
+		content  ContentInfo
+		wantType MatchType
+		wantIDs  []string
+	}{
+		{
+			name:     "slow kinopoisk wins over fast title",
+			content:  ContentInfo{KinopoiskID: "123", Title: "Властелин колец", Year: 2001},
+			wantType: MatchByKinopoisk,
+			wantIDs:  []string{"p1", "p2"},
+		},
+		{
+			name:     "empty kinopoisk falls through to title year",
+			content:  ContentInfo{KinopoiskID: "999", Title: "Властелин колец", Year: 2001},
+			wantType: MatchByTitleYear,
+			wantIDs:  []string{"p2", "p3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, matchType, err := m.FindMatches(context.Background(), tt.content)
+			if err != nil {
+				t.Fatalf("FindMatches() error = %v", err)
+			}
+			if matchType != tt.wantType {
+				t.Errorf("matchType = %s, want %s", matchType, tt.wantType)
+			}
+			var ids []string
+			for _, match := range matches {
+				ids = append(ids, match.PageID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("page ids = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestAliasTitles(t *testing.T) {
+	content := ContentInfo{
+		Title:         "Во все тяжкие",
+		OriginalTitle: "Breaking Bad",
+		Aliases:       []string{"  Пуф ", "breaking bad", "", "-", "Пуф", "Во все тяжкие (2008)", "Breaking Bad: El Camino"},
+	}
+
+	want := []string{"Пуф", "Breaking Bad: El Camino"}
+	if got := aliasTitles(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("aliasTitles() = %q, want %q", got, want)
+	}
+}
+
+func TestMatcherSearchesAliases(t *testing.T) {
+	content := ContentInfo{
+		Title:   "Во все тяжкие",
+		Year:    2008,
+		Aliases: []string{"Пуф", "Breaking Bad"},
+	}
+	stages, _ := ParseStageConfig("title_fuzzy_year")
+
+	// Однословный алиас проверяется только с годом, как и однословное название
+	want := []string{
+		`"Breaking Bad"|`,
+		`"Breaking Bad"|year = 2008`,
+		`"Во все тяжкие"|`,
+		`"Во все тяжкие"|year = 2008`,
+		`"Пуф"|year = 2008`,
+	}
+
+	all := &recordingSearcher{}
+	m := &Matcher{meili: all, stages: stages, maxHits: DefaultMaxSearchHits}
+	if _, err := m.FindAllMatches(context.Background(), content, 0); err != nil {
+		t.Fatalf("FindAllMatches() error = %v", err)
+	}
+	if !reflect.DeepEqual(all.sortedCalls(), want) {
+		t.Errorf("FindAllMatches calls = %q, want %q", all.sortedCalls(), want)
+	}
+
+	first := &recordingSearcher{}
+	m.meili = first
+	if _, _, err := m.FindMatches(context.Background(), content); err != nil {
+		t.Fatalf("FindMatches() error = %v", err)
+	}
+	if !reflect.DeepEqual(first.sortedCalls(), want) {
+		t.Errorf("FindMatches calls = %q, want %q", first.sortedCalls(), want)
+	}
+}
+
+func TestMatcherRequireStrongSignal(t *testing.T) {
+	content := ContentInfo{KinopoiskID: "123", MALID: "5114", Title: "Властелин колец", Year: 2001}
+	strongOnly := []string{
+		`"Властелин колец"|year = 2001`,
+		"5114|",
+		`|kinopoisk_id = "123"`,
+	}
+
+	t.Run("deployment policy skips title-only stages", func(t *testing.T) {
+		all := &recordingSearcher{}
+		m := &Matcher{meili: all, stages: StageConfig{RequireStrongSignal: true}, maxHits: DefaultMaxSearchHits}
+		if _, err := m.FindAllMatches(context.Background(), content, 0); err != nil {
+			t.Fatalf("FindAllMatches() error = %v", err)
+		}
+		if !reflect.DeepEqual(all.sortedCalls(), strongOnly) {
+			t.Errorf("FindAllMatches calls = %q, want %q", all.sortedCalls(), strongOnly)
+		}
+	})
+
+	t.Run("per-request policy via context", func(t *testing.T) {
+		first := &recordingSearcher{}
+		m := &Matcher{meili: first, maxHits: DefaultMaxSearchHits}
+		ctx := WithRequireStrongSignal(context.Background())
+		if _, _, err := m.FindMatches(ctx, content); err != nil {
+			t.Fatalf("FindMatches() error = %v", err)
+		}
+		if !reflect.DeepEqual(first.sortedCalls(), strongOnly) {
+			t.Errorf("FindMatches calls = %q, want %q", first.sortedCalls(), strongOnly)
+		}
+		if m.stages.RequireStrongSignal {
+			t.Error("per-request policy must not change matcher config")
+		}
+	})
+
+	t.Run("title-only matches are excluded", func(t *testing.T) {
+		m := &Matcher{meili: newDelayedSearcher(), maxHits: DefaultMaxSearchHits}
+		ctx := WithRequireStrongSignal(context.Background())
+		matches, err := m.FindAllMatches(ctx, ContentInfo{KinopoiskID: "123", Title: "Властелин колец", Year: 2001}, 0)
+		if err != nil {
+			t.Fatalf("FindAllMatches() error = %v", err)
+		}
+
+		got := make(map[string]MatchType, len(matches))
+		for _, match := range matches {
+			got[match.PageID] = match.MatchType
+		}
+		want := map[string]MatchType{
+			"p1": MatchByKinopoisk,
+			"p2": MatchByKinopoisk,
+			"p3": MatchByTitleYear,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("matches = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestStageConfigRequireStrongSignal(t *testing.T) {
+	cfg := StageConfig{RequireStrongSignal: true}
+	for _, stage := range AllMatchStages {
+		want := stage != MatchByTitle && stage != MatchByTitleFuzzyYear
+		if got := cfg.Enabled(stage); got != want {
+			t.Errorf("Enabled(%s) = %v, want %v", stage, got, want)
+		}
+	}
+}
+
+// yearRangeSearcher применяет к документам фильтр по году, как Meilisearch
+type yearRangeSearcher struct {
+	docs []meili.PageDocument
+}
+
+func (y *yearRangeSearcher) SearchPagesWithOffset(ctx context.Context, query, filters string, limit, offset int64) (*meili.SearchResult, error) {
+	if offset > 0 {
+		return &meili.SearchResult{}, nil
+	}
+	var from, to int
+	if _, err := fmt.Sscanf(filters, "year = %d", &from); err == nil {
+		to = from
+	} else if _, err := fmt.Sscanf(filters, "year >= %d AND year <= %d", &from, &to); err != nil {
+		return nil, fmt.Errorf("unexpected filter %q", filters)
+	}
+
+	var hits []meili.PageDocument
+	for _, doc := range y.docs {
+		if doc.Year >= from && doc.Year <= to {
+			hits = append(hits, doc)
+		}
+	}
+	return &meili.SearchResult{Hits: hits}, nil
+}
+
+func TestSearchByTitleAndYearTolerance(t *testing.T) {
+	var docs []meili.PageDocument
+	for year := 2013; year <= 2017; year++ {
+		docs = append(docs, meili.PageDocument{ID: itoa(year), SiteID: "site-1", Title: "Левиафан", Year: year})
+	}
+
+	tests := []struct {
+		name      string
+		tolerance int
+		wantPages []string
+	}{
+		{"strict by default", 0, []string{"2015"}},
+		{"one year each way", 1, []string{"2014", "2015", "2016"}},
+		{"two years each way", 2, []string{"2013", "2014", "2015", "2016", "2017"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Matcher{meili: &yearRangeSearcher{docs: docs}, maxHits: DefaultMaxSearchHits}
+			m.SetYearTolerance(tt.tolerance)
+
+			matches, err := m.searchByTitleAndYearWithSite(context.Background(), "Левиафан", 2015, "", m.maxHits)
+			if err != nil {
+				t.Fatalf("searchByTitleAndYearWithSite() error = %v", err)
+			}
+			var got []string
+			for _, match := range matches {
+				got = append(got, match.PageID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.wantPages) {
+				t.Errorf("pages = %v, want %v", got, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestYearFilter(t *testing.T) {
+	m := &Matcher{}
+	if got := m.yearFilter(2015); got != "year = 2015" {
+		t.Errorf("yearFilter() without tolerance = %q", got)
+	}
+	m.SetYearTolerance(-1)
+	if m.yearTolerance != 0 {
+		t.Errorf("negative tolerance accepted: %d", m.yearTolerance)
+	}
+	m.SetYearTolerance(1)
+	if got := m.yearFilter(2015); got != "year >= 2014 AND year <= 2016" {
+		t.Errorf("yearFilter() with tolerance 1 = %q", got)
+	}
+}
+
+func TestClosestYear(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		tolerance int
+		wantYear  int
+		wantOK    bool
+	}{
+		{"exact without tolerance", "Левиафан (2015) смотреть", 0, 2015, true},
+		{"neighbour rejected without tolerance", "Левиафан (2014) смотреть", 0, 0, false},
+		{"neighbour accepted with tolerance 1", "Левиафан (2014) смотреть", 1, 2014, true},
+		{"two years off with tolerance 1", "Левиафан (2013) смотреть", 1, 0, false},
+		{"closest of several", "Левиафан 2014, ремастер 2015", 1, 2015, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			year, ok := closestYear(tt.text, 2015, tt.tolerance)
+			if year != tt.wantYear || ok != tt.wantOK {
+				t.Errorf("closestYear() = %d, %v, want %d, %v", year, ok, tt.wantYear, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFuzzyYearTolerance(t *testing.T) {
+	doc := meili.PageDocument{ID: "p1", SiteID: "site-1", Title: "Левиафан 2014 смотреть онлайн"}
+
+	for _, tt := range []struct {
+		tolerance    int
+		wantEvidence string
+	}{
+		{0, ""},
+		{1, "title~year 'Левиафан' 2014"},
+	} {
+		m := &Matcher{meili: &fakeSearcher{docs: []meili.PageDocument{doc}}, maxHits: DefaultMaxSearchHits}
+		m.SetYearTolerance(tt.tolerance)
+
+		matches, err := m.searchFuzzyWithYearInText(context.Background(), "Левиафан", 2015, "", m.maxHits)
+		if err != nil {
+			t.Fatalf("searchFuzzyWithYearInText() error = %v", err)
+		}
+		var got string
+		if len(matches) == 1 {
+			got = matches[0].Evidence
+		}
+		if len(matches) > 1 || got != tt.wantEvidence {
+			t.Errorf("tolerance %d: matches = %+v, want evidence %q", tt.tolerance, matches, tt.wantEvidence)
+		}
+
+		verdict := explainFuzzyYear(StageConfig{}, "Левиафан", 2015, tt.tolerance, doc)
+		if wantMatched := tt.wantEvidence != ""; (verdict.Verdict == VerdictMatched) != wantMatched {
+			t.Errorf("tolerance %d: explain verdict = %s", tt.tolerance, verdict.Verdict)
+		}
+	}
+}
+
+func TestMatcherLanguageScoping(t *testing.T) {
+	base := ContentInfo{Title: "Сад камней", Year: 2015}
+	withMDL := base
+	withMDL.MyDramaListID = "25172"
+
+	tests := []struct {
+		name     string
+		content  func() ContentInfo
+		disabled string
+		want     []string
+	}{
+		{
+			name:    "untagged content runs all stages",
+			content: func() ContentInfo { return withMDL },
+			want:    []string{`"Сад камней"|`, `"Сад камней"|year = 2015`, "25172|", "Сад камней|"},
+		},
+		{
+			name:    "korean drama with MyDramaList ID skips title-only stages",
+			content: func() ContentInfo { c := withMDL; c.Language = "ko"; return c },
+			want:    []string{`"Сад камней"|year = 2015`, "25172|"},
+		},
+		{
+			name:    "language inferred from region",
+			content: func() ContentInfo { c := withMDL; c.Region = "kr"; return c },
+			want:    []string{`"Сад камней"|year = 2015`, "25172|"},
+		},
+		{
+			name:    "korean drama without profile ID keeps year-bound stages",
+			content: func() ContentInfo { c := base; c.Language = "KO"; return c },
+			want:    []string{`"Сад камней"|year = 2015`, "Сад камней|"},
+		},
+		{
+			name:     "disabled profile stage is not preferred",
+			content:  func() ContentInfo { c := withMDL; c.Language = "ko"; return c },
+			disabled: "mydramalist",
+			want:     []string{`"Сад камней"|year = 2015`, "Сад камней|"},
+		},
+		{
+			name:    "other languages are not scoped",
+			content: func() ContentInfo { c := withMDL; c.Language = "en"; c.Region = "US"; return c },
+			want:    []string{`"Сад камней"|`, `"Сад камней"|year = 2015`, "25172|", "Сад камней|"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages, err := ParseStageConfig(tt.disabled)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := &recordingSearcher{}
+			m := &Matcher{meili: rec, stages: stages, maxHits: DefaultMaxSearchHits}
+			if _, err := m.FindAllMatches(context.Background(), tt.content(), 0); err != nil {
+				t.Fatalf("FindAllMatches() error = %v", err)
+			}
+			if !reflect.DeepEqual(rec.sortedCalls(), tt.want) {
+				t.Errorf("calls = %q, want %q", rec.sortedCalls(), tt.want)
+			}
+			if len(m.stages.Disabled) != len(stages.Disabled) {
+				t.Error("language scoping must not change matcher config")
+			}
+		})
+	}
+}
+
+func TestMatchEvidence(t *testing.T) {
+	content := ContentInfo{
+		Title:         "Белое солнце пустыни",
+		Year:          1970,
+		KinopoiskID:   "41519",
+		IMDBID:        "tt0065476",
+		MALID:         "5114",
+		ShikimoriID:   "5114",
+		MyDramaListID: "25172",
+	}
+	doc := meili.PageDocument{
+		ID:        "p1",
+		SiteID:    "site-1",
+		Title:     "Белое солнце пустыни (1969) смотреть онлайн",
+		Year:      1969,
+		LinksText: "https://myanimelist.net/anime/5114 https://shikimori.one/animes/z5114 https://mydramalist.com/25172",
+	}
+
+	tests := []struct {
+		stage      MatchType
+		want       string
+		wantQuery  string
+		wantFilter string
+	}{
+		{MatchByKinopoisk, "kinopoisk_id=41519", "", `kinopoisk_id = "41519"`},
+		{MatchByIMDB, "imdb_id=tt0065476", "", `imdb_id = "tt0065476"`},
+		{MatchByMAL, "mal_id=5114", "5114", ""},
+		{MatchByShikimori, "shikimori_id=5114", "5114", ""},
+		{MatchByMyDramaList, "mydramalist_id=25172", "25172", ""},
+		// Год страницы, а не контента: совпадение найдено с допуском по году
+		{MatchByTitleYear, "title+year 'Белое солнце пустыни' 1969", `"Белое солнце пустыни"`, "year >= 1969 AND year <= 1971"},
+		{MatchByTitle, "title 'Белое солнце пустыни'", `"Белое солнце пустыни"`, ""},
+		{MatchByTitleFuzzyYear, "title~year 'Белое солнце пустыни' 1970", "Белое солнце пустыни", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.stage), func(t *testing.T) {
+			only := StageConfig{Disabled: make(map[MatchType]bool)}
+			for _, stage := range AllMatchStages {
+				only.Disabled[stage] = stage != tt.stage
+			}
+			fuzzyDoc := doc
+			if tt.stage == MatchByTitleFuzzyYear {
+				fuzzyDoc.Title = "Белое солнце пустыни 1970"
+			}
+			m := &Matcher{meili: &fakeSearcher{docs: []meili.PageDocument{fuzzyDoc}}, stages: only, maxHits: DefaultMaxSearchHits}
+			m.SetYearTolerance(1)
+
+			all, err := m.FindAllMatches(context.Background(), content, 0)
+			if err != nil {
+				t.Fatalf("FindAllMatches() error = %v", err)
+			}
+			if len(all) != 1 || all[0].MatchType != tt.stage || all[0].Evidence != tt.want {
+				t.Errorf("FindAllMatches() = %+v, want evidence %q", all, tt.want)
+			}
+			if len(all) == 1 && (all[0].Query != tt.wantQuery || all[0].Filter != tt.wantFilter) {
+				t.Errorf("FindAllMatches() query = %q filter = %q, want %q / %q", all[0].Query, all[0].Filter, tt.wantQuery, tt.wantFilter)
+			}
+
+			first, matchType, err := m.FindMatches(context.Background(), content)
+			if err != nil {
+				t.Fatalf("FindMatches() error = %v", err)
+			}
+			if matchType != tt.stage || len(first) != 1 || first[0].Evidence != tt.want {
+				t.Errorf("FindMatches() = %+v (%s), want evidence %q", first, matchType, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherMatchesAliasOnlyPage(t *testing.T) {
+	content := ContentInfo{
+		Title:         "Левиафан",
+		OriginalTitle: "Leviathan",
+		Year:          2014,
+		Aliases:       []string{"Левиафан Звягинцева", "Кит", "Leviathan"},
+	}
+	titleOnly := StageConfig{Disabled: map[MatchType]bool{MatchByTitleYear: true, MatchByTitleFuzzyYear: true}}
+
+	tests := []struct {
+		name      string
+		pageTitle string
+		allowlist []string
+		want      bool
+	}{
+		{"multi-word alias", "Левиафан Звягинцева смотреть онлайн", nil, true},
+		{"single-word alias skipped", "Кит", nil, false},
+		{"single-word alias allowlisted", "Кит", []string{"кит"}, true},
+		{"allowlist covers only listed titles", "Кит", []string{"Левиафан"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs := []meili.PageDocument{{ID: "p1", SiteID: "site-1", Title: tt.pageTitle}}
+			m := &Matcher{meili: &fakeSearcher{docs: docs}, stages: titleOnly, maxHits: DefaultMaxSearchHits}
+			m.SetSingleWordAllowlist(tt.allowlist)
+
+			all, err := m.FindAllMatches(context.Background(), content, 0)
+			if err != nil {
+				t.Fatalf("FindAllMatches() error = %v", err)
+			}
+			first, _, err := m.FindMatches(context.Background(), content)
+			if err != nil {
+				t.Fatalf("FindMatches() error = %v", err)
+			}
+			if got := len(all) == 1 && all[0].MatchType == MatchByTitle; got != tt.want {
+				t.Errorf("FindAllMatches() = %+v, want matched %v", all, tt.want)
+			}
+			if got := len(first) == 1; got != tt.want {
+				t.Errorf("FindMatches() = %+v, want matched %v", first, tt.want)
+			}
+		})
+	}
+}
+
+func TestDropThinFuzzySites(t *testing.T) {
+	matches := []PageMatch{
+		{PageID: "p1", SiteID: "s1", MatchType: MatchByKinopoisk},
+		{PageID: "p2", SiteID: "s1", MatchType: MatchByTitleFuzzyYear},
+		{PageID: "p3", SiteID: "s2", MatchType: MatchByTitleFuzzyYear},
+		{PageID: "p4", SiteID: "s2", MatchType: MatchByTitleFuzzyYear},
+		{PageID: "p5", SiteID: "s3", MatchType: MatchByTitleYear},
+		{PageID: "p6", SiteID: "s4", MatchType: MatchByTitleFuzzyYear},
+	}
+
+	tests := []struct {
+		name    string
+		minHits int
+		want    []string
+	}{
+		{"disabled", 0, []string{"p1", "p2", "p3", "p4", "p5", "p6"}},
+		{"one hit is enough", 1, []string{"p1", "p2", "p3", "p4", "p5", "p6"}},
+		{"single fuzzy pages dropped, trusted kept", 2, []string{"p1", "p3", "p4", "p5"}},
+		{"all fuzzy dropped", 3, []string{"p1", "p5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, pm := range dropThinFuzzySites(matches, tt.minHits) {
+				got = append(got, pm.PageID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dropThinFuzzySites(min=%d) = %v, want %v", tt.minHits, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package violations
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// DefaultNegativeCacheSize — сколько пустых запросов запоминается за один пересчёт
+const DefaultNegativeCacheSize = 10000
+
+// negativeCache запоминает запросы с пустой выдачей в пределах одного пересчёта.
+// Живёт в контексте RefreshAll/RefreshForSite, поэтому не переживает прогон
+type negativeCache struct {
+	mu    sync.Mutex
+	max   int
+	keys  map[string]struct{}
+	order []string // порядок добавления, для вытеснения самых старых
+}
+
+func newNegativeCache(max int) *negativeCache {
+	if max <= 0 {
+		max = DefaultNegativeCacheSize
+	}
+	return &negativeCache{max: max, keys: make(map[string]struct{})}
+}
+
+func (c *negativeCache) has(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.keys[key]
+	return ok
+}
+
+func (c *negativeCache) add(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.keys[key]; ok {
+		return
+	}
+	if len(c.order) >= c.max {
+		oldest := c.order[0]
+		c.order = c.order[1:]
+		delete(c.keys, oldest)
+	}
+	c.keys[key] = struct{}{}
+	c.order = append(c.order, key)
+}
+
+// negativeCacheKey — регистр и лишние пробелы запроса не влияют на выдачу Meilisearch,
+// значения фильтра сравниваются точно
+func negativeCacheKey(query, filter string) string {
+	query = strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	return query + "\x00" + filter
+}
+
+type negativeCacheCtxKey struct{}
+
+func withNegativeCache(ctx context.Context, cache *negativeCache) context.Context {
+	return context.WithValue(ctx, negativeCacheCtxKey{}, cache)
+}
+
+func negativeCacheFrom(ctx context.Context) *negativeCache {
+	cache, _ := ctx.Value(negativeCacheCtxKey{}).(*negativeCache)
+	return cache
+}
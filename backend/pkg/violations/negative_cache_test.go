@@ -0,0 +1,95 @@
+package violations
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNegativeCacheSkipsRepeatedEmptySearches(t *testing.T) {
+	searcher := &recordingSearcher{}
+	m := &Matcher{meili: searcher, maxHits: DefaultMaxSearchHits}
+	content := ContentInfo{Title: "Неизвестный фильм", Year: 2001}
+
+	ctx := withNegativeCache(context.Background(), newNegativeCache(100))
+	if _, err := m.FindAllMatches(ctx, content, 0); err != nil {
+		t.Fatalf("FindAllMatches() error = %v", err)
+	}
+	first := len(searcher.calls)
+	if first == 0 {
+		t.Fatal("expected searches on first run")
+	}
+
+	// Тот же запрос с другим регистром и пробелами — попадание в кэш
+	if _, err := m.FindAllMatches(ctx, ContentInfo{Title: "неизвестный  ФИЛЬМ", Year: 2001}, 0); err != nil {
+		t.Fatalf("FindAllMatches() error = %v", err)
+	}
+	if len(searcher.calls) != first {
+		t.Errorf("got %d searches after cached run, want %d", len(searcher.calls), first)
+	}
+
+	// Другой год — другой фильтр, промах
+	if _, err := m.FindAllMatches(ctx, ContentInfo{Title: "Неизвестный фильм", Year: 2002}, 0); err != nil {
+		t.Fatalf("FindAllMatches() error = %v", err)
+	}
+	if len(searcher.calls) == first {
+		t.Error("search with different filter should miss the cache")
+	}
+}
+
+func TestNegativeCacheDoesNotStoreHits(t *testing.T) {
+	searcher := &fakeSearcher{docs: makePageDocs(3)}
+	m := &Matcher{meili: searcher, maxHits: DefaultMaxSearchHits}
+	ctx := withNegativeCache(context.Background(), newNegativeCache(100))
+
+	for i := 0; i < 2; i++ {
+		matches, err := m.FindAllMatches(ctx, ContentInfo{KinopoiskID: "123"}, 0)
+		if err != nil {
+			t.Fatalf("FindAllMatches() error = %v", err)
+		}
+		if len(matches) != 3 {
+			t.Fatalf("got %d matches, want 3", len(matches))
+		}
+	}
+	if len(searcher.offsets) != 2 {
+		t.Errorf("got %d searches, want 2 (non-empty results are not cached)", len(searcher.offsets))
+	}
+}
+
+func TestNegativeCacheScopedToRun(t *testing.T) {
+	searcher := &recordingSearcher{}
+	m := &Matcher{meili: searcher, maxHits: DefaultMaxSearchHits}
+	content := ContentInfo{KinopoiskID: "404"}
+
+	run1 := withNegativeCache(context.Background(), newNegativeCache(100))
+	m.FindAllMatches(run1, content, 0)
+	m.FindAllMatches(run1, content, 0)
+
+	run2 := withNegativeCache(context.Background(), newNegativeCache(100))
+	m.FindAllMatches(run2, content, 0)
+
+	// Без кэша в контексте пустые выдачи не запоминаются
+	m.FindAllMatches(context.Background(), content, 0)
+	m.FindAllMatches(context.Background(), content, 0)
+
+	if len(searcher.calls) != 4 {
+		t.Errorf("got %d searches, want 4 (one per run plus two uncached)", len(searcher.calls))
+	}
+}
+
+func TestNegativeCacheBounded(t *testing.T) {
+	cache := newNegativeCache(2)
+	cache.add("a")
+	cache.add("b")
+	cache.add("a")
+	cache.add("c")
+
+	if cache.has("a") {
+		t.Error("oldest entry should be evicted")
+	}
+	if !cache.has("b") || !cache.has("c") {
+		t.Error("recent entries should stay cached")
+	}
+	if len(cache.keys) != 2 || len(cache.order) != 2 {
+		t.Errorf("cache size = %d/%d, want 2", len(cache.keys), len(cache.order))
+	}
+}
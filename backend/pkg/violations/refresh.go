@@ -0,0 +1,105 @@
+package violations
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RefreshProgress — состояние пакетного пересчёта
+type RefreshProgress struct {
+	Done    int
+	Total   int
+	Updated int64
+	// Violations — сколько нарушений найдено по уже пересчитанному контенту
+	Violations int64
+	Elapsed    time.Duration
+}
+
+// Rate — обработано контента в секунду
+func (p RefreshProgress) Rate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Done) / p.Elapsed.Seconds()
+}
+
+type RefreshOptions struct {
+	// Workers — сколько контента пересчитывается параллельно (<=1 — последовательно)
+	Workers int
+	// OnProgress вызывается после каждого контента; вызовы сериализованы
+	OnProgress func(RefreshProgress)
+}
+
+// RefreshAllWithOptions пересчитывает violations для всего контента пулом из opts.Workers воркеров.
+// Счётчики контента обновляются через ContentCountUpdater сразу после пересчёта каждого контента
+func (s *Service) RefreshAllWithOptions(ctx context.Context, contents []ContentInfo, opts RefreshOptions) (int64, error) {
+	ctx = withNegativeCache(ctx, newNegativeCache(s.negativeCacheSize))
+
+	var (
+		updated  atomic.Int64
+		found    atomic.Int64
+		mu       sync.Mutex
+		done     int
+		started  = time.Now()
+		progress = opts.OnProgress
+	)
+
+	forEachConcurrent(ctx, len(contents), opts.Workers, func(i int) {
+		if stats, err := s.RefreshForContent(ctx, contents[i], 0); err == nil {
+			updated.Add(1)
+			found.Add(stats.ViolationsCount)
+		}
+
+		if progress == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		progress(RefreshProgress{
+			Done:       done,
+			Total:      len(contents),
+			Updated:    updated.Load(),
+			Violations: found.Load(),
+			Elapsed:    time.Since(started),
+		})
+	})
+
+	return updated.Load(), ctx.Err()
+}
+
+// forEachConcurrent вызывает fn для индексов [0, n) не более чем в workers горутинах.
+// После отмены ctx новые индексы не выдаются, уже запущенные вызовы дорабатывают
+func forEachConcurrent(ctx context.Context, n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+}
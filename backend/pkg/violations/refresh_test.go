@@ -0,0 +1,92 @@
+package violations
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachConcurrentProcessesAllItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		workers int
+	}{
+		{"sequential", 100, 1},
+		{"zero workers falls back to one", 10, 0},
+		{"parallel", 1000, 8},
+		{"more workers than items", 3, 16},
+		{"empty", 0, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				seen    = make(map[int]int)
+				running atomic.Int32
+				peak    atomic.Int32
+			)
+
+			forEachConcurrent(context.Background(), tt.n, tt.workers, func(i int) {
+				cur := running.Add(1)
+				for {
+					p := peak.Load()
+					if cur <= p || peak.CompareAndSwap(p, cur) {
+						break
+					}
+				}
+				time.Sleep(100 * time.Microsecond)
+				running.Add(-1)
+
+				mu.Lock()
+				seen[i]++
+				mu.Unlock()
+			})
+
+			if len(seen) != tt.n {
+				t.Fatalf("processed %d items, want %d", len(seen), tt.n)
+			}
+			for i, count := range seen {
+				if count != 1 {
+					t.Errorf("item %d processed %d times", i, count)
+				}
+			}
+			limit := tt.workers
+			if limit < 1 {
+				limit = 1
+			}
+			if int(peak.Load()) > limit {
+				t.Errorf("peak concurrency %d exceeds %d workers", peak.Load(), limit)
+			}
+		})
+	}
+}
+
+func TestForEachConcurrentStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var processed atomic.Int32
+
+	forEachConcurrent(ctx, 1000, 4, func(i int) {
+		if processed.Add(1) == 10 {
+			cancel()
+		}
+		time.Sleep(time.Millisecond)
+	})
+
+	if n := processed.Load(); n >= 1000 {
+		t.Errorf("processed %d items after cancel, want early stop", n)
+	}
+}
+
+func TestRefreshProgressRate(t *testing.T) {
+	p := RefreshProgress{Done: 50, Total: 100, Elapsed: 10 * time.Second}
+	if p.Rate() != 5 {
+		t.Errorf("Rate() = %v, want 5", p.Rate())
+	}
+	if (RefreshProgress{}).Rate() != 0 {
+		t.Error("Rate() with zero elapsed should be 0")
+	}
+}
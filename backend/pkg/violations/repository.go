@@ -2,17 +2,37 @@ package violations
 
 import (
 	"context"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-const collectionName = "violations"
+const (
+	collectionName             = "violations"
+	pagesCollectionName        = "pages"
+	suppressionsCollectionName = "violation_suppressions"
+	removalsCollectionName     = "violation_removals"
+)
+
+// externalIDFields — поля страницы, по которым матчер ищет контент
+var externalIDFields = []string{
+	"external_ids.kinopoisk_id",
+	"external_ids.imdb_id",
+	"external_ids.mal_id",
+	"external_ids.shikimori_id",
+	"external_ids.mydramalist_id",
+}
 
 type Repository struct {
-	coll *mongo.Collection
+	coll         *mongo.Collection
+	suppressions *mongo.Collection
+	removals     *mongo.Collection
 }
 
 func NewRepository(db *mongo.Database) *Repository {
@@ -24,7 +44,8 @@ func NewRepository(db *mongo.Database) *Repository {
 	indexes := []mongo.IndexModel{
 		{Keys: bson.D{{Key: "content_id", Value: 1}}},
 		{Keys: bson.D{{Key: "site_id", Value: 1}}},
-		{Keys: bson.D{{Key: "page_id", Value: 1}}},
+		// покрывает выборку content_id по странице
+		{Keys: bson.D{{Key: "page_id", Value: 1}, {Key: "content_id", Value: 1}}},
 		{
 			Keys:    bson.D{{Key: "content_id", Value: 1}, {Key: "page_id", Value: 1}},
 			Options: options.Index().SetUnique(true),
@@ -32,7 +53,194 @@ func NewRepository(db *mongo.Database) *Repository {
 	}
 	coll.Indexes().CreateMany(ctx, indexes)
 
-	return &Repository{coll: coll}
+	suppressions := db.Collection(suppressionsCollectionName)
+	suppressions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "content_id", Value: 1}, {Key: "page_url", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	removals := db.Collection(removalsCollectionName)
+	removals.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "content_id", Value: 1}, {Key: "removed_at", Value: 1}},
+	})
+
+	return &Repository{coll: coll, suppressions: suppressions, removals: removals}
+}
+
+// SetReviewStatus ставит или снимает отметку проверки у нарушений контента на страницах pageIDs
+func (r *Repository) SetReviewStatus(ctx context.Context, contentID string, pageIDs []string, status ReviewStatus) (int64, error) {
+	filter := bson.M{"content_id": contentID, "page_id": bson.M{"$in": pageIDs}}
+	update := bson.M{"$set": bson.M{"review_status": status, "reviewed_at": time.Now()}}
+	if status == ReviewNone {
+		update = bson.M{"$unset": bson.M{"review_status": "", "reviewed_at": ""}}
+	}
+	res, err := r.coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// Suppress отмечает страницы ложными срабатываниями: нарушения удаляются,
+// а подавление по адресу страницы не даёт пересчёту добавить их снова
+func (r *Repository) Suppress(ctx context.Context, contentID string, pageIDs []string) (int64, error) {
+	if len(pageIDs) == 0 {
+		return 0, nil
+	}
+
+	targets, err := r.suppressionTargets(ctx, contentID, pageIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(targets))
+	for _, t := range targets {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"content_id": contentID, "page_url": t.PageURL}).
+			SetUpdate(bson.M{
+				"$set": bson.M{"page_id": t.PageID, "site_id": t.SiteID},
+				"$setOnInsert": bson.M{
+					"content_id": contentID,
+					"page_url":   t.PageURL,
+					"created_at": now,
+				},
+			}).
+			SetUpsert(true))
+	}
+	if len(models) > 0 {
+		if _, err := r.suppressions.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := r.coll.DeleteMany(ctx, bson.M{"content_id": contentID, "page_id": bson.M{"$in": pageIDs}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// suppressionTargets находит адреса страниц: из нарушений, а для страниц без нарушения — из pages.
+// Страницы, адрес которых неизвестен, пропускаются
+func (r *Repository) suppressionTargets(ctx context.Context, contentID string, pageIDs []string) ([]Suppression, error) {
+	cursor, err := r.coll.Find(ctx, bson.M{"content_id": contentID, "page_id": bson.M{"$in": pageIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var found []Violation
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	byPage := make(map[string]Suppression, len(pageIDs))
+	for _, v := range found {
+		byPage[v.PageID] = Suppression{PageID: v.PageID, SiteID: v.SiteID, PageURL: v.PageURL}
+	}
+
+	var missing []primitive.ObjectID
+	for _, id := range pageIDs {
+		if _, ok := byPage[id]; ok {
+			continue
+		}
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			missing = append(missing, oid)
+		}
+	}
+	if len(missing) > 0 {
+		pages := r.coll.Database().Collection(pagesCollectionName)
+		cursor, err := pages.Find(ctx, bson.M{"_id": bson.M{"$in": missing}},
+			options.Find().SetProjection(bson.M{"site_id": 1, "url": 1}))
+		if err != nil {
+			return nil, err
+		}
+		var docs []struct {
+			ID     primitive.ObjectID `bson:"_id"`
+			SiteID string             `bson:"site_id"`
+			URL    string             `bson:"url"`
+		}
+		if err := cursor.All(ctx, &docs); err != nil {
+			return nil, err
+		}
+		for _, d := range docs {
+			byPage[d.ID.Hex()] = Suppression{PageID: d.ID.Hex(), SiteID: d.SiteID, PageURL: d.URL}
+		}
+	}
+
+	targets := make([]Suppression, 0, len(byPage))
+	for _, id := range pageIDs {
+		if t, ok := byPage[id]; ok && t.PageURL != "" {
+			targets = append(targets, t)
+		}
+	}
+	return targets, nil
+}
+
+// Unsuppress снимает подавление страниц; нарушения вернутся при следующем пересчёте
+func (r *Repository) Unsuppress(ctx context.Context, contentID string, pageIDs []string) (int64, error) {
+	res, err := r.suppressions.DeleteMany(ctx, bson.M{"content_id": contentID, "page_id": bson.M{"$in": pageIDs}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// SuppressedURLs — адреса страниц, отмеченных ложными срабатываниями для контента
+func (r *Repository) SuppressedURLs(ctx context.Context, contentID string) (map[string]bool, error) {
+	urls, err := r.suppressions.Distinct(ctx, "page_url", bson.M{"content_id": contentID})
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		if s, ok := u.(string); ok {
+			result[s] = true
+		}
+	}
+	return result, nil
+}
+
+// FindSuppressions возвращает подавления контента, новые первыми
+func (r *Repository) FindSuppressions(ctx context.Context, contentID string, limit, offset int64) ([]Suppression, int64, error) {
+	filter := bson.M{"content_id": contentID}
+
+	total, err := r.suppressions.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(limit).
+		SetSkip(offset)
+	cursor, err := r.suppressions.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	var items []Suppression
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// DeleteSuppression удаляет одно подавление контента; false, если его нет
+func (r *Repository) DeleteSuppression(ctx context.Context, contentID, suppressionID string) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(suppressionID)
+	if err != nil {
+		return false, nil
+	}
+	res, err := r.suppressions.DeleteOne(ctx, bson.M{"_id": oid, "content_id": contentID})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// DeleteSuppressionsByContentID убирает подавления удалённого контента
+func (r *Repository) DeleteSuppressionsByContentID(ctx context.Context, contentID string) error {
+	_, err := r.suppressions.DeleteMany(ctx, bson.M{"content_id": contentID})
+	return err
 }
 
 func (r *Repository) Upsert(ctx context.Context, v *Violation) error {
@@ -43,15 +251,21 @@ func (r *Repository) Upsert(ctx context.Context, v *Violation) error {
 
 	update := bson.M{
 		"$set": bson.M{
-			"site_id":    v.SiteID,
-			"page_url":   v.PageURL,
-			"page_title": v.PageTitle,
-			"match_type": v.MatchType,
-			"found_at":   v.FoundAt,
+			"site_id":      v.SiteID,
+			"page_url":     v.PageURL,
+			"page_title":   v.PageTitle,
+			"match_type":   v.MatchType,
+			"season":       v.Season,
+			"episodes":     v.Episodes,
+			"found_at":     v.FoundAt,
+			"evidence":     v.Evidence,
+			"match_query":  v.MatchQuery,
+			"match_filter": v.MatchFilter,
 		},
 		"$setOnInsert": bson.M{
-			"content_id": v.ContentID,
-			"page_id":    v.PageID,
+			"content_id":    v.ContentID,
+			"page_id":       v.PageID,
+			"first_seen_at": v.FoundAt,
 		},
 	}
 
@@ -73,15 +287,21 @@ func (r *Repository) UpsertMany(ctx context.Context, violations []Violation) err
 		}
 		update := bson.M{
 			"$set": bson.M{
-				"site_id":    v.SiteID,
-				"page_url":   v.PageURL,
-				"page_title": v.PageTitle,
-				"match_type": v.MatchType,
-				"found_at":   v.FoundAt,
+				"site_id":      v.SiteID,
+				"page_url":     v.PageURL,
+				"page_title":   v.PageTitle,
+				"match_type":   v.MatchType,
+				"season":       v.Season,
+				"episodes":     v.Episodes,
+				"found_at":     v.FoundAt,
+				"evidence":     v.Evidence,
+				"match_query":  v.MatchQuery,
+				"match_filter": v.MatchFilter,
 			},
 			"$setOnInsert": bson.M{
-				"content_id": v.ContentID,
-				"page_id":    v.PageID,
+				"content_id":    v.ContentID,
+				"page_id":       v.PageID,
+				"first_seen_at": v.FoundAt,
 			},
 		}
 		models[i] = mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true)
@@ -102,6 +322,24 @@ func (r *Repository) DeleteByPageID(ctx context.Context, pageID string) error {
 	return err
 }
 
+// Resolve снимает нарушение контента на странице с записью в журнал снятых нарушений
+func (r *Repository) Resolve(ctx context.Context, contentID, pageID string) error {
+	return r.removeWithHistory(ctx, bson.M{"content_id": contentID, "page_id": pageID})
+}
+
+// FindByContentAndPage возвращает nil, если нарушения по странице нет
+func (r *Repository) FindByContentAndPage(ctx context.Context, contentID, pageID string) (*Violation, error) {
+	var v Violation
+	err := r.coll.FindOne(ctx, bson.M{"content_id": contentID, "page_id": pageID}).Decode(&v)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
 func (r *Repository) FindByContentID(ctx context.Context, contentID string, limit, offset int64) ([]Violation, int64, error) {
 	filter := bson.M{"content_id": contentID}
 
@@ -160,10 +398,11 @@ func (r *Repository) GetContentStats(ctx context.Context, contentID string) (*Co
 	pipeline := mongo.Pipeline{
 		{{Key: "$match", Value: bson.M{"content_id": contentID}}},
 		{{Key: "$group", Value: bson.M{
-			"_id":      "$content_id",
-			"count":    bson.M{"$sum": 1},
-			"page_ids": bson.M{"$addToSet": "$page_id"},
-			"site_ids": bson.M{"$addToSet": "$site_id"},
+			"_id":         "$content_id",
+			"count":       bson.M{"$sum": 1},
+			"page_ids":    bson.M{"$addToSet": "$page_id"},
+			"site_ids":    bson.M{"$addToSet": "$site_id"},
+			"match_types": bson.M{"$push": "$match_type"},
 		}}},
 	}
 
@@ -174,10 +413,11 @@ func (r *Repository) GetContentStats(ctx context.Context, contentID string) (*Co
 	defer cursor.Close(ctx)
 
 	var results []struct {
-		ID      string   `bson:"_id"`
-		Count   int64    `bson:"count"`
-		PageIDs []string `bson:"page_ids"`
-		SiteIDs []string `bson:"site_ids"`
+		ID         string      `bson:"_id"`
+		Count      int64       `bson:"count"`
+		PageIDs    []string    `bson:"page_ids"`
+		SiteIDs    []string    `bson:"site_ids"`
+		MatchTypes []MatchType `bson:"match_types"`
 	}
 
 	if err := cursor.All(ctx, &results); err != nil {
@@ -199,9 +439,209 @@ func (r *Repository) GetContentStats(ctx context.Context, contentID string) (*Co
 		SitesCount:      int64(len(r0.SiteIDs)),
 		PageIDs:         r0.PageIDs,
 		SiteIDs:         r0.SiteIDs,
+		MatchTypeCounts: countMatchTypes(r0.MatchTypes),
 	}, nil
 }
 
+func (r *Repository) GetSeasonStats(ctx context.Context, contentID string) ([]SeasonStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"content_id": contentID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"$ifNull": bson.A{"$season", 0}},
+			"count":    bson.M{"$sum": 1},
+			"episodes": bson.M{"$addToSet": bson.M{"$ifNull": bson.A{"$episodes", bson.A{}}}},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Season   int     `bson:"_id"`
+		Count    int64   `bson:"count"`
+		Episodes [][]int `bson:"episodes"`
+	}
+
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	stats := make([]SeasonStats, len(results))
+	for i, res := range results {
+		stats[i] = SeasonStats{
+			Season:          res.Season,
+			ViolationsCount: res.Count,
+			Episodes:        mergeEpisodes(res.Episodes),
+		}
+	}
+	return stats, nil
+}
+
+func mergeEpisodes(groups [][]int) []int {
+	seen := make(map[int]bool)
+	var episodes []int
+	for _, group := range groups {
+		for _, e := range group {
+			if !seen[e] {
+				seen[e] = true
+				episodes = append(episodes, e)
+			}
+		}
+	}
+	sort.Ints(episodes)
+	return episodes
+}
+
+type siteViolationCount struct {
+	SiteID  string `bson:"_id"`
+	Domain  string `bson:"domain"`
+	PageURL string `bson:"page_url"`
+	Count   int64  `bson:"count"`
+}
+
+// GetDomainStats группирует нарушения контента по сайтам и подтягивает домены из sites
+func (r *Repository) GetDomainStats(ctx context.Context, contentID string) ([]DomainStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"content_id": contentID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$site_id",
+			"count":    bson.M{"$sum": 1},
+			"page_url": bson.M{"$first": "$page_url"},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "sites",
+			"let": bson.M{"site_oid": bson.M{"$convert": bson.M{
+				"input":   "$_id",
+				"to":      "objectId",
+				"onError": nil,
+				"onNull":  nil,
+			}}},
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", "$$site_oid"}}}}},
+				{{Key: "$project", Value: bson.M{"domain": 1}}},
+			},
+			"as": "site",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"count":    1,
+			"page_url": 1,
+			"domain":   bson.M{"$arrayElemAt": bson.A{"$site.domain", 0}},
+		}}},
+	}
+
+	cursor, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []siteViolationCount
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	return rollupByDomain(rows), nil
+}
+
+// rollupByDomain объединяет сайты с одинаковым доменом (например, www и без www).
+// Если сайт уже удалён, домен берётся из URL страницы
+func rollupByDomain(rows []siteViolationCount) []DomainStats {
+	index := make(map[string]int)
+	var stats []DomainStats
+
+	for _, row := range rows {
+		domain := row.Domain
+		if domain == "" {
+			if u, err := url.Parse(row.PageURL); err == nil {
+				domain = u.Hostname()
+			}
+		}
+		domain = strings.TrimPrefix(strings.ToLower(domain), "www.")
+		if domain == "" {
+			domain = row.SiteID
+		}
+
+		i, ok := index[domain]
+		if !ok {
+			i = len(stats)
+			index[domain] = i
+			stats = append(stats, DomainStats{Domain: domain})
+		}
+		stats[i].ViolationsCount += row.Count
+		stats[i].SiteIDs = append(stats[i].SiteIDs, row.SiteID)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].ViolationsCount != stats[j].ViolationsCount {
+			return stats[i].ViolationsCount > stats[j].ViolationsCount
+		}
+		return stats[i].Domain < stats[j].Domain
+	})
+	for i := range stats {
+		sort.Strings(stats[i].SiteIDs)
+	}
+	return stats
+}
+
+// GetTopSites возвращает сайты с наибольшим числом нарушений.
+// contentIDs == nil — по всему контенту, пустой срез — ни по какому
+func (r *Repository) GetTopSites(ctx context.Context, contentIDs []string, limit int64) ([]TopSite, error) {
+	cursor, err := r.coll.Aggregate(ctx, topSitesPipeline(contentIDs, limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sites []TopSite
+	if err := cursor.All(ctx, &sites); err != nil {
+		return nil, err
+	}
+	return sites, nil
+}
+
+func topSitesPipeline(contentIDs []string, limit int64) mongo.Pipeline {
+	var pipeline mongo.Pipeline
+	if contentIDs != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"content_id": bson.M{"$in": contentIDs}}}})
+	}
+
+	return append(pipeline,
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":              "$site_id",
+			"violations_count": bson.M{"$sum": 1},
+			"content_ids":      bson.M{"$addToSet": "$content_id"},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "violations_count", Value: -1},
+			{Key: "_id", Value: 1},
+		}}},
+		bson.D{{Key: "$limit", Value: limit}},
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from": "sites",
+			"let": bson.M{"site_oid": bson.M{"$convert": bson.M{
+				"input":   "$_id",
+				"to":      "objectId",
+				"onError": nil,
+				"onNull":  nil,
+			}}},
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", "$$site_oid"}}}}},
+				{{Key: "$project", Value: bson.M{"domain": 1}}},
+			},
+			"as": "site",
+		}}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"violations_count": 1,
+			"contents_count":   bson.M{"$size": "$content_ids"},
+			"domain":           bson.M{"$arrayElemAt": bson.A{"$site.domain", 0}},
+		}}},
+	)
+}
+
 func (r *Repository) GetSiteStats(ctx context.Context, siteID string) (*SiteStats, error) {
 	pipeline := mongo.Pipeline{
 		{{Key: "$match", Value: bson.M{"site_id": siteID}}},
@@ -249,14 +689,7 @@ func (r *Repository) GetSiteStats(ctx context.Context, siteID string) (*SiteStat
 }
 
 func (r *Repository) GetAllContentStats(ctx context.Context) (map[string]*ContentStats, error) {
-	pipeline := mongo.Pipeline{
-		{{Key: "$group", Value: bson.M{
-			"_id":      "$content_id",
-			"count":    bson.M{"$sum": 1},
-			"page_ids": bson.M{"$addToSet": "$page_id"},
-			"site_ids": bson.M{"$addToSet": "$site_id"},
-		}}},
-	}
+	pipeline := allContentStatsPipeline()
 
 	cursor, err := r.coll.Aggregate(ctx, pipeline)
 	if err != nil {
@@ -265,10 +698,14 @@ func (r *Repository) GetAllContentStats(ctx context.Context) (map[string]*Conten
 	defer cursor.Close(ctx)
 
 	var results []struct {
-		ID      string   `bson:"_id"`
-		Count   int64    `bson:"count"`
-		PageIDs []string `bson:"page_ids"`
-		SiteIDs []string `bson:"site_ids"`
+		ID         string   `bson:"_id"`
+		Count      int64    `bson:"count"`
+		PageIDs    []string `bson:"page_ids"`
+		SiteIDs    []string `bson:"site_ids"`
+		MatchTypes []struct {
+			Type  MatchType `bson:"type"`
+			Count int64     `bson:"count"`
+		} `bson:"match_types"`
 	}
 
 	if err := cursor.All(ctx, &results); err != nil {
@@ -277,18 +714,56 @@ func (r *Repository) GetAllContentStats(ctx context.Context) (map[string]*Conten
 
 	statsMap := make(map[string]*ContentStats, len(results))
 	for _, r := range results {
+		counts := make(map[MatchType]int64, len(r.MatchTypes))
+		for _, mt := range r.MatchTypes {
+			counts[mt.Type] += mt.Count
+		}
 		statsMap[r.ID] = &ContentStats{
 			ContentID:       r.ID,
 			ViolationsCount: r.Count,
 			SitesCount:      int64(len(r.SiteIDs)),
 			PageIDs:         r.PageIDs,
 			SiteIDs:         r.SiteIDs,
+			MatchTypeCounts: counts,
 		}
 	}
 
 	return statsMap, nil
 }
 
+// allContentStatsPipeline сначала считает нарушения по паре (контент, тип
+// совпадения), чтобы не собирать в группу match_type каждого нарушения
+func allContentStatsPipeline() mongo.Pipeline {
+	unionSets := func(field string) bson.M {
+		return bson.M{"$reduce": bson.M{
+			"input":        field,
+			"initialValue": bson.A{},
+			"in":           bson.M{"$setUnion": bson.A{"$$value", "$$this"}},
+		}}
+	}
+	return mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"content_id": "$content_id", "match_type": "$match_type"},
+			"count":    bson.M{"$sum": 1},
+			"page_ids": bson.M{"$addToSet": "$page_id"},
+			"site_ids": bson.M{"$addToSet": "$site_id"},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$_id.content_id",
+			"count":       bson.M{"$sum": "$count"},
+			"page_ids":    bson.M{"$push": "$page_ids"},
+			"site_ids":    bson.M{"$push": "$site_ids"},
+			"match_types": bson.M{"$push": bson.M{"type": "$_id.match_type", "count": "$count"}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"count":       1,
+			"match_types": 1,
+			"page_ids":    unionSets("$page_ids"),
+			"site_ids":    unionSets("$site_ids"),
+		}}},
+	}
+}
+
 func (r *Repository) GetAllSiteStats(ctx context.Context) (map[string]*SiteStats, error) {
 	pipeline := mongo.Pipeline{
 		{{Key: "$group", Value: bson.M{
@@ -330,6 +805,125 @@ func (r *Repository) GetAllSiteStats(ctx context.Context) (map[string]*SiteStats
 	return statsMap, nil
 }
 
+// CountByMatchType считает нарушения по этапам матчера; since/until ограничивают found_at
+func (r *Repository) CountByMatchType(ctx context.Context, since, until *time.Time) (map[MatchType]int64, error) {
+	cursor, err := r.coll.Aggregate(ctx, matchTypeCountsPipeline(since, until))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		MatchType MatchType `bson:"_id"`
+		Count     int64     `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[MatchType]int64, len(rows))
+	for _, row := range rows {
+		counts[row.MatchType] = row.Count
+	}
+	return counts, nil
+}
+
+func matchTypeCountsPipeline(since, until *time.Time) mongo.Pipeline {
+	var pipeline mongo.Pipeline
+
+	foundAt := bson.M{}
+	if since != nil {
+		foundAt["$gte"] = *since
+	}
+	if until != nil {
+		foundAt["$lt"] = *until
+	}
+	if len(foundAt) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"found_at": foundAt}}})
+	}
+
+	return append(pipeline, bson.D{{Key: "$group", Value: bson.M{
+		"_id":   "$match_type",
+		"count": bson.M{"$sum": 1},
+	}}})
+}
+
+// FindUnmatchedPages возвращает страницы сайта с внешними ID, по которым нет ни одного нарушения
+func (r *Repository) FindUnmatchedPages(ctx context.Context, siteID string, limit, offset int64) ([]UnmatchedPage, int64, error) {
+	pages := r.coll.Database().Collection(pagesCollectionName)
+
+	cursor, err := pages.Aggregate(ctx, unmatchedPagesPipeline(siteID, limit, offset))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Items []UnmatchedPage `bson:"items"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, 0, err
+	}
+	if len(result) == 0 {
+		return nil, 0, nil
+	}
+
+	var total int64
+	if len(result[0].Total) > 0 {
+		total = result[0].Total[0].Count
+	}
+	return result[0].Items, total, nil
+}
+
+// unmatchedPagesPipeline — anti-join pages → violations по page_id (hex _id страницы)
+func unmatchedPagesPipeline(siteID string, limit, offset int64) mongo.Pipeline {
+	hasExternalID := make(bson.A, len(externalIDFields))
+	for i, field := range externalIDFields {
+		hasExternalID[i] = bson.M{field: bson.M{"$nin": bson.A{nil, ""}}}
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"site_id": siteID, "$or": hasExternalID}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": collectionName,
+			"let":  bson.M{"page_id": bson.M{"$toString": "$_id"}},
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"$expr": bson.M{"$eq": bson.A{"$page_id", "$$page_id"}}}}},
+				{{Key: "$limit", Value: 1}},
+				{{Key: "$project", Value: bson.M{"_id": 1}}},
+			},
+			"as": "violations",
+		}}},
+		{{Key: "$match", Value: bson.M{"violations": bson.M{"$size": 0}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "indexed_at", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$facet", Value: bson.M{
+			"items": mongo.Pipeline{
+				{{Key: "$skip", Value: offset}},
+				{{Key: "$limit", Value: limit}},
+				{{Key: "$project", Value: bson.M{
+					"_id":            0,
+					"page_id":        bson.M{"$toString": "$_id"},
+					"url":            1,
+					"title":          1,
+					"year":           1,
+					"kinopoisk_id":   "$external_ids.kinopoisk_id",
+					"imdb_id":        "$external_ids.imdb_id",
+					"mal_id":         "$external_ids.mal_id",
+					"shikimori_id":   "$external_ids.shikimori_id",
+					"mydramalist_id": "$external_ids.mydramalist_id",
+					"indexed_at":     1,
+				}}},
+			},
+			"total": mongo.Pipeline{
+				{{Key: "$count", Value: "count"}},
+			},
+		}}},
+	}
+}
+
 func (r *Repository) CountBySiteID(ctx context.Context, siteID string) (int64, error) {
 	return r.coll.CountDocuments(ctx, bson.M{"site_id": siteID})
 }
@@ -368,6 +962,93 @@ func (r *Repository) GetDistinctContentIDs(ctx context.Context, siteID string) (
 	return contentIDs, nil
 }
 
+// CountSharedContentBySite — сколько контента из contentIDs нарушает каждый сайт, кроме excludeSiteID
+func (r *Repository) CountSharedContentBySite(ctx context.Context, contentIDs []string, excludeSiteID string) (map[string]int64, error) {
+	result := make(map[string]int64)
+	if len(contentIDs) == 0 {
+		return result, nil
+	}
+
+	cursor, err := r.coll.Aggregate(ctx, sharedContentPipeline(contentIDs, excludeSiteID))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		SiteID string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.SiteID] = row.Count
+	}
+	return result, nil
+}
+
+func sharedContentPipeline(contentIDs []string, excludeSiteID string) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"content_id": bson.M{"$in": contentIDs},
+			"site_id":    bson.M{"$ne": excludeSiteID},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"site_id": "$site_id", "content_id": "$content_id"}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$_id.site_id", "count": bson.M{"$sum": 1}}}},
+	}
+}
+
+func (r *Repository) GetContentIDsByPageID(ctx context.Context, pageID string) ([]string, error) {
+	result, err := r.coll.Distinct(ctx, "content_id", bson.M{"page_id": pageID})
+	if err != nil {
+		return nil, err
+	}
+
+	contentIDs := make([]string, len(result))
+	for i, v := range result {
+		if s, ok := v.(string); ok {
+			contentIDs[i] = s
+		}
+	}
+	return contentIDs, nil
+}
+
+// GetContentIDsByPageIDs возвращает content_id нарушений, сгруппированные по page_id
+func (r *Repository) GetContentIDsByPageIDs(ctx context.Context, pageIDs []string) (map[string][]string, error) {
+	result := make(map[string][]string)
+	if len(pageIDs) == 0 {
+		return result, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"page_id": bson.M{"$in": pageIDs}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$page_id",
+			"content_ids": bson.M{"$addToSet": "$content_id"},
+		}}},
+	}
+
+	cursor, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		PageID     string   `bson:"_id"`
+		ContentIDs []string `bson:"content_ids"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.PageID] = row.ContentIDs
+	}
+	return result, nil
+}
+
 func (r *Repository) GetPageIDsBySiteID(ctx context.Context, siteID string) ([]string, error) {
 	result, err := r.coll.Distinct(ctx, "page_id", bson.M{"site_id": siteID})
 	if err != nil {
@@ -383,6 +1064,32 @@ func (r *Repository) GetPageIDsBySiteID(ctx context.Context, siteID string) ([]s
 	return pageIDs, nil
 }
 
+// ForEachByContentID обходит нарушения контента курсором, не загружая их в память.
+// siteIDs ограничивает обход сайтами; nil — все сайты
+func (r *Repository) ForEachByContentID(ctx context.Context, contentID string, siteIDs []string, fn func(v *Violation) error) error {
+	filter := bson.M{"content_id": contentID}
+	if siteIDs != nil {
+		filter["site_id"] = bson.M{"$in": siteIDs}
+	}
+
+	cursor, err := r.coll.Find(ctx, filter, options.Find().SetBatchSize(1000))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var v Violation
+		if err := cursor.Decode(&v); err != nil {
+			return err
+		}
+		if err := fn(&v); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
 func (r *Repository) FindAllByContentID(ctx context.Context, contentID string) ([]Violation, error) {
 	cursor, err := r.coll.Find(ctx, bson.M{"content_id": contentID})
 	if err != nil {
@@ -398,36 +1105,90 @@ func (r *Repository) FindAllByContentID(ctx context.Context, contentID string) (
 	return violations, nil
 }
 
+// DeleteNotInPageIDs удаляет violations контента, которых нет в validPageIDs; пустой список — все.
+// Удаление записывается в журнал снятых нарушений
 func (r *Repository) DeleteNotInPageIDs(ctx context.Context, contentID string, validPageIDs []string) error {
-	if len(validPageIDs) == 0 {
-		return r.DeleteByContentID(ctx, contentID)
+	return r.removeWithHistory(ctx, staleContentViolationsFilter(contentID, validPageIDs))
+}
+
+// DeleteByContentAndSiteNotInPageIDs удаляет violations для content+site, которых нет в validPageIDs
+func (r *Repository) DeleteByContentAndSiteNotInPageIDs(ctx context.Context, contentID, siteID string, validPageIDs []string) error {
+	return r.removeWithHistory(ctx, staleSiteViolationsFilter(contentID, siteID, validPageIDs))
+}
+
+// removeWithHistory удаляет нарушения, которые пересчёт больше не находит, сохраняя
+// в violation_removals, когда страница была найдена и когда пропала
+func (r *Repository) removeWithHistory(ctx context.Context, filter bson.M) error {
+	cursor, err := r.coll.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	var stale []Violation
+	if err := cursor.All(ctx, &stale); err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		return nil
 	}
 
-	filter := bson.M{
-		"content_id": contentID,
-		"page_id":    bson.M{"$nin": validPageIDs},
+	now := time.Now()
+	docs := make([]interface{}, len(stale))
+	ids := make([]primitive.ObjectID, len(stale))
+	for i, v := range stale {
+		docs[i] = newRemoval(v, now)
+		ids[i] = v.ID
 	}
-	_, err := r.coll.DeleteMany(ctx, filter)
+	if _, err := r.removals.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false)); err != nil {
+		return err
+	}
+
+	// Удаляем по _id: нарушение, добавленное после выборки, не должно пропасть без записи
+	_, err = r.coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
 	return err
 }
 
-// DeleteByContentAndSiteNotInPageIDs удаляет violations для content+site, которых нет в validPageIDs
-func (r *Repository) DeleteByContentAndSiteNotInPageIDs(ctx context.Context, contentID, siteID string, validPageIDs []string) error {
-	filter := bson.M{
-		"content_id": contentID,
-		"site_id":    siteID,
+// FindRemovalsByContentID возвращает журнал снятых нарушений контента по времени снятия
+func (r *Repository) FindRemovalsByContentID(ctx context.Context, contentID string) ([]Removal, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "removed_at", Value: 1}})
+	cursor, err := r.removals.Find(ctx, bson.M{"content_id": contentID}, opts)
+	if err != nil {
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	if len(validPageIDs) == 0 {
-		_, err := r.coll.DeleteMany(ctx, filter)
-		return err
+	var removals []Removal
+	if err := cursor.All(ctx, &removals); err != nil {
+		return nil, err
 	}
+	return removals, nil
+}
 
-	filter["page_id"] = bson.M{"$nin": validPageIDs}
-	_, err := r.coll.DeleteMany(ctx, filter)
+// DeleteRemovalsByContentID очищает журнал снятых нарушений удалённого контента
+func (r *Repository) DeleteRemovalsByContentID(ctx context.Context, contentID string) error {
+	_, err := r.removals.DeleteMany(ctx, bson.M{"content_id": contentID})
 	return err
 }
 
+// staleSiteViolationsFilter всегда ограничен site_id — нарушения на других сайтах не затрагиваются
+func staleSiteViolationsFilter(contentID, siteID string, validPageIDs []string) bson.M {
+	filter := staleContentViolationsFilter(contentID, validPageIDs)
+	filter["site_id"] = siteID
+	return filter
+}
+
+// staleContentViolationsFilter — нарушения контента, которых нет среди найденных пересчётом.
+// Ручные нарушения матчер не находит, поэтому они не считаются устаревшими
+func staleContentViolationsFilter(contentID string, validPageIDs []string) bson.M {
+	filter := bson.M{
+		"content_id": contentID,
+		"match_type": bson.M{"$ne": MatchManual},
+	}
+	if len(validPageIDs) > 0 {
+		filter["page_id"] = bson.M{"$nin": validPageIDs}
+	}
+	return filter
+}
+
 // DeleteBySiteID удаляет все violations для сайта
 func (r *Repository) DeleteBySiteID(ctx context.Context, siteID string) (int64, error) {
 	result, err := r.coll.DeleteMany(ctx, bson.M{"site_id": siteID})
@@ -0,0 +1,326 @@
+package violations
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestRollupByDomain(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []siteViolationCount
+		want []DomainStats
+	}{
+		{
+			name: "empty",
+			rows: nil,
+			want: nil,
+		},
+		{
+			name: "one site per domain sorted by count",
+			rows: []siteViolationCount{
+				{SiteID: "s1", Domain: "kinogo.media", Count: 3},
+				{SiteID: "s2", Domain: "lordfilm.org", Count: 40},
+				{SiteID: "s3", Domain: "hdrezka.ag", Count: 3},
+			},
+			want: []DomainStats{
+				{Domain: "lordfilm.org", SiteIDs: []string{"s2"}, ViolationsCount: 40},
+				{Domain: "hdrezka.ag", SiteIDs: []string{"s3"}, ViolationsCount: 3},
+				{Domain: "kinogo.media", SiteIDs: []string{"s1"}, ViolationsCount: 3},
+			},
+		},
+		{
+			name: "www and case variants are merged",
+			rows: []siteViolationCount{
+				{SiteID: "s2", Domain: "www.Kinogo.media", Count: 2},
+				{SiteID: "s1", Domain: "kinogo.media", Count: 5},
+			},
+			want: []DomainStats{
+				{Domain: "kinogo.media", SiteIDs: []string{"s1", "s2"}, ViolationsCount: 7},
+			},
+		},
+		{
+			name: "deleted site falls back to page url host",
+			rows: []siteViolationCount{
+				{SiteID: "s1", PageURL: "https://www.narko-tv.com/season-3/", Count: 4},
+				{SiteID: "s2", Domain: "narko-tv.com", Count: 1},
+			},
+			want: []DomainStats{
+				{Domain: "narko-tv.com", SiteIDs: []string{"s1", "s2"}, ViolationsCount: 5},
+			},
+		},
+		{
+			name: "no domain and no url uses site id",
+			rows: []siteViolationCount{
+				{SiteID: "orphan", Count: 1},
+			},
+			want: []DomainStats{
+				{Domain: "orphan", SiteIDs: []string{"orphan"}, ViolationsCount: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rollupByDomain(tt.rows)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rollupByDomain() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopSitesPipeline(t *testing.T) {
+	stageOps := func(p []bson.D) []string {
+		ops := make([]string, len(p))
+		for i, st := range p {
+			ops[i] = st[0].Key
+		}
+		return ops
+	}
+	ranking := []string{"$group", "$sort", "$limit", "$lookup", "$project"}
+
+	t.Run("admin sees all content", func(t *testing.T) {
+		p := topSitesPipeline(nil, 10)
+		if got := stageOps(p); !reflect.DeepEqual(got, ranking) {
+			t.Fatalf("stages = %v, want %v", got, ranking)
+		}
+	})
+
+	t.Run("user scoped to own content", func(t *testing.T) {
+		p := topSitesPipeline([]string{"c1", "c2"}, 10)
+		want := append([]string{"$match"}, ranking...)
+		if got := stageOps(p); !reflect.DeepEqual(got, want) {
+			t.Fatalf("stages = %v, want %v", got, want)
+		}
+		match := p[0][0].Value.(bson.M)
+		in := match["content_id"].(bson.M)["$in"]
+		if !reflect.DeepEqual(in, []string{"c1", "c2"}) {
+			t.Errorf("$in = %v, want [c1 c2]", in)
+		}
+	})
+
+	t.Run("user without content matches nothing", func(t *testing.T) {
+		p := topSitesPipeline([]string{}, 10)
+		if p[0][0].Key != "$match" {
+			t.Fatalf("first stage = %s, want $match", p[0][0].Key)
+		}
+	})
+
+	t.Run("ranked by count then site id before limit", func(t *testing.T) {
+		p := topSitesPipeline(nil, 5)
+		sort := p[1][0].Value.(bson.D)
+		want := bson.D{{Key: "violations_count", Value: -1}, {Key: "_id", Value: 1}}
+		if !reflect.DeepEqual(sort, want) {
+			t.Errorf("$sort = %v, want %v", sort, want)
+		}
+		if got := p[2][0].Value; got != int64(5) {
+			t.Errorf("$limit = %v, want 5", got)
+		}
+	})
+}
+
+func TestStaleSiteViolationsFilter(t *testing.T) {
+	tests := []struct {
+		name         string
+		validPageIDs []string
+		want         bson.M
+	}{
+		{
+			name: "no matches left on site",
+			want: bson.M{"content_id": "c1", "site_id": "s1", "match_type": bson.M{"$ne": MatchManual}},
+		},
+		{
+			name:         "keeps still matching pages",
+			validPageIDs: []string{"p1", "p2"},
+			want: bson.M{
+				"content_id": "c1",
+				"site_id":    "s1",
+				"match_type": bson.M{"$ne": MatchManual},
+				"page_id":    bson.M{"$nin": []string{"p1", "p2"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := staleSiteViolationsFilter("c1", "s1", tt.validPageIDs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("staleSiteViolationsFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmatchedPagesPipeline(t *testing.T) {
+	p := unmatchedPagesPipeline("s1", 20, 40)
+
+	stageOps := make([]string, len(p))
+	for i, st := range p {
+		stageOps[i] = st[0].Key
+	}
+	want := []string{"$match", "$lookup", "$match", "$sort", "$facet"}
+	if !reflect.DeepEqual(stageOps, want) {
+		t.Fatalf("stages = %v, want %v", stageOps, want)
+	}
+
+	t.Run("only site pages with external ids", func(t *testing.T) {
+		match := p[0][0].Value.(bson.M)
+		if match["site_id"] != "s1" {
+			t.Errorf("site_id = %v, want s1", match["site_id"])
+		}
+		or := match["$or"].(bson.A)
+		if len(or) != len(externalIDFields) {
+			t.Fatalf("$or has %d conditions, want %d", len(or), len(externalIDFields))
+		}
+		kp := or[0].(bson.M)["external_ids.kinopoisk_id"]
+		if !reflect.DeepEqual(kp, bson.M{"$nin": bson.A{nil, ""}}) {
+			t.Errorf("kinopoisk condition = %v, want non-empty", kp)
+		}
+	})
+
+	t.Run("joins violations by hex page id", func(t *testing.T) {
+		lookup := p[1][0].Value.(bson.M)
+		if lookup["from"] != collectionName {
+			t.Errorf("from = %v, want %s", lookup["from"], collectionName)
+		}
+		let := lookup["let"].(bson.M)
+		if !reflect.DeepEqual(let["page_id"], bson.M{"$toString": "$_id"}) {
+			t.Errorf("let page_id = %v, want $toString of _id", let["page_id"])
+		}
+		inner := lookup["pipeline"].(mongo.Pipeline)
+		eq := inner[0][0].Value.(bson.M)["$expr"].(bson.M)["$eq"]
+		if !reflect.DeepEqual(eq, bson.A{"$page_id", "$$page_id"}) {
+			t.Errorf("$eq = %v, want page_id join", eq)
+		}
+	})
+
+	t.Run("keeps pages without violations", func(t *testing.T) {
+		anti := p[2][0].Value.(bson.M)
+		want := bson.M{"violations": bson.M{"$size": 0}}
+		if !reflect.DeepEqual(anti, want) {
+			t.Errorf("anti-join $match = %v, want %v", anti, want)
+		}
+	})
+
+	t.Run("paginates after the anti-join", func(t *testing.T) {
+		items := p[4][0].Value.(bson.M)["items"].(mongo.Pipeline)
+		if got := items[0][0].Value; got != int64(40) {
+			t.Errorf("$skip = %v, want 40", got)
+		}
+		if got := items[1][0].Value; got != int64(20) {
+			t.Errorf("$limit = %v, want 20", got)
+		}
+	})
+}
+
+func TestSharedContentPipeline(t *testing.T) {
+	got := sharedContentPipeline([]string{"c1", "c2"}, "s1")
+
+	want := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"content_id": bson.M{"$in": []string{"c1", "c2"}},
+			"site_id":    bson.M{"$ne": "s1"},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"site_id": "$site_id", "content_id": "$content_id"}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$_id.site_id", "count": bson.M{"$sum": 1}}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sharedContentPipeline() = %v, want %v", got, want)
+	}
+}
+
+func TestAllContentStatsPipeline(t *testing.T) {
+	p := allContentStatsPipeline()
+	if len(p) != 3 {
+		t.Fatalf("pipeline = %v, want $group, $group, $project", p)
+	}
+
+	first := p[0][0].Value.(bson.M)
+	wantID := bson.M{"content_id": "$content_id", "match_type": "$match_type"}
+	if !reflect.DeepEqual(first["_id"], wantID) {
+		t.Errorf("first $group _id = %v, want %v", first["_id"], wantID)
+	}
+	if !reflect.DeepEqual(first["count"], bson.M{"$sum": 1}) {
+		t.Errorf("first $group count = %v, want $sum 1", first["count"])
+	}
+	if _, ok := first["match_types"]; ok {
+		t.Error("first $group must not collect match_types per violation")
+	}
+
+	second := p[1][0].Value.(bson.M)
+	if second["_id"] != "$_id.content_id" {
+		t.Errorf("second $group _id = %v, want $_id.content_id", second["_id"])
+	}
+	if !reflect.DeepEqual(second["count"], bson.M{"$sum": "$count"}) {
+		t.Errorf("second $group count = %v, want $sum $count", second["count"])
+	}
+}
+
+func TestMatchTypeCountsPipeline(t *testing.T) {
+	if p := matchTypeCountsPipeline(nil, nil); len(p) != 1 || p[0][0].Key != "$group" {
+		t.Fatalf("without window pipeline = %v, want single $group", p)
+	}
+
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.AddDate(0, 1, 0)
+	p := matchTypeCountsPipeline(&since, &until)
+	if len(p) != 2 || p[0][0].Key != "$match" {
+		t.Fatalf("with window pipeline = %v, want $match then $group", p)
+	}
+	want := bson.M{"found_at": bson.M{"$gte": since, "$lt": until}}
+	if got := p[0][0].Value; !reflect.DeepEqual(got, want) {
+		t.Errorf("$match = %v, want %v", got, want)
+	}
+}
+
+func TestBuildMatchTypeHistogram(t *testing.T) {
+	h := buildMatchTypeHistogram(map[MatchType]int64{
+		MatchByKinopoisk:      6,
+		MatchByTitleFuzzyYear: 3,
+		MatchType("legacy"):   1,
+	})
+
+	if h.Total != 10 {
+		t.Errorf("Total = %d, want 10", h.Total)
+	}
+	if len(h.Items) != len(AllMatchStages)+1 {
+		t.Fatalf("items = %d, want every stage plus legacy type", len(h.Items))
+	}
+	if h.Items[0].MatchType != MatchByKinopoisk || h.Items[0].Count != 6 || h.Items[0].Percent != 60 {
+		t.Errorf("first item = %+v", h.Items[0])
+	}
+	if last := h.Items[len(h.Items)-1]; last.MatchType != "legacy" || last.Percent != 10 {
+		t.Errorf("legacy item = %+v", last)
+	}
+	for _, item := range h.Items {
+		if item.MatchType == MatchByIMDB && (item.Count != 0 || item.Percent != 0) {
+			t.Errorf("empty stage = %+v", item)
+		}
+	}
+
+	empty := buildMatchTypeHistogram(nil)
+	if empty.Total != 0 || len(empty.Items) != len(AllMatchStages) || empty.Items[0].Percent != 0 {
+		t.Errorf("empty histogram = %+v", empty)
+	}
+}
+
+func TestBuildMatchTypeHistogramRoundsPercent(t *testing.T) {
+	h := buildMatchTypeHistogram(map[MatchType]int64{MatchByTitle: 1, MatchByIMDB: 2})
+	for _, item := range h.Items {
+		switch item.MatchType {
+		case MatchByTitle:
+			if item.Percent != 33.33 {
+				t.Errorf("title percent = %v, want 33.33", item.Percent)
+			}
+		case MatchByIMDB:
+			if item.Percent != 66.67 {
+				t.Errorf("imdb percent = %v, want 66.67", item.Percent)
+			}
+		}
+	}
+}
@@ -2,29 +2,39 @@ package violations
 
 import (
 	"context"
+	"math"
+	"sort"
+	"time"
 
 	"github.com/video-analitics/backend/pkg/meili"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ContentCountUpdater должен быть безопасен для конкурентных вызовов — RefreshAllWithOptions
+// обновляет счётчики из нескольких воркеров
 type ContentCountUpdater interface {
-	UpdateViolationsCount(ctx context.Context, id string, violationsCount, sitesCount int64) error
+	UpdateViolationsCount(ctx context.Context, id string, violationsCount, sitesCount int64, matchTypeCounts map[MatchType]int64) error
 }
 
 type Service struct {
 	repo           *Repository
+	matcher        *Matcher
 	calculator     *Calculator
 	contentUpdater ContentCountUpdater
+	// negativeCacheSize — потолок кэша пустых запросов одного пересчёта
+	negativeCacheSize int
 }
 
-func NewService(db *mongo.Database, meiliClient *meili.Client) *Service {
+func NewService(db *mongo.Database, meiliClient *meili.Client, stages StageConfig) *Service {
 	repo := NewRepository(db)
-	matcher := NewMatcher(meiliClient)
+	matcher := NewMatcher(meiliClient, stages)
 	calculator := NewCalculator(repo, matcher)
 
 	return &Service{
-		repo:       repo,
-		calculator: calculator,
+		repo:              repo,
+		matcher:           matcher,
+		calculator:        calculator,
+		negativeCacheSize: DefaultNegativeCacheSize,
 	}
 }
 
@@ -32,39 +42,92 @@ func (s *Service) SetContentUpdater(updater ContentCountUpdater) {
 	s.contentUpdater = updater
 }
 
-func (s *Service) RefreshForContent(ctx context.Context, content ContentInfo) (*ContentStats, error) {
-	stats, err := s.calculator.CalculateForContent(ctx, content)
+// SetMaxSearchHits ограничивает число совпадений, выбираемых одним поисковым запросом
+func (s *Service) SetMaxSearchHits(n int64) {
+	s.matcher.SetMaxHits(n)
+}
+
+// SetSearchTimeout задаёт таймаут одного запроса матчера к Meilisearch; 0 — таймаут по умолчанию
+func (s *Service) SetSearchTimeout(d time.Duration) {
+	s.matcher.SetSearchTimeout(d)
+}
+
+// SetYearTolerance разрешает совпадение по названию и году при расхождении года на n лет
+func (s *Service) SetYearTolerance(n int) {
+	s.matcher.SetYearTolerance(n)
+}
+
+// SetMinFuzzyHits задаёт, сколько нечётких совпадений на одном сайте нужно, чтобы записать их нарушениями
+func (s *Service) SetMinFuzzyHits(n int) {
+	s.matcher.SetMinFuzzyHits(n)
+}
+
+// SetSingleWordAllowlist задаёт однословные названия, которые ищутся и без года
+func (s *Service) SetSingleWordAllowlist(titles []string) {
+	s.matcher.SetSingleWordAllowlist(titles)
+}
+
+// SetNegativeCacheSize задаёт потолок кэша пустых запросов для RefreshAll/RefreshForSite
+func (s *Service) SetNegativeCacheSize(n int) {
+	if n > 0 {
+		s.negativeCacheSize = n
+	}
+}
+
+// updateContentCounts переносит посчитанную статистику в кэш-поля контента
+func (s *Service) updateContentCounts(ctx context.Context, stats *ContentStats) {
+	if s.contentUpdater == nil || stats == nil {
+		return
+	}
+	s.contentUpdater.UpdateViolationsCount(ctx, stats.ContentID, stats.ViolationsCount, stats.SitesCount, stats.MatchTypeCounts)
+}
+
+// RefreshForContent пересчитывает нарушения контента. maxPerStage переопределяет потолок выдачи
+// одного поискового запроса (выше — для очень популярных тайтлов), 0 — SetMaxSearchHits
+func (s *Service) RefreshForContent(ctx context.Context, content ContentInfo, maxPerStage int64) (*ContentStats, error) {
+	stats, err := s.calculator.CalculateForContent(ctx, content, maxPerStage)
 	if err != nil {
 		return nil, err
 	}
 
-	if s.contentUpdater != nil && stats != nil {
-		s.contentUpdater.UpdateViolationsCount(ctx, content.ID, stats.ViolationsCount, stats.SitesCount)
-	}
+	s.updateContentCounts(ctx, stats)
 
 	return stats, nil
 }
 
-func (s *Service) RefreshAll(ctx context.Context, contents []ContentInfo) (int64, error) {
-	updated, err := s.calculator.CalculateForAllContent(ctx, contents)
-	if err != nil {
-		return updated, err
+// PreviewForContent возвращает нарушения, которые дал бы пересчёт, не трогая violations и счётчики контента
+func (s *Service) PreviewForContent(ctx context.Context, content ContentInfo) ([]Violation, error) {
+	return s.calculator.PreviewForContent(ctx, content)
+}
+
+// RefreshForContentOnSite пересчитывает violations контента только на одном сайте
+// (например, после обхода этого сайта) и обновляет общие счётчики контента
+func (s *Service) RefreshForContentOnSite(ctx context.Context, content ContentInfo, siteID string) (*ContentStats, error) {
+	if siteID == "" {
+		return s.RefreshForContent(ctx, content, 0)
 	}
 
-	if s.contentUpdater != nil {
-		for _, content := range contents {
-			stats, _ := s.repo.GetContentStats(ctx, content.ID)
-			if stats != nil {
-				s.contentUpdater.UpdateViolationsCount(ctx, content.ID, stats.ViolationsCount, stats.SitesCount)
-			}
-		}
+	if _, err := s.calculator.CalculateForContentOnSite(ctx, content, siteID); err != nil {
+		return nil, err
 	}
 
-	return updated, nil
+	stats, err := s.repo.GetContentStats(ctx, content.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.updateContentCounts(ctx, stats)
+
+	return stats, nil
+}
+
+func (s *Service) RefreshAll(ctx context.Context, contents []ContentInfo) (int64, error) {
+	return s.RefreshAllWithOptions(ctx, contents, RefreshOptions{})
 }
 
 // RefreshForSite обновляет violations только для страниц конкретного сайта
 func (s *Service) RefreshForSite(ctx context.Context, siteID string, contents []ContentInfo) (int64, error) {
+	ctx = withNegativeCache(ctx, newNegativeCache(s.negativeCacheSize))
 	updated, err := s.calculator.CalculateForSite(ctx, siteID, contents)
 	if err != nil {
 		return updated, err
@@ -73,15 +136,61 @@ func (s *Service) RefreshForSite(ctx context.Context, siteID string, contents []
 	if s.contentUpdater != nil {
 		for _, content := range contents {
 			stats, _ := s.repo.GetContentStats(ctx, content.ID)
-			if stats != nil {
-				s.contentUpdater.UpdateViolationsCount(ctx, content.ID, stats.ViolationsCount, stats.SitesCount)
-			}
+			s.updateContentCounts(ctx, stats)
 		}
 	}
 
 	return updated, nil
 }
 
+// ExplainMatch объясняет по этапам, почему страница совпала или не совпала с контентом
+func (s *Service) ExplainMatch(content ContentInfo, page meili.PageDocument) []StageVerdict {
+	return s.matcher.Explain(content, page)
+}
+
+func (s *Service) GetByContentAndPage(ctx context.Context, contentID, pageID string) (*Violation, error) {
+	return s.repo.FindByContentAndPage(ctx, contentID, pageID)
+}
+
+// RecordManual сохраняет нарушение, найденное пользователем, с типом manual и обновляет счётчики контента.
+// Уже записанное нарушение страницы не меняется; created=false — оно было раньше
+func (s *Service) RecordManual(ctx context.Context, v Violation) (stored *Violation, created bool, err error) {
+	existing, err := s.repo.FindByContentAndPage(ctx, v.ContentID, v.PageID)
+	if err != nil || existing != nil {
+		return existing, false, err
+	}
+
+	v.MatchType = MatchManual
+	v.FoundAt = time.Now()
+	if err := s.repo.Upsert(ctx, &v); err != nil {
+		return nil, false, err
+	}
+
+	stats, err := s.repo.GetContentStats(ctx, v.ContentID)
+	if err != nil {
+		return nil, false, err
+	}
+	s.updateContentCounts(ctx, stats)
+
+	stored, err = s.repo.FindByContentAndPage(ctx, v.ContentID, v.PageID)
+	return stored, true, err
+}
+
+// Resolve снимает нарушение, страница которого больше не содержит контент (проверено вручную),
+// и обновляет счётчики контента; снятие попадает в хронологию как обычное
+func (s *Service) Resolve(ctx context.Context, contentID, pageID string) error {
+	if err := s.repo.Resolve(ctx, contentID, pageID); err != nil {
+		return err
+	}
+
+	stats, err := s.repo.GetContentStats(ctx, contentID)
+	if err != nil {
+		return err
+	}
+	s.updateContentCounts(ctx, stats)
+	return nil
+}
+
 func (s *Service) GetByContentID(ctx context.Context, contentID string, limit, offset int64) ([]Violation, int64, error) {
 	return s.repo.FindByContentID(ctx, contentID, limit, offset)
 }
@@ -94,10 +203,69 @@ func (s *Service) GetAllByContentID(ctx context.Context, contentID string) ([]Vi
 	return s.repo.FindAllByContentID(ctx, contentID)
 }
 
+// ForEachByContentID обходит нарушения контента потоково (для больших выгрузок); siteIDs == nil — все сайты
+func (s *Service) ForEachByContentID(ctx context.Context, contentID string, siteIDs []string, fn func(v *Violation) error) error {
+	return s.repo.ForEachByContentID(ctx, contentID, siteIDs, fn)
+}
+
 func (s *Service) GetContentStats(ctx context.Context, contentID string) (*ContentStats, error) {
 	return s.repo.GetContentStats(ctx, contentID)
 }
 
+func (s *Service) GetSeasonStats(ctx context.Context, contentID string) ([]SeasonStats, error) {
+	return s.repo.GetSeasonStats(ctx, contentID)
+}
+
+func (s *Service) GetDomainStats(ctx context.Context, contentID string) ([]DomainStats, error) {
+	return s.repo.GetDomainStats(ctx, contentID)
+}
+
+func (s *Service) GetTopSites(ctx context.Context, contentIDs []string, limit int64) ([]TopSite, error) {
+	return s.repo.GetTopSites(ctx, contentIDs, limit)
+}
+
+// GetMatchTypeHistogram возвращает распределение нарушений по этапам матчера за окно found_at
+func (s *Service) GetMatchTypeHistogram(ctx context.Context, since, until *time.Time) (*MatchTypeHistogram, error) {
+	counts, err := s.repo.CountByMatchType(ctx, since, until)
+	if err != nil {
+		return nil, err
+	}
+	return buildMatchTypeHistogram(counts), nil
+}
+
+// buildMatchTypeHistogram перечисляет все этапы в порядке приоритета, включая пустые;
+// типы из старых данных, которых уже нет среди этапов, идут в конце
+func buildMatchTypeHistogram(counts map[MatchType]int64) *MatchTypeHistogram {
+	var total int64
+	for _, n := range counts {
+		total += n
+	}
+
+	order := append([]MatchType(nil), AllMatchStages...)
+	var legacy []MatchType
+	for mt := range counts {
+		if !isMatchStage(mt) {
+			legacy = append(legacy, mt)
+		}
+	}
+	sort.Slice(legacy, func(i, j int) bool { return legacy[i] < legacy[j] })
+	order = append(order, legacy...)
+
+	items := make([]MatchTypeStat, len(order))
+	for i, mt := range order {
+		items[i] = MatchTypeStat{MatchType: mt, Count: counts[mt]}
+		if total > 0 {
+			items[i].Percent = math.Round(float64(counts[mt])*10000/float64(total)) / 100
+		}
+	}
+	return &MatchTypeHistogram{Total: total, Items: items}
+}
+
+// GetUnmatchedPages возвращает страницы сайта с внешними ID, не совпавшие ни с одним контентом
+func (s *Service) GetUnmatchedPages(ctx context.Context, siteID string, limit, offset int64) ([]UnmatchedPage, int64, error) {
+	return s.repo.FindUnmatchedPages(ctx, siteID, limit, offset)
+}
+
 func (s *Service) GetSiteStats(ctx context.Context, siteID string) (*SiteStats, error) {
 	return s.repo.GetSiteStats(ctx, siteID)
 }
@@ -118,6 +286,74 @@ func (s *Service) DeleteByContentID(ctx context.Context, contentID string) error
 	return s.repo.DeleteByContentID(ctx, contentID)
 }
 
+// DeleteRemovalsByContentID очищает журнал снятых нарушений окончательно удалённого контента
+func (s *Service) DeleteRemovalsByContentID(ctx context.Context, contentID string) error {
+	return s.repo.DeleteRemovalsByContentID(ctx, contentID)
+}
+
+// GetTimeline — история нарушений контента: действующие и снятые пересчётом, по времени появления
+func (s *Service) GetTimeline(ctx context.Context, contentID string) ([]TimelineEntry, error) {
+	active, err := s.repo.FindAllByContentID(ctx, contentID)
+	if err != nil {
+		return nil, err
+	}
+	removals, err := s.repo.FindRemovalsByContentID(ctx, contentID)
+	if err != nil {
+		return nil, err
+	}
+	return buildTimeline(active, removals), nil
+}
+
+// DeleteSuppressionsByContentID убирает отметки ложных срабатываний окончательно удалённого контента
+func (s *Service) DeleteSuppressionsByContentID(ctx context.Context, contentID string) error {
+	return s.repo.DeleteSuppressionsByContentID(ctx, contentID)
+}
+
+// ListSuppressions возвращает страницы, отмеченные ложными срабатываниями для контента
+func (s *Service) ListSuppressions(ctx context.Context, contentID string, limit, offset int64) ([]Suppression, int64, error) {
+	return s.repo.FindSuppressions(ctx, contentID, limit, offset)
+}
+
+// RemoveSuppression снимает подавление; страница вернётся в нарушения при следующем пересчёте
+func (s *Service) RemoveSuppression(ctx context.Context, contentID, suppressionID string) (bool, error) {
+	return s.repo.DeleteSuppression(ctx, contentID, suppressionID)
+}
+
+// ReviewResult — итог ручной отметки нарушений
+type ReviewResult struct {
+	Updated int64 `json:"updated"`
+	// Unsuppressed — сколько страниц перестали быть ложными срабатываниями;
+	// их нарушения вернутся после пересчёта контента
+	Unsuppressed int64 `json:"unsuppressed"`
+}
+
+// Review отмечает нарушения контента на страницах pageIDs. Ложные срабатывания удаляются
+// и подавляются для будущих пересчётов, счётчики контента обновляются сразу
+func (s *Service) Review(ctx context.Context, contentID string, pageIDs []string, status ReviewStatus) (*ReviewResult, error) {
+	if status == ReviewFalsePositive {
+		deleted, err := s.repo.Suppress(ctx, contentID, pageIDs)
+		if err != nil {
+			return nil, err
+		}
+		stats, err := s.repo.GetContentStats(ctx, contentID)
+		if err != nil {
+			return nil, err
+		}
+		s.updateContentCounts(ctx, stats)
+		return &ReviewResult{Updated: deleted}, nil
+	}
+
+	unsuppressed, err := s.repo.Unsuppress(ctx, contentID, pageIDs)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.SetReviewStatus(ctx, contentID, pageIDs, status)
+	if err != nil {
+		return nil, err
+	}
+	return &ReviewResult{Updated: updated, Unsuppressed: unsuppressed}, nil
+}
+
 func (s *Service) CountBySiteID(ctx context.Context, siteID string) (int64, error) {
 	return s.repo.CountBySiteID(ctx, siteID)
 }
@@ -130,6 +366,22 @@ func (s *Service) GetPageIDsBySiteID(ctx context.Context, siteID string) ([]stri
 	return s.repo.GetPageIDsBySiteID(ctx, siteID)
 }
 
+func (s *Service) GetDistinctContentIDs(ctx context.Context, siteID string) ([]string, error) {
+	return s.repo.GetDistinctContentIDs(ctx, siteID)
+}
+
+func (s *Service) CountSharedContentBySite(ctx context.Context, contentIDs []string, excludeSiteID string) (map[string]int64, error) {
+	return s.repo.CountSharedContentBySite(ctx, contentIDs, excludeSiteID)
+}
+
+func (s *Service) GetContentIDsByPageID(ctx context.Context, pageID string) ([]string, error) {
+	return s.repo.GetContentIDsByPageID(ctx, pageID)
+}
+
+func (s *Service) GetContentIDsByPageIDs(ctx context.Context, pageIDs []string) (map[string][]string, error) {
+	return s.repo.GetContentIDsByPageIDs(ctx, pageIDs)
+}
+
 func (s *Service) DeleteBySiteID(ctx context.Context, siteID string) (int64, error) {
 	return s.repo.DeleteBySiteID(ctx, siteID)
 }
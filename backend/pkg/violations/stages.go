@@ -0,0 +1,87 @@
+package violations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AllMatchStages — этапы поиска совпадений в порядке приоритета
+var AllMatchStages = []MatchType{
+	MatchByKinopoisk,
+	MatchByIMDB,
+	MatchByMAL,
+	MatchByShikimori,
+	MatchByMyDramaList,
+	MatchByTitleYear,
+	MatchByTitle,
+	MatchByTitleFuzzyYear,
+}
+
+// titleOnlyStages — этапы, которые находят страницу только по названию, без внешнего ID и точного года
+var titleOnlyStages = map[MatchType]bool{
+	MatchByTitle:          true,
+	MatchByTitleFuzzyYear: true,
+}
+
+// StageConfig включает и выключает этапы матчера.
+// Нулевое значение — все этапы включены
+type StageConfig struct {
+	Disabled map[MatchType]bool
+	// RequireStrongSignal — строгая политика: засчитываются только совпадения по внешнему ID
+	// или по названию с годом, этапы только по названию не выполняются
+	RequireStrongSignal bool
+}
+
+// Enabled сообщает, выполняется ли этап
+func (c StageConfig) Enabled(stage MatchType) bool {
+	if c.RequireStrongSignal && titleOnlyStages[stage] {
+		return false
+	}
+	return !c.Disabled[stage]
+}
+
+type strongSignalCtxKey struct{}
+
+// WithRequireStrongSignal включает строгую политику для пересчётов с этим контекстом,
+// даже если она выключена в настройках деплоя
+func WithRequireStrongSignal(ctx context.Context) context.Context {
+	return context.WithValue(ctx, strongSignalCtxKey{}, true)
+}
+
+// forContext возвращает конфигурацию с учётом политики, переданной через контекст
+func (c StageConfig) forContext(ctx context.Context) StageConfig {
+	if strict, _ := ctx.Value(strongSignalCtxKey{}).(bool); strict {
+		c.RequireStrongSignal = true
+	}
+	return c
+}
+
+// ParseStageConfig разбирает список отключённых этапов через запятую, например "title,mal"
+func ParseStageConfig(disabled string) (StageConfig, error) {
+	var cfg StageConfig
+	for _, name := range strings.Split(disabled, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		stage := MatchType(name)
+		if !isMatchStage(stage) {
+			return StageConfig{}, fmt.Errorf("unknown match stage %q", name)
+		}
+		if cfg.Disabled == nil {
+			cfg.Disabled = make(map[MatchType]bool)
+		}
+		cfg.Disabled[stage] = true
+	}
+	return cfg, nil
+}
+
+func isMatchStage(stage MatchType) bool {
+	for _, s := range AllMatchStages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package violations
+
+import (
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Removal — нарушение, которое пересчёт перестал находить: страница исчезла,
+// сменила содержимое или больше не проходит матчер
+type Removal struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ContentID   string             `bson:"content_id" json:"content_id"`
+	SiteID      string             `bson:"site_id" json:"site_id"`
+	PageID      string             `bson:"page_id" json:"page_id"`
+	PageURL     string             `bson:"page_url" json:"page_url"`
+	PageTitle   string             `bson:"page_title" json:"page_title"`
+	MatchType   MatchType          `bson:"match_type" json:"match_type"`
+	FirstSeenAt time.Time          `bson:"first_seen_at" json:"first_seen_at"`
+	// LastSeenAt — последний пересчёт, который ещё находил страницу
+	LastSeenAt time.Time `bson:"last_seen_at" json:"last_seen_at"`
+	RemovedAt  time.Time `bson:"removed_at" json:"removed_at"`
+}
+
+func newRemoval(v Violation, now time.Time) Removal {
+	return Removal{
+		ContentID:   v.ContentID,
+		SiteID:      v.SiteID,
+		PageID:      v.PageID,
+		PageURL:     v.PageURL,
+		PageTitle:   v.PageTitle,
+		MatchType:   v.MatchType,
+		FirstSeenAt: firstSeen(v),
+		LastSeenAt:  v.FoundAt,
+		RemovedAt:   now,
+	}
+}
+
+// firstSeen — для нарушений без first_seen_at лучшая оценка — found_at
+func firstSeen(v Violation) time.Time {
+	if v.FirstSeenAt != nil {
+		return *v.FirstSeenAt
+	}
+	return v.FoundAt
+}
+
+// TimelineEntry — один период, когда страница нарушала права на контент.
+// Страница, пропавшая и найденная снова, даёт несколько периодов
+type TimelineEntry struct {
+	SiteID      string
+	PageID      string
+	PageURL     string
+	PageTitle   string
+	MatchType   MatchType
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+	// RemovedAt — nil, пока нарушение действует
+	RemovedAt *time.Time
+}
+
+// buildTimeline объединяет действующие и снятые нарушения по времени появления
+func buildTimeline(active []Violation, removals []Removal) []TimelineEntry {
+	entries := make([]TimelineEntry, 0, len(active)+len(removals))
+	for _, r := range removals {
+		removedAt := r.RemovedAt
+		entries = append(entries, TimelineEntry{
+			SiteID:      r.SiteID,
+			PageID:      r.PageID,
+			PageURL:     r.PageURL,
+			PageTitle:   r.PageTitle,
+			MatchType:   r.MatchType,
+			FirstSeenAt: r.FirstSeenAt,
+			LastSeenAt:  r.LastSeenAt,
+			RemovedAt:   &removedAt,
+		})
+	}
+	for _, v := range active {
+		entries = append(entries, TimelineEntry{
+			SiteID:      v.SiteID,
+			PageID:      v.PageID,
+			PageURL:     v.PageURL,
+			PageTitle:   v.PageTitle,
+			MatchType:   v.MatchType,
+			FirstSeenAt: firstSeen(v),
+			LastSeenAt:  v.FoundAt,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].FirstSeenAt.Equal(entries[j].FirstSeenAt) {
+			return entries[i].FirstSeenAt.Before(entries[j].FirstSeenAt)
+		}
+		return entries[i].PageURL < entries[j].PageURL
+	})
+	return entries
+}
@@ -0,0 +1,45 @@
+package violations
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC) }
+	firstSeen := day(2)
+
+	active := []Violation{
+		// найдена до появления first_seen_at — период начинается с found_at
+		{PageID: "p3", PageURL: "https://c.example/3", FoundAt: day(9)},
+		{PageID: "p1", PageURL: "https://a.example/1", FoundAt: day(9), FirstSeenAt: &firstSeen},
+	}
+	removals := []Removal{
+		{PageID: "p2", PageURL: "https://b.example/2", FirstSeenAt: day(1), LastSeenAt: day(4), RemovedAt: day(5)},
+		// та же страница пропадала раньше и нашлась снова
+		{PageID: "p1", PageURL: "https://a.example/1", FirstSeenAt: day(2), LastSeenAt: day(2), RemovedAt: day(3)},
+	}
+
+	got := buildTimeline(active, removals)
+
+	want := []struct {
+		url       string
+		firstSeen time.Time
+		removed   bool
+	}{
+		{"https://b.example/2", day(1), true},
+		{"https://a.example/1", day(2), true},
+		{"https://a.example/1", day(2), false},
+		{"https://c.example/3", day(9), false},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		e := got[i]
+		if e.PageURL != w.url || !e.FirstSeenAt.Equal(w.firstSeen) || (e.RemovedAt != nil) != w.removed {
+			t.Errorf("entry %d = %s first_seen=%v removed=%v, want %s first_seen=%v removed=%v",
+				i, e.PageURL, e.FirstSeenAt, e.RemovedAt != nil, w.url, w.firstSeen, w.removed)
+		}
+	}
+}
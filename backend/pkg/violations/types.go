@@ -17,6 +17,8 @@ const (
 	MatchByTitleYear      MatchType = "title_year"
 	MatchByTitle          MatchType = "title"
 	MatchByTitleFuzzyYear MatchType = "title_fuzzy_year"
+	// MatchManual — страница добавлена пользователем вручную; пересчёт такие нарушения не снимает
+	MatchManual MatchType = "manual"
 )
 
 type Violation struct {
@@ -27,19 +29,67 @@ type Violation struct {
 	PageURL   string             `bson:"page_url" json:"page_url"`
 	PageTitle string             `bson:"page_title" json:"page_title"`
 	MatchType MatchType          `bson:"match_type" json:"match_type"`
+	Season    int                `bson:"season,omitempty" json:"season,omitempty"`
+	Episodes  []int              `bson:"episodes,omitempty" json:"episodes,omitempty"`
 	FoundAt   time.Time          `bson:"found_at" json:"found_at"`
+	// FirstSeenAt — когда страница впервые найдена; found_at обновляется каждым пересчётом.
+	// У нарушений, найденных до появления поля, его нет
+	FirstSeenAt *time.Time `bson:"first_seen_at,omitempty" json:"first_seen_at,omitempty"`
+	// Evidence — чем именно совпала страница, например "kinopoisk_id=5019944"
+	Evidence string `bson:"evidence,omitempty" json:"evidence,omitempty"`
+	// MatchQuery/MatchFilter — запрос в Meilisearch, которым этап нашёл страницу; по ним решение можно воспроизвести
+	MatchQuery  string `bson:"match_query,omitempty" json:"match_query,omitempty"`
+	MatchFilter string `bson:"match_filter,omitempty" json:"match_filter,omitempty"`
+	// ReviewStatus — отметка аналитика; пересчёт её не сбрасывает
+	ReviewStatus ReviewStatus `bson:"review_status,omitempty" json:"review_status,omitempty"`
+	ReviewedAt   *time.Time   `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+}
+
+// ReviewStatus — результат ручной проверки нарушения
+type ReviewStatus string
+
+const (
+	ReviewNone          ReviewStatus = ""
+	ReviewReviewed      ReviewStatus = "reviewed"
+	ReviewFalsePositive ReviewStatus = "false_positive" // нарушение удаляется и больше не находится матчером
+)
+
+// ParseReviewStatus проверяет статус из запроса; пустая строка снимает отметку
+func ParseReviewStatus(s string) (ReviewStatus, bool) {
+	switch st := ReviewStatus(s); st {
+	case ReviewNone, ReviewReviewed, ReviewFalsePositive:
+		return st, true
+	}
+	return "", false
+}
+
+// Suppression — страница, отмеченная ложным срабатыванием для контента. Ключ — адрес
+// страницы, чтобы подавление пережило переиндексацию страницы под новым ID
+type Suppression struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ContentID string             `bson:"content_id" json:"content_id"`
+	PageURL   string             `bson:"page_url" json:"page_url"`
+	PageID    string             `bson:"page_id,omitempty" json:"page_id,omitempty"`
+	SiteID    string             `bson:"site_id,omitempty" json:"site_id,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
 }
 
 type ContentInfo struct {
 	ID            string
 	Title         string
 	OriginalTitle string
+	// Aliases — альтернативные/локализованные названия для этапов title и title_year
+	Aliases       []string
 	Year          int
 	KinopoiskID   string
 	IMDBID        string
 	MALID         string
 	ShikimoriID   string
 	MyDramaListID string
+	// Language — язык оригинала (ISO 639-1), Region — страна производства (ISO 3166-1);
+	// влияют на выбор этапов матчера
+	Language string
+	Region   string
 }
 
 type PageMatch struct {
@@ -49,6 +99,13 @@ type PageMatch struct {
 	URL       string
 	Title     string
 	MatchType MatchType
+	// Evidence — поле и значение, по которым этап нашёл страницу
+	Evidence string
+	// Query/Filter — что этап отправил в Meilisearch
+	Query     string
+	Filter    string
+	Season    int
+	Episodes  []int
 	IndexedAt time.Time
 }
 
@@ -58,6 +115,58 @@ type ContentStats struct {
 	SitesCount      int64    `json:"sites_count"`
 	PageIDs         []string `json:"page_ids,omitempty"`
 	SiteIDs         []string `json:"site_ids,omitempty"`
+	// MatchTypeCounts — сколько нарушений найдено каждым этапом матчера
+	MatchTypeCounts map[MatchType]int64 `json:"match_type_counts,omitempty"`
+}
+
+// SeasonStats - разбивка нарушений сериала по сезонам.
+// Season = 0 объединяет страницы без распознанного сезона.
+type SeasonStats struct {
+	Season          int   `json:"season"`
+	ViolationsCount int64 `json:"violations_count"`
+	Episodes        []int `json:"episodes,omitempty"`
+}
+
+// DomainStats - сколько страниц с контентом нашлось на домене
+type DomainStats struct {
+	Domain          string   `json:"domain"`
+	SiteIDs         []string `json:"site_ids"`
+	ViolationsCount int64    `json:"violations_count"`
+}
+
+// TopSite - строка рейтинга сайтов по числу нарушений
+type TopSite struct {
+	SiteID          string `bson:"_id" json:"site_id"`
+	Domain          string `bson:"domain" json:"domain"`
+	ViolationsCount int64  `bson:"violations_count" json:"violations_count"`
+	ContentsCount   int64  `bson:"contents_count" json:"contents_count"`
+}
+
+// MatchTypeStat — сколько нарушений найдено этапом матчера и их доля от всех
+type MatchTypeStat struct {
+	MatchType MatchType `json:"match_type"`
+	Count     int64     `json:"count"`
+	Percent   float64   `json:"percent"`
+}
+
+// MatchTypeHistogram — распределение нарушений всего каталога по этапам матчера
+type MatchTypeHistogram struct {
+	Total int64           `json:"total"`
+	Items []MatchTypeStat `json:"items"`
+}
+
+// UnmatchedPage - проиндексированная страница с внешними ID, не совпавшая ни с одним контентом
+type UnmatchedPage struct {
+	PageID        string    `bson:"page_id" json:"page_id"`
+	URL           string    `bson:"url" json:"url"`
+	Title         string    `bson:"title" json:"title"`
+	Year          int       `bson:"year,omitempty" json:"year,omitempty"`
+	KinopoiskID   string    `bson:"kinopoisk_id,omitempty" json:"kinopoisk_id,omitempty"`
+	IMDBID        string    `bson:"imdb_id,omitempty" json:"imdb_id,omitempty"`
+	MALID         string    `bson:"mal_id,omitempty" json:"mal_id,omitempty"`
+	ShikimoriID   string    `bson:"shikimori_id,omitempty" json:"shikimori_id,omitempty"`
+	MyDramaListID string    `bson:"mydramalist_id,omitempty" json:"mydramalist_id,omitempty"`
+	IndexedAt     time.Time `bson:"indexed_at" json:"indexed_at"`
 }
 
 type SiteStats struct {
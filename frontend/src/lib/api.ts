@@ -8,13 +8,21 @@ import type {
   PagesQueryParams,
   TasksQueryParams,
   CreateSiteRequest,
+  SiteImportJob,
+  RelatedSite,
+  AddViolationResponse,
+  VerifyViolationResponse,
+  VerifyAllViolationsJob,
   ScanSitesRequest,
   ScanSitesResponse,
   ScanStageResponse,
+  Content,
   ContentWithStats,
+  ContentPriority,
   ContentQueryParams,
   CreateContentRequest,
   Violation,
+  TimelineEntry,
   ViolationsQueryParams,
   SitemapURLsResponse,
   SitemapURLStats,
@@ -176,6 +184,11 @@ export const authApi = {
   me: () => request<User>('/auth/me'),
   logout: () =>
     request<void>('/auth/logout', { method: 'POST' }),
+  changePassword: (currentPassword: string, newPassword: string) =>
+    request<{ message: string }>('/auth/change-password', {
+      method: 'POST',
+      body: JSON.stringify({ current_password: currentPassword, new_password: newPassword }),
+    }),
 }
 
 export const usersApi = {
@@ -191,6 +204,7 @@ export const usersApi = {
 export const sitesApi = {
   list: (params: SitesQueryParams = {}): Promise<PaginatedResponse<Site>> => {
     const query = buildQueryString({
+      domain: params.domain,
       status: params.status,
       scanned_since: params.scanned_since,
       has_violations: params.has_violations,
@@ -218,6 +232,21 @@ export const sitesApi = {
     })
   },
 
+  importSites: (sites: CreateSiteRequest[]): Promise<SiteImportJob> => {
+    return request<SiteImportJob>('/sites/import', {
+      method: 'POST',
+      body: JSON.stringify({ sites }),
+    })
+  },
+
+  getImportJob: (jobId: string): Promise<SiteImportJob> => {
+    return request<SiteImportJob>(`/sites/import/${jobId}`)
+  },
+
+  related: (id: string): Promise<{ items: RelatedSite[]; total: number }> => {
+    return request<{ items: RelatedSite[]; total: number }>(`/sites/${id}/related`)
+  },
+
   scan: (data: ScanSitesRequest): Promise<ScanSitesResponse> => {
     return request<ScanSitesResponse>('/sites/scan', {
       method: 'POST',
@@ -225,19 +254,46 @@ export const sitesApi = {
     })
   },
 
-  delete: (id: string): Promise<{ message: string; pages_deleted: number; tasks_deleted: number }> => {
-    return request<{ message: string; pages_deleted: number; tasks_deleted: number }>(`/sites/${id}`, {
+  delete: (id: string): Promise<{ message: string; restore_until: string }> => {
+    return request<{ message: string; restore_until: string }>(`/sites/${id}`, {
       method: 'DELETE',
     })
   },
 
-  deleteBulk: (siteIds: string[]): Promise<{ deleted_count: number; pages_deleted: number; tasks_deleted: number }> => {
-    return request<{ deleted_count: number; pages_deleted: number; tasks_deleted: number }>('/sites/delete', {
+  deleteBulk: (siteIds: string[]): Promise<{ deleted_count: number; restore_until: string }> => {
+    return request<{ deleted_count: number; restore_until: string }>('/sites/delete', {
       method: 'POST',
       body: JSON.stringify({ site_ids: siteIds }),
     })
   },
 
+  restore: (id: string): Promise<Site> => {
+    return request<Site>(`/sites/${id}/restore`, {
+      method: 'POST',
+    })
+  },
+
+  update: (id: string, data: { scan_interval_h?: number }): Promise<Site> => {
+    return request<Site>(`/sites/${id}`, {
+      method: 'PATCH',
+      body: JSON.stringify(data),
+    })
+  },
+
+  updateDeltaCrawl: (id: string, enabled: boolean): Promise<Site> => {
+    return request<Site>(`/sites/${id}/delta-crawl`, {
+      method: 'PUT',
+      body: JSON.stringify({ enabled }),
+    })
+  },
+
+  updateScanIntervalBulk: (siteIds: string[], scanIntervalH: number): Promise<{ updated: number; skipped: number }> => {
+    return request<{ updated: number; skipped: number }>('/sites/scan-interval', {
+      method: 'POST',
+      body: JSON.stringify({ site_ids: siteIds, scan_interval_h: scanIntervalH }),
+    })
+  },
+
   analyze: (id: string): Promise<{ status: string; task_id: string }> => {
     return request<{ status: string; task_id: string }>(`/sites/${id}/analyze`, {
       method: 'POST',
@@ -266,6 +322,7 @@ export const pagesApi = {
       title: params.title,
       year: params.year,
       has_player: params.has_player,
+      has_external_id: params.has_external_id,
       has_violations: params.has_violations,
       sort_by: params.sort_by,
       sort_order: params.sort_order,
@@ -285,12 +342,17 @@ export const pagesApi = {
       site_id: params.site_id,
       year: params.year,
       has_player: params.has_player,
+      has_external_id: params.has_external_id,
       has_violations: params.has_violations,
       sort_by: params.sort_by,
       sort_order: params.sort_order,
     })
     return `${API_BASE}/pages/export${query}`
   },
+
+  snapshotUrl: (id: string): string => {
+    return `${API_BASE}/pages/${id}/snapshot`
+  },
 }
 
 export const tasksApi = {
@@ -366,6 +428,19 @@ export const contentApi = {
     })
   },
 
+  restore: (id: string): Promise<Content> => {
+    return request<Content>(`/content/${id}/restore`, {
+      method: 'POST',
+    })
+  },
+
+  updatePriority: (id: string, priority: ContentPriority): Promise<ContentWithStats> => {
+    return request<ContentWithStats>(`/content/${id}/priority`, {
+      method: 'PUT',
+      body: JSON.stringify({ priority }),
+    })
+  },
+
   violations: (id: string, params: ViolationsQueryParams = {}): Promise<PaginatedResponse<Violation>> => {
     const query = buildQueryString({
       limit: params.limit ?? 20,
@@ -374,6 +449,36 @@ export const contentApi = {
     return request<PaginatedResponse<Violation>>(`/content/${id}/violations${query}`)
   },
 
+  addViolation: (id: string, url: string, force = false): Promise<AddViolationResponse> => {
+    return request<AddViolationResponse>(`/content/${id}/violations`, {
+      method: 'POST',
+      body: JSON.stringify({ url, force }),
+    })
+  },
+
+  verifyViolation: (contentId: string, pageId: string): Promise<VerifyViolationResponse> => {
+    return request<VerifyViolationResponse>(`/violations/${pageId}/verify`, {
+      method: 'POST',
+      body: JSON.stringify({ content_id: contentId }),
+    })
+  },
+
+  verifyAllViolations: (id: string): Promise<VerifyAllViolationsJob> => {
+    return request<VerifyAllViolationsJob>(`/content/${id}/violations/verify-all`, { method: 'POST' })
+  },
+
+  verifyAllViolationsJob: (id: string, jobId: string): Promise<VerifyAllViolationsJob> => {
+    return request<VerifyAllViolationsJob>(`/content/${id}/violations/verify-all/${jobId}`)
+  },
+
+  previewMatches: (id: string): Promise<{ items: Violation[]; total: number; sites: number }> => {
+    return request<{ items: Violation[]; total: number; sites: number }>(`/content/${id}/matches/preview`)
+  },
+
+  timeline: (id: string): Promise<{ items: TimelineEntry[]; total: number }> => {
+    return request<{ items: TimelineEntry[]; total: number }>(`/content/${id}/timeline`)
+  },
+
   exportViolationsUrl: (id: string): string => {
     return `${API_BASE}/content/${id}/violations/export`
   },
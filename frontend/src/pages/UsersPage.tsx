@@ -1,7 +1,7 @@
 import { useState } from 'react'
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
 import { usersApi } from '@/lib/api'
-import type { User, CreateUserRequest } from '@/types'
+import type { User, CreateUserRequest, UserRole } from '@/types'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
@@ -33,10 +33,16 @@ function formatDate(dateString: string): string {
   return new Date(dateString).toLocaleString('ru-RU')
 }
 
-function RoleBadge({ role }: { role: 'admin' | 'user' }) {
+const roleLabels: Record<UserRole, string> = {
+  admin: 'Администратор',
+  user: 'Пользователь',
+  viewer: 'Только чтение',
+}
+
+function RoleBadge({ role }: { role: UserRole }) {
   return (
-    <Badge variant={role === 'admin' ? 'default' : 'secondary'}>
-      {role === 'admin' ? 'Администратор' : 'Пользователь'}
+    <Badge variant={role === 'admin' ? 'default' : role === 'viewer' ? 'outline' : 'secondary'}>
+      {roleLabels[role]}
     </Badge>
   )
 }
@@ -217,7 +223,7 @@ export function UsersPage() {
                 <Label htmlFor="role">Роль</Label>
                 <Select
                   value={newUser.role}
-                  onValueChange={(value: 'admin' | 'user') =>
+                  onValueChange={(value: UserRole) =>
                     setNewUser({ ...newUser, role: value })
                   }
                 >
@@ -226,6 +232,7 @@ export function UsersPage() {
                   </SelectTrigger>
                   <SelectContent>
                     <SelectItem value="user">Пользователь</SelectItem>
+                    <SelectItem value="viewer">Только чтение</SelectItem>
                     <SelectItem value="admin">Администратор</SelectItem>
                   </SelectContent>
                 </Select>
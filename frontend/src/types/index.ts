@@ -25,6 +25,7 @@ export interface Site {
   next_scan_at?: string
   failure_count: number
   scan_interval_h: number
+  delta_crawl?: boolean
   scanner_type?: ScannerType
   captcha_type?: CaptchaType
   freeze_reason?: string
@@ -47,6 +48,30 @@ export interface CreateSiteRequest {
   scan_interval_h?: number
 }
 
+export interface SiteImportJob {
+  job_id: string
+  status: 'running' | 'completed'
+  total: number
+  processed: number
+  created: number
+  linked: number
+  failed: number
+  detect_queued: number
+  started_at: string
+  finished_at?: string
+}
+
+export type RelatedReason = 'same_label' | 'contains_label' | 'similar_label' | 'shared_cms_content'
+
+export interface RelatedSite {
+  site_id: string
+  domain: string
+  status: SiteStatus
+  cms?: string
+  reasons: RelatedReason[]
+  shared_content?: number
+}
+
 export interface ScanSitesRequest {
   site_ids: string[]
   force?: boolean
@@ -79,6 +104,7 @@ export interface Page {
   player_url?: string
   http_status: number
   indexed_at: string
+  snapshot_at?: string
 }
 
 export type TaskStatus = 'pending' | 'processing' | 'completed' | 'failed' | 'cancelled'
@@ -116,12 +142,17 @@ export interface PageStats {
 export interface PaginatedResponse<T> {
   items: T[]
   total: number
+  limit?: number
+  offset?: number
+  has_more?: boolean
+  next_cursor?: string
 }
 
 export type ScannedSinceFilter = 'today' | 'week' | 'month'
 export type HasViolationsFilter = 'true' | 'false'
 
 export interface SitesQueryParams {
+  domain?: string
   status?: SiteStatus
   scanned_since?: ScannedSinceFilter
   has_violations?: HasViolationsFilter
@@ -136,6 +167,7 @@ export interface PagesQueryParams {
   title?: string
   year?: number
   has_player?: boolean
+  has_external_id?: boolean
   has_violations?: boolean
   sort_by?: 'indexed_at' | 'year'
   sort_order?: 'asc' | 'desc'
@@ -161,6 +193,10 @@ export interface Content {
   mal_id?: string
   shikimori_id?: string
   mydramalist_id?: string
+  language?: string
+  region?: string
+  priority?: ContentPriority
+  next_refresh_at?: string
   created_at: string
 }
 
@@ -178,8 +214,13 @@ export interface CreateContentRequest {
   mal_id?: string
   shikimori_id?: string
   mydramalist_id?: string
+  language?: string
+  region?: string
+  priority?: ContentPriority
 }
 
+export type ContentPriority = 'high' | 'normal' | 'low'
+
 export type ContentSortBy = 'violations_count' | 'created_at'
 export type ContentHasViolations = 'true' | 'false'
 
@@ -204,9 +245,71 @@ export interface Violation {
   url: string
   title: string
   match_type: string
+  evidence?: string
+  match_query?: string
+  match_filter?: string
   found_at: string
 }
 
+export interface MatchStageVerdict {
+  stage: string
+  verdict: string
+  title?: string
+  normalized_title?: string
+  normalized_page_title?: string
+  short_phrase?: boolean
+  detail?: string
+}
+
+export interface AddViolationResponse {
+  violation: Violation
+  created: boolean
+  matched: boolean
+  stages: MatchStageVerdict[]
+}
+
+export interface VerifyAllViolationsSummary {
+  total: number
+  present: number
+  removed: number
+  errored: number
+  errors?: { page_id: string; error: string }[]
+}
+
+export interface VerifyAllViolationsJob {
+  job_id: string
+  content_id: string
+  status: 'running' | 'completed' | 'failed'
+  total: number
+  checked: number
+  summary?: VerifyAllViolationsSummary
+  error?: string
+  started_at: string
+  finished_at?: string
+}
+
+export interface VerifyViolationResponse {
+  page_id: string
+  content_id: string
+  status: 'present' | 'removed'
+  title?: string
+  reason?: string
+  stages?: MatchStageVerdict[]
+  resolved: boolean
+}
+
+export interface TimelineEntry {
+  page_id: string
+  site_id: string
+  domain: string
+  url: string
+  title: string
+  match_type: string
+  first_seen_at: string
+  last_seen_at: string
+  removed_at?: string
+}
+
 export interface ViolationsQueryParams {
   limit?: number
   offset?: number
@@ -244,10 +347,12 @@ export interface SitemapURLStats {
   total: number
 }
 
+export type UserRole = 'admin' | 'user' | 'viewer'
+
 export interface User {
   id: string
   login: string
-  role: 'admin' | 'user'
+  role: UserRole
   is_active: boolean
   created_at: string
 }
@@ -266,7 +371,7 @@ export interface UsersListResponse {
 export interface CreateUserRequest {
   login: string
   password: string
-  role: 'admin' | 'user'
+  role: UserRole
 }
 
 export interface UpdateUserRequest {